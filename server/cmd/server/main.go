@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/anath2/language-app/internal/config"
 	httprouter "github.com/anath2/language-app/internal/http"
+	"github.com/anath2/language-app/internal/logging"
 	"github.com/joho/godotenv"
 )
 
@@ -16,12 +20,19 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
+	logging.SetFormat(cfg.LogFormat)
 
 	addr := cfg.Addr
 	if envPort := os.Getenv("PORT"); envPort != "" {
 		addr = ":" + envPort
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	log.Printf("server listening on %s", addr)
-	log.Fatal(httprouter.ListenAndServe(addr, cfg))
+	if err := httprouter.ListenAndServe(ctx, addr, cfg); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("server stopped")
 }
@@ -5,25 +5,191 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/anath2/language-app/internal/textsplit"
+	"github.com/anath2/language-app/internal/translation"
+)
+
+const (
+	defaultSessionMaxAgeHours     = 168
+	defaultDBMaxOpenConns         = 1
+	defaultDBBusyTimeoutMs        = 3000
+	defaultBackupInterval         = 24 * time.Hour
+	defaultBackupRetention        = 7
+	defaultCedictFastPathCoverage = 0.95
+	defaultCedictGlossSenses      = 3
+	defaultJobScanInterval        = 30 * time.Second
+	defaultShutdownTimeout        = 30 * time.Second
+	defaultSegmentationTimeout    = 60 * time.Second
+	defaultPerSegmentTimeout      = 60 * time.Second
+	defaultFullTranslationTimeout = 10 * time.Minute
+)
+
+// defaultCORSAllowedMethods are the methods cross-origin callers may use
+// unless CORS_ALLOWED_METHODS says otherwise.
+var defaultCORSAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// Translation providers selectable with TRANSLATION_PROVIDER.
+const (
+	TranslationProviderOpenAI    = "openai"
+	TranslationProviderAnthropic = "anthropic"
+)
+
+// Segment definition sources selectable with DEFINITION_PRIORITY. The
+// *_first values take the other source when the preferred one has nothing;
+// the *_only values never do.
+const (
+	DefinitionPriorityCedictFirst = "cedict_first"
+	DefinitionPriorityLLMFirst    = "llm_first"
+	DefinitionPriorityCedictOnly  = "cedict_only"
+	DefinitionPriorityLLMOnly     = "llm_only"
+)
+
+// Rate limit defaults for the LLM-backed endpoints, per session.
+const (
+	DefaultRateLimitPerMinute = 30
+	DefaultRateLimitBurst     = 10
 )
 
-const defaultSessionMaxAgeHours = 168
+// Chat context caps, applied when the matching setting is left unset.
+const (
+	DefaultChatMaxArticleRunes = 6000
+	DefaultChatHistoryTurns    = 10
+	DefaultChatMaxPromptTokens = 24000
+)
+
+// DefaultSSEPollInterval is how often a translation stream checks for
+// progress it was not notified of, such as from a job another process runs.
+const DefaultSSEPollInterval = time.Second
+
+// DefaultReadingCharsPerMinute is an intermediate learner's reading speed,
+// well below a native reader's.
+const DefaultReadingCharsPerMinute = 250
+
+// DefaultMaxInputChars is the longest input, in characters, accepted as a
+// single translation.
+const DefaultMaxInputChars = 20000
 
 type Config struct {
-	Addr                   string
-	AppPassword            string
-	AppSecretKey           string
-	SessionMaxAgeSeconds   int
-	SecureCookies          bool
-	MigrationsDir          string
-	TranslationDBPath      string
+	Addr                 string
+	AppPassword          string
+	AppSecretKey         string
+	SessionMaxAgeSeconds int
+	// SessionIdleTimeout expires sessions not used for this long; zero
+	// disables it, leaving only the SessionMaxAgeSeconds cap.
+	SessionIdleTimeout time.Duration
+	SecureCookies      bool
+	MigrationsDir      string
+	TranslationDBPath  string
+	// DBMaxOpenConns and DBMaxIdleConns size the SQLite connection pool;
+	// DBBusyTimeoutMs is how long a connection waits for a lock.
+	DBMaxOpenConns         int
+	DBMaxIdleConns         int
+	DBBusyTimeoutMs        int
 	OpenAIAPIKey           string
 	OpenAITranslationModel string
 	OpenAIChatModel        string
 	OpenAIBaseURL          string
 	OpenAIDebugLog         bool
+	// ChatMaxArticleRunes caps the article sent with each chat turn, keeping
+	// the part around the selected text; ChatHistoryTurns is how many recent
+	// messages are sent verbatim, older ones being summarized; chat turns
+	// whose estimated prompt exceeds ChatMaxPromptTokens are refused.
+	ChatMaxArticleRunes int
+	ChatHistoryTurns    int
+	ChatMaxPromptTokens int
+	// OfflineMode segments and translates from CEDICT alone, without any
+	// LLM calls.
+	OfflineMode bool
+	// SegmentationRepair realigns model segmentations that do not rebuild
+	// their input so they concatenate to it exactly.
+	SegmentationRepair bool
+	// TranslationProvider picks the API used for segmentation and
+	// translation; chat always uses the OpenAI-compatible endpoint.
+	TranslationProvider       string
+	AnthropicAPIKey           string
+	AnthropicTranslationModel string
+	AnthropicBaseURL          string
+	// SpeechToTextModel enables pronunciation scoring through an
+	// OpenAI-compatible /audio/transcriptions endpoint.
+	SpeechToTextModel      string
+	SpeechToTextBaseURL    string
+	SpeechToTextAPIKey     string
+	SRSStrugglingThreshold int
+	SRSStrugglingWindow    time.Duration
+	SRSOpacityDecay        string
+	SRSOpacityHorizon      time.Duration
+	SRSStrugglingFloor     float64
+	SentenceDelimiters     map[string]string
+	PinyinStyle            string
+	CedictPath             string
+	// CedictFastPathCoverage is the share of characters CEDICT must know for
+	// its segmentation to be used instead of the LLM's; zero disables it.
+	CedictFastPathCoverage float64
+	CedictGlossSenses      int
+	FrequencyListPath      string
+	HSKWordlistPath        string
+	LogFormat              string
+	RateLimitPerMinute     int
+	RateLimitBurst         int
+	// AllowedOrigins lists the origins allowed to call /api/* cross-origin;
+	// empty keeps the API same-origin only.
+	AllowedOrigins       []string
+	CORSAllowedMethods   []string
+	CORSAllowCredentials bool
+	// BackupDir holds database snapshots; empty means a backups directory
+	// next to the database file.
+	BackupDir string
+	// BackupInterval is how often a snapshot is taken; zero disables
+	// periodic backups, leaving only on-demand ones.
+	BackupInterval  time.Duration
+	BackupRetention int
+	// JobScanInterval is how often the queue looks for translation jobs
+	// whose lease expired and resumes them.
+	JobScanInterval time.Duration
+	// TranslationSegmentDelay spaces a job's segment translation calls to
+	// the provider; zero sends them back to back.
+	TranslationSegmentDelay time.Duration
+	// TranslationWorkers caps concurrent translation jobs; zero means no cap.
+	TranslationWorkers int
+	// ShutdownTimeout bounds how long a shutdown waits for in-flight
+	// requests and translation jobs before releasing their leases.
+	ShutdownTimeout time.Duration
+	// SegmentationTimeout, SegmentTranslationTimeout and
+	// FullTranslationTimeout bound each model call of that kind.
+	// Segmentation and per-segment calls are short and should fail fast;
+	// a long document's full translation can take minutes.
+	SegmentationTimeout       time.Duration
+	SegmentTranslationTimeout time.Duration
+	FullTranslationTimeout    time.Duration
+	// SSEPollInterval is the translation stream's fallback progress check;
+	// jobs run by this process wake the stream as soon as they progress.
+	SSEPollInterval time.Duration
+	// ReadingCharsPerMinute turns a translation's character count into the
+	// reading-time estimate shown in the history list.
+	ReadingCharsPerMinute int
+	// StudyLocation is the timezone whose calendar days count toward study
+	// streaks.
+	StudyLocation *time.Location
+	// MaxInputChars is the longest input accepted as one translation;
+	// longer input must be split by the caller or created in chunks.
+	MaxInputChars int
+	// DisableAuth lets /api requests without a session through as the
+	// instance owner. It is for local development and is refused alongside
+	// SecureCookies.
+	DisableAuth bool
+	// DefinitionPriority is one of the DefinitionPriority* values and
+	// decides whether CEDICT or the model supplies a segment's pinyin and
+	// gloss.
+	DefinitionPriority string
+	// AllowPrivateNetworks lets pages fetched for translation and callback
+	// URLs live on loopback, private or link-local addresses, which are
+	// refused by default.
+	AllowPrivateNetworks bool
 }
 
 func Load() (Config, error) {
@@ -36,16 +202,32 @@ func Load() (Config, error) {
 	if appSecretKey == "" {
 		return Config{}, fmt.Errorf("APP_SECRET_KEY environment variable is required")
 	}
+	// Offline mode makes no upstream calls, so no LLM settings are required.
+	offlineMode := strings.EqualFold(os.Getenv("OFFLINE_MODE"), "true")
 	openAIAPIKey := envFirstOrDefault([]string{"OPENAI_API_KEY", "OPENROUTER_API_KEY"}, "")
-	if openAIAPIKey == "" {
+	if openAIAPIKey == "" && !offlineMode {
 		return Config{}, fmt.Errorf("OPENAI_API_KEY environment variable is required (or legacy OPENROUTER_API_KEY)")
 	}
+	translationProvider := strings.ToLower(envOrDefault("TRANSLATION_PROVIDER", TranslationProviderOpenAI))
+	if translationProvider != TranslationProviderOpenAI && translationProvider != TranslationProviderAnthropic {
+		return Config{}, fmt.Errorf("invalid TRANSLATION_PROVIDER: must be openai or anthropic")
+	}
 	openAITranslationModel := envFirstOrDefault([]string{"OPENAI_TRANSLATION_MODEL", "OPENROUTER_TRANSLATION_MODEL"}, "")
-	if openAITranslationModel == "" {
+	if openAITranslationModel == "" && translationProvider == TranslationProviderOpenAI && !offlineMode {
 		return Config{}, fmt.Errorf("OPENAI_TRANSLATION_MODEL environment variable is required (or legacy OPENROUTER_TRANSLATION_MODEL)")
 	}
+	anthropicAPIKey := strings.TrimSpace(os.Getenv("ANTHROPIC_API_KEY"))
+	anthropicTranslationModel := strings.TrimSpace(os.Getenv("ANTHROPIC_TRANSLATION_MODEL"))
+	if translationProvider == TranslationProviderAnthropic && !offlineMode {
+		if anthropicAPIKey == "" {
+			return Config{}, fmt.Errorf("ANTHROPIC_API_KEY environment variable is required when TRANSLATION_PROVIDER=anthropic")
+		}
+		if anthropicTranslationModel == "" {
+			return Config{}, fmt.Errorf("ANTHROPIC_TRANSLATION_MODEL environment variable is required when TRANSLATION_PROVIDER=anthropic")
+		}
+	}
 	openAIChatModel := envFirstOrDefault([]string{"OPENAI_CHAT_MODEL", "OPENROUTER_CHAT_MODEL"}, "")
-	if openAIChatModel == "" {
+	if openAIChatModel == "" && !offlineMode {
 		return Config{}, fmt.Errorf("OPENAI_CHAT_MODEL environment variable is required (or legacy OPENROUTER_CHAT_MODEL)")
 	}
 
@@ -55,6 +237,18 @@ func Load() (Config, error) {
 	if err != nil {
 		return Config{}, fmt.Errorf("invalid OPENAI_BASE_URL: %w", err)
 	}
+	// Speech-to-text is optional: pronunciation scoring is disabled without a
+	// model, and the endpoint and key default to the OpenAI ones.
+	speechToTextModel := strings.TrimSpace(os.Getenv("SPEECH_TO_TEXT_MODEL"))
+	speechToTextBaseURL, err := normalizeAndValidateOpenAIBaseURL(envOrDefault("SPEECH_TO_TEXT_BASE_URL", openAIBaseURL))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SPEECH_TO_TEXT_BASE_URL: %w", err)
+	}
+	speechToTextAPIKey := envFirstOrDefault([]string{"SPEECH_TO_TEXT_API_KEY"}, openAIAPIKey)
+	anthropicBaseURL, err := normalizeAndValidateAnthropicBaseURL(envOrDefault("ANTHROPIC_BASE_URL", "https://api.anthropic.com"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ANTHROPIC_BASE_URL: %w", err)
+	}
 
 	sessionHours := defaultSessionMaxAgeHours
 	if raw := os.Getenv("SESSION_MAX_AGE_HOURS"); raw != "" {
@@ -65,6 +259,365 @@ func Load() (Config, error) {
 		sessionHours = parsed
 	}
 
+	var sessionIdleTimeout time.Duration
+	if raw := os.Getenv("SESSION_IDLE_TIMEOUT"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SESSION_IDLE_TIMEOUT: %w", err)
+		}
+		// Last-seen times are recorded once a minute, so finer timeouts
+		// would expire active sessions.
+		if parsed != 0 && parsed < time.Minute {
+			return Config{}, fmt.Errorf("invalid SESSION_IDLE_TIMEOUT: must be at least 1m")
+		}
+		sessionIdleTimeout = parsed
+	}
+
+	// The SRS defaults live with the store that applies them.
+	srsDefaults := translation.DefaultSRSConfig()
+
+	strugglingThreshold := srsDefaults.StrugglingThreshold
+	if raw := os.Getenv("SRS_STRUGGLING_THRESHOLD"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SRS_STRUGGLING_THRESHOLD: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid SRS_STRUGGLING_THRESHOLD: must be at least 1")
+		}
+		strugglingThreshold = parsed
+	}
+
+	strugglingWindow := srsDefaults.StrugglingWindow
+	if raw := os.Getenv("SRS_STRUGGLING_WINDOW"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SRS_STRUGGLING_WINDOW: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, fmt.Errorf("invalid SRS_STRUGGLING_WINDOW: must be a positive duration")
+		}
+		strugglingWindow = parsed
+	}
+
+	opacityDecay := strings.ToLower(envOrDefault("SRS_OPACITY_DECAY", srsDefaults.OpacityDecay))
+	if opacityDecay != "linear" && opacityDecay != "exponential" {
+		return Config{}, fmt.Errorf("invalid SRS_OPACITY_DECAY: must be linear or exponential")
+	}
+
+	opacityHorizon := srsDefaults.OpacityHorizon
+	if raw := os.Getenv("SRS_OPACITY_HORIZON"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SRS_OPACITY_HORIZON: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, fmt.Errorf("invalid SRS_OPACITY_HORIZON: must be a positive duration")
+		}
+		opacityHorizon = parsed
+	}
+
+	strugglingFloor := srsDefaults.StrugglingFloor
+	if raw := os.Getenv("SRS_STRUGGLING_FLOOR"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SRS_STRUGGLING_FLOOR: %w", err)
+		}
+		if parsed < 0 || parsed > 1 {
+			return Config{}, fmt.Errorf("invalid SRS_STRUGGLING_FLOOR: must be between 0 and 1")
+		}
+		strugglingFloor = parsed
+	}
+
+	sentenceDelimiters, err := parseSentenceDelimiters(os.Getenv("SENTENCE_DELIMITERS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SENTENCE_DELIMITERS: %w", err)
+	}
+
+	pinyinStyle := strings.ToLower(envOrDefault("PINYIN_STYLE", "tone_marks"))
+	if pinyinStyle != "tone_marks" && pinyinStyle != "numbered" {
+		return Config{}, fmt.Errorf("invalid PINYIN_STYLE: must be tone_marks or numbered")
+	}
+
+	studyLocation, err := time.LoadLocation(envOrDefault("STUDY_TIMEZONE", "UTC"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid STUDY_TIMEZONE: %w", err)
+	}
+
+	cedictFastPathCoverage := defaultCedictFastPathCoverage
+	if raw := os.Getenv("CEDICT_FAST_PATH_COVERAGE"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CEDICT_FAST_PATH_COVERAGE: %w", err)
+		}
+		if parsed < 0 || parsed > 1 {
+			return Config{}, fmt.Errorf("invalid CEDICT_FAST_PATH_COVERAGE: must be between 0 and 1")
+		}
+		cedictFastPathCoverage = parsed
+	}
+
+	definitionPriority := strings.ToLower(envOrDefault("DEFINITION_PRIORITY", DefinitionPriorityCedictFirst))
+	switch definitionPriority {
+	case DefinitionPriorityCedictFirst, DefinitionPriorityLLMFirst, DefinitionPriorityCedictOnly, DefinitionPriorityLLMOnly:
+	default:
+		return Config{}, fmt.Errorf("invalid DEFINITION_PRIORITY: must be cedict_first, llm_first, cedict_only or llm_only")
+	}
+
+	cedictGlossSenses := defaultCedictGlossSenses
+	if raw := os.Getenv("CEDICT_GLOSS_SENSES"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CEDICT_GLOSS_SENSES: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid CEDICT_GLOSS_SENSES: must be at least 1")
+		}
+		cedictGlossSenses = parsed
+	}
+
+	rateLimitPerMinute := DefaultRateLimitPerMinute
+	if raw := os.Getenv("RATE_LIMIT_PER_MINUTE"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid RATE_LIMIT_PER_MINUTE: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid RATE_LIMIT_PER_MINUTE: must be at least 1")
+		}
+		rateLimitPerMinute = parsed
+	}
+
+	rateLimitBurst := DefaultRateLimitBurst
+	if raw := os.Getenv("RATE_LIMIT_BURST"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid RATE_LIMIT_BURST: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid RATE_LIMIT_BURST: must be at least 1")
+		}
+		rateLimitBurst = parsed
+	}
+
+	chatMaxArticleRunes := DefaultChatMaxArticleRunes
+	if raw := os.Getenv("CHAT_MAX_ARTICLE_RUNES"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CHAT_MAX_ARTICLE_RUNES: %w", err)
+		}
+		if parsed < 100 {
+			return Config{}, fmt.Errorf("invalid CHAT_MAX_ARTICLE_RUNES: must be at least 100")
+		}
+		chatMaxArticleRunes = parsed
+	}
+
+	chatHistoryTurns := DefaultChatHistoryTurns
+	if raw := os.Getenv("CHAT_HISTORY_TURNS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CHAT_HISTORY_TURNS: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid CHAT_HISTORY_TURNS: must be at least 1")
+		}
+		chatHistoryTurns = parsed
+	}
+
+	chatMaxPromptTokens := DefaultChatMaxPromptTokens
+	if raw := os.Getenv("CHAT_MAX_PROMPT_TOKENS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CHAT_MAX_PROMPT_TOKENS: %w", err)
+		}
+		if parsed < 1000 {
+			return Config{}, fmt.Errorf("invalid CHAT_MAX_PROMPT_TOKENS: must be at least 1000")
+		}
+		chatMaxPromptTokens = parsed
+	}
+
+	dbMaxOpenConns := defaultDBMaxOpenConns
+	if raw := os.Getenv("DB_MAX_OPEN_CONNS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: must be at least 1")
+		}
+		dbMaxOpenConns = parsed
+	}
+
+	// Idle connections default to the pool size so connections are reused
+	// rather than reopened.
+	dbMaxIdleConns := dbMaxOpenConns
+	if raw := os.Getenv("DB_MAX_IDLE_CONNS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid DB_MAX_IDLE_CONNS: %w", err)
+		}
+		if parsed < 1 || parsed > dbMaxOpenConns {
+			return Config{}, fmt.Errorf("invalid DB_MAX_IDLE_CONNS: must be between 1 and DB_MAX_OPEN_CONNS")
+		}
+		dbMaxIdleConns = parsed
+	}
+
+	dbBusyTimeoutMs := defaultDBBusyTimeoutMs
+	if raw := os.Getenv("DB_BUSY_TIMEOUT_MS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid DB_BUSY_TIMEOUT_MS: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid DB_BUSY_TIMEOUT_MS: must be at least 1")
+		}
+		dbBusyTimeoutMs = parsed
+	}
+
+	backupInterval := defaultBackupInterval
+	if raw := os.Getenv("BACKUP_INTERVAL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid BACKUP_INTERVAL: %w", err)
+		}
+		if parsed != 0 && parsed < time.Minute {
+			return Config{}, fmt.Errorf("invalid BACKUP_INTERVAL: must be at least 1m")
+		}
+		backupInterval = parsed
+	}
+
+	backupRetention := defaultBackupRetention
+	if raw := os.Getenv("BACKUP_RETENTION"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid BACKUP_RETENTION: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid BACKUP_RETENTION: must be at least 1")
+		}
+		backupRetention = parsed
+	}
+
+	jobScanInterval := defaultJobScanInterval
+	if raw := os.Getenv("JOB_SCAN_INTERVAL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid JOB_SCAN_INTERVAL: %w", err)
+		}
+		if parsed < time.Second {
+			return Config{}, fmt.Errorf("invalid JOB_SCAN_INTERVAL: must be at least 1s")
+		}
+		jobScanInterval = parsed
+	}
+
+	segmentationTimeout, err := parseCallTimeout("LLM_SEGMENTATION_TIMEOUT", defaultSegmentationTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	segmentTranslationTimeout, err := parseCallTimeout("LLM_SEGMENT_TRANSLATION_TIMEOUT", defaultPerSegmentTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	fullTranslationTimeout, err := parseCallTimeout("LLM_FULL_TRANSLATION_TIMEOUT", defaultFullTranslationTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var translationSegmentDelay time.Duration
+	if raw := os.Getenv("TRANSLATION_SEGMENT_DELAY"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid TRANSLATION_SEGMENT_DELAY: %w", err)
+		}
+		if parsed < 0 {
+			return Config{}, fmt.Errorf("invalid TRANSLATION_SEGMENT_DELAY: must not be negative")
+		}
+		translationSegmentDelay = parsed
+	}
+
+	translationWorkers := 0
+	if raw := os.Getenv("TRANSLATION_WORKERS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid TRANSLATION_WORKERS: %w", err)
+		}
+		if parsed < 0 {
+			return Config{}, fmt.Errorf("invalid TRANSLATION_WORKERS: must not be negative")
+		}
+		translationWorkers = parsed
+	}
+
+	ssePollInterval := DefaultSSEPollInterval
+	if raw := os.Getenv("SSE_POLL_INTERVAL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SSE_POLL_INTERVAL: %w", err)
+		}
+		if parsed < 10*time.Millisecond {
+			return Config{}, fmt.Errorf("invalid SSE_POLL_INTERVAL: must be at least 10ms")
+		}
+		ssePollInterval = parsed
+	}
+
+	readingCharsPerMinute := DefaultReadingCharsPerMinute
+	if raw := os.Getenv("READING_CHARS_PER_MINUTE"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid READING_CHARS_PER_MINUTE: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid READING_CHARS_PER_MINUTE: must be at least 1")
+		}
+		readingCharsPerMinute = parsed
+	}
+
+	maxInputChars := DefaultMaxInputChars
+	if raw := os.Getenv("MAX_INPUT_CHARS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid MAX_INPUT_CHARS: %w", err)
+		}
+		if parsed < 1 {
+			return Config{}, fmt.Errorf("invalid MAX_INPUT_CHARS: must be at least 1")
+		}
+		maxInputChars = parsed
+	}
+
+	shutdownTimeout := defaultShutdownTimeout
+	if raw := os.Getenv("SHUTDOWN_TIMEOUT"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: %w", err)
+		}
+		if parsed < time.Second {
+			return Config{}, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: must be at least 1s")
+		}
+		shutdownTimeout = parsed
+	}
+
+	// CORS is off by default, keeping the API same-origin only.
+	allowedOrigins, err := parseAllowedOrigins(os.Getenv("ALLOWED_ORIGINS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ALLOWED_ORIGINS: %w", err)
+	}
+	corsAllowedMethods := defaultCORSAllowedMethods
+	if raw := strings.TrimSpace(os.Getenv("CORS_ALLOWED_METHODS")); raw != "" {
+		corsAllowedMethods = nil
+		for _, method := range strings.Split(raw, ",") {
+			if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+				corsAllowedMethods = append(corsAllowedMethods, method)
+			}
+		}
+	}
+	corsAllowCredentials := !strings.EqualFold(os.Getenv("CORS_ALLOW_CREDENTIALS"), "false")
+	if corsAllowCredentials && slices.Contains(allowedOrigins, "*") {
+		return Config{}, fmt.Errorf("invalid ALLOWED_ORIGINS: * requires CORS_ALLOW_CREDENTIALS=false")
+	}
+
+	logFormat := strings.ToLower(envOrDefault("LOG_FORMAT", "text"))
+	if logFormat != "text" && logFormat != "json" {
+		return Config{}, fmt.Errorf("invalid LOG_FORMAT: must be text or json")
+	}
+
 	addr := os.Getenv("APP_ADDR")
 	if addr == "" {
 		addr = ":8080"
@@ -75,24 +628,81 @@ func Load() (Config, error) {
 		return Config{}, err
 	}
 
+	dbPath := envOrDefault("LANGUAGE_APP_DB_PATH", filepath.Join(repoRoot, "server", "data", "language_app.db"))
+
 	secureCookies := strings.EqualFold(os.Getenv("SECURE_COOKIES"), "true")
 	if os.Getenv("SECURE_COOKIES") == "" {
 		secureCookies = true
 	}
+	disableAuth := strings.EqualFold(os.Getenv("DISABLE_AUTH"), "true")
+	if disableAuth && secureCookies {
+		return Config{}, fmt.Errorf("DISABLE_AUTH=true is for local development and requires SECURE_COOKIES=false")
+	}
 
 	return Config{
-		Addr:                   addr,
-		AppPassword:            appPassword,
-		AppSecretKey:           appSecretKey,
-		SessionMaxAgeSeconds:   sessionHours * 3600,
-		SecureCookies:          secureCookies,
-		MigrationsDir:          envOrDefault("LANGUAGE_APP_MIGRATIONS_DIR", filepath.Join(repoRoot, "server", "migrations")),
-		TranslationDBPath:      envOrDefault("LANGUAGE_APP_DB_PATH", filepath.Join(repoRoot, "server", "data", "language_app.db")),
-		OpenAIAPIKey:           openAIAPIKey,
-		OpenAITranslationModel: openAITranslationModel,
-		OpenAIChatModel:        openAIChatModel,
-		OpenAIBaseURL:          openAIBaseURL,
-		OpenAIDebugLog:         strings.EqualFold(envFirstOrDefault([]string{"OPENAI_DEBUG_LOG", "OPENROUTER_DEBUG_LOG"}, ""), "true"),
+		Addr:                      addr,
+		AppPassword:               appPassword,
+		AppSecretKey:              appSecretKey,
+		SessionMaxAgeSeconds:      sessionHours * 3600,
+		SessionIdleTimeout:        sessionIdleTimeout,
+		SecureCookies:             secureCookies,
+		MigrationsDir:             envOrDefault("LANGUAGE_APP_MIGRATIONS_DIR", filepath.Join(repoRoot, "server", "migrations")),
+		TranslationDBPath:         dbPath,
+		DBMaxOpenConns:            dbMaxOpenConns,
+		DBMaxIdleConns:            dbMaxIdleConns,
+		DBBusyTimeoutMs:           dbBusyTimeoutMs,
+		OpenAIAPIKey:              openAIAPIKey,
+		OpenAITranslationModel:    openAITranslationModel,
+		OpenAIChatModel:           openAIChatModel,
+		OpenAIBaseURL:             openAIBaseURL,
+		OpenAIDebugLog:            strings.EqualFold(envFirstOrDefault([]string{"OPENAI_DEBUG_LOG", "OPENROUTER_DEBUG_LOG"}, ""), "true"),
+		ChatMaxArticleRunes:       chatMaxArticleRunes,
+		ChatHistoryTurns:          chatHistoryTurns,
+		ChatMaxPromptTokens:       chatMaxPromptTokens,
+		OfflineMode:               offlineMode,
+		SegmentationRepair:        !strings.EqualFold(os.Getenv("SEGMENTATION_REPAIR"), "false"),
+		TranslationProvider:       translationProvider,
+		AnthropicAPIKey:           anthropicAPIKey,
+		AnthropicTranslationModel: anthropicTranslationModel,
+		AnthropicBaseURL:          anthropicBaseURL,
+		SpeechToTextModel:         speechToTextModel,
+		SpeechToTextBaseURL:       speechToTextBaseURL,
+		SpeechToTextAPIKey:        speechToTextAPIKey,
+		SRSStrugglingThreshold:    strugglingThreshold,
+		SRSStrugglingWindow:       strugglingWindow,
+		SRSOpacityDecay:           opacityDecay,
+		SRSOpacityHorizon:         opacityHorizon,
+		SRSStrugglingFloor:        strugglingFloor,
+		SentenceDelimiters:        sentenceDelimiters,
+		PinyinStyle:               pinyinStyle,
+		StudyLocation:             studyLocation,
+		CedictPath:                envOrDefault("CEDICT_PATH", filepath.Join(repoRoot, "server", "data", "cedict_ts.u8")),
+		CedictFastPathCoverage:    cedictFastPathCoverage,
+		CedictGlossSenses:         cedictGlossSenses,
+		FrequencyListPath:         os.Getenv("FREQUENCY_LIST_PATH"),
+		HSKWordlistPath:           os.Getenv("HSK_WORDLIST_PATH"),
+		LogFormat:                 logFormat,
+		RateLimitPerMinute:        rateLimitPerMinute,
+		RateLimitBurst:            rateLimitBurst,
+		AllowedOrigins:            allowedOrigins,
+		CORSAllowedMethods:        corsAllowedMethods,
+		CORSAllowCredentials:      corsAllowCredentials,
+		BackupDir:                 envOrDefault("BACKUP_DIR", filepath.Join(filepath.Dir(dbPath), "backups")),
+		BackupInterval:            backupInterval,
+		BackupRetention:           backupRetention,
+		JobScanInterval:           jobScanInterval,
+		TranslationSegmentDelay:   translationSegmentDelay,
+		TranslationWorkers:        translationWorkers,
+		ShutdownTimeout:           shutdownTimeout,
+		SegmentationTimeout:       segmentationTimeout,
+		SegmentTranslationTimeout: segmentTranslationTimeout,
+		FullTranslationTimeout:    fullTranslationTimeout,
+		SSEPollInterval:           ssePollInterval,
+		ReadingCharsPerMinute:     readingCharsPerMinute,
+		MaxInputChars:             maxInputChars,
+		DisableAuth:               disableAuth,
+		DefinitionPriority:        definitionPriority,
+		AllowPrivateNetworks:      strings.EqualFold(os.Getenv("ALLOW_PRIVATE_NETWORKS"), "true"),
 	}, nil
 }
 
@@ -127,6 +737,46 @@ func envFirstOrDefault(keys []string, fallback string) string {
 	return fallback
 }
 
+// parseSentenceDelimiters reads space-separated lang=delimiters entries,
+// such as "zh=。！？ ja=。", into a map of delimiter sets by language.
+func parseSentenceDelimiters(raw string) (map[string]string, error) {
+	var out map[string]string
+	for _, entry := range strings.Fields(raw) {
+		lang, set, ok := strings.Cut(entry, "=")
+		if !ok || set == "" {
+			return nil, fmt.Errorf("%q must look like zh=。！？", entry)
+		}
+		if !textsplit.SupportsLanguage(lang) {
+			return nil, fmt.Errorf("unsupported language %q", lang)
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[lang] = set
+	}
+	return out, nil
+}
+
+// parseAllowedOrigins splits a comma-separated origin list. Each entry must be
+// * or a scheme://host[:port] origin without a path.
+func parseAllowedOrigins(raw string) ([]string, error) {
+	var origins []string
+	for _, origin := range strings.Split(raw, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+		if origin != "*" {
+			parsed, err := url.Parse(origin)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" {
+				return nil, fmt.Errorf("%q must be * or an origin like https://app.example.com", origin)
+			}
+		}
+		origins = append(origins, origin)
+	}
+	return origins, nil
+}
+
 func normalizeAndValidateOpenAIBaseURL(raw string) (string, error) {
 	baseURL := strings.TrimRight(strings.TrimSpace(raw), "/")
 	if baseURL == "" {
@@ -156,3 +806,39 @@ func normalizeAndValidateOpenAIBaseURL(raw string) (string, error) {
 	parsed.RawPath = ""
 	return strings.TrimRight(parsed.String(), "/"), nil
 }
+
+// normalizeAndValidateAnthropicBaseURL follows the Anthropic SDK convention
+// of a base URL without the /v1 version prefix.
+func normalizeAndValidateAnthropicBaseURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimRight(strings.TrimSpace(raw), "/"))
+	if err != nil {
+		return "", fmt.Errorf("parse URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("must include scheme and host")
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("must not include query string or fragment")
+	}
+	if strings.HasSuffix(parsed.Path, "/v1") || strings.Contains(parsed.Path, "/v1/") {
+		return "", fmt.Errorf("must be a base URL only; do not include /v1")
+	}
+	return parsed.String(), nil
+}
+
+// parseCallTimeout reads a model call timeout from name, which must be at
+// least a second when set.
+func parseCallTimeout(name string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if parsed < time.Second {
+		return 0, fmt.Errorf("invalid %s: must be at least 1s", name)
+	}
+	return parsed, nil
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -133,6 +135,441 @@ func TestLoadSupportsLegacyOpenRouterEnvNames(t *testing.T) {
 	}
 }
 
+func TestLoadValidatesSRSStrugglingSettings(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SRSStrugglingThreshold != 3 || cfg.SRSStrugglingWindow != 7*24*time.Hour {
+		t.Fatalf("unexpected struggling defaults: threshold=%d window=%s", cfg.SRSStrugglingThreshold, cfg.SRSStrugglingWindow)
+	}
+
+	t.Setenv("SRS_STRUGGLING_THRESHOLD", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for struggling threshold below 1")
+	}
+
+	t.Setenv("SRS_STRUGGLING_THRESHOLD", "5")
+	t.Setenv("SRS_STRUGGLING_WINDOW", "-1h")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for non-positive struggling window")
+	}
+
+	t.Setenv("SRS_STRUGGLING_WINDOW", "72h")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SRSStrugglingThreshold != 5 || cfg.SRSStrugglingWindow != 72*time.Hour {
+		t.Fatalf("unexpected struggling settings: threshold=%d window=%s", cfg.SRSStrugglingThreshold, cfg.SRSStrugglingWindow)
+	}
+}
+
+func TestLoadSentenceDelimiters(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SentenceDelimiters != nil {
+		t.Fatalf("expected default delimiters, got %v", cfg.SentenceDelimiters)
+	}
+
+	t.Setenv("SENTENCE_DELIMITERS", " zh=。！？  ja=。 ")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.SentenceDelimiters) != 2 || cfg.SentenceDelimiters["zh"] != "。！？" || cfg.SentenceDelimiters["ja"] != "。" {
+		t.Fatalf("unexpected delimiters: %v", cfg.SentenceDelimiters)
+	}
+
+	for _, raw := range []string{"zh", "zh=", "fr=."} {
+		t.Setenv("SENTENCE_DELIMITERS", raw)
+		if _, err := Load(); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestLoadValidatesLogFormat(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.LogFormat != "text" {
+		t.Fatalf("expected text log format by default, got %q", cfg.LogFormat)
+	}
+
+	t.Setenv("LOG_FORMAT", "JSON")
+	if cfg, err = Load(); err != nil || cfg.LogFormat != "json" {
+		t.Fatalf("expected json log format, got %q (err=%v)", cfg.LogFormat, err)
+	}
+
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unknown log format")
+	}
+}
+
+func TestLoadSessionIdleTimeout(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SessionIdleTimeout != 0 {
+		t.Fatalf("expected idle timeout disabled by default, got %s", cfg.SessionIdleTimeout)
+	}
+
+	t.Setenv("SESSION_IDLE_TIMEOUT", "15m")
+	if cfg, err = Load(); err != nil || cfg.SessionIdleTimeout != 15*time.Minute {
+		t.Fatalf("expected 15m idle timeout, got %s (err=%v)", cfg.SessionIdleTimeout, err)
+	}
+
+	t.Setenv("SESSION_IDLE_TIMEOUT", "30s")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for idle timeout under a minute")
+	}
+}
+
+func TestLoadDBPoolSettings(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.DBMaxOpenConns != 1 || cfg.DBMaxIdleConns != 1 || cfg.DBBusyTimeoutMs != 3000 {
+		t.Fatalf("unexpected pool defaults: open=%d idle=%d busy=%d", cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBBusyTimeoutMs)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "4")
+	t.Setenv("DB_BUSY_TIMEOUT_MS", "5000")
+	if cfg, err = Load(); err != nil || cfg.DBMaxOpenConns != 4 || cfg.DBMaxIdleConns != 4 || cfg.DBBusyTimeoutMs != 5000 {
+		t.Fatalf("expected idle conns to follow open conns, got open=%d idle=%d busy=%d (err=%v)", cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBBusyTimeoutMs, err)
+	}
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "5")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for more idle than open connections")
+	}
+	t.Setenv("DB_MAX_IDLE_CONNS", "")
+	t.Setenv("DB_MAX_OPEN_CONNS", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for zero open connections")
+	}
+}
+
+func TestLoadBackupSettings(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("LANGUAGE_APP_DB_PATH", filepath.Join(repoRoot, "db", "app.db"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.BackupDir != filepath.Join(repoRoot, "db", "backups") {
+		t.Fatalf("expected backups next to the database, got %q", cfg.BackupDir)
+	}
+	if cfg.BackupInterval != 24*time.Hour || cfg.BackupRetention != 7 {
+		t.Fatalf("unexpected backup defaults: interval=%s retention=%d", cfg.BackupInterval, cfg.BackupRetention)
+	}
+
+	t.Setenv("BACKUP_INTERVAL", "0")
+	t.Setenv("BACKUP_RETENTION", "3")
+	if cfg, err = Load(); err != nil || cfg.BackupInterval != 0 || cfg.BackupRetention != 3 {
+		t.Fatalf("expected periodic backups disabled with retention 3, got interval=%s retention=%d (err=%v)", cfg.BackupInterval, cfg.BackupRetention, err)
+	}
+
+	t.Setenv("BACKUP_INTERVAL", "30s")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for backup interval under a minute")
+	}
+	t.Setenv("BACKUP_INTERVAL", "6h")
+	t.Setenv("BACKUP_RETENTION", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for zero backup retention")
+	}
+}
+
+func TestLoadQueueSettings(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.JobScanInterval != 30*time.Second {
+		t.Fatalf("expected default job scan interval of 30s, got %s", cfg.JobScanInterval)
+	}
+
+	t.Setenv("JOB_SCAN_INTERVAL", "2m")
+	if cfg, err = Load(); err != nil || cfg.JobScanInterval != 2*time.Minute {
+		t.Fatalf("expected job scan interval of 2m, got %s (err=%v)", cfg.JobScanInterval, err)
+	}
+
+	t.Setenv("JOB_SCAN_INTERVAL", "500ms")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for job scan interval under a second")
+	}
+	t.Setenv("JOB_SCAN_INTERVAL", "")
+
+	if cfg.TranslationWorkers != 0 {
+		t.Fatalf("expected uncapped translation workers by default, got %d", cfg.TranslationWorkers)
+	}
+	t.Setenv("TRANSLATION_WORKERS", "2")
+	if cfg, err = Load(); err != nil || cfg.TranslationWorkers != 2 {
+		t.Fatalf("expected 2 translation workers, got %d (err=%v)", cfg.TranslationWorkers, err)
+	}
+	t.Setenv("TRANSLATION_WORKERS", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for negative translation workers")
+	}
+	t.Setenv("TRANSLATION_WORKERS", "")
+
+	if cfg.TranslationSegmentDelay != 0 {
+		t.Fatalf("expected no segment delay by default, got %s", cfg.TranslationSegmentDelay)
+	}
+	t.Setenv("TRANSLATION_SEGMENT_DELAY", "250ms")
+	if cfg, err = Load(); err != nil || cfg.TranslationSegmentDelay != 250*time.Millisecond {
+		t.Fatalf("expected a 250ms segment delay, got %s (err=%v)", cfg.TranslationSegmentDelay, err)
+	}
+	t.Setenv("TRANSLATION_SEGMENT_DELAY", "-1s")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for a negative segment delay")
+	}
+}
+
+func TestLoadShutdownTimeout(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.ShutdownTimeout != 30*time.Second {
+		t.Fatalf("expected default shutdown timeout of 30s, got %s", cfg.ShutdownTimeout)
+	}
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "2m")
+	if cfg, err = Load(); err != nil || cfg.ShutdownTimeout != 2*time.Minute {
+		t.Fatalf("expected shutdown timeout of 2m, got %s (err=%v)", cfg.ShutdownTimeout, err)
+	}
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "nope")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for an unparseable shutdown timeout")
+	}
+}
+
+func TestLoadReadingCharsPerMinute(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.ReadingCharsPerMinute != DefaultReadingCharsPerMinute {
+		t.Fatalf("expected default reading speed, got %d", cfg.ReadingCharsPerMinute)
+	}
+
+	t.Setenv("READING_CHARS_PER_MINUTE", "400")
+	if cfg, err = Load(); err != nil || cfg.ReadingCharsPerMinute != 400 {
+		t.Fatalf("expected reading speed of 400, got %d (err=%v)", cfg.ReadingCharsPerMinute, err)
+	}
+
+	t.Setenv("READING_CHARS_PER_MINUTE", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for a zero reading speed")
+	}
+}
+
+func TestLoadDisableAuthRequiresInsecureCookies(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.DisableAuth {
+		t.Fatal("expected auth to be required by default")
+	}
+
+	t.Setenv("DISABLE_AUTH", "true")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected DISABLE_AUTH to be refused with the default secure cookies")
+	}
+
+	t.Setenv("SECURE_COOKIES", "false")
+	if cfg, err = Load(); err != nil || !cfg.DisableAuth {
+		t.Fatalf("expected auth to be disabled, got %v (err=%v)", cfg.DisableAuth, err)
+	}
+}
+
+func TestLoadDefinitionPriority(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.DefinitionPriority != DefinitionPriorityCedictFirst {
+		t.Fatalf("expected cedict_first by default, got %q", cfg.DefinitionPriority)
+	}
+
+	t.Setenv("DEFINITION_PRIORITY", "LLM_Only")
+	if cfg, err = Load(); err != nil || cfg.DefinitionPriority != DefinitionPriorityLLMOnly {
+		t.Fatalf("expected llm_only, got %q (err=%v)", cfg.DefinitionPriority, err)
+	}
+
+	t.Setenv("DEFINITION_PRIORITY", "user_first")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected an unknown DEFINITION_PRIORITY to be rejected")
+	}
+}
+
+func TestLoadMaxInputChars(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.MaxInputChars != DefaultMaxInputChars {
+		t.Fatalf("expected default input limit, got %d", cfg.MaxInputChars)
+	}
+
+	t.Setenv("MAX_INPUT_CHARS", "5000")
+	if cfg, err = Load(); err != nil || cfg.MaxInputChars != 5000 {
+		t.Fatalf("expected input limit of 5000, got %d (err=%v)", cfg.MaxInputChars, err)
+	}
+
+	t.Setenv("MAX_INPUT_CHARS", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for a zero input limit")
+	}
+}
+
+func TestLoadSSEPollInterval(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SSEPollInterval != time.Second {
+		t.Fatalf("expected default SSE poll interval of 1s, got %s", cfg.SSEPollInterval)
+	}
+
+	t.Setenv("SSE_POLL_INTERVAL", "250ms")
+	if cfg, err = Load(); err != nil || cfg.SSEPollInterval != 250*time.Millisecond {
+		t.Fatalf("expected SSE poll interval of 250ms, got %s (err=%v)", cfg.SSEPollInterval, err)
+	}
+
+	t.Setenv("SSE_POLL_INTERVAL", "1ms")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for an SSE poll interval under 10ms")
+	}
+}
+
 func createTempRepoRoot(t *testing.T) string {
 	t.Helper()
 
@@ -163,3 +600,327 @@ func withChdir(t *testing.T, dir string) {
 		_ = os.Chdir(wd)
 	})
 }
+
+func TestLoadTranslationProvider(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENROUTER_TRANSLATION_MODEL", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("ANTHROPIC_TRANSLATION_MODEL", "")
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+
+	t.Setenv("TRANSLATION_PROVIDER", "gemini")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unsupported TRANSLATION_PROVIDER")
+	}
+
+	t.Setenv("TRANSLATION_PROVIDER", "Anthropic")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error when ANTHROPIC_API_KEY is missing")
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "ant-key")
+	t.Setenv("ANTHROPIC_TRANSLATION_MODEL", "claude-test")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.TranslationProvider != TranslationProviderAnthropic || cfg.AnthropicAPIKey != "ant-key" || cfg.AnthropicTranslationModel != "claude-test" {
+		t.Fatalf("unexpected anthropic settings: %+v", cfg)
+	}
+	if cfg.AnthropicBaseURL != "https://api.anthropic.com" {
+		t.Fatalf("unexpected default ANTHROPIC_BASE_URL: %q", cfg.AnthropicBaseURL)
+	}
+
+	t.Setenv("ANTHROPIC_BASE_URL", "https://api.anthropic.com/v1/messages")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for ANTHROPIC_BASE_URL with an API path")
+	}
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+
+	t.Setenv("TRANSLATION_PROVIDER", "")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected the default openai provider to require OPENAI_TRANSLATION_MODEL")
+	}
+}
+
+func TestLoadOfflineModeSkipsLLMSettings(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENROUTER_API_KEY", "")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "")
+	t.Setenv("OPENROUTER_TRANSLATION_MODEL", "")
+	t.Setenv("OPENAI_CHAT_MODEL", "")
+	t.Setenv("OPENROUTER_CHAT_MODEL", "")
+	t.Setenv("OFFLINE_MODE", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if !cfg.OfflineMode {
+		t.Fatal("expected offline mode to be enabled")
+	}
+}
+
+func TestLoadCedictFastPathCoverage(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.CedictFastPathCoverage != 0.95 {
+		t.Fatalf("unexpected default coverage: %v", cfg.CedictFastPathCoverage)
+	}
+
+	t.Setenv("CEDICT_FAST_PATH_COVERAGE", "0")
+	if cfg, err = Load(); err != nil || cfg.CedictFastPathCoverage != 0 {
+		t.Fatalf("expected fast path disabled, got %v (err=%v)", cfg.CedictFastPathCoverage, err)
+	}
+
+	t.Setenv("CEDICT_FAST_PATH_COVERAGE", "1.5")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for coverage above 1")
+	}
+}
+
+func TestLoadCedictGlossSenses(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.CedictGlossSenses != 3 {
+		t.Fatalf("unexpected default gloss senses: %d", cfg.CedictGlossSenses)
+	}
+
+	t.Setenv("CEDICT_GLOSS_SENSES", "1")
+	if cfg, err = Load(); err != nil || cfg.CedictGlossSenses != 1 {
+		t.Fatalf("expected one gloss sense, got %d (err=%v)", cfg.CedictGlossSenses, err)
+	}
+
+	t.Setenv("CEDICT_GLOSS_SENSES", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for zero gloss senses")
+	}
+}
+
+func TestLoadSegmentationRepair(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if !cfg.SegmentationRepair {
+		t.Fatal("expected segmentation repair on by default")
+	}
+
+	t.Setenv("SEGMENTATION_REPAIR", "false")
+	if cfg, err = Load(); err != nil || cfg.SegmentationRepair {
+		t.Fatalf("expected segmentation repair off, got %v (err=%v)", cfg.SegmentationRepair, err)
+	}
+}
+
+func TestLoadChatContextCaps(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.ChatMaxArticleRunes != DefaultChatMaxArticleRunes || cfg.ChatHistoryTurns != DefaultChatHistoryTurns || cfg.ChatMaxPromptTokens != DefaultChatMaxPromptTokens {
+		t.Fatalf("unexpected chat cap defaults: article=%d turns=%d tokens=%d", cfg.ChatMaxArticleRunes, cfg.ChatHistoryTurns, cfg.ChatMaxPromptTokens)
+	}
+
+	t.Setenv("CHAT_HISTORY_TURNS", "4")
+	if cfg, err = Load(); err != nil || cfg.ChatHistoryTurns != 4 {
+		t.Fatalf("expected 4 history turns, got %d (err=%v)", cfg.ChatHistoryTurns, err)
+	}
+	t.Setenv("CHAT_HISTORY_TURNS", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for zero history turns")
+	}
+	t.Setenv("CHAT_HISTORY_TURNS", "")
+	t.Setenv("CHAT_MAX_PROMPT_TOKENS", "500")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for a prompt token cap below 1000")
+	}
+}
+
+func TestLoadSpeechToTextDefaultsToOpenAI(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_BASE_URL", "https://api.openai.com/v1")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SpeechToTextModel != "" || cfg.SpeechToTextBaseURL != "https://api.openai.com/v1" || cfg.SpeechToTextAPIKey != "oa-key" {
+		t.Fatalf("unexpected speech-to-text defaults: %+v", cfg)
+	}
+
+	t.Setenv("SPEECH_TO_TEXT_MODEL", "whisper-1")
+	t.Setenv("SPEECH_TO_TEXT_BASE_URL", "https://stt.example.com/v1/")
+	t.Setenv("SPEECH_TO_TEXT_API_KEY", "stt-key")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SpeechToTextModel != "whisper-1" || cfg.SpeechToTextBaseURL != "https://stt.example.com/v1" || cfg.SpeechToTextAPIKey != "stt-key" {
+		t.Fatalf("unexpected speech-to-text settings: model=%q base=%q key=%q", cfg.SpeechToTextModel, cfg.SpeechToTextBaseURL, cfg.SpeechToTextAPIKey)
+	}
+
+	t.Setenv("SPEECH_TO_TEXT_BASE_URL", "not a url")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for invalid SPEECH_TO_TEXT_BASE_URL")
+	}
+}
+
+func TestLoadAllowedOrigins(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.AllowedOrigins) != 0 || !cfg.CORSAllowCredentials {
+		t.Fatalf("expected same-origin default, got origins=%v credentials=%v", cfg.AllowedOrigins, cfg.CORSAllowCredentials)
+	}
+
+	t.Setenv("ALLOWED_ORIGINS", "https://app.example.com/, http://localhost:5173")
+	t.Setenv("CORS_ALLOWED_METHODS", "get, post")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if strings.Join(cfg.AllowedOrigins, ",") != "https://app.example.com,http://localhost:5173" || strings.Join(cfg.CORSAllowedMethods, ",") != "GET,POST" {
+		t.Fatalf("unexpected CORS settings: origins=%v methods=%v", cfg.AllowedOrigins, cfg.CORSAllowedMethods)
+	}
+
+	t.Setenv("ALLOWED_ORIGINS", "https://app.example.com/path")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for an origin with a path")
+	}
+	t.Setenv("ALLOWED_ORIGINS", "*")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for * with credentials")
+	}
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+	if cfg, err = Load(); err != nil || cfg.CORSAllowCredentials {
+		t.Fatalf("expected * allowed without credentials, got credentials=%v (err=%v)", cfg.CORSAllowCredentials, err)
+	}
+}
+
+func TestLoadStudyTimezone(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.StudyLocation != time.UTC {
+		t.Fatalf("expected UTC study days by default, got %v", cfg.StudyLocation)
+	}
+
+	t.Setenv("STUDY_TIMEZONE", "Not/A_Zone")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for an unknown timezone")
+	}
+}
+
+func TestLoadCallTimeouts(t *testing.T) {
+	repoRoot := createTempRepoRoot(t)
+	withChdir(t, repoRoot)
+
+	t.Setenv("APP_PASSWORD", "pw")
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("OPENAI_API_KEY", "oa-key")
+	t.Setenv("OPENAI_TRANSLATION_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENAI_CHAT_MODEL", "openai/gpt-4o-mini")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SegmentationTimeout != time.Minute || cfg.SegmentTranslationTimeout != time.Minute || cfg.FullTranslationTimeout != 10*time.Minute {
+		t.Fatalf("unexpected default timeouts: %s %s %s", cfg.SegmentationTimeout, cfg.SegmentTranslationTimeout, cfg.FullTranslationTimeout)
+	}
+
+	t.Setenv("LLM_SEGMENTATION_TIMEOUT", "15s")
+	t.Setenv("LLM_SEGMENT_TRANSLATION_TIMEOUT", "20s")
+	t.Setenv("LLM_FULL_TRANSLATION_TIMEOUT", "30m")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.SegmentationTimeout != 15*time.Second || cfg.SegmentTranslationTimeout != 20*time.Second || cfg.FullTranslationTimeout != 30*time.Minute {
+		t.Fatalf("unexpected timeouts: %s %s %s", cfg.SegmentationTimeout, cfg.SegmentTranslationTimeout, cfg.FullTranslationTimeout)
+	}
+
+	t.Setenv("LLM_SEGMENTATION_TIMEOUT", "500ms")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for a sub-second timeout")
+	}
+}
@@ -2,17 +2,52 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/anath2/language-app/internal/intelligence"
+	"github.com/anath2/language-app/internal/translation"
 )
 
+// instructionReloader is optional: reloading the segmentation instruction
+// reports 503 when it is nil.
+var instructionReloader intelligence.SegmentationInstructionReloader
+
+func ConfigureInstructionReloader(ir intelligence.SegmentationInstructionReloader) {
+	instructionReloader = ir
+}
+
+// segmentationEvaluator is optional: evaluating an instruction reports 503
+// when it is nil, e.g. in offline mode.
+var segmentationEvaluator intelligence.SegmentationEvaluator
+
+// evaluationRunning allows one evaluation at a time, since each makes a model
+// call per dataset case.
+var evaluationRunning sync.Mutex
+
+func ConfigureSegmentationEvaluator(se intelligence.SegmentationEvaluator) {
+	segmentationEvaluator = se
+}
+
+// segmentationStats is optional: metrics omit segmentation counters when it
+// is nil.
+var segmentationStats intelligence.SegmentationStatsReporter
+
+func ConfigureSegmentationStats(sr intelligence.SegmentationStatsReporter) {
+	segmentationStats = sr
+}
+
 func ExportProgress(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
-	jsonContent, err := srs.ExportProgressJSON()
+	jsonContent, err := srs.ExportProgressJSON(currentUserID(r))
 	if err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -23,48 +58,183 @@ func ExportProgress(w http.ResponseWriter, r *http.Request) {
 
 func ImportProgress(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	if err := r.ParseMultipartForm(1 << 20); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid multipart payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid multipart payload")
 		return
 	}
 	file, _, err := r.FormFile("file")
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid file type. Please upload a .json file."})
+		WriteError(w, http.StatusBadRequest, "Invalid file type. Please upload a .json file.")
 		return
 	}
 	defer file.Close()
 	buf := make([]byte, 1<<20+1)
 	n, _ := file.Read(buf)
 	if n > 1<<20 {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "File too large. Maximum size is 1024KB."})
+		WriteError(w, http.StatusBadRequest, "File too large. Maximum size is 1024KB.")
 		return
 	}
-	counts, err := srs.ImportProgressJSON(string(buf[:n]))
+	// Replace wipes the user's progress first; merge combines the file with it.
+	var importJSON func(string, string) (map[string]int, error)
+	mode := r.FormValue("mode")
+	switch mode {
+	case "", "replace":
+		mode = "replace"
+		importJSON = srs.ImportProgressJSON
+	case "merge":
+		importJSON = srs.MergeProgressJSON
+	default:
+		WriteError(w, http.StatusBadRequest, "mode must be replace or merge")
+		return
+	}
+	// A dry run validates the file and reports what it would import.
+	dryRun := strings.EqualFold(r.FormValue("dry_run"), "true")
+	if dryRun {
+		importJSON = srs.ValidateProgressJSON
+	}
+	counts, err := importJSON(currentUserID(r), string(buf[:n]))
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	WriteJSON(w, http.StatusOK, map[string]any{
 		"success": true,
+		"mode":    mode,
+		"dry_run": dryRun,
 		"counts":  counts,
 	})
 }
 
+// ReloadDictionary re-reads the dictionary file so self-hosters can pick up
+// an updated cedict_ts.u8 without restarting. The dictionary is shared by
+// every user, so only the instance owner may reload it.
+func ReloadDictionary(w http.ResponseWriter, r *http.Request) {
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	if dictionary == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Dictionary is not available")
+		return
+	}
+	count, err := dictionary.ReloadDictionary()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"entries": count,
+	})
+}
+
+// ReloadSegmentationInstruction swaps in the compiled instruction written by
+// a GEPA campaign so an improved prompt goes live without a restart. It
+// changes the prompt for every user, so only the instance owner may run it.
+func ReloadSegmentationInstruction(w http.ResponseWriter, r *http.Request) {
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	if instructionReloader == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Segmentation instruction reload is not available")
+		return
+	}
+	info, err := instructionReloader.ReloadSegmentationInstruction()
+	if errors.Is(err, intelligence.ErrInstructionNotFound) {
+		WriteError(w, http.StatusNotFound, "Compiled instruction not found")
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"path":    info.Path,
+		"sha256":  info.SHA256,
+		"length":  info.Length,
+	})
+}
+
+type evaluateInstructionRequest struct {
+	Instruction string `json:"instruction"`
+	Dataset     string `json:"dataset"`
+}
+
+type evaluateInstructionResponse struct {
+	Dataset                string  `json:"dataset"`
+	TotalCases             int     `json:"total_cases"`
+	ExactMatches           int     `json:"exact_matches"`
+	ReconstructionFailures int     `json:"reconstruction_failures"`
+	Errors                 int     `json:"errors"`
+	Accuracy               float64 `json:"accuracy"`
+	AvgLatencyMs           int64   `json:"avg_latency_ms"`
+}
+
+// EvaluateSegmentationInstruction scores a candidate instruction against a
+// bundled GEPA dataset using the live model config. It makes one model call
+// per case, so only the instance owner may run it and only one runs at a
+// time.
+func EvaluateSegmentationInstruction(w http.ResponseWriter, r *http.Request) {
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	if segmentationEvaluator == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Segmentation evaluation is not available")
+		return
+	}
+	var req evaluateInstructionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	instruction := strings.TrimSpace(req.Instruction)
+	if instruction == "" {
+		WriteError(w, http.StatusBadRequest, "instruction is required")
+		return
+	}
+	if !evaluationRunning.TryLock() {
+		WriteError(w, http.StatusConflict, "An evaluation is already running")
+		return
+	}
+	defer evaluationRunning.Unlock()
+
+	result, err := segmentationEvaluator.EvaluateSegmentationInstruction(r.Context(), instruction, strings.TrimSpace(req.Dataset))
+	if errors.Is(err, intelligence.ErrEvalDatasetNotFound) {
+		WriteError(w, http.StatusNotFound, "Dataset not found")
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, evaluateInstructionResponse{
+		Dataset:                result.Dataset,
+		TotalCases:             result.TotalCases,
+		ExactMatches:           result.ExactMatches,
+		ReconstructionFailures: result.ReconstructionFailures,
+		Errors:                 result.Errors,
+		Accuracy:               result.Accuracy,
+		AvgLatencyMs:           result.AvgLatency.Milliseconds(),
+	})
+}
+
 func GetProfile(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
-	profile, ok := profiles.GetUserProfile()
+	userID := currentUserID(r)
+	profile, ok := profiles.GetUserProfile(userID)
 	var profileObj any
 	if ok {
 		profileObj = map[string]any{
 			"name":       profile.Name,
 			"email":      profile.Email,
 			"language":   profile.Language,
+			"timezone":   profile.Timezone,
 			"created_at": profile.CreatedAt,
 			"updated_at": profile.UpdatedAt,
 		}
@@ -72,29 +242,29 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, map[string]any{
 		"profile": profileObj,
 		"vocabStats": map[string]int{
-			"known":    srs.CountSegmentsByStatus("known"),
-			"learning": srs.CountSegmentsByStatus("learning"),
-			"total":    srs.CountTotalSegments(),
+			"known":    srs.CountSegmentsByStatus(userID, "known"),
+			"learning": srs.CountSegmentsByStatus(userID, "learning"),
+			"total":    srs.CountTotalSegments(userID),
 		},
 	})
 }
 
 func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	var payload map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 	name := payload["name"]
 	email := payload["email"]
 	language := payload["language"]
-	profile, err := profiles.UpsertUserProfile(name, email, language)
+	profile, err := profiles.UpsertUserProfile(currentUserID(r), name, email, language, payload["timezone"])
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	WriteJSON(w, http.StatusOK, map[string]any{
@@ -102,8 +272,265 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 			"name":       profile.Name,
 			"email":      profile.Email,
 			"language":   profile.Language,
+			"timezone":   profile.Timezone,
 			"created_at": profile.CreatedAt,
 			"updated_at": profile.UpdatedAt,
 		},
 	})
 }
+
+type userSummary struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	CreatedAt string `json:"created_at"`
+}
+
+// requireInstanceOwner limits account management to the default user, who
+// signs in with the shared APP_PASSWORD.
+func requireInstanceOwner(w http.ResponseWriter, r *http.Request) bool {
+	if users == nil {
+		WriteError(w, http.StatusServiceUnavailable, "User accounts are not available")
+		return false
+	}
+	if currentUserID(r) != translation.DefaultUserID {
+		WriteError(w, http.StatusForbidden, "Only the instance owner can do this")
+		return false
+	}
+	return true
+}
+
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	items, err := users.ListUsers()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	summaries := make([]userSummary, 0, len(items))
+	for _, item := range items {
+		summaries = append(summaries, userSummary{ID: item.ID, Username: item.Username, CreatedAt: item.CreatedAt})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"users": summaries})
+}
+
+func CreateUser(w http.ResponseWriter, r *http.Request) {
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	user, err := users.CreateUser(req.Username, req.Password)
+	if errors.Is(err, translation.ErrUsernameTaken) {
+		WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusCreated, userSummary{ID: user.ID, Username: user.Username, CreatedAt: user.CreatedAt})
+}
+
+// CreateBackup snapshots the database on demand, alongside the periodic
+// backups.
+func CreateBackup(w http.ResponseWriter, r *http.Request) {
+	if backups == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Backups are not available")
+		return
+	}
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	path, err := backups.Backup(r.Context())
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]string{"path": path})
+}
+
+type foreignKeyViolation struct {
+	Table  string `json:"table"`
+	RowID  *int64 `json:"rowid"`
+	Parent string `json:"parent"`
+}
+
+type walCheckpoint struct {
+	Busy               bool `json:"busy"`
+	LogFrames          int  `json:"log_frames"`
+	CheckpointedFrames int  `json:"checkpointed_frames"`
+}
+
+// CheckDatabase runs SQLite's integrity and foreign key checks and, when
+// asked, checkpoints the WAL. Problems are reported, not repaired; restoring
+// a backup is the fix for a corrupt database.
+func CheckDatabase(w http.ResponseWriter, r *http.Request) {
+	if maintenance == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Database checks are not available")
+		return
+	}
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	var payload struct {
+		Checkpoint bool `json:"checkpoint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	report, err := maintenance.CheckIntegrity(r.Context())
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	violations := make([]foreignKeyViolation, 0, len(report.ForeignKeyViolations))
+	for _, v := range report.ForeignKeyViolations {
+		violations = append(violations, foreignKeyViolation{Table: v.Table, RowID: v.RowID, Parent: v.Parent})
+	}
+	response := map[string]any{
+		"ok":                     len(report.IntegrityErrors) == 0 && len(violations) == 0,
+		"integrity_errors":       report.IntegrityErrors,
+		"foreign_key_violations": violations,
+	}
+	if payload.Checkpoint {
+		checkpoint, err := maintenance.CheckpointWAL(r.Context())
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		response["checkpoint"] = walCheckpoint{
+			Busy:               checkpoint.Busy,
+			LogFrames:          checkpoint.LogFrames,
+			CheckpointedFrames: checkpoint.CheckpointedFrames,
+		}
+	}
+	WriteJSON(w, http.StatusOK, response)
+}
+
+type translationError struct {
+	TranslationID string `json:"translation_id"`
+	Status        string `json:"status"`
+	ErrorMessage  string `json:"error_message"`
+	Detail        string `json:"detail"`
+	Attempts      int    `json:"attempts"`
+	FailedAt      string `json:"failed_at"`
+}
+
+// GetTranslationError returns the full error behind a failed translation.
+// The error_message shown with the translation is cut short; detail keeps
+// what the provider returned, with API keys redacted.
+func GetTranslationError(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	failure, err := translations.GetJobFailure(pathParam(r, "translation_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if failure.Status != "failed" {
+		WriteError(w, http.StatusNotFound, "Translation has not failed")
+		return
+	}
+	WriteJSON(w, http.StatusOK, translationError{
+		TranslationID: failure.TranslationID,
+		Status:        failure.Status,
+		ErrorMessage:  failure.ErrorMessage,
+		Detail:        failure.Detail,
+		Attempts:      failure.Attempts,
+		FailedAt:      failure.UpdatedAt,
+	})
+}
+
+type jobMetrics struct {
+	ByState       map[string]int `json:"by_state"`
+	ExpiredLeases int            `json:"expired_leases"`
+	Running       int            `json:"running"`
+}
+
+type reviewMetrics struct {
+	SegmentsDue   int `json:"segments_due"`
+	CharactersDue int `json:"characters_due"`
+}
+
+// segmentationMetrics reports live segmentation quality since startup.
+// ReconstructionRate is the share of model segmentations that rebuild their
+// input; it is null until the model has segmented anything.
+type segmentationMetrics struct {
+	ModelCalls         int64    `json:"model_calls"`
+	Reconstructed      int64    `json:"reconstructed"`
+	NotReconstructed   int64    `json:"not_reconstructed"`
+	EmptyResults       int64    `json:"empty_results"`
+	Repaired           int64    `json:"repaired"`
+	CedictFastPath     int64    `json:"cedict_fast_path"`
+	ReconstructionRate *float64 `json:"reconstruction_rate"`
+}
+
+func toSegmentationMetrics(stats intelligence.SegmentationStats) segmentationMetrics {
+	out := segmentationMetrics{
+		ModelCalls:       stats.ModelCalls,
+		Reconstructed:    stats.Reconstructed,
+		NotReconstructed: stats.NotReconstructed,
+		EmptyResults:     stats.EmptyResults,
+		Repaired:         stats.Repaired,
+		CedictFastPath:   stats.CedictFastPath,
+	}
+	if stats.ModelCalls > 0 {
+		rate := float64(stats.Reconstructed) / float64(stats.ModelCalls)
+		out.ReconstructionRate = &rate
+	}
+	return out
+}
+
+// GetMetrics reports the state of the translation pipeline. Expired leases
+// are restarted by the background scanner, so a count that stays above zero
+// points at a worker that keeps dying mid-job.
+func GetMetrics(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !requireInstanceOwner(w, r) {
+		return
+	}
+	statuses, err := translations.CountByStatus()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	jobs, err := translations.CountJobsByState()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	metrics := map[string]any{
+		"translations": statuses,
+		"jobs": jobMetrics{
+			ByState:       jobs.ByState,
+			ExpiredLeases: jobs.ExpiredLeases,
+			Running:       jobQueue.RunningCount(),
+		},
+		"reviews": reviewMetrics{
+			SegmentsDue:   srs.GetSegmentDueCount(currentUserID(r)),
+			CharactersDue: srs.GetCharacterDueCount(currentUserID(r)),
+		},
+	}
+	if segmentationStats != nil {
+		metrics["segmentation"] = toSegmentationMetrics(segmentationStats.SegmentationStats())
+	}
+	WriteJSON(w, http.StatusOK, metrics)
+}
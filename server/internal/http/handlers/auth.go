@@ -2,29 +2,51 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/anath2/language-app/internal/config"
 	"github.com/anath2/language-app/internal/http/middleware"
+	"github.com/anath2/language-app/internal/translation"
 )
 
+// Login signs in with a username and password, or with the shared
+// APP_PASSWORD as the default user when no username is given.
 func Login(cfg config.Config, sessionManager *middleware.SessionManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var payload struct {
+			Username string `json:"username"`
 			Password string `json:"password"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+			WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 			return
 		}
 
-		if !sessionManager.VerifyPassword(payload.Password, cfg.AppPassword) {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{"detail": "Invalid password"})
+		userID := translation.DefaultUserID
+		if username := strings.TrimSpace(payload.Username); username != "" {
+			if users == nil {
+				WriteError(w, http.StatusUnauthorized, "Invalid username or password")
+				return
+			}
+			user, err := users.Authenticate(username, payload.Password)
+			if errors.Is(err, translation.ErrInvalidCredentials) {
+				WriteError(w, http.StatusUnauthorized, "Invalid username or password")
+				return
+			}
+			if err != nil {
+				writeStoreError(w, err)
+				return
+			}
+			userID = user.ID
+		} else if !sessionManager.VerifyPassword(payload.Password, cfg.AppPassword) {
+			WriteError(w, http.StatusUnauthorized, "Invalid password")
 			return
 		}
 
-		if err := sessionManager.SetSessionCookie(w, r); err != nil {
-			WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": "Could not create session"})
+		if err := sessionManager.SetSessionCookie(w, r, userID); err != nil {
+			WriteError(w, http.StatusInternalServerError, "Could not create session")
 			return
 		}
 
@@ -34,7 +56,73 @@ func Login(cfg config.Config, sessionManager *middleware.SessionManager) http.Ha
 
 func Logout(sessionManager *middleware.SessionManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if err := sessionManager.RevokeCurrentSession(r); err != nil {
+			writeStoreError(w, err)
+			return
+		}
 		sessionManager.ClearSessionCookie(w, r)
 		WriteJSON(w, http.StatusOK, map[string]bool{"ok": true})
 	}
 }
+
+// LogoutAll revokes every session of the signed-in user, including the
+// current one, so cookies left on other devices stop working.
+func LogoutAll(sessionManager *middleware.SessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		revoked, err := sessionManager.RevokeUserSessions(currentUserID(r))
+		if errors.Is(err, middleware.ErrSessionsUnavailable) {
+			WriteError(w, http.StatusServiceUnavailable, "Session tracking is not available")
+			return
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		sessionManager.ClearSessionCookie(w, r)
+		WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "revoked": revoked})
+	}
+}
+
+type sessionSummary struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
+
+// ListSessions returns the signed-in user's active sessions.
+func ListSessions(sessionManager *middleware.SessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessions, err := sessionManager.ListUserSessions(currentUserID(r))
+		if errors.Is(err, middleware.ErrSessionsUnavailable) {
+			WriteError(w, http.StatusServiceUnavailable, "Session tracking is not available")
+			return
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		currentID := sessionManager.CurrentSessionID(r)
+		summaries := make([]sessionSummary, 0, len(sessions))
+		for _, session := range sessions {
+			summaries = append(summaries, sessionSummary{
+				ID:         session.ID,
+				UserAgent:  session.UserAgent,
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				Current:    session.ID == currentID,
+			})
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{"sessions": summaries})
+	}
+}
+
+// currentUserID is the signed-in user, treating sessions issued before user
+// accounts existed as the default user.
+func currentUserID(r *http.Request) string {
+	if userID := middleware.UserIDFromContext(r.Context()); userID != "" {
+		return userID
+	}
+	return translation.DefaultUserID
+}
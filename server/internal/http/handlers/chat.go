@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"unicode"
 
 	"github.com/anath2/language-app/internal/intelligence"
 	"github.com/anath2/language-app/internal/translation"
@@ -12,6 +16,20 @@ import (
 type createChatMessageRequest struct {
 	Message      string `json:"message"`
 	SelectedText string `json:"selected_text"`
+	// Temperature and Model override the configured chat defaults.
+	Temperature *float64 `json:"temperature"`
+	Model       string   `json:"model"`
+}
+
+const (
+	maxChatTemperature = 2.0
+	maxChatModelLength = 200
+)
+
+type updateReviewCardRequest struct {
+	ChineseText *string `json:"chinese_text"`
+	Pinyin      *string `json:"pinyin"`
+	English     *string `json:"english"`
 }
 
 type chatListResponse struct {
@@ -21,50 +39,73 @@ type chatListResponse struct {
 
 func CreateChatMessage(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	translationID := pathParam(r, "translation_id")
 	item, exists := translations.Get(translationID)
 	if !exists {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+		WriteError(w, http.StatusNotFound, "Translation not found")
 		return
 	}
 
 	var req createChatMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 	req.Message = strings.TrimSpace(req.Message)
 	if req.Message == "" {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "message is required"})
+		WriteError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+	if detail := normalizeChatOverrides(&req); detail != "" {
+		WriteError(w, http.StatusBadRequest, detail)
 		return
 	}
 
 	thread, err := chats.EnsureChatForTranslation(translationID)
 	if err != nil {
 		if err == translation.ErrNotFound {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+			WriteError(w, http.StatusNotFound, "Translation not found")
 			return
 		}
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	userMsg, err := chats.AppendChatMessage(translationID, translation.ChatRoleUser, req.Message, req.SelectedText)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	history, err := chats.ListChatMessages(translationID)
 	if err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
+	streamChatTurn(w, r, item, thread.ID, userMsg, history, req)
+}
+
+// normalizeChatOverrides trims the per-message model override and checks both
+// overrides, returning the 400 detail for an invalid value.
+func normalizeChatOverrides(req *createChatMessageRequest) string {
+	if req.Temperature != nil && (*req.Temperature < 0 || *req.Temperature > maxChatTemperature) {
+		return "temperature must be between 0 and 2"
+	}
+	req.Model = strings.TrimSpace(req.Model)
+	if len(req.Model) > maxChatModelLength || strings.ContainsFunc(req.Model, unicode.IsSpace) {
+		return "model must be a model id without spaces, at most 200 characters"
+	}
+	return ""
+}
+
+// streamChatTurn runs the AI turn answering userMsg and streams it over SSE,
+// storing the reply and any review cards. history must end with userMsg.
+func streamChatTurn(w http.ResponseWriter, r *http.Request, item translation.Translation, chatID string, userMsg translation.ChatMessage, history []translation.ChatMessage, req createChatMessageRequest) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -76,19 +117,27 @@ func CreateChatMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	selectedText := ""
+	if userMsg.SelectedText != nil {
+		selectedText = *userMsg.SelectedText
+	}
+	translationID := item.ID
+
 	emitSSE(w, map[string]any{
 		"type":            "start",
 		"translation_id":  translationID,
-		"chat_id":         thread.ID,
+		"chat_id":         chatID,
 		"user_message_id": userMsg.ID,
 	})
 	flusher.Flush()
 
 	result, err := chatProvider.ChatWithTranslationContext(r.Context(), intelligence.ChatWithTranslationRequest{
 		TranslationText: item.InputText,
-		UserMessage:     req.Message,
+		UserMessage:     userMsg.Content,
 		History:         history,
-		SelectedText:    req.SelectedText,
+		SelectedText:    selectedText,
+		Temperature:     req.Temperature,
+		Model:           req.Model,
 	}, func(chunk string) error {
 		if strings.TrimSpace(chunk) == "" {
 			return nil
@@ -114,43 +163,32 @@ func CreateChatMessage(w http.ResponseWriter, r *http.Request) {
 
 	if len(result.ToolCalls) > 0 {
 		// One AI text message for the whole turn.
-		aiMsg, err := chats.AppendChatMessage(translationID, translation.ChatRoleAI, "Here's a practice card for you:", "")
+		aiMsg, err := chats.AppendChatMessage(translationID, translation.ChatRoleAI, toolTurnIntro(result.ToolCalls), "")
 		if err != nil {
 			emitSSE(w, map[string]any{"type": "error", "message": err.Error()})
 			flusher.Flush()
 			return
 		}
 
-		// One tool message per tool call — each owns its own review card.
+		// One tool message per tool call — each owns its own review card or
+		// grammar note.
 		toolResults := make([]map[string]any, 0, len(result.ToolCalls))
 		for _, tc := range result.ToolCalls {
-			if tc.Name != "create_review_card" {
+			var toolResult map[string]any
+			switch tc.Name {
+			case "create_review_card":
+				toolResult, err = storeReviewCardCall(translationID, tc)
+			case "explain_grammar":
+				toolResult, err = storeGrammarNoteCall(translationID, tc)
+			default:
 				continue
 			}
-			chineseText, _ := tc.Arguments["chinese_text"].(string)
-			pinyin, _ := tc.Arguments["pinyin"].(string)
-			english, _ := tc.Arguments["english"].(string)
-
-			toolMsg, err := chats.AppendChatMessage(translationID, translation.ChatRoleTool, chineseText, "")
 			if err != nil {
 				emitSSE(w, map[string]any{"type": "error", "message": err.Error()})
 				flusher.Flush()
 				return
 			}
-			if err := chats.SetReviewCard(toolMsg.ID, chineseText, pinyin, english); err != nil {
-				emitSSE(w, map[string]any{"type": "error", "message": err.Error()})
-				flusher.Flush()
-				return
-			}
-			toolResults = append(toolResults, map[string]any{
-				"message_id": toolMsg.ID,
-				"review_card": translation.ChatReviewCard{
-					ChineseText: chineseText,
-					Pinyin:      pinyin,
-					English:     english,
-					Status:      "pending",
-				},
-			})
+			toolResults = append(toolResults, toolResult)
 		}
 		emitSSE(w, map[string]any{
 			"type":         "complete",
@@ -177,24 +215,85 @@ func CreateChatMessage(w http.ResponseWriter, r *http.Request) {
 	flusher.Flush()
 }
 
+// toolTurnIntro is the AI message stored ahead of a turn's tool results.
+func toolTurnIntro(calls []intelligence.ToolCallResult) string {
+	for _, tc := range calls {
+		if tc.Name == "create_review_card" {
+			return "Here's a practice card for you:"
+		}
+	}
+	return "Here's how this grammar point works:"
+}
+
+// storeReviewCardCall saves a create_review_card call as a tool message with
+// a pending review card.
+func storeReviewCardCall(translationID string, tc intelligence.ToolCallResult) (map[string]any, error) {
+	chineseText, _ := tc.Arguments["chinese_text"].(string)
+	pinyin, _ := tc.Arguments["pinyin"].(string)
+	english, _ := tc.Arguments["english"].(string)
+
+	toolMsg, err := chats.AppendChatMessage(translationID, translation.ChatRoleTool, chineseText, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := chats.SetReviewCard(translationID, toolMsg.ID, chineseText, pinyin, english); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"message_id": toolMsg.ID,
+		"review_card": translation.ChatReviewCard{
+			ChineseText: chineseText,
+			Pinyin:      pinyin,
+			English:     english,
+			Status:      "pending",
+		},
+	}, nil
+}
+
+// storeGrammarNoteCall saves an explain_grammar call as a tool message with
+// its grammar note.
+func storeGrammarNoteCall(translationID string, tc intelligence.ToolCallResult) (map[string]any, error) {
+	note := translation.ChatGrammarNote{Examples: []string{}}
+	note.Point, _ = tc.Arguments["point"].(string)
+	note.Explanation, _ = tc.Arguments["explanation"].(string)
+	examples, _ := tc.Arguments["examples"].([]any)
+	for _, example := range examples {
+		if text, ok := example.(string); ok && strings.TrimSpace(text) != "" {
+			note.Examples = append(note.Examples, text)
+		}
+	}
+
+	toolMsg, err := chats.AppendChatMessage(translationID, translation.ChatRoleTool, note.Point, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := chats.SetGrammarNote(toolMsg.ID, note); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"message_id":   toolMsg.ID,
+		"grammar_note": note,
+	}, nil
+}
+
 func ListChatMessages(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	translationID := pathParam(r, "translation_id")
 	thread, err := chats.EnsureChatForTranslation(translationID)
 	if err != nil {
 		if err == translation.ErrNotFound {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+			WriteError(w, http.StatusNotFound, "Translation not found")
 			return
 		}
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	items, err := chats.ListChatMessages(translationID)
 	if err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	WriteJSON(w, http.StatusOK, chatListResponse{
@@ -203,99 +302,489 @@ func ListChatMessages(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ReplayChatTurn returns the final `complete` event of a chat turn so a
+// client that lost the chat/new stream can recover the reply and any review
+// cards. message_id may be the user message from the `start` event or the
+// reply itself.
+func ReplayChatTurn(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	messageID := pathParam(r, "message_id")
+	if _, ok := translations.Get(translationID); !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+	items, err := chats.ListChatMessages(translationID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	idx := -1
+	for i, msg := range items {
+		if msg.ID == messageID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		WriteError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if items[idx].Role == translation.ChatRoleUser {
+		idx++
+	}
+	if idx >= len(items) || items[idx].Role != translation.ChatRoleAI {
+		WriteError(w, http.StatusNotFound, "No reply stored for this message yet")
+		return
+	}
+
+	reply := items[idx]
+	event := map[string]any{
+		"type":       "complete",
+		"message_id": reply.ID,
+		"content":    reply.Content,
+	}
+	toolResults := make([]map[string]any, 0)
+	for _, msg := range items[idx+1:] {
+		if msg.Role != translation.ChatRoleTool {
+			break
+		}
+		switch {
+		case msg.ReviewCard != nil:
+			toolResults = append(toolResults, map[string]any{
+				"message_id":  msg.ID,
+				"review_card": msg.ReviewCard,
+			})
+		case msg.GrammarNote != nil:
+			toolResults = append(toolResults, map[string]any{
+				"message_id":   msg.ID,
+				"grammar_note": msg.GrammarNote,
+			})
+		}
+	}
+	if len(toolResults) > 0 {
+		event["tool_results"] = toolResults
+	}
+	WriteJSON(w, http.StatusOK, event)
+}
+
+// ExportChatMessages downloads the translation's chat thread as markdown.
+func ExportChatMessages(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	item, ok := translations.Get(translationID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+	items, err := chats.ListChatMessages(translationID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	title := strings.TrimSpace(item.Title)
+	if title == "" {
+		title = strings.TrimSpace(item.InputText)
+		if runes := []rune(title); len(runes) > 40 {
+			title = string(runes[:40]) + "…"
+		}
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"language_app_chat_%s.md\"", translationID))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(translation.FormatChatMarkdown(title, items)))
+}
+
 func ClearChatMessages(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	translationID := pathParam(r, "translation_id")
 	if err := chats.ClearChatMessages(translationID); err != nil {
 		if err == translation.ErrNotFound {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+			WriteError(w, http.StatusNotFound, "Translation not found")
 			return
 		}
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	WriteJSON(w, http.StatusOK, map[string]bool{"ok": true})
 }
 
+type editChatMessageRequest struct {
+	Message string `json:"message"`
+}
+
+// findChatMessage returns the message with messageID from the translation's
+// chat, writing the error response itself when it cannot.
+func findChatMessage(w http.ResponseWriter, translationID string, messageID string) (translation.ChatMessage, []translation.ChatMessage, bool) {
+	items, err := chats.ListChatMessages(translationID)
+	if err != nil {
+		writeStoreError(w, err)
+		return translation.ChatMessage{}, nil, false
+	}
+	for _, msg := range items {
+		if msg.ID != messageID {
+			continue
+		}
+		if msg.Role != translation.ChatRoleUser {
+			WriteError(w, http.StatusBadRequest, "Only user messages can be edited or resent")
+			return translation.ChatMessage{}, nil, false
+		}
+		return msg, items, true
+	}
+	WriteError(w, http.StatusNotFound, "Message not found")
+	return translation.ChatMessage{}, nil, false
+}
+
+// EditChatMessage rewrites the content of a user message. The rest of the
+// thread is left alone until the message is resent.
+func EditChatMessage(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	messageID := pathParam(r, "message_id")
+	if _, ok := translations.Get(translationID); !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+
+	var req editChatMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		WriteError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+
+	msg, _, ok := findChatMessage(w, translationID, messageID)
+	if !ok {
+		return
+	}
+	if err := chats.UpdateChatMessageContent(translationID, messageID, req.Message); err != nil {
+		if err == translation.ErrNotFound {
+			WriteError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+	msg.Content = req.Message
+	WriteJSON(w, http.StatusOK, msg)
+}
+
+// ResendChatMessage drops every message after a user message and streams a
+// fresh AI turn for it, exactly as chat/new would. The optional body carries
+// the same temperature and model overrides.
+func ResendChatMessage(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	messageID := pathParam(r, "message_id")
+	item, exists := translations.Get(translationID)
+	if !exists {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+
+	var req createChatMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if detail := normalizeChatOverrides(&req); detail != "" {
+		WriteError(w, http.StatusBadRequest, detail)
+		return
+	}
+
+	userMsg, items, ok := findChatMessage(w, translationID, messageID)
+	if !ok {
+		return
+	}
+	if err := chats.DeleteChatMessagesAfter(translationID, userMsg.MessageIdx); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	history := make([]translation.ChatMessage, 0, len(items))
+	for _, msg := range items {
+		if msg.MessageIdx <= userMsg.MessageIdx {
+			history = append(history, msg)
+		}
+	}
+
+	streamChatTurn(w, r, item, userMsg.ChatID, userMsg, history, req)
+}
+
 func AcceptReviewCard(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	translationID := pathParam(r, "translation_id")
 	messageID := pathParam(r, "message_id")
 
-	card, err := chats.GetMessageReviewCard(messageID)
+	card, err := chats.GetMessageReviewCard(translationID, messageID)
 	if err != nil {
 		if err == translation.ErrNotFound {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Message not found"})
+			WriteError(w, http.StatusNotFound, "Message not found")
 			return
 		}
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	if card == nil {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "No review card on this message"})
+		WriteError(w, http.StatusNotFound, "No review card on this message")
 		return
 	}
 	if card.Status == "accepted" {
-		WriteJSON(w, http.StatusConflict, map[string]string{"detail": "Review card already accepted"})
+		WriteError(w, http.StatusConflict, "Review card already accepted")
 		return
 	}
 
 	deduplicated := false
-	existingItems, err := srs.GetSegmentSRSInfo([]string{card.ChineseText})
+	existingItems, err := srs.GetSegmentSRSInfo(currentUserID(r), []string{card.ChineseText})
 	if err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	if len(existingItems) > 0 {
 		deduplicated = true
 	} else {
-		if _, err := srs.SaveSegment(card.ChineseText, card.Pinyin, card.English, &translationID, nil, "learning"); err != nil {
-			WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		if _, err := srs.SaveSegment(currentUserID(r), card.ChineseText, card.Pinyin, card.English, &translationID, nil, "learning"); err != nil {
+			writeStoreError(w, err)
 			return
 		}
 	}
 
-	if err := chats.AcceptMessageReviewCard(messageID); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	if err := chats.AcceptMessageReviewCard(translationID, messageID); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "deduplicated": deduplicated})
 }
 
+// UpdateReviewCard edits a pending review card. Omitted fields keep their
+// current value.
+func UpdateReviewCard(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	messageID := pathParam(r, "message_id")
+
+	var req updateReviewCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	card, err := chats.GetMessageReviewCard(translationID, messageID)
+	if err != nil {
+		if err == translation.ErrNotFound {
+			WriteError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+	if card == nil {
+		WriteError(w, http.StatusNotFound, "No review card on this message")
+		return
+	}
+	if req.ChineseText != nil {
+		card.ChineseText = *req.ChineseText
+	}
+	if req.Pinyin != nil {
+		card.Pinyin = *req.Pinyin
+	}
+	if req.English != nil {
+		card.English = *req.English
+	}
+
+	if err := chats.UpdateMessageReviewCard(translationID, messageID, card.ChineseText, card.Pinyin, card.English); err != nil {
+		switch err {
+		case translation.ErrNotFound:
+			WriteError(w, http.StatusNotFound, "No review card on this message")
+		case translation.ErrReviewCardAccepted:
+			WriteError(w, http.StatusConflict, "Cannot edit an already accepted review card")
+		default:
+			WriteError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	updated, err := chats.GetMessageReviewCard(translationID, messageID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "review_card": updated})
+}
+
+// RegenerateReviewCard asks the chat model for a fresh practice sentence covering
+// the same material as a pending review card, replaces the card in place, and
+// streams the result over SSE.
+func RegenerateReviewCard(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	messageID := pathParam(r, "message_id")
+
+	item, exists := translations.Get(translationID)
+	if !exists {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+	card, err := chats.GetMessageReviewCard(translationID, messageID)
+	if err != nil {
+		if err == translation.ErrNotFound {
+			WriteError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+	if card == nil {
+		WriteError(w, http.StatusNotFound, "No review card on this message")
+		return
+	}
+	if card.Status == "accepted" {
+		WriteError(w, http.StatusConflict, "Cannot regenerate an already accepted review card")
+		return
+	}
+
+	history, err := chats.ListChatMessages(translationID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		emitSSE(w, map[string]any{"type": "error", "message": "Streaming is not supported"})
+		return
+	}
+
+	emitSSE(w, map[string]any{
+		"type":           "start",
+		"translation_id": translationID,
+		"message_id":     messageID,
+	})
+	flusher.Flush()
+
+	prompt := fmt.Sprintf(
+		"Create a new review card with a different practice sentence that targets the same word as this card: %s (%s) - %s. Use the create_review_card function.",
+		card.ChineseText, card.Pinyin, card.English,
+	)
+	result, err := chatProvider.ChatWithTranslationContext(r.Context(), intelligence.ChatWithTranslationRequest{
+		TranslationText: item.InputText,
+		UserMessage:     prompt,
+		History:         history,
+		SelectedText:    card.ChineseText,
+	}, nil, func(toolName string) {
+		emitSSE(w, map[string]any{
+			"type":      "tool_call_start",
+			"tool_name": toolName,
+		})
+		flusher.Flush()
+	})
+	if err != nil {
+		emitSSE(w, map[string]any{"type": "error", "message": err.Error()})
+		flusher.Flush()
+		return
+	}
+
+	var regenerated *translation.ChatReviewCard
+	for _, tc := range result.ToolCalls {
+		if tc.Name != "create_review_card" {
+			continue
+		}
+		chineseText, _ := tc.Arguments["chinese_text"].(string)
+		pinyin, _ := tc.Arguments["pinyin"].(string)
+		english, _ := tc.Arguments["english"].(string)
+		if strings.TrimSpace(chineseText) == "" {
+			continue
+		}
+		regenerated = &translation.ChatReviewCard{
+			ChineseText: chineseText,
+			Pinyin:      pinyin,
+			English:     english,
+			Status:      "pending",
+		}
+		break
+	}
+	if regenerated == nil {
+		emitSSE(w, map[string]any{"type": "error", "message": "Model did not produce a review card"})
+		flusher.Flush()
+		return
+	}
+	if err := chats.SetReviewCard(translationID, messageID, regenerated.ChineseText, regenerated.Pinyin, regenerated.English); err != nil {
+		emitSSE(w, map[string]any{"type": "error", "message": err.Error()})
+		flusher.Flush()
+		return
+	}
+
+	emitSSE(w, map[string]any{
+		"type":        "complete",
+		"message_id":  messageID,
+		"review_card": regenerated,
+	})
+	flusher.Flush()
+}
+
 func RejectReviewCard(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
+	translationID := pathParam(r, "translation_id")
 	messageID := pathParam(r, "message_id")
 
-	card, err := chats.GetMessageReviewCard(messageID)
+	card, err := chats.GetMessageReviewCard(translationID, messageID)
 	if err != nil {
 		if err == translation.ErrNotFound {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Message not found"})
+			WriteError(w, http.StatusNotFound, "Message not found")
 			return
 		}
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	if card == nil {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "No review card on this message"})
+		WriteError(w, http.StatusNotFound, "No review card on this message")
 		return
 	}
 	if card.Status == "accepted" {
-		WriteJSON(w, http.StatusConflict, map[string]string{"detail": "Cannot reject an already accepted review card"})
+		WriteError(w, http.StatusConflict, "Cannot reject an already accepted review card")
 		return
 	}
 
-	if err := chats.RejectMessageReviewCard(messageID); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	if err := chats.RejectMessageReviewCard(translationID, messageID); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/anath2/language-app/internal/intelligence"
 	"github.com/anath2/language-app/internal/queue"
@@ -9,13 +11,40 @@ import (
 )
 
 type translationStore interface {
-	Create(inputText string, sourceType string) (translation.Translation, error)
-	List(limit int, offset int, status string) ([]translation.Translation, int, error)
+	CreateWithLanguagePair(inputText string, sourceType string, lang translation.LanguagePair) (translation.Translation, error)
+	CreateWithMetadata(inputText string, sourceType string, lang translation.LanguagePair, metadata map[string]string) (translation.Translation, error)
+	CreateForUser(userID string, inputText string, sourceType string, lang translation.LanguagePair, metadata map[string]string, priority int) (translation.Translation, error)
+	CreateWithIdempotencyKey(userID string, key string, window time.Duration, inputText string, sourceType string, lang translation.LanguagePair, metadata map[string]string, priority int) (translation.Translation, bool, error)
+	CreateChunkedForUser(userID string, chunks []string, sourceType string, lang translation.LanguagePair, metadata map[string]string, priority int) ([]translation.Translation, error)
+	ListChunks(groupID string) ([]translation.ChunkPart, error)
+	List(limit int, offset int, filter translation.ListFilter) ([]translation.Translation, int, error)
+	ListForUser(userID string, limit int, offset int, filter translation.ListFilter) ([]translation.Translation, int, error)
+	Neighbors(userID string, id string, filter translation.ListFilter) (*translation.TranslationRef, *translation.TranslationRef, error)
 	Get(id string) (translation.Translation, bool)
+	GetStatusOnly(id string) (status string, progress, total int, ok bool)
+	GetOwner(id string) (string, error)
 	Delete(id string) bool
 	UpdateTranslationSegments(translationID string, sentenceIdx int, segments []translation.SegmentResult) error
+	UpdateSingleSegment(translationID string, sentenceIdx, segIdx int, result translation.SegmentResult) error
+	MergeSegments(translationID string, sentenceIdx int, fromSegIdx, toSegIdx int) ([]translation.SegmentResult, error)
+	GetSegmentationOverride(userID string, sentence string, lang translation.LanguagePair) ([]string, bool, error)
 	UpdateTitle(id string, title string) error
+	SetFavorite(id string, fav bool) error
+	AddTag(translationID string, tag string) error
+	RemoveTag(translationID string, tag string) error
+	ListTags(translationID string) ([]string, error)
 	UpdateInputTextForReprocessing(id string, newText string) (map[int]string, error)
+	CountByStatus() (map[string]int, error)
+	CountJobsByState() (translation.JobStateCounts, error)
+	GetJobFailure(translationID string) (translation.JobFailure, error)
+	SetSegmentNote(translationID string, sentenceIdx int, segIdx int, note string) (translation.SegmentNote, error)
+	GetSegmentNote(translationID string, sentenceIdx int, segIdx int) (translation.SegmentNote, error)
+	DeleteSegmentNote(translationID string, sentenceIdx int, segIdx int) error
+	ListCustomDictionary(userID string) ([]translation.CustomDictionaryEntry, error)
+	GetCustomDictionaryEntry(userID string, headword string) (translation.CustomDictionaryEntry, error)
+	SaveCustomDictionaryEntry(userID string, entry translation.CustomDictionaryEntry) (translation.CustomDictionaryEntry, error)
+	DeleteCustomDictionaryEntry(userID string, headword string) error
+	ApplyCustomDictionary(userID string, results []translation.SegmentResult, lang translation.LanguagePair) error
 }
 
 type chatStore interface {
@@ -23,33 +52,62 @@ type chatStore interface {
 	AppendChatMessage(translationID string, role string, content string, selectedText string) (translation.ChatMessage, error)
 	ListChatMessages(translationID string) ([]translation.ChatMessage, error)
 	ClearChatMessages(translationID string) error
-	SetReviewCard(messageID, chineseText, pinyin, english string) error
-	GetMessageReviewCard(messageID string) (*translation.ChatReviewCard, error)
-	AcceptMessageReviewCard(messageID string) error
-	RejectMessageReviewCard(messageID string) error
+	UpdateChatMessageContent(translationID string, messageID string, content string) error
+	DeleteChatMessagesAfter(translationID string, messageIdx int) error
+	SetReviewCard(translationID, messageID, chineseText, pinyin, english string) error
+	SetGrammarNote(messageID string, note translation.ChatGrammarNote) error
+	GetMessageReviewCard(translationID, messageID string) (*translation.ChatReviewCard, error)
+	UpdateMessageReviewCard(translationID, messageID, chineseText, pinyin, english string) error
+	AcceptMessageReviewCard(translationID, messageID string) error
+	RejectMessageReviewCard(translationID, messageID string) error
 }
 
 type srsStore interface {
-	SaveSegment(headword string, pinyin string, english string, translationID *string, snippet *string, status string) (string, error)
-	UpdateSegmentStatus(segmentID string, status string) error
-	UpdateCharacterStatus(characterID string, status string) error
-	RecordLookup(segmentID string) (translation.SegmentSRSInfo, bool)
-	GetSegmentSRSInfo(headwords []string) ([]translation.SegmentSRSInfo, error)
-	GetSegmentReviewQueue(limit int) ([]translation.SegmentReviewCard, error)
-	GetSegmentDueCount() int
-	RecordReviewAnswer(entityID string, entityType string, grade int) (translation.ReviewAnswerResult, bool, error)
-	CountSegmentsByStatus(status string) int
-	CountTotalSegments() int
-	ExportProgressJSON() (string, error)
-	ImportProgressJSON(input string) (map[string]int, error)
-	ExtractAndLinkCharacters(segmentID string, segment string, segmentPinyin string, segmentEnglish string, charData []translation.CharTranslation) error
-	GetCharacterReviewQueue(limit int) ([]translation.CharacterReviewCard, error)
-	GetCharacterDueCount() int
+	SaveSegment(userID string, headword string, pinyin string, english string, translationID *string, snippet *string, status string) (string, error)
+	UpdateSegmentStatus(userID string, segmentID string, status string) error
+	UpdateCharacterStatus(userID string, characterID string, status string) error
+	RecordLookup(userID string, segmentID string) (translation.SegmentSRSInfo, bool)
+	GetSegmentSRSInfo(userID string, headwords []string) ([]translation.SegmentSRSInfo, error)
+	GetSegmentReviewQueue(userID string, limit int, order string, hskLevel int) ([]translation.SegmentReviewCard, error)
+	GetClozeCard(userID string, segmentID string) (translation.ClozeCard, bool)
+	GetSegmentDueCount(userID string) int
+	RecordReviewAnswer(userID string, entityID string, entityType string, grade int) (translation.ReviewAnswerResult, bool, error)
+	UndoLastReview(userID string, entityID string, entityType string) (bool, error)
+	GetSRSStats(userID string, days int) (translation.SRSStats, error)
+	GetStudyStreak(userID string) (current, longest int, err error)
+	ComputeDifficulty(userID string, translationID string) (translation.DifficultyBreakdown, error)
+	CountSegmentsByStatus(userID string, status string) int
+	CountTotalSegments(userID string) int
+	ExportProgressJSON(userID string) (string, error)
+	ImportVocabCSV(userID string, input string) (translation.VocabImportResult, error)
+	ImportProgressJSON(userID string, input string) (map[string]int, error)
+	ValidateProgressJSON(userID string, input string) (map[string]int, error)
+	MergeProgressJSON(userID string, input string) (map[string]int, error)
+	ExtractAndLinkCharacters(userID string, segmentID string, segment string, segmentPinyin string, segmentEnglish string, charData []translation.CharTranslation) error
+	GetCharacterReviewQueue(userID string, limit int) ([]translation.CharacterReviewCard, error)
+	GetCharacterDueCount(userID string) int
+	GetWordsForCharacter(userID string, characterID string) ([]translation.SegmentRecord, error)
+	ListRecentLookups(userID string, limit int) ([]translation.RecentLookup, error)
 }
 
 type profileStore interface {
-	GetUserProfile() (translation.UserProfile, bool)
-	UpsertUserProfile(name string, email string, language string) (translation.UserProfile, error)
+	GetUserProfile(userID string) (translation.UserProfile, bool)
+	UpsertUserProfile(userID string, name string, email string, language string, timezone string) (translation.UserProfile, error)
+}
+
+type backupStore interface {
+	Backup(ctx context.Context) (string, error)
+}
+
+type maintenanceStore interface {
+	CheckIntegrity(ctx context.Context) (translation.IntegrityReport, error)
+	CheckpointWAL(ctx context.Context) (translation.WALCheckpoint, error)
+}
+
+type userStore interface {
+	CreateUser(username string, password string) (translation.User, error)
+	Authenticate(username string, password string) (translation.User, error)
+	ListUsers() ([]translation.User, error)
 }
 
 var translations translationStore
@@ -60,6 +118,19 @@ var jobQueue *queue.Manager
 var transProvider intelligence.TranslationProvider
 var chatProvider intelligence.ChatProvider
 
+// users is optional: without it only the shared password can sign in.
+var users userStore
+
+// backups is optional: the backup endpoint reports 503 when it is nil.
+var backups backupStore
+
+// maintenance is optional: the database check endpoint reports 503 when it
+// is nil.
+var maintenance maintenanceStore
+
+// dictionary is optional: dictionary endpoints report 503 when it is nil.
+var dictionary intelligence.DictionaryProvider
+
 func ConfigureDependencies(
 	ts translationStore,
 	cs chatStore,
@@ -68,6 +139,7 @@ func ConfigureDependencies(
 	manager *queue.Manager,
 	tp intelligence.TranslationProvider,
 	cp intelligence.ChatProvider,
+	dp intelligence.DictionaryProvider,
 ) {
 	translations = ts
 	chats = cs
@@ -76,6 +148,19 @@ func ConfigureDependencies(
 	jobQueue = manager
 	transProvider = tp
 	chatProvider = cp
+	dictionary = dp
+}
+
+func ConfigureUsers(us userStore) {
+	users = us
+}
+
+func ConfigureBackups(bs backupStore) {
+	backups = bs
+}
+
+func ConfigureMaintenance(ms maintenanceStore) {
+	maintenance = ms
 }
 
 func validateDependencies() error {
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anath2/language-app/internal/intelligence"
+	"github.com/anath2/language-app/internal/logging"
+	"github.com/anath2/language-app/internal/pinyin"
+	"github.com/anath2/language-app/internal/translation"
+)
+
+type dictionaryLookupRequest struct {
+	Word string `json:"word"`
+}
+
+type dictEntryResponse struct {
+	Traditional    string   `json:"traditional"`
+	Simplified     string   `json:"simplified"`
+	Pinyin         string   `json:"pinyin"`
+	PinyinNumbered string   `json:"pinyin_numbered"`
+	Definitions    []string `json:"definitions"`
+}
+
+// dictSenseResponse is one meaning of a word with the reading it belongs to.
+type dictSenseResponse struct {
+	Pinyin     string `json:"pinyin"`
+	Definition string `json:"definition"`
+}
+
+type dictionaryLookupResponse struct {
+	Word    string              `json:"word"`
+	Entries []dictEntryResponse `json:"entries"`
+	Senses  []dictSenseResponse `json:"senses"`
+}
+
+// LookupDictionaryWord returns every dictionary entry for a typed word, plus
+// every sense across them flattened into one list. A word that is not in the
+// dictionary yields empty lists, not a 404.
+func LookupDictionaryWord(w http.ResponseWriter, r *http.Request) {
+	if dictionary == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Dictionary is not available")
+		return
+	}
+	var req dictionaryLookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	word := strings.TrimSpace(req.Word)
+	if word == "" {
+		WriteError(w, http.StatusBadRequest, "word is required")
+		return
+	}
+
+	entries, _ := dictionary.LookupWord(word)
+	WriteJSON(w, http.StatusOK, dictionaryLookupResponse{
+		Word:    word,
+		Entries: toDictEntryResponses(entries),
+		Senses:  toDictSenseResponses(entries),
+	})
+}
+
+// toDictSenseResponses lists each definition under its tone-marked reading.
+// Classifier notes ("CL:...") stay in the entries' definitions but are not
+// senses of their own.
+func toDictSenseResponses(entries []intelligence.DictEntry) []dictSenseResponse {
+	out := make([]dictSenseResponse, 0, len(entries))
+	for _, entry := range entries {
+		for _, def := range entry.Definitions {
+			if strings.HasPrefix(def, "CL:") {
+				continue
+			}
+			out = append(out, dictSenseResponse{Pinyin: entry.Pinyin, Definition: def})
+		}
+	}
+	return out
+}
+
+func toDictEntryResponses(entries []intelligence.DictEntry) []dictEntryResponse {
+	out := make([]dictEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, dictEntryResponse{
+			Traditional:    entry.Traditional,
+			Simplified:     entry.Simplified,
+			Pinyin:         entry.Pinyin,
+			PinyinNumbered: entry.PinyinNumbered,
+			Definitions:    entry.Definitions,
+		})
+	}
+	return out
+}
+
+type dictionarySearchResponse struct {
+	Prefix  string              `json:"prefix"`
+	Entries []dictEntryResponse `json:"entries"`
+}
+
+// SearchDictionary serves prefix autocomplete over dictionary headwords.
+func SearchDictionary(w http.ResponseWriter, r *http.Request) {
+	if dictionary == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Dictionary is not available")
+		return
+	}
+	prefix := strings.TrimSpace(r.URL.Query().Get("prefix"))
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 20)
+
+	entries, err := dictionary.SearchDictionaryPrefix(prefix, limit)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusOK, dictionarySearchResponse{
+		Prefix:  prefix,
+		Entries: toDictEntryResponses(entries),
+	})
+}
+
+// Limits on custom dictionary entries, in characters.
+const (
+	maxCustomHeadwordRunes = 32
+	maxCustomPinyinRunes   = 128
+	maxCustomEnglishRunes  = 500
+)
+
+type customDictionaryRequest struct {
+	Headword string `json:"headword"`
+	Pinyin   string `json:"pinyin"`
+	English  string `json:"english"`
+}
+
+type customDictionaryEntryResponse struct {
+	Headword  string `json:"headword"`
+	Pinyin    string `json:"pinyin"`
+	English   string `json:"english"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type customDictionaryListResponse struct {
+	Entries []customDictionaryEntryResponse `json:"entries"`
+}
+
+func toCustomDictionaryEntryResponse(entry translation.CustomDictionaryEntry) customDictionaryEntryResponse {
+	return customDictionaryEntryResponse{
+		Headword:  entry.Headword,
+		Pinyin:    entry.Pinyin,
+		English:   entry.English,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}
+}
+
+// writeCustomDictionaryError reports a missing entry as a 404.
+func writeCustomDictionaryError(w http.ResponseWriter, err error) {
+	if errors.Is(err, translation.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Custom dictionary entry not found")
+		return
+	}
+	writeStoreError(w, err)
+}
+
+// ListCustomDictionary returns the signed-in user's custom dictionary.
+func ListCustomDictionary(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	entries, err := translations.ListCustomDictionary(currentUserID(r))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	out := make([]customDictionaryEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, toCustomDictionaryEntryResponse(entry))
+	}
+	WriteJSON(w, http.StatusOK, customDictionaryListResponse{Entries: out})
+}
+
+// SaveCustomDictionaryEntry creates or replaces the signed-in user's entry
+// for a headword. Its gloss, and its pinyin when given, take the place of
+// CEDICT's and the model's in the user's later translations. Numbered
+// pinyin is converted to tone marks.
+func SaveCustomDictionaryEntry(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var req customDictionaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	entry := translation.CustomDictionaryEntry{
+		Headword: strings.TrimSpace(req.Headword),
+		Pinyin:   pinyin.NumberedToToneMarks(strings.TrimSpace(req.Pinyin)),
+		English:  strings.TrimSpace(req.English),
+	}
+	switch {
+	case entry.Headword == "":
+		WriteError(w, http.StatusBadRequest, "headword is required")
+		return
+	case entry.English == "":
+		WriteError(w, http.StatusBadRequest, "english is required")
+		return
+	case utf8.RuneCountInString(entry.Headword) > maxCustomHeadwordRunes:
+		WriteError(w, http.StatusBadRequest, "headword must be at most 32 characters")
+		return
+	case utf8.RuneCountInString(entry.Pinyin) > maxCustomPinyinRunes:
+		WriteError(w, http.StatusBadRequest, "pinyin must be at most 128 characters")
+		return
+	case utf8.RuneCountInString(entry.English) > maxCustomEnglishRunes:
+		WriteError(w, http.StatusBadRequest, "english must be at most 500 characters")
+		return
+	}
+
+	saved, err := translations.SaveCustomDictionaryEntry(currentUserID(r), entry)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, toCustomDictionaryEntryResponse(saved))
+}
+
+func GetCustomDictionaryEntry(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	entry, err := translations.GetCustomDictionaryEntry(currentUserID(r), pathParam(r, "headword"))
+	if err != nil {
+		writeCustomDictionaryError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, toCustomDictionaryEntryResponse(entry))
+}
+
+// DeleteCustomDictionaryEntry removes an entry. Translations already made
+// keep the gloss they were given.
+func DeleteCustomDictionaryEntry(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := translations.DeleteCustomDictionaryEntry(currentUserID(r), pathParam(r, "headword")); err != nil {
+		writeCustomDictionaryError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// applyCustomDictionary glosses results from the signed-in user's custom
+// dictionary. A failed lookup leaves the results as they were.
+func applyCustomDictionary(r *http.Request, results []translation.SegmentResult, lang translation.LanguagePair) {
+	if err := translations.ApplyCustomDictionary(currentUserID(r), results, lang); err != nil {
+		logging.Printf(r.Context(), "custom dictionary lookup failed: err=%v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+// Error codes are stable strings clients can branch on; Message (sent as
+// detail) is for display and may change.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeTooLarge       = "too_large"
+	CodeRateLimited    = "rate_limited"
+	CodeDBBusy         = "db_busy"
+	CodeUpstream       = "upstream_error"
+	CodeUnavailable    = "unavailable"
+	CodeNotImplemented = "not_implemented"
+	CodeInternal       = "internal"
+)
+
+// APIError is the body of every error response.
+type APIError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"detail"`
+}
+
+func (e APIError) Error() string {
+	return e.Message
+}
+
+// WriteError writes an error response whose code follows from the status.
+// Use WriteAPIError when a status covers more than one failure clients need
+// to tell apart.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteAPIError(w, APIError{Status: status, Code: codeForStatus(status), Message: message})
+}
+
+func WriteAPIError(w http.ResponseWriter, apiErr APIError) {
+	WriteJSON(w, apiErr.Status, apiErr)
+}
+
+// writeStoreError reports a failed store or dependency call: missing rows
+// are 404 and a database still locked after the store's retries is a
+// retryable 503. Anything else is a 500.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, translation.ErrNotFound):
+		WriteAPIError(w, APIError{Status: http.StatusNotFound, Code: CodeNotFound, Message: err.Error()})
+	case translation.IsDBBusy(err):
+		WriteAPIError(w, APIError{Status: http.StatusServiceUnavailable, Code: CodeDBBusy, Message: "Database is busy, try again"})
+	default:
+		WriteAPIError(w, APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: err.Error()})
+	}
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+func TestWriteStoreErrorMapsCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "not found", err: fmt.Errorf("load: %w", translation.ErrNotFound), status: http.StatusNotFound, code: CodeNotFound},
+		{name: "locked", err: errors.New("update: database is locked (5) (SQLITE_BUSY)"), status: http.StatusServiceUnavailable, code: CodeDBBusy},
+		{name: "other", err: errors.New("disk I/O error"), status: http.StatusInternalServerError, code: CodeInternal},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			writeStoreError(res, tc.err)
+			var body APIError
+			if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if res.Code != tc.status || body.Code != tc.code || body.Message == "" {
+				t.Fatalf("got status=%d body=%s, want status=%d code=%q", res.Code, res.Body.String(), tc.status, tc.code)
+			}
+		})
+	}
+}
+
+func TestWriteErrorDerivesCodeFromStatus(t *testing.T) {
+	res := httptest.NewRecorder()
+	WriteError(res, http.StatusBadRequest, "input_text is required")
+	if got := res.Body.String(); got != `{"code":"invalid_request","detail":"input_text is required"}`+"\n" {
+		t.Fatalf("unexpected body: %q", got)
+	}
+}
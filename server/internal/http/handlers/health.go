@@ -1,7 +1,76 @@
 package handlers
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
 
+// readinessTimeout bounds each readiness check so a hung dependency cannot
+// hang the probe.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether one dependency is usable.
+type ReadinessCheck func(ctx context.Context) error
+
+// readinessChecks are run by Ready, keyed by dependency name.
+var readinessChecks map[string]ReadinessCheck
+
+func ConfigureReadinessChecks(checks map[string]ReadinessCheck) {
+	readinessChecks = checks
+}
+
+type readinessCheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string                          `json:"status"`
+	Checks map[string]readinessCheckResult `json:"checks"`
+}
+
+// Health is the liveness probe: it only reports that the process is serving.
 func Health(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// Ready is the readiness probe. It runs every configured check concurrently
+// and returns 503 when any of them fails.
+func Ready(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(readinessChecks))
+	for name := range readinessChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]readinessCheckResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check ReadinessCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+			defer cancel()
+			if err := check(ctx); err != nil {
+				results[i] = readinessCheckResult{Status: "error", Error: err.Error()}
+				return
+			}
+			results[i] = readinessCheckResult{Status: "ok"}
+		}(i, readinessChecks[name])
+	}
+	wg.Wait()
+
+	resp := readinessResponse{Status: "ready", Checks: make(map[string]readinessCheckResult, len(names))}
+	status := http.StatusOK
+	for i, name := range names {
+		resp.Checks[name] = results[i]
+		if results[i].Status != "ok" {
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	WriteJSON(w, status, resp)
+}
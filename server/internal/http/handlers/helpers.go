@@ -1,21 +1,64 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode"
 
+	"github.com/anath2/language-app/internal/config"
 	"github.com/go-chi/chi/v5"
 )
 
+// WriteJSON sets the Content-Type before the status so the compression
+// middleware can pick it up; no Content-Length is set, as it would not match
+// a compressed body.
 func WriteJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// writeJSONWithETag writes a 200 JSON response tagged with a hash of its
+// body, or an empty 304 when If-None-Match already names that hash. Hashing
+// the whole body keeps the tag in step with anything that can still change,
+// such as an edited title.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 requires for GET.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func NotImplementedJSON(w http.ResponseWriter) {
-	WriteJSON(w, http.StatusNotImplemented, map[string]string{"detail": "not implemented yet"})
+	WriteError(w, http.StatusNotImplemented, "not implemented yet")
 }
 
 func parseIntDefault(raw string, fallback int) int {
@@ -33,6 +76,28 @@ func pathParam(r *http.Request, key string) string {
 	return chi.URLParam(r, key)
 }
 
+// countCJKRunes counts the Chinese, Japanese and Korean characters in text,
+// leaving out punctuation, whitespace and Latin text.
+func countCJKRunes(text string) int {
+	count := 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			count++
+		}
+	}
+	return count
+}
+
+// readingMinutes estimates the time to read chars characters, rounded up
+// and never less than a minute.
+func readingMinutes(chars int, charsPerMinute int) int {
+	if charsPerMinute <= 0 {
+		charsPerMinute = config.DefaultReadingCharsPerMinute
+	}
+	minutes := (chars + charsPerMinute - 1) / charsPerMinute
+	return max(minutes, 1)
+}
+
 func preview(text string, max int) string {
 	if max < 0 {
 		max = 0
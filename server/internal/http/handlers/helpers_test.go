@@ -50,3 +50,51 @@ func TestPreview(t *testing.T) {
 		})
 	}
 }
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc123"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: `"abc123"`, want: true},
+		{header: `W/"abc123"`, want: true},
+		{header: `"other", "abc123"`, want: true},
+		{header: `*`, want: true},
+		{header: `"other"`, want: false},
+		{header: ``, want: false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestReadingEstimate(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		cpm       int
+		wantChars int
+		wantMins  int
+	}{
+		{name: "counts runes not bytes", text: "你好，世界！", cpm: 250, wantChars: 4, wantMins: 1},
+		{name: "ignores latin text", text: "Hello 世界 2024", cpm: 250, wantChars: 2, wantMins: 1},
+		{name: "empty input still takes a minute", text: "", cpm: 250, wantChars: 0, wantMins: 1},
+		{name: "rounds up", text: "一二三四五六七", cpm: 3, wantChars: 7, wantMins: 3},
+		{name: "exact multiple", text: "一二三四五六", cpm: 3, wantChars: 6, wantMins: 2},
+		{name: "kana and hangul count", text: "ひらがなカタカナ한국어", cpm: 250, wantChars: 11, wantMins: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chars := countCJKRunes(tt.text)
+			if chars != tt.wantChars {
+				t.Fatalf("countCJKRunes(%q) = %d, want %d", tt.text, chars, tt.wantChars)
+			}
+			if got := readingMinutes(chars, tt.cpm); got != tt.wantMins {
+				t.Fatalf("readingMinutes(%d, %d) = %d, want %d", chars, tt.cpm, got, tt.wantMins)
+			}
+		})
+	}
+}
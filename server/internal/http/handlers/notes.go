@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+const maxSegmentNoteRunes = 2000
+
+type segmentNoteRequest struct {
+	Note string `json:"note"`
+}
+
+type segmentNoteResponse struct {
+	TranslationID string `json:"translation_id"`
+	SentenceIdx   int    `json:"sentence_idx"`
+	SegIdx        int    `json:"seg_idx"`
+	Segment       string `json:"segment"`
+	Note          string `json:"note"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+func toSegmentNoteResponse(note translation.SegmentNote) segmentNoteResponse {
+	return segmentNoteResponse{
+		TranslationID: note.TranslationID,
+		SentenceIdx:   note.SentenceIdx,
+		SegIdx:        note.SegIdx,
+		Segment:       note.Segment,
+		Note:          note.Note,
+		CreatedAt:     note.CreatedAt,
+		UpdatedAt:     note.UpdatedAt,
+	}
+}
+
+// segmentNotePath parses the segment a note route addresses, writing a 400
+// when the indexes are not valid.
+func segmentNotePath(w http.ResponseWriter, r *http.Request) (string, int, int, bool) {
+	sentenceIdx := parseIntDefault(pathParam(r, "sentence_idx"), -1)
+	segIdx := parseIntDefault(pathParam(r, "seg_idx"), -1)
+	if sentenceIdx < 0 || segIdx < 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid sentence or segment index")
+		return "", 0, 0, false
+	}
+	return pathParam(r, "translation_id"), sentenceIdx, segIdx, true
+}
+
+// writeSegmentNoteError reports a missing translation, segment or note as a
+// single 404, since the route cannot tell the client which was missing.
+func writeSegmentNoteError(w http.ResponseWriter, err error) {
+	if errors.Is(err, translation.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Segment note not found")
+		return
+	}
+	writeStoreError(w, err)
+}
+
+// SetSegmentNote saves the learner's note on a segment, replacing any
+// earlier one. Notes are keyed by the sentence's text, so they survive
+// edits that leave the sentence unchanged.
+func SetSegmentNote(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID, sentenceIdx, segIdx, ok := segmentNotePath(w, r)
+	if !ok {
+		return
+	}
+	var req segmentNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+	if note == "" {
+		WriteError(w, http.StatusBadRequest, "note is required")
+		return
+	}
+	if utf8.RuneCountInString(note) > maxSegmentNoteRunes {
+		WriteError(w, http.StatusBadRequest, "note must be at most 2000 characters")
+		return
+	}
+
+	saved, err := translations.SetSegmentNote(translationID, sentenceIdx, segIdx, note)
+	if errors.Is(err, translation.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Segment not found")
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, toSegmentNoteResponse(saved))
+}
+
+func GetSegmentNote(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID, sentenceIdx, segIdx, ok := segmentNotePath(w, r)
+	if !ok {
+		return
+	}
+	note, err := translations.GetSegmentNote(translationID, sentenceIdx, segIdx)
+	if err != nil {
+		writeSegmentNoteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, toSegmentNoteResponse(note))
+}
+
+func DeleteSegmentNote(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID, sentenceIdx, segIdx, ok := segmentNotePath(w, r)
+	if !ok {
+		return
+	}
+	if err := translations.DeleteSegmentNote(translationID, sentenceIdx, segIdx); err != nil {
+		writeSegmentNoteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
@@ -1,24 +1,178 @@
 package handlers
 
-import "net/http"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/anath2/language-app/internal/ocr"
+	"github.com/anath2/language-app/internal/translation"
+)
+
+// maxOCRImages caps how many images one extract-text request may carry.
+const maxOCRImages = 10
+
+// maxOCRImageBytes bounds each uploaded image and maxOCRRequestBytes the
+// whole extract-text request, so a multi-page upload cannot fill the disk
+// the multipart parser spills to.
+const (
+	maxOCRImageBytes   = 8 << 20
+	maxOCRRequestBytes = 32 << 20
+)
+
+// maxOCRPixels caps the dimensions of an image decoded for preprocessing.
+// Decoding allocates by the size the header declares, not the file size, so
+// a tiny file can otherwise ask for gigabytes.
+const maxOCRPixels = 40_000_000
+
+var errOCRImageTooLarge = errors.New("image dimensions exceed the OCR limit")
+
+type ocrImageResult struct {
+	Index    int    `json:"index"`
+	Filename string `json:"filename"`
+	Text     string `json:"text"`
+}
+
+// ocrOptions are the per-request extract-text form flags.
+type ocrOptions struct {
+	Preprocess bool // grayscale and contrast-stretch before OCR
+	Deskew     bool // also straighten rotated text; needs Preprocess
+	Debug      bool // log preprocessing stats
+}
+
+type extractTextResponse struct {
+	Text   string           `json:"text"`
+	Lang   string           `json:"lang"`
+	Images []ocrImageResult `json:"images"`
+}
+
+// ExtractText runs OCR on one or more uploaded images and joins their text.
+// lang is validated and echoed back, but the placeholder recognizer does not
+// use it yet, so it has no effect on the result.
 func ExtractText(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
+	r.Body = http.MaxBytesReader(w, r.Body, maxOCRRequestBytes)
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid multipart payload"})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Upload too large. Maximum size is %d MB per request.", maxOCRRequestBytes>>20))
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "Invalid multipart payload")
 		return
 	}
-	file, _, err := r.FormFile("image")
-	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Image file is required"})
+
+	lang := translation.LanguagePair{Source: strings.TrimSpace(r.FormValue("lang"))}.WithDefaults()
+	if err := lang.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, "unsupported lang "+strconv.Quote(lang.Source))
+		return
+	}
+
+	// images[] carries a multi-page upload; image is the single-file form.
+	files := append(r.MultipartForm.File["images[]"], r.MultipartForm.File["images"]...)
+	files = append(files, r.MultipartForm.File["image"]...)
+	if len(files) == 0 {
+		WriteError(w, http.StatusBadRequest, "Image file is required")
+		return
+	}
+	if len(files) > maxOCRImages {
+		WriteError(w, http.StatusBadRequest, "At most "+strconv.Itoa(maxOCRImages)+" images are allowed")
 		return
 	}
+	for _, header := range files {
+		if header.Size > maxOCRImageBytes {
+			WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Image %s too large. Maximum size is %d MB.", strconv.Quote(header.Filename), maxOCRImageBytes>>20))
+			return
+		}
+	}
+
+	opts := ocrOptions{
+		Preprocess: strings.EqualFold(r.FormValue("preprocess"), "true"),
+		Deskew:     strings.EqualFold(r.FormValue("deskew"), "true"),
+		Debug:      strings.EqualFold(r.FormValue("debug"), "true"),
+	}
+
+	results := make([]ocrImageResult, 0, len(files))
+	texts := make([]string, 0, len(files))
+	for i, header := range files {
+		text, err := extractImageText(header, lang.Source, opts)
+		if errors.Is(err, errOCRImageTooLarge) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "Image "+strconv.Quote(header.Filename)+" is too large. Maximum is "+strconv.Itoa(maxOCRPixels/1_000_000)+" megapixels.")
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Could not read image "+strconv.Quote(header.Filename))
+			return
+		}
+		results = append(results, ocrImageResult{Index: i, Filename: header.Filename, Text: text})
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, extractTextResponse{
+		Text:   strings.Join(texts, "\n"),
+		Lang:   lang.Source,
+		Images: results,
+	})
+}
+
+// extractImageText runs OCR on one uploaded image in the given source
+// language, preprocessing it first when requested.
+func extractImageText(header *multipart.FileHeader, lang string, opts ocrOptions) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxOCRImageBytes))
 	_ = file.Close()
+	if err != nil {
+		return "", err
+	}
+	if !opts.Preprocess {
+		return recognizeText(data, lang)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxOCRPixels {
+		return "", errOCRImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	gray, stats := ocr.Preprocess(img, ocr.Options{Contrast: true, Deskew: opts.Deskew})
+	var prepared bytes.Buffer
+	if err := png.Encode(&prepared, gray); err != nil {
+		return "", err
+	}
+	if opts.Debug {
+		log.Printf(
+			"ocr preprocess: file=%s contrast=%d-%d skew=%.1f",
+			header.Filename, stats.ContrastLow, stats.ContrastHigh, stats.SkewDegrees,
+		)
+	}
+	return recognizeText(prepared.Bytes(), lang)
+}
 
+// recognizeText sends image bytes to the OCR backend and returns the text.
+func recognizeText(data []byte, lang string) (string, error) {
 	// Intelligence layer deferred: return stable contract-compatible placeholder.
-	WriteJSON(w, http.StatusOK, map[string]string{"text": ""})
+	return "", nil
 }
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anath2/language-app/internal/intelligence"
+	"github.com/anath2/language-app/internal/pronunciation"
+	"github.com/anath2/language-app/internal/translation"
+)
+
+// maxTargetRunes caps the text a pronunciation attempt is scored against.
+const maxTargetRunes = 500
+
+// maxPronunciationAudioBytes bounds an uploaded recording; a minute of
+// compressed speech is well under it.
+const maxPronunciationAudioBytes = 10 << 20
+
+// speech is optional: pronunciation scoring reports 400 when it is nil.
+var speech intelligence.SpeechProvider
+
+func ConfigureSpeech(sp intelligence.SpeechProvider) {
+	speech = sp
+}
+
+type pronunciationScoreResponse struct {
+	Target     string                   `json:"target"`
+	Transcript string                   `json:"transcript"`
+	Score      float64                  `json:"score"`
+	Syllables  []pronunciation.Syllable `json:"syllables"`
+}
+
+// ScorePronunciation transcribes an uploaded recording and diffs it against
+// the segment or sentence the learner was reading.
+func ScorePronunciation(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if speech == nil {
+		WriteError(w, http.StatusBadRequest, "Speech-to-text is not configured")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPronunciationAudioBytes+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeAudioTooLarge(w)
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "Invalid multipart payload")
+		return
+	}
+	lang := translation.LanguagePair{Source: strings.TrimSpace(r.FormValue("lang"))}.WithDefaults()
+	if err := lang.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, "unsupported lang "+strconv.Quote(lang.Source))
+		return
+	}
+	target := strings.TrimSpace(r.FormValue("target"))
+	if target == "" {
+		WriteError(w, http.StatusBadRequest, "target is required")
+		return
+	}
+	if len([]rune(target)) > maxTargetRunes {
+		WriteError(w, http.StatusBadRequest, "target must be at most "+strconv.Itoa(maxTargetRunes)+" characters")
+		return
+	}
+	file, header, err := r.FormFile("audio")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Audio file is required")
+		return
+	}
+	audio, err := io.ReadAll(io.LimitReader(file, maxPronunciationAudioBytes+1))
+	_ = file.Close()
+	if err != nil || len(audio) == 0 {
+		WriteError(w, http.StatusBadRequest, "Could not read audio "+strconv.Quote(header.Filename))
+		return
+	}
+	if len(audio) > maxPronunciationAudioBytes {
+		writeAudioTooLarge(w)
+		return
+	}
+
+	transcript, err := speech.Transcribe(r.Context(), audio, header.Filename, lang.Source)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Failed to transcribe audio: "+err.Error())
+		return
+	}
+
+	result := pronunciation.Score(target, transcript, dictionaryPinyin)
+	WriteJSON(w, http.StatusOK, pronunciationScoreResponse{
+		Target:     target,
+		Transcript: transcript,
+		Score:      result.Score,
+		Syllables:  result.Syllables,
+	})
+}
+
+func writeAudioTooLarge(w http.ResponseWriter) {
+	WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Audio too large. Maximum size is %d MB.", maxPronunciationAudioBytes>>20))
+}
+
+// dictionaryPinyin reads a character's pinyin from its first dictionary
+// entry, so homophones and tone errors can be told apart.
+func dictionaryPinyin(char string) string {
+	if dictionary == nil {
+		return ""
+	}
+	entries, ok := dictionary.LookupWord(char)
+	if !ok {
+		return ""
+	}
+	return entries[0].PinyinNumbered
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// sseBufferSize bounds the events queued for one stream client. Progress
+// that does not fit is coalesced into a single catch_up event once the
+// client drains the buffer, rather than queued segment by segment.
+const sseBufferSize = 32
+
+// sseWriteTimeout disconnects a client that stops reading.
+const sseWriteTimeout = 30 * time.Second
+
+// sseStream writes events from its own goroutine so that polling for
+// progress never waits on a slow client.
+type sseStream struct {
+	events chan any
+	// done is closed when the writer exits, either after close or because
+	// a write to the client failed.
+	done chan struct{}
+}
+
+func newSSEStream(w http.ResponseWriter, flusher http.Flusher) *sseStream {
+	s := &sseStream{events: make(chan any, sseBufferSize), done: make(chan struct{})}
+	rc := http.NewResponseController(w)
+	go func() {
+		defer close(s.done)
+		for payload := range s.events {
+			// Not every ResponseWriter supports deadlines; without one a
+			// stalled client is only noticed when the connection drops.
+			_ = rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+			if err := emitSSE(w, payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}()
+	return s
+}
+
+// offer queues payload if the buffer has room and reports whether it did.
+func (s *sseStream) offer(payload any) bool {
+	select {
+	case s.events <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// send queues payload, waiting for room. It is for events a client must
+// not miss (start, complete, error) and returns false once the client is
+// gone.
+func (s *sseStream) send(ctx context.Context, payload any) bool {
+	select {
+	case s.events <- payload:
+		return true
+	case <-s.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// closed is closed once the client can no longer be written to.
+func (s *sseStream) closed() <-chan struct{} {
+	return s.done
+}
+
+// close stops the stream after writing whatever is still queued.
+func (s *sseStream) close() {
+	close(s.events)
+	<-s.done
+}
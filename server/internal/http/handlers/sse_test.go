@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anath2/language-app/internal/queue"
+)
+
+// stalledWriter blocks every write until release is closed, like a client
+// that stopped reading.
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	select {
+	case w.blocked <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestSSEStreamDoesNotBlockOnStalledClient(t *testing.T) {
+	w := &stalledWriter{ResponseRecorder: httptest.NewRecorder(), blocked: make(chan struct{}, 1), release: make(chan struct{})}
+	stream := newSSEStream(w, w)
+
+	stream.offer(map[string]any{"type": "progress", "current": 0})
+	<-w.blocked
+	accepted := 1
+	for i := 1; i < sseBufferSize*2; i++ {
+		if stream.offer(map[string]any{"type": "progress", "current": i}) {
+			accepted++
+		}
+	}
+	// The writer holds one event while blocked; the rest fill the buffer.
+	if accepted != sseBufferSize+1 {
+		t.Fatalf("expected offers to stop at the buffer size, accepted %d", accepted)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if stream.send(ctx, map[string]any{"type": "complete"}) {
+		t.Fatal("expected send to give up while the buffer stays full")
+	}
+
+	close(w.release)
+	if !stream.send(context.Background(), map[string]any{"type": "complete"}) {
+		t.Fatal("expected send to succeed once the client reads again")
+	}
+	stream.close()
+
+	body := w.Body.String()
+	if got := strings.Count(body, `"type":"progress"`); got != accepted {
+		t.Fatalf("expected %d progress events written, got %d", accepted, got)
+	}
+	if !strings.HasSuffix(body, "data: {\"type\":\"complete\"}\n\n") {
+		t.Fatalf("expected complete to be written last, got %q", body[max(0, len(body)-80):])
+	}
+}
+
+func TestSSEStreamStopsAfterWriteError(t *testing.T) {
+	stream := newSSEStream(failingWriter{httptest.NewRecorder()}, httptest.NewRecorder())
+	stream.offer(map[string]any{"type": "progress"})
+	select {
+	case <-stream.closed():
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to close after a failed write")
+	}
+	// The buffer may still take the event; what matters is that send does
+	// not block once the writer is gone.
+	stream.send(context.Background(), map[string]any{"type": "complete"})
+	stream.close()
+}
+
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, http.ErrHandlerTimeout
+}
+
+func TestCatchUpEventCarriesMissedResults(t *testing.T) {
+	progress := queue.Progress{
+		Total: 5,
+		Results: []queue.SegmentProgress{
+			{Segment: "一", Index: 0}, {Segment: "二", Index: 1}, {Segment: "三", Index: 2},
+		},
+	}
+	event := catchUpEvent(progress, 1)
+	results := event["results"].([]map[string]any)
+	if event["type"] != "catch_up" || event["current"] != 3 || event["total"] != 5 {
+		t.Fatalf("unexpected catch_up event: %+v", event)
+	}
+	if len(results) != 2 || results[0]["segment"] != "二" || results[1]["segment"] != "三" {
+		t.Fatalf("expected the results from index 1 on, got %+v", results)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+type translationTagRequest struct {
+	Tag string `json:"tag"`
+}
+
+type translationTagsResponse struct {
+	TranslationID string   `json:"translation_id"`
+	Tags          []string `json:"tags"`
+}
+
+// AddTranslationTag labels a translation. Tags are lowercased, so "News"
+// and "news" are the same label.
+func AddTranslationTag(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	var req translationTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if err := translations.AddTag(translationID, req.Tag); err != nil {
+		writeTagError(w, err)
+		return
+	}
+	writeTranslationTags(w, translationID)
+}
+
+// RemoveTranslationTag removes one label; the tag to remove is sent in the
+// body like AddTranslationTag's.
+func RemoveTranslationTag(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	translationID := pathParam(r, "translation_id")
+	var req translationTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if err := translations.RemoveTag(translationID, req.Tag); err != nil {
+		if errors.Is(err, translation.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		writeTagError(w, err)
+		return
+	}
+	writeTranslationTags(w, translationID)
+}
+
+func writeTranslationTags(w http.ResponseWriter, translationID string) {
+	tags, err := translations.ListTags(translationID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, translationTagsResponse{TranslationID: translationID, Tags: tags})
+}
+
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, translation.ErrInvalidTag):
+		WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, translation.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "Translation not found")
+	default:
+		writeStoreError(w, err)
+	}
+}
+
+// nonNilTags keeps untagged translations serialising as [] rather than null.
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -5,33 +5,159 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/anath2/language-app/internal/config"
+	"github.com/anath2/language-app/internal/queue"
+	"github.com/anath2/language-app/internal/textsplit"
 	"github.com/anath2/language-app/internal/translation"
+	"github.com/anath2/language-app/internal/webhook"
+	"github.com/anath2/language-app/internal/webtext"
 )
 
+const (
+	fromURLFetchTimeout = 15 * time.Second
+	fromURLMaxBytes     = 2 << 20
+)
+
+// Repeating POST /api/translations with the same Idempotency-Key returns
+// the original translation for idempotencyWindow after it was created.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyWindow        = 24 * time.Hour
+	maxIdempotencyKeyLen     = 255
+	// maxSegmentPreviewChars bounds a segmentation preview, which makes a
+	// model call per sentence while the caller waits.
+	maxSegmentPreviewChars = 5000
+)
+
+// streamPollInterval is how often a translation stream reloads progress
+// without being told it changed.
+var streamPollInterval = config.DefaultSSEPollInterval
+
+func ConfigureStreamPollInterval(interval time.Duration) {
+	streamPollInterval = interval
+}
+
+// readingCharsPerMinute is the reading speed behind the history list's
+// reading-time estimates.
+var readingCharsPerMinute = config.DefaultReadingCharsPerMinute
+
+func ConfigureReadingSpeed(charsPerMinute int) {
+	readingCharsPerMinute = charsPerMinute
+}
+
+// maxInputChars is the longest input, in characters, accepted as one
+// translation.
+var maxInputChars = config.DefaultMaxInputChars
+
+// ConfigureInputLimit sets maxInputChars; a non-positive limit uses the
+// default.
+func ConfigureInputLimit(chars int) {
+	if chars <= 0 {
+		chars = config.DefaultMaxInputChars
+	}
+	maxInputChars = chars
+}
+
+// inputLength counts text's characters as they will be stored, and reports
+// whether that is over maxInputChars.
+func inputLength(text string) (int, bool) {
+	n := utf8.RuneCountInString(translation.NormalizeInputText(text))
+	return n, n > maxInputChars
+}
+
+// hskLevels maps headword → HSK level for annotating served segments; nil
+// leaves segments unannotated.
+var hskLevels map[string]int
+
+func ConfigureHSKLevels(levels map[string]int) {
+	hskLevels = levels
+}
+
+// hskLevelFor returns segment's HSK level, or nil when it is not listed.
+func hskLevelFor(segment string) *int {
+	level, ok := hskLevels[strings.TrimSpace(segment)]
+	if !ok {
+		return nil
+	}
+	return &level
+}
+
+// withHSKLevels annotates every segment in sentences with its HSK level.
+func withHSKLevels(sentences []translation.SentenceResult) []translation.SentenceResult {
+	for i := range sentences {
+		for j := range sentences[i].Translations {
+			sentences[i].Translations[j].HSKLevel = hskLevelFor(sentences[i].Translations[j].Segment)
+		}
+	}
+	return sentences
+}
+
+// pageFetcher downloads pages for CreateTranslationFromURL.
+var pageFetcher = webtext.NewFetcher(fromURLFetchTimeout, fromURLMaxBytes, false)
+
+// ConfigurePageFetching lets CreateTranslationFromURL fetch from loopback and
+// private addresses when allowPrivate is set.
+func ConfigurePageFetching(allowPrivate bool) {
+	pageFetcher = webtext.NewFetcher(fromURLFetchTimeout, fromURLMaxBytes, allowPrivate)
+}
+
 type createTranslationRequest struct {
 	InputText  string `json:"input_text"`
 	SourceType string `json:"source_type"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	// CallbackURL is POSTed {translation_id, status} when the job finishes.
+	CallbackURL string `json:"callback_url"`
+	// Priority is high (the default), normal or low; bulk imports pass low
+	// so they queue behind translations a user is waiting on.
+	Priority string `json:"priority"`
+	// Chunk splits input longer than the limit into linked translations
+	// instead of rejecting it.
+	Chunk bool `json:"chunk"`
+}
+
+type createTranslationFromURLRequest struct {
+	URL        string `json:"url"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	Chunk      bool   `json:"chunk"`
 }
 
+// createTranslationResponse describes the created translation. For chunked
+// input it is the first part, and Parts lists every part in order.
 type createTranslationResponse struct {
-	TranslationID string `json:"translation_id"`
-	Status        string `json:"status"`
+	TranslationID string   `json:"translation_id"`
+	Status        string   `json:"status"`
+	ChunkGroupID  string   `json:"chunk_group_id,omitempty"`
+	Parts         []string `json:"parts,omitempty"`
 }
 
 type translationSummary struct {
-	ID                     string  `json:"id"`
-	CreatedAt              string  `json:"created_at"`
-	Status                 string  `json:"status"`
-	SourceType             string  `json:"source_type"`
-	Title                  string  `json:"title"`
-	InputPreview           string  `json:"input_preview"`
-	FullTranslationPreview *string `json:"full_translation_preview"`
-	SegmentCount           *int    `json:"segment_count"`
-	TotalSegments          *int    `json:"total_segments"`
+	ID                     string   `json:"id"`
+	CreatedAt              string   `json:"created_at"`
+	Status                 string   `json:"status"`
+	SourceType             string   `json:"source_type"`
+	SourceLang             string   `json:"source_lang"`
+	TargetLang             string   `json:"target_lang"`
+	Title                  string   `json:"title"`
+	InputPreview           string   `json:"input_preview"`
+	FullTranslationPreview *string  `json:"full_translation_preview"`
+	SegmentCount           *int     `json:"segment_count"`
+	TotalSegments          *int     `json:"total_segments"`
+	IsFavorite             bool     `json:"is_favorite"`
+	Tags                   []string `json:"tags"`
+	// CharacterCount counts CJK characters in the input, the unit
+	// EstimatedReadingMinutes is based on.
+	CharacterCount          int `json:"character_count"`
+	EstimatedReadingMinutes int `json:"estimated_reading_minutes"`
 }
 
 type listTranslationsResponse struct {
@@ -40,15 +166,52 @@ type listTranslationsResponse struct {
 }
 
 type translationDetailResponse struct {
-	ID              string      `json:"id"`
-	CreatedAt       string      `json:"created_at"`
-	Status          string      `json:"status"`
-	SourceType      string      `json:"source_type"`
-	Title           string      `json:"title"`
-	InputText       string      `json:"input_text"`
-	FullTranslation *string     `json:"full_translation"`
-	ErrorMessage    *string     `json:"error_message"`
-	Sentences       interface{} `json:"sentences"`
+	ID              string              `json:"id"`
+	CreatedAt       string              `json:"created_at"`
+	Status          string              `json:"status"`
+	SourceType      string              `json:"source_type"`
+	SourceLang      string              `json:"source_lang"`
+	TargetLang      string              `json:"target_lang"`
+	Title           string              `json:"title"`
+	InputText       string              `json:"input_text"`
+	RawInputText    *string             `json:"raw_input_text"`
+	FullTranslation *string             `json:"full_translation"`
+	ErrorMessage    *string             `json:"error_message"`
+	Sentences       interface{}         `json:"sentences"`
+	Difficulty      *difficultyResponse `json:"difficulty"`
+	SourceURL       string              `json:"source_url,omitempty"`
+	IsFavorite      bool                `json:"is_favorite"`
+	Tags            []string            `json:"tags"`
+	Chunk           *chunkResponse      `json:"chunk"`
+}
+
+// chunkResponse places a translation among the parts of a chunked input,
+// e.g. part 2 of 5.
+type chunkResponse struct {
+	GroupID string `json:"group_id"`
+	Index   int    `json:"index"`
+	Count   int    `json:"count"`
+}
+
+type chunkPartResponse struct {
+	TranslationID string `json:"translation_id"`
+	Index         int    `json:"index"`
+	Status        string `json:"status"`
+	Title         string `json:"title"`
+}
+
+type listChunksResponse struct {
+	GroupID string              `json:"group_id"`
+	Count   int                 `json:"count"`
+	Parts   []chunkPartResponse `json:"parts"`
+}
+
+type difficultyResponse struct {
+	TotalWords    int     `json:"total_words"`
+	UnknownWords  int     `json:"unknown_words"`
+	LearningWords int     `json:"learning_words"`
+	KnownWords    int     `json:"known_words"`
+	Score         float64 `json:"score"`
 }
 
 type translationStatusResponse struct {
@@ -66,9 +229,13 @@ type translateSentenceSegmentsRequest struct {
 }
 
 type translationResult struct {
-	Segment string `json:"segment"`
-	Pinyin  string `json:"pinyin"`
-	English string `json:"english"`
+	Segment    string `json:"segment"`
+	Pinyin     string `json:"pinyin"`
+	English    string `json:"english"`
+	Source     string `json:"source,omitempty"`
+	POS        string `json:"pos,omitempty"`
+	Classifier string `json:"classifier,omitempty"`
+	HSKLevel   *int   `json:"hsk_level,omitempty"`
 }
 
 type translateSentenceSegmentsResponse struct {
@@ -77,55 +244,356 @@ type translateSentenceSegmentsResponse struct {
 
 func TranslateSentenceSegments(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	var req translateSentenceSegmentsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
+	lang := translation.DefaultLanguagePair()
+	if req.TranslationID != nil {
+		// The result is written back to the translation, so it must be the
+		// caller's own.
+		item, ok := translations.Get(*req.TranslationID)
+		if !ok || item.UserID != currentUserID(r) {
+			WriteError(w, http.StatusNotFound, "Translation not found")
+			return
+		}
+		lang = translation.LanguagePair{Source: item.SourceLang, Target: item.TargetLang}.WithDefaults()
+	}
 	results := make([]translationResult, 0, len(req.Segments))
 	sentenceText := strings.Join(req.Segments, "")
-	segmentResults, err := transProvider.TranslateSentenceSegments(r.Context(), req.Segments, sentenceText, derefOr(req.FullText, ""))
+	segmentResults, err := transProvider.TranslateSentenceSegments(r.Context(), req.Segments, sentenceText, derefOr(req.FullText, ""), lang)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	applyCustomDictionary(r, segmentResults, lang)
 	storeSegments := make([]translation.SegmentResult, 0, len(segmentResults))
 	for _, translated := range segmentResults {
 		item := translationResult{
-			Segment: translated.Segment,
-			Pinyin:  translated.Pinyin,
-			English: translated.English,
+			Segment:    translated.Segment,
+			Pinyin:     translated.Pinyin,
+			English:    translated.English,
+			Source:     translated.Source,
+			POS:        translated.POS,
+			Classifier: translated.Classifier,
+			HSKLevel:   hskLevelFor(translated.Segment),
 		}
 		results = append(results, item)
 		storeSegments = append(storeSegments, translated)
 	}
 	if req.TranslationID != nil && req.SentenceIdx != nil {
 		if err := translations.UpdateTranslationSegments(*req.TranslationID, *req.SentenceIdx, storeSegments); err != nil {
-			WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+			WriteError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 	}
 	WriteJSON(w, http.StatusOK, translateSentenceSegmentsResponse{Translations: results})
 }
 
+type previewSegmentsRequest struct {
+	Text       string `json:"text"`
+	SourceLang string `json:"source_lang"`
+}
+
+type previewSentence struct {
+	Text      string   `json:"text"`
+	Indent    string   `json:"indent"`
+	Separator string   `json:"separator"`
+	Segments  []string `json:"segments"`
+}
+
+type previewSegmentsResponse struct {
+	Sentences []previewSentence `json:"sentences"`
+}
+
+// PreviewSegments shows how text would be segmented, sentence by sentence,
+// without creating a translation or translating any segment. Sentences the
+// user has corrected before show their corrected segmentation, as they
+// would in a real translation.
+func PreviewSegments(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var req previewSegmentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	text := translation.NormalizeInputText(req.Text)
+	if strings.TrimSpace(text) == "" {
+		WriteError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+	if utf8.RuneCountInString(text) > maxSegmentPreviewChars {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("text must be at most %d characters", maxSegmentPreviewChars))
+		return
+	}
+	lang := translation.LanguagePair{Source: req.SourceLang}.WithDefaults()
+	if err := lang.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID := currentUserID(r)
+	sentences := textsplit.Split(text, lang.Source)
+	out := make([]previewSentence, 0, len(sentences))
+	for _, sent := range sentences {
+		segments, ok, err := translations.GetSegmentationOverride(userID, sent.Text, lang)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if !ok {
+			if segments, err = transProvider.Segment(r.Context(), sent.Text, lang); err != nil {
+				WriteError(w, http.StatusBadGateway, "Segmentation failed: "+err.Error())
+				return
+			}
+		}
+		kept := make([]string, 0, len(segments))
+		for _, seg := range segments {
+			if seg = strings.TrimSpace(seg); seg != "" {
+				kept = append(kept, seg)
+			}
+		}
+		out = append(out, previewSentence{Text: sent.Text, Indent: sent.Indent, Separator: sent.Separator, Segments: kept})
+	}
+	WriteJSON(w, http.StatusOK, previewSegmentsResponse{Sentences: out})
+}
+
+// RetranslateSegment re-runs translation for one segment, using its sentence
+// and the full input as context, and stores the new pinyin/english.
+func RetranslateSegment(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	translationID := pathParam(r, "translation_id")
+	sentenceIdx := parseIntDefault(pathParam(r, "sentence_idx"), -1)
+	segIdx := parseIntDefault(pathParam(r, "seg_idx"), -1)
+	if sentenceIdx < 0 || segIdx < 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid sentence or segment index")
+		return
+	}
+
+	item, ok := translations.Get(translationID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+	if sentenceIdx >= len(item.Sentences) || segIdx >= len(item.Sentences[sentenceIdx].Translations) {
+		WriteError(w, http.StatusNotFound, "Segment not found")
+		return
+	}
+
+	sentence := item.Sentences[sentenceIdx].Translations
+	var sentenceText strings.Builder
+	for _, seg := range sentence {
+		sentenceText.WriteString(seg.Segment)
+	}
+	segment := sentence[segIdx].Segment
+	lang := translation.LanguagePair{Source: item.SourceLang, Target: item.TargetLang}.WithDefaults()
+
+	translated, err := transProvider.TranslateSentenceSegments(r.Context(), []string{segment}, sentenceText.String(), item.InputText, lang)
+	if err != nil || len(translated) == 0 {
+		detail := "Failed to retranslate segment"
+		if err != nil {
+			detail += ": " + err.Error()
+		}
+		WriteError(w, http.StatusInternalServerError, detail)
+		return
+	}
+	translated[0].Segment = segment
+	applyCustomDictionary(r, translated[:1], lang)
+	result := translated[0]
+	if err := translations.UpdateSingleSegment(translationID, sentenceIdx, segIdx, result); err != nil {
+		if err == translation.ErrNotFound {
+			WriteError(w, http.StatusNotFound, "Segment not found")
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, translationResult{
+		Segment:    result.Segment,
+		Pinyin:     result.Pinyin,
+		English:    result.English,
+		Source:     result.Source,
+		POS:        result.POS,
+		Classifier: result.Classifier,
+		HSKLevel:   hskLevelFor(result.Segment),
+	})
+}
+
+type mergeSegmentsRequest struct {
+	FromSegIdx *int `json:"from_seg_idx"`
+	ToSegIdx   *int `json:"to_seg_idx"`
+}
+
+// MergeSegments joins a contiguous range of segments in one sentence and
+// re-translates the merged segment. The response is the sentence's full,
+// re-indexed segment list.
+func MergeSegments(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	translationID := pathParam(r, "translation_id")
+	sentenceIdx := parseIntDefault(pathParam(r, "sentence_idx"), -1)
+	if sentenceIdx < 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid sentence index")
+		return
+	}
+	var req mergeSegmentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if req.FromSegIdx == nil || req.ToSegIdx == nil {
+		WriteError(w, http.StatusBadRequest, "from_seg_idx and to_seg_idx are required")
+		return
+	}
+
+	item, ok := translations.Get(translationID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+
+	merged, err := translations.MergeSegments(translationID, sentenceIdx, *req.FromSegIdx, *req.ToSegIdx)
+	if err != nil {
+		if err == translation.ErrNotFound {
+			WriteError(w, http.StatusNotFound, "Sentence not found")
+			return
+		}
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mergedIdx := *req.FromSegIdx
+	var sentenceText strings.Builder
+	for _, seg := range merged {
+		sentenceText.WriteString(seg.Segment)
+	}
+	lang := translation.LanguagePair{Source: item.SourceLang, Target: item.TargetLang}.WithDefaults()
+	translated, err := transProvider.TranslateSentenceSegments(r.Context(), []string{merged[mergedIdx].Segment}, sentenceText.String(), item.InputText, lang)
+	if err != nil || len(translated) == 0 {
+		detail := "Segments merged but re-translation failed"
+		if err != nil {
+			detail += ": " + err.Error()
+		}
+		WriteError(w, http.StatusInternalServerError, detail)
+		return
+	}
+	translated[0].Segment = merged[mergedIdx].Segment
+	applyCustomDictionary(r, translated[:1], lang)
+	result := translated[0]
+	if err := translations.UpdateSingleSegment(translationID, sentenceIdx, mergedIdx, result); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	merged[mergedIdx] = result
+
+	results := make([]translationResult, 0, len(merged))
+	for _, seg := range merged {
+		results = append(results, translationResult{Segment: seg.Segment, Pinyin: seg.Pinyin, English: seg.English, Source: seg.Source, POS: seg.POS, Classifier: seg.Classifier, HSKLevel: hskLevelFor(seg.Segment)})
+	}
+	WriteJSON(w, http.StatusOK, translateSentenceSegmentsResponse{Translations: results})
+}
+
+// RequireTranslationOwner wraps routes keyed by {translation_id} so a user
+// only reaches their own translations. Missing translations and other users'
+// ones both report 404. When the owner cannot be read the request is refused
+// rather than let through.
+func RequireTranslationOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := validateDependencies(); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		owner, err := translations.GetOwner(pathParam(r, "translation_id"))
+		if errors.Is(err, translation.ErrNotFound) || (err == nil && owner != currentUserID(r)) {
+			WriteError(w, http.StatusNotFound, "Translation not found")
+			return
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func CreateTranslation(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	var req createTranslationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lang := translation.LanguagePair{Source: req.SourceLang, Target: req.TargetLang}
+	idempotencyKey := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+	if len(idempotencyKey) > maxIdempotencyKeyLen {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLen))
+		return
+	}
+
+	var metadata map[string]string
+	if callbackURL := strings.TrimSpace(req.CallbackURL); callbackURL != "" {
+		if err := webhook.ValidateURL(callbackURL); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		metadata = map[string]string{queue.CallbackURLMetadataKey: callbackURL}
+	}
+	priority, ok := parseJobPriority(req.Priority)
+	if !ok {
+		WriteError(w, http.StatusBadRequest, "priority must be high, normal or low")
+		return
+	}
+	if req.Chunk && idempotencyKey != "" {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("chunk cannot be combined with %s", IdempotencyKeyHeader))
+		return
+	}
+	if n, tooLong := inputLength(req.InputText); tooLong {
+		if !req.Chunk {
+			writeInputTooLong(w, n, true)
+			return
+		}
+		createChunked(w, r, req.InputText, req.SourceType, lang, metadata, priority)
 		return
 	}
 
-	item, err := translations.Create(req.InputText, req.SourceType)
+	var item translation.Translation
+	var err error
+	created := true
+	if idempotencyKey != "" {
+		item, created, err = translations.CreateWithIdempotencyKey(currentUserID(r), idempotencyKey, idempotencyWindow, req.InputText, req.SourceType, lang, metadata, priority)
+	} else {
+		item, err = translations.CreateForUser(currentUserID(r), req.InputText, req.SourceType, lang, metadata, priority)
+	}
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !created {
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		WriteJSON(w, http.StatusOK, createTranslationResponse{
+			TranslationID: item.ID,
+			Status:        item.Status,
+		})
 		return
 	}
 
@@ -134,38 +602,157 @@ func CreateTranslation(w http.ResponseWriter, r *http.Request) {
 		Status:        item.Status,
 	})
 
-	jobQueue.StartProcessing(item.ID)
+	jobQueue.StartProcessing(r.Context(), item.ID)
+}
+
+// writeInputTooLong rejects input over maxInputChars, pointing at chunk
+// where the endpoint offers it.
+func writeInputTooLong(w http.ResponseWriter, n int, canChunk bool) {
+	msg := fmt.Sprintf("input_text is %d characters; the limit is %d. Split the text into shorter translations", n, maxInputChars)
+	if canChunk {
+		msg += ` or set "chunk": true to translate it in linked parts`
+	}
+	WriteError(w, http.StatusRequestEntityTooLarge, msg)
+}
+
+// createChunked splits text at sentence boundaries into parts within
+// maxInputChars and queues them as linked translations. Only the first part
+// starts now; the queue starts each later part when the one before it
+// completes. Every part carries metadata, so a callback URL hears about
+// each part as it finishes.
+func createChunked(w http.ResponseWriter, r *http.Request, text string, sourceType string, lang translation.LanguagePair, metadata map[string]string, priority int) {
+	chunks := textsplit.Chunk(text, lang.WithDefaults().Source, maxInputChars)
+	parts, err := translations.CreateChunkedForUser(currentUserID(r), chunks, sourceType, lang, metadata, priority)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ids := make([]string, 0, len(parts))
+	for _, part := range parts {
+		ids = append(ids, part.ID)
+	}
+
+	WriteJSON(w, http.StatusOK, createTranslationResponse{
+		TranslationID: parts[0].ID,
+		Status:        parts[0].Status,
+		ChunkGroupID:  parts[0].Chunk.GroupID,
+		Parts:         ids,
+	})
+
+	jobQueue.StartProcessing(r.Context(), parts[0].ID)
+}
+
+// parseJobPriority maps a request's priority name onto a job priority.
+// Translations created through the API are interactive, so an empty value
+// means high.
+func parseJobPriority(name string) (int, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "high":
+		return translation.JobPriorityHigh, true
+	case "normal":
+		return translation.JobPriorityNormal, true
+	case "low":
+		return translation.JobPriorityLow, true
+	}
+	return 0, false
+}
+
+// CreateTranslationFromURL fetches a page, keeps the paragraphs written in the
+// source language and queues them as a new translation.
+func CreateTranslationFromURL(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var req createTranslationFromURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	pageURL := strings.TrimSpace(req.URL)
+	if pageURL == "" {
+		WriteError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	lang := translation.LanguagePair{Source: req.SourceLang, Target: req.TargetLang}.WithDefaults()
+	if err := lang.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := pageFetcher.Fetch(r.Context(), pageURL)
+	if errors.Is(err, webtext.ErrTooLarge) {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("Page is larger than %d MB", fromURLMaxBytes>>20))
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Could not fetch URL: "+err.Error())
+		return
+	}
+	text := webtext.MainText(webtext.ExtractText(page), lang.Source)
+	if text == "" {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("No %s text found at URL", translation.LanguageName(lang.Source)))
+		return
+	}
+	metadata := map[string]string{"source_url": pageURL}
+	if n, tooLong := inputLength(text); tooLong {
+		if !req.Chunk {
+			writeInputTooLong(w, n, true)
+			return
+		}
+		createChunked(w, r, text, "url", lang, metadata, translation.JobPriorityHigh)
+		return
+	}
+
+	item, err := translations.CreateForUser(currentUserID(r), text, "url", lang, metadata, translation.JobPriorityHigh)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, createTranslationResponse{
+		TranslationID: item.ID,
+		Status:        item.Status,
+	})
+
+	jobQueue.StartProcessing(r.Context(), item.ID)
 }
 
 func ListTranslations(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	query := r.URL.Query()
 	limit := parseIntDefault(query.Get("limit"), 20)
 	offset := parseIntDefault(query.Get("offset"), 0)
-	status := strings.TrimSpace(query.Get("status"))
-
-	items, total, err := translations.List(limit, offset, status)
+	items, total, err := translations.ListForUser(currentUserID(r), limit, offset, parseListFilter(query))
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	summaries := make([]translationSummary, 0, len(items))
 	for _, item := range items {
+		chars := countCJKRunes(item.InputText)
 		summaries = append(summaries, translationSummary{
-			ID:                     item.ID,
-			CreatedAt:              item.CreatedAt,
-			Status:                 item.Status,
-			SourceType:             item.SourceType,
-			Title:                  item.Title,
-			InputPreview:           preview(item.InputText, 100),
-			FullTranslationPreview: previewPtr(item.FullTranslation, 100),
-			SegmentCount:           intPtrIfKnown(item.Progress, item.Status),
-			TotalSegments:          intPtrIfKnown(item.Total, item.Status),
+			ID:                      item.ID,
+			CreatedAt:               item.CreatedAt,
+			Status:                  item.Status,
+			SourceType:              item.SourceType,
+			SourceLang:              item.SourceLang,
+			TargetLang:              item.TargetLang,
+			Title:                   item.Title,
+			InputPreview:            preview(item.InputText, 100),
+			FullTranslationPreview:  previewPtr(item.FullTranslation, 100),
+			SegmentCount:            intPtrIfKnown(item.Progress, item.Status),
+			TotalSegments:           intPtrIfKnown(item.Total, item.Status),
+			IsFavorite:              item.IsFavorite,
+			Tags:                    nonNilTags(item.Tags),
+			CharacterCount:          chars,
+			EstimatedReadingMinutes: readingMinutes(chars, readingCharsPerMinute),
 		})
 	}
 
@@ -175,50 +762,215 @@ func ListTranslations(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// parseListFilter reads the list filters shared by ListTranslations and
+// GetTranslationNeighbors. tag may repeat or hold a comma-separated list;
+// all of them must match.
+func parseListFilter(query url.Values) translation.ListFilter {
+	filter := translation.ListFilter{
+		Status:       strings.TrimSpace(query.Get("status")),
+		FavoriteOnly: strings.EqualFold(query.Get("favorite"), "true"),
+	}
+	for _, value := range query["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if strings.TrimSpace(tag) != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+	return filter
+}
+
+type translationRefResponse struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type translationNeighborsResponse struct {
+	TranslationID string                  `json:"translation_id"`
+	Previous      *translationRefResponse `json:"previous"`
+	Next          *translationRefResponse `json:"next"`
+}
+
+// GetTranslationNeighbors returns the translations created just before and
+// after this one, within the list the client is browsing (same status,
+// favorite and tag filters as ListTranslations).
+func GetTranslationNeighbors(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	translationID := pathParam(r, "translation_id")
+	prev, next, err := translations.Neighbors(currentUserID(r), translationID, parseListFilter(r.URL.Query()))
+	if errors.Is(err, translation.ErrInvalidFilter) {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, translation.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, translationNeighborsResponse{
+		TranslationID: translationID,
+		Previous:      toTranslationRefResponse(prev),
+		Next:          toTranslationRefResponse(next),
+	})
+}
+
+// GetTranslationChunks lists the parts of the chunked input translationID
+// belongs to, so a reader can move between them.
+func GetTranslationChunks(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	item, ok := translations.Get(pathParam(r, "translation_id"))
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+	if item.Chunk == nil {
+		WriteError(w, http.StatusNotFound, "Translation is not part of a chunked input")
+		return
+	}
+	parts, err := translations.ListChunks(item.Chunk.GroupID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	resp := listChunksResponse{
+		GroupID: item.Chunk.GroupID,
+		Count:   item.Chunk.Count,
+		Parts:   make([]chunkPartResponse, 0, len(parts)),
+	}
+	for _, part := range parts {
+		resp.Parts = append(resp.Parts, chunkPartResponse{
+			TranslationID: part.TranslationID,
+			Index:         part.Index,
+			Status:        part.Status,
+			Title:         part.Title,
+		})
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+func toTranslationRefResponse(ref *translation.TranslationRef) *translationRefResponse {
+	if ref == nil {
+		return nil
+	}
+	return &translationRefResponse{ID: ref.ID, Title: ref.Title}
+}
+
+// ExportTranslationPlaintext renders a translation's segments as interlinear
+// text, TSV or markdown tables for pasting into notes.
+func ExportTranslationPlaintext(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	format := strings.TrimSpace(r.URL.Query().Get("format"))
+	if format == "" {
+		format = translation.ExportFormatInterlinear
+	}
+
+	item, ok := translations.Get(pathParam(r, "translation_id"))
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Translation not found")
+		return
+	}
+
+	text, err := translation.FormatSentences(item.Sentences, format)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", translation.ExportContentType(format))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(text))
+}
+
 func GetTranslation(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	translationID := pathParam(r, "translation_id")
 	item, ok := translations.Get(translationID)
 	if !ok {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+		WriteError(w, http.StatusNotFound, "Translation not found")
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, translationDetailResponse{
+	// Difficulty is informational; a failure to compute it should not hide
+	// the translation itself.
+	var difficulty *difficultyResponse
+	if breakdown, err := srs.ComputeDifficulty(item.UserID, item.ID); err == nil {
+		difficulty = &difficultyResponse{
+			TotalWords:    breakdown.TotalWords,
+			UnknownWords:  breakdown.UnknownWords,
+			LearningWords: breakdown.LearningWords,
+			KnownWords:    breakdown.KnownWords,
+			Score:         breakdown.Score,
+		}
+	}
+
+	resp := translationDetailResponse{
 		ID:              item.ID,
 		CreatedAt:       item.CreatedAt,
 		Status:          item.Status,
 		SourceType:      item.SourceType,
+		SourceLang:      item.SourceLang,
+		TargetLang:      item.TargetLang,
 		Title:           item.Title,
 		InputText:       item.InputText,
+		RawInputText:    item.RawInputText,
 		FullTranslation: item.FullTranslation,
 		ErrorMessage:    item.ErrorMessage,
-		Sentences:       item.Sentences,
-	})
+		Sentences:       withHSKLevels(item.Sentences),
+		Difficulty:      difficulty,
+		SourceURL:       item.Metadata["source_url"],
+		IsFavorite:      item.IsFavorite,
+		Tags:            nonNilTags(item.Tags),
+	}
+	if item.Chunk != nil {
+		resp.Chunk = &chunkResponse{GroupID: item.Chunk.GroupID, Index: item.Chunk.Index, Count: item.Chunk.Count}
+	}
+	// Only finished translations are cacheable; pending ones change with
+	// every segment the queue stores.
+	if item.Status != "completed" && item.Status != "failed" {
+		w.Header().Set("Cache-Control", "no-store")
+		WriteJSON(w, http.StatusOK, resp)
+		return
+	}
+	writeJSONWithETag(w, r, resp)
 }
 
 func GetTranslationStatus(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	translationID := pathParam(r, "translation_id")
-	item, ok := translations.Get(translationID)
+	status, progress, total, ok := translations.GetStatusOnly(translationID)
 	if !ok {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+		WriteError(w, http.StatusNotFound, "Translation not found")
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, translationStatusResponse{
-		TranslationID: item.ID,
-		Status:        item.Status,
-		Progress:      intPtrIfKnown(item.Progress, item.Status),
-		Total:         intPtrIfKnown(item.Total, item.Status),
+		TranslationID: translationID,
+		Status:        status,
+		Progress:      intPtrIfKnown(progress, status),
+		Total:         intPtrIfKnown(total, status),
 	})
 }
 
@@ -234,7 +986,7 @@ type updateTranslationResponse struct {
 
 func UpdateTranslation(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
@@ -242,25 +994,31 @@ func UpdateTranslation(w http.ResponseWriter, r *http.Request) {
 
 	var req updateTranslationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 
 	hasTitle := req.Title != ""
-	hasInputText := strings.TrimSpace(req.InputText) != ""
+	hasInputText := strings.TrimSpace(translation.NormalizeInputText(req.InputText)) != ""
 
 	if !hasTitle && !hasInputText {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "input_text or title is required"})
+		WriteError(w, http.StatusBadRequest, "input_text or title is required")
 		return
 	}
+	if hasInputText {
+		if n, tooLong := inputLength(req.InputText); tooLong {
+			writeInputTooLong(w, n, false)
+			return
+		}
+	}
 
 	if hasTitle {
 		if err := translations.UpdateTitle(translationID, req.Title); err != nil {
 			if errors.Is(err, translation.ErrNotFound) {
-				WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+				WriteError(w, http.StatusNotFound, "Translation not found")
 				return
 			}
-			WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+			writeStoreError(w, err)
 			return
 		}
 	}
@@ -273,10 +1031,10 @@ func UpdateTranslation(w http.ResponseWriter, r *http.Request) {
 	sentencesToProcess, err := translations.UpdateInputTextForReprocessing(translationID, req.InputText)
 	if err != nil {
 		if errors.Is(err, translation.ErrNotFound) {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+			WriteError(w, http.StatusNotFound, "Translation not found")
 			return
 		}
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
@@ -288,7 +1046,7 @@ func UpdateTranslation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobQueue.StartReprocessing(translationID, sentencesToProcess)
+	jobQueue.StartReprocessing(r.Context(), translationID, sentencesToProcess)
 
 	WriteJSON(w, http.StatusOK, updateTranslationResponse{
 		Status:           "pending",
@@ -296,15 +1054,51 @@ func UpdateTranslation(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+type setFavoriteRequest struct {
+	// Favorite defaults to true so an empty POST favorites the translation.
+	Favorite *bool `json:"favorite"`
+}
+
+type setFavoriteResponse struct {
+	TranslationID string `json:"translation_id"`
+	IsFavorite    bool   `json:"is_favorite"`
+}
+
+func SetTranslationFavorite(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	translationID := pathParam(r, "translation_id")
+
+	var req setFavoriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	favorite := req.Favorite == nil || *req.Favorite
+
+	if err := translations.SetFavorite(translationID, favorite); err != nil {
+		if errors.Is(err, translation.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Translation not found")
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, setFavoriteResponse{TranslationID: translationID, IsFavorite: favorite})
+}
+
 func DeleteTranslation(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
 	translationID := pathParam(r, "translation_id")
 	if !translations.Delete(translationID) {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Translation not found"})
+		WriteError(w, http.StatusNotFound, "Translation not found")
 		return
 	}
 	WriteJSON(w, http.StatusOK, map[string]bool{"ok": true})
@@ -312,7 +1106,7 @@ func DeleteTranslation(w http.ResponseWriter, r *http.Request) {
 
 func TranslationStream(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 
@@ -346,82 +1140,197 @@ func TranslationStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobQueue.StartProcessing(translationID)
+	jobQueue.StartProcessing(r.Context(), translationID)
 	streamLiveProgress(r.Context(), w, flusher, translationID)
 }
 
+// streamLiveProgress streams a job's progress to the client. It reloads
+// progress when the queue reports a change and, as a fallback for jobs run
+// by another process, every streamPollInterval. Events go through a bounded
+// sseStream: when the client falls behind, progress it has not received is
+// sent as one catch_up event instead of one event per segment. start, error
+// and complete are never dropped.
 func streamLiveProgress(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, translationID string) {
-	ticker := time.NewTicker(20 * time.Millisecond)
-	defer ticker.Stop()
+	stream := newSSEStream(w, flusher)
+	defer stream.close()
 
-	startSent := false
-	lastProgress := 0
+	updates, unsubscribe := jobQueue.Subscribe(translationID)
+	defer unsubscribe()
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
 
+	live := &liveProgress{stream: stream, translationID: translationID}
+	if live.step(ctx) {
+		return
+	}
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-stream.closed():
+			return
+		case <-updates:
 		case <-ticker.C:
-			item, exists := translations.Get(translationID)
-			if !exists {
-				emitSSE(w, map[string]any{"type": "error", "message": "Translation not found"})
-				flusher.Flush()
-				return
+			// Ticks only catch jobs run elsewhere; skip loading every
+			// segment unless the translation row says something changed.
+			if !live.changed() {
+				continue
 			}
+		}
+		if live.step(ctx) {
+			return
+		}
+	}
+}
 
-			if item.Status == "failed" {
-				emitSSE(w, map[string]any{"type": "error", "message": derefOr(item.ErrorMessage, "Translation failed")})
-				flusher.Flush()
-				return
-			}
+// liveProgress tracks what streamLiveProgress has sent so far.
+type liveProgress struct {
+	stream        *sseStream
+	translationID string
+	startSent     bool
+	// queued counts the progress results handed to stream; behind is set
+	// when the stream's buffer was full.
+	queued   int
+	behind   bool
+	fullSent int
+	// status, current and total are the row values the last step saw.
+	status  string
+	current int
+	total   int
+}
 
-			progress, ok := jobQueue.GetProgress(translationID)
-			if !ok {
-				continue
-			}
+// changed reports whether the translation row differs from what the last
+// step saw, reading only the row.
+func (l *liveProgress) changed() bool {
+	status, current, total, ok := translations.GetStatusOnly(l.translationID)
+	if !ok {
+		return true
+	}
+	return status != l.status || current != l.current || total != l.total
+}
 
-			if !startSent && progress.Total > 0 {
-				emitSSE(w, map[string]any{
-					"type":            "start",
-					"translation_id":  translationID,
-					"total":           progress.Total,
-					"sentences":       sentenceInfo(item.Sentences),
-					"fullTranslation": item.FullTranslation,
-				})
-				flusher.Flush()
-				startSent = true
-			}
+// step sends whatever changed since the last call and reports whether the
+// stream is finished.
+func (l *liveProgress) step(ctx context.Context) bool {
+	progress, ok := jobQueue.GetProgress(l.translationID)
+	if !ok {
+		if _, _, _, exists := translations.GetStatusOnly(l.translationID); !exists {
+			l.stream.send(ctx, map[string]any{"type": "error", "message": "Translation not found"})
+			return true
+		}
+		return false
+	}
+
+	l.status, l.current, l.total = progress.Status, progress.Current, progress.Total
+
+	if progress.Status == "failed" {
+		message := progress.Error
+		if message == "" {
+			message = "Translation failed"
+		}
+		l.stream.send(ctx, map[string]any{"type": "error", "message": message})
+		return true
+	}
+
+	// A dropped delta is covered by the next one, which starts from the
+	// last delta the client was sent.
+	if len(progress.FullTranslation) > l.fullSent && l.stream.offer(map[string]any{
+		"type":            "full_delta",
+		"delta":           progress.FullTranslation[l.fullSent:],
+		"fullTranslation": progress.FullTranslation,
+	}) {
+		l.fullSent = len(progress.FullTranslation)
+	}
+
+	if !l.startSent && progress.Total > 0 {
+		// The sentence layout only exists once the job has started, so the
+		// translation is loaded here rather than on every step.
+		item, _ := translations.Get(l.translationID)
+		if !l.stream.send(ctx, map[string]any{
+			"type":            "start",
+			"translation_id":  l.translationID,
+			"total":           progress.Total,
+			"sentences":       sentenceInfo(item.Sentences),
+			"fullTranslation": item.FullTranslation,
+		}) {
+			return true
+		}
+		l.startSent = true
+	}
 
-			for i := lastProgress; i < len(progress.Results); i++ {
-				result := progress.Results[i]
-				emitSSE(w, map[string]any{
-					"type":    "progress",
-					"current": i + 1,
-					"total":   progress.Total,
-					"result": map[string]any{
-						"segment":        result.Segment,
-						"pinyin":         result.Pinyin,
-						"english":        result.English,
-						"index":          result.Index,
-						"sentence_index": result.SentenceIndex,
-					},
-				})
-				flusher.Flush()
+	done := progress.Status == "completed"
+	if l.queued < len(progress.Results) {
+		if !l.behind {
+			for ; l.queued < len(progress.Results); l.queued++ {
+				if !l.stream.offer(progressEvent(progress, l.queued)) {
+					l.behind = true
+					break
+				}
 			}
-			lastProgress = len(progress.Results)
-
-			if progress.Status == "completed" || item.Status == "completed" {
-				fresh, _ := translations.Get(translationID)
-				emitSSE(w, map[string]any{
-					"type":            "complete",
-					"sentences":       fresh.Sentences,
-					"fullTranslation": fresh.FullTranslation,
-				})
-				flusher.Flush()
-				return
+		}
+		if l.behind {
+			// Whatever the client missed must reach it before complete.
+			event := catchUpEvent(progress, l.queued)
+			if done {
+				if !l.stream.send(ctx, event) {
+					return true
+				}
+			} else if !l.stream.offer(event) {
+				return false
 			}
+			l.queued = len(progress.Results)
+			l.behind = false
 		}
 	}
+
+	if done {
+		fresh, _ := translations.Get(l.translationID)
+		l.stream.send(ctx, map[string]any{
+			"type":            "complete",
+			"sentences":       withHSKLevels(fresh.Sentences),
+			"fullTranslation": fresh.FullTranslation,
+		})
+		return true
+	}
+	return false
+}
+
+func progressEvent(progress queue.Progress, i int) map[string]any {
+	return map[string]any{
+		"type":    "progress",
+		"current": i + 1,
+		"total":   progress.Total,
+		"result":  progressResult(progress.Results[i]),
+	}
+}
+
+// catchUpEvent carries every result from index from on, for a client that
+// fell too far behind to be sent them one by one.
+func catchUpEvent(progress queue.Progress, from int) map[string]any {
+	results := make([]map[string]any, 0, len(progress.Results)-from)
+	for _, result := range progress.Results[from:] {
+		results = append(results, progressResult(result))
+	}
+	return map[string]any{
+		"type":    "catch_up",
+		"current": len(progress.Results),
+		"total":   progress.Total,
+		"results": results,
+	}
+}
+
+func progressResult(result queue.SegmentProgress) map[string]any {
+	return map[string]any{
+		"segment":        result.Segment,
+		"pinyin":         result.Pinyin,
+		"english":        result.English,
+		"source":         result.Source,
+		"pos":            result.POS,
+		"classifier":     result.Classifier,
+		"hsk_level":      hskLevelFor(result.Segment),
+		"index":          result.Index,
+		"sentence_index": result.SentenceIndex,
+	}
 }
 
 func replayCompletedStream(w http.ResponseWriter, flusher http.Flusher, item translation.Translation) {
@@ -446,6 +1355,10 @@ func replayCompletedStream(w http.ResponseWriter, flusher http.Flusher, item tra
 					"segment":        seg.Segment,
 					"pinyin":         seg.Pinyin,
 					"english":        seg.English,
+					"source":         seg.Source,
+					"pos":            seg.POS,
+					"classifier":     seg.Classifier,
+					"hsk_level":      hskLevelFor(seg.Segment),
 					"index":          current - 1,
 					"sentence_index": sentenceIdx,
 				},
@@ -456,19 +1369,20 @@ func replayCompletedStream(w http.ResponseWriter, flusher http.Flusher, item tra
 
 	emitSSE(w, map[string]any{
 		"type":            "complete",
-		"sentences":       item.Sentences,
+		"sentences":       withHSKLevels(item.Sentences),
 		"fullTranslation": item.FullTranslation,
 	})
 	flusher.Flush()
 }
 
-func emitSSE(w http.ResponseWriter, payload any) {
+func emitSSE(w http.ResponseWriter, payload any) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
-		_, _ = fmt.Fprint(w, "data: {\"type\":\"error\",\"message\":\"Failed to encode SSE payload\"}\n\n")
-		return
+		_, err = fmt.Fprint(w, "data: {\"type\":\"error\",\"message\":\"Failed to encode SSE payload\"}\n\n")
+		return err
 	}
-	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
 }
 
 func sentenceInfo(sentences []translation.SentenceResult) []map[string]any {
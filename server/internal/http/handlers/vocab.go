@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/anath2/language-app/internal/translation"
@@ -21,6 +24,21 @@ type saveVocabResponse struct {
 	SegmentID string `json:"segment_id"`
 }
 
+// maxVocabImportBytes bounds an uploaded vocabulary CSV; a full HSK list
+// with definitions is well under it.
+const maxVocabImportBytes = 4 << 20
+
+type vocabImportErrorResponse struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type vocabImportResponse struct {
+	Imported int                        `json:"imported"`
+	Skipped  int                        `json:"skipped"`
+	Errors   []vocabImportErrorResponse `json:"errors"`
+}
+
 type updateVocabStatusRequest struct {
 	SegmentID   string `json:"segment_id"`
 	CharacterID string `json:"character_id"`
@@ -57,12 +75,41 @@ type vocabSRSInfoListResponse struct {
 	Items []vocabSRSInfoResponse `json:"items"`
 }
 
+type characterWordResponse struct {
+	SegmentID string `json:"segment_id,omitempty"`
+	Headword  string `json:"headword"`
+	Pinyin    string `json:"pinyin"`
+	English   string `json:"english"`
+	Status    string `json:"status,omitempty"`
+}
+
+type characterWordsResponse struct {
+	CharacterID string                  `json:"character_id"`
+	Words       []characterWordResponse `json:"words"`
+}
+
+type recentLookupResponse struct {
+	SegmentID  string `json:"segment_id"`
+	Headword   string `json:"headword"`
+	Pinyin     string `json:"pinyin"`
+	English    string `json:"english"`
+	Status     string `json:"status"`
+	LookedUpAt string `json:"looked_up_at"`
+}
+
+type recentLookupsResponse struct {
+	Words []recentLookupResponse `json:"words"`
+}
+
 type reviewCardResponse struct {
-	SegmentID string   `json:"segment_id"`
-	Headword  string   `json:"headword"`
-	Pinyin    string   `json:"pinyin"`
-	English   string   `json:"english"`
-	Snippets  []string `json:"snippets"`
+	SegmentID     string   `json:"segment_id"`
+	Headword      string   `json:"headword"`
+	Pinyin        string   `json:"pinyin"`
+	English       string   `json:"english"`
+	Snippets      []string `json:"snippets"`
+	Examples      []string `json:"examples"`
+	FrequencyRank *int     `json:"frequency_rank"`
+	HSKLevel      *int     `json:"hsk_level"`
 }
 
 type reviewQueueResponse struct {
@@ -70,6 +117,19 @@ type reviewQueueResponse struct {
 	DueCount int                  `json:"due_count"`
 }
 
+type clozeCardResponse struct {
+	SegmentID string `json:"segment_id"`
+	Pinyin    string `json:"pinyin"`
+	English   string `json:"english"`
+	Sentence  string `json:"sentence"`
+	Answer    string `json:"answer"`
+}
+
+type clozeQueueResponse struct {
+	Cards    []clozeCardResponse `json:"cards"`
+	DueCount int                 `json:"due_count"`
+}
+
 type reviewAnswerRequest struct {
 	SegmentID   string `json:"segment_id"`
 	CharacterID string `json:"character_id"`
@@ -85,6 +145,38 @@ type reviewAnswerResponse struct {
 	RemainingDue int     `json:"remaining_due"`
 }
 
+type undoReviewResponse struct {
+	Undone       bool `json:"undone"`
+	RemainingDue int  `json:"remaining_due"`
+}
+
+type dailyReviewCountResponse struct {
+	Date   string `json:"date"`
+	Count  int    `json:"count"`
+	Passed int    `json:"passed"`
+}
+
+type intervalBucketResponse struct {
+	Label   string   `json:"label"`
+	MinDays float64  `json:"min_days"`
+	MaxDays *float64 `json:"max_days"`
+	Count   int      `json:"count"`
+}
+
+type srsStatsResponse struct {
+	Days              int                        `json:"days"`
+	TotalReviews      int                        `json:"total_reviews"`
+	RetentionRate     float64                    `json:"retention_rate"`
+	ReviewsPerDay     []dailyReviewCountResponse `json:"reviews_per_day"`
+	StatusCounts      map[string]int             `json:"status_counts"`
+	IntervalHistogram []intervalBucketResponse   `json:"interval_histogram"`
+}
+
+type studyStreakResponse struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
 type dueCountResponse struct {
 	DueCount int `json:"due_count"`
 }
@@ -111,45 +203,45 @@ type characterReviewQueueResponse struct {
 
 func SaveVocab(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	var req saveVocabRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
-	id, err := srs.SaveSegment(req.Headword, req.Pinyin, req.English, req.TranslationID, req.Snippet, req.Status)
+	id, err := srs.SaveSegment(currentUserID(r), req.Headword, req.Pinyin, req.English, req.TranslationID, req.Snippet, req.Status)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	_ = srs.ExtractAndLinkCharacters(id, req.Headword, req.Pinyin, req.English, nil)
+	_ = srs.ExtractAndLinkCharacters(currentUserID(r), id, req.Headword, req.Pinyin, req.English, nil)
 	WriteJSON(w, http.StatusOK, saveVocabResponse{SegmentID: id})
 }
 
 func UpdateVocabStatus(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	var req updateVocabStatusRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 	var err error
 	if strings.TrimSpace(req.CharacterID) != "" {
-		err = srs.UpdateCharacterStatus(req.CharacterID, req.Status)
+		err = srs.UpdateCharacterStatus(currentUserID(r), req.CharacterID, req.Status)
 	} else {
-		err = srs.UpdateSegmentStatus(req.SegmentID, req.Status)
+		err = srs.UpdateSegmentStatus(currentUserID(r), req.SegmentID, req.Status)
 	}
 	if err != nil {
 		if err == translation.ErrNotFound {
-			WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Saved item not found"})
+			WriteError(w, http.StatusNotFound, "Saved item not found")
 			return
 		}
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	WriteJSON(w, http.StatusOK, okResponse{Ok: true})
@@ -157,17 +249,17 @@ func UpdateVocabStatus(w http.ResponseWriter, r *http.Request) {
 
 func RecordLookup(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	var req recordLookupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
-	info, ok := srs.RecordLookup(req.SegmentID)
+	info, ok := srs.RecordLookup(currentUserID(r), req.SegmentID)
 	if !ok {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Segment not found"})
+		WriteError(w, http.StatusNotFound, "Segment not found")
 		return
 	}
 	WriteJSON(w, http.StatusOK, recordLookupResponse{
@@ -179,7 +271,7 @@ func RecordLookup(w http.ResponseWriter, r *http.Request) {
 
 func GetVocabSRSInfo(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	headwords := strings.TrimSpace(r.URL.Query().Get("headwords"))
@@ -188,9 +280,9 @@ func GetVocabSRSInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	parts := strings.Split(headwords, ",")
-	items, err := srs.GetSegmentSRSInfo(parts)
+	items, err := srs.GetSegmentSRSInfo(currentUserID(r), parts)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	resp := make([]vocabSRSInfoResponse, 0, len(items))
@@ -210,41 +302,143 @@ func GetVocabSRSInfo(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, vocabSRSInfoListResponse{Items: resp})
 }
 
+func GetCharacterWords(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	characterID := pathParam(r, "character_id")
+	words, err := srs.GetWordsForCharacter(currentUserID(r), characterID)
+	if err != nil {
+		if err == translation.ErrNotFound {
+			WriteError(w, http.StatusNotFound, "Character not found")
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+	resp := make([]characterWordResponse, 0, len(words))
+	for _, word := range words {
+		resp = append(resp, characterWordResponse{
+			SegmentID: word.ID,
+			Headword:  word.Headword,
+			Pinyin:    word.Pinyin,
+			English:   word.English,
+			Status:    word.Status,
+		})
+	}
+	WriteJSON(w, http.StatusOK, characterWordsResponse{CharacterID: characterID, Words: resp})
+}
+
+func ListRecentLookups(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 20)
+	words, err := srs.ListRecentLookups(currentUserID(r), limit)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	resp := make([]recentLookupResponse, 0, len(words))
+	for _, word := range words {
+		resp = append(resp, recentLookupResponse{
+			SegmentID:  word.ID,
+			Headword:   word.Headword,
+			Pinyin:     word.Pinyin,
+			English:    word.English,
+			Status:     word.Status,
+			LookedUpAt: word.LookedUpAt,
+		})
+	}
+	WriteJSON(w, http.StatusOK, recentLookupsResponse{Words: resp})
+}
+
+func GetStudyStreak(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	current, longest, err := srs.GetStudyStreak(currentUserID(r))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, studyStreakResponse{Current: current, Longest: longest})
+}
+
 func GetReviewQueue(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "flashcard" && mode != "cloze" {
+		WriteError(w, http.StatusBadRequest, "mode must be flashcard or cloze")
+		return
+	}
+	hskLevel := 0
+	if raw := r.URL.Query().Get("hsk"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > translation.MaxHSKLevel {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("hsk must be between 1 and %d", translation.MaxHSKLevel))
+			return
+		}
+		hskLevel = parsed
+	}
 	limit := parseIntDefault(r.URL.Query().Get("limit"), 10)
-	cards, err := srs.GetSegmentReviewQueue(limit)
+	cards, err := srs.GetSegmentReviewQueue(currentUserID(r), limit, r.URL.Query().Get("order"), hskLevel)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if mode == "cloze" {
+		// Words with no sentence to blank out are left to flashcard reviews.
+		clozeCards := make([]clozeCardResponse, 0, len(cards))
+		for _, c := range cards {
+			cloze, ok := srs.GetClozeCard(currentUserID(r), c.SegmentID)
+			if !ok {
+				continue
+			}
+			clozeCards = append(clozeCards, clozeCardResponse{
+				SegmentID: cloze.SegmentID,
+				Pinyin:    cloze.Pinyin,
+				English:   cloze.English,
+				Sentence:  cloze.Sentence,
+				Answer:    cloze.Answer,
+			})
+		}
+		WriteJSON(w, http.StatusOK, clozeQueueResponse{Cards: clozeCards, DueCount: srs.GetSegmentDueCount(currentUserID(r))})
 		return
 	}
 	respCards := make([]reviewCardResponse, 0, len(cards))
 	for _, c := range cards {
 		respCards = append(respCards, reviewCardResponse{
-			SegmentID: c.SegmentID,
-			Headword:  c.Headword,
-			Pinyin:    c.Pinyin,
-			English:   c.English,
-			Snippets:  c.Snippets,
+			SegmentID:     c.SegmentID,
+			Headword:      c.Headword,
+			Pinyin:        c.Pinyin,
+			English:       c.English,
+			Snippets:      c.Snippets,
+			Examples:      c.Examples,
+			FrequencyRank: c.FrequencyRank,
+			HSKLevel:      c.HSKLevel,
 		})
 	}
 	WriteJSON(w, http.StatusOK, reviewQueueResponse{
 		Cards:    respCards,
-		DueCount: srs.GetSegmentDueCount(),
+		DueCount: srs.GetSegmentDueCount(currentUserID(r)),
 	})
 }
 
 func RecordReviewAnswer(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	var req reviewAnswerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": "Invalid JSON payload"})
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 	entityID := strings.TrimSpace(req.SegmentID)
@@ -257,13 +451,13 @@ func RecordReviewAnswer(w http.ResponseWriter, r *http.Request) {
 	} else if entityType == "" {
 		entityType = "segment"
 	}
-	res, ok, err := srs.RecordReviewAnswer(entityID, entityType, req.Grade)
+	res, ok, err := srs.RecordReviewAnswer(currentUserID(r), entityID, entityType, req.Grade)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	if !ok {
-		WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Saved item not found"})
+		WriteError(w, http.StatusNotFound, "Saved item not found")
 		return
 	}
 	WriteJSON(w, http.StatusOK, reviewAnswerResponse{
@@ -275,23 +469,92 @@ func RecordReviewAnswer(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func UndoReviewAnswer(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var req reviewAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	entityID := strings.TrimSpace(req.SegmentID)
+	entityType := strings.TrimSpace(req.EntityType)
+	if strings.TrimSpace(req.CharacterID) != "" {
+		entityID = strings.TrimSpace(req.CharacterID)
+		if entityType == "" {
+			entityType = "character"
+		}
+	} else if entityType == "" {
+		entityType = "segment"
+	}
+	if entityID == "" {
+		WriteError(w, http.StatusBadRequest, "segment_id or character_id is required")
+		return
+	}
+	undone, err := srs.UndoLastReview(currentUserID(r), entityID, entityType)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	remainingDue := srs.GetSegmentDueCount(currentUserID(r))
+	if entityType == "character" {
+		remainingDue = srs.GetCharacterDueCount(currentUserID(r))
+	}
+	WriteJSON(w, http.StatusOK, undoReviewResponse{Undone: undone, RemainingDue: remainingDue})
+}
+
+func GetReviewStats(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	days := parseIntDefault(r.URL.Query().Get("days"), 30)
+	if days <= 0 || days > 365 {
+		WriteError(w, http.StatusBadRequest, "days must be between 1 and 365")
+		return
+	}
+	stats, err := srs.GetSRSStats(currentUserID(r), days)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	perDay := make([]dailyReviewCountResponse, 0, len(stats.ReviewsPerDay))
+	for _, d := range stats.ReviewsPerDay {
+		perDay = append(perDay, dailyReviewCountResponse{Date: d.Date, Count: d.Count, Passed: d.Passed})
+	}
+	histogram := make([]intervalBucketResponse, 0, len(stats.IntervalHistogram))
+	for _, b := range stats.IntervalHistogram {
+		histogram = append(histogram, intervalBucketResponse{Label: b.Label, MinDays: b.MinDays, MaxDays: b.MaxDays, Count: b.Count})
+	}
+	WriteJSON(w, http.StatusOK, srsStatsResponse{
+		Days:              stats.Days,
+		TotalReviews:      stats.TotalReviews,
+		RetentionRate:     stats.RetentionRate,
+		ReviewsPerDay:     perDay,
+		StatusCounts:      stats.StatusCounts,
+		IntervalHistogram: histogram,
+	})
+}
+
 func GetReviewCount(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
-	WriteJSON(w, http.StatusOK, dueCountResponse{DueCount: srs.GetSegmentDueCount()})
+	WriteJSON(w, http.StatusOK, dueCountResponse{DueCount: srs.GetSegmentDueCount(currentUserID(r))})
 }
 
 func GetCharacterReviewQueue(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
 		return
 	}
 	limit := parseIntDefault(r.URL.Query().Get("limit"), 10)
-	cards, err := srs.GetCharacterReviewQueue(limit)
+	cards, err := srs.GetCharacterReviewQueue(currentUserID(r), limit)
 	if err != nil {
-		WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	respCards := make([]characterReviewCardResponse, 0, len(cards))
@@ -315,14 +578,104 @@ func GetCharacterReviewQueue(w http.ResponseWriter, r *http.Request) {
 	}
 	WriteJSON(w, http.StatusOK, characterReviewQueueResponse{
 		Cards:    respCards,
-		DueCount: srs.GetCharacterDueCount(),
+		DueCount: srs.GetCharacterDueCount(currentUserID(r)),
 	})
 }
 
 func GetCharacterReviewCount(w http.ResponseWriter, r *http.Request) {
 	if err := validateDependencies(); err != nil {
-		WriteJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		writeStoreError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, dueCountResponse{DueCount: srs.GetCharacterDueCount(currentUserID(r))})
+}
+
+// ExportAnkiDeck downloads saved words and their schedules as a note file for
+// Anki's text importer.
+func ExportAnkiDeck(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	progressJSON, err := srs.ExportProgressJSON(currentUserID(r))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	deck, err := translation.FormatAnkiTSV(progressJSON)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/tab-separated-values; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"language_app_anki.txt\"")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(deck))
+}
+
+// ImportVocabCSV saves the words of an uploaded CSV or wordlist next to the
+// existing vocabulary, skipping words already saved.
+func ImportVocabCSV(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxVocabImportBytes+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid multipart payload")
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "file is required. Please upload a .csv file.")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxVocabImportBytes+1))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Could not read file")
+		return
+	}
+	if len(content) > maxVocabImportBytes {
+		WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File too large. Maximum size is %d MB.", maxVocabImportBytes>>20))
+		return
+	}
+
+	result, err := srs.ImportVocabCSV(currentUserID(r), string(content))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	resp := vocabImportResponse{
+		Imported: result.Imported,
+		Skipped:  result.Skipped,
+		Errors:   make([]vocabImportErrorResponse, 0, len(result.Errors)),
+	}
+	for _, rowErr := range result.Errors {
+		resp.Errors = append(resp.Errors, vocabImportErrorResponse{Line: rowErr.Line, Message: rowErr.Message})
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// ExportVocabCSV downloads saved words and characters with their schedules
+// as a spreadsheet-friendly CSV.
+func ExportVocabCSV(w http.ResponseWriter, r *http.Request) {
+	if err := validateDependencies(); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	progressJSON, err := srs.ExportProgressJSON(currentUserID(r))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	sheet, err := translation.FormatVocabCSV(progressJSON)
+	if err != nil {
+		writeStoreError(w, err)
 		return
 	}
-	WriteJSON(w, http.StatusOK, dueCountResponse{DueCount: srs.GetCharacterDueCount()})
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"language_app_vocab.csv\"")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(sheet))
 }
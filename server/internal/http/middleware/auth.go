@@ -1,48 +1,101 @@
 package middleware
 
 import (
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/anath2/language-app/internal/config"
+	"github.com/anath2/language-app/internal/translation"
 )
 
 const sessionCookieName = "session"
 
+// ErrSessionsUnavailable is returned by session listing and revocation when
+// the manager has no session store.
+var ErrSessionsUnavailable = errors.New("session store is not configured")
+
 type sessionPayload struct {
-	Authenticated bool  `json:"authenticated"`
-	CreatedAtUnix int64 `json:"created_at_unix"`
+	Authenticated bool   `json:"authenticated"`
+	CreatedAtUnix int64  `json:"created_at_unix"`
+	UserID        string `json:"user_id,omitempty"`
+	SessionID     string `json:"sid,omitempty"`
+}
+
+// SessionStore records issued sessions so they can be listed and revoked.
+type SessionStore interface {
+	CreateSession(id string, userID string, userAgent string) error
+	TouchSession(id string, idleTimeout time.Duration) (string, bool, error)
+	RevokeSession(id string) error
+	RevokeUserSessions(userID string) (int, error)
+	ListUserSessions(userID string, since time.Time) ([]translation.Session, error)
+}
+
+type userIDContextKey struct{}
+
+// UserIDFromContext returns the signed-in user id stored by Auth. It is empty
+// for public routes and for sessions issued before user accounts existed.
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey{}).(string)
+	return userID
 }
 
 type SessionManager struct {
 	secretKey            []byte
 	sessionMaxAgeSeconds int
+	sessionIdleTimeout   time.Duration
 	secureCookies        bool
+	// store is optional: without it sessions are only checked by signature
+	// and age, cannot be listed or revoked, and never expire when idle.
+	store SessionStore
 }
 
 func NewSessionManager(cfg config.Config) *SessionManager {
 	return &SessionManager{
 		secretKey:            []byte(cfg.AppSecretKey),
 		sessionMaxAgeSeconds: cfg.SessionMaxAgeSeconds,
+		sessionIdleTimeout:   cfg.SessionIdleTimeout,
 		secureCookies:        cfg.SecureCookies,
 	}
 }
 
+// UseStore makes the manager record sessions in store and honor only
+// sessions present there, so revoked cookies stop working immediately.
+func (sm *SessionManager) UseStore(store SessionStore) {
+	sm.store = store
+}
+
 func (sm *SessionManager) VerifyPassword(input string, expected string) bool {
 	return subtle.ConstantTimeCompare([]byte(input), []byte(expected)) == 1
 }
 
-func (sm *SessionManager) SetSessionCookie(w http.ResponseWriter, r *http.Request) error {
+// SetSessionCookie issues a session cookie scoped to userID.
+func (sm *SessionManager) SetSessionCookie(w http.ResponseWriter, r *http.Request, userID string) error {
 	payload := sessionPayload{
 		Authenticated: true,
 		CreatedAtUnix: time.Now().UTC().Unix(),
+		UserID:        userID,
+	}
+	if sm.store != nil {
+		sessionID, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		if err := sm.store.CreateSession(sessionID, userID, r.UserAgent()); err != nil {
+			return err
+		}
+		payload.SessionID = sessionID
 	}
 
 	token, err := sm.signPayload(payload)
@@ -75,13 +128,96 @@ func (sm *SessionManager) ClearSessionCookie(w http.ResponseWriter, r *http.Requ
 }
 
 func (sm *SessionManager) VerifySessionFromRequest(r *http.Request) bool {
+	_, ok := sm.SessionUserID(r)
+	return ok
+}
+
+// SessionUserID returns the user id of a valid session cookie.
+func (sm *SessionManager) SessionUserID(r *http.Request) (string, bool) {
+	payload, ok := sm.sessionFromRequest(r)
+	if !ok {
+		return "", false
+	}
+	if sm.store == nil {
+		return payload.UserID, true
+	}
+	// Cookies issued before sessions were recorded carry no id and cannot be
+	// revoked, so they are no longer honored.
+	if payload.SessionID == "" {
+		return "", false
+	}
+	userID, active, err := sm.store.TouchSession(payload.SessionID, sm.sessionIdleTimeout)
+	if err != nil {
+		log.Printf("session lookup failed: %v", err)
+		return "", false
+	}
+	return userID, active
+}
+
+// CurrentSessionID returns the recorded session id of the request's cookie,
+// or "" when there is none.
+func (sm *SessionManager) CurrentSessionID(r *http.Request) string {
+	payload, ok := sm.sessionFromRequest(r)
+	if !ok {
+		return ""
+	}
+	return payload.SessionID
+}
+
+// RevokeCurrentSession revokes the session of the request's cookie, if any.
+func (sm *SessionManager) RevokeCurrentSession(r *http.Request) error {
+	sessionID := sm.CurrentSessionID(r)
+	if sm.store == nil || sessionID == "" {
+		return nil
+	}
+	return sm.store.RevokeSession(sessionID)
+}
+
+// RevokeUserSessions revokes every session of userID, on every device.
+func (sm *SessionManager) RevokeUserSessions(userID string) (int, error) {
+	if sm.store == nil {
+		return 0, ErrSessionsUnavailable
+	}
+	return sm.store.RevokeUserSessions(userID)
+}
+
+// ListUserSessions returns the unexpired, unrevoked sessions of userID.
+func (sm *SessionManager) ListUserSessions(userID string) ([]translation.Session, error) {
+	if sm.store == nil {
+		return nil, ErrSessionsUnavailable
+	}
+	now := time.Now().UTC()
+	sessions, err := sm.store.ListUserSessions(userID, now.Add(-time.Duration(sm.sessionMaxAgeSeconds)*time.Second))
+	if err != nil || sm.sessionIdleTimeout <= 0 {
+		return sessions, err
+	}
+	active := sessions[:0]
+	for _, session := range sessions {
+		seen, err := time.Parse(time.RFC3339Nano, session.LastSeenAt)
+		if err == nil && now.Sub(seen) > sm.sessionIdleTimeout {
+			continue
+		}
+		active = append(active, session)
+	}
+	return active, nil
+}
+
+func (sm *SessionManager) sessionFromRequest(r *http.Request) (sessionPayload, bool) {
 	cookie, err := r.Cookie(sessionCookieName)
 	if err != nil || cookie.Value == "" {
-		return false
+		return sessionPayload{}, false
 	}
 	return sm.verifyToken(cookie.Value)
 }
 
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
 func (sm *SessionManager) signPayload(payload sessionPayload) (string, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -93,10 +229,10 @@ func (sm *SessionManager) signPayload(payload sessionPayload) (string, error) {
 	return payloadEncoded + "." + signatureEncoded, nil
 }
 
-func (sm *SessionManager) verifyToken(token string) bool {
+func (sm *SessionManager) verifyToken(token string) (sessionPayload, bool) {
 	parts := strings.Split(token, ".")
 	if len(parts) != 2 {
-		return false
+		return sessionPayload{}, false
 	}
 
 	payloadEncoded := parts[0]
@@ -104,28 +240,28 @@ func (sm *SessionManager) verifyToken(token string) bool {
 
 	providedSignature, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
-		return false
+		return sessionPayload{}, false
 	}
 
 	if subtle.ConstantTimeCompare(providedSignature, expectedSignature) != 1 {
-		return false
+		return sessionPayload{}, false
 	}
 
 	payloadBytes, err := base64.RawURLEncoding.DecodeString(payloadEncoded)
 	if err != nil {
-		return false
+		return sessionPayload{}, false
 	}
 
 	var payload sessionPayload
 	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-		return false
+		return sessionPayload{}, false
 	}
 	if !payload.Authenticated {
-		return false
+		return sessionPayload{}, false
 	}
 
 	age := time.Now().UTC().Unix() - payload.CreatedAtUnix
-	return age >= 0 && age <= int64(sm.sessionMaxAgeSeconds)
+	return payload, age >= 0 && age <= int64(sm.sessionMaxAgeSeconds)
 }
 
 func (sm *SessionManager) signature(payloadEncoded string) []byte {
@@ -156,24 +292,36 @@ func (sm *SessionManager) cookieShouldBeSecure(r *http.Request) bool {
 	return sm.secureCookies
 }
 
+// Auth rejects requests without a valid session. With cfg.DisableAuth they
+// are let through as the instance owner instead; a signed-in session still
+// runs as its own user. Config.Load refuses DisableAuth with secure cookies,
+// and Auth checks again so a Config built in code cannot skip that guard.
 func Auth(cfg config.Config, sessionManager *SessionManager) func(http.Handler) http.Handler {
+	bypass := cfg.DisableAuth && !cfg.SecureCookies
+	if bypass {
+		log.Printf("DISABLE_AUTH is set: requests without a session run as the instance owner")
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			path := r.URL.Path
 
-			if path == "/api/auth/login" || path == "/health" {
+			if path == "/api/auth/login" || path == "/health" || path == "/health/ready" {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			if sessionManager.VerifySessionFromRequest(r) {
+			if userID, ok := sessionManager.SessionUserID(r); ok {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userIDContextKey{}, userID)))
+				return
+			}
+			if bypass {
 				next.ServeHTTP(w, r)
 				return
 			}
 
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
-			_, _ = w.Write([]byte(`{"detail":"Not authenticated"}`))
+			_, _ = w.Write([]byte(`{"code":"unauthorized","detail":"Not authenticated"}`))
 		})
 	}
 }
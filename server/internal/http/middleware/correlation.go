@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/anath2/language-app/internal/logging"
+)
+
+// CorrelationIDHeader carries the correlation id on requests and responses.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// maxCorrelationIDLength bounds client-supplied ids so they cannot bloat logs.
+const maxCorrelationIDLength = 64
+
+// CorrelationID tags each request's context with a correlation id, reusing
+// the client's X-Correlation-ID when it sends a usable one, and echoes it on
+// the response.
+func CorrelationID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(CorrelationIDHeader))
+		if id == "" || len(id) > maxCorrelationIDLength || strings.ContainsAny(id, " \t\r\n\"") {
+			id = logging.NewCorrelationID()
+		}
+		w.Header().Set(CorrelationIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithCorrelationID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/anath2/language-app/internal/config"
+	"github.com/go-chi/cors"
+)
+
+// CORS answers cross-origin requests to /api/* from cfg.AllowedOrigins,
+// including preflights, which never reach the handlers behind it. It must run
+// before Auth, since preflights carry no credentials. With no origins
+// configured it does nothing and the API stays same-origin only.
+func CORS(cfg config.Config, allowedHeaders []string, exposedHeaders []string) func(http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	withCORS := cors.Handler(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   allowedHeaders,
+		ExposedHeaders:   exposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           300,
+	})
+	return func(next http.Handler) http.Handler {
+		apiHandler := withCORS(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				apiHandler.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateLimitBuckets bounds limiter memory; idle full buckets are dropped
+// once it is reached.
+const maxRateLimitBuckets = 10000
+
+// RateLimiter is an in-memory token bucket per session. Each session may make
+// burst requests at once and regains perMinute requests per minute.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(perMinute int, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for key. When none is left it returns how long until
+// the next one is available.
+func (l *RateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitBuckets {
+			l.evictIdle(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if l.rate <= 0 {
+		return false, time.Minute
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// evictIdle drops buckets that have refilled completely, since a fresh
+// bucket would behave the same. Callers hold l.mu.
+func (l *RateLimiter) evictIdle(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitExpensive applies limiter to the endpoints that call the LLM,
+// keyed by session cookie. Other requests pass through untouched.
+func RateLimitExpensive(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isRateLimitedPath(r.Method, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ok, wait := limiter.allow(rateLimitKey(r))
+			if !ok {
+				seconds := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"code":"rate_limited","detail":"Rate limit exceeded"}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isRateLimitedPath(method string, path string) bool {
+	if method != http.MethodPost {
+		return false
+	}
+	return path == "/api/translations" ||
+		path == "/api/translations/from-url" ||
+		path == "/api/translations/sentence-segments/translate" ||
+		path == "/api/pronunciation/score" ||
+		(strings.HasPrefix(path, "/api/translations/") && (strings.HasSuffix(path, "/chat/new") || strings.HasSuffix(path, "/resend")))
+}
+
+// rateLimitKey identifies the caller by a hash of the session cookie, falling
+// back to the client IP when there is no session.
+func rateLimitKey(r *http.Request) string {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+		sum := sha256.Sum256([]byte(cookie.Value))
+		return "session:" + hex.EncodeToString(sum[:])
+	}
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	return "ip:" + host
+}
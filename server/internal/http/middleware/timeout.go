@@ -12,7 +12,7 @@ func TimeoutUnlessStream(timeout time.Duration) func(http.Handler) http.Handler
 	return func(next http.Handler) http.Handler {
 		withTimeout := chimiddleware.Timeout(timeout)(next)
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if isTranslationStreamPath(r.URL.Path) {
+			if isTranslationStreamPath(r.URL.Path) || isLongRunningAdminPath(r.URL.Path) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -21,7 +21,15 @@ func TimeoutUnlessStream(timeout time.Duration) func(http.Handler) http.Handler
 	}
 }
 
+// isLongRunningAdminPath matches admin endpoints that make many model calls
+// and report when done, such as evaluating a segmentation instruction.
+func isLongRunningAdminPath(path string) bool {
+	return path == "/api/admin/segmentation/evaluate"
+}
+
 func isTranslationStreamPath(path string) bool {
 	return (strings.HasPrefix(path, "/api/translations/") && strings.HasSuffix(path, "/stream")) ||
-		(strings.HasPrefix(path, "/api/translations/") && strings.HasSuffix(path, "/chat/new"))
+		(strings.HasPrefix(path, "/api/translations/") && strings.HasSuffix(path, "/chat/new")) ||
+		(strings.HasPrefix(path, "/api/translations/") && strings.HasSuffix(path, "/resend")) ||
+		(strings.HasPrefix(path, "/api/translations/") && strings.HasSuffix(path, "/regenerate"))
 }
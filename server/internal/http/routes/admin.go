@@ -12,4 +12,13 @@ func RegisterAdminRoutes(r chi.Router) {
 	r.Method(http.MethodPost, "/api/admin/progress/import", http.HandlerFunc(handlers.ImportProgress))
 	r.Method(http.MethodGet, "/api/admin/profile", http.HandlerFunc(handlers.GetProfile))
 	r.Method(http.MethodPost, "/api/admin/profile", http.HandlerFunc(handlers.UpdateProfile))
+	r.Method(http.MethodGet, "/api/admin/users", http.HandlerFunc(handlers.ListUsers))
+	r.Method(http.MethodPost, "/api/admin/users", http.HandlerFunc(handlers.CreateUser))
+	r.Method(http.MethodPost, "/api/admin/dictionary/reload", http.HandlerFunc(handlers.ReloadDictionary))
+	r.Method(http.MethodPost, "/api/admin/segmentation/reload-instruction", http.HandlerFunc(handlers.ReloadSegmentationInstruction))
+	r.Method(http.MethodPost, "/api/admin/segmentation/evaluate", http.HandlerFunc(handlers.EvaluateSegmentationInstruction))
+	r.Method(http.MethodPost, "/api/admin/backup", http.HandlerFunc(handlers.CreateBackup))
+	r.Method(http.MethodPost, "/api/admin/db/check", http.HandlerFunc(handlers.CheckDatabase))
+	r.Method(http.MethodGet, "/api/admin/metrics", http.HandlerFunc(handlers.GetMetrics))
+	r.Method(http.MethodGet, "/api/admin/translations/{translation_id}/error", http.HandlerFunc(handlers.GetTranslationError))
 }
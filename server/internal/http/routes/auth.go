@@ -12,4 +12,6 @@ import (
 func RegisterAuthRoutes(r chi.Router, cfg config.Config, sessionManager *middleware.SessionManager) {
 	r.Method(http.MethodPost, "/api/auth/login", handlers.Login(cfg, sessionManager))
 	r.Method(http.MethodPost, "/api/auth/logout", handlers.Logout(sessionManager))
+	r.Method(http.MethodPost, "/api/auth/logout-all", handlers.LogoutAll(sessionManager))
+	r.Method(http.MethodGet, "/api/auth/sessions", handlers.ListSessions(sessionManager))
 }
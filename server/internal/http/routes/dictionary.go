@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/anath2/language-app/internal/http/handlers"
+	"github.com/go-chi/chi/v5"
+)
+
+func RegisterDictionaryRoutes(r chi.Router) {
+	r.Method(http.MethodPost, "/api/dictionary/lookup", http.HandlerFunc(handlers.LookupDictionaryWord))
+	r.Method(http.MethodGet, "/api/dictionary/search", http.HandlerFunc(handlers.SearchDictionary))
+	r.Method(http.MethodGet, "/api/dictionary/custom", http.HandlerFunc(handlers.ListCustomDictionary))
+	r.Method(http.MethodPost, "/api/dictionary/custom", http.HandlerFunc(handlers.SaveCustomDictionaryEntry))
+	r.Method(http.MethodGet, "/api/dictionary/custom/{headword}", http.HandlerFunc(handlers.GetCustomDictionaryEntry))
+	r.Method(http.MethodDelete, "/api/dictionary/custom/{headword}", http.HandlerFunc(handlers.DeleteCustomDictionaryEntry))
+}
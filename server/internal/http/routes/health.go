@@ -9,4 +9,5 @@ import (
 
 func RegisterHealthRoutes(r chi.Router) {
 	r.Method(http.MethodGet, "/health", http.HandlerFunc(handlers.Health))
+	r.Method(http.MethodGet, "/health/ready", http.HandlerFunc(handlers.Ready))
 }
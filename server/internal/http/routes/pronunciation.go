@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/anath2/language-app/internal/http/handlers"
+	"github.com/go-chi/chi/v5"
+)
+
+func RegisterPronunciationRoutes(r chi.Router) {
+	r.Method(http.MethodPost, "/api/pronunciation/score", http.HandlerFunc(handlers.ScorePronunciation))
+}
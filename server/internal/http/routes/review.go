@@ -9,6 +9,10 @@ import (
 
 func RegisterReviewRoutes(r chi.Router) {
 	r.Method(http.MethodPost, "/api/review/answer", http.HandlerFunc(handlers.RecordReviewAnswer))
+	r.Method(http.MethodPost, "/api/review/undo", http.HandlerFunc(handlers.UndoReviewAnswer))
+	r.Method(http.MethodGet, "/api/review/stats", http.HandlerFunc(handlers.GetReviewStats))
+	r.Method(http.MethodGet, "/api/stats/streak", http.HandlerFunc(handlers.GetStudyStreak))
+	r.Method(http.MethodGet, "/api/review/export/anki", http.HandlerFunc(handlers.ExportAnkiDeck))
 	r.Method(http.MethodGet, "/api/review/words/queue", http.HandlerFunc(handlers.GetReviewQueue))
 	r.Method(http.MethodGet, "/api/review/words/count", http.HandlerFunc(handlers.GetReviewCount))
 	r.Method(http.MethodGet, "/api/review/characters/queue", http.HandlerFunc(handlers.GetCharacterReviewQueue))
@@ -13,6 +13,7 @@ func TestRegisterHealthRoutes(t *testing.T) {
 	RegisterHealthRoutes(r)
 
 	assertRouteRegistered(t, r, http.MethodGet, "/health")
+	assertRouteRegistered(t, r, http.MethodGet, "/health/ready")
 }
 
 func TestRegisterOCRRoutes(t *testing.T) {
@@ -23,6 +24,19 @@ func TestRegisterOCRRoutes(t *testing.T) {
 	assertRouteRegistered(t, r, http.MethodPost, "/api/ocr/extract-text")
 }
 
+func TestRegisterDictionaryRoutes(t *testing.T) {
+	r := chi.NewRouter()
+
+	RegisterDictionaryRoutes(r)
+
+	assertRouteRegistered(t, r, http.MethodPost, "/api/dictionary/lookup")
+	assertRouteRegistered(t, r, http.MethodGet, "/api/dictionary/search")
+	assertRouteRegistered(t, r, http.MethodGet, "/api/dictionary/custom")
+	assertRouteRegistered(t, r, http.MethodPost, "/api/dictionary/custom")
+	assertRouteRegistered(t, r, http.MethodGet, "/api/dictionary/custom/{headword}")
+	assertRouteRegistered(t, r, http.MethodDelete, "/api/dictionary/custom/{headword}")
+}
+
 func assertRouteRegistered(t *testing.T, r chi.Router, method string, path string) {
 	t.Helper()
 
@@ -9,16 +9,39 @@ import (
 
 func RegisterTranslationRoutes(r chi.Router) {
 	r.Method(http.MethodPost, "/api/translations", http.HandlerFunc(handlers.CreateTranslation))
+	r.Method(http.MethodPost, "/api/translations/from-url", http.HandlerFunc(handlers.CreateTranslationFromURL))
 	r.Method(http.MethodGet, "/api/translations", http.HandlerFunc(handlers.ListTranslations))
-	r.Method(http.MethodGet, "/api/translations/{translation_id}", http.HandlerFunc(handlers.GetTranslation))
-	r.Method(http.MethodGet, "/api/translations/{translation_id}/status", http.HandlerFunc(handlers.GetTranslationStatus))
-	r.Method(http.MethodPatch, "/api/translations/{translation_id}", http.HandlerFunc(handlers.UpdateTranslation))
-	r.Method(http.MethodDelete, "/api/translations/{translation_id}", http.HandlerFunc(handlers.DeleteTranslation))
-	r.Method(http.MethodGet, "/api/translations/{translation_id}/stream", http.HandlerFunc(handlers.TranslationStream))
 	r.Method(http.MethodPost, "/api/translations/sentence-segments/translate", http.HandlerFunc(handlers.TranslateSentenceSegments))
-	r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/new", http.HandlerFunc(handlers.CreateChatMessage))
-	r.Method(http.MethodGet, "/api/translations/{translation_id}/chat/list", http.HandlerFunc(handlers.ListChatMessages))
-	r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/clear", http.HandlerFunc(handlers.ClearChatMessages))
-	r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/messages/{message_id}/accept", http.HandlerFunc(handlers.AcceptReviewCard))
-	r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/messages/{message_id}/reject", http.HandlerFunc(handlers.RejectReviewCard))
+	r.Method(http.MethodPost, "/api/segments/preview", http.HandlerFunc(handlers.PreviewSegments))
+	// Per-translation routes only reach translations owned by the signed-in user.
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.RequireTranslationOwner)
+		r.Method(http.MethodGet, "/api/translations/{translation_id}", http.HandlerFunc(handlers.GetTranslation))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/status", http.HandlerFunc(handlers.GetTranslationStatus))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/neighbors", http.HandlerFunc(handlers.GetTranslationNeighbors))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/chunks", http.HandlerFunc(handlers.GetTranslationChunks))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/plaintext", http.HandlerFunc(handlers.ExportTranslationPlaintext))
+		r.Method(http.MethodPatch, "/api/translations/{translation_id}", http.HandlerFunc(handlers.UpdateTranslation))
+		r.Method(http.MethodDelete, "/api/translations/{translation_id}", http.HandlerFunc(handlers.DeleteTranslation))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/favorite", http.HandlerFunc(handlers.SetTranslationFavorite))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/tags", http.HandlerFunc(handlers.AddTranslationTag))
+		r.Method(http.MethodDelete, "/api/translations/{translation_id}/tags", http.HandlerFunc(handlers.RemoveTranslationTag))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/stream", http.HandlerFunc(handlers.TranslationStream))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/segments/{sentence_idx}/{seg_idx}/retranslate", http.HandlerFunc(handlers.RetranslateSegment))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/segments/{sentence_idx}/merge", http.HandlerFunc(handlers.MergeSegments))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/segments/{sentence_idx}/{seg_idx}/note", http.HandlerFunc(handlers.SetSegmentNote))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/segments/{sentence_idx}/{seg_idx}/note", http.HandlerFunc(handlers.GetSegmentNote))
+		r.Method(http.MethodDelete, "/api/translations/{translation_id}/segments/{sentence_idx}/{seg_idx}/note", http.HandlerFunc(handlers.DeleteSegmentNote))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/new", http.HandlerFunc(handlers.CreateChatMessage))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/chat/list", http.HandlerFunc(handlers.ListChatMessages))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/clear", http.HandlerFunc(handlers.ClearChatMessages))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/chat/export", http.HandlerFunc(handlers.ExportChatMessages))
+		r.Method(http.MethodPatch, "/api/translations/{translation_id}/chat/messages/{message_id}", http.HandlerFunc(handlers.EditChatMessage))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/messages/{message_id}/resend", http.HandlerFunc(handlers.ResendChatMessage))
+		r.Method(http.MethodGet, "/api/translations/{translation_id}/chat/messages/{message_id}/stream-replay", http.HandlerFunc(handlers.ReplayChatTurn))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/messages/{message_id}/accept", http.HandlerFunc(handlers.AcceptReviewCard))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/messages/{message_id}/reject", http.HandlerFunc(handlers.RejectReviewCard))
+		r.Method(http.MethodPatch, "/api/translations/{translation_id}/chat/messages/{message_id}/card", http.HandlerFunc(handlers.UpdateReviewCard))
+		r.Method(http.MethodPost, "/api/translations/{translation_id}/chat/messages/{message_id}/regenerate", http.HandlerFunc(handlers.RegenerateReviewCard))
+	})
 }
@@ -12,4 +12,8 @@ func RegisterVocabRoutes(r chi.Router) {
 	r.Method(http.MethodPost, "/api/vocab/status", http.HandlerFunc(handlers.UpdateVocabStatus))
 	r.Method(http.MethodPost, "/api/vocab/lookup", http.HandlerFunc(handlers.RecordLookup))
 	r.Method(http.MethodGet, "/api/vocab/srs-info", http.HandlerFunc(handlers.GetVocabSRSInfo))
+	r.Method(http.MethodGet, "/api/vocab/recent", http.HandlerFunc(handlers.ListRecentLookups))
+	r.Method(http.MethodGet, "/api/vocab/export.csv", http.HandlerFunc(handlers.ExportVocabCSV))
+	r.Method(http.MethodPost, "/api/vocab/import.csv", http.HandlerFunc(handlers.ImportVocabCSV))
+	r.Method(http.MethodGet, "/api/vocab/characters/{character_id}/words", http.HandlerFunc(handlers.GetCharacterWords))
 }
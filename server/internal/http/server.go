@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	stdhttp "net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/anath2/language-app/internal/config"
@@ -12,31 +13,46 @@ import (
 	"github.com/anath2/language-app/internal/http/middleware"
 	"github.com/anath2/language-app/internal/http/routes"
 	ilchat "github.com/anath2/language-app/internal/intelligence/chat"
+	ilspeech "github.com/anath2/language-app/internal/intelligence/speech"
 	iltrans "github.com/anath2/language-app/internal/intelligence/translation"
 	"github.com/anath2/language-app/internal/migrations"
 	"github.com/anath2/language-app/internal/queue"
+	"github.com/anath2/language-app/internal/textsplit"
 	"github.com/anath2/language-app/internal/translation"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 )
 
 func NewRouter(cfg config.Config) stdhttp.Handler {
+	router, _ := newRouter(cfg)
+	return router
+}
+
+// resources are the long-lived pieces ListenAndServe releases on shutdown.
+type resources struct {
+	db      *translation.DB
+	manager *queue.Manager
+	// stop ends the background scanner and periodic backups.
+	stop context.CancelFunc
+}
+
+func newRouter(cfg config.Config) (stdhttp.Handler, *resources) {
 	if err := runMigrations(cfg); err != nil {
-		return initializationErrorHandler(err)
+		return initializationErrorHandler(err), nil
 	}
 
-	if err := initDependencies(cfg); err != nil {
-		return initializationErrorHandler(err)
+	sessionManager := middleware.NewSessionManager(cfg)
+	res, err := initDependencies(cfg, sessionManager)
+	if err != nil {
+		return initializationErrorHandler(err), nil
 	}
 
 	r := chi.NewRouter()
-	sessionManager := middleware.NewSessionManager(cfg)
 
 	addMiddleware(r, cfg, sessionManager)
 	registerRoutes(r, cfg, sessionManager)
 
-	return r
+	return r, res
 }
 
 func runMigrations(cfg config.Config) error {
@@ -51,54 +67,230 @@ func runMigrations(cfg config.Config) error {
 	return nil
 }
 
-func initDependencies(cfg config.Config) error {
-	db, err := translation.NewDB(cfg.TranslationDBPath)
+func initDependencies(cfg config.Config, sessionManager *middleware.SessionManager) (*resources, error) {
+	db, err := translation.NewDBWithConfig(cfg.TranslationDBPath, dbConfigFrom(cfg))
 	if err != nil {
-		return fmt.Errorf("initialize translation store: %w", err)
+		return nil, fmt.Errorf("initialize translation store: %w", err)
 	}
 
 	translationStore := translation.NewTranslationStore(db)
 	chatStore := translation.NewChatStore(db)
-	srsStore := translation.NewSRSStore(db)
+	srsCfg := srsConfigFrom(cfg)
+	srsCfg.FrequencyRanks = loadFrequencyRanks(cfg.FrequencyListPath)
+	srsCfg.HSKLevels = loadHSKLevels(cfg.HSKWordlistPath)
+	srsStore, err := translation.NewSRSStoreWithConfig(db, srsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("initialize srs store: %w", err)
+	}
+	if normalized, err := srsStore.NormalizeSavedPinyin(); err != nil {
+		log.Printf("saved pinyin backfill failed: %v", err)
+	} else if normalized > 0 {
+		log.Printf("saved pinyin normalized: segments=%d", normalized)
+	}
+	if filled, err := srsStore.FillFrequencyRanks(); err != nil {
+		log.Printf("frequency rank backfill failed: %v", err)
+	} else if filled > 0 {
+		log.Printf("frequency ranks filled in: segments=%d", filled)
+	}
+	if filled, err := srsStore.FillHSKLevels(); err != nil {
+		log.Printf("hsk level backfill failed: %v", err)
+	} else if filled > 0 {
+		log.Printf("hsk levels filled in: segments=%d", filled)
+	}
 	profileStore := translation.NewProfileStore(db)
 
 	translationProv, err := iltrans.NewProvider(cfg)
 	if err != nil {
-		return fmt.Errorf("initialize translation provider: %w", err)
+		return nil, fmt.Errorf("initialize translation provider: %w", err)
 	}
 	chatProv := ilchat.New(cfg)
 
+	if err := textsplit.ConfigureDelimiters(cfg.SentenceDelimiters); err != nil {
+		return nil, fmt.Errorf("configure sentence delimiters: %w", err)
+	}
+
 	manager := queue.NewManager(translationStore, translationProv)
-	handlers.ConfigureDependencies(translationStore, chatStore, srsStore, profileStore, manager, translationProv, chatProv)
+	manager.UseSegmentCache(translation.NewSegmentCacheStore(db))
+	manager.UseSegmentationOverrides(translationStore)
+	manager.UseCustomDictionary(translationStore)
+	manager.RedactSecrets(cfg.OpenAIAPIKey, cfg.AnthropicAPIKey)
+	if cfg.AllowPrivateNetworks {
+		manager.AllowPrivateCallbacks()
+	}
+	manager.LimitWorkers(cfg.TranslationWorkers)
+	manager.SetSegmentDelay(cfg.TranslationSegmentDelay)
+	handlers.ConfigureDependencies(translationStore, chatStore, srsStore, profileStore, manager, translationProv, chatProv, translationProv)
+	handlers.ConfigureUsers(translation.NewUserStore(db))
+	handlers.ConfigureReadingSpeed(cfg.ReadingCharsPerMinute)
+	handlers.ConfigureInputLimit(cfg.MaxInputChars)
+	handlers.ConfigurePageFetching(cfg.AllowPrivateNetworks)
+	handlers.ConfigureHSKLevels(srsCfg.HSKLevels)
+	handlers.ConfigureInstructionReloader(translationProv)
+	handlers.ConfigureSegmentationStats(translationProv)
+	if cfg.OfflineMode {
+		handlers.ConfigureSegmentationEvaluator(nil)
+	} else {
+		handlers.ConfigureSegmentationEvaluator(translationProv)
+	}
+	handlers.ConfigureStreamPollInterval(cfg.SSEPollInterval)
+	// Checked before configuring so a disabled provider stays a nil interface.
+	if speechProv := ilspeech.New(cfg); speechProv != nil {
+		handlers.ConfigureSpeech(speechProv)
+	} else {
+		handlers.ConfigureSpeech(nil)
+	}
+	sessionManager.UseStore(translation.NewSessionStore(db))
+	backupStore := translation.NewBackupStore(db, backupDirFrom(cfg), cfg.BackupRetention)
+	handlers.ConfigureBackups(backupStore)
+	handlers.ConfigureMaintenance(translation.NewMaintenanceStore(db))
+	handlers.ConfigureReadinessChecks(map[string]handlers.ReadinessCheck{
+		"database": func(ctx context.Context) error {
+			var one int
+			return db.Conn.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+		},
+		"upstream": translationProv.CheckUpstream,
+	})
+	backgroundCtx, stop := context.WithCancel(context.Background())
 	manager.ResumeRestartableJobs()
-	manager.StartBackgroundScanner(context.Background())
+	manager.StartBackgroundScanner(backgroundCtx, cfg.JobScanInterval)
+	if cfg.BackupInterval > 0 {
+		backupStore.StartPeriodic(backgroundCtx, cfg.BackupInterval)
+	}
 
-	return nil
+	return &resources{db: db, manager: manager, stop: stop}, nil
+}
+
+// backupDirFrom defaults the backup directory to one next to the database.
+func backupDirFrom(cfg config.Config) string {
+	if cfg.BackupDir != "" {
+		return cfg.BackupDir
+	}
+	return filepath.Join(filepath.Dir(cfg.TranslationDBPath), "backups")
+}
+
+// dbConfigFrom maps config values onto the connection pool settings, keeping
+// the defaults for anything left unset.
+func dbConfigFrom(cfg config.Config) translation.DBConfig {
+	dbCfg := translation.DefaultDBConfig()
+	if cfg.DBMaxOpenConns != 0 {
+		dbCfg.MaxOpenConns = cfg.DBMaxOpenConns
+		dbCfg.MaxIdleConns = cfg.DBMaxOpenConns
+	}
+	if cfg.DBMaxIdleConns != 0 {
+		dbCfg.MaxIdleConns = cfg.DBMaxIdleConns
+	}
+	if cfg.DBBusyTimeoutMs != 0 {
+		dbCfg.BusyTimeoutMs = cfg.DBBusyTimeoutMs
+	}
+	return dbCfg
+}
+
+// srsConfigFrom maps config values onto the SRS store settings, keeping the
+// store defaults for anything left unset.
+func srsConfigFrom(cfg config.Config) translation.SRSConfig {
+	srsCfg := translation.DefaultSRSConfig()
+	if cfg.SRSStrugglingThreshold != 0 {
+		srsCfg.StrugglingThreshold = cfg.SRSStrugglingThreshold
+	}
+	if cfg.SRSStrugglingWindow != 0 {
+		srsCfg.StrugglingWindow = cfg.SRSStrugglingWindow
+	}
+	// The decay settings are applied together: a zero floor is meaningful, so
+	// an empty mode is what marks them as unset.
+	if cfg.SRSOpacityDecay != "" {
+		srsCfg.OpacityDecay = cfg.SRSOpacityDecay
+		srsCfg.OpacityHorizon = cfg.SRSOpacityHorizon
+		srsCfg.StrugglingFloor = cfg.SRSStrugglingFloor
+	}
+	if cfg.PinyinStyle != "" {
+		srsCfg.PinyinStyle = cfg.PinyinStyle
+	}
+	if cfg.StudyLocation != nil {
+		srsCfg.DayLocation = cfg.StudyLocation
+	}
+	return srsCfg
+}
+
+// loadFrequencyRanks reads the optional word frequency list. A missing or
+// unreadable list only disables frequency ranking.
+func loadFrequencyRanks(path string) map[string]int {
+	if path == "" {
+		return nil
+	}
+	ranks, err := translation.LoadFrequencyRanks(path)
+	if err != nil {
+		log.Printf("frequency list unavailable, ranking disabled: %v", err)
+		return nil
+	}
+	log.Printf("loaded frequency list: path=%s words=%d", path, len(ranks))
+	return ranks
+}
+
+// loadHSKLevels reads the optional HSK wordlist. A missing or unreadable list
+// only disables HSK annotation.
+func loadHSKLevels(path string) map[string]int {
+	if path == "" {
+		return nil
+	}
+	levels, err := translation.LoadHSKLevels(path)
+	if err != nil {
+		log.Printf("hsk wordlist unavailable, levels disabled: %v", err)
+		return nil
+	}
+	log.Printf("loaded hsk wordlist: path=%s words=%d", path, len(levels))
+	return levels
 }
 
 func addMiddleware(r chi.Router, cfg config.Config, sessionManager *middleware.SessionManager) {
 	r.Use(chimiddleware.RequestID)
+	r.Use(middleware.CorrelationID)
 	r.Use(chimiddleware.RealIP)
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
+	r.Use(chimiddleware.Compress(5, compressibleContentTypes...))
 	r.Use(middleware.TimeoutUnlessStream(60 * time.Second))
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		AllowCredentials: true,
-	}))
+	r.Use(middleware.CORS(cfg,
+		[]string{"Accept", "Authorization", "Content-Type", middleware.CorrelationIDHeader, handlers.IdempotencyKeyHeader},
+		[]string{middleware.CorrelationIDHeader, handlers.IdempotentReplayedHeader},
+	))
 
 	r.Use(middleware.Auth(cfg, sessionManager))
+	r.Use(middleware.RateLimitExpensive(rateLimiterFrom(cfg)))
+}
+
+// compressibleContentTypes are gzipped or deflated when the client accepts
+// it. text/event-stream is left out on purpose: a compressed stream holds
+// events in the encoder's buffer instead of delivering each one as it is
+// flushed.
+var compressibleContentTypes = []string{
+	"application/json",
+	"text/plain",
+	"text/markdown",
+	"text/tab-separated-values",
+}
+
+// rateLimiterFrom builds the LLM endpoint limiter, treating unset values as
+// the config defaults.
+func rateLimiterFrom(cfg config.Config) *middleware.RateLimiter {
+	perMinute, burst := cfg.RateLimitPerMinute, cfg.RateLimitBurst
+	if perMinute == 0 {
+		perMinute = config.DefaultRateLimitPerMinute
+	}
+	if burst == 0 {
+		burst = config.DefaultRateLimitBurst
+	}
+	return middleware.NewRateLimiter(perMinute, burst)
 }
 
 func registerRoutes(r chi.Router, cfg config.Config, sessionManager *middleware.SessionManager) {
 	routes.RegisterHealthRoutes(r)
 	routes.RegisterOCRRoutes(r)
+	routes.RegisterPronunciationRoutes(r)
 	routes.RegisterAuthRoutes(r, cfg, sessionManager)
 	routes.RegisterTranslationRoutes(r)
 	routes.RegisterVocabRoutes(r)
 	routes.RegisterReviewRoutes(r)
+	routes.RegisterDictionaryRoutes(r)
 	routes.RegisterAdminRoutes(r)
 }
 
@@ -111,6 +303,39 @@ func initializationErrorHandler(err error) stdhttp.Handler {
 	})
 }
 
-func ListenAndServe(addr string, cfg config.Config) error {
-	return stdhttp.ListenAndServe(addr, NewRouter(cfg))
+// ListenAndServe serves until ctx is cancelled, then shuts down within
+// cfg.ShutdownTimeout: it stops accepting connections, lets in-flight
+// requests finish, drains running translation jobs and closes the database.
+func ListenAndServe(ctx context.Context, addr string, cfg config.Config) error {
+	router, res := newRouter(cfg)
+	srv := &stdhttp.Server{Addr: addr, Handler: router}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down, waiting up to %s for in-flight work", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http shutdown: %v", err)
+		_ = srv.Close()
+	}
+	if res == nil {
+		return nil
+	}
+	res.stop()
+	if err := res.manager.Drain(shutdownCtx); err != nil {
+		log.Printf("%v; released their leases for the next start", err)
+	}
+	if err := res.db.Conn.Close(); err != nil {
+		return fmt.Errorf("close database: %w", err)
+	}
+	return nil
 }
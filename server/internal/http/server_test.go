@@ -2,13 +2,19 @@ package http_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/binary"
 	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/png"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -35,6 +41,7 @@ func newTestConfig(t *testing.T) config.Config {
 		OpenAITranslationModel: "openai/gpt-4o-mini",
 		OpenAIChatModel:        "openai/gpt-4o-mini",
 		OpenAIBaseURL:          "http://127.0.0.1:9/v1",
+		SSEPollInterval:        50 * time.Millisecond,
 	}
 }
 
@@ -95,25 +102,60 @@ func TestRouteContractWithAuthenticatedSession(t *testing.T) {
 		status int
 	}{
 		{name: "health", method: http.MethodGet, path: "/health", status: http.StatusOK},
-		{name: "logout", method: http.MethodPost, path: "/api/auth/logout", status: http.StatusOK},
+		{name: "list sessions", method: http.MethodGet, path: "/api/auth/sessions", status: http.StatusOK},
 		{name: "create translation", method: http.MethodPost, path: "/api/translations", status: http.StatusBadRequest},
+		{name: "create translation from url", method: http.MethodPost, path: "/api/translations/from-url", status: http.StatusBadRequest},
 		{name: "list translations", method: http.MethodGet, path: "/api/translations", status: http.StatusOK},
 		{name: "get translation", method: http.MethodGet, path: "/api/translations/123", status: http.StatusNotFound},
 		{name: "translation status", method: http.MethodGet, path: "/api/translations/123/status", status: http.StatusNotFound},
+		{name: "translation neighbors", method: http.MethodGet, path: "/api/translations/123/neighbors", status: http.StatusNotFound},
+		{name: "translation chunks", method: http.MethodGet, path: "/api/translations/123/chunks", status: http.StatusNotFound},
+		{name: "translation plaintext", method: http.MethodGet, path: "/api/translations/123/plaintext", status: http.StatusNotFound},
 		{name: "delete translation", method: http.MethodDelete, path: "/api/translations/123", status: http.StatusNotFound},
+		{name: "favorite translation", method: http.MethodPost, path: "/api/translations/123/favorite", status: http.StatusNotFound},
+		{name: "tag translation", method: http.MethodPost, path: "/api/translations/123/tags", status: http.StatusNotFound},
+		{name: "untag translation", method: http.MethodDelete, path: "/api/translations/123/tags", status: http.StatusNotFound},
 		{name: "save vocab", method: http.MethodPost, path: "/api/vocab/save", status: http.StatusBadRequest},
 		{name: "update vocab status", method: http.MethodPost, path: "/api/vocab/status", status: http.StatusBadRequest},
 		{name: "lookup vocab", method: http.MethodPost, path: "/api/vocab/lookup", status: http.StatusBadRequest},
 		{name: "vocab srs info", method: http.MethodGet, path: "/api/vocab/srs-info", status: http.StatusOK},
+		{name: "vocab recent", method: http.MethodGet, path: "/api/vocab/recent", status: http.StatusOK},
+		{name: "vocab csv export", method: http.MethodGet, path: "/api/vocab/export.csv", status: http.StatusOK},
+		{name: "vocab csv import", method: http.MethodPost, path: "/api/vocab/import.csv", status: http.StatusBadRequest},
+		{name: "character words", method: http.MethodGet, path: "/api/vocab/characters/123/words", status: http.StatusNotFound},
 		{name: "review queue", method: http.MethodGet, path: "/api/review/words/queue", status: http.StatusOK},
+		{name: "review queue cloze", method: http.MethodGet, path: "/api/review/words/queue?mode=cloze", status: http.StatusOK},
+		{name: "review queue unknown mode", method: http.MethodGet, path: "/api/review/words/queue?mode=typing", status: http.StatusBadRequest},
+		{name: "review queue hsk filter", method: http.MethodGet, path: "/api/review/words/queue?hsk=3", status: http.StatusOK},
+		{name: "review queue invalid hsk", method: http.MethodGet, path: "/api/review/words/queue?hsk=0", status: http.StatusBadRequest},
 		{name: "review answer", method: http.MethodPost, path: "/api/review/answer", status: http.StatusBadRequest},
+		{name: "review undo", method: http.MethodPost, path: "/api/review/undo", status: http.StatusBadRequest},
+		{name: "review stats", method: http.MethodGet, path: "/api/review/stats", status: http.StatusOK},
+		{name: "study streak", method: http.MethodGet, path: "/api/stats/streak", status: http.StatusOK},
+		{name: "anki export", method: http.MethodGet, path: "/api/review/export/anki", status: http.StatusOK},
 		{name: "review count", method: http.MethodGet, path: "/api/review/words/count", status: http.StatusOK},
+		{name: "retranslate segment", method: http.MethodPost, path: "/api/translations/123/segments/0/0/retranslate", status: http.StatusNotFound},
+		{name: "merge segments", method: http.MethodPost, path: "/api/translations/123/segments/0/merge", status: http.StatusNotFound},
 		{name: "translate sentence segments", method: http.MethodPost, path: "/api/translations/sentence-segments/translate", status: http.StatusBadRequest},
+		{name: "preview segments", method: http.MethodPost, path: "/api/segments/preview", status: http.StatusBadRequest},
+		{name: "dictionary lookup", method: http.MethodPost, path: "/api/dictionary/lookup", status: http.StatusBadRequest},
+		{name: "dictionary search", method: http.MethodGet, path: "/api/dictionary/search", status: http.StatusBadRequest},
+		{name: "list custom dictionary", method: http.MethodGet, path: "/api/dictionary/custom", status: http.StatusOK},
+		{name: "save custom dictionary entry", method: http.MethodPost, path: "/api/dictionary/custom", status: http.StatusBadRequest},
+		{name: "get custom dictionary entry", method: http.MethodGet, path: "/api/dictionary/custom/abc", status: http.StatusNotFound},
+		{name: "delete custom dictionary entry", method: http.MethodDelete, path: "/api/dictionary/custom/abc", status: http.StatusNotFound},
 		{name: "export progress", method: http.MethodGet, path: "/api/admin/progress/export", status: http.StatusOK},
 		{name: "import progress", method: http.MethodPost, path: "/api/admin/progress/import", status: http.StatusBadRequest},
 		{name: "get profile", method: http.MethodGet, path: "/api/admin/profile", status: http.StatusOK},
 		{name: "update profile", method: http.MethodPost, path: "/api/admin/profile", status: http.StatusBadRequest},
+		{name: "backup", method: http.MethodPost, path: "/api/admin/backup", status: http.StatusOK},
+		{name: "db check", method: http.MethodPost, path: "/api/admin/db/check", status: http.StatusOK},
+		{name: "metrics", method: http.MethodGet, path: "/api/admin/metrics", status: http.StatusOK},
+		{name: "translation error", method: http.MethodGet, path: "/api/admin/translations/123/error", status: http.StatusNotFound},
+		{name: "reload instruction", method: http.MethodPost, path: "/api/admin/segmentation/reload-instruction", status: http.StatusNotFound},
+		{name: "evaluate instruction", method: http.MethodPost, path: "/api/admin/segmentation/evaluate", status: http.StatusBadRequest},
 		{name: "extract text no file", method: http.MethodPost, path: "/api/ocr/extract-text", status: http.StatusBadRequest},
+		{name: "pronunciation without speech-to-text", method: http.MethodPost, path: "/api/pronunciation/score", status: http.StatusBadRequest},
 	}
 
 	for _, tc := range tests {
@@ -135,11 +177,665 @@ func TestRouteContractWithAuthenticatedSession(t *testing.T) {
 	sseRes := httptest.NewRecorder()
 	router.ServeHTTP(sseRes, sseReq)
 
-	if sseRes.Code != http.StatusOK {
-		t.Fatalf("expected SSE status 200, got %d", sseRes.Code)
+	if sseRes.Code != http.StatusNotFound {
+		t.Fatalf("expected SSE status 404 for a missing translation, got %d", sseRes.Code)
 	}
-	if got := sseRes.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
-		t.Fatalf("expected SSE content type, got %q", got)
+
+	// Logout revokes the session, so it runs after every other route.
+	logoutReq := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	logoutReq.Header.Set("Cookie", sessionCookie)
+	logoutRes := httptest.NewRecorder()
+	router.ServeHTTP(logoutRes, logoutReq)
+	if logoutRes.Code != http.StatusOK {
+		t.Fatalf("expected logout status 200, got %d", logoutRes.Code)
+	}
+}
+
+func TestExpensiveEndpointsAreRateLimitedPerSession(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.RateLimitPerMinute = 1
+	cfg.RateLimitBurst = 2
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	post := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Cookie", sessionCookie)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	for i := 0; i < 2; i++ {
+		if res := post("/api/translations"); res.Code != http.StatusBadRequest {
+			t.Fatalf("request %d: expected status 400 within burst, got %d", i+1, res.Code)
+		}
+	}
+	limited := post("/api/translations/123/chat/new")
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429 after burst, got %d", limited.Code)
+	}
+	if limited.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header on 429")
+	}
+
+	// Cheap endpoints share no budget with the limited ones.
+	listReq := httptest.NewRequest(http.MethodGet, "/api/translations", nil)
+	listReq.Header.Set("Cookie", sessionCookie)
+	listRes := httptest.NewRecorder()
+	router.ServeHTTP(listRes, listReq)
+	if listRes.Code != http.StatusOK {
+		t.Fatalf("expected list status 200 while limited, got %d", listRes.Code)
+	}
+}
+
+func TestCorrelationIDHeader(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if got := res.Header().Get("X-Correlation-ID"); len(got) != 16 {
+		t.Fatalf("expected generated correlation id, got %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("X-Correlation-ID", "client-trace-1")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got := res.Header().Get("X-Correlation-ID"); got != "client-trace-1" {
+		t.Fatalf("expected client correlation id to be echoed, got %q", got)
+	}
+}
+
+func TestCORSIsSameOriginUnlessConfigured(t *testing.T) {
+	request := func(router http.Handler, method string, path string, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	if got := request(router, http.MethodGet, "/api/translations", "https://app.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers by default, got %q", got)
+	}
+
+	cfg = newTestConfig(t)
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.CORSAllowedMethods = []string{"GET", "POST"}
+	cfg.CORSAllowCredentials = true
+	router = httprouter.NewRouter(cfg)
+
+	// The preflight is answered before auth, so it needs no session.
+	preflight := request(router, http.MethodOptions, "/api/translations", "https://app.example.com")
+	if preflight.Code != http.StatusOK {
+		t.Fatalf("expected preflight 200, got %d", preflight.Code)
+	}
+	if got := preflight.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("expected allowed origin echoed, got %q", got)
+	}
+	if got := preflight.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("expected credentialed requests allowed, got %q", got)
+	}
+
+	unauthenticated := request(router, http.MethodGet, "/api/translations", "https://app.example.com")
+	if unauthenticated.Code != http.StatusUnauthorized || unauthenticated.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("expected a 401 the browser can read, got %d %q", unauthenticated.Code, unauthenticated.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if got := request(router, http.MethodGet, "/api/translations", "https://evil.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected other origins refused, got %q", got)
+	}
+	if got := request(router, http.MethodGet, "/health", "https://app.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected CORS limited to /api/*, got %q", got)
+	}
+}
+
+func TestJSONIsCompressedButSSEIsNot(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/translations", nil)
+	req.Header.Set("Cookie", sessionCookie)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || res.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzipped JSON, got %d encoding=%q", res.Code, res.Header().Get("Content-Encoding"))
+	}
+	if res.Header().Get("Content-Length") != "" {
+		t.Fatalf("expected no Content-Length on a compressed body, got %q", res.Header().Get("Content-Length"))
+	}
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatalf("open gzip body: %v", err)
+	}
+	var listed map[string]any
+	if err := json.NewDecoder(zr).Decode(&listed); err != nil {
+		t.Fatalf("decode gzipped JSON: %v", err)
+	}
+
+	payload, _ := json.Marshal(map[string]string{"input_text": "你好世界", "source_type": "text"})
+	createReq := httptest.NewRequest(http.MethodPost, "/api/translations", bytes.NewReader(payload))
+	createReq.Header.Set("Cookie", sessionCookie)
+	createReq.Header.Set("Content-Type", "application/json")
+	createRes := httptest.NewRecorder()
+	router.ServeHTTP(createRes, createReq)
+	var created struct {
+		TranslationID string `json:"translation_id"`
+	}
+	if err := json.NewDecoder(createRes.Body).Decode(&created); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+
+	sseReq := httptest.NewRequest(http.MethodGet, "/api/translations/"+created.TranslationID+"/stream", nil)
+	sseReq.Header.Set("Cookie", sessionCookie)
+	sseReq.Header.Set("Accept-Encoding", "gzip")
+	sseRes := httptest.NewRecorder()
+	router.ServeHTTP(sseRes, sseReq)
+	if got := sseRes.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected SSE left uncompressed, got %q", got)
+	}
+	if !strings.HasPrefix(sseRes.Body.String(), "data: ") {
+		t.Fatalf("expected plain SSE events, got %q", sseRes.Body.String())
+	}
+}
+
+func TestReadinessReportsEachDependency(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+
+	// No session: orchestrator probes are unauthenticated.
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	// The test upstream address refuses connections.
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected readiness status 503, got %d", res.Code)
+	}
+	var body struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Status string `json:"status"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode readiness response: %v", err)
+	}
+	if body.Status != "unavailable" || body.Checks["database"].Status != "ok" || body.Checks["upstream"].Status != "error" {
+		t.Fatalf("unexpected readiness response: %+v", body)
+	}
+}
+
+func TestCreateTranslationFromURL(t *testing.T) {
+	cfg := newTestConfig(t)
+	// The test page is served from loopback.
+	cfg.AllowPrivateNetworks = true
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/article" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><body><nav>Home</nav><p>你好世界。</p></body></html>`))
+	}))
+	defer page.Close()
+
+	postURL := func(rawURL string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(map[string]string{"url": rawURL})
+		req := httptest.NewRequest(http.MethodPost, "/api/translations/from-url", bytes.NewReader(payload))
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	createRes := postURL(page.URL + "/article")
+	if createRes.Code != http.StatusOK {
+		t.Fatalf("expected from-url status 200, got %d: %s", createRes.Code, createRes.Body.String())
+	}
+	var created struct {
+		TranslationID string `json:"translation_id"`
+	}
+	if err := json.Unmarshal(createRes.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode from-url response: %v", err)
+	}
+
+	getReq := httptest.NewRequest(http.MethodGet, "/api/translations/"+created.TranslationID, nil)
+	getReq.Header.Set("Cookie", sessionCookie)
+	getRes := httptest.NewRecorder()
+	router.ServeHTTP(getRes, getReq)
+	var detail struct {
+		SourceType string `json:"source_type"`
+		InputText  string `json:"input_text"`
+		SourceURL  string `json:"source_url"`
+	}
+	if err := json.Unmarshal(getRes.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode translation detail: %v", err)
+	}
+	if detail.SourceType != "url" || detail.InputText != "你好世界。" || detail.SourceURL != page.URL+"/article" {
+		t.Fatalf("unexpected translation detail: %+v", detail)
+	}
+
+	if res := postURL(page.URL + "/missing"); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected fetch failure status 400, got %d", res.Code)
+	}
+}
+
+func TestUserAccountsScopeTranslations(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	ownerCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	do := func(method, path, cookie string, body any) *httptest.ResponseRecorder {
+		var reader *bytes.Reader
+		if body != nil {
+			payload, _ := json.Marshal(body)
+			reader = bytes.NewReader(payload)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	created := do(http.MethodPost, "/api/admin/users", ownerCookie, map[string]string{"username": "mei", "password": "s3cret"})
+	if created.Code != http.StatusCreated {
+		t.Fatalf("expected create user status 201, got %d: %s", created.Code, created.Body.String())
+	}
+	if res := do(http.MethodPost, "/api/admin/users", ownerCookie, map[string]string{"username": "mei", "password": "x"}); res.Code != http.StatusConflict {
+		t.Fatalf("expected duplicate username status 409, got %d", res.Code)
+	}
+	if res := do(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "mei", "password": "wrong"}); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected bad password status 401, got %d", res.Code)
+	}
+
+	login := do(http.MethodPost, "/api/auth/login", "", map[string]string{"username": "mei", "password": "s3cret"})
+	if login.Code != http.StatusOK {
+		t.Fatalf("expected user login status 200, got %d", login.Code)
+	}
+	var userCookie string
+	for _, c := range login.Result().Cookies() {
+		if c.Name == "session" {
+			userCookie = c.String()
+		}
+	}
+	if userCookie == "" {
+		t.Fatal("expected session cookie for user login")
+	}
+	if res := do(http.MethodGet, "/api/admin/users", userCookie, nil); res.Code != http.StatusForbidden {
+		t.Fatalf("expected non-owner user admin status 403, got %d", res.Code)
+	}
+	if res := do(http.MethodPost, "/api/admin/segmentation/reload-instruction", userCookie, nil); res.Code != http.StatusForbidden {
+		t.Fatalf("expected non-owner instruction reload status 403, got %d", res.Code)
+	}
+	if res := do(http.MethodPost, "/api/admin/dictionary/reload", userCookie, nil); res.Code != http.StatusForbidden {
+		t.Fatalf("expected non-owner dictionary reload status 403, got %d", res.Code)
+	}
+
+	ownerCreate := do(http.MethodPost, "/api/translations", ownerCookie, map[string]string{"input_text": "你好世界"})
+	if ownerCreate.Code != http.StatusOK {
+		t.Fatalf("expected owner create status 200, got %d", ownerCreate.Code)
+	}
+	var ownerTranslation struct {
+		TranslationID string `json:"translation_id"`
+	}
+	if err := json.NewDecoder(ownerCreate.Body).Decode(&ownerTranslation); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+
+	if res := do(http.MethodGet, "/api/translations/"+ownerTranslation.TranslationID, userCookie, nil); res.Code != http.StatusNotFound {
+		t.Fatalf("expected other user's translation status 404, got %d", res.Code)
+	}
+	if res := do(http.MethodDelete, "/api/translations/"+ownerTranslation.TranslationID, userCookie, nil); res.Code != http.StatusNotFound {
+		t.Fatalf("expected other user's delete status 404, got %d", res.Code)
+	}
+	if res := do(http.MethodPost, "/api/translations/sentence-segments/translate", userCookie, map[string]any{
+		"segments":       []string{"你好", "世界"},
+		"translation_id": ownerTranslation.TranslationID,
+		"sentence_idx":   0,
+	}); res.Code != http.StatusNotFound {
+		t.Fatalf("expected other user's sentence segments write status 404, got %d", res.Code)
+	}
+	list := do(http.MethodGet, "/api/translations", userCookie, nil)
+	var listed struct {
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(list.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode list response: %v", err)
+	}
+	if listed.Total != 0 {
+		t.Fatalf("expected empty list for new user, got total=%d", listed.Total)
+	}
+	if res := do(http.MethodGet, "/api/translations/"+ownerTranslation.TranslationID, ownerCookie, nil); res.Code != http.StatusOK {
+		t.Fatalf("expected owner translation status 200, got %d", res.Code)
+	}
+
+	saved := do(http.MethodPost, "/api/vocab/save", ownerCookie, map[string]string{"headword": "你好", "pinyin": "nǐ hǎo", "english": "hello"})
+	if saved.Code != http.StatusOK {
+		t.Fatalf("expected owner save vocab status 200, got %d: %s", saved.Code, saved.Body.String())
+	}
+	var savedVocab struct {
+		SegmentID string `json:"segment_id"`
+	}
+	if err := json.NewDecoder(saved.Body).Decode(&savedVocab); err != nil {
+		t.Fatalf("decode save vocab response: %v", err)
+	}
+	if res := do(http.MethodPost, "/api/vocab/status", userCookie, map[string]string{"segment_id": savedVocab.SegmentID, "status": "known"}); res.Code != http.StatusNotFound {
+		t.Fatalf("expected other user's vocab status update 404, got %d", res.Code)
+	}
+	if res := do(http.MethodPost, "/api/review/answer", userCookie, map[string]any{"segment_id": savedVocab.SegmentID, "grade": 2}); res.Code != http.StatusNotFound {
+		t.Fatalf("expected other user's review answer 404, got %d", res.Code)
+	}
+	var wordCount struct {
+		DueCount int `json:"due_count"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/api/review/words/count", userCookie, nil).Body).Decode(&wordCount); err != nil {
+		t.Fatalf("decode review count: %v", err)
+	}
+	if wordCount.DueCount != 0 {
+		t.Fatalf("expected no due words for new user, got %d", wordCount.DueCount)
+	}
+	var exported struct {
+		SavedSegments []map[string]any `json:"saved_segments"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/api/admin/progress/export", userCookie, nil).Body).Decode(&exported); err != nil {
+		t.Fatalf("decode progress export: %v", err)
+	}
+	if len(exported.SavedSegments) != 0 {
+		t.Fatalf("expected new user's export to hold no words, got %d", len(exported.SavedSegments))
+	}
+
+	if res := do(http.MethodPost, "/api/admin/profile", userCookie, map[string]string{"name": "Mei", "language": "en"}); res.Code != http.StatusOK {
+		t.Fatalf("expected user profile update status 200, got %d", res.Code)
+	}
+	var ownerProfile struct {
+		Profile *struct {
+			Name string `json:"name"`
+		} `json:"profile"`
+		VocabStats struct {
+			Total int `json:"total"`
+		} `json:"vocabStats"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/api/admin/profile", ownerCookie, nil).Body).Decode(&ownerProfile); err != nil {
+		t.Fatalf("decode owner profile: %v", err)
+	}
+	if ownerProfile.Profile != nil && ownerProfile.Profile.Name == "Mei" {
+		t.Fatal("expected another user's profile update to leave the owner's profile alone")
+	}
+	if ownerProfile.VocabStats.Total != 1 {
+		t.Fatalf("expected owner vocab total 1, got %d", ownerProfile.VocabStats.Total)
+	}
+}
+
+func TestSessionsAreListedAndRevocable(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	laptop := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+	phone := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+	tablet := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	do := func(method, path, cookie string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Cookie", cookie)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	listRes := do(http.MethodGet, "/api/auth/sessions", laptop)
+	if listRes.Code != http.StatusOK {
+		t.Fatalf("expected sessions status 200, got %d", listRes.Code)
+	}
+	var listed struct {
+		Sessions []struct {
+			ID         string `json:"id"`
+			LastSeenAt string `json:"last_seen_at"`
+			Current    bool   `json:"current"`
+		} `json:"sessions"`
+	}
+	if err := json.NewDecoder(listRes.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode sessions: %v", err)
+	}
+	current := 0
+	for _, session := range listed.Sessions {
+		if session.Current {
+			current++
+		}
+		if session.ID == "" || session.LastSeenAt == "" {
+			t.Fatalf("expected id and last_seen_at, got %+v", session)
+		}
+	}
+	if len(listed.Sessions) != 3 || current != 1 {
+		t.Fatalf("expected 3 sessions with one current, got %+v", listed.Sessions)
+	}
+
+	if res := do(http.MethodPost, "/api/auth/logout", tablet); res.Code != http.StatusOK {
+		t.Fatalf("expected logout status 200, got %d", res.Code)
+	}
+	if res := do(http.MethodGet, "/api/translations", tablet); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected logged-out cookie status 401, got %d", res.Code)
+	}
+	if res := do(http.MethodGet, "/api/translations", phone); res.Code != http.StatusOK {
+		t.Fatalf("expected other session to survive logout, got %d", res.Code)
+	}
+
+	logoutAll := do(http.MethodPost, "/api/auth/logout-all", laptop)
+	if logoutAll.Code != http.StatusOK {
+		t.Fatalf("expected logout-all status 200, got %d", logoutAll.Code)
+	}
+	var revoked struct {
+		Revoked int `json:"revoked"`
+	}
+	if err := json.NewDecoder(logoutAll.Body).Decode(&revoked); err != nil {
+		t.Fatalf("decode logout-all: %v", err)
+	}
+	if revoked.Revoked != 2 {
+		t.Fatalf("expected 2 revoked sessions, got %d", revoked.Revoked)
+	}
+	for name, cookie := range map[string]string{"laptop": laptop, "phone": phone} {
+		if res := do(http.MethodGet, "/api/translations", cookie); res.Code != http.StatusUnauthorized {
+			t.Fatalf("expected %s cookie status 401 after logout-all, got %d", name, res.Code)
+		}
+	}
+}
+
+func TestCreateTranslationIdempotencyKey(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	create := func(key string) (string, *httptest.ResponseRecorder) {
+		payload, _ := json.Marshal(map[string]string{"input_text": "你好世界"})
+		req := httptest.NewRequest(http.MethodPost, "/api/translations", bytes.NewReader(payload))
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected create status 200, got %d", res.Code)
+		}
+		var body struct {
+			TranslationID string `json:"translation_id"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatalf("decode create response: %v", err)
+		}
+		return body.TranslationID, res
+	}
+
+	first, firstRes := create("click-1")
+	if firstRes.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("first request must not be marked as replayed")
+	}
+	second, secondRes := create("click-1")
+	if second != first || secondRes.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replay of %s, got %s (replayed=%q)", first, second, secondRes.Header().Get("Idempotent-Replayed"))
+	}
+	if other, _ := create("click-2"); other == first {
+		t.Fatal("expected a different key to create a new translation")
+	}
+	if unkeyed, _ := create(""); unkeyed == first {
+		t.Fatal("expected requests without a key to always create")
+	}
+}
+
+func TestCreateTranslationValidatesCallbackURL(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	for callbackURL, want := range map[string]int{
+		"ftp://example.com/hook":      http.StatusBadRequest,
+		"not a url":                   http.StatusBadRequest,
+		"http://127.0.0.1:9/hook?x=1": http.StatusOK,
+	} {
+		payload, _ := json.Marshal(map[string]string{"input_text": "你好世界", "callback_url": callbackURL})
+		req := httptest.NewRequest(http.MethodPost, "/api/translations", bytes.NewReader(payload))
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != want {
+			t.Fatalf("callback_url %q: expected status %d, got %d", callbackURL, want, res.Code)
+		}
+	}
+}
+
+func TestCreateTranslationValidatesPriority(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	for priority, want := range map[string]int{
+		"low":    http.StatusOK,
+		"HIGH":   http.StatusOK,
+		"urgent": http.StatusBadRequest,
+	} {
+		payload, _ := json.Marshal(map[string]string{"input_text": "你好世界", "priority": priority})
+		req := httptest.NewRequest(http.MethodPost, "/api/translations", bytes.NewReader(payload))
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != want {
+			t.Fatalf("priority %q: expected status %d, got %d", priority, want, res.Code)
+		}
+	}
+}
+
+func TestExportTranslationPlaintext(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	payload, _ := json.Marshal(map[string]string{"input_text": "你好世界"})
+	createReq := httptest.NewRequest(http.MethodPost, "/api/translations", bytes.NewReader(payload))
+	createReq.Header.Set("Cookie", sessionCookie)
+	createReq.Header.Set("Content-Type", "application/json")
+	createRes := httptest.NewRecorder()
+	router.ServeHTTP(createRes, createReq)
+	var created struct {
+		TranslationID string `json:"translation_id"`
+	}
+	if err := json.NewDecoder(createRes.Body).Decode(&created); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+
+	for format, want := range map[string]struct {
+		status      int
+		contentType string
+	}{
+		"":               {http.StatusOK, "text/plain"},
+		"interlinear":    {http.StatusOK, "text/plain"},
+		"tsv":            {http.StatusOK, "text/tab-separated-values"},
+		"markdown-table": {http.StatusOK, "text/markdown"},
+		"pdf":            {http.StatusBadRequest, "application/json"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/translations/"+created.TranslationID+"/plaintext?format="+format, nil)
+		req.Header.Set("Cookie", sessionCookie)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != want.status || !strings.HasPrefix(res.Header().Get("Content-Type"), want.contentType) {
+			t.Fatalf("format %q: expected %d %s, got %d %s", format, want.status, want.contentType, res.Code, res.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestVocabCSVImportRoundTrips(t *testing.T) {
+	cfg := newTestConfig(t)
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	postCSV := func(content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "vocab.csv")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = part.Write([]byte(content))
+		_ = writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/vocab/import.csv", &body)
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	res := postCSV("headword,pinyin,english\n学习,xué xí,\"to study, to learn\"\n,,missing\n")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var result struct {
+		Imported int `json:"imported"`
+		Skipped  int `json:"skipped"`
+		Errors   []struct {
+			Line int `json:"line"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode import response: %v", err)
+	}
+	if result.Imported != 1 || result.Skipped != 1 || len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Fatalf("unexpected import result: %s", res.Body.String())
+	}
+
+	// Re-importing the CSV export adds nothing new.
+	exportReq := httptest.NewRequest(http.MethodGet, "/api/vocab/export.csv", nil)
+	exportReq.Header.Set("Cookie", sessionCookie)
+	exportRes := httptest.NewRecorder()
+	router.ServeHTTP(exportRes, exportReq)
+	if !strings.Contains(exportRes.Body.String(), "\"to study, to learn\"") {
+		t.Fatalf("expected the imported word in the export, got %q", exportRes.Body.String())
+	}
+	res = postCSV(exportRes.Body.String())
+	if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("re-import: %d %s", res.Code, res.Body.String())
+	}
+	if result.Imported != 0 || len(result.Errors) != 0 {
+		t.Fatalf("expected re-import to skip everything, got %s", res.Body.String())
 	}
 }
 
@@ -415,6 +1111,69 @@ func TestAdminAndOCRContracts(t *testing.T) {
 		t.Fatalf("expected export progress status 200, got %d", exportRes.Code)
 	}
 
+	metricsReq := httptest.NewRequest(http.MethodGet, "/api/admin/metrics", nil)
+	metricsReq.Header.Set("Cookie", sessionCookie)
+	metricsRes := httptest.NewRecorder()
+	router.ServeHTTP(metricsRes, metricsReq)
+	if metricsRes.Code != http.StatusOK {
+		t.Fatalf("expected metrics status 200, got %d", metricsRes.Code)
+	}
+	var metrics struct {
+		Translations map[string]int `json:"translations"`
+		Jobs         struct {
+			ByState       map[string]int `json:"by_state"`
+			ExpiredLeases *int           `json:"expired_leases"`
+			Running       *int           `json:"running"`
+		} `json:"jobs"`
+		Reviews struct {
+			SegmentsDue *int `json:"segments_due"`
+		} `json:"reviews"`
+		Segmentation *struct {
+			ModelCalls *int64 `json:"model_calls"`
+		} `json:"segmentation"`
+	}
+	if err := json.Unmarshal(metricsRes.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("decode metrics: %v", err)
+	}
+	if metrics.Translations == nil || metrics.Jobs.ByState == nil || metrics.Jobs.ExpiredLeases == nil || metrics.Jobs.Running == nil || metrics.Reviews.SegmentsDue == nil || metrics.Segmentation == nil || metrics.Segmentation.ModelCalls == nil {
+		t.Fatalf("expected every metrics section, got %s", metricsRes.Body.String())
+	}
+
+	// A dry-run import of the export validates it without writing.
+	postImport := func(fields map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "progress.json")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = part.Write(exportRes.Body.Bytes())
+		for name, value := range fields {
+			_ = writer.WriteField(name, value)
+		}
+		_ = writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/progress/import", &body)
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	importRes := postImport(map[string]string{"mode": "merge", "dry_run": "true"})
+	if importRes.Code != http.StatusOK {
+		t.Fatalf("expected dry-run import status 200, got %d: %s", importRes.Code, importRes.Body.String())
+	}
+	var importResult struct {
+		Mode   string `json:"mode"`
+		DryRun bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(importRes.Body.Bytes(), &importResult); err != nil || importResult.Mode != "merge" || !importResult.DryRun {
+		t.Fatalf("expected merge dry run in import response, got %s", importRes.Body.String())
+	}
+	if res := postImport(map[string]string{"mode": "append"}); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown import mode status 400, got %d", res.Code)
+	}
+
 	// OCR contract: multipart image accepted and returns text payload.
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
@@ -433,6 +1192,202 @@ func TestAdminAndOCRContracts(t *testing.T) {
 	if ocrRes.Code != http.StatusOK {
 		t.Fatalf("expected ocr extract-text status 200, got %d", ocrRes.Code)
 	}
+
+	// Multi-image upload returns one breakdown entry per image, in order.
+	postImages := func(count int, lang string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for i := 0; i < count; i++ {
+			part, err := writer.CreateFormFile("images[]", "page"+strconv.Itoa(i+1)+".png")
+			if err != nil {
+				t.Fatalf("create form file: %v", err)
+			}
+			_, _ = part.Write([]byte("fake-image-bytes"))
+		}
+		if lang != "" {
+			_ = writer.WriteField("lang", lang)
+		}
+		_ = writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/ocr/extract-text", &body)
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	multiRes := postImages(2, "ja")
+	if multiRes.Code != http.StatusOK {
+		t.Fatalf("expected multi-image extract-text status 200, got %d", multiRes.Code)
+	}
+	var multiBody struct {
+		Lang   string `json:"lang"`
+		Images []struct {
+			Index    int    `json:"index"`
+			Filename string `json:"filename"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal(multiRes.Body.Bytes(), &multiBody); err != nil {
+		t.Fatalf("decode multi-image response: %v", err)
+	}
+	if multiBody.Lang != "ja" || len(multiBody.Images) != 2 || multiBody.Images[1].Filename != "page2.png" {
+		t.Fatalf("unexpected multi-image response: %+v", multiBody)
+	}
+	if res := postImages(11, ""); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected too many images status 400, got %d", res.Code)
+	}
+	if res := postImages(1, "xx"); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected unsupported lang status 400, got %d", res.Code)
+	}
+
+	// Each image and the request as a whole are size-limited.
+	postSized := func(sizes ...int) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for i, size := range sizes {
+			part, err := writer.CreateFormFile("images[]", "page"+strconv.Itoa(i+1)+".png")
+			if err != nil {
+				t.Fatalf("create form file: %v", err)
+			}
+			_, _ = part.Write(make([]byte, size))
+		}
+		_ = writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/ocr/extract-text", &body)
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	if res := postSized(8<<20 + 1); res.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized image status 413, got %d", res.Code)
+	}
+	if res := postSized(7<<20, 7<<20, 7<<20, 7<<20, 7<<20); res.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized request status 413, got %d", res.Code)
+	}
+
+	// Preprocessing decodes the image, so it needs real image bytes.
+	postPreprocessed := func(data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("image", "photo.png")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = part.Write(data)
+		_ = writer.WriteField("preprocess", "true")
+		_ = writer.WriteField("deskew", "true")
+		_ = writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/ocr/extract-text", &body)
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	var pngBytes bytes.Buffer
+	if err := png.Encode(&pngBytes, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	if res := postPreprocessed(pngBytes.Bytes()); res.Code != http.StatusOK {
+		t.Fatalf("expected preprocessed extract-text status 200, got %d", res.Code)
+	}
+	if res := postPreprocessed([]byte("fake-image-bytes")); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected undecodable image status 400, got %d", res.Code)
+	}
+
+	// A PNG header declaring 60000x60000 pixels is refused before decoding.
+	ihdr := append([]byte("IHDR"), make([]byte, 13)...)
+	binary.BigEndian.PutUint32(ihdr[4:], 60000)
+	binary.BigEndian.PutUint32(ihdr[8:], 60000)
+	ihdr[12] = 8 // 8-bit grayscale
+	huge := binary.BigEndian.AppendUint32([]byte("\x89PNG\r\n\x1a\n"), 13)
+	huge = binary.BigEndian.AppendUint32(append(huge, ihdr...), crc32.ChecksumIEEE(ihdr))
+	if res := postPreprocessed(huge); res.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized image status 413, got %d", res.Code)
+	}
+}
+
+func TestPronunciationScoreTranscribesAndDiffs(t *testing.T) {
+	var gotModel, gotLanguage string
+	stt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer stt-key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gotModel, gotLanguage = r.FormValue("model"), r.FormValue("language")
+		_, _ = w.Write([]byte(`{"text":"我喜欢学生。"}`))
+	}))
+	defer stt.Close()
+
+	cfg := newTestConfig(t)
+	cfg.SpeechToTextModel = "whisper-1"
+	cfg.SpeechToTextBaseURL = stt.URL + "/v1"
+	cfg.SpeechToTextAPIKey = "stt-key"
+	router := httprouter.NewRouter(cfg)
+	sessionCookie := loginAndGetSessionCookie(t, router, cfg.AppPassword)
+
+	postAudio := func(target string, audio []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		_ = writer.WriteField("target", target)
+		if audio != nil {
+			part, err := writer.CreateFormFile("audio", "attempt.webm")
+			if err != nil {
+				t.Fatalf("create form file: %v", err)
+			}
+			_, _ = part.Write(audio)
+		}
+		_ = writer.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/pronunciation/score", &body)
+		req.Header.Set("Cookie", sessionCookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	res := postAudio("我喜欢学习", []byte("fake-audio-bytes"))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected score status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var scored struct {
+		Transcript string  `json:"transcript"`
+		Score      float64 `json:"score"`
+		Syllables  []struct {
+			Target string `json:"target"`
+			Heard  string `json:"heard"`
+			Status string `json:"status"`
+		} `json:"syllables"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &scored); err != nil {
+		t.Fatalf("decode score response: %v", err)
+	}
+	if gotModel != "whisper-1" || gotLanguage != "zh" {
+		t.Fatalf("unexpected transcription request: model=%q language=%q", gotModel, gotLanguage)
+	}
+	if scored.Transcript != "我喜欢学生。" || scored.Score != 0.8 || len(scored.Syllables) != 5 {
+		t.Fatalf("unexpected score: %+v", scored)
+	}
+	if last := scored.Syllables[4]; last.Target != "习" || last.Heard != "生" || last.Status != "mismatch" {
+		t.Fatalf("expected the last syllable flagged, got %+v", last)
+	}
+
+	if missing := postAudio("我喜欢学习", nil); missing.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without audio, got %d", missing.Code)
+	}
+	if noTarget := postAudio("", []byte("fake-audio-bytes")); noTarget.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without target, got %d", noTarget.Code)
+	}
+	if tooLarge := postAudio("我喜欢学习", make([]byte, 10<<20+1)); tooLarge.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a recording over 10 MB, got %d", tooLarge.Code)
+	}
+	if tooLarge := postAudio("我喜欢学习", make([]byte, 12<<20)); tooLarge.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a request body over the limit, got %d", tooLarge.Code)
+	}
 }
 
 func TestTranslationSSENotFound(t *testing.T) {
@@ -445,19 +1400,11 @@ func TestTranslationSSENotFound(t *testing.T) {
 	streamRes := httptest.NewRecorder()
 	router.ServeHTTP(streamRes, streamReq)
 
-	if streamRes.Code != http.StatusOK {
-		t.Fatalf("expected stream status 200, got %d", streamRes.Code)
-	}
-	dataLines := extractSSEDataLines(streamRes.Body.String())
-	if len(dataLines) == 0 {
-		t.Fatal("expected SSE error event")
-	}
-	var event map[string]any
-	if err := json.Unmarshal([]byte(dataLines[0]), &event); err != nil {
-		t.Fatalf("invalid SSE json: %v", err)
+	if streamRes.Code != http.StatusNotFound {
+		t.Fatalf("expected stream status 404, got %d", streamRes.Code)
 	}
-	if event["type"] != "error" {
-		t.Fatalf("expected error event, got %v", event["type"])
+	if got := streamRes.Header().Get("Content-Type"); strings.HasPrefix(got, "text/event-stream") {
+		t.Fatalf("expected a plain error response before the stream opens, got %q", got)
 	}
 }
 
@@ -476,12 +1423,36 @@ func TestAuthBehaviorParity(t *testing.T) {
 			t.Fatalf("expected 401, got %d", res.Code)
 		}
 		body, _ := io.ReadAll(res.Result().Body)
-		if strings.TrimSpace(string(body)) != `{"detail":"Not authenticated"}` {
+		if strings.TrimSpace(string(body)) != `{"code":"unauthorized","detail":"Not authenticated"}` {
 			t.Fatalf("unexpected body: %q", string(body))
 		}
 	})
 }
 
+func TestDisableAuthSkipsLogin(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.DisableAuth = true
+	router := httprouter.NewRouter(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/translations", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 without a session, got %d: %s", res.Code, res.Body.String())
+	}
+
+	// The guard holds even for a Config that skipped Load's validation.
+	cfg = newTestConfig(t)
+	cfg.DisableAuth = true
+	cfg.SecureCookies = true
+	router = httprouter.NewRouter(cfg)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/translations", nil))
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with secure cookies, got %d", res.Code)
+	}
+}
+
 func extractSSEDataLines(body string) []string {
 	lines := strings.Split(body, "\n")
 	out := make([]string, 0, len(lines))
@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+// ErrPromptTooLarge is returned when a chat turn is still over the prompt
+// token cap after the article and history have been truncated.
+var ErrPromptTooLarge = errors.New("chat prompt too large")
+
+const (
+	// summaryLineRunes clips each older message in the history summary.
+	summaryLineRunes = 120
+	// maxSummaryLines bounds the summary itself; the oldest lines go first.
+	maxSummaryLines = 20
+)
+
+// truncateArticle keeps at most maxRunes of the article, centred on the
+// selected text when it appears in the article and on the opening otherwise.
+func truncateArticle(article string, selected string, maxRunes int) string {
+	runes := []rune(article)
+	if maxRunes <= 0 || len(runes) <= maxRunes {
+		return article
+	}
+	start := 0
+	if selected = strings.TrimSpace(selected); selected != "" {
+		if idx := strings.Index(article, selected); idx >= 0 {
+			mid := len([]rune(article[:idx])) + len([]rune(selected))/2
+			start = mid - maxRunes/2
+		}
+	}
+	start = max(0, min(start, len(runes)-maxRunes))
+	end := start + maxRunes
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(string(runes[start:end]))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// splitHistory returns the messages older than the last turns user and AI
+// messages, and those last turns themselves. Tool messages only carry review
+// cards and are left out of both.
+func splitHistory(history []translation.ChatMessage, turns int) (older []translation.ChatMessage, recent []translation.ChatMessage) {
+	conversational := make([]translation.ChatMessage, 0, len(history))
+	for _, msg := range history {
+		if msg.Role == translation.ChatRoleTool {
+			continue
+		}
+		conversational = append(conversational, msg)
+	}
+	if turns <= 0 || len(conversational) <= turns {
+		return nil, conversational
+	}
+	cut := len(conversational) - turns
+	return conversational[:cut], conversational[cut:]
+}
+
+// summarizeHistory condenses older turns into a short rolling summary, one
+// clipped line per message, so the tutor keeps the thread without the cost
+// of resending it verbatim.
+func summarizeHistory(older []translation.ChatMessage) string {
+	if len(older) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	if omitted := len(older) - maxSummaryLines; omitted > 0 {
+		fmt.Fprintf(&b, "(%d earlier messages omitted)\n", omitted)
+		older = older[omitted:]
+	}
+	for _, msg := range older {
+		speaker := "Learner"
+		if msg.Role == translation.ChatRoleAI {
+			speaker = "Tutor"
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", speaker, clipRunes(strings.Join(strings.Fields(msg.Content), " "), summaryLineRunes))
+	}
+	return b.String()
+}
+
+// clipRunes shortens s to at most n runes, marking the cut with an ellipsis.
+func clipRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// estimateTokens roughly sizes text for the prompt cap: one token per CJK
+// character and one per four other characters.
+func estimateTokens(text string) int {
+	han, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			han++
+		} else {
+			other++
+		}
+	}
+	return han + (other+3)/4
+}
@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+func TestTruncateArticleCentresOnSelection(t *testing.T) {
+	article := strings.Repeat("前", 500) + "目标" + strings.Repeat("后", 500)
+
+	got := truncateArticle(article, "目标", 100)
+	if !strings.Contains(got, "目标") {
+		t.Fatalf("expected the selection to survive truncation, got %q", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Fatalf("expected both cuts marked, got %q", got)
+	}
+	if n := len([]rune(strings.Trim(got, "…"))); n != 100 {
+		t.Fatalf("expected 100 runes kept, got %d", n)
+	}
+
+	head := truncateArticle(article, "", 100)
+	if strings.HasPrefix(head, "…") || !strings.HasPrefix(head, "前") {
+		t.Fatalf("expected the opening without a selection, got %q", head)
+	}
+	if short := truncateArticle("短文", "", 100); short != "短文" {
+		t.Fatalf("expected short articles untouched, got %q", short)
+	}
+}
+
+func TestSplitHistorySummarizesOlderTurns(t *testing.T) {
+	history := []translation.ChatMessage{
+		{Role: translation.ChatRoleUser, Content: "What does 学习 mean?"},
+		{Role: translation.ChatRoleAI, Content: "It means to study."},
+		{Role: translation.ChatRoleTool, Content: "我们在学习。"},
+		{Role: translation.ChatRoleUser, Content: "And 喜欢?"},
+		{Role: translation.ChatRoleAI, Content: "It means to like."},
+	}
+
+	older, recent := splitHistory(history, 2)
+	if len(older) != 2 || len(recent) != 2 || recent[0].Content != "And 喜欢?" {
+		t.Fatalf("unexpected split: older=%v recent=%v", older, recent)
+	}
+	summary := summarizeHistory(older)
+	if !strings.Contains(summary, "- Learner: What does 学习 mean?") || !strings.Contains(summary, "- Tutor: It means to study.") {
+		t.Fatalf("unexpected summary: %q", summary)
+	}
+	if summarizeHistory(nil) != "" {
+		t.Fatal("expected no summary without older turns")
+	}
+}
+
+func TestEstimateTokensCountsHanPerCharacter(t *testing.T) {
+	if got := estimateTokens("学习"); got != 2 {
+		t.Fatalf("expected 2 tokens, got %d", got)
+	}
+	if got := estimateTokens("abcdefgh"); got != 2 {
+		t.Fatalf("expected 2 tokens, got %d", got)
+	}
+}
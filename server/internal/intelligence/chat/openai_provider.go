@@ -19,22 +19,43 @@ import (
 
 const chatHTTPTimeout = 10 * time.Minute
 
+// defaultChatTemperature is used when a request does not set one.
+const defaultChatTemperature = 0.7
+
 // Provider implements intelligence.ChatProvider using raw OpenAI SSE streaming.
 type Provider struct {
 	httpClient *http.Client
 	baseURL    string
 	model      string
 	apiKey     string
+	// maxArticleRunes, historyTurns and maxPromptTokens cap the context sent
+	// with each turn.
+	maxArticleRunes int
+	historyTurns    int
+	maxPromptTokens int
 }
 
 // New creates a chat Provider from config.
 func New(cfg config.Config) *Provider {
-	return &Provider{
-		httpClient: &http.Client{Timeout: chatHTTPTimeout},
-		baseURL:    cfg.OpenAIBaseURL,
-		model:      cfg.OpenAIChatModel,
-		apiKey:     cfg.OpenAIAPIKey,
+	p := &Provider{
+		httpClient:      &http.Client{Timeout: chatHTTPTimeout},
+		baseURL:         cfg.OpenAIBaseURL,
+		model:           cfg.OpenAIChatModel,
+		apiKey:          cfg.OpenAIAPIKey,
+		maxArticleRunes: cfg.ChatMaxArticleRunes,
+		historyTurns:    cfg.ChatHistoryTurns,
+		maxPromptTokens: cfg.ChatMaxPromptTokens,
+	}
+	if p.maxArticleRunes == 0 {
+		p.maxArticleRunes = config.DefaultChatMaxArticleRunes
+	}
+	if p.historyTurns == 0 {
+		p.historyTurns = config.DefaultChatHistoryTurns
 	}
+	if p.maxPromptTokens == 0 {
+		p.maxPromptTokens = config.DefaultChatMaxPromptTokens
+	}
+	return p
 }
 
 var reviewCardTool = map[string]any{
@@ -60,10 +81,35 @@ Call this when the user asks to create either a
 	},
 }
 
+var explainGrammarTool = map[string]any{
+	"type": "function",
+	"function": map[string]any{
+		"name": "explain_grammar",
+		"description": `Explain a Chinese grammar point from the article.
+Call this when the user asks why a word or particle is used, what a sentence pattern means,
+or otherwise asks about grammar (e.g. "why is 了 here?").`,
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"point":       map[string]any{"type": "string", "description": "The grammar point, e.g. \"了 marking a completed action\""},
+				"explanation": map[string]any{"type": "string", "description": "A concise English explanation of how the point works in the article"},
+				"examples": map[string]any{
+					"type":        "array",
+					"description": "Short example sentences using the point, each with pinyin and English",
+					"items":       map[string]any{"type": "string"},
+				},
+			},
+			"required": []string{"point", "explanation", "examples"},
+		},
+	},
+}
+
 // ChatWithTranslationContext implements intelligence.ChatProvider.
 // It builds a messages array with a system prompt containing the article and
 // highlighted segments, appends prior history turns, then streams the response
-// token-by-token via onChunk.
+// token-by-token via onChunk. Long articles are cut down around the selection
+// and only the last historyTurns messages are sent verbatim, older ones being
+// summarized in the system prompt.
 func (p *Provider) ChatWithTranslationContext(ctx context.Context, req intelligence.ChatWithTranslationRequest, onChunk func(string) error, onToolCallStart func(name string)) (intelligence.ChatResult, error) {
 	userMessage := strings.TrimSpace(req.UserMessage)
 	if userMessage == "" {
@@ -84,20 +130,25 @@ When the user asks to:
 - create a practice word, character, sentence, phrase, or segment, use the create_review_card function.
 - create a example word, character, sentence, phrase, or segment, use the create_review_card function.
 - create a character review card, use the create_review_card function.
+- explain a grammar point, particle, or sentence pattern (e.g. "why is 了 here?"), use the explain_grammar function.
 
 ## ARTICLE:
 %s
 `,
-		translationText,
+		truncateArticle(translationText, req.SelectedText, p.maxArticleRunes),
 	)
 	if req.SelectedText != "" {
 		systemPrompt += fmt.Sprintf("\n## SELECTED TEXT:\n<selected>%s</selected>\n", req.SelectedText)
 	}
+	older, recent := splitHistory(req.History, p.historyTurns)
+	if summary := summarizeHistory(older); summary != "" {
+		systemPrompt += "\n## EARLIER CONVERSATION (summarized):\n" + summary
+	}
 
 	messages := []map[string]string{
 		{"role": "system", "content": systemPrompt},
 	}
-	for _, msg := range req.History {
+	for _, msg := range recent {
 		role := strings.ToLower(msg.Role)
 		if role != "user" && role != "assistant" {
 			continue
@@ -111,19 +162,34 @@ When the user asks to:
 		"role":    "user",
 		"content": userMessage,
 	})
+	promptTokens := 0
+	for _, msg := range messages {
+		promptTokens += estimateTokens(msg["content"])
+	}
+	if promptTokens > p.maxPromptTokens {
+		return intelligence.ChatResult{}, fmt.Errorf("%w: about %d tokens, limit %d", ErrPromptTooLarge, promptTokens, p.maxPromptTokens)
+	}
 
 	reasoning := map[string]any{
 		"enabled": false,
 	}
+	model := p.model
+	if req.Model != "" {
+		model = req.Model
+	}
+	temperature := defaultChatTemperature
+	if req.Temperature != nil {
+		temperature = *req.Temperature
+	}
 
 	body, err := json.Marshal(map[string]any{
-		"model":       p.model,
+		"model":       model,
 		"messages":    messages,
 		"stream":      true,
 		"thinking":    false,
-		"temperature": 0.7,
+		"temperature": temperature,
 		"reasoning":   reasoning,
-		"tools":       []any{reviewCardTool},
+		"tools":       []any{reviewCardTool, explainGrammarTool},
 		"tool_choice": "auto",
 	})
 	if err != nil {
@@ -2,6 +2,8 @@ package intelligence
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/anath2/language-app/internal/translation"
 )
@@ -11,13 +13,21 @@ type ChatWithTranslationRequest struct {
 	UserMessage     string
 	History         []translation.ChatMessage
 	SelectedText    string
+	// Temperature and Model override the provider defaults when set.
+	Temperature *float64
+	Model       string
 }
 
 // TranslationProvider defines the translation intelligence contract.
+// lang selects the source language of the text and the target language of
+// the translations.
 type TranslationProvider interface {
-	Segment(ctx context.Context, text string) ([]string, error)
-	TranslateSentenceSegments(ctx context.Context, segments []string, sentence string, fullText string) ([]translation.SegmentResult, error)
-	TranslateFull(ctx context.Context, text string) (string, error)
+	Segment(ctx context.Context, text string, lang translation.LanguagePair) ([]string, error)
+	TranslateSentenceSegments(ctx context.Context, segments []string, sentence string, fullText string, lang translation.LanguagePair) ([]translation.SegmentResult, error)
+	TranslateFull(ctx context.Context, text string, lang translation.LanguagePair) (string, error)
+	// TranslateFullStream is the streaming form of TranslateFull: onChunk is
+	// called with each delta and the result is their concatenation.
+	TranslateFullStream(ctx context.Context, text string, lang translation.LanguagePair, onChunk func(string) error) (string, error)
 }
 
 type ToolCallResult struct {
@@ -37,3 +47,93 @@ type ChatResult struct {
 type ChatProvider interface {
 	ChatWithTranslationContext(ctx context.Context, req ChatWithTranslationRequest, onChunk func(string) error, onToolCallStart func(name string)) (ChatResult, error)
 }
+
+// SpeechProvider transcribes recorded speech. lang is the source language
+// code of the recording, e.g. "zh".
+type SpeechProvider interface {
+	Transcribe(ctx context.Context, audio []byte, filename string, lang string) (string, error)
+}
+
+// SegmentationInstruction describes the instruction a provider segments
+// with, so operators can confirm which prompt is live.
+type SegmentationInstruction struct {
+	Path   string // file it was read from
+	SHA256 string // hex digest of the instruction text
+	Length int    // in characters
+}
+
+// ErrInstructionNotFound is returned when no compiled segmentation
+// instruction exists to load.
+var ErrInstructionNotFound = errors.New("compiled segmentation instruction not found")
+
+// SegmentationInstructionReloader swaps in a newly compiled segmentation
+// instruction without restarting.
+type SegmentationInstructionReloader interface {
+	ReloadSegmentationInstruction() (SegmentationInstruction, error)
+}
+
+// SegmentationStats counts segmentation outcomes since the process started.
+// ModelCalls counts texts the model segmented; each ends up in exactly one of
+// Reconstructed, NotReconstructed or EmptyResults. Repaired counts the
+// NotReconstructed ones that were realigned to their input. CedictFastPath
+// counts texts segmented from the dictionary without the model.
+type SegmentationStats struct {
+	ModelCalls       int64
+	Reconstructed    int64
+	NotReconstructed int64
+	EmptyResults     int64
+	Repaired         int64
+	CedictFastPath   int64
+}
+
+// SegmentationStatsReporter exposes live segmentation quality counters.
+type SegmentationStatsReporter interface {
+	SegmentationStats() SegmentationStats
+}
+
+// ErrEvalDatasetNotFound is returned when an evaluation names a dataset that
+// is not bundled.
+var ErrEvalDatasetNotFound = errors.New("evaluation dataset not found")
+
+// SegmentationEvaluation scores a candidate segmentation instruction against
+// a bundled dataset. A case counts as an exact match when its segments equal
+// the reference segmentation (or, for datasets without one, reconstruct the
+// input); failed model calls count as both errors and reconstruction
+// failures.
+type SegmentationEvaluation struct {
+	Dataset                string
+	TotalCases             int
+	ExactMatches           int
+	ReconstructionFailures int
+	Errors                 int
+	Accuracy               float64
+	AvgLatency             time.Duration
+}
+
+// SegmentationEvaluator runs a candidate segmentation instruction against a
+// bundled dataset without changing the live one.
+type SegmentationEvaluator interface {
+	EvaluateSegmentationInstruction(ctx context.Context, instruction string, dataset string) (SegmentationEvaluation, error)
+}
+
+// DictEntry is one dictionary sense group for a headword.
+type DictEntry struct {
+	Traditional    string
+	Simplified     string
+	Pinyin         string // tone-marked
+	PinyinNumbered string // as written in the dictionary, e.g. "xue2 xi2"
+	Definitions    []string
+}
+
+// DictionaryProvider exposes the word dictionary used alongside translation.
+type DictionaryProvider interface {
+	// LookupWord returns every entry whose simplified or traditional headword
+	// is word. The bool is false when there is no match.
+	LookupWord(word string) ([]DictEntry, bool)
+	// SearchDictionaryPrefix returns entries whose simplified headword starts
+	// with prefix, shortest headwords first.
+	SearchDictionaryPrefix(prefix string, limit int) ([]DictEntry, error)
+	// ReloadDictionary re-reads the dictionary source and returns the number
+	// of entries now loaded.
+	ReloadDictionary() (int, error)
+}
@@ -0,0 +1,96 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anath2/language-app/internal/config"
+)
+
+const speechHTTPTimeout = 2 * time.Minute
+
+// Provider implements intelligence.SpeechProvider against an
+// OpenAI-compatible /audio/transcriptions endpoint.
+type Provider struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+	apiKey     string
+}
+
+// New creates a speech Provider from config. It returns nil when no
+// speech-to-text model is configured.
+func New(cfg config.Config) *Provider {
+	if cfg.SpeechToTextModel == "" {
+		return nil
+	}
+	return &Provider{
+		httpClient: &http.Client{Timeout: speechHTTPTimeout},
+		baseURL:    cfg.SpeechToTextBaseURL,
+		model:      cfg.SpeechToTextModel,
+		apiKey:     cfg.SpeechToTextAPIKey,
+	}
+}
+
+// Transcribe implements intelligence.SpeechProvider.
+func (p *Provider) Transcribe(ctx context.Context, audio []byte, filename string, lang string) (string, error) {
+	if len(audio) == 0 {
+		return "", fmt.Errorf("audio is required")
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("model", p.model); err != nil {
+		return "", fmt.Errorf("write transcription model: %w", err)
+	}
+	if lang != "" {
+		if err := form.WriteField("language", lang); err != nil {
+			return "", fmt.Errorf("write transcription language: %w", err)
+		}
+	}
+	if err := form.WriteField("response_format", "json"); err != nil {
+		return "", fmt.Errorf("write transcription format: %w", err)
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("create transcription file part: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", fmt.Errorf("write transcription audio: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("close transcription form: %w", err)
+	}
+
+	endpoint := strings.TrimRight(p.baseURL, "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", fmt.Errorf("create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("transcription upstream status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode transcription response: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
@@ -0,0 +1,191 @@
+package translation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/anath2/language-app/internal/config"
+	"github.com/anath2/language-app/internal/logging"
+)
+
+const (
+	anthropicAPI     = "anthropic"
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens caps each reply; the Messages API requires a limit
+	// and long passages need room for a full translation.
+	anthropicMaxTokens = 8192
+)
+
+func newAnthropicProvider(cfg config.Config) *Provider {
+	return &Provider{
+		api:                anthropicAPI,
+		client:             &http.Client{},
+		baseURL:            strings.TrimRight(cfg.AnthropicBaseURL, "/"),
+		apiKey:             cfg.AnthropicAPIKey,
+		model:              strings.TrimSpace(cfg.AnthropicTranslationModel),
+		instruction:        loadCompiledSegmentationInstruction(cfg),
+		dict:               newCedictDictionary(resolveCedictPath(cfg.CedictPath)),
+		fastPathCoverage:   cfg.CedictFastPathCoverage,
+		glossSenses:        cfg.CedictGlossSenses,
+		repairSegmentation: cfg.SegmentationRepair,
+		timeouts:           newCallTimeouts(cfg),
+		definitionPriority: cfg.DefinitionPriority,
+	}
+}
+
+type anthropicRequest struct {
+	Model      string               `json:"model"`
+	MaxTokens  int                  `json:"max_tokens"`
+	System     string               `json:"system"`
+	Messages   []chatMessage        `json:"messages"`
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Stream     bool                 `json:"stream,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// completeAnthropic gets structured output from the Messages API by forcing
+// a call to a single tool whose input schema is the response schema, then
+// returns the tool input as JSON so the shared parsers can read it.
+func (p *Provider) completeAnthropic(ctx context.Context, systemPrompt, userPrompt string, schema map[string]any, schemaName string) (string, error) {
+	respBody, err := p.postAnthropic(ctx, anthropicRequest{
+		Model:     p.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    systemPrompt,
+		Messages:  []chatMessage{{Role: "user", Content: userPrompt}},
+		Tools: []anthropicTool{{
+			Name:        schemaName,
+			Description: "Record the result in the required structure.",
+			InputSchema: schema,
+		}},
+		ToolChoice: &anthropicToolChoice{Type: "tool", Name: schemaName},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer respBody.Close()
+
+	var msgResp struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := json.NewDecoder(respBody).Decode(&msgResp); err != nil {
+		return "", fmt.Errorf("parse upstream response: %w", err)
+	}
+	for _, block := range msgResp.Content {
+		if block.Type == "tool_use" && block.Name == schemaName {
+			return string(block.Input), nil
+		}
+	}
+	return "", fmt.Errorf("no %s tool call in upstream response", schemaName)
+}
+
+// completeStreamAnthropic streams plain text from the Messages API, reading
+// text deltas from content_block_delta events.
+func (p *Provider) completeStreamAnthropic(ctx context.Context, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
+	respBody, err := p.postAnthropic(ctx, anthropicRequest{
+		Model:     p.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    systemPrompt,
+		Messages:  []chatMessage{{Role: "user", Content: userPrompt}},
+		Stream:    true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer respBody.Close()
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(respBody)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		payload := strings.TrimPrefix(line, "data: ")
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			logging.Printf(ctx, "translation SSE parse error: %v payload=%q", err, payload)
+			continue
+		}
+		switch event.Type {
+		case "message_stop":
+			return full.String(), nil
+		case "error":
+			return full.String(), fmt.Errorf("upstream stream error: %s", event.Error.Message)
+		case "content_block_delta":
+		default:
+			continue
+		}
+		if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+			continue
+		}
+		full.WriteString(event.Delta.Text)
+		if onChunk != nil {
+			if err := onChunk(event.Delta.Text); err != nil {
+				return full.String(), err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return full.String(), fmt.Errorf("read upstream stream: %w", err)
+	}
+	return full.String(), nil
+}
+
+// postAnthropic sends a Messages API request and returns the body of a
+// successful response; the caller closes it.
+func (p *Provider) postAnthropic(ctx context.Context, reqBody anthropicRequest) (io.ReadCloser, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if reqBody.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	p.setAuthHeaders(httpReq)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("upstream request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamErrorBody+1))
+		return nil, upstreamStatusError(resp.StatusCode, respBody)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,122 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	store "github.com/anath2/language-app/internal/translation"
+)
+
+func newTestAnthropicProvider(t *testing.T, srv *httptest.Server) *Provider {
+	t.Helper()
+	p := newTestProvider(t, srv)
+	p.api = anthropicAPI
+	return p
+}
+
+func TestAnthropicProvider_TranslateSentenceSegments(t *testing.T) {
+	t.Parallel()
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]any{{
+				"type": "tool_use",
+				"name": "sentence_segments_translation_result",
+				"input": map[string]any{"translations": []map[string]any{
+					{"pinyin": "nǐ hǎo", "english": "hello"},
+				}},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestAnthropicProvider(t, srv)
+	results, err := p.TranslateSentenceSegments(context.Background(), []string{"你好"}, "你好", "你好", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Pinyin != "nǐ hǎo" || results[0].English != "hello" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if got["system"] == "" || got["max_tokens"] == nil {
+		t.Fatalf("expected system prompt and max_tokens in request, got %v", got)
+	}
+	choice, _ := got["tool_choice"].(map[string]any)
+	if choice["type"] != "tool" || choice["name"] != "sentence_segments_translation_result" {
+		t.Fatalf("expected forced tool choice, got %v", got["tool_choice"])
+	}
+}
+
+func TestAnthropicProvider_MissingToolCall(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]any{{"type": "text", "text": "sorry"}},
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestAnthropicProvider(t, srv)
+	if _, err := p.Segment(context.Background(), "你好", store.DefaultLanguagePair()); err == nil {
+		t.Fatal("expected error when the reply has no tool call")
+	}
+}
+
+func TestAnthropicProvider_TranslateFullStream(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		for _, delta := range []string{"Hello", ", ", "world!"} {
+			data, _ := json.Marshal(map[string]any{
+				"type":  "content_block_delta",
+				"delta": map[string]any{"type": "text_delta", "text": delta},
+			})
+			_, _ = fmt.Fprintf(w, "event: content_block_delta\ndata: %s\n\n", data)
+		}
+		_, _ = fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer srv.Close()
+
+	p := newTestAnthropicProvider(t, srv)
+	var chunks []string
+	got, err := p.TranslateFullStream(context.Background(), "你好世界", store.DefaultLanguagePair(), func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "Hello, world!" || strings.Join(chunks, "") != got {
+		t.Fatalf("unexpected stream result: got=%q chunks=%q", got, chunks)
+	}
+}
+
+func TestAnthropicProvider_CheckUpstream(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("x-api-key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"data":[]}`)
+	}))
+	defer srv.Close()
+
+	p := newTestAnthropicProvider(t, srv)
+	if err := p.CheckUpstream(context.Background()); err != nil {
+		t.Fatalf("expected reachable upstream, got %v", err)
+	}
+}
@@ -0,0 +1,621 @@
+package translation
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/anath2/language-app/internal/config"
+	"github.com/anath2/language-app/internal/intelligence"
+	"github.com/anath2/language-app/internal/pinyin"
+	store "github.com/anath2/language-app/internal/translation"
+)
+
+// cedictEntry is one line of a CC-CEDICT file:
+//
+//	傳統 传统 [chuan2 tong3] /tradition/traditional/
+type cedictEntry struct {
+	Traditional string
+	Simplified  string
+	Pinyin      string // numbered, as written in the file
+	Definitions []string
+}
+
+const (
+	defaultDictionarySearchLimit = 20
+	maxDictionarySearchLimit     = 100
+	// defaultGlossSenses is how many CEDICT senses a fallback gloss joins
+	// when the provider was not configured with a count.
+	defaultGlossSenses = 3
+)
+
+// cedictDictionary is an in-memory CC-CEDICT index keyed by headword. The
+// file is parsed on first use so servers that never look words up do not pay
+// for it.
+type cedictDictionary struct {
+	path     string
+	loadOnce sync.Once
+	mu       sync.RWMutex
+	entries  map[string][]cedictEntry
+	// simplified holds every simplified headword in sorted order so prefix
+	// searches can binary-search to the first match.
+	simplified []string
+	// maxHeadwordRunes bounds the lookahead of greedySegment.
+	maxHeadwordRunes int
+}
+
+func newCedictDictionary(path string) *cedictDictionary {
+	return &cedictDictionary{path: path}
+}
+
+// resolveCedictPath returns the configured path, or the first default location
+// that exists when none is configured.
+func resolveCedictPath(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	for _, path := range []string{
+		filepath.Join("data", "cedict_ts.u8"),
+		filepath.Join("server", "data", "cedict_ts.u8"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func (d *cedictDictionary) ensureLoaded() {
+	d.loadOnce.Do(func() {
+		entries, err := parseCedictFile(d.path)
+		if err != nil {
+			log.Printf("cedict dictionary unavailable: %v", err)
+			entries = map[string][]cedictEntry{}
+		} else {
+			log.Printf("loaded cedict dictionary: path=%s headwords=%d", d.path, len(entries))
+		}
+		d.mu.Lock()
+		d.entries = entries
+		d.simplified = sortedSimplifiedHeadwords(entries)
+		d.maxHeadwordRunes = longestHeadwordRunes(entries)
+		d.mu.Unlock()
+	})
+}
+
+// Reload re-parses the dictionary file and swaps in the new index. Lookups in
+// flight keep reading the old index until the swap. On error the current
+// index is left untouched.
+func (d *cedictDictionary) Reload() error {
+	entries, err := parseCedictFile(d.path)
+	if err != nil {
+		return err
+	}
+	simplified := sortedSimplifiedHeadwords(entries)
+	maxHeadwordRunes := longestHeadwordRunes(entries)
+	// A reload stands in for the lazy initial load; wait for one already
+	// running so it cannot overwrite the fresh index afterwards.
+	d.loadOnce.Do(func() {})
+	d.mu.Lock()
+	d.entries = entries
+	d.simplified = simplified
+	d.maxHeadwordRunes = maxHeadwordRunes
+	d.mu.Unlock()
+	log.Printf("reloaded cedict dictionary: path=%s headwords=%d", d.path, len(entries))
+	return nil
+}
+
+// entryCount returns the number of dictionary lines currently indexed.
+func (d *cedictDictionary) entryCount() int {
+	d.ensureLoaded()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	count := 0
+	for _, headword := range d.simplified {
+		for _, entry := range d.entries[headword] {
+			if entry.Simplified == headword {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func (d *cedictDictionary) lookup(word string) []cedictEntry {
+	d.ensureLoaded()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.entries[word]
+}
+
+// searchPrefix returns the simplified headwords starting with prefix, shortest
+// first, capped at limit.
+func (d *cedictDictionary) searchPrefix(prefix string, limit int) []string {
+	d.ensureLoaded()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var matches []string
+	for i := sort.SearchStrings(d.simplified, prefix); i < len(d.simplified); i++ {
+		if !strings.HasPrefix(d.simplified[i], prefix) {
+			break
+		}
+		matches = append(matches, d.simplified[i])
+	}
+	// No frequency data ships with CC-CEDICT, so shorter (more general)
+	// headwords come first, then code point order for stability.
+	sort.SliceStable(matches, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(matches[i]), utf8.RuneCountInString(matches[j])
+		if li != lj {
+			return li < lj
+		}
+		return matches[i] < matches[j]
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// greedySegment splits text by forward maximum matching: at each ideograph
+// it takes the longest CEDICT headword starting there, or the single
+// character when none matches. Runs of letters and digits and runs of
+// whitespace are kept whole; other characters stand alone.
+func (d *cedictDictionary) greedySegment(text string) []string {
+	d.ensureLoaded()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	runes := []rune(text)
+	var segments []string
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		end := i + 1
+		switch {
+		case isCJKIdeograph(r):
+			for n := min(d.maxHeadwordRunes, len(runes)-i); n > 1; n-- {
+				if _, ok := d.entries[string(runes[i:i+n])]; ok {
+					end = i + n
+					break
+				}
+			}
+		case unicode.IsSpace(r):
+			for end < len(runes) && unicode.IsSpace(runes[end]) {
+				end++
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			for end < len(runes) && !isCJKIdeograph(runes[end]) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+		}
+		segments = append(segments, string(runes[i:end]))
+		i = end
+	}
+	return segments
+}
+
+func longestHeadwordRunes(entries map[string][]cedictEntry) int {
+	longest := 0
+	for headword := range entries {
+		longest = max(longest, utf8.RuneCountInString(headword))
+	}
+	return longest
+}
+
+func sortedSimplifiedHeadwords(entries map[string][]cedictEntry) []string {
+	out := make([]string, 0, len(entries))
+	for headword, group := range entries {
+		for _, entry := range group {
+			if entry.Simplified == headword {
+				out = append(out, headword)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func parseCedictFile(path string) (map[string][]cedictEntry, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no cedict file found")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open cedict file: %w", err)
+	}
+	defer f.Close()
+
+	entries := make(map[string][]cedictEntry)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry, ok := parseCedictLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		entries[entry.Simplified] = append(entries[entry.Simplified], entry)
+		if entry.Traditional != entry.Simplified {
+			entries[entry.Traditional] = append(entries[entry.Traditional], entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read cedict file: %w", err)
+	}
+	return entries, nil
+}
+
+func parseCedictLine(line string) (cedictEntry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return cedictEntry{}, false
+	}
+	open := strings.Index(line, "[")
+	closeIdx := strings.Index(line, "]")
+	if open < 0 || closeIdx < open {
+		return cedictEntry{}, false
+	}
+	headwords := strings.Fields(line[:open])
+	if len(headwords) != 2 {
+		return cedictEntry{}, false
+	}
+	var defs []string
+	for _, def := range strings.Split(line[closeIdx+1:], "/") {
+		if def = strings.TrimSpace(def); def != "" {
+			defs = append(defs, def)
+		}
+	}
+	if len(defs) == 0 {
+		return cedictEntry{}, false
+	}
+	return cedictEntry{
+		Traditional: headwords[0],
+		Simplified:  headwords[1],
+		Pinyin:      strings.TrimSpace(line[open+1 : closeIdx]),
+		Definitions: defs,
+	}, true
+}
+
+func (e cedictEntry) toDictEntry() intelligence.DictEntry {
+	return intelligence.DictEntry{
+		Traditional:    e.Traditional,
+		Simplified:     e.Simplified,
+		Pinyin:         pinyin.NumberedToToneMarks(e.Pinyin),
+		PinyinNumbered: e.Pinyin,
+		Definitions:    append([]string(nil), e.Definitions...),
+	}
+}
+
+// resolveSegment combines the model's reading and gloss for a segment with
+// CEDICT, as definitionPriority directs, and records which of them the
+// result rests on. CEDICT is only consulted for Chinese input.
+func (p *Provider) resolveSegment(segment, llmPinyin, llmEnglish, llmPOS string, lang store.LanguagePair) store.SegmentResult {
+	var entries []cedictEntry
+	if p.dict != nil && lang.Source == store.DefaultSourceLang {
+		entries = p.dict.lookup(segment)
+	}
+	// The source a *_only priority excludes is hidden from the reading and
+	// gloss; part of speech and classifier still draw on both.
+	defEntries := entries
+	switch {
+	case p.cedictOnly(lang):
+		llmPinyin, llmEnglish = "", ""
+	case p.definitionPriority == config.DefinitionPriorityLLMOnly:
+		defEntries = nil
+	}
+	preferCedict := p.definitionPriority != config.DefinitionPriorityLLMFirst
+	reading := resolvePinyin(defEntries, llmPinyin)
+	english, source := resolveMeaning(defEntries, reading, llmEnglish, lang.Target, p.glossSenseLimit(), preferCedict)
+	pos := resolvePOS(entries, llmPOS)
+	return store.SegmentResult{
+		Segment:    segment,
+		Pinyin:     reading,
+		English:    english,
+		Source:     source,
+		POS:        pos,
+		Classifier: resolveClassifier(entries, reading, pos),
+	}
+}
+
+// cedictSegment segments Chinese text from CEDICT alone so common text skips
+// the model. It gives up, leaving segmentation to the model, when the share
+// of characters inside known words is below fastPathCoverage or the segments
+// do not reconstruct the input.
+func (p *Provider) cedictSegment(text string, lang store.LanguagePair) ([]string, bool) {
+	if p.fastPathCoverage <= 0 || p.dict == nil || lang.Source != store.DefaultSourceLang {
+		return nil, false
+	}
+	segments := p.dict.greedySegment(text)
+	if normalizeForReconstruction(strings.Join(segments, "")) != normalizeForReconstruction(text) {
+		return nil, false
+	}
+	known, total := 0, 0
+	for _, seg := range segments {
+		ideographs := 0
+		for _, r := range seg {
+			if isCJKIdeograph(r) {
+				ideographs++
+			}
+		}
+		total += ideographs
+		if ideographs > 0 && len(p.dict.lookup(seg)) > 0 {
+			known += ideographs
+		}
+	}
+	if total == 0 || float64(known)/float64(total) < p.fastPathCoverage {
+		return nil, false
+	}
+	return segments, true
+}
+
+// SegmentNeedsContext reports whether a segment's translation depends on its
+// sentence, so a cached translation should only be reused for that sentence.
+// That holds when CEDICT lists several readings for it, or none at all so the
+// model's contextual gloss is all there is, and always for non-Chinese input.
+func (p *Provider) SegmentNeedsContext(segment string, lang store.LanguagePair) bool {
+	if p.dict == nil || lang.Source != store.DefaultSourceLang {
+		return true
+	}
+	entries := p.dict.lookup(segment)
+	if len(entries) == 0 {
+		return true
+	}
+	reading := comparablePinyin(entries[0].Pinyin)
+	for _, entry := range entries[1:] {
+		if comparablePinyin(entry.Pinyin) != reading {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeForReconstruction drops whitespace, which segmenters may trim, so
+// joined segments can be compared with their input.
+func normalizeForReconstruction(text string) string {
+	return strings.Join(strings.Fields(text), "")
+}
+
+// resolvePinyin prefers CEDICT readings. The model's reading is kept when it
+// matches one of the dictionary readings, since it was chosen in context for
+// characters with several pronunciations.
+func resolvePinyin(entries []cedictEntry, llmPinyin string) string {
+	if len(entries) == 0 {
+		return llmPinyin
+	}
+	if llmPinyin != "" {
+		want := comparablePinyin(pinyin.ToneMarksToNumbered(llmPinyin))
+		for _, entry := range entries {
+			if comparablePinyin(entry.Pinyin) == want {
+				return llmPinyin
+			}
+		}
+	}
+	return pinyin.NumberedToToneMarks(entries[0].Pinyin)
+}
+
+func (p *Provider) glossSenseLimit() int {
+	if p.glossSenses > 0 {
+		return p.glossSenses
+	}
+	return defaultGlossSenses
+}
+
+// cedictOnly reports whether segments in lang are read and glossed from
+// CEDICT alone, so the model need not be asked. Input CEDICT does not cover
+// still goes to the model.
+func (p *Provider) cedictOnly(lang store.LanguagePair) bool {
+	return p.definitionPriority == config.DefinitionPriorityCedictOnly && p.dict != nil && lang.Source == store.DefaultSourceLang
+}
+
+// resolveMeaning picks a segment's gloss from up to limit CEDICT senses for
+// its reading and the model's contextual gloss. With preferCedict the
+// dictionary senses win whenever CEDICT has the word; otherwise the model's
+// gloss is kept when there is one. Either way the other fills a gap.
+func resolveMeaning(entries []cedictEntry, reading string, llmEnglish string, targetLang string, limit int, preferCedict bool) (string, string) {
+	// CEDICT glosses are English, so they are no use for other targets.
+	hasCedictGloss := len(entries) > 0 && targetLang == store.DefaultTargetLang
+	if hasCedictGloss && (preferCedict || llmEnglish == "") {
+		return strings.Join(glossSenses(entries, reading, limit), "; "), store.SegmentSourceCedict
+	}
+	if llmEnglish != "" {
+		if len(entries) > 0 {
+			return llmEnglish, store.SegmentSourceCedict
+		}
+		return llmEnglish, store.SegmentSourceLLM
+	}
+	return "", store.SegmentSourceFallback
+}
+
+// entriesForReading returns the entries read as reading (tone-marked), or
+// just the first entry when none are.
+func entriesForReading(entries []cedictEntry, reading string) []cedictEntry {
+	want := comparablePinyin(pinyin.ToneMarksToNumbered(reading))
+	matching := make([]cedictEntry, 0, len(entries))
+	for _, entry := range entries {
+		if comparablePinyin(entry.Pinyin) == want {
+			matching = append(matching, entry)
+		}
+	}
+	if len(matching) == 0 {
+		return entries[:1]
+	}
+	return matching
+}
+
+// glossSenses picks up to limit distinct senses from the entries read as
+// reading, so a polysemous character is glossed for the pronunciation it has
+// in context rather than whichever entry the file lists first. Classifier
+// notes ("CL:...") are not senses and are skipped.
+func glossSenses(entries []cedictEntry, reading string, limit int) []string {
+	senses := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for _, entry := range entriesForReading(entries, reading) {
+		for _, def := range entry.Definitions {
+			if len(senses) == limit {
+				return senses
+			}
+			if strings.HasPrefix(def, "CL:") || seen[def] {
+				continue
+			}
+			seen[def] = true
+			senses = append(senses, def)
+		}
+	}
+	return senses
+}
+
+// resolvePOS keeps the model's part of speech, which reflects how the word is
+// used in its sentence, and otherwise infers one from the CEDICT senses so
+// dictionary-only segments are tagged without a model call.
+func resolvePOS(entries []cedictEntry, llmPOS string) string {
+	if pos := store.NormalizePOS(llmPOS); pos != "" {
+		return pos
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return inferPOS(entries[0].Definitions)
+}
+
+var cedictPronouns = map[string]bool{
+	"i": true, "me": true, "you": true, "he": true, "him": true, "she": true, "her": true,
+	"it": true, "we": true, "us": true, "they": true, "them": true,
+}
+
+// inferPOS guesses a part of speech from CEDICT definition conventions:
+// "classifier for ..." senses are measure words, nouns list their classifiers
+// as "CL:...", and verb senses start with "to ". It returns "" when the
+// definitions give no clear signal.
+func inferPOS(defs []string) string {
+	if len(defs) == 0 {
+		return ""
+	}
+	first := strings.ToLower(strings.TrimSpace(defs[0]))
+	switch {
+	case strings.HasPrefix(first, "classifier for"), strings.HasPrefix(first, "measure word"):
+		return store.POSMeasureWord
+	case strings.Contains(first, "particle"):
+		return store.POSParticle
+	case cedictPronouns[first]:
+		return store.POSPronoun
+	}
+	for _, def := range defs {
+		if strings.HasPrefix(def, "CL:") {
+			return store.POSNoun
+		}
+	}
+	if strings.HasPrefix(first, "to ") {
+		return store.POSVerb
+	}
+	return ""
+}
+
+// resolveClassifier returns the first measure word CEDICT lists for a noun
+// under the reading it has in context. Other parts of speech get none, since
+// a classifier note on a word used as, say, a verb describes a different use.
+func resolveClassifier(entries []cedictEntry, reading string, pos string) string {
+	if pos != store.POSNoun || len(entries) == 0 {
+		return ""
+	}
+	for _, entry := range entriesForReading(entries, reading) {
+		for _, def := range entry.Definitions {
+			if classifiers := parseClassifiers(def); len(classifiers) > 0 {
+				return classifiers[0]
+			}
+		}
+	}
+	return ""
+}
+
+// parseClassifiers reads a CEDICT classifier note such as
+// "CL:本[ben3],冊|册[ce4]" and returns the simplified measure words in order.
+// Definitions that are not classifier notes yield nil.
+func parseClassifiers(def string) []string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(def), "CL:")
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range strings.Split(rest, ",") {
+		word, _, _ := strings.Cut(strings.TrimSpace(item), "[")
+		if _, simplified, found := strings.Cut(word, "|"); found {
+			word = simplified
+		}
+		if word = strings.TrimSpace(word); word != "" {
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+var comparablePinyinReplacer = strings.NewReplacer("u:", "v", "ü", "v", "U:", "v", "Ü", "v", "5", "", " ", "")
+
+// comparablePinyin reduces numbered pinyin to a spacing-, case- and
+// neutral-tone-insensitive form so readings from different sources compare.
+func comparablePinyin(numbered string) string {
+	return strings.ToLower(comparablePinyinReplacer.Replace(numbered))
+}
+
+// LookupWord implements intelligence.DictionaryProvider.
+func (p *Provider) LookupWord(word string) ([]intelligence.DictEntry, bool) {
+	word = strings.TrimSpace(word)
+	if word == "" || p.dict == nil {
+		return []intelligence.DictEntry{}, false
+	}
+	matches := p.dict.lookup(word)
+	out := make([]intelligence.DictEntry, 0, len(matches))
+	for _, entry := range matches {
+		out = append(out, entry.toDictEntry())
+	}
+	return out, len(out) > 0
+}
+
+// SearchDictionaryPrefix implements intelligence.DictionaryProvider. It returns
+// entries whose simplified headword starts with prefix, for autocomplete.
+// limit defaults to 20 and is capped at 100 entries.
+func (p *Provider) SearchDictionaryPrefix(prefix string, limit int) ([]intelligence.DictEntry, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, fmt.Errorf("prefix is required")
+	}
+	if limit <= 0 {
+		limit = defaultDictionarySearchLimit
+	}
+	if limit > maxDictionarySearchLimit {
+		limit = maxDictionarySearchLimit
+	}
+	out := make([]intelligence.DictEntry, 0, limit)
+	if p.dict == nil {
+		return out, nil
+	}
+	for _, headword := range p.dict.searchPrefix(prefix, limit) {
+		for _, entry := range p.dict.lookup(headword) {
+			if entry.Simplified != headword {
+				continue
+			}
+			if len(out) == limit {
+				return out, nil
+			}
+			out = append(out, entry.toDictEntry())
+		}
+	}
+	return out, nil
+}
+
+// ReloadDictionary implements intelligence.DictionaryProvider, re-reading the
+// dictionary file from disk and returning the new entry count.
+func (p *Provider) ReloadDictionary() (int, error) {
+	if p.dict == nil {
+		return 0, fmt.Errorf("dictionary is not configured")
+	}
+	if err := p.dict.Reload(); err != nil {
+		return 0, fmt.Errorf("reload dictionary: %w", err)
+	}
+	return p.dict.entryCount(), nil
+}
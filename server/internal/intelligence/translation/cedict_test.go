@@ -0,0 +1,379 @@
+package translation
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anath2/language-app/internal/config"
+	store "github.com/anath2/language-app/internal/translation"
+)
+
+const testCedict = `# CC-CEDICT test fixture
+學習 学习 [xue2 xi2] /to learn/to study/
+學 学 [xue2] /to learn/to imitate/science/
+學生 学生 [xue2 sheng5] /student/schoolchild/
+女 女 [nu:3] /female/woman/daughter/
+malformed line without brackets
+`
+
+func newTestDictionaryProvider(t *testing.T) *Provider {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cedict_ts.u8")
+	if err := os.WriteFile(path, []byte(testCedict), 0o644); err != nil {
+		t.Fatalf("write cedict fixture: %v", err)
+	}
+	return &Provider{dict: newCedictDictionary(path)}
+}
+
+func TestParseCedictLine(t *testing.T) {
+	t.Parallel()
+	entry, ok := parseCedictLine("學習 学习 [xue2 xi2] /to learn/to study/")
+	if !ok {
+		t.Fatal("expected line to parse")
+	}
+	if entry.Traditional != "學習" || entry.Simplified != "学习" || entry.Pinyin != "xue2 xi2" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if len(entry.Definitions) != 2 || entry.Definitions[1] != "to study" {
+		t.Fatalf("unexpected definitions: %v", entry.Definitions)
+	}
+
+	for _, line := range []string{"", "# comment", "no brackets here", "学 [xue2] /missing traditional/"} {
+		if _, ok := parseCedictLine(line); ok {
+			t.Fatalf("expected %q to be rejected", line)
+		}
+	}
+}
+
+func TestLookupWord(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+
+	entries, ok := p.LookupWord("学习")
+	if !ok || len(entries) != 1 {
+		t.Fatalf("expected one entry for 学习, got ok=%v entries=%+v", ok, entries)
+	}
+	if entries[0].Pinyin != "xué xí" || entries[0].PinyinNumbered != "xue2 xi2" {
+		t.Fatalf("unexpected pinyin: %+v", entries[0])
+	}
+
+	if entries, ok := p.LookupWord("學習"); !ok || entries[0].Simplified != "学习" {
+		t.Fatalf("expected traditional headword lookup to match, got ok=%v entries=%+v", ok, entries)
+	}
+	if entries, _ := p.LookupWord("女"); len(entries) != 1 || entries[0].Pinyin != "nǚ" {
+		t.Fatalf("expected u: to convert to ǚ, got %+v", entries)
+	}
+	if entries, ok := p.LookupWord("不存在"); ok || entries == nil || len(entries) != 0 {
+		t.Fatalf("expected empty non-nil result for unknown word, got ok=%v entries=%v", ok, entries)
+	}
+}
+
+func TestSearchDictionaryPrefix(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+
+	entries, err := p.SearchDictionaryPrefix("学", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make([]string, 0, len(entries))
+	for _, e := range entries {
+		got = append(got, e.Simplified)
+	}
+	want := []string{"学", "学习", "学生"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	if entries, _ := p.SearchDictionaryPrefix("学", 2); len(entries) != 2 {
+		t.Fatalf("expected limit to cap results, got %d", len(entries))
+	}
+	if _, err := p.SearchDictionaryPrefix("  ", 10); err == nil {
+		t.Fatal("expected empty prefix to be rejected")
+	}
+}
+
+func TestReloadDictionaryPicksUpFileChanges(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+	if _, ok := p.LookupWord("老师"); ok {
+		t.Fatal("did not expect 老师 before reload")
+	}
+
+	updated := testCedict + "老師 老师 [lao3 shi1] /teacher/\n"
+	if err := os.WriteFile(p.dict.path, []byte(updated), 0o644); err != nil {
+		t.Fatalf("rewrite cedict fixture: %v", err)
+	}
+	count, err := p.ReloadDictionary()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("expected 5 entries after reload, got %d", count)
+	}
+	if _, ok := p.LookupWord("老师"); !ok {
+		t.Fatal("expected 老师 after reload")
+	}
+
+	if err := os.Remove(p.dict.path); err != nil {
+		t.Fatalf("remove fixture: %v", err)
+	}
+	if _, err := p.ReloadDictionary(); err == nil {
+		t.Fatal("expected reload of a missing file to fail")
+	}
+	if _, ok := p.LookupWord("老师"); !ok {
+		t.Fatal("expected failed reload to keep the previous index")
+	}
+}
+
+func TestResolveSegmentRecordsSource(t *testing.T) {
+	t.Parallel()
+	zhEn := store.DefaultLanguagePair()
+
+	tests := []struct {
+		name        string
+		priority    string
+		segment     string
+		llmPinyin   string
+		llmEnglish  string
+		wantPinyin  string
+		wantEnglish string
+		wantSource  string
+	}{
+		{name: "dictionary senses win by default", segment: "学习", llmPinyin: "xuéxí", llmEnglish: "studying", wantPinyin: "xuéxí", wantEnglish: "to learn; to study", wantSource: store.SegmentSourceCedict},
+		{name: "dictionary word keeps contextual gloss", priority: config.DefinitionPriorityLLMFirst, segment: "学习", llmPinyin: "xuéxí", llmEnglish: "studying", wantPinyin: "xuéxí", wantEnglish: "studying", wantSource: store.SegmentSourceCedict},
+		{name: "dictionary reading overrides mismatched model reading", priority: config.DefinitionPriorityLLMFirst, segment: "学习", llmPinyin: "xuē xī", llmEnglish: "study", wantPinyin: "xué xí", wantEnglish: "study", wantSource: store.SegmentSourceCedict},
+		{name: "neutral tone matches", priority: config.DefinitionPriorityLLMFirst, segment: "学生", llmPinyin: "xuésheng", llmEnglish: "student", wantPinyin: "xuésheng", wantEnglish: "student", wantSource: store.SegmentSourceCedict},
+		{name: "dictionary fills missing gloss", priority: config.DefinitionPriorityLLMFirst, segment: "女", llmPinyin: "", llmEnglish: "", wantPinyin: "nǚ", wantEnglish: "female; woman; daughter", wantSource: store.SegmentSourceCedict},
+		{name: "model only", segment: "电脑", llmPinyin: "diànnǎo", llmEnglish: "computer", wantPinyin: "diànnǎo", wantEnglish: "computer", wantSource: store.SegmentSourceLLM},
+		{name: "nothing available", segment: "电脑", wantSource: store.SegmentSourceFallback},
+		{name: "cedict only ignores the model", priority: config.DefinitionPriorityCedictOnly, segment: "学习", llmPinyin: "xuē xī", llmEnglish: "studying", wantPinyin: "xué xí", wantEnglish: "to learn; to study", wantSource: store.SegmentSourceCedict},
+		{name: "cedict only leaves unknown words empty", priority: config.DefinitionPriorityCedictOnly, segment: "电脑", llmPinyin: "diànnǎo", llmEnglish: "computer", wantSource: store.SegmentSourceFallback},
+		{name: "llm only ignores the dictionary", priority: config.DefinitionPriorityLLMOnly, segment: "学习", llmPinyin: "xuē xī", llmEnglish: "studying", wantPinyin: "xuē xī", wantEnglish: "studying", wantSource: store.SegmentSourceLLM},
+		{name: "llm only leaves gaps unfilled", priority: config.DefinitionPriorityLLMOnly, segment: "女", wantSource: store.SegmentSourceFallback},
+	}
+	for _, tc := range tests {
+		p := newTestDictionaryProvider(t)
+		p.definitionPriority = tc.priority
+		got := p.resolveSegment(tc.segment, tc.llmPinyin, tc.llmEnglish, "", zhEn)
+		if got.Pinyin != tc.wantPinyin || got.English != tc.wantEnglish || got.Source != tc.wantSource {
+			t.Fatalf("%s: got %+v", tc.name, got)
+		}
+	}
+}
+
+func TestCedictOnlySkipsModelForChineseSegments(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+	p.definitionPriority = config.DefinitionPriorityCedictOnly
+	p.client = http.DefaultClient
+	p.baseURL = "http://unused.invalid"
+
+	results, err := p.TranslateSentenceSegments(context.Background(), []string{"学习", "女"}, "学习女", "学习女", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("expected no model call, got %v", err)
+	}
+	if results[0].English != "to learn; to study" || results[1].Pinyin != "nǚ" {
+		t.Fatalf("unexpected cedict results: %+v", results)
+	}
+}
+
+func TestGlossSensesFollowReadingAndLimit(t *testing.T) {
+	t.Parallel()
+	entries := []cedictEntry{
+		{Simplified: "行", Pinyin: "hang2", Definitions: []string{"row", "line", "profession", "CL:個|个"}},
+		{Simplified: "行", Pinyin: "xing2", Definitions: []string{"to walk", "to go", "capable", "OK"}},
+		{Simplified: "行", Pinyin: "xing2", Definitions: []string{"to go", "behavior"}},
+	}
+	if got := glossSenses(entries, "xíng", 3); strings.Join(got, "; ") != "to walk; to go; capable" {
+		t.Fatalf("expected senses for the xing2 reading, got %v", got)
+	}
+	if got := glossSenses(entries, "xíng", 10); strings.Join(got, "; ") != "to walk; to go; capable; OK; behavior" {
+		t.Fatalf("expected distinct senses across matching entries, got %v", got)
+	}
+	if got := glossSenses(entries, "háng", 5); strings.Join(got, "; ") != "row; line; profession" {
+		t.Fatalf("expected classifier note skipped, got %v", got)
+	}
+	if got := glossSenses(entries, "", 1); strings.Join(got, "; ") != "row" {
+		t.Fatalf("expected first entry without a matching reading, got %v", got)
+	}
+
+	p := newTestDictionaryProvider(t)
+	p.glossSenses = 1
+	if got := p.resolveSegment("女", "", "", "", store.DefaultLanguagePair()); got.English != "female" {
+		t.Fatalf("expected a single configured sense, got %q", got.English)
+	}
+}
+
+func TestResolveClassifierForNouns(t *testing.T) {
+	t.Parallel()
+	if got := parseClassifiers("CL:本[ben3],冊|册[ce4]"); strings.Join(got, ",") != "本,册" {
+		t.Fatalf("unexpected classifiers: %v", got)
+	}
+	if got := parseClassifiers("book"); got != nil {
+		t.Fatalf("expected no classifiers, got %v", got)
+	}
+
+	entries := []cedictEntry{
+		{Simplified: "书", Pinyin: "shu1", Definitions: []string{"book", "letter", "CL:本[ben3],冊|册[ce4]"}},
+	}
+	if got := resolveClassifier(entries, "shū", store.POSNoun); got != "本" {
+		t.Fatalf("expected 本 for 书, got %q", got)
+	}
+	if got := resolveClassifier(entries, "shū", store.POSVerb); got != "" {
+		t.Fatalf("expected no classifier for a verb, got %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "cedict_ts.u8")
+	if err := os.WriteFile(path, []byte("書 书 [shu1] /book/letter/CL:本[ben3],冊|册[ce4]/\n"), 0o644); err != nil {
+		t.Fatalf("write cedict fixture: %v", err)
+	}
+	p := &Provider{dict: newCedictDictionary(path)}
+	got := p.resolveSegment("书", "", "", "", store.DefaultLanguagePair())
+	if got.POS != store.POSNoun || got.Classifier != "本" || got.English != "book; letter" {
+		t.Fatalf("expected an inferred noun with its classifier, got %+v", got)
+	}
+}
+
+func TestGreedySegmentPrefersLongestHeadword(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+	got := p.dict.greedySegment("我學習学生abc 12，女")
+	want := []string{"我", "學習", "学生", "abc", " ", "12", "，", "女"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("greedySegment = %q, want %q", got, want)
+	}
+}
+
+func TestOfflineProviderUsesOnlyCedict(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+	p.offline = true
+	ctx := context.Background()
+	lang := store.DefaultLanguagePair()
+
+	segments, err := p.Segment(ctx, "我学习", lang)
+	if err != nil {
+		t.Fatalf("segment: %v", err)
+	}
+	if strings.Join(segments, "|") != "我|学习" {
+		t.Fatalf("unexpected segments: %q", segments)
+	}
+	results, err := p.TranslateSentenceSegments(ctx, segments, "我学习", "我学习", lang)
+	if err != nil {
+		t.Fatalf("translate segments: %v", err)
+	}
+	if results[0].English != "" || results[0].Source != store.SegmentSourceFallback {
+		t.Fatalf("expected unknown segment to be marked as fallback, got %+v", results[0])
+	}
+	if results[1].Pinyin != "xué xí" || results[1].English != "to learn; to study" || results[1].Source != store.SegmentSourceCedict {
+		t.Fatalf("unexpected cedict result: %+v", results[1])
+	}
+	full, err := p.TranslateFull(ctx, "我学习学生。", lang)
+	if err != nil {
+		t.Fatalf("translate full: %v", err)
+	}
+	if full != "我 to learn student。" {
+		t.Fatalf("unexpected offline gloss: %q", full)
+	}
+	if err := p.CheckUpstream(ctx); err != nil {
+		t.Fatalf("expected offline upstream check to pass, got %v", err)
+	}
+}
+
+func TestSegmentUsesCedictWhenCoverageIsHigh(t *testing.T) {
+	t.Parallel()
+	srv := mockCompletionServer(t, `{"segments":["我","学习"]}`)
+	defer srv.Close()
+
+	p := newTestDictionaryProvider(t)
+	p.client = srv.Client()
+	p.baseURL = "http://unused.invalid"
+	p.fastPathCoverage = 0.95
+	ctx := context.Background()
+
+	// Every character is in a known word, so the model is not needed.
+	segments, err := p.Segment(ctx, "学习 学生", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("segment: %v", err)
+	}
+	if strings.Join(segments, "|") != "学习| |学生" {
+		t.Fatalf("unexpected cedict segments: %q", segments)
+	}
+
+	// 我 is unknown, so coverage falls below the threshold.
+	p.baseURL = srv.URL
+	segments, err = p.Segment(ctx, "我学习", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("segment: %v", err)
+	}
+	if strings.Join(segments, "|") != "我|学习" {
+		t.Fatalf("expected model segments, got %q", segments)
+	}
+}
+
+func TestSegmentNeedsContext(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "cedict_ts.u8")
+	fixture := testCedict + "了 了 [le5] /completed action marker/\n瞭 了 [liao3] /to finish/to understand/\n"
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatalf("write cedict fixture: %v", err)
+	}
+	p := &Provider{dict: newCedictDictionary(path)}
+	zh := store.DefaultLanguagePair()
+
+	if p.SegmentNeedsContext("学习", zh) {
+		t.Fatal("expected a single-reading word to be cacheable on its own")
+	}
+	if !p.SegmentNeedsContext("了", zh) {
+		t.Fatal("expected a word with several readings to need its sentence")
+	}
+	if !p.SegmentNeedsContext("我", zh) {
+		t.Fatal("expected a word missing from CEDICT to need its sentence")
+	}
+	if !p.SegmentNeedsContext("学习", store.LanguagePair{Source: "ja", Target: "en"}) {
+		t.Fatal("expected non-Chinese input to need its sentence")
+	}
+}
+
+func TestResolvePOSPrefersModelAndInfersFromCedict(t *testing.T) {
+	t.Parallel()
+	p := newTestDictionaryProvider(t)
+	zhEn := store.DefaultLanguagePair()
+
+	if got := p.resolveSegment("学习", "", "", "Noun", zhEn).POS; got != store.POSNoun {
+		t.Fatalf("expected the model's part of speech, got %q", got)
+	}
+	if got := p.resolveSegment("学习", "", "", "", zhEn).POS; got != store.POSVerb {
+		t.Fatalf("expected a verb inferred from \"to learn\", got %q", got)
+	}
+	if got := p.resolveSegment("电脑", "", "", "gerund", zhEn).POS; got != "" {
+		t.Fatalf("expected no part of speech without a usable signal, got %q", got)
+	}
+
+	tests := []struct {
+		defs []string
+		want string
+	}{
+		{defs: []string{"classifier for people or objects in general"}, want: store.POSMeasureWord},
+		{defs: []string{"(modal particle indicating polite suggestion)"}, want: store.POSParticle},
+		{defs: []string{"I", "me", "my"}, want: store.POSPronoun},
+		{defs: []string{"book", "letter", "CL:本[ben3]"}, want: store.POSNoun},
+		{defs: []string{"to eat"}, want: store.POSVerb},
+		{defs: []string{"good", "well"}, want: ""},
+	}
+	for _, tc := range tests {
+		if got := inferPOS(tc.defs); got != tc.want {
+			t.Fatalf("inferPOS(%q) = %q, want %q", tc.defs, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package translation
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anath2/language-app/internal/intelligence"
+)
+
+// DefaultEvalDataset is the bundled corpus used when an evaluation names
+// none.
+const DefaultEvalDataset = "sentences_20"
+
+var evalDatasetName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// evalCase is one row of a GEPA dataset. Expected is empty for datasets that
+// only list input text, which are scored on reconstruction alone.
+type evalCase struct {
+	ID       string
+	Text     string
+	Expected []string
+}
+
+// resolveEvalDataset finds a bundled dataset CSV by name, looking in the
+// same places as the compiled instruction.
+func resolveEvalDataset(name string) (string, error) {
+	if !evalDatasetName.MatchString(name) {
+		return "", intelligence.ErrEvalDatasetNotFound
+	}
+	for _, dir := range []string{
+		filepath.Join("data", "jepa", "datasets"),
+		filepath.Join("server", "data", "jepa", "datasets"),
+	} {
+		path := filepath.Join(dir, name+".csv")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", intelligence.ErrEvalDatasetNotFound
+}
+
+// loadEvalCases reads a GEPA dataset CSV. The text column is "sentence" or
+// "paragraph"; an optional "expected_segments_json" column holds the
+// reference segmentation as a JSON array.
+func loadEvalCases(path string) ([]evalCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open eval dataset: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read eval dataset header: %w", err)
+	}
+	idCol, textCol, expectedCol := -1, -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "id":
+			idCol = i
+		case "sentence", "paragraph":
+			textCol = i
+		case "expected_segments_json":
+			expectedCol = i
+		}
+	}
+	if textCol < 0 {
+		return nil, fmt.Errorf("eval dataset has no sentence or paragraph column")
+	}
+
+	var cases []evalCase
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read eval dataset: %w", err)
+		}
+		c := evalCase{Text: strings.TrimSpace(record[textCol])}
+		if c.Text == "" {
+			continue
+		}
+		if idCol >= 0 {
+			c.ID = strings.TrimSpace(record[idCol])
+		}
+		if expectedCol >= 0 && strings.TrimSpace(record[expectedCol]) != "" {
+			if err := json.Unmarshal([]byte(record[expectedCol]), &c.Expected); err != nil {
+				return nil, fmt.Errorf("parse expected segments for %q: %w", c.ID, err)
+			}
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("eval dataset %s has no cases", path)
+	}
+	return cases, nil
+}
+
+// EvaluateSegmentationInstruction implements intelligence.SegmentationEvaluator.
+// It segments every case in the named bundled dataset with instruction,
+// one model call at a time, mirroring evaluate_program in
+// scripts-py/gepa_segmentation.py: a case matches when its segments equal
+// the reference segmentation, or reconstruct the input when the dataset has
+// none. The live instruction and segmentation counters are left untouched.
+func (p *Provider) EvaluateSegmentationInstruction(ctx context.Context, instruction string, dataset string) (intelligence.SegmentationEvaluation, error) {
+	if p.offline {
+		return intelligence.SegmentationEvaluation{}, fmt.Errorf("segmentation evaluation needs a model; offline mode is on")
+	}
+	if dataset == "" {
+		dataset = DefaultEvalDataset
+	}
+	path, err := resolveEvalDataset(dataset)
+	if err != nil {
+		return intelligence.SegmentationEvaluation{}, err
+	}
+	cases, err := loadEvalCases(path)
+	if err != nil {
+		return intelligence.SegmentationEvaluation{}, err
+	}
+
+	out := intelligence.SegmentationEvaluation{Dataset: dataset, TotalCases: len(cases)}
+	var elapsed time.Duration
+	for _, c := range cases {
+		if err := ctx.Err(); err != nil {
+			return intelligence.SegmentationEvaluation{}, err
+		}
+		started := time.Now()
+		callCtx, cancel := withTimeout(ctx, p.timeouts.segmentation)
+		content, err := p.complete(callCtx, instruction, c.Text, segmentationSchema, "segmentation_result")
+		cancel()
+		elapsed += time.Since(started)
+		var segments []string
+		if err == nil {
+			segments, err = parseSegmentsResult(content)
+		}
+		if err != nil {
+			out.Errors++
+			out.ReconstructionFailures++
+			continue
+		}
+		if normalizeForReconstruction(strings.Join(segments, "")) != normalizeForReconstruction(c.Text) {
+			out.ReconstructionFailures++
+			continue
+		}
+		if len(c.Expected) == 0 || slices.Equal(segments, c.Expected) {
+			out.ExactMatches++
+		}
+	}
+	out.Accuracy = float64(out.ExactMatches) / float64(out.TotalCases)
+	out.AvgLatency = elapsed / time.Duration(out.TotalCases)
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anath2/language-app/internal/intelligence"
+	store "github.com/anath2/language-app/internal/translation"
+)
+
+func TestProvider_EvaluateSegmentationInstruction(t *testing.T) {
+	tempDir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("chdir temp dir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+	dataset := "id,sentence,expected_segments_json\n" +
+		"s1,我喜欢学习。,\"[\"\"我\"\",\"\"喜欢\"\",\"\"学习\"\",\"\"。\"\"]\"\n" +
+		"s2,人工智能改变世界。,\"[\"\"人工智能\"\",\"\"改变\"\",\"\"世界\"\",\"\"。\"\"]\"\n" +
+		"s3,今天下午。,\"[\"\"今天\"\",\"\"下午\"\",\"\"。\"\"]\"\n"
+	path := filepath.Join("data", "jepa", "datasets", "tiny.csv")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir datasets: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(dataset), 0o644); err != nil {
+		t.Fatalf("write dataset: %v", err)
+	}
+
+	// One exact match, one segmentation that reconstructs but differs from
+	// the reference, and one that drops characters.
+	replies := map[string][]string{
+		"我喜欢学习。":    {"我", "喜欢", "学习", "。"},
+		"人工智能改变世界。": {"人工", "智能", "改变", "世界", "。"},
+		"今天下午。":     {"今天", "。"},
+	}
+	var systemPrompt string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		systemPrompt = req.Messages[0].Content
+		content, _ := json.Marshal(map[string]any{"segments": replies[req.Messages[1].Content]})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"content": string(content)}}},
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, srv)
+	got, err := p.EvaluateSegmentationInstruction(context.Background(), "candidate instruction", "tiny")
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	if got.TotalCases != 3 || got.ExactMatches != 1 || got.ReconstructionFailures != 1 || got.Errors != 0 {
+		t.Fatalf("unexpected evaluation: %+v", got)
+	}
+	if got.Accuracy < 0.33 || got.Accuracy > 0.34 {
+		t.Fatalf("expected accuracy of one in three, got %v", got.Accuracy)
+	}
+	if systemPrompt != "candidate instruction" {
+		t.Fatalf("expected the candidate instruction to be used, got %q", systemPrompt)
+	}
+	if live := p.segmentationInstruction(store.DefaultLanguagePair()); live != "test instruction" {
+		t.Fatalf("expected the live instruction to be unchanged, got %q", live)
+	}
+	if stats := p.SegmentationStats(); stats.ModelCalls != 0 {
+		t.Fatalf("expected evaluation to leave live counters alone, got %+v", stats)
+	}
+
+	for _, name := range []string{"missing", "../tiny"} {
+		if _, err := p.EvaluateSegmentationInstruction(context.Background(), "x", name); !errors.Is(err, intelligence.ErrEvalDatasetNotFound) {
+			t.Fatalf("dataset %q: expected ErrEvalDatasetNotFound, got %v", name, err)
+		}
+	}
+}
@@ -16,14 +16,30 @@ func isCJKIdeograph(r rune) bool {
 		(r >= 0x30000 && r <= 0x323AF) // Extensions G-H
 }
 
-func shouldSkipSegment(segment string) bool {
+// isSourceScript reports whether r belongs to the writing system of
+// sourceLang: ideographs for all supported languages, plus kana for Japanese
+// and Hangul for Korean.
+func isSourceScript(r rune, sourceLang string) bool {
+	if isCJKIdeograph(r) {
+		return true
+	}
+	switch sourceLang {
+	case "ja":
+		return unicode.In(r, unicode.Hiragana, unicode.Katakana)
+	case "ko":
+		return unicode.Is(unicode.Hangul, r)
+	}
+	return false
+}
+
+func shouldSkipSegment(segment string, sourceLang string) bool {
 	if strings.TrimSpace(segment) == "" {
 		return true
 	}
 
 	hasCJK := false
 	for _, r := range segment {
-		if isCJKIdeograph(r) {
+		if isSourceScript(r, sourceLang) {
 			hasCJK = true
 			continue
 		}
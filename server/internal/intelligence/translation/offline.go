@@ -0,0 +1,44 @@
+package translation
+
+import (
+	"fmt"
+	"strings"
+
+	store "github.com/anath2/language-app/internal/translation"
+)
+
+func (p *Provider) offlineSegment(text string) ([]string, error) {
+	if p.dict == nil {
+		return nil, fmt.Errorf("segment text: offline mode needs a CEDICT dictionary")
+	}
+	return p.dict.greedySegment(text), nil
+}
+
+// offlineGloss stands in for the full translation in offline mode: a
+// word-by-word gloss using the first CEDICT sense of each segment. Segments
+// CEDICT does not know are kept in the source script.
+func (p *Provider) offlineGloss(text string, lang store.LanguagePair) (string, error) {
+	segments, err := p.offlineSegment(text)
+	if err != nil {
+		return "", fmt.Errorf("translate full text: %w", err)
+	}
+	var b strings.Builder
+	prevWord := false
+	for _, seg := range segments {
+		if shouldSkipSegment(seg, lang.Source) {
+			b.WriteString(seg)
+			prevWord = false
+			continue
+		}
+		gloss := seg
+		if english := p.resolveSegment(seg, "", "", "", lang).English; english != "" {
+			gloss, _, _ = strings.Cut(english, "; ")
+		}
+		if prevWord {
+			b.WriteByte(' ')
+		}
+		b.WriteString(gloss)
+		prevWord = true
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -2,13 +2,20 @@ package translation
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// errEmptySegments is returned when the model replies with no segments.
+var errEmptySegments = errors.New("parse segments: empty result")
+
 type batchTranslation struct {
+	// Segment echoes the input segment so misaligned replies can be caught.
+	Segment string `json:"segment"`
 	Pinyin  string `json:"pinyin"`
 	English string `json:"english"`
+	POS     string `json:"pos"`
 }
 
 // parseSegmentsResult unmarshals {"segments": [...]} from a json_schema response.
@@ -20,12 +27,12 @@ func parseSegmentsResult(content string) ([]string, error) {
 		return nil, fmt.Errorf("parse segments JSON: %w", err)
 	}
 	if len(result.Segments) == 0 {
-		return nil, fmt.Errorf("parse segments: empty result")
+		return nil, errEmptySegments
 	}
 	return result.Segments, nil
 }
 
-// parseBatchTranslationsResult unmarshals {"translations": [{pinyin, english}, ...]} from a json_schema response.
+// parseBatchTranslationsResult unmarshals {"translations": [{segment, pinyin, english, pos}, ...]} from a json_schema response.
 func parseBatchTranslationsResult(content string) ([]batchTranslation, error) {
 	var result struct {
 		Translations []batchTranslation `json:"translations"`
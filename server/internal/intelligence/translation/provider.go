@@ -1,9 +1,13 @@
 package translation
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,27 +16,101 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/anath2/language-app/internal/config"
+	"github.com/anath2/language-app/internal/intelligence"
+	"github.com/anath2/language-app/internal/logging"
 	store "github.com/anath2/language-app/internal/translation"
 )
 
+// llmTimeout bounds model calls whose kind has no configured timeout.
 const llmTimeout = 10 * time.Minute
 const defaultSegmentationInstruction = "Split the Chinese text into meaningful segments of words and return segments as an ordered JSON array."
+const segmentationInstructionTemplate = "Split the %s text into meaningful segments of words and return segments as an ordered JSON array."
 
 // Provider calls an OpenAI-compatible /chat/completions endpoint directly
-// and uses response_format: json_schema for structured output.
-// It implements intelligence.TranslationProvider.
+// and uses response_format: json_schema for structured output, or the
+// Anthropic Messages API when api is anthropicAPI. Prompts and the CEDICT
+// fast path are shared by both. In offline mode it makes no upstream calls
+// and answers from CEDICT alone. It implements intelligence.TranslationProvider.
 type Provider struct {
-	client      *http.Client
-	baseURL     string
-	apiKey      string
-	model       string
-	instruction string
+	api     string
+	offline bool
+	// fastPathCoverage is the CEDICT coverage needed to skip the model for
+	// segmentation; zero always asks the model.
+	fastPathCoverage float64
+	client           *http.Client
+	baseURL          string
+	apiKey           string
+	model            string
+	instruction      string
+	dict             *cedictDictionary
+	// glossSenses caps the CEDICT senses in a fallback gloss; zero means
+	// defaultGlossSenses.
+	glossSenses int
+	// instructionMu guards instruction, which ReloadSegmentationInstruction
+	// replaces while requests are in flight.
+	instructionMu sync.RWMutex
+	segStats      segmentationCounters
+	// repairSegmentation realigns model segmentations that do not rebuild
+	// their input; see repairSegments.
+	repairSegmentation bool
+	timeouts           callTimeouts
+	// definitionPriority is one of the config.DefinitionPriority* values;
+	// empty means cedict_first.
+	definitionPriority string
+}
+
+// callTimeouts bounds each kind of model call; zero means llmTimeout.
+type callTimeouts struct {
+	segmentation       time.Duration
+	segmentTranslation time.Duration
+	fullTranslation    time.Duration
+}
+
+func newCallTimeouts(cfg config.Config) callTimeouts {
+	return callTimeouts{
+		segmentation:       cfg.SegmentationTimeout,
+		segmentTranslation: cfg.SegmentTranslationTimeout,
+		fullTranslation:    cfg.FullTranslationTimeout,
+	}
+}
+
+// withTimeout bounds one model call by d, or llmTimeout when d is zero.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		d = llmTimeout
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// segmentationCounters backs SegmentationStats.
+type segmentationCounters struct {
+	modelCalls       atomic.Int64
+	reconstructed    atomic.Int64
+	notReconstructed atomic.Int64
+	emptyResults     atomic.Int64
+	repaired         atomic.Int64
+	cedictFastPath   atomic.Int64
 }
 
 func NewProvider(cfg config.Config) (*Provider, error) {
+	if cfg.OfflineMode {
+		log.Printf("offline mode: translating from CEDICT only, LLM calls disabled")
+		return &Provider{
+			offline:     true,
+			instruction: defaultSegmentationInstruction,
+			dict:        newCedictDictionary(resolveCedictPath(cfg.CedictPath)),
+			glossSenses: cfg.CedictGlossSenses,
+		}, nil
+	}
+	if cfg.TranslationProvider == config.TranslationProviderAnthropic {
+		return newAnthropicProvider(cfg), nil
+	}
 	baseURL, _, err := normalizeOpenAIEndpoint(cfg.OpenAIBaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid OPENAI_BASE_URL %q: %w", cfg.OpenAIBaseURL, err)
@@ -43,11 +121,17 @@ func NewProvider(cfg config.Config) (*Provider, error) {
 		log.Printf("openai-compatible debug enabled: base_url=%s model=%s", baseURL, cfg.OpenAITranslationModel)
 	}
 	return &Provider{
-		client:      &http.Client{Timeout: llmTimeout, Transport: transport},
-		baseURL:     baseURL,
-		apiKey:      cfg.OpenAIAPIKey,
-		model:       strings.TrimSpace(cfg.OpenAITranslationModel),
-		instruction: loadCompiledSegmentationInstruction(cfg),
+		client:             &http.Client{Transport: transport},
+		baseURL:            baseURL,
+		apiKey:             cfg.OpenAIAPIKey,
+		model:              strings.TrimSpace(cfg.OpenAITranslationModel),
+		instruction:        loadCompiledSegmentationInstruction(cfg),
+		dict:               newCedictDictionary(resolveCedictPath(cfg.CedictPath)),
+		fastPathCoverage:   cfg.CedictFastPathCoverage,
+		glossSenses:        cfg.CedictGlossSenses,
+		repairSegmentation: cfg.SegmentationRepair,
+		timeouts:           newCallTimeouts(cfg),
+		definitionPriority: cfg.DefinitionPriority,
 	}, nil
 }
 
@@ -73,10 +157,12 @@ var sentenceSegmentsTranslationSchema = map[string]any{
 			"items": map[string]any{
 				"type": "object",
 				"properties": map[string]any{
+					"segment": map[string]any{"type": "string"},
 					"pinyin":  map[string]any{"type": "string"},
 					"english": map[string]any{"type": "string"},
+					"pos":     map[string]any{"type": "string", "enum": store.PartsOfSpeech},
 				},
-				"required":             []string{"pinyin", "english"},
+				"required":             []string{"segment", "pinyin", "english", "pos"},
 				"additionalProperties": false,
 			},
 		},
@@ -96,25 +182,72 @@ var fullTranslationSchema = map[string]any{
 
 // ---- TranslationProvider implementation ----
 
-func (p *Provider) Segment(ctx context.Context, text string) ([]string, error) {
+func (p *Provider) Segment(ctx context.Context, text string, lang store.LanguagePair) ([]string, error) {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return []string{}, nil
 	}
-	content, err := p.complete(ctx, p.instruction, text, segmentationSchema, "segmentation_result")
+	if p.offline {
+		return p.offlineSegment(text)
+	}
+	if segments, ok := p.cedictSegment(text, lang); ok {
+		p.segStats.cedictFastPath.Add(1)
+		return segments, nil
+	}
+	callCtx, cancel := withTimeout(ctx, p.timeouts.segmentation)
+	content, err := p.complete(callCtx, p.segmentationInstruction(lang), text, segmentationSchema, "segmentation_result")
+	cancel()
 	if err != nil {
-		log.Printf("segment failed: err=%v text_preview=%q", err, preview(text, 40))
+		logging.Printf(ctx, "segment failed: err=%v text_preview=%q", err, preview(text, 40))
 		return nil, fmt.Errorf("segment text: %w", err)
 	}
 	segments, err := parseSegmentsResult(content)
 	if err != nil {
-		log.Printf("segment parse failed: err=%v text_preview=%q content=%q", err, preview(text, 40), content)
+		if errors.Is(err, errEmptySegments) {
+			p.segStats.modelCalls.Add(1)
+			p.segStats.emptyResults.Add(1)
+		}
+		logging.Printf(ctx, "segment parse failed: err=%v text_preview=%q content=%q", err, preview(text, 40), content)
 		return nil, fmt.Errorf("segment text: %w", err)
 	}
-	return segments, nil
+	if p.recordReconstruction(text, segments) || !p.repairSegmentation {
+		return segments, nil
+	}
+	repaired, ok := repairSegments(text, segments)
+	if !ok {
+		logging.Printf(ctx, "segment repair skipped, text too long: text_preview=%q", preview(text, 40))
+		return segments, nil
+	}
+	p.segStats.repaired.Add(1)
+	logging.Printf(ctx, "segment repair applied: text_preview=%q segments=%d repaired=%d", preview(text, 40), len(segments), len(repaired))
+	return repaired, nil
+}
+
+// recordReconstruction counts whether the model's segments rebuild text, the
+// same check the GEPA harness scores offline, and reports the outcome.
+func (p *Provider) recordReconstruction(text string, segments []string) bool {
+	p.segStats.modelCalls.Add(1)
+	if normalizeForReconstruction(strings.Join(segments, "")) == normalizeForReconstruction(text) {
+		p.segStats.reconstructed.Add(1)
+		return true
+	}
+	p.segStats.notReconstructed.Add(1)
+	return false
 }
 
-func (p *Provider) TranslateSentenceSegments(ctx context.Context, segments []string, sentence string, fullText string) ([]store.SegmentResult, error) {
+// SegmentationStats implements intelligence.SegmentationStatsReporter.
+func (p *Provider) SegmentationStats() intelligence.SegmentationStats {
+	return intelligence.SegmentationStats{
+		ModelCalls:       p.segStats.modelCalls.Load(),
+		Reconstructed:    p.segStats.reconstructed.Load(),
+		NotReconstructed: p.segStats.notReconstructed.Load(),
+		EmptyResults:     p.segStats.emptyResults.Load(),
+		Repaired:         p.segStats.repaired.Load(),
+		CedictFastPath:   p.segStats.cedictFastPath.Load(),
+	}
+}
+
+func (p *Provider) TranslateSentenceSegments(ctx context.Context, segments []string, sentence string, fullText string, lang store.LanguagePair) ([]store.SegmentResult, error) {
 	type indexedSegment struct {
 		originalIdx int
 		segment     string
@@ -124,7 +257,7 @@ func (p *Provider) TranslateSentenceSegments(ctx context.Context, segments []str
 	var cjkSegments []indexedSegment
 	for i, seg := range segments {
 		seg = strings.TrimSpace(seg)
-		if seg == "" || shouldSkipSegment(seg) {
+		if seg == "" || shouldSkipSegment(seg, lang.Source) {
 			out[i] = store.SegmentResult{Segment: seg}
 			continue
 		}
@@ -133,51 +266,104 @@ func (p *Provider) TranslateSentenceSegments(ctx context.Context, segments []str
 	if len(cjkSegments) == 0 {
 		return out, nil
 	}
+	if p.offline || p.cedictOnly(lang) {
+		for _, cs := range cjkSegments {
+			out[cs.originalIdx] = p.resolveSegment(cs.segment, "", "", "", lang)
+		}
+		return out, nil
+	}
 
 	segStrings := make([]string, len(cjkSegments))
 	for i, cs := range cjkSegments {
 		segStrings[i] = cs.segment
 	}
-	segJSON, err := json.Marshal(segStrings)
-	if err != nil {
-		return nil, fmt.Errorf("marshal segments: %w", err)
-	}
-	userMsg, err := json.Marshal(map[string]any{
-		"segments":  string(segJSON),
-		"sentence":  sentence,
-		"full_text": fullText,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("marshal translate request: %w", err)
-	}
-
-	const systemPrompt = "Given an array of Chinese word segments from a sentence, produce the pinyin (with tone marks) and a concise English translation for each segment. Use the sentence and full text for context to select the correct reading and meaning. Return a JSON object with a \"translations\" array of objects with \"pinyin\" and \"english\" fields, in the same order as the input segments."
-	content, err := p.complete(ctx, systemPrompt, string(userMsg), sentenceSegmentsTranslationSchema, "sentence_segments_translation_result")
+	content, err := p.requestSegmentTranslations(ctx, segStrings, sentence, fullText, lang)
 	if err != nil {
 		return nil, fmt.Errorf("translate sentence segments: %w", err)
 	}
 	translations, err := parseBatchTranslationsResult(content)
 	if err != nil {
-		return nil, fmt.Errorf("translate sentence segments: %w", err)
+		if len(cjkSegments) == 1 {
+			return nil, fmt.Errorf("translate sentence segments: %w", err)
+		}
+		logging.Printf(ctx, "batch translation parse failed, translating segments one by one: err=%v segments=%d", err, len(cjkSegments))
+		translations = nil
 	}
 
 	for i, cs := range cjkSegments {
-		result := store.SegmentResult{Segment: cs.segment}
+		var t batchTranslation
 		if i < len(translations) {
-			result.Pinyin = normalizeModelField(translations[i].Pinyin)
-			result.English = normalizeModelField(translations[i].English)
+			t = translations[i]
 		}
-		out[cs.originalIdx] = result
+		// A missing or misaligned entry is retried on its own rather than
+		// attaching another segment's reading.
+		if len(cjkSegments) > 1 && (i >= len(translations) || !translationMatches(t, cs.segment)) {
+			t = p.translateSingleSegment(ctx, cs.segment, sentence, fullText, lang)
+		}
+		out[cs.originalIdx] = p.resolveSegment(cs.segment, normalizeModelField(t.Pinyin), normalizeModelField(t.English), t.POS, lang)
 	}
 	return out, nil
 }
 
-func (p *Provider) TranslateFull(ctx context.Context, text string) (string, error) {
+// translateSingleSegment is the per-segment fallback for a batch reply that
+// could not be used. On failure it returns an empty translation, leaving the
+// segment to CEDICT.
+func (p *Provider) translateSingleSegment(ctx context.Context, segment string, sentence string, fullText string, lang store.LanguagePair) batchTranslation {
+	content, err := p.requestSegmentTranslations(ctx, []string{segment}, sentence, fullText, lang)
+	if err == nil {
+		var translations []batchTranslation
+		if translations, err = parseBatchTranslationsResult(content); err == nil && len(translations) > 0 {
+			return translations[0]
+		}
+	}
+	logging.Printf(ctx, "single segment translation failed: segment=%q err=%v", segment, err)
+	return batchTranslation{}
+}
+
+// translationMatches reports whether a batch entry belongs to segment. Entries
+// that do not echo their segment are trusted by position.
+func translationMatches(t batchTranslation, segment string) bool {
+	echoed := strings.TrimSpace(t.Segment)
+	return echoed == "" || echoed == segment
+}
+
+// requestSegmentTranslations asks the model for the reading and translation
+// of each segment in one structured request.
+func (p *Provider) requestSegmentTranslations(ctx context.Context, segments []string, sentence string, fullText string, lang store.LanguagePair) (string, error) {
+	segJSON, err := json.Marshal(segments)
+	if err != nil {
+		return "", fmt.Errorf("marshal segments: %w", err)
+	}
+	userMsg, err := json.Marshal(map[string]any{
+		"segments":  string(segJSON),
+		"sentence":  sentence,
+		"full_text": fullText,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal translate request: %w", err)
+	}
+
+	systemPrompt := fmt.Sprintf(
+		"Given an array of %s word segments from a sentence, produce the %s and a concise %s translation for each segment. Use the sentence and full text for context to select the correct reading and meaning. Return a JSON object with a \"translations\" array of objects with \"segment\" (the input segment, unchanged), \"pinyin\" (the reading), \"english\" (the translation) and \"pos\" (the part of speech in this sentence, one of %s) fields, in the same order as the input segments.",
+		store.LanguageName(lang.Source), readingDescription(lang.Source), store.LanguageName(lang.Target), strings.Join(store.PartsOfSpeech, ", "),
+	)
+	ctx, cancel := withTimeout(ctx, p.timeouts.segmentTranslation)
+	defer cancel()
+	return p.complete(ctx, systemPrompt, string(userMsg), sentenceSegmentsTranslationSchema, "sentence_segments_translation_result")
+}
+
+func (p *Provider) TranslateFull(ctx context.Context, text string, lang store.LanguagePair) (string, error) {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return "", nil
 	}
-	const systemPrompt = "Return concise translation data for the full text as a JSON object with a \"translation\" field."
+	if p.offline {
+		return p.offlineGloss(text, lang)
+	}
+	systemPrompt := fmt.Sprintf("Return a concise %s translation of the full %s text as a JSON object with a \"translation\" field.",
+		store.LanguageName(lang.Target), store.LanguageName(lang.Source))
+	ctx, cancel := withTimeout(ctx, p.timeouts.fullTranslation)
+	defer cancel()
 	content, err := p.complete(ctx, systemPrompt, text, fullTranslationSchema, "full_translation_result")
 	if err != nil {
 		return "", fmt.Errorf("translate full text: %w", err)
@@ -185,6 +371,59 @@ func (p *Provider) TranslateFull(ctx context.Context, text string) (string, erro
 	return parseFullTranslationResult(content)
 }
 
+// TranslateFullStream translates the full text as plain prose, calling onChunk
+// with each delta as it arrives. The returned string is exactly the
+// concatenation of every chunk passed to onChunk.
+func (p *Provider) TranslateFullStream(ctx context.Context, text string, lang store.LanguagePair, onChunk func(string) error) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", nil
+	}
+	if p.offline {
+		gloss, err := p.offlineGloss(text, lang)
+		if err != nil {
+			return "", err
+		}
+		if onChunk != nil {
+			if err := onChunk(gloss); err != nil {
+				return gloss, err
+			}
+		}
+		return gloss, nil
+	}
+	systemPrompt := fmt.Sprintf("Translate the full %s text into natural %s. Reply with the translation only, without commentary or formatting.",
+		store.LanguageName(lang.Source), store.LanguageName(lang.Target))
+	ctx, cancel := withTimeout(ctx, p.timeouts.fullTranslation)
+	defer cancel()
+	out, err := p.completeStream(ctx, systemPrompt, text, onChunk)
+	if err != nil {
+		return out, fmt.Errorf("translate full text: %w", err)
+	}
+	return out, nil
+}
+
+// segmentationInstruction returns the compiled instruction for Chinese input,
+// which it was optimised for, and a generic instruction for other languages.
+func (p *Provider) segmentationInstruction(lang store.LanguagePair) string {
+	if lang.Source == store.DefaultSourceLang {
+		p.instructionMu.RLock()
+		defer p.instructionMu.RUnlock()
+		return p.instruction
+	}
+	return fmt.Sprintf(segmentationInstructionTemplate, store.LanguageName(lang.Source))
+}
+
+func readingDescription(sourceLang string) string {
+	switch sourceLang {
+	case "ja":
+		return "romaji reading"
+	case "ko":
+		return "revised romanization"
+	default:
+		return "pinyin (with tone marks)"
+	}
+}
+
 // ---- HTTP helper ----
 
 type chatCompletionRequest struct {
@@ -210,6 +449,9 @@ type jsonSchema struct {
 }
 
 func (p *Provider) complete(ctx context.Context, systemPrompt, userPrompt string, schema map[string]any, schemaName string) (string, error) {
+	if p.api == anthropicAPI {
+		return p.completeAnthropic(ctx, systemPrompt, userPrompt, schema, schemaName)
+	}
 	reqBody := chatCompletionRequest{
 		Model: p.model,
 		Messages: []chatMessage{
@@ -248,11 +490,7 @@ func (p *Provider) complete(ctx context.Context, systemPrompt, userPrompt string
 		return "", fmt.Errorf("read upstream response: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		snippet := strings.TrimSpace(string(respBody))
-		if len(snippet) > 300 {
-			snippet = snippet[:300] + "..."
-		}
-		return "", fmt.Errorf("upstream returned status %d: %s", resp.StatusCode, snippet)
+		return "", upstreamStatusError(resp.StatusCode, respBody)
 	}
 
 	var chatResp struct {
@@ -271,9 +509,101 @@ func (p *Provider) complete(ctx context.Context, systemPrompt, userPrompt string
 	return chatResp.Choices[0].Message.Content, nil
 }
 
+type streamCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+func (p *Provider) completeStream(ctx context.Context, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
+	if p.api == anthropicAPI {
+		return p.completeStreamAnthropic(ctx, systemPrompt, userPrompt, onChunk)
+	}
+	body, err := json.Marshal(streamCompletionRequest{
+		Model: p.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("upstream request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamErrorBody+1))
+		return "", upstreamStatusError(resp.StatusCode, respBody)
+	}
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		payload := strings.TrimPrefix(line, "data: ")
+		if payload == "[DONE]" {
+			break
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			logging.Printf(ctx, "translation SSE parse error: %v payload=%q", err, payload)
+			continue
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content := chunk.Choices[0].Delta.Content
+		full.WriteString(content)
+		if onChunk != nil {
+			if err := onChunk(content); err != nil {
+				return full.String(), err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return full.String(), fmt.Errorf("read upstream stream: %w", err)
+	}
+	return full.String(), nil
+}
+
 // ---- Startup helpers ----
 
 func loadCompiledSegmentationInstruction(cfg config.Config) string {
+	instruction, path, err := readCompiledSegmentationInstruction()
+	if err != nil {
+		log.Printf("compiled segmentation instruction not found, using default")
+		return defaultSegmentationInstruction
+	}
+	log.Printf("loaded compiled segmentation instruction: path=%s", path)
+	return instruction
+}
+
+// readCompiledSegmentationInstruction returns the instruction written by the
+// GEPA script and the path it came from, skipping empty files.
+func readCompiledSegmentationInstruction() (string, string, error) {
 	for _, path := range []string{
 		filepath.Join("data", "jepa", "compiled_instruction.txt"),
 		filepath.Join("server", "data", "jepa", "compiled_instruction.txt"),
@@ -287,11 +617,44 @@ func loadCompiledSegmentationInstruction(cfg config.Config) string {
 			log.Printf("segmentation instruction file empty, falling back: path=%s", path)
 			continue
 		}
-		log.Printf("loaded compiled segmentation instruction: path=%s", path)
-		return instruction
+		return instruction, path, nil
+	}
+	return "", "", intelligence.ErrInstructionNotFound
+}
+
+// ReloadSegmentationInstruction implements
+// intelligence.SegmentationInstructionReloader. It re-reads the compiled
+// instruction from disk and swaps it in for subsequent segmentation
+// requests; on error the current instruction stays live.
+func (p *Provider) ReloadSegmentationInstruction() (intelligence.SegmentationInstruction, error) {
+	instruction, path, err := readCompiledSegmentationInstruction()
+	if err != nil {
+		return intelligence.SegmentationInstruction{}, err
+	}
+	p.instructionMu.Lock()
+	p.instruction = instruction
+	p.instructionMu.Unlock()
+	sum := sha256.Sum256([]byte(instruction))
+	info := intelligence.SegmentationInstruction{
+		Path:   path,
+		SHA256: hex.EncodeToString(sum[:]),
+		Length: utf8.RuneCountInString(instruction),
+	}
+	log.Printf("reloaded compiled segmentation instruction: path=%s sha256=%s", path, info.SHA256)
+	return info, nil
+}
+
+// maxUpstreamErrorBody bounds how much of a failed upstream response is
+// kept in the returned error, so the job's stored failure shows what the
+// provider said without growing unbounded.
+const maxUpstreamErrorBody = 8 << 10
+
+func upstreamStatusError(status int, body []byte) error {
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) > maxUpstreamErrorBody {
+		snippet = snippet[:maxUpstreamErrorBody] + "..."
 	}
-	log.Printf("compiled segmentation instruction not found, using default")
-	return defaultSegmentationInstruction
+	return fmt.Errorf("upstream returned status %d: %s", status, snippet)
 }
 
 // ---- Debug transport ----
@@ -308,18 +671,18 @@ func (rt *openAIDebugRoundTripper) RoundTrip(req *http.Request) (*http.Response,
 	start := time.Now()
 	resp, err := base.RoundTrip(req)
 	if err != nil {
-		log.Printf("openai-compatible upstream request failed: method=%s url=%s err=%v elapsed_ms=%d",
+		logging.Printf(req.Context(), "openai-compatible upstream request failed: method=%s url=%s err=%v elapsed_ms=%d",
 			req.Method, req.URL.String(), err, time.Since(start).Milliseconds())
 		return nil, err
 	}
-	log.Printf("openai-compatible upstream response: method=%s url=%s status=%d elapsed_ms=%d",
+	logging.Printf(req.Context(), "openai-compatible upstream response: method=%s url=%s status=%d elapsed_ms=%d",
 		req.Method, req.URL.String(), resp.StatusCode, time.Since(start).Milliseconds())
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 || resp.Body == nil {
 		return resp, nil
 	}
 	bodyBytes, readErr := io.ReadAll(resp.Body)
 	if readErr != nil {
-		log.Printf("openai-compatible upstream non-2xx body read failed: status=%d err=%v", resp.StatusCode, readErr)
+		logging.Printf(req.Context(), "openai-compatible upstream non-2xx body read failed: status=%d err=%v", resp.StatusCode, readErr)
 		resp.Body = io.NopCloser(bytes.NewReader(nil))
 		return resp, nil
 	}
@@ -328,10 +691,48 @@ func (rt *openAIDebugRoundTripper) RoundTrip(req *http.Request) (*http.Response,
 	if len(snippet) > 1000 {
 		snippet = snippet[:1000] + "..."
 	}
-	log.Printf("openai-compatible upstream non-2xx body: status=%d body=%s", resp.StatusCode, snippet)
+	logging.Printf(req.Context(), "openai-compatible upstream non-2xx body: status=%d body=%s", resp.StatusCode, snippet)
 	return resp, nil
 }
 
+// CheckUpstream lists the upstream models to confirm the provider is reachable
+// and accepts the configured key. The caller bounds it with ctx. Offline mode
+// has no upstream, so it always passes.
+func (p *Provider) CheckUpstream(ctx context.Context) error {
+	if p.offline {
+		return nil
+	}
+	modelsURL := p.baseURL + "/models"
+	if p.api == anthropicAPI {
+		modelsURL = p.baseURL + "/v1/models"
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL, nil)
+	if err != nil {
+		return fmt.Errorf("build models request: %w", err)
+	}
+	p.setAuthHeaders(httpReq)
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("list models: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("list models: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// setAuthHeaders adds the credentials each upstream API expects.
+func (p *Provider) setAuthHeaders(req *http.Request) {
+	if p.api == anthropicAPI {
+		req.Header.Set("x-api-key", p.apiKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+}
+
 func normalizeOpenAIEndpoint(rawBaseURL string) (string, string, error) {
 	baseURL := strings.TrimRight(strings.TrimSpace(rawBaseURL), "/")
 	if baseURL == "" {
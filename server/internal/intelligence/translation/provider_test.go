@@ -3,13 +3,21 @@ package translation
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/anath2/language-app/internal/config"
+	"github.com/anath2/language-app/internal/intelligence"
+
+	store "github.com/anath2/language-app/internal/translation"
 )
 
 func mockCompletionServer(t *testing.T, content string) *httptest.Server {
@@ -41,7 +49,7 @@ func TestProvider_Segment(t *testing.T) {
 	defer srv.Close()
 
 	p := newTestProvider(t, srv)
-	segments, err := p.Segment(context.Background(), "你好世界")
+	segments, err := p.Segment(context.Background(), "你好世界", store.DefaultLanguagePair())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -53,7 +61,7 @@ func TestProvider_Segment(t *testing.T) {
 func TestProvider_Segment_EmptyInput(t *testing.T) {
 	t.Parallel()
 	p := &Provider{client: &http.Client{}, baseURL: "http://unused", model: "m", instruction: "i"}
-	segments, err := p.Segment(context.Background(), "   ")
+	segments, err := p.Segment(context.Background(), "   ", store.DefaultLanguagePair())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -71,7 +79,7 @@ func TestProvider_Segment_UpstreamError(t *testing.T) {
 	defer srv.Close()
 
 	p := newTestProvider(t, srv)
-	_, err := p.Segment(context.Background(), "你好")
+	_, err := p.Segment(context.Background(), "你好", store.DefaultLanguagePair())
 	if err == nil {
 		t.Fatal("expected error for upstream 500, got nil")
 	}
@@ -86,7 +94,7 @@ func TestProvider_Segment_NoChoices(t *testing.T) {
 	defer srv.Close()
 
 	p := newTestProvider(t, srv)
-	_, err := p.Segment(context.Background(), "你好")
+	_, err := p.Segment(context.Background(), "你好", store.DefaultLanguagePair())
 	if err == nil {
 		t.Fatal("expected error for empty choices, got nil")
 	}
@@ -95,7 +103,7 @@ func TestProvider_Segment_NoChoices(t *testing.T) {
 func TestProvider_TranslateSentenceSegments_SkipsNonCJK(t *testing.T) {
 	t.Parallel()
 	p := &Provider{client: &http.Client{}, baseURL: "http://unused", model: "m", instruction: "i"}
-	results, err := p.TranslateSentenceSegments(context.Background(), []string{"。", "!", " "}, "test", "test")
+	results, err := p.TranslateSentenceSegments(context.Background(), []string{"。", "!", " "}, "test", "test", store.DefaultLanguagePair())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -111,17 +119,20 @@ func TestProvider_TranslateSentenceSegments_SkipsNonCJK(t *testing.T) {
 
 func TestProvider_TranslateSentenceSegments_HappyPath(t *testing.T) {
 	t.Parallel()
-	srv := mockCompletionServer(t, `{"translations":[{"pinyin":"nǐ hǎo","english":"hello"},{"pinyin":"shì jiè","english":"world"}]}`)
+	srv := mockCompletionServer(t, `{"translations":[{"pinyin":"nǐ hǎo","english":"hello","pos":"interjection"},{"pinyin":"shì jiè","english":"world","pos":"noun"}]}`)
 	defer srv.Close()
 
 	p := newTestProvider(t, srv)
-	results, err := p.TranslateSentenceSegments(context.Background(), []string{"你好", "世界"}, "你好世界", "你好世界")
+	results, err := p.TranslateSentenceSegments(context.Background(), []string{"你好", "世界"}, "你好世界", "你好世界", store.DefaultLanguagePair())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(results) != 2 || results[0].Pinyin != "nǐ hǎo" || results[0].English != "hello" {
 		t.Fatalf("unexpected results: %+v", results)
 	}
+	if results[0].POS != store.POSInterjection || results[1].POS != store.POSNoun {
+		t.Fatalf("expected parts of speech from the model, got %+v", results)
+	}
 }
 
 func TestProvider_TranslateFull(t *testing.T) {
@@ -130,7 +141,7 @@ func TestProvider_TranslateFull(t *testing.T) {
 	defer srv.Close()
 
 	p := newTestProvider(t, srv)
-	got, err := p.TranslateFull(context.Background(), "你好世界")
+	got, err := p.TranslateFull(context.Background(), "你好世界", store.DefaultLanguagePair())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -139,6 +150,58 @@ func TestProvider_TranslateFull(t *testing.T) {
 	}
 }
 
+func TestProvider_TranslateFullStream(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"Hello", ", ", "world!"} {
+			data, _ := json.Marshal(map[string]any{
+				"choices": []map[string]any{{"delta": map[string]any{"content": delta}}},
+			})
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, srv)
+	var chunks []string
+	got, err := p.TranslateFullStream(context.Background(), "你好世界", store.DefaultLanguagePair(), func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "Hello, world!" || strings.Join(chunks, "") != got {
+		t.Fatalf("unexpected stream result: got=%q chunks=%q", got, chunks)
+	}
+}
+
+func TestProvider_CheckUpstream(t *testing.T) {
+	t.Parallel()
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+		_, _ = fmt.Fprint(w, `{"data":[]}`)
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, srv)
+	if err := p.CheckUpstream(context.Background()); err != nil {
+		t.Fatalf("expected reachable upstream, got %v", err)
+	}
+	status.Store(http.StatusUnauthorized)
+	if err := p.CheckUpstream(context.Background()); err == nil {
+		t.Fatal("expected error for rejected key")
+	}
+}
+
 func TestProvider_RequestHasBearerToken(t *testing.T) {
 	t.Parallel()
 	var gotAuth string
@@ -153,7 +216,7 @@ func TestProvider_RequestHasBearerToken(t *testing.T) {
 
 	p := newTestProvider(t, srv)
 	p.apiKey = "sk-secret"
-	_, _ = p.Segment(context.Background(), "测试")
+	_, _ = p.Segment(context.Background(), "测试", store.DefaultLanguagePair())
 	if gotAuth != "Bearer sk-secret" {
 		t.Fatalf("expected 'Bearer sk-secret', got %q", gotAuth)
 	}
@@ -248,3 +311,179 @@ func TestLoadCompiledSegmentationInstruction_FallsBackToLegacyServerPath(t *test
 		t.Fatalf("expected legacy fallback instruction, got %q", got)
 	}
 }
+
+func TestProvider_SegmentRecordsReconstructionStats(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		content string
+		want    intelligence.SegmentationStats
+	}{
+		{content: `{"segments":["你好","世界"]}`, want: intelligence.SegmentationStats{ModelCalls: 1, Reconstructed: 1}},
+		{content: `{"segments":["你好"]}`, want: intelligence.SegmentationStats{ModelCalls: 1, NotReconstructed: 1}},
+		{content: `{"segments":[]}`, want: intelligence.SegmentationStats{ModelCalls: 1, EmptyResults: 1}},
+	} {
+		srv := mockCompletionServer(t, tc.content)
+		p := newTestProvider(t, srv)
+		_, _ = p.Segment(context.Background(), "你好世界", store.DefaultLanguagePair())
+		srv.Close()
+		if got := p.SegmentationStats(); got != tc.want {
+			t.Fatalf("content %s: got stats %+v, want %+v", tc.content, got, tc.want)
+		}
+	}
+}
+
+func TestProvider_SegmentRepairsReconstruction(t *testing.T) {
+	t.Parallel()
+	srv := mockCompletionServer(t, `{"segments":["你好","世"]}`)
+	defer srv.Close()
+	p := newTestProvider(t, srv)
+	p.repairSegmentation = true
+
+	segments, err := p.Segment(context.Background(), "你好世界", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("segment: %v", err)
+	}
+	if strings.Join(segments, "|") != "你好|世|界" {
+		t.Fatalf("unexpected repaired segments: %v", segments)
+	}
+	want := intelligence.SegmentationStats{ModelCalls: 1, NotReconstructed: 1, Repaired: 1}
+	if got := p.SegmentationStats(); got != want {
+		t.Fatalf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestProvider_CallTimeoutsApplyPerOperation(t *testing.T) {
+	t.Parallel()
+	// Every reply takes 200ms: longer than the segmentation timeout, well
+	// within the full translation one.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"content": `{"translation":"Hello world"}`}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, srv)
+	p.timeouts = callTimeouts{segmentation: 50 * time.Millisecond, fullTranslation: time.Minute}
+
+	if _, err := p.Segment(context.Background(), "你好世界", store.DefaultLanguagePair()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected segmentation to hit its deadline, got %v", err)
+	}
+	if _, err := p.TranslateFull(context.Background(), "你好世界", store.DefaultLanguagePair()); err != nil {
+		t.Fatalf("expected full translation to outlast the segmentation timeout, got %v", err)
+	}
+}
+
+func TestProvider_ReloadSegmentationInstruction(t *testing.T) {
+	tempDir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("chdir temp dir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+
+	p := &Provider{instruction: "old instruction"}
+	if _, err := p.ReloadSegmentationInstruction(); !errors.Is(err, intelligence.ErrInstructionNotFound) {
+		t.Fatalf("expected ErrInstructionNotFound, got %v", err)
+	}
+	if got := p.segmentationInstruction(store.DefaultLanguagePair()); got != "old instruction" {
+		t.Fatalf("expected the old instruction to stay live, got %q", got)
+	}
+
+	path := filepath.Join("data", "jepa", "compiled_instruction.txt")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir instruction dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("分词 better instruction\n"), 0o644); err != nil {
+		t.Fatalf("write instruction: %v", err)
+	}
+	info, err := p.ReloadSegmentationInstruction()
+	if err != nil {
+		t.Fatalf("reload instruction: %v", err)
+	}
+	if info.Path != path || info.Length != 21 || len(info.SHA256) != 64 {
+		t.Fatalf("unexpected instruction info: %+v", info)
+	}
+	if got := p.segmentationInstruction(store.DefaultLanguagePair()); got != "分词 better instruction" {
+		t.Fatalf("expected the reloaded instruction, got %q", got)
+	}
+}
+
+func TestProvider_TranslateSentenceSegments_FallsBackPerSegment(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req chatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var user struct {
+			Segments string `json:"segments"`
+		}
+		_ = json.Unmarshal([]byte(req.Messages[1].Content), &user)
+		var segments []string
+		_ = json.Unmarshal([]byte(user.Segments), &segments)
+
+		content := `{"translations": [` // truncated batch reply
+		if len(segments) == 1 {
+			content = fmt.Sprintf(`{"translations":[{"segment":%q,"pinyin":"p-%s","english":"e-%s"}]}`, segments[0], segments[0], segments[0])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"content": content}}},
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, srv)
+	results, err := p.TranslateSentenceSegments(context.Background(), []string{"你好", "，", "世界"}, "你好，世界", "你好，世界", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].English != "e-你好" || results[2].English != "e-世界" || results[1].English != "" {
+		t.Fatalf("unexpected fallback results: %+v", results)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("expected one batch request and two single-segment retries, got %d", got)
+	}
+}
+
+func TestProvider_TranslateSentenceSegments_RetriesMisalignedEntries(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"translations":[{"segment":"你好","pinyin":"nǐ hǎo","english":"hello"},{"segment":"再见","pinyin":"zài jiàn","english":"goodbye"}]}`
+		if requests.Add(1) > 1 {
+			content = `{"translations":[{"segment":"世界","pinyin":"shì jiè","english":"world"}]}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"content": content}}},
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, srv)
+	results, err := p.TranslateSentenceSegments(context.Background(), []string{"你好", "世界"}, "你好世界", "你好世界", store.DefaultLanguagePair())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].English != "hello" || results[1].English != "world" {
+		t.Fatalf("expected misaligned entry to be retried, got %+v", results)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("expected one retry, got %d requests", got)
+	}
+}
@@ -0,0 +1,94 @@
+package translation
+
+// maxRepairCells bounds the alignment table repairSegments builds, so a
+// runaway reply cannot make repair allocate without limit. Sentences are far
+// below it.
+const maxRepairCells = 4_000_000
+
+// repairSegments realigns segments that do not concatenate to text. It
+// aligns the characters of the segments with the characters of text by
+// longest common subsequence: characters the model added are dropped, and
+// characters it left out are spliced back in, joining the surrounding
+// segment when they fall inside one and becoming their own segment
+// otherwise. The result concatenates to text exactly. It returns false when
+// text and segments are too long to align.
+func repairSegments(text string, segments []string) ([]string, bool) {
+	src := []rune(text)
+	var got []rune
+	var owner []int
+	for i, seg := range segments {
+		for _, r := range seg {
+			got = append(got, r)
+			owner = append(owner, i)
+		}
+	}
+	n, m := len(src), len(got)
+	if (n+1)*(m+1) > maxRepairCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the LCS length of src[i:] and got[j:].
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case src[i] == got[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// srcOwner[i] is the segment that source character i belongs to, or -1
+	// when the model left it out.
+	srcOwner := make([]int, n)
+	for i, j := 0, 0; i < n; {
+		switch {
+		case j < m && src[i] == got[j]:
+			srcOwner[i] = owner[j]
+			i++
+			j++
+		case j < m && lcs[i][j+1] >= lcs[i+1][j]:
+			j++
+		default:
+			srcOwner[i] = -1
+			i++
+		}
+	}
+
+	// A run of missing characters joins its segment when the characters on
+	// both sides belong to the same one.
+	for i := 0; i < n; {
+		if srcOwner[i] != -1 {
+			i++
+			continue
+		}
+		end := i
+		for end < n && srcOwner[end] == -1 {
+			end++
+		}
+		if i > 0 && end < n && srcOwner[i-1] == srcOwner[end] {
+			for k := i; k < end; k++ {
+				srcOwner[k] = srcOwner[end]
+			}
+		}
+		i = end
+	}
+
+	var out []string
+	for i := 0; i < n; {
+		end := i + 1
+		for end < n && srcOwner[end] == srcOwner[i] {
+			end++
+		}
+		out = append(out, string(src[i:end]))
+		i = end
+	}
+	return out, true
+}
@@ -0,0 +1,43 @@
+package translation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepairSegments(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		text     string
+		segments []string
+		want     []string
+	}{
+		{name: "dropped inside segment", text: "我去图书馆", segments: []string{"我", "去", "图馆"}, want: []string{"我", "去", "图书馆"}},
+		{name: "dropped at boundary", text: "我喜欢学习", segments: []string{"我", "喜欢", "学"}, want: []string{"我", "喜欢", "学", "习"}},
+		{name: "missing punctuation", text: "你好，世界。", segments: []string{"你好", "世界"}, want: []string{"你好", "，", "世界", "。"}},
+		{name: "extra character", text: "你好世界", segments: []string{"你好", "的", "世界"}, want: []string{"你好", "世界"}},
+		{name: "substituted character", text: "今天很冷", segments: []string{"今天", "很", "令"}, want: []string{"今天", "很", "冷"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := repairSegments(tc.text, tc.segments)
+			if !ok {
+				t.Fatal("expected repair to run")
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+			if strings.Join(got, "") != tc.text {
+				t.Fatalf("repaired segments %q do not rebuild %q", got, tc.text)
+			}
+		})
+	}
+}
+
+func TestRepairSegmentsRejectsOversizedInput(t *testing.T) {
+	t.Parallel()
+	text := strings.Repeat("字", 2500)
+	if _, ok := repairSegments(text, []string{text}); ok {
+		t.Fatal("expected oversized input to be refused")
+	}
+}
@@ -3,12 +3,14 @@ package translation
 import (
 	"context"
 	"testing"
+
+	store "github.com/anath2/language-app/internal/translation"
 )
 
 func TestTranslateSentenceSegments_SkipsNonCJK(t *testing.T) {
 	t.Parallel()
 	provider := &Provider{}
-	results, err := provider.TranslateSentenceSegments(context.Background(), []string{"。", "!", " "}, "test", "test")
+	results, err := provider.TranslateSentenceSegments(context.Background(), []string{"。", "!", " "}, "test", "test", store.DefaultLanguagePair())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
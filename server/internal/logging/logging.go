@@ -0,0 +1,122 @@
+// Package logging tags log lines with the correlation id of the request or
+// job that produced them, and can emit them as JSON.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type contextKey struct{}
+
+var (
+	mu     sync.Mutex
+	format           = FormatText
+	output io.Writer = os.Stderr
+)
+
+// SetFormat switches between plain text and JSON log lines. In JSON mode
+// the standard logger is redirected too, so untagged log.Printf calls also
+// come out as JSON.
+func SetFormat(f string) {
+	setFormat(f, os.Stderr)
+}
+
+func setFormat(f string, w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+	if f == FormatJSON {
+		format = FormatJSON
+		log.SetFlags(0)
+		log.SetOutput(jsonWriter{})
+		return
+	}
+	format = FormatText
+	log.SetFlags(log.LstdFlags)
+	log.SetOutput(w)
+}
+
+// NewCorrelationID returns a random 16-character hex id.
+func NewCorrelationID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// CorrelationID returns the id stored in ctx, or "" when there is none.
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Printf logs like log.Printf, tagged with ctx's correlation id.
+func Printf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	id := CorrelationID(ctx)
+	if currentFormat() == FormatJSON {
+		writeJSON(msg, id)
+		return
+	}
+	if id != "" {
+		msg = "cid=" + id + " " + msg
+	}
+	log.Print(msg)
+}
+
+func currentFormat() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return format
+}
+
+type jsonLine struct {
+	Time          string `json:"time"`
+	Message       string `json:"msg"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+func writeJSON(msg string, id string) {
+	line, err := json.Marshal(jsonLine{
+		Time:          time.Now().UTC().Format(time.RFC3339Nano),
+		Message:       strings.TrimRight(msg, "\n"),
+		CorrelationID: id,
+	})
+	if err != nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	_, _ = output.Write(append(line, '\n'))
+}
+
+// jsonWriter adapts the standard logger's output to JSON lines.
+type jsonWriter struct{}
+
+func (jsonWriter) Write(p []byte) (int, error) {
+	writeJSON(string(p), "")
+	return len(p), nil
+}
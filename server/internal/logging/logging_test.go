@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPrintfTagsCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	setFormat(FormatText, &buf)
+	defer setFormat(FormatText, os.Stderr)
+
+	ctx := WithCorrelationID(context.Background(), "abc123")
+	Printf(ctx, "job started: id=%s", "t1")
+	if !strings.Contains(buf.String(), "cid=abc123 job started: id=t1") {
+		t.Fatalf("expected tagged text line, got %q", buf.String())
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	setFormat(FormatJSON, &buf)
+	defer setFormat(FormatText, os.Stderr)
+
+	Printf(WithCorrelationID(context.Background(), "abc123"), "hello %d", 1)
+	log.Printf("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %q", buf.String())
+	}
+	var tagged, plain jsonLine
+	if err := json.Unmarshal([]byte(lines[0]), &tagged); err != nil {
+		t.Fatalf("decode tagged line: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &plain); err != nil {
+		t.Fatalf("decode plain line: %v", err)
+	}
+	if tagged.Message != "hello 1" || tagged.CorrelationID != "abc123" {
+		t.Fatalf("unexpected tagged line: %+v", tagged)
+	}
+	if plain.Message != "plain" || plain.CorrelationID != "" {
+		t.Fatalf("unexpected plain line: %+v", plain)
+	}
+}
+
+func TestNewCorrelationIDIsUnique(t *testing.T) {
+	a, b := NewCorrelationID(), NewCorrelationID()
+	if len(a) != 16 || a == b {
+		t.Fatalf("expected distinct 16-char ids, got %q and %q", a, b)
+	}
+}
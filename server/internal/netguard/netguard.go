@@ -0,0 +1,69 @@
+// Package netguard keeps requests to user-supplied URLs away from the
+// server's own network: loopback, private, link-local (including cloud
+// metadata endpoints) and other non-public addresses.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a connection would reach an address
+// that is not on the public internet.
+var ErrNonPublicAddress = errors.New("destination is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// net.IP.IsPrivate does not cover.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublic reports whether ip is a globally routable unicast address.
+func IsPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		if ip4[0] == 0 {
+			return false
+		}
+		ip = ip4
+	}
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// Transport returns an HTTP transport like http.DefaultTransport, minus proxy
+// settings, whose connections may only reach public addresses. The check
+// runs on the address actually dialed, after DNS resolution, so it also
+// covers redirects and hostnames that resolve to internal addresses. With
+// allowPrivate it is a plain transport, for self-hosted setups that fetch
+// from their own network.
+func Transport(allowPrivate bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return transport
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDialAddress,
+	}
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return transport
+}
+
+// checkDialAddress is a net.Dialer Control func refusing non-public
+// destinations.
+func checkDialAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublic(ip) {
+		return fmt.Errorf("dial %s: %w", address, ErrNonPublicAddress)
+	}
+	return nil
+}
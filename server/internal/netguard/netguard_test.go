@@ -0,0 +1,40 @@
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	for _, raw := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		if !IsPublic(net.ParseIP(raw)) {
+			t.Fatalf("expected %s to be public", raw)
+		}
+	}
+	for _, raw := range []string{
+		"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "0.1.2.3", "::", "fe80::1", "fd00:ec2::254", "224.0.0.1", "::ffff:127.0.0.1",
+	} {
+		if IsPublic(net.ParseIP(raw)) {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestTransportRefusesNonPublicAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := (&http.Client{Transport: Transport(false)}).Get(server.URL)
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Fatalf("expected ErrNonPublicAddress, got %v", err)
+	}
+	res, err := (&http.Client{Transport: Transport(true)}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected allowPrivate transport to connect, got %v", err)
+	}
+	_ = res.Body.Close()
+}
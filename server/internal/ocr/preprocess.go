@@ -0,0 +1,179 @@
+// Package ocr prepares photographed text images for OCR.
+package ocr
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// contrastClipFraction is the share of darkest and lightest pixels
+	// ignored when stretching contrast, so glare or dust does not pin the
+	// range.
+	contrastClipFraction = 0.01
+	// maxSkewDegrees bounds the rotation deskew will search for.
+	maxSkewDegrees  = 10.0
+	skewStepDegrees = 0.5
+	// inkThreshold separates text pixels from background after contrast
+	// stretching.
+	inkThreshold = 128
+	// maxSkewSamples caps the ink pixels examined when estimating skew.
+	maxSkewSamples = 200_000
+)
+
+// Options selects the preprocessing steps. Grayscale conversion always runs.
+type Options struct {
+	Contrast bool
+	Deskew   bool
+}
+
+// Stats describes what Preprocess did, for debug logging.
+type Stats struct {
+	ContrastLow  uint8
+	ContrastHigh uint8
+	SkewDegrees  float64
+}
+
+// Preprocess converts img to grayscale, optionally stretches its contrast
+// and straightens rotated text lines.
+func Preprocess(img image.Image, opts Options) (*image.Gray, Stats) {
+	gray := Grayscale(img)
+	var stats Stats
+	if opts.Contrast {
+		stats.ContrastLow, stats.ContrastHigh = StretchContrast(gray)
+	}
+	if opts.Deskew {
+		stats.SkewDegrees = EstimateSkew(gray)
+		if stats.SkewDegrees != 0 {
+			gray = Rotate(gray, stats.SkewDegrees)
+		}
+	}
+	return gray, stats
+}
+
+// Grayscale returns a luma-only copy of img.
+func Grayscale(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out.Set(x-bounds.Min.X, y-bounds.Min.Y, color.GrayModel.Convert(img.At(x, y)))
+		}
+	}
+	return out
+}
+
+// StretchContrast linearly maps the image's clipped luma range onto 0-255 in
+// place. The range adapts to each image, so dim and washed-out photos both
+// end up with dark ink on a light page. It returns the low and high levels
+// that were stretched.
+func StretchContrast(img *image.Gray) (uint8, uint8) {
+	var histogram [256]int
+	for _, v := range img.Pix {
+		histogram[v]++
+	}
+	clip := int(float64(len(img.Pix)) * contrastClipFraction)
+	low, high := 0, 255
+	for seen := 0; low < 255; low++ {
+		seen += histogram[low]
+		if seen > clip {
+			break
+		}
+	}
+	for seen := 0; high > 0; high-- {
+		seen += histogram[high]
+		if seen > clip {
+			break
+		}
+	}
+	if high <= low {
+		return uint8(low), uint8(high)
+	}
+	scale := 255.0 / float64(high-low)
+	for i, v := range img.Pix {
+		switch {
+		case int(v) <= low:
+			img.Pix[i] = 0
+		case int(v) >= high:
+			img.Pix[i] = 255
+		default:
+			img.Pix[i] = uint8(math.Round(float64(int(v)-low) * scale))
+		}
+	}
+	return uint8(low), uint8(high)
+}
+
+// EstimateSkew returns the rotation of text lines in degrees, positive when
+// lines slope downward to the right. It picks the angle whose row projection
+// of ink pixels is most sharply peaked, which is when rows line up with text
+// lines. Returns 0 when the image has no ink.
+func EstimateSkew(img *image.Gray) float64 {
+	bounds := img.Bounds()
+	type point struct{ x, y float64 }
+	ink := make([]point, 0)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.GrayAt(x, y).Y < inkThreshold {
+				ink = append(ink, point{float64(x), float64(y)})
+			}
+		}
+	}
+	if len(ink) == 0 {
+		return 0
+	}
+	if len(ink) > maxSkewSamples {
+		stride := len(ink)/maxSkewSamples + 1
+		sampled := make([]point, 0, len(ink)/stride+1)
+		for i := 0; i < len(ink); i += stride {
+			sampled = append(sampled, ink[i])
+		}
+		ink = sampled
+	}
+
+	bestAngle, bestScore := 0.0, -1.0
+	rows := make(map[int]int)
+	for angle := -maxSkewDegrees; angle <= maxSkewDegrees+1e-9; angle += skewStepDegrees {
+		rad := angle * math.Pi / 180
+		sin, cos := math.Sin(rad), math.Cos(rad)
+		clear(rows)
+		for _, p := range ink {
+			rows[int(math.Round(p.y*cos-p.x*sin))]++
+		}
+		score := 0.0
+		for _, n := range rows {
+			score += float64(n) * float64(n)
+		}
+		// Prefer the smallest rotation among equally good angles.
+		if score > bestScore || (score == bestScore && math.Abs(angle) < math.Abs(bestAngle)) {
+			bestAngle, bestScore = angle, score
+		}
+	}
+	return math.Round(bestAngle/skewStepDegrees) * skewStepDegrees
+}
+
+// Rotate turns img by degrees about its centre, counter-clockwise as
+// displayed for positive values, filling uncovered corners with white.
+// Rotating by EstimateSkew's result levels the text lines.
+func Rotate(img *image.Gray, degrees float64) *image.Gray {
+	bounds := img.Bounds()
+	out := image.NewGray(bounds)
+	rad := degrees * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	cx := float64(bounds.Min.X+bounds.Max.X-1) / 2
+	cy := float64(bounds.Min.Y+bounds.Max.Y-1) / 2
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			// Inverse mapping: find the source pixel that lands on (x, y).
+			sx := int(math.Round(cx + dx*cos - dy*sin))
+			sy := int(math.Round(cy + dx*sin + dy*cos))
+			if image.Pt(sx, sy).In(bounds) {
+				out.SetGray(x, y, img.GrayAt(sx, sy))
+			} else {
+				out.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package ocr
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// skewedLines draws dark horizontal text-like bars on a light page, rotated so
+// they slope downward to the right by degrees.
+func skewedLines(width, height int, degrees float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	slope := math.Tan(degrees * math.Pi / 180)
+	for line := 20; line < height-20; line += 24 {
+		for x := 10; x < width-10; x++ {
+			y := line + int(math.Round(float64(x-width/2)*slope))
+			for dy := 0; dy < 4; dy++ {
+				if image.Pt(x, y+dy).In(img.Bounds()) {
+					img.SetGray(x, y+dy, color.Gray{Y: 60})
+				}
+			}
+		}
+	}
+	return img
+}
+
+func TestStretchContrastSpansFullRange(t *testing.T) {
+	img := skewedLines(100, 100, 0)
+	low, high := StretchContrast(img)
+	if low != 60 || high != 200 {
+		t.Fatalf("expected stretched range 60-200, got %d-%d", low, high)
+	}
+	var darkest, lightest uint8 = 255, 0
+	for _, v := range img.Pix {
+		darkest = min(darkest, v)
+		lightest = max(lightest, v)
+	}
+	if darkest != 0 || lightest != 255 {
+		t.Fatalf("expected pixels to span 0-255, got %d-%d", darkest, lightest)
+	}
+}
+
+func TestEstimateSkewAndRotateLevelLines(t *testing.T) {
+	img := skewedLines(300, 200, 4)
+	StretchContrast(img)
+	skew := EstimateSkew(img)
+	if math.Abs(skew-4) > skewStepDegrees {
+		t.Fatalf("expected skew near 4 degrees, got %.1f", skew)
+	}
+	if leveled := EstimateSkew(Rotate(img, skew)); math.Abs(leveled) > skewStepDegrees {
+		t.Fatalf("expected rotated image to be level, got %.1f", leveled)
+	}
+	if got := EstimateSkew(image.NewGray(image.Rect(0, 0, 10, 10))); got != 0 {
+		t.Fatalf("expected 0 skew for an image without ink, got %.1f", got)
+	}
+}
+
+func TestPreprocessConvertsToGrayscale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(5, 5, 15, 15))
+	for y := 5; y < 15; y++ {
+		for x := 5; x < 15; x++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	gray, stats := Preprocess(src, Options{})
+	if gray.Bounds() != image.Rect(0, 0, 10, 10) {
+		t.Fatalf("expected bounds rebased to origin, got %v", gray.Bounds())
+	}
+	if stats != (Stats{}) {
+		t.Fatalf("expected no stats without contrast or deskew, got %+v", stats)
+	}
+}
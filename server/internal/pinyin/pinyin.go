@@ -0,0 +1,201 @@
+// Package pinyin converts between numbered (ni3 hao3) and tone-marked (nǐ hǎo)
+// Hanyu Pinyin.
+package pinyin
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	StyleToneMarks = "tone_marks"
+	StyleNumbered  = "numbered"
+)
+
+// toneMarks maps a base vowel to its tone-marked forms for tones 1-4.
+var toneMarks = map[rune][4]rune{
+	'a': {'ā', 'á', 'ǎ', 'à'},
+	'e': {'ē', 'é', 'ě', 'è'},
+	'i': {'ī', 'í', 'ǐ', 'ì'},
+	'o': {'ō', 'ó', 'ǒ', 'ò'},
+	'u': {'ū', 'ú', 'ǔ', 'ù'},
+	'ü': {'ǖ', 'ǘ', 'ǚ', 'ǜ'},
+	'A': {'Ā', 'Á', 'Ǎ', 'À'},
+	'E': {'Ē', 'É', 'Ě', 'È'},
+	'I': {'Ī', 'Í', 'Ǐ', 'Ì'},
+	'O': {'Ō', 'Ó', 'Ǒ', 'Ò'},
+	'U': {'Ū', 'Ú', 'Ǔ', 'Ù'},
+	'Ü': {'Ǖ', 'Ǘ', 'Ǚ', 'Ǜ'},
+}
+
+// markedVowels is the reverse of toneMarks: marked vowel -> (base vowel, tone).
+var markedVowels = func() map[rune]struct {
+	base rune
+	tone int
+} {
+	out := make(map[rune]struct {
+		base rune
+		tone int
+	})
+	for base, marks := range toneMarks {
+		for i, m := range marks {
+			out[m] = struct {
+				base rune
+				tone int
+			}{base: base, tone: i + 1}
+		}
+	}
+	return out
+}()
+
+// IsValidStyle reports whether style is one of the supported output styles.
+func IsValidStyle(style string) bool {
+	return style == StyleToneMarks || style == StyleNumbered
+}
+
+// Normalize rewrites pinyin into the requested style. Text that is already in
+// that style, or has no tone information, is returned with whitespace tidied.
+func Normalize(raw string, style string) string {
+	text := strings.Join(strings.Fields(raw), " ")
+	if style == StyleNumbered {
+		return ToneMarksToNumbered(text)
+	}
+	return NumberedToToneMarks(text)
+}
+
+// NumberedToToneMarks converts syllables like "lu:4" or "nv3" into tone-marked
+// form. Tone 5 (and 0) is neutral and simply drops the digit.
+func NumberedToToneMarks(text string) string {
+	var out strings.Builder
+	runes := []rune(text)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && isSyllableRune(runes[i]) {
+			continue
+		}
+		if i < len(runes) && runes[i] >= '0' && runes[i] <= '5' && i > start {
+			out.WriteString(markSyllable(runes[start:i], int(runes[i]-'0')))
+			start = i + 1
+			continue
+		}
+		out.WriteString(string(runes[start:i]))
+		if i < len(runes) {
+			out.WriteRune(runes[i])
+		}
+		start = i + 1
+	}
+	return out.String()
+}
+
+// ToneMarksToNumbered converts tone-marked syllables into numbered form, e.g.
+// "Zhōngguó" -> "Zhong1guo2". Syllables without a mark are left unchanged.
+func ToneMarksToNumbered(text string) string {
+	var out strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		mv, ok := markedVowels[runes[i]]
+		if !ok {
+			out.WriteRune(runes[i])
+			continue
+		}
+		out.WriteRune(mv.base)
+		// Copy the rest of the syllable: trailing vowels, then an optional
+		// n/ng/r coda that is not the onset of the next syllable.
+		j := i + 1
+		for j < len(runes) && isPlainVowel(runes[j]) {
+			out.WriteRune(runes[j])
+			j++
+		}
+		j = copyCoda(&out, runes, j)
+		out.WriteRune(rune('0' + mv.tone))
+		i = j - 1
+	}
+	return out.String()
+}
+
+func copyCoda(out *strings.Builder, runes []rune, j int) int {
+	startsSyllable := func(k int) bool {
+		return k < len(runes) && (isPlainVowel(runes[k]) || isMarkedVowel(runes[k]))
+	}
+	if j < len(runes) && unicode.ToLower(runes[j]) == 'n' {
+		if j+1 < len(runes) && unicode.ToLower(runes[j+1]) == 'g' && !startsSyllable(j+2) {
+			out.WriteRune(runes[j])
+			out.WriteRune(runes[j+1])
+			return j + 2
+		}
+		if !startsSyllable(j + 1) {
+			out.WriteRune(runes[j])
+			return j + 1
+		}
+		return j
+	}
+	if j < len(runes) && unicode.ToLower(runes[j]) == 'r' && !startsSyllable(j+1) {
+		out.WriteRune(runes[j])
+		return j + 1
+	}
+	return j
+}
+
+func markSyllable(syllable []rune, tone int) string {
+	normalized := make([]rune, 0, len(syllable))
+	for i := 0; i < len(syllable); i++ {
+		r := syllable[i]
+		switch {
+		case r == 'v':
+			normalized = append(normalized, 'ü')
+		case r == 'V':
+			normalized = append(normalized, 'Ü')
+		case (r == 'u' || r == 'U') && i+1 < len(syllable) && syllable[i+1] == ':':
+			if r == 'u' {
+				normalized = append(normalized, 'ü')
+			} else {
+				normalized = append(normalized, 'Ü')
+			}
+			i++
+		default:
+			normalized = append(normalized, r)
+		}
+	}
+	if tone < 1 || tone > 4 {
+		return string(normalized)
+	}
+	idx := toneVowelIndex(normalized)
+	if idx < 0 {
+		return string(normalized)
+	}
+	normalized[idx] = toneMarks[normalized[idx]][tone-1]
+	return string(normalized)
+}
+
+// toneVowelIndex applies the standard placement rules: a or e takes the mark,
+// "ou" marks the o, otherwise the last vowel is marked.
+func toneVowelIndex(syllable []rune) int {
+	last := -1
+	for i, r := range syllable {
+		lower := unicode.ToLower(r)
+		if lower == 'a' || lower == 'e' {
+			return i
+		}
+		if lower == 'o' && i+1 < len(syllable) && unicode.ToLower(syllable[i+1]) == 'u' {
+			return i
+		}
+		if isPlainVowel(r) {
+			last = i
+		}
+	}
+	return last
+}
+
+func isSyllableRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == 'ü' || r == 'Ü' || r == ':'
+}
+
+func isPlainVowel(r rune) bool {
+	_, ok := toneMarks[r]
+	return ok
+}
+
+func isMarkedVowel(r rune) bool {
+	_, ok := markedVowels[r]
+	return ok
+}
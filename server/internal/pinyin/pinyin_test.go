@@ -0,0 +1,54 @@
+package pinyin
+
+import "testing"
+
+func TestNumberedToToneMarks(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "ni3 hao3", want: "nǐ hǎo"},
+		{in: "ni3hao3", want: "nǐhǎo"},
+		{in: "Zhong1 guo2", want: "Zhōng guó"},
+		{in: "lu:4 se4", want: "lǜ sè"},
+		{in: "nv3 ren2", want: "nǚ rén"},
+		{in: "dou1 gou3", want: "dōu gǒu"},
+		{in: "gui4", want: "guì"},
+		{in: "de5", want: "de"},
+		{in: "xue xi", want: "xue xi"},
+		{in: "nǐ hǎo", want: "nǐ hǎo"},
+	}
+	for _, tc := range tests {
+		if got := NumberedToToneMarks(tc.in); got != tc.want {
+			t.Fatalf("NumberedToToneMarks(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestToneMarksToNumbered(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "nǐ hǎo", want: "ni3 hao3"},
+		{in: "nǐhǎo", want: "ni3hao3"},
+		{in: "Zhōngguó", want: "Zhong1guo2"},
+		{in: "xiànzài", want: "xian4zai4"},
+		{in: "lǜ", want: "lü4"},
+		{in: "de", want: "de"},
+	}
+	for _, tc := range tests {
+		if got := ToneMarksToNumbered(tc.in); got != tc.want {
+			t.Fatalf("ToneMarksToNumbered(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeCollapsesWhitespace(t *testing.T) {
+	if got := Normalize("  ni3   hao3 ", StyleToneMarks); got != "nǐ hǎo" {
+		t.Fatalf("unexpected tone-mark normalization: %q", got)
+	}
+	if got := Normalize("nǐ  hǎo", StyleNumbered); got != "ni3 hao3" {
+		t.Fatalf("unexpected numbered normalization: %q", got)
+	}
+}
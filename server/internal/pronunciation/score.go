@@ -0,0 +1,164 @@
+// Package pronunciation scores a transcription of the learner's speech
+// against the text they meant to say.
+package pronunciation
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/anath2/language-app/internal/pinyin"
+)
+
+// Syllable statuses in a Result diff.
+const (
+	StatusMatch    = "match"    // heard as written, or as a homophone
+	StatusTone     = "tone"     // same syllable with a different tone
+	StatusMismatch = "mismatch" // a different syllable
+	StatusMissing  = "missing"  // in the target but not heard
+	StatusExtra    = "extra"    // heard but not in the target
+)
+
+// Syllable is one step of the alignment between target and transcript. Han
+// characters are one syllable each; other scripts are compared word by word.
+type Syllable struct {
+	Target       string `json:"target"`
+	Heard        string `json:"heard"`
+	TargetPinyin string `json:"target_pinyin,omitempty"`
+	HeardPinyin  string `json:"heard_pinyin,omitempty"`
+	Status       string `json:"status"`
+}
+
+// Result is the similarity of a transcript to its target, from 0 to 1.
+type Result struct {
+	Score     float64    `json:"score"`
+	Syllables []Syllable `json:"syllables"`
+}
+
+// PinyinFunc returns the pinyin of a Han character, tone-numbered ("xue2")
+// or tone-marked ("xué"), or "" when it is unknown.
+type PinyinFunc func(char string) string
+
+// Score aligns transcript against target with a weighted edit distance and
+// turns the cost into a score. Homophones cost nothing, since speech-to-text
+// cannot tell them apart; tone errors cost half a syllable. Punctuation and
+// spacing are ignored.
+func Score(target string, transcript string, pinyinOf PinyinFunc) Result {
+	want := tokens(target, pinyinOf)
+	heard := tokens(transcript, pinyinOf)
+
+	// cost[i][j] aligns want[i:] with heard[j:].
+	cost := make([][]float64, len(want)+1)
+	for i := range cost {
+		cost[i] = make([]float64, len(heard)+1)
+	}
+	for i := len(want); i >= 0; i-- {
+		for j := len(heard); j >= 0; j-- {
+			switch {
+			case i == len(want):
+				cost[i][j] = float64(len(heard) - j)
+			case j == len(heard):
+				cost[i][j] = float64(len(want) - i)
+			default:
+				_, sub := compare(want[i], heard[j])
+				cost[i][j] = min(sub+cost[i+1][j+1], 1+cost[i+1][j], 1+cost[i][j+1])
+			}
+		}
+	}
+
+	syllables := make([]Syllable, 0, max(len(want), len(heard)))
+	i, j := 0, 0
+	for i < len(want) || j < len(heard) {
+		if i < len(want) && j < len(heard) {
+			status, sub := compare(want[i], heard[j])
+			if cost[i][j] == sub+cost[i+1][j+1] {
+				syllables = append(syllables, Syllable{
+					Target: want[i].text, Heard: heard[j].text,
+					TargetPinyin: want[i].pinyin, HeardPinyin: heard[j].pinyin,
+					Status: status,
+				})
+				i, j = i+1, j+1
+				continue
+			}
+		}
+		if i < len(want) && (j == len(heard) || cost[i][j] == 1+cost[i+1][j]) {
+			syllables = append(syllables, Syllable{Target: want[i].text, TargetPinyin: want[i].pinyin, Status: StatusMissing})
+			i++
+			continue
+		}
+		syllables = append(syllables, Syllable{Heard: heard[j].text, HeardPinyin: heard[j].pinyin, Status: StatusExtra})
+		j++
+	}
+
+	score := 0.0
+	if len(want) > 0 {
+		score = math.Max(0, 1-cost[0][0]/float64(len(want)))
+	}
+	return Result{Score: math.Round(score*1000) / 1000, Syllables: syllables}
+}
+
+type token struct {
+	text   string
+	pinyin string
+}
+
+// tokens splits text into Han characters and lower-cased runs of other
+// letters and digits.
+func tokens(text string, pinyinOf PinyinFunc) []token {
+	out := make([]token, 0)
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			out = append(out, token{text: strings.ToLower(word.String())})
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			tok := token{text: string(r)}
+			if pinyinOf != nil {
+				tok.pinyin = numberedSyllable(pinyinOf(tok.text))
+			}
+			out = append(out, tok)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+// numberedSyllable normalizes pinyin to lower-case numbered form, writing
+// the neutral tone as 5 so "le" and "le5" compare equal.
+func numberedSyllable(raw string) string {
+	syllable := strings.ToLower(strings.TrimSpace(pinyin.ToneMarksToNumbered(raw)))
+	if syllable == "" {
+		return ""
+	}
+	if last := syllable[len(syllable)-1]; last < '0' || last > '9' {
+		syllable += "5"
+	}
+	return syllable
+}
+
+// compare returns the status and cost of aligning a target token with a
+// heard one.
+func compare(want token, heard token) (string, float64) {
+	if want.text == heard.text {
+		return StatusMatch, 0
+	}
+	if want.pinyin == "" || heard.pinyin == "" {
+		return StatusMismatch, 1
+	}
+	if want.pinyin == heard.pinyin {
+		return StatusMatch, 0
+	}
+	if strings.TrimRight(want.pinyin, "012345") == strings.TrimRight(heard.pinyin, "012345") {
+		return StatusTone, 0.5
+	}
+	return StatusMismatch, 1
+}
@@ -0,0 +1,56 @@
+package pronunciation
+
+import "testing"
+
+var testPinyin = map[string]string{
+	"我": "wo3", "喜": "xi3", "欢": "huan5", "学": "xue2", "习": "xi2",
+	"洗": "xi3", "雪": "xue3", "了": "le",
+}
+
+func pinyinOf(char string) string { return testPinyin[char] }
+
+func TestScoreExactMatch(t *testing.T) {
+	got := Score("我喜欢学习。", "我喜欢学习", pinyinOf)
+	if got.Score != 1 || len(got.Syllables) != 5 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	for _, syl := range got.Syllables {
+		if syl.Status != StatusMatch {
+			t.Fatalf("expected all matches, got %+v", got.Syllables)
+		}
+	}
+}
+
+func TestScoreDiffsSyllables(t *testing.T) {
+	// 喜 heard as the homophone 洗, 学 with the wrong tone and 习 as 了.
+	got := Score("我喜欢学习", "我洗欢雪了", pinyinOf)
+	want := []string{StatusMatch, StatusMatch, StatusMatch, StatusTone, StatusMismatch}
+	if len(got.Syllables) != len(want) {
+		t.Fatalf("unexpected syllables: %+v", got.Syllables)
+	}
+	for i, status := range want {
+		if got.Syllables[i].Status != status {
+			t.Fatalf("syllable %d: expected %s, got %+v", i, status, got.Syllables[i])
+		}
+	}
+	if got.Syllables[3].TargetPinyin != "xue2" || got.Syllables[3].HeardPinyin != "xue3" {
+		t.Fatalf("expected pinyin on the tone error, got %+v", got.Syllables[3])
+	}
+	if got.Score != 0.7 {
+		t.Fatalf("expected score 0.7, got %v", got.Score)
+	}
+}
+
+func TestScoreMissingAndExtra(t *testing.T) {
+	got := Score("我喜欢", "我", pinyinOf)
+	if got.Score != 0.333 || got.Syllables[1].Status != StatusMissing || got.Syllables[2].Status != StatusMissing {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	got = Score("我", "我我", pinyinOf)
+	if got.Score != 0 || got.Syllables[1].Status != StatusExtra {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if empty := Score("", "我", pinyinOf); empty.Score != 0 {
+		t.Fatalf("expected zero score for an empty target, got %v", empty.Score)
+	}
+}
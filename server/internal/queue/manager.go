@@ -2,6 +2,9 @@ package queue
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -9,13 +12,39 @@ import (
 	"unicode/utf8"
 
 	"github.com/anath2/language-app/internal/intelligence"
+	"github.com/anath2/language-app/internal/logging"
+	"github.com/anath2/language-app/internal/textsplit"
 	"github.com/anath2/language-app/internal/translation"
+	"github.com/anath2/language-app/internal/webhook"
 )
 
+// CallbackURLMetadataKey is the translation metadata key holding the URL
+// notified when the job completes or fails.
+const CallbackURLMetadataKey = "callback_url"
+
+// Callback delivery gives each attempt callbackTimeout and retries failures
+// after 2s, then 4s.
+const (
+	callbackTimeout  = 10 * time.Second
+	callbackAttempts = 3
+	callbackBackoff  = 2 * time.Second
+)
+
+// CallbackPayload is POSTed to a translation's callback URL when its job
+// finishes.
+type CallbackPayload struct {
+	TranslationID string `json:"translation_id"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
 type SegmentProgress struct {
 	Segment       string `json:"segment"`
 	Pinyin        string `json:"pinyin"`
 	English       string `json:"english"`
+	Source        string `json:"source,omitempty"`
+	POS           string `json:"pos,omitempty"`
+	Classifier    string `json:"classifier,omitempty"`
 	Index         int    `json:"index"`
 	SentenceIndex int    `json:"sentence_index"`
 }
@@ -26,6 +55,8 @@ type Progress struct {
 	Total   int               `json:"total"`
 	Results []SegmentProgress `json:"results"`
 	Error   string            `json:"error,omitempty"`
+	// FullTranslation is the full translation streamed so far by a running job.
+	FullTranslation string `json:"full_translation,omitempty"`
 }
 
 type Manager struct {
@@ -33,6 +64,29 @@ type Manager struct {
 	provider intelligence.TranslationProvider
 	mu       sync.RWMutex
 	running  map[string]struct{}
+	// streamedFull holds the in-flight full translation per running job.
+	streamedFull map[string]string
+	callbacks    *webhook.Client
+	// segmentCache, when set, answers repeated segments without the provider.
+	segmentCache segmentCache
+	// overrides, when set, supplies learner-corrected segmentations that
+	// take the place of the provider's.
+	overrides segmentationOverrides
+	// customDictionary, when set, replaces the glosses of segments the
+	// learner has given their own.
+	customDictionary customDictionary
+	// draining is set by Drain; no new jobs start once it is.
+	draining bool
+	// maxWorkers caps concurrent jobs; zero runs every job immediately.
+	// When capped, a finished job hands its slot to the highest priority
+	// pending job.
+	maxWorkers int
+	// segmentDelay spaces a job's segment translation calls to the provider.
+	segmentDelay time.Duration
+	// progress wakes Subscribe callers whenever a job records progress.
+	progress progressHub
+	// secrets are scrubbed from failure messages before they are stored.
+	secrets []string
 }
 
 type translationStore interface {
@@ -40,7 +94,8 @@ type translationStore interface {
 	Get(id string) (translation.Translation, bool)
 	ClaimTranslationJob(translationID string, leaseDuration time.Duration) (bool, error)
 	RenewLease(translationID string, d time.Duration) error
-	Fail(id string, message string) error
+	ReleaseTranslationJob(translationID string) error
+	Fail(id string, message string, detail string) error
 	SetFullTranslation(id string, fullTranslation string) error
 	SetProcessing(id string, total int, sentences []translation.SentenceInit) error
 	SetReprocessing(id string, total int) error
@@ -48,6 +103,27 @@ type translationStore interface {
 	GetProgressSnapshot(id string) (translation.ProgressSnapshot, bool)
 	AddProgressSegment(id string, result translation.SegmentResult, sentenceIndex int) (int, int, error)
 	AddReprocessedSegment(id string, result translation.SegmentResult, sentenceIdx int, segIdx int) error
+	ReleaseNextChunk(translationID string) (string, bool, error)
+}
+
+type segmentCache interface {
+	GetCachedSegment(segment string, lang translation.LanguagePair, contextKey string) (translation.SegmentResult, bool, error)
+	PutCachedSegment(result translation.SegmentResult, lang translation.LanguagePair, contextKey string) error
+}
+
+type segmentationOverrides interface {
+	GetSegmentationOverride(userID string, sentence string, lang translation.LanguagePair) ([]string, bool, error)
+}
+
+type customDictionary interface {
+	ApplyCustomDictionary(userID string, results []translation.SegmentResult, lang translation.LanguagePair) error
+}
+
+// contextSensitiveProvider is implemented by providers that can tell when a
+// segment's reading or gloss depends on its sentence. Such segments are
+// cached per sentence; all others are cached on their own.
+type contextSensitiveProvider interface {
+	SegmentNeedsContext(segment string, lang translation.LanguagePair) bool
 }
 
 type queuedSegment struct {
@@ -56,24 +132,80 @@ type queuedSegment struct {
 	Segment       string
 }
 
-type sentenceInfo struct {
-	Text      string
-	Indent    string
-	Separator string
-}
-
 const jobLeaseDuration = 5 * time.Minute
 const leaseRenewalInterval = 100 * time.Second    // renew at ~1/3 of jobLeaseDuration
-const expiredLeaseScanInterval = 30 * time.Second // how often the scanner polls for expired leases
+const expiredLeaseScanInterval = 30 * time.Second // default for how often the scanner polls for expired leases
+const drainPollInterval = 50 * time.Millisecond   // how often Drain checks for finished jobs
+
+// maxErrorMessageLen bounds the error shown with a failed translation and
+// maxErrorDetailLen the full error kept for debugging, both in bytes.
+const (
+	maxErrorMessageLen = 200
+	maxErrorDetailLen  = 16 << 10
+)
 
 func NewManager(store translationStore, provider intelligence.TranslationProvider) *Manager {
 	return &Manager{
-		store:    store,
-		provider: provider,
-		running:  make(map[string]struct{}),
+		store:        store,
+		provider:     provider,
+		running:      make(map[string]struct{}),
+		streamedFull: make(map[string]string),
+		callbacks:    webhook.NewClient(callbackTimeout, callbackAttempts, callbackBackoff, false),
 	}
 }
 
+// UseSegmentCache makes the manager reuse earlier segment translations.
+func (m *Manager) UseSegmentCache(cache segmentCache) {
+	m.segmentCache = cache
+}
+
+// UseSegmentationOverrides makes the manager segment sentences a learner
+// has corrected the way they corrected them.
+func (m *Manager) UseSegmentationOverrides(overrides segmentationOverrides) {
+	m.overrides = overrides
+}
+
+// UseCustomDictionary makes the manager gloss segments from the learner's
+// custom dictionary where it has them.
+func (m *Manager) UseCustomDictionary(dict customDictionary) {
+	m.customDictionary = dict
+}
+
+// AllowPrivateCallbacks lets callback URLs point at loopback, private and
+// link-local addresses, which are refused by default.
+func (m *Manager) AllowPrivateCallbacks() {
+	m.callbacks = webhook.NewClient(callbackTimeout, callbackAttempts, callbackBackoff, true)
+}
+
+// RedactSecrets makes the manager scrub secrets, such as provider API keys,
+// from the errors it records for failed jobs.
+func (m *Manager) RedactSecrets(secrets ...string) {
+	for _, secret := range secrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			m.secrets = append(m.secrets, secret)
+		}
+	}
+}
+
+// SetSegmentDelay makes each job wait d between its segment translation
+// calls to the provider, for providers with strict rate limits. Zero (the
+// default) sends them back to back.
+func (m *Manager) SetSegmentDelay(d time.Duration) {
+	m.segmentDelay = d
+}
+
+// LimitWorkers caps how many jobs run at once. Jobs started beyond the cap
+// stay pending until a slot frees up, then run in priority order.
+func (m *Manager) LimitWorkers(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxWorkers = n
+}
+
+// ResumeRestartableJobs starts every pending job and every leased job whose
+// lease expired, in priority order, skipping the ones this process is
+// already running so a scan tick does not log or claim them again. It stops
+// once the worker cap is reached.
 func (m *Manager) ResumeRestartableJobs() {
 	ids, err := m.store.ListRestartableTranslationIDs()
 	if err != nil {
@@ -81,19 +213,31 @@ func (m *Manager) ResumeRestartableJobs() {
 		return
 	}
 	for _, translationID := range ids {
-		m.StartProcessing(translationID)
+		if m.atCapacity() {
+			return
+		}
+		if m.isRunning(translationID) {
+			continue
+		}
+		ctx := logging.WithCorrelationID(context.Background(), logging.NewCorrelationID())
+		logging.Printf(ctx, "resuming translation job: id=%s", translationID)
+		m.StartProcessing(ctx, translationID)
 	}
 }
 
 // StartBackgroundScanner periodically scans for jobs with expired leases and
-// re-queues them via ResumeRestartableJobs. The in-memory running map prevents
-// double-processing jobs that are still active.
+// re-queues them via ResumeRestartableJobs, so a job whose worker died
+// mid-run is picked up without a restart. A non-positive interval uses
+// expiredLeaseScanInterval.
 //
 // Pass context.Background() for a process-lifetime scanner (killed when the
 // process exits). Use a cancellable context for graceful shutdown.
-func (m *Manager) StartBackgroundScanner(ctx context.Context) {
+func (m *Manager) StartBackgroundScanner(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = expiredLeaseScanInterval
+	}
 	go func() {
-		ticker := time.NewTicker(expiredLeaseScanInterval)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 		for {
 			select {
@@ -106,7 +250,10 @@ func (m *Manager) StartBackgroundScanner(ctx context.Context) {
 	}()
 }
 
-func (m *Manager) StartProcessing(translationID string) {
+// StartProcessing runs the translation's job in the background, or leaves
+// it pending when every worker is busy. ctx only carries values such as the
+// correlation id; the job outlives its cancellation.
+func (m *Manager) StartProcessing(ctx context.Context, translationID string) {
 	item, ok := m.store.Get(translationID)
 	if !ok {
 		return
@@ -116,7 +263,7 @@ func (m *Manager) StartProcessing(translationID string) {
 	}
 
 	m.mu.Lock()
-	if _, exists := m.running[translationID]; exists {
+	if _, exists := m.running[translationID]; exists || m.draining || m.atCapacityLocked() {
 		m.mu.Unlock()
 		return
 	}
@@ -130,19 +277,22 @@ func (m *Manager) StartProcessing(translationID string) {
 	}
 
 	go func(item translation.Translation) {
-		m.runJob(context.Background(), translationID, item)
+		m.runJob(context.WithoutCancel(ctx), translationID, item)
 	}(item)
 }
 
 // StartReprocessing processes only the sentences in sentencesToProcess (sentenceIdx → sentence text).
 // It regenerates the full translation and re-segments only the changed sentences.
-func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map[int]string) {
+// As with StartProcessing, ctx is used for its values only.
+func (m *Manager) StartReprocessing(ctx context.Context, translationID string, sentencesToProcess map[int]string) {
 	if len(sentencesToProcess) == 0 {
 		return
 	}
 
 	m.mu.Lock()
-	if _, exists := m.running[translationID]; exists {
+	// Edits bypass the worker cap: the changed sentences live only in this
+	// call, so the job cannot wait in the queue.
+	if _, exists := m.running[translationID]; exists || m.draining {
 		m.mu.Unlock()
 		return
 	}
@@ -155,9 +305,10 @@ func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map
 		return
 	}
 
+	ctx = context.WithoutCancel(ctx)
 	go func() {
-		ctx := context.Background()
-		defer m.removeRunning(translationID)
+		defer m.finishJob(translationID)
+		logging.Printf(ctx, "translation reprocessing started: id=%s sentences=%d", translationID, len(sentencesToProcess))
 
 		// Renewal goroutine: same pattern as runJob.
 		// defer cancelRenew() handles all exit paths — no per-return call needed.
@@ -173,7 +324,7 @@ func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map
 					if err := m.store.RenewLease(translationID, jobLeaseDuration); err != nil {
 						consecutiveFailures++
 						// TODO: fail job if consecutiveFailures exceeds threshold.
-						log.Printf("lease renewal failed for %s (consecutive failures: %d): %v",
+						logging.Printf(ctx, "lease renewal failed for %s (consecutive failures: %d): %v",
 							translationID, consecutiveFailures, err)
 					} else {
 						consecutiveFailures = 0
@@ -187,19 +338,19 @@ func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map
 		// Load the full input text for generating the full translation.
 		item, ok := m.store.Get(translationID)
 		if !ok {
-			_ = m.store.Fail(translationID, "Translation not found during reprocessing")
+			m.fail(ctx, translationID, "Translation not found during reprocessing")
 			return
 		}
 
 		// Reuse existing full translation if set; only generate if absent.
 		if item.FullTranslation == nil || *item.FullTranslation == "" {
-			fullTranslation, err := m.provider.TranslateFull(ctx, item.InputText)
+			fullTranslation, err := m.provider.TranslateFull(ctx, item.InputText, languagePairOf(item))
 			if err != nil {
-				_ = m.store.Fail(translationID, "Failed to generate full translation: "+err.Error())
+				m.fail(ctx, translationID, "Failed to generate full translation: "+err.Error())
 				return
 			}
 			if err := m.store.SetFullTranslation(translationID, fullTranslation); err != nil {
-				_ = m.store.Fail(translationID, "Failed to store full translation: "+err.Error())
+				m.fail(ctx, translationID, "Failed to store full translation: "+err.Error())
 				return
 			}
 		}
@@ -227,9 +378,9 @@ func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map
 
 		for _, sentenceIdx := range orderedIdxs {
 			sentence := sentencesToProcess[sentenceIdx]
-			segments, err := m.provider.Segment(ctx, sentence)
+			segments, err := m.segment(ctx, item.UserID, sentence, languagePairOf(item))
 			if err != nil {
-				_ = m.store.Fail(translationID, "Failed to segment during reprocessing: "+err.Error())
+				m.fail(ctx, translationID, "Failed to segment during reprocessing: "+err.Error())
 				return
 			}
 			for _, seg := range segments {
@@ -248,6 +399,7 @@ func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map
 		if err := m.store.SetReprocessing(translationID, len(allWork)); err != nil {
 			return
 		}
+		m.progress.publish(translationID)
 
 		// Group by sentence for batched translation.
 		type reprocessBatch struct {
@@ -265,47 +417,66 @@ func (m *Manager) StartReprocessing(translationID string, sentencesToProcess map
 			b.segments = append(b.segments, work.segment)
 		}
 
+		var stats cacheStats
 		for _, sentenceIdx := range orderedIdxs {
 			b, ok := batchMap[sentenceIdx]
 			if !ok {
 				continue
 			}
-			translated, err := m.provider.TranslateSentenceSegments(ctx, b.segments, b.sentenceText, item.InputText)
+			translated, err := m.translateSegments(ctx, &stats, item.UserID, b.segments, b.sentenceText, item.InputText, languagePairOf(item))
 			if err != nil || len(translated) == 0 {
-				_ = m.store.Fail(translationID, "Failed to translate segment during reprocessing")
+				m.fail(ctx, translationID, "Failed to translate segment during reprocessing")
 				return
 			}
 			for segIdx, result := range translated {
 				if err := m.store.AddReprocessedSegment(translationID, result, sentenceIdx, segIdx); err != nil {
-					_ = m.store.Fail(translationID, "Failed to store reprocessed segment")
+					m.fail(ctx, translationID, "Failed to store reprocessed segment")
 					return
 				}
+				m.progress.publish(translationID)
 			}
 		}
 
-		if err := m.store.Complete(translationID); err != nil {
-			_ = m.store.Fail(translationID, "Failed to complete reprocessed translation")
+		if err := m.complete(ctx, translationID); err != nil {
+			m.fail(ctx, translationID, "Failed to complete reprocessed translation")
+			return
 		}
+		m.logCacheStats(ctx, translationID, stats)
 	}()
 }
 
+// Subscribe returns a channel that receives a value after translationID's
+// job records progress, completes or fails. Notifications are coalesced, so
+// a receiver should reload progress with GetProgress rather than count
+// them. Only jobs run by this process publish; call unsubscribe when done.
+func (m *Manager) Subscribe(translationID string) (<-chan struct{}, func()) {
+	return m.progress.subscribe(translationID)
+}
+
 func (m *Manager) GetProgress(translationID string) (Progress, bool) {
 	snapshot, ok := m.store.GetProgressSnapshot(translationID)
 	if !ok {
 		return Progress{}, false
 	}
+	m.mu.RLock()
+	streamedFull := m.streamedFull[translationID]
+	m.mu.RUnlock()
 	progress := Progress{
-		Status:  snapshot.Status,
-		Current: snapshot.Current,
-		Total:   snapshot.Total,
-		Error:   snapshot.Error,
-		Results: make([]SegmentProgress, 0, len(snapshot.Results)),
+		Status:          snapshot.Status,
+		Current:         snapshot.Current,
+		Total:           snapshot.Total,
+		Error:           snapshot.Error,
+		Results:         make([]SegmentProgress, 0, len(snapshot.Results)),
+		FullTranslation: streamedFull,
 	}
 	for _, result := range snapshot.Results {
 		progress.Results = append(progress.Results, SegmentProgress{
 			Segment:       result.Segment,
 			Pinyin:        result.Pinyin,
 			English:       result.English,
+			Source:        result.Source,
+			POS:           result.POS,
+			Classifier:    result.Classifier,
 			Index:         result.Index,
 			SentenceIndex: result.SentenceIndex,
 		})
@@ -314,7 +485,8 @@ func (m *Manager) GetProgress(translationID string) (Progress, bool) {
 }
 
 func (m *Manager) runJob(ctx context.Context, translationID string, item translation.Translation) {
-	defer m.removeRunning(translationID)
+	defer m.finishJob(translationID)
+	logging.Printf(ctx, "translation job started: id=%s status=%s", translationID, item.Status)
 
 	// Renewal goroutine: extends the lease every leaseRenewalInterval.
 	// Cancelled automatically when runJob returns via defer cancelRenew() —
@@ -332,7 +504,7 @@ func (m *Manager) runJob(ctx context.Context, translationID string, item transla
 					consecutiveFailures++
 					// TODO: if consecutiveFailures exceeds a threshold (e.g. 3),
 					// fail the job to avoid a zombie worker holding a claim it can no longer renew.
-					log.Printf("lease renewal failed for %s (consecutive failures: %d): %v",
+					logging.Printf(ctx, "lease renewal failed for %s (consecutive failures: %d): %v",
 						translationID, consecutiveFailures, err)
 				} else {
 					consecutiveFailures = 0
@@ -343,34 +515,37 @@ func (m *Manager) runJob(ctx context.Context, translationID string, item transla
 		}
 	}()
 
-	sentences := splitInputSentences(item.InputText)
+	sentences := textsplit.Split(item.InputText, item.SourceLang)
 	if len(sentences) == 0 {
-		_ = m.store.Fail(translationID, "No sentences found for segmentation")
+		m.fail(ctx, translationID, "No sentences found for segmentation")
 		return
 	}
 
-	fullTranslation, err := m.provider.TranslateFull(ctx, item.InputText)
+	fullTranslation, err := m.provider.TranslateFullStream(ctx, item.InputText, languagePairOf(item), func(chunk string) error {
+		m.mu.Lock()
+		m.streamedFull[translationID] += chunk
+		m.mu.Unlock()
+		m.progress.publish(translationID)
+		return nil
+	})
 	if err != nil {
-		_ = m.store.Fail(translationID, "Failed to generate full translation: "+err.Error())
+		m.fail(ctx, translationID, "Failed to generate full translation: "+err.Error())
 		return
 	}
 	if err := m.store.SetFullTranslation(translationID, fullTranslation); err != nil {
-		_ = m.store.Fail(translationID, "Failed to store full translation: "+err.Error())
+		m.fail(ctx, translationID, "Failed to store full translation: "+err.Error())
 		return
 	}
+	m.progress.publish(translationID)
 
-	queued, err := m.segmentInputBySentence(ctx, sentences)
+	queued, err := m.segmentInputBySentence(ctx, item.UserID, sentences, languagePairOf(item))
 	if err != nil {
-		msg := err.Error()
-		if len(msg) > 200 {
-			msg = msg[:200] + "..."
-		}
-		_ = m.store.Fail(translationID, "Failed to segment: "+msg)
+		m.fail(ctx, translationID, "Failed to segment: "+err.Error())
 		return
 	}
 	total := len(queued)
 	if total == 0 {
-		_ = m.store.Fail(translationID, "No translatable segments found")
+		m.fail(ctx, translationID, "No translatable segments found")
 		return
 	}
 
@@ -379,17 +554,18 @@ func (m *Manager) runJob(ctx context.Context, translationID string, item transla
 		startIndex = 0
 		sentenceInits := make([]translation.SentenceInit, len(sentences))
 		for i, s := range sentences {
-			sentenceInits[i] = translation.SentenceInit{Indent: s.Indent, Separator: s.Separator}
+			sentenceInits[i] = translation.SentenceInit{Indent: s.Indent, Separator: s.Separator, Text: s.Text}
 		}
 		if err := m.store.SetProcessing(translationID, total, sentenceInits); err != nil {
-			_ = m.store.Fail(translationID, "Failed to initialise processing state: "+err.Error())
+			m.fail(ctx, translationID, "Failed to initialise processing state: "+err.Error())
 			return
 		}
 	}
+	m.progress.publish(translationID)
 
 	if startIndex >= len(queued) {
-		if err := m.store.Complete(translationID); err != nil {
-			_ = m.store.Fail(translationID, "Failed to complete translation")
+		if err := m.complete(ctx, translationID); err != nil {
+			m.fail(ctx, translationID, "Failed to complete translation")
 		}
 		return
 	}
@@ -420,29 +596,151 @@ func (m *Manager) runJob(ctx context.Context, translationID string, item transla
 		batches = append(batches, *currentBatch)
 	}
 
+	var stats cacheStats
 	for _, batch := range batches {
-		translated, err := m.provider.TranslateSentenceSegments(ctx, batch.segments, batch.sentenceText, item.InputText)
+		translated, err := m.translateSegments(ctx, &stats, item.UserID, batch.segments, batch.sentenceText, item.InputText, languagePairOf(item))
 		if err != nil || len(translated) == 0 {
-			_ = m.store.Fail(translationID, "Failed to translate sentence segments")
+			m.fail(ctx, translationID, "Failed to translate sentence segments")
 			return
 		}
 		for _, segmentResult := range translated {
 			if _, _, err := m.store.AddProgressSegment(translationID, segmentResult, batch.sentenceIdx); err != nil {
-				_ = m.store.Fail(translationID, "Failed to update translation progress")
+				m.fail(ctx, translationID, "Failed to update translation progress")
 				return
 			}
+			m.progress.publish(translationID)
 		}
 	}
 
-	if err := m.store.Complete(translationID); err != nil {
-		_ = m.store.Fail(translationID, "Failed to complete translation")
+	if err := m.complete(ctx, translationID); err != nil {
+		m.fail(ctx, translationID, "Failed to complete translation")
+		return
+	}
+	m.logCacheStats(ctx, translationID, stats)
+	logging.Printf(ctx, "translation job completed: id=%s segments=%d", translationID, total)
+}
+
+type cacheStats struct {
+	hits   int
+	misses int
+	// providerCalls counts the job's segment translation calls so far.
+	providerCalls int
+}
+
+// spaceProviderCall waits segmentDelay before every provider call of a job
+// but the first.
+func (m *Manager) spaceProviderCall(stats *cacheStats) {
+	if m.segmentDelay > 0 && stats.providerCalls > 0 {
+		time.Sleep(m.segmentDelay)
+	}
+	stats.providerCalls++
+}
+
+// translateSegments translates one sentence's segments for userID, then
+// applies their custom dictionary. The custom glosses are applied after the
+// cache is consulted and written, so the shared cache never holds them.
+func (m *Manager) translateSegments(ctx context.Context, stats *cacheStats, userID string, segments []string, sentence string, fullText string, lang translation.LanguagePair) ([]translation.SegmentResult, error) {
+	out, err := m.translateSegmentsCached(ctx, stats, segments, sentence, fullText, lang)
+	if err != nil || m.customDictionary == nil {
+		return out, err
+	}
+	if err := m.customDictionary.ApplyCustomDictionary(userID, out, lang); err != nil {
+		logging.Printf(ctx, "custom dictionary lookup failed: err=%v", err)
+	}
+	return out, nil
+}
+
+// translateSegmentsCached translates one sentence's segments, answering from
+// the segment cache where it can and sending only the misses to the
+// provider. New results are written back to the cache.
+func (m *Manager) translateSegmentsCached(ctx context.Context, stats *cacheStats, segments []string, sentence string, fullText string, lang translation.LanguagePair) ([]translation.SegmentResult, error) {
+	if m.segmentCache == nil {
+		m.spaceProviderCall(stats)
+		return m.provider.TranslateSentenceSegments(ctx, segments, sentence, fullText, lang)
+	}
+
+	out := make([]translation.SegmentResult, len(segments))
+	contextKeys := make([]string, len(segments))
+	var missIdxs []int
+	var missSegments []string
+	for i, seg := range segments {
+		contextKeys[i] = m.segmentContextKey(seg, sentence, lang)
+		cached, ok, err := m.segmentCache.GetCachedSegment(seg, lang, contextKeys[i])
+		if err != nil {
+			logging.Printf(ctx, "segment cache lookup failed: segment=%q err=%v", seg, err)
+		}
+		if ok {
+			out[i] = cached
+			stats.hits++
+			continue
+		}
+		missIdxs = append(missIdxs, i)
+		missSegments = append(missSegments, seg)
+	}
+	stats.misses += len(missSegments)
+	if len(missSegments) == 0 {
+		return out, nil
 	}
+
+	m.spaceProviderCall(stats)
+	translated, err := m.provider.TranslateSentenceSegments(ctx, missSegments, sentence, fullText, lang)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(missSegments) {
+		return nil, fmt.Errorf("provider returned %d translations for %d segments", len(translated), len(missSegments))
+	}
+	for j, idx := range missIdxs {
+		result := translated[j]
+		out[idx] = result
+		// Untranslated and fallback segments are retried next time.
+		if result.English == "" || result.Source == translation.SegmentSourceFallback {
+			continue
+		}
+		if err := m.segmentCache.PutCachedSegment(result, lang, contextKeys[idx]); err != nil {
+			logging.Printf(ctx, "segment cache write failed: segment=%q err=%v", result.Segment, err)
+		}
+	}
+	return out, nil
 }
 
-func (m *Manager) segmentInputBySentence(ctx context.Context, sentences []sentenceInfo) ([]queuedSegment, error) {
+// segmentContextKey returns "" for segments cached on their own and a hash of
+// the sentence for segments the provider says depend on it.
+func (m *Manager) segmentContextKey(segment string, sentence string, lang translation.LanguagePair) string {
+	csp, ok := m.provider.(contextSensitiveProvider)
+	if !ok || !csp.SegmentNeedsContext(segment, lang) {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(sentence)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *Manager) logCacheStats(ctx context.Context, translationID string, stats cacheStats) {
+	if m.segmentCache == nil {
+		return
+	}
+	logging.Printf(ctx, "segment cache: id=%s hits=%d misses=%d", translationID, stats.hits, stats.misses)
+}
+
+// segment splits sentence into segments, preferring the segmentation userID
+// corrected it to over asking the provider. A failed override lookup falls
+// back to the provider.
+func (m *Manager) segment(ctx context.Context, userID string, sentence string, lang translation.LanguagePair) ([]string, error) {
+	if m.overrides != nil {
+		segments, ok, err := m.overrides.GetSegmentationOverride(userID, sentence, lang)
+		if err != nil {
+			logging.Printf(ctx, "segmentation override lookup failed: err=%v", err)
+		} else if ok {
+			return segments, nil
+		}
+	}
+	return m.provider.Segment(ctx, sentence, lang)
+}
+
+func (m *Manager) segmentInputBySentence(ctx context.Context, userID string, sentences []textsplit.Sentence, lang translation.LanguagePair) ([]queuedSegment, error) {
 	queued := make([]queuedSegment, 0, len(sentences)*4)
 	for sentenceIdx, sent := range sentences {
-		segments, err := m.provider.Segment(ctx, sent.Text)
+		segments, err := m.segment(ctx, userID, sent.Text, lang)
 		if err != nil {
 			return nil, err
 		}
@@ -461,86 +759,156 @@ func (m *Manager) segmentInputBySentence(ctx context.Context, sentences []senten
 	return queued, nil
 }
 
-func splitInputSentences(text string) []sentenceInfo {
-	var out []sentenceInfo
-	var sentence strings.Builder
-	var lineIndent strings.Builder
-	atLineStart := true
+// complete marks the translation completed, notifies its callback URL and,
+// for a part of a chunked input, starts the next part.
+func (m *Manager) complete(ctx context.Context, translationID string) error {
+	if err := m.store.Complete(translationID); err != nil {
+		return err
+	}
+	m.progress.publish(translationID)
+	m.notify(ctx, CallbackPayload{TranslationID: translationID, Status: "completed"})
+	nextID, released, err := m.store.ReleaseNextChunk(translationID)
+	if err != nil {
+		logging.Printf(ctx, "release next chunk failed: id=%s err=%v", translationID, err)
+	} else if released {
+		// At capacity this is a no-op; finishJob picks the pending part up
+		// once this job gives back its slot.
+		m.StartProcessing(ctx, nextID)
+	}
+	return nil
+}
+
+// fail marks the translation failed and logs why under ctx's correlation
+// id. The translation shows message cut to maxErrorMessageLen; the job keeps
+// up to maxErrorDetailLen of it for the admin error endpoint. Both have
+// secrets redacted.
+func (m *Manager) fail(ctx context.Context, translationID string, message string) {
+	message = m.redact(message)
+	detail := truncateRunes(message, maxErrorDetailLen)
+	message = truncateRunes(message, maxErrorMessageLen)
+	logging.Printf(ctx, "translation job failed: id=%s err=%s", translationID, message)
+	_ = m.store.Fail(translationID, message, detail)
+	m.progress.publish(translationID)
+	m.notify(ctx, CallbackPayload{TranslationID: translationID, Status: "failed", Error: message})
+}
 
-	addSeparatorChar := func(r rune) {
-		if len(out) > 0 {
-			out[len(out)-1].Separator += string(r)
-		}
+func (m *Manager) redact(message string) string {
+	for _, secret := range m.secrets {
+		message = strings.ReplaceAll(message, secret, "[redacted]")
 	}
+	return message
+}
 
-	for len(text) > 0 {
-		r, size := utf8.DecodeRuneInString(text)
-		text = text[size:]
+// truncateRunes cuts s to at most limit bytes on a character boundary,
+// marking the cut with "...".
+func truncateRunes(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
 
-		if atLineStart {
-			if r == ' ' || r == '\t' {
-				lineIndent.WriteRune(r)
-				continue
-			}
-			if r == '\n' || r == '\r' {
-				addSeparatorChar(r)
-				lineIndent.Reset()
-				// atLineStart stays true
-				continue
-			}
-			atLineStart = false
+// notify delivers payload to the translation's callback URL, if it has one,
+// in the background so retries do not hold up the job.
+func (m *Manager) notify(ctx context.Context, payload CallbackPayload) {
+	item, ok := m.store.Get(payload.TranslationID)
+	if !ok {
+		return
+	}
+	callbackURL := item.Metadata[CallbackURLMetadataKey]
+	if callbackURL == "" {
+		return
+	}
+	go func() {
+		if err := m.callbacks.Post(ctx, callbackURL, payload); err != nil {
+			logging.Printf(ctx, "translation callback failed: id=%s url=%s err=%v", payload.TranslationID, callbackURL, err)
+			return
 		}
+		logging.Printf(ctx, "translation callback delivered: id=%s status=%s", payload.TranslationID, payload.Status)
+	}()
+}
 
-		if r == '\n' || r == '\r' {
-			s := strings.TrimSpace(sentence.String())
-			if s != "" {
-				out = append(out, sentenceInfo{
-					Text:   s,
-					Indent: lineIndent.String(),
-				})
-			}
-			addSeparatorChar(r)
-			sentence.Reset()
-			lineIndent.Reset()
-			atLineStart = true
-			continue
-		}
+func languagePairOf(item translation.Translation) translation.LanguagePair {
+	return translation.LanguagePair{Source: item.SourceLang, Target: item.TargetLang}.WithDefaults()
+}
 
-		sentence.WriteRune(r)
-		if isSentenceDelimiter(r) {
-			s := strings.TrimSpace(sentence.String())
-			if s != "" {
-				out = append(out, sentenceInfo{
-					Text:   s,
-					Indent: lineIndent.String(),
-				})
-				sentence.Reset()
-				lineIndent.Reset()
+// Drain stops the manager from starting new jobs and waits for the running
+// ones to finish. Progress is persisted segment by segment, so when ctx ends
+// first the remaining jobs have their leases released; the next start
+// resumes them from where they stopped instead of waiting out the lease.
+func (m *Manager) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	m.draining = true
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		if m.RunningCount() == 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			m.mu.RLock()
+			ids := make([]string, 0, len(m.running))
+			for id := range m.running {
+				ids = append(ids, id)
+			}
+			m.mu.RUnlock()
+			for _, id := range ids {
+				if err := m.store.ReleaseTranslationJob(id); err != nil {
+					log.Printf("release translation job %s: %v", id, err)
+				}
 			}
+			return fmt.Errorf("drain translation jobs: %d still running: %w", len(ids), ctx.Err())
 		}
 	}
+}
 
-	if s := strings.TrimSpace(sentence.String()); s != "" {
-		out = append(out, sentenceInfo{
-			Text:   s,
-			Indent: lineIndent.String(),
-		})
-	}
+func (m *Manager) atCapacity() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.atCapacityLocked()
+}
 
-	return out
+func (m *Manager) atCapacityLocked() bool {
+	return m.maxWorkers > 0 && len(m.running) >= m.maxWorkers
 }
 
-func isSentenceDelimiter(r rune) bool {
-	switch r {
-	case '。', '！', '？', '!', '?', ';', '；':
-		return true
-	default:
-		return false
+// finishJob frees a finished job's slot and, when workers are capped, hands
+// it to the next pending job.
+func (m *Manager) finishJob(translationID string) {
+	m.removeRunning(translationID)
+	m.mu.RLock()
+	startNext := m.maxWorkers > 0 && !m.draining
+	m.mu.RUnlock()
+	if startNext {
+		m.ResumeRestartableJobs()
 	}
 }
 
+func (m *Manager) isRunning(translationID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.running[translationID]
+	return ok
+}
+
 func (m *Manager) removeRunning(translationID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.running, translationID)
+	delete(m.streamedFull, translationID)
+}
+
+// RunningCount reports how many jobs this process is currently working on.
+func (m *Manager) RunningCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.running)
 }
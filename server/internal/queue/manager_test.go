@@ -2,15 +2,21 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/anath2/language-app/internal/intelligence"
 	"github.com/anath2/language-app/internal/migrations"
 	"github.com/anath2/language-app/internal/translation"
+	"github.com/anath2/language-app/internal/webhook"
 )
 
 type mockProvider struct {
@@ -28,7 +34,7 @@ func newTranslationStoreForTest(t *testing.T, dbPath string) *translation.Transl
 	return translation.NewTranslationStore(db)
 }
 
-func (m *mockProvider) TranslateFull(_ context.Context, text string) (string, error) {
+func (m *mockProvider) TranslateFull(_ context.Context, text string, _ translation.LanguagePair) (string, error) {
 	m.translateFullCalls++
 	if m.translateFullErr != nil {
 		return "", m.translateFullErr
@@ -36,7 +42,20 @@ func (m *mockProvider) TranslateFull(_ context.Context, text string) (string, er
 	return "mock translation of: " + text, nil
 }
 
-func (m *mockProvider) Segment(_ context.Context, text string) ([]string, error) {
+func (m *mockProvider) TranslateFullStream(ctx context.Context, text string, lang translation.LanguagePair, onChunk func(string) error) (string, error) {
+	full, err := m.TranslateFull(ctx, text, lang)
+	if err != nil {
+		return "", err
+	}
+	for _, word := range strings.SplitAfter(full, " ") {
+		if err := onChunk(word); err != nil {
+			return "", err
+		}
+	}
+	return full, nil
+}
+
+func (m *mockProvider) Segment(_ context.Context, text string, _ translation.LanguagePair) ([]string, error) {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return []string{}, nil
@@ -51,7 +70,7 @@ func (m *mockProvider) Segment(_ context.Context, text string) ([]string, error)
 	return out, nil
 }
 
-func (m *mockProvider) TranslateSentenceSegments(_ context.Context, segments []string, _ string, _ string) ([]translation.SegmentResult, error) {
+func (m *mockProvider) TranslateSentenceSegments(_ context.Context, segments []string, _ string, _ string, _ translation.LanguagePair) ([]translation.SegmentResult, error) {
 	out := make([]translation.SegmentResult, 0, len(segments))
 	for _, seg := range segments {
 		out = append(out, translation.SegmentResult{
@@ -85,7 +104,7 @@ func TestQueueProgressLifecycle(t *testing.T) {
 		t.Fatalf("create translation: %v", err)
 	}
 
-	manager.StartProcessing(item.ID)
+	manager.StartProcessing(context.Background(), item.ID)
 
 	deadline := time.Now().Add(2 * time.Second)
 	for {
@@ -136,7 +155,7 @@ func TestQueueProgressSurvivesManagerRestart(t *testing.T) {
 		t.Fatalf("create translation: %v", err)
 	}
 
-	manager.StartProcessing(item.ID)
+	manager.StartProcessing(context.Background(), item.ID)
 
 	deadline := time.Now().Add(2 * time.Second)
 	for {
@@ -220,7 +239,7 @@ func TestTranslateFullFailureFails(t *testing.T) {
 		t.Fatalf("create translation: %v", err)
 	}
 
-	manager.StartProcessing(item.ID)
+	manager.StartProcessing(context.Background(), item.ID)
 
 	deadline := time.Now().Add(2 * time.Second)
 	for {
@@ -244,6 +263,118 @@ func TestTranslateFullFailureFails(t *testing.T) {
 	}
 }
 
+func TestFailureKeepsFullRedactedDetail(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	body := `{"error":"invalid key sk-secret"} ` + strings.Repeat("x", 1000)
+	provider := &mockProvider{translateFullErr: fmt.Errorf("upstream returned status 401: %s", body)}
+	manager := NewManager(store, provider)
+	manager.RedactSecrets("sk-secret", "")
+
+	item, err := store.Create("你好世界", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	manager.StartProcessing(context.Background(), item.ID)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if tr, ok := store.Get(item.ID); ok && tr.Status == "failed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for translation to fail")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	failure, err := store.GetJobFailure(item.ID)
+	if err != nil {
+		t.Fatalf("get job failure: %v", err)
+	}
+	if len(failure.ErrorMessage) > maxErrorMessageLen+len("...") {
+		t.Fatalf("expected a short error message, got %d bytes", len(failure.ErrorMessage))
+	}
+	if !strings.HasSuffix(failure.Detail, strings.Repeat("x", 1000)) {
+		t.Fatalf("expected the full upstream body in the detail, got %q", failure.Detail)
+	}
+	if strings.Contains(failure.Detail, "sk-secret") || strings.Contains(failure.ErrorMessage, "sk-secret") {
+		t.Fatal("expected the API key to be redacted")
+	}
+	if !strings.Contains(failure.Detail, "invalid key [redacted]") {
+		t.Fatalf("expected a redaction marker, got %q", failure.Detail)
+	}
+}
+
+func TestTruncateRunesCutsOnCharacterBoundary(t *testing.T) {
+	if got := truncateRunes("你好世界", 7); got != "你好..." {
+		t.Fatalf("unexpected truncation: %q", got)
+	}
+	if got := truncateRunes("你好", 6); got != "你好" {
+		t.Fatalf("expected short text unchanged, got %q", got)
+	}
+}
+
+func TestCallbackPostedOnCompletionAndFailure(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+
+	received := make(chan CallbackPayload, 4)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails so the retry path is exercised.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload CallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	run := func(provider *mockProvider) CallbackPayload {
+		t.Helper()
+		manager := NewManager(store, provider)
+		manager.callbacks = webhook.NewClient(time.Second, 3, time.Millisecond, true)
+		item, err := store.CreateWithMetadata("你好世界", "text", translation.DefaultLanguagePair(), map[string]string{
+			CallbackURLMetadataKey: server.URL,
+		})
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		manager.StartProcessing(context.Background(), item.ID)
+		select {
+		case payload := <-received:
+			if payload.TranslationID != item.ID {
+				t.Fatalf("expected callback for %s, got %+v", item.ID, payload)
+			}
+			return payload
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for callback")
+		}
+		return CallbackPayload{}
+	}
+
+	if payload := run(&mockProvider{}); payload.Status != "completed" || payload.Error != "" {
+		t.Fatalf("expected completed callback, got %+v", payload)
+	}
+	if attempts.Load() != 2 {
+		t.Fatalf("expected one retry, got %d attempts", attempts.Load())
+	}
+	if payload := run(&mockProvider{translateFullErr: fmt.Errorf("upstream unavailable")}); payload.Status != "failed" || payload.Error == "" {
+		t.Fatalf("expected failed callback with error, got %+v", payload)
+	}
+}
+
 func TestReprocessingPreservesFullTranslation(t *testing.T) {
 	tmp := t.TempDir()
 	dbPath := filepath.Join(tmp, "translations.db")
@@ -258,7 +389,7 @@ func TestReprocessingPreservesFullTranslation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create translation: %v", err)
 	}
-	manager.StartProcessing(item.ID)
+	manager.StartProcessing(context.Background(), item.ID)
 
 	deadline := time.Now().Add(2 * time.Second)
 	for {
@@ -280,7 +411,7 @@ func TestReprocessingPreservesFullTranslation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("update input text: %v", err)
 	}
-	manager.StartReprocessing(item.ID, sentencesToProcess)
+	manager.StartReprocessing(context.Background(), item.ID, sentencesToProcess)
 
 	deadline = time.Now().Add(2 * time.Second)
 	for {
@@ -324,7 +455,7 @@ func TestReprocessingGeneratesFullTranslationWhenAbsent(t *testing.T) {
 	if err != nil {
 		t.Fatalf("update input text: %v", err)
 	}
-	manager.StartReprocessing(item.ID, sentencesToProcess)
+	manager.StartReprocessing(context.Background(), item.ID, sentencesToProcess)
 
 	deadline := time.Now().Add(2 * time.Second)
 	for {
@@ -382,6 +513,42 @@ func TestScannerRecoversStaleLeasedJob(t *testing.T) {
 	}
 }
 
+func TestBackgroundScannerRecoversAbandonedJob(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	manager := NewManager(store, &mockProvider{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.StartBackgroundScanner(ctx, 10*time.Millisecond)
+
+	// The worker "dies" after claiming: nothing in this process is running it.
+	item, err := store.Create("你好世界", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	claimed, err := store.ClaimTranslationJob(item.ID, 50*time.Millisecond)
+	if err != nil || !claimed {
+		t.Fatalf("claim job: err=%v claimed=%v", err, claimed)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		tr, ok := store.Get(item.ID)
+		if ok && tr.Status == "completed" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the scanner to recover the job; status=%q", tr.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
 func TestScannerDoesNotDoubleProcessActiveJob(t *testing.T) {
 	tmp := t.TempDir()
 	dbPath := filepath.Join(tmp, "translations.db")
@@ -396,7 +563,7 @@ func TestScannerDoesNotDoubleProcessActiveJob(t *testing.T) {
 		t.Fatalf("create translation: %v", err)
 	}
 
-	manager.StartProcessing(item.ID)
+	manager.StartProcessing(context.Background(), item.ID)
 
 	// While the job is in-flight, fire a scanner tick.
 	// StartProcessing checks m.running and returns early — no second claim.
@@ -424,3 +591,480 @@ func TestScannerDoesNotDoubleProcessActiveJob(t *testing.T) {
 		time.Sleep(20 * time.Millisecond)
 	}
 }
+
+// contextProvider counts the segments sent for translation and treats the
+// segments in needsContext as depending on their sentence.
+type contextProvider struct {
+	mockProvider
+	needsContext map[string]bool
+	translated   atomic.Int32
+}
+
+func (p *contextProvider) TranslateSentenceSegments(ctx context.Context, segments []string, sentence string, fullText string, lang translation.LanguagePair) ([]translation.SegmentResult, error) {
+	p.translated.Add(int32(len(segments)))
+	return p.mockProvider.TranslateSentenceSegments(ctx, segments, sentence, fullText, lang)
+}
+
+func (p *contextProvider) SegmentNeedsContext(segment string, _ translation.LanguagePair) bool {
+	return p.needsContext[segment]
+}
+
+func TestSegmentCacheSkipsRepeatedSegments(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	cacheDB, err := translation.NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("open cache db: %v", err)
+	}
+	provider := &contextProvider{needsContext: map[string]bool{"了": true}}
+	manager := NewManager(store, provider)
+	manager.UseSegmentCache(translation.NewSegmentCacheStore(cacheDB))
+
+	translate := func(text string) []SegmentProgress {
+		t.Helper()
+		item, err := store.Create(text, "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		manager.StartProcessing(context.Background(), item.ID)
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			progress, ok := manager.GetProgress(item.ID)
+			if ok && progress.Status == "completed" {
+				return progress.Results
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q to complete", text)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	translate("我来了")
+	if got := provider.translated.Load(); got != 3 {
+		t.Fatalf("expected 3 segments translated on a cold cache, got %d", got)
+	}
+
+	// 我 and 来 are reused; 了 depends on its sentence, which has changed.
+	results := translate("我来了吗")
+	if got := provider.translated.Load(); got != 5 {
+		t.Fatalf("expected only 了 and 吗 to reach the provider, got %d segments in total", got)
+	}
+	if len(results) != 4 || results[0].English != "translation_of_我" {
+		t.Fatalf("unexpected results from cache: %+v", results)
+	}
+
+	// The same sentence again is answered entirely from the cache.
+	translate("我来了吗")
+	if got := provider.translated.Load(); got != 5 {
+		t.Fatalf("expected no provider calls for a repeated sentence, got %d segments in total", got)
+	}
+}
+
+func TestSegmentationOverrideReplacesProviderSegments(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	manager := NewManager(store, &mockProvider{})
+	manager.UseSegmentationOverrides(store)
+
+	translate := func(text string) (string, []SegmentProgress) {
+		t.Helper()
+		item, err := store.Create(text, "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		manager.StartProcessing(context.Background(), item.ID)
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			progress, ok := manager.GetProgress(item.ID)
+			if ok && progress.Status == "completed" {
+				return item.ID, progress.Results
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q to complete", text)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	id, results := translate("我来了")
+	if len(results) != 3 {
+		t.Fatalf("expected one segment per character, got %+v", results)
+	}
+	if _, err := store.MergeSegments(id, 0, 1, 2); err != nil {
+		t.Fatalf("merge segments: %v", err)
+	}
+
+	_, results = translate("我来了")
+	if len(results) != 2 || results[0].Segment != "我" || results[1].Segment != "来了" {
+		t.Fatalf("expected the corrected segmentation to be reused, got %+v", results)
+	}
+}
+
+func TestCustomDictionaryGlossesSegmentsWithoutCachingThem(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	cacheDB, err := translation.NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("open cache db: %v", err)
+	}
+	manager := NewManager(store, &mockProvider{})
+	manager.UseSegmentCache(translation.NewSegmentCacheStore(cacheDB))
+	manager.UseCustomDictionary(store)
+
+	translate := func(text string) []SegmentProgress {
+		t.Helper()
+		item, err := store.Create(text, "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		manager.StartProcessing(context.Background(), item.ID)
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			progress, ok := manager.GetProgress(item.ID)
+			if ok && progress.Status == "completed" {
+				return progress.Results
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q to complete", text)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	if _, err := store.SaveCustomDictionaryEntry(translation.DefaultUserID, translation.CustomDictionaryEntry{Headword: "来", English: "to come (my gloss)"}); err != nil {
+		t.Fatalf("save custom entry: %v", err)
+	}
+	results := translate("我来了")
+	if len(results) != 3 || results[1].English != "to come (my gloss)" || results[1].Source != translation.SegmentSourceUser {
+		t.Fatalf("expected the custom gloss for 来, got %+v", results)
+	}
+	if results[0].Source == translation.SegmentSourceUser {
+		t.Fatalf("expected 我 to keep the provider's gloss, got %+v", results[0])
+	}
+
+	// The cache holds the provider's gloss, so removing the entry restores it.
+	if err := store.DeleteCustomDictionaryEntry(translation.DefaultUserID, "来"); err != nil {
+		t.Fatalf("delete custom entry: %v", err)
+	}
+	results = translate("我来了")
+	if results[1].English != "translation_of_来" {
+		t.Fatalf("expected the provider's gloss after deleting the entry, got %+v", results[1])
+	}
+}
+
+// blockingProvider holds every full translation until release is closed.
+type blockingProvider struct {
+	mockProvider
+	release chan struct{}
+}
+
+func (p *blockingProvider) TranslateFullStream(ctx context.Context, text string, lang translation.LanguagePair, onChunk func(string) error) (string, error) {
+	<-p.release
+	return p.mockProvider.TranslateFullStream(ctx, text, lang, onChunk)
+}
+
+func TestDrainReleasesLeasesOfUnfinishedJobs(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	provider := &blockingProvider{release: make(chan struct{})}
+	defer close(provider.release)
+	manager := NewManager(store, provider)
+
+	item, err := store.Create("你好世界", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	manager.StartProcessing(context.Background(), item.ID)
+	if manager.RunningCount() != 1 {
+		t.Fatalf("expected the job to be running, got %d", manager.RunningCount())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := manager.Drain(ctx); err == nil {
+		t.Fatal("expected drain to time out while the job is blocked")
+	}
+
+	// The lease is released, so the job is restartable straight away.
+	ids, err := store.ListRestartableTranslationIDs()
+	if err != nil {
+		t.Fatalf("list restartable: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != item.ID {
+		t.Fatalf("expected the drained job to be restartable, got %v", ids)
+	}
+
+	// A draining manager starts nothing new.
+	other, err := store.Create("谢谢", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	manager.StartProcessing(context.Background(), other.ID)
+	if manager.RunningCount() != 1 {
+		t.Fatalf("expected no new job while draining, got %d running", manager.RunningCount())
+	}
+}
+
+func TestDrainWaitsForRunningJobs(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	manager := NewManager(store, &mockProvider{})
+
+	item, err := store.Create("你好世界", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	manager.StartProcessing(context.Background(), item.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := manager.Drain(ctx); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	tr, _ := store.Get(item.ID)
+	if tr.Status != "completed" {
+		t.Fatalf("expected drain to wait for completion, got status %q", tr.Status)
+	}
+}
+
+// orderProvider records the order full translations start in and holds
+// the first one until release is closed.
+type orderProvider struct {
+	mockProvider
+	release chan struct{}
+	mu      sync.Mutex
+	started []string
+}
+
+func (p *orderProvider) TranslateFullStream(ctx context.Context, text string, lang translation.LanguagePair, onChunk func(string) error) (string, error) {
+	p.mu.Lock()
+	p.started = append(p.started, text)
+	first := len(p.started) == 1
+	p.mu.Unlock()
+	if first {
+		<-p.release
+	}
+	return p.mockProvider.TranslateFullStream(ctx, text, lang, onChunk)
+}
+
+func TestCappedWorkersRunHighPriorityJobsFirst(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	provider := &orderProvider{release: make(chan struct{})}
+	manager := NewManager(store, provider)
+	manager.LimitWorkers(1)
+
+	create := func(text string, priority int) translation.Translation {
+		t.Helper()
+		item, err := store.Create(text, "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		if err := store.SetJobPriority(item.ID, priority); err != nil {
+			t.Fatalf("set job priority: %v", err)
+		}
+		manager.StartProcessing(context.Background(), item.ID)
+		return item
+	}
+
+	// The first job takes the only worker; the rest wait as pending.
+	create("第一", translation.JobPriorityNormal)
+	bulk := create("批量", translation.JobPriorityLow)
+	interactive := create("用户", translation.JobPriorityHigh)
+	if got := manager.RunningCount(); got != 1 {
+		t.Fatalf("expected one running job under the cap, got %d", got)
+	}
+	close(provider.release)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		a, _ := store.Get(bulk.ID)
+		b, _ := store.Get(interactive.ID)
+		if a.Status == "completed" && b.Status == "completed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for queued jobs; bulk=%q interactive=%q", a.Status, b.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	want := []string{"第一", "用户", "批量"}
+	if strings.Join(provider.started, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected jobs to start in priority order %v, got %v", want, provider.started)
+	}
+}
+
+// timingProvider records when each segment translation call arrives.
+type timingProvider struct {
+	mockProvider
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func (p *timingProvider) TranslateSentenceSegments(ctx context.Context, segments []string, sentence string, fullText string, lang translation.LanguagePair) ([]translation.SegmentResult, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, time.Now())
+	p.mu.Unlock()
+	return p.mockProvider.TranslateSentenceSegments(ctx, segments, sentence, fullText, lang)
+}
+
+func TestSegmentDelaySpacesProviderCalls(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	provider := &timingProvider{}
+	manager := NewManager(store, provider)
+	manager.SetSegmentDelay(40 * time.Millisecond)
+
+	item, err := store.Create("你好。谢谢。再见。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	manager.StartProcessing(context.Background(), item.ID)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		tr, ok := store.Get(item.ID)
+		if ok && tr.Status == "completed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for translation; status=%q", tr.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if len(provider.calls) < 2 {
+		t.Fatalf("expected a provider call per sentence, got %d", len(provider.calls))
+	}
+	for i := 1; i < len(provider.calls); i++ {
+		if gap := provider.calls[i].Sub(provider.calls[i-1]); gap < 40*time.Millisecond {
+			t.Fatalf("expected calls at least 40ms apart, call %d came after %s", i, gap)
+		}
+	}
+}
+
+func TestSubscribeWakesOnProgressUntilCompletion(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	manager := NewManager(store, &mockProvider{})
+
+	item, err := store.Create("你好。谢谢。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	updates, unsubscribe := manager.Subscribe(item.ID)
+	defer unsubscribe()
+	manager.StartProcessing(context.Background(), item.ID)
+
+	// Waiting only on notifications, with no polling, must still reach the
+	// completed state.
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case <-updates:
+		case <-deadline:
+			t.Fatal("timed out waiting for a progress notification")
+		}
+		progress, ok := manager.GetProgress(item.ID)
+		if ok && progress.Status == "completed" {
+			return
+		}
+	}
+}
+
+func TestProgressHubCoalescesAndUnsubscribes(t *testing.T) {
+	var hub progressHub
+	updates, unsubscribe := hub.subscribe("t1")
+	other, unsubscribeOther := hub.subscribe("t2")
+	defer unsubscribeOther()
+
+	hub.publish("t1")
+	hub.publish("t1")
+	if len(updates) != 1 {
+		t.Fatalf("expected notifications to coalesce into one, got %d", len(updates))
+	}
+	if len(other) != 0 {
+		t.Fatal("expected only subscribers of t1 to be notified")
+	}
+	<-updates
+
+	unsubscribe()
+	hub.publish("t1")
+	if len(updates) != 0 {
+		t.Fatal("expected no notification after unsubscribing")
+	}
+	if _, ok := hub.subs["t1"]; ok {
+		t.Fatal("expected the last unsubscribe to drop the translation's entry")
+	}
+}
+
+func TestCompletingChunkStartsNextPart(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	store := newTranslationStoreForTest(t, dbPath)
+	manager := NewManager(store, &mockProvider{})
+
+	parts, err := store.CreateChunkedForUser(translation.DefaultUserID, []string{"第一句。", "第二句。", "第三句。"}, "text", translation.LanguagePair{}, nil, translation.JobPriorityNormal)
+	if err != nil {
+		t.Fatalf("create chunked translation: %v", err)
+	}
+	manager.StartProcessing(context.Background(), parts[0].ID)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		done := 0
+		for _, part := range parts {
+			if item, ok := store.Get(part.ID); ok && item.Status == "completed" {
+				done++
+			}
+		}
+		if done == len(parts) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out with %d of %d parts completed", done, len(parts))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
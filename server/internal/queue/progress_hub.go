@@ -0,0 +1,46 @@
+package queue
+
+import "sync"
+
+// progressHub wakes streams when a translation's progress changes, so they
+// need not poll the database. Notifications coalesce: a subscriber that has
+// not yet handled one is not sent another, and reloads the latest progress
+// when it does.
+type progressHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan struct{}]struct{}
+}
+
+func (h *progressHub) subscribe(translationID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[string]map[chan struct{}]struct{})
+	}
+	if h.subs[translationID] == nil {
+		h.subs[translationID] = make(map[chan struct{}]struct{})
+	}
+	h.subs[translationID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[translationID], ch)
+		if len(h.subs[translationID]) == 0 {
+			delete(h.subs, translationID)
+		}
+	}
+	return ch, unsubscribe
+}
+
+func (h *progressHub) publish(translationID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[translationID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
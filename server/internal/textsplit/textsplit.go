@@ -0,0 +1,186 @@
+// Package textsplit splits input text into sentences while keeping the
+// whitespace layout needed to reassemble it.
+package textsplit
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultLanguage is used for delimiters when a language is not supported.
+const defaultLanguage = "zh"
+
+// defaultSentenceDelimiters lists the runes that end a sentence for each
+// supported source language.
+var defaultSentenceDelimiters = map[string]string{
+	"zh": "。！？!?;；",
+	"ja": "。！？!?",
+	"ko": ".!?。！？",
+}
+
+// sentenceDelimiters is defaultSentenceDelimiters with any sets replaced by
+// ConfigureDelimiters.
+var sentenceDelimiters = defaultSentenceDelimiters
+
+// quotePairs maps the quotes and brackets that can enclose a delimiter to
+// their closing rune. A delimiter between an open pair does not end the
+// sentence, so quoted speech stays with the words around it.
+var quotePairs = map[rune]rune{
+	'「': '」',
+	'『': '』',
+	'“': '”',
+	'《': '》',
+	'（': '）',
+}
+
+// Sentence is one sentence of the input. Indent is the leading whitespace of
+// the line it starts, and Separator holds the line breaks that follow it.
+type Sentence struct {
+	Text      string
+	Indent    string
+	Separator string
+}
+
+// SupportsLanguage reports whether lang has a sentence delimiter set.
+func SupportsLanguage(lang string) bool {
+	_, ok := defaultSentenceDelimiters[lang]
+	return ok
+}
+
+// ConfigureDelimiters replaces the delimiter set of each language in
+// overrides; languages it leaves out keep their defaults. It is meant to be
+// called once at startup, before any text is split. Nil restores every
+// default.
+func ConfigureDelimiters(overrides map[string]string) error {
+	delimiters := make(map[string]string, len(defaultSentenceDelimiters))
+	for lang, set := range defaultSentenceDelimiters {
+		delimiters[lang] = set
+	}
+	for lang, set := range overrides {
+		if !SupportsLanguage(lang) {
+			return fmt.Errorf("unsupported language %q", lang)
+		}
+		if strings.TrimSpace(set) == "" {
+			return fmt.Errorf("no delimiters given for %q", lang)
+		}
+		delimiters[lang] = set
+	}
+	sentenceDelimiters = delimiters
+	return nil
+}
+
+// IsSentenceDelimiter reports whether r ends a sentence in lang. Unsupported
+// languages use the Chinese delimiter set.
+func IsSentenceDelimiter(r rune, lang string) bool {
+	delimiters, ok := sentenceDelimiters[lang]
+	if !ok {
+		delimiters = sentenceDelimiters[defaultLanguage]
+	}
+	return strings.ContainsRune(delimiters, r)
+}
+
+// Split breaks text into sentences at lang's delimiters and at line breaks.
+// Delimiters inside quotes or brackets (see quotePairs) are skipped; an
+// unclosed quote runs to the end of its line. Blank lines before the first
+// sentence are dropped.
+func Split(text string, lang string) []Sentence {
+	var out []Sentence
+	var sentence strings.Builder
+	var lineIndent strings.Builder
+	atLineStart := true
+	// closers holds the closing rune of each open quote, innermost last.
+	var closers []rune
+
+	addSeparatorChar := func(r rune) {
+		if len(out) > 0 {
+			out[len(out)-1].Separator += string(r)
+		}
+	}
+	flush := func() {
+		if s := strings.TrimSpace(sentence.String()); s != "" {
+			out = append(out, Sentence{Text: s, Indent: lineIndent.String()})
+		}
+		sentence.Reset()
+		lineIndent.Reset()
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		if atLineStart {
+			if r == ' ' || r == '\t' {
+				lineIndent.WriteRune(r)
+				continue
+			}
+			if r == '\n' || r == '\r' {
+				addSeparatorChar(r)
+				lineIndent.Reset()
+				continue
+			}
+			atLineStart = false
+		}
+
+		if r == '\n' || r == '\r' {
+			flush()
+			addSeparatorChar(r)
+			atLineStart = true
+			closers = closers[:0]
+			continue
+		}
+
+		sentence.WriteRune(r)
+		if closer, ok := quotePairs[r]; ok {
+			closers = append(closers, closer)
+		} else if len(closers) > 0 && r == closers[len(closers)-1] {
+			closers = closers[:len(closers)-1]
+		} else if len(closers) == 0 && IsSentenceDelimiter(r, lang) {
+			flush()
+		}
+	}
+	flush()
+
+	return out
+}
+
+// Chunk groups the sentences of text into pieces of at most maxRunes
+// characters each, keeping each sentence's indent and line breaks. A
+// sentence longer than maxRunes is cut into maxRunes-sized parts. Spaces
+// between sentences on one line are dropped, as Split drops them, and so is
+// a piece that would hold only whitespace.
+func Chunk(text string, lang string, maxRunes int) []string {
+	if maxRunes < 1 {
+		return nil
+	}
+	var out []string
+	emit := func(chunk string) {
+		if strings.TrimSpace(chunk) != "" {
+			out = append(out, chunk)
+		}
+	}
+	var current strings.Builder
+	currentRunes := 0
+	flush := func() {
+		emit(current.String())
+		current.Reset()
+		currentRunes = 0
+	}
+	for _, sent := range Split(text, lang) {
+		piece := sent.Indent + sent.Text + sent.Separator
+		n := utf8.RuneCountInString(piece)
+		if currentRunes+n > maxRunes {
+			flush()
+		}
+		for n > maxRunes {
+			runes := []rune(piece)
+			emit(string(runes[:maxRunes]))
+			piece = string(runes[maxRunes:])
+			n -= maxRunes
+		}
+		current.WriteString(piece)
+		currentRunes += n
+	}
+	flush()
+	return out
+}
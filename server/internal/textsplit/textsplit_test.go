@@ -0,0 +1,172 @@
+package textsplit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		lang string
+		want []Sentence
+	}{
+		{
+			name: "delimiters on one line",
+			text: "你好。世界！",
+			lang: "zh",
+			want: []Sentence{{Text: "你好。"}, {Text: "世界！"}},
+		},
+		{
+			name: "mixed indentation",
+			text: "  第一句。\n\t第二句。\n",
+			lang: "zh",
+			want: []Sentence{
+				{Text: "第一句。", Indent: "  ", Separator: "\n"},
+				{Text: "第二句。", Indent: "\t", Separator: "\n"},
+			},
+		},
+		{
+			name: "crlf line endings",
+			text: "一。\r\n\r\n二",
+			lang: "zh",
+			want: []Sentence{
+				{Text: "一。", Separator: "\r\n\r\n"},
+				{Text: "二"},
+			},
+		},
+		{
+			name: "trailing delimiters",
+			text: "好吗？！",
+			lang: "zh",
+			want: []Sentence{{Text: "好吗？"}, {Text: "！"}},
+		},
+		{
+			name: "leading blank lines are dropped",
+			text: "\n\n  你好",
+			lang: "zh",
+			want: []Sentence{{Text: "你好", Indent: "  "}},
+		},
+		{
+			name: "korean uses full stop",
+			text: "안녕하세요. 반갑습니다!",
+			lang: "ko",
+			want: []Sentence{{Text: "안녕하세요."}, {Text: "반갑습니다!"}},
+		},
+		{
+			name: "chinese ignores full stop",
+			text: "안녕하세요. 반갑습니다!",
+			lang: "zh",
+			want: []Sentence{{Text: "안녕하세요. 반갑습니다!"}},
+		},
+		{
+			name: "delimiter inside quotes",
+			text: "“你好。”他说。「走吧！」",
+			lang: "zh",
+			want: []Sentence{{Text: "“你好。”他说。"}, {Text: "「走吧！」"}},
+		},
+		{
+			name: "nested quotes and brackets",
+			text: "他读了《三体》（很好看！）。『「好。」』对。",
+			lang: "zh",
+			want: []Sentence{{Text: "他读了《三体》（很好看！）。"}, {Text: "『「好。」』对。"}},
+		},
+		{
+			name: "unclosed quote ends at the line",
+			text: "“你好。再见。\n好。",
+			lang: "zh",
+			want: []Sentence{{Text: "“你好。再见。", Separator: "\n"}, {Text: "好。"}},
+		},
+		{
+			name: "stray closing quote",
+			text: "好”。对。",
+			lang: "zh",
+			want: []Sentence{{Text: "好”。"}, {Text: "对。"}},
+		},
+		{
+			name: "empty",
+			text: "  \n",
+			lang: "zh",
+			want: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Split(tc.text, tc.lang); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("Split(%q) = %#v, want %#v", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigureDelimiters(t *testing.T) {
+	t.Cleanup(func() { _ = ConfigureDelimiters(nil) })
+
+	if err := ConfigureDelimiters(map[string]string{"zh": "。"}); err != nil {
+		t.Fatalf("configure delimiters: %v", err)
+	}
+	if got := Split("你好！世界。", "zh"); len(got) != 1 {
+		t.Fatalf("expected ！ to no longer split, got %#v", got)
+	}
+	if got := Split("はい！いいえ。", "ja"); len(got) != 2 {
+		t.Fatalf("expected other languages to keep their defaults, got %#v", got)
+	}
+
+	if err := ConfigureDelimiters(map[string]string{"fr": "."}); err == nil {
+		t.Fatal("expected an unsupported language to be rejected")
+	}
+	if err := ConfigureDelimiters(map[string]string{"zh": " "}); err == nil {
+		t.Fatal("expected an empty delimiter set to be rejected")
+	}
+	if err := ConfigureDelimiters(nil); err != nil || len(Split("你好！世界。", "zh")) != 2 {
+		t.Fatalf("expected defaults to be restored, err=%v", err)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxRunes int
+		want     []string
+	}{
+		{
+			name:     "fits in one chunk",
+			text:     "你好。世界！",
+			maxRunes: 10,
+			want:     []string{"你好。世界！"},
+		},
+		{
+			name:     "breaks between sentences",
+			text:     "第一句。第二句。\n第三句。",
+			maxRunes: 9,
+			want:     []string{"第一句。第二句。\n", "第三句。"},
+		},
+		{
+			name:     "keeps line breaks with their sentence",
+			text:     "第一句。\n第二句。",
+			maxRunes: 5,
+			want:     []string{"第一句。\n", "第二句。"},
+		},
+		{
+			name:     "cuts an overlong sentence",
+			text:     "一二三四五六七。",
+			maxRunes: 3,
+			want:     []string{"一二三", "四五六", "七。"},
+		},
+		{
+			name:     "drops a whitespace-only remainder",
+			text:     "一二三。\n",
+			maxRunes: 4,
+			want:     []string{"一二三。"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chunk(tt.text, "zh", tt.maxRunes); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Chunk() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,35 +1,74 @@
 package translation
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/binary"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// DBConfig tunes the connection pool. SQLite allows one writer at a time,
+// so a single open connection is the safe default: requests queue in the
+// pool instead of failing with "database is locked". BusyTimeoutMs bounds
+// how long a connection waits on a lock held by another process, such as
+// the migrate command.
+type DBConfig struct {
+	MaxOpenConns  int
+	MaxIdleConns  int
+	BusyTimeoutMs int
+}
+
+func DefaultDBConfig() DBConfig {
+	return DBConfig{MaxOpenConns: 1, MaxIdleConns: 1, BusyTimeoutMs: 3000}
+}
+
+func (c DBConfig) Validate() error {
+	if c.MaxOpenConns < 1 {
+		return fmt.Errorf("max open conns must be at least 1")
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("max idle conns must be between 0 and max open conns")
+	}
+	if c.BusyTimeoutMs < 0 {
+		return fmt.Errorf("busy timeout must not be negative")
+	}
+	return nil
+}
+
 func NewDB(dbPath string) (*DB, error) {
+	return NewDBWithConfig(dbPath, DefaultDBConfig())
+}
+
+func NewDBWithConfig(dbPath string, cfg DBConfig) (*DB, error) {
 	if dbPath == "" {
 		return nil, fmt.Errorf("translation db path is required")
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid db config: %w", err)
+	}
 
-	conn, err := sql.Open("sqlite", dbPath)
+	// Pragmas go in the DSN so the driver applies them to every pooled
+	// connection, not just the first one.
+	pragmas := url.Values{}
+	pragmas.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeoutMs))
+	pragmas.Add("_pragma", "foreign_keys(1)")
+	pragmas.Add("_pragma", "journal_mode(WAL)")
+	conn, err := sql.Open("sqlite", dbPath+"?"+pragmas.Encode())
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite db: %w", err)
 	}
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	conn.SetMaxIdleConns(cfg.MaxIdleConns)
 
-	if _, err := conn.Exec(`PRAGMA foreign_keys = ON;`); err != nil {
-		_ = conn.Close()
-		return nil, fmt.Errorf("enable foreign keys: %w", err)
-	}
-	if _, err := conn.Exec(`PRAGMA journal_mode = WAL;`); err != nil {
+	if err := conn.Ping(); err != nil {
 		_ = conn.Close()
-		return nil, fmt.Errorf("set wal mode: %w", err)
-	}
-	if _, err := conn.Exec(`PRAGMA busy_timeout = 3000;`); err != nil {
-		_ = conn.Close()
-		return nil, fmt.Errorf("set busy timeout: %w", err)
+		return nil, fmt.Errorf("connect sqlite db: %w", err)
 	}
 	if err := verifySchema(conn); err != nil {
 		_ = conn.Close()
@@ -51,8 +90,11 @@ func verifySchema(db *sql.DB) error {
 		"saved_characters",
 		"character_segment_links",
 		"srs_state",
+		"srs_review_log",
 		"vocab_lookups",
 		"user_profile",
+		"users",
+		"sessions",
 	}
 	for _, table := range requiredTables {
 		var exists int
@@ -69,8 +111,66 @@ func verifySchema(db *sql.DB) error {
 	return nil
 }
 
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	idMu      sync.Mutex
+	idLastMs  int64
+	idEntropy [10]byte
+)
+
+// newID returns a ULID: a 48-bit millisecond timestamp and 80 random bits,
+// written as 26 Crockford base32 characters, so ids sort by creation time.
+// Ids minted within the same millisecond increment the random part of the
+// previous one, keeping them unique and ordered in tight loops.
 func newID() (string, error) {
-	return fmt.Sprintf("%d", time.Now().UTC().UnixNano()), nil
+	idMu.Lock()
+	defer idMu.Unlock()
+
+	ms := time.Now().UnixMilli()
+	fresh := ms > idLastMs
+	if !fresh {
+		// Same millisecond, or the clock stepped back: stay on the last
+		// timestamp and count up.
+		ms = idLastMs
+		fresh = true
+		for i := len(idEntropy) - 1; i >= 0; i-- {
+			idEntropy[i]++
+			if idEntropy[i] != 0 {
+				fresh = false
+				break
+			}
+		}
+		if fresh {
+			ms++
+		}
+	}
+	if fresh {
+		if _, err := rand.Read(idEntropy[:]); err != nil {
+			return "", fmt.Errorf("generate id: %w", err)
+		}
+	}
+	idLastMs = ms
+
+	var out [26]byte
+	for i := 9; i >= 0; i-- {
+		out[i] = crockfordBase32[ms&31]
+		ms >>= 5
+	}
+	hi := uint64(idEntropy[0])<<8 | uint64(idEntropy[1])
+	lo := binary.BigEndian.Uint64(idEntropy[2:])
+	for i := 25; i >= 10; i-- {
+		out[i] = crockfordBase32[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
+
+// IsDBBusy reports whether err is SQLite refusing a write because another
+// connection holds the lock; retrying later may succeed.
+func IsDBBusy(err error) bool {
+	return err != nil && isDBLocked(err)
 }
 
 func isDBLocked(err error) bool {
@@ -0,0 +1,28 @@
+package translation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewIDIsUniqueAndSortable(t *testing.T) {
+	seen := make(map[string]bool)
+	prev := ""
+	for i := 0; i < 10000; i++ {
+		id, err := newID()
+		if err != nil {
+			t.Fatalf("new id: %v", err)
+		}
+		if len(id) != 26 || strings.Trim(id, crockfordBase32) != "" {
+			t.Fatalf("expected a 26-character base32 id, got %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate id %q after %d ids", id, i)
+		}
+		if id <= prev {
+			t.Fatalf("expected ids in creation order, got %q after %q", id, prev)
+		}
+		seen[id] = true
+		prev = id
+	}
+}
@@ -0,0 +1,395 @@
+package translation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Plaintext export formats for FormatSentences.
+const (
+	ExportFormatInterlinear   = "interlinear"
+	ExportFormatTSV           = "tsv"
+	ExportFormatMarkdownTable = "markdown-table"
+)
+
+// ExportContentType returns the Content-Type for an export format.
+func ExportContentType(format string) string {
+	switch format {
+	case ExportFormatTSV:
+		return "text/tab-separated-values; charset=utf-8"
+	case ExportFormatMarkdownTable:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// FormatSentences renders translated sentences as text:
+//
+//   - interlinear stacks segment, pinyin and english rows with aligned
+//     columns, keeping each sentence's indent and the line breaks of its
+//     separator;
+//   - tsv writes one row per segment under a header;
+//   - markdown-table writes one table per sentence.
+//
+// Segments with no text are skipped.
+func FormatSentences(sentences []SentenceResult, format string) (string, error) {
+	switch format {
+	case ExportFormatInterlinear:
+		return formatInterlinear(sentences), nil
+	case ExportFormatTSV:
+		return formatTSV(sentences), nil
+	case ExportFormatMarkdownTable:
+		return formatMarkdownTables(sentences), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func formatInterlinear(sentences []SentenceResult) string {
+	var b strings.Builder
+	for i, sentence := range sentences {
+		segments := exportableSegments(sentence.Translations)
+		if len(segments) > 0 {
+			rows := [3][]string{}
+			for _, seg := range segments {
+				cells := [3]string{flattenCell(seg.Segment), flattenCell(seg.Pinyin), flattenCell(seg.English)}
+				width := 0
+				for _, cell := range cells {
+					width = max(width, displayWidth(cell))
+				}
+				for row, cell := range cells {
+					rows[row] = append(rows[row], cell+strings.Repeat(" ", width-displayWidth(cell)))
+				}
+			}
+			indent := flattenCell(sentence.Indent)
+			for _, row := range rows {
+				b.WriteString(strings.TrimRight(indent+strings.Join(row, "  "), " "))
+				b.WriteString("\n")
+			}
+		}
+		if i < len(sentences)-1 {
+			// Blocks are always separated by a blank line; paragraph breaks
+			// in the separator add one more blank line each.
+			b.WriteString(strings.Repeat("\n", max(1, strings.Count(sentence.Separator, "\n"))))
+		}
+	}
+	return b.String()
+}
+
+func formatTSV(sentences []SentenceResult) string {
+	var b strings.Builder
+	b.WriteString("sentence\tsegment\tpinyin\tenglish\n")
+	for i, sentence := range sentences {
+		for _, seg := range exportableSegments(sentence.Translations) {
+			b.WriteString(strings.Join([]string{
+				strconv.Itoa(i + 1),
+				tsvCell(seg.Segment),
+				tsvCell(seg.Pinyin),
+				tsvCell(seg.English),
+			}, "\t"))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func formatMarkdownTables(sentences []SentenceResult) string {
+	tables := make([]string, 0, len(sentences))
+	for _, sentence := range sentences {
+		segments := exportableSegments(sentence.Translations)
+		if len(segments) == 0 {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString("| Segment | Pinyin | English |\n| --- | --- | --- |\n")
+		for _, seg := range segments {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(seg.Segment), markdownCell(seg.Pinyin), markdownCell(seg.English))
+		}
+		tables = append(tables, b.String())
+	}
+	return strings.Join(tables, "\n")
+}
+
+func exportableSegments(segments []SegmentResult) []SegmentResult {
+	out := make([]SegmentResult, 0, len(segments))
+	for _, seg := range segments {
+		if strings.TrimSpace(seg.Segment) != "" {
+			out = append(out, seg)
+		}
+	}
+	return out
+}
+
+// flattenCell collapses line breaks and tabs so a value stays on one row.
+func flattenCell(value string) string {
+	return strings.Join(strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == '\t'
+	}), " ")
+}
+
+func tsvCell(value string) string {
+	return strings.TrimSpace(flattenCell(value))
+}
+
+func markdownCell(value string) string {
+	return strings.ReplaceAll(strings.TrimSpace(flattenCell(value)), "|", `\|`)
+}
+
+// displayWidth approximates the monospace width of s, counting CJK and
+// fullwidth characters as two columns.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul),
+			r >= 0x3000 && r <= 0x303F, r >= 0xFF01 && r <= 0xFF60:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// ankiProgress is the subset of the ExportProgressJSON bundle used by
+// FormatAnkiTSV.
+type ankiProgress struct {
+	SavedSegments []struct {
+		ID              string  `json:"id"`
+		Headword        string  `json:"headword"`
+		Pinyin          string  `json:"pinyin"`
+		English         string  `json:"english"`
+		Status          string  `json:"status"`
+		LastSeenSnippet *string `json:"last_seen_snippet"`
+	} `json:"saved_segments"`
+	SRSState []struct {
+		SegmentID    *string  `json:"segment_id"`
+		DueAt        *string  `json:"due_at"`
+		IntervalDays *float64 `json:"interval_days"`
+		Ease         *float64 `json:"ease"`
+		Reps         *float64 `json:"reps"`
+		Lapses       *float64 `json:"lapses"`
+	} `json:"srs_state"`
+}
+
+// FormatAnkiTSV converts an ExportProgressJSON bundle into a note file for
+// Anki's text importer: one note per saved word with its snippet and SRS
+// schedule. Anki's ease factor is written in permille (2.5 → 2500). Status
+// becomes a tag so learning and known words can be filtered after import.
+func FormatAnkiTSV(progressJSON string) (string, error) {
+	var progress ankiProgress
+	if err := json.Unmarshal([]byte(progressJSON), &progress); err != nil {
+		return "", fmt.Errorf("decode progress export: %w", err)
+	}
+	type schedule struct {
+		due, interval, ease, reps, lapses string
+	}
+	schedules := make(map[string]schedule, len(progress.SRSState))
+	for _, state := range progress.SRSState {
+		if state.SegmentID == nil {
+			continue
+		}
+		sched := schedule{}
+		if state.DueAt != nil {
+			if due, err := time.Parse(time.RFC3339Nano, *state.DueAt); err == nil {
+				sched.due = due.UTC().Format("2006-01-02")
+			}
+		}
+		if state.IntervalDays != nil {
+			sched.interval = strconv.FormatFloat(*state.IntervalDays, 'f', -1, 64)
+		}
+		if state.Ease != nil {
+			sched.ease = strconv.Itoa(int(math.Round(*state.Ease * 1000)))
+		}
+		if state.Reps != nil {
+			sched.reps = strconv.Itoa(int(*state.Reps))
+		}
+		if state.Lapses != nil {
+			sched.lapses = strconv.Itoa(int(*state.Lapses))
+		}
+		schedules[*state.SegmentID] = sched
+	}
+
+	var b strings.Builder
+	b.WriteString("#separator:tab\n#html:true\n")
+	b.WriteString("#columns:Headword\tPinyin\tEnglish\tSnippet\tDue\tInterval\tEase\tReps\tLapses\tTags\n")
+	b.WriteString("#tags column:10\n")
+	for _, seg := range progress.SavedSegments {
+		snippet := ""
+		if seg.LastSeenSnippet != nil {
+			snippet = *seg.LastSeenSnippet
+		}
+		sched := schedules[seg.ID]
+		b.WriteString(strings.Join([]string{
+			ankiField(seg.Headword),
+			ankiField(seg.Pinyin),
+			ankiField(seg.English),
+			ankiField(snippet),
+			sched.due,
+			sched.interval,
+			sched.ease,
+			sched.reps,
+			sched.lapses,
+			"language-app status::" + strings.Join(strings.Fields(seg.Status), "_"),
+		}, "\t"))
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// vocabCSVProgress is the subset of the ExportProgressJSON bundle used by
+// FormatVocabCSV.
+type vocabCSVProgress struct {
+	SavedSegments []struct {
+		ID       string `json:"id"`
+		Headword string `json:"headword"`
+		Pinyin   string `json:"pinyin"`
+		English  string `json:"english"`
+		Status   string `json:"status"`
+	} `json:"saved_segments"`
+	SavedCharacters []struct {
+		ID        string `json:"id"`
+		Character string `json:"character"`
+		Pinyin    string `json:"pinyin"`
+		English   string `json:"english"`
+		Status    string `json:"status"`
+	} `json:"saved_characters"`
+	SRSState []struct {
+		SegmentID    *string  `json:"segment_id"`
+		CharacterID  *string  `json:"character_id"`
+		DueAt        *string  `json:"due_at"`
+		IntervalDays *float64 `json:"interval_days"`
+		Reps         *float64 `json:"reps"`
+		Lapses       *float64 `json:"lapses"`
+	} `json:"srs_state"`
+}
+
+// vocabCSVHeader names the columns of FormatVocabCSV.
+var vocabCSVHeader = []string{"headword", "pinyin", "english", "status", "type", "due_at", "interval_days", "reps", "lapses"}
+
+// FormatVocabCSV converts an ExportProgressJSON bundle into a CSV of saved
+// words followed by saved characters (type "word" or "character"), each with
+// its SRS schedule. Schedule columns are empty for items never scheduled.
+// The file starts with a UTF-8 byte order mark so Excel reads the Chinese
+// text correctly.
+func FormatVocabCSV(progressJSON string) (string, error) {
+	var progress vocabCSVProgress
+	if err := json.Unmarshal([]byte(progressJSON), &progress); err != nil {
+		return "", fmt.Errorf("decode progress export: %w", err)
+	}
+	schedules := make(map[string][]string, len(progress.SRSState))
+	for _, state := range progress.SRSState {
+		sched := []string{"", "", "", ""}
+		if state.DueAt != nil {
+			sched[0] = *state.DueAt
+		}
+		if state.IntervalDays != nil {
+			sched[1] = strconv.FormatFloat(*state.IntervalDays, 'f', -1, 64)
+		}
+		if state.Reps != nil {
+			sched[2] = strconv.Itoa(int(*state.Reps))
+		}
+		if state.Lapses != nil {
+			sched[3] = strconv.Itoa(int(*state.Lapses))
+		}
+		switch {
+		case state.SegmentID != nil:
+			schedules["word:"+*state.SegmentID] = sched
+		case state.CharacterID != nil:
+			schedules["character:"+*state.CharacterID] = sched
+		}
+	}
+	schedule := func(key string) []string {
+		if sched, ok := schedules[key]; ok {
+			return sched
+		}
+		return []string{"", "", "", ""}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	_ = w.Write(vocabCSVHeader)
+	for _, seg := range progress.SavedSegments {
+		_ = w.Write(append([]string{seg.Headword, seg.Pinyin, seg.English, seg.Status, "word"}, schedule("word:"+seg.ID)...))
+	}
+	for _, char := range progress.SavedCharacters {
+		_ = w.Write(append([]string{char.Character, char.Pinyin, char.English, char.Status, "character"}, schedule("character:"+char.ID)...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("write vocab csv: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ankiField HTML-escapes a value for an html:true import, keeping line breaks
+// as <br> and replacing tabs, which would split the field.
+func ankiField(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\t", " ")
+	return strings.ReplaceAll(html.EscapeString(strings.TrimSpace(value)), "\n", "<br>")
+}
+
+// FormatChatMarkdown renders a chat thread as a markdown document. Review
+// cards and grammar notes are quote blocks; tool messages without either
+// (rejected cards) are not rendered, as in the chat UI.
+func FormatChatMarkdown(title string, messages []ChatMessage) string {
+	var b strings.Builder
+	b.WriteString("# Chat: " + flattenCell(title) + "\n")
+	for _, msg := range messages {
+		switch msg.Role {
+		case ChatRoleUser:
+			b.WriteString("\n**You:**")
+			if msg.SelectedText != nil && strings.TrimSpace(*msg.SelectedText) != "" {
+				b.WriteString(" _(about “" + flattenCell(*msg.SelectedText) + "”)_")
+			}
+			b.WriteString("\n\n" + strings.TrimSpace(msg.Content) + "\n")
+		case ChatRoleAI:
+			b.WriteString("\n**Tutor:**\n\n" + strings.TrimSpace(msg.Content) + "\n")
+		case ChatRoleTool:
+			switch {
+			case msg.ReviewCard != nil:
+				card := msg.ReviewCard
+				writeQuote(&b, []string{
+					"**Review card** (" + card.Status + ")",
+					card.ChineseText,
+					"_" + card.Pinyin + "_",
+					card.English,
+				})
+			case msg.GrammarNote != nil:
+				note := msg.GrammarNote
+				lines := []string{"**Grammar:** " + note.Point, note.Explanation}
+				for _, example := range note.Examples {
+					lines = append(lines, "- "+example)
+				}
+				writeQuote(&b, lines)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeQuote writes paragraphs as one markdown quote block, keeping line
+// breaks inside each paragraph.
+func writeQuote(b *strings.Builder, paragraphs []string) {
+	b.WriteString("\n")
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			b.WriteString(">\n")
+		}
+		for _, line := range strings.Split(strings.TrimSpace(paragraph), "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+	}
+}
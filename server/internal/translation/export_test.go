@@ -0,0 +1,152 @@
+package translation
+
+import "testing"
+
+func TestFormatSentences(t *testing.T) {
+	sentences := []SentenceResult{
+		{
+			Indent:    "  ",
+			Separator: "\n\n",
+			Translations: []SegmentResult{
+				{Segment: "你好", Pinyin: "nǐ hǎo", English: "hello"},
+				{Segment: "", Pinyin: "", English: ""},
+				{Segment: "。"},
+			},
+		},
+		{
+			Translations: []SegmentResult{
+				{Segment: "世界", Pinyin: "shì jiè", English: "world | earth\nplanet"},
+			},
+		},
+	}
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: ExportFormatInterlinear,
+			want: "  你好    。\n" +
+				"  nǐ hǎo\n" +
+				"  hello\n" +
+				"\n\n" +
+				"世界\n" +
+				"shì jiè\n" +
+				"world | earth planet\n",
+		},
+		{
+			format: ExportFormatTSV,
+			want: "sentence\tsegment\tpinyin\tenglish\n" +
+				"1\t你好\tnǐ hǎo\thello\n" +
+				"1\t。\t\t\n" +
+				"2\t世界\tshì jiè\tworld | earth planet\n",
+		},
+		{
+			format: ExportFormatMarkdownTable,
+			want: "| Segment | Pinyin | English |\n| --- | --- | --- |\n" +
+				"| 你好 | nǐ hǎo | hello |\n" +
+				"| 。 |  |  |\n" +
+				"\n" +
+				"| Segment | Pinyin | English |\n| --- | --- | --- |\n" +
+				"| 世界 | shì jiè | world \\| earth planet |\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			got, err := FormatSentences(sentences, tc.format)
+			if err != nil {
+				t.Fatalf("format: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("unexpected output:\n%q\nwant:\n%q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := FormatSentences(sentences, "pdf"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestFormatAnkiTSV(t *testing.T) {
+	bundle := `{
+		"saved_segments": [
+			{"id": "s1", "headword": "学习", "pinyin": "xué xí", "english": "to study", "status": "learning", "last_seen_snippet": "我\t学习<中文>\n每天"},
+			{"id": "s2", "headword": "你好", "pinyin": "nǐ hǎo", "english": "hello", "status": "known", "last_seen_snippet": null}
+		],
+		"srs_state": [
+			{"segment_id": "s1", "character_id": null, "due_at": "2026-03-04T10:00:00Z", "interval_days": 6, "ease": 2.5, "reps": 3, "lapses": 1},
+			{"segment_id": null, "character_id": "c1", "due_at": "2026-01-01T00:00:00Z", "interval_days": 1, "ease": 2.5, "reps": 1, "lapses": 0}
+		]
+	}`
+
+	got, err := FormatAnkiTSV(bundle)
+	if err != nil {
+		t.Fatalf("format anki: %v", err)
+	}
+	want := "#separator:tab\n#html:true\n" +
+		"#columns:Headword\tPinyin\tEnglish\tSnippet\tDue\tInterval\tEase\tReps\tLapses\tTags\n" +
+		"#tags column:10\n" +
+		"学习\txué xí\tto study\t我 学习&lt;中文&gt;<br>每天\t2026-03-04\t6\t2500\t3\t1\tlanguage-app status::learning\n" +
+		"你好\tnǐ hǎo\thello\t\t\t\t\t\t\tlanguage-app status::known\n"
+	if got != want {
+		t.Fatalf("unexpected output:\n%q\nwant:\n%q", got, want)
+	}
+
+	if _, err := FormatAnkiTSV("not json"); err == nil {
+		t.Fatal("expected error for invalid bundle")
+	}
+}
+
+func TestFormatVocabCSV(t *testing.T) {
+	bundle := `{
+		"saved_segments": [
+			{"id": "s1", "headword": "学习", "pinyin": "xué xí", "english": "to study; to learn, \"xue\"", "status": "learning"},
+			{"id": "s2", "headword": "你好", "pinyin": "nǐ hǎo", "english": "hello/hi", "status": "known"}
+		],
+		"saved_characters": [
+			{"id": "c1", "character": "学", "pinyin": "xué", "english": "learn, study", "status": "learning"}
+		],
+		"srs_state": [
+			{"segment_id": "s1", "character_id": null, "due_at": "2026-03-04T10:00:00Z", "interval_days": 6.5, "ease": 2.5, "reps": 3, "lapses": 1},
+			{"segment_id": null, "character_id": "c1", "due_at": "2026-01-01T00:00:00Z", "interval_days": 1, "ease": 2.5, "reps": 1, "lapses": 0}
+		]
+	}`
+
+	got, err := FormatVocabCSV(bundle)
+	if err != nil {
+		t.Fatalf("format vocab csv: %v", err)
+	}
+	want := "\ufeffheadword,pinyin,english,status,type,due_at,interval_days,reps,lapses\n" +
+		"学习,xué xí,\"to study; to learn, \"\"xue\"\"\",learning,word,2026-03-04T10:00:00Z,6.5,3,1\n" +
+		"你好,nǐ hǎo,hello/hi,known,word,,,,\n" +
+		"学,xué,\"learn, study\",learning,character,2026-01-01T00:00:00Z,1,1,0\n"
+	if got != want {
+		t.Fatalf("unexpected output:\n%q\nwant:\n%q", got, want)
+	}
+
+	if _, err := FormatVocabCSV("not json"); err == nil {
+		t.Fatal("expected error for invalid bundle")
+	}
+}
+
+func TestFormatChatMarkdown(t *testing.T) {
+	selected := "学习"
+	messages := []ChatMessage{
+		{Role: ChatRoleUser, Content: "Make a card for 学习", SelectedText: &selected},
+		{Role: ChatRoleAI, Content: "Here's a practice card for you:"},
+		{Role: ChatRoleTool, Content: "我们在学习。", ReviewCard: &ChatReviewCard{ChineseText: "我们在学习。", Pinyin: "wǒmen zài xuéxí.", English: "We are studying.", Status: "pending"}},
+		{Role: ChatRoleTool, Content: "rejected"},
+		{Role: ChatRoleTool, Content: "了", GrammarNote: &ChatGrammarNote{Point: "了", Explanation: "Marks completion.", Examples: []string{"我吃了。"}}},
+	}
+
+	got := FormatChatMarkdown("我喜欢\n学习", messages)
+	want := "# Chat: 我喜欢 学习\n" +
+		"\n**You:** _(about “学习”)_\n\nMake a card for 学习\n" +
+		"\n**Tutor:**\n\nHere's a practice card for you:\n" +
+		"\n> **Review card** (pending)\n>\n> 我们在学习。\n>\n> _wǒmen zài xuéxí._\n>\n> We are studying.\n" +
+		"\n> **Grammar:** 了\n>\n> Marks completion.\n>\n> - 我吃了。\n"
+	if got != want {
+		t.Fatalf("unexpected output:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package translation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadFrequencyRanks reads a word frequency list mapping headword → rank,
+// where rank 1 is the most common word. Each CSV row is "headword,rank"; rows
+// with only a headword are ranked by their position in the file. A header row
+// whose rank column is not a number is skipped.
+func LoadFrequencyRanks(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open frequency list: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	ranks := make(map[string]int)
+	position := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read frequency list: %w", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		headword := strings.TrimSpace(record[0])
+		if headword == "" || strings.HasPrefix(headword, "#") {
+			continue
+		}
+		position++
+		rank := position
+		if len(record) > 1 {
+			parsed, err := strconv.Atoi(strings.TrimSpace(record[1]))
+			if err != nil {
+				if position == 1 {
+					// Header row.
+					position = 0
+					continue
+				}
+				return nil, fmt.Errorf("invalid rank for %q: %w", headword, err)
+			}
+			rank = parsed
+		}
+		if existing, ok := ranks[headword]; !ok || rank < existing {
+			ranks[headword] = rank
+		}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,68 @@
+package translation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MaxHSKLevel is the highest HSK level; HSK 3.0 bands run from 1 to 9.
+const MaxHSKLevel = 9
+
+// LoadHSKLevels reads an HSK wordlist mapping headword → level. Each CSV row
+// is "headword,level"; a header row whose level column is not a number is
+// skipped, as are blank rows and rows starting with '#'. A word listed at
+// several levels keeps the lowest.
+func LoadHSKLevels(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open hsk wordlist: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	levels := make(map[string]int)
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read hsk wordlist: %w", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		headword := strings.TrimSpace(record[0])
+		if headword == "" || strings.HasPrefix(headword, "#") {
+			continue
+		}
+		rows++
+		if len(record) < 2 {
+			return nil, fmt.Errorf("missing hsk level for %q", headword)
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			if rows == 1 {
+				// Header row.
+				continue
+			}
+			return nil, fmt.Errorf("invalid hsk level for %q: %w", headword, err)
+		}
+		if level < 1 || level > MaxHSKLevel {
+			return nil, fmt.Errorf("hsk level for %q must be between 1 and %d", headword, MaxHSKLevel)
+		}
+		if existing, ok := levels[headword]; !ok || level < existing {
+			levels[headword] = level
+		}
+	}
+	return levels, nil
+}
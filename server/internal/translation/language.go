@@ -0,0 +1,72 @@
+package translation
+
+import (
+	"fmt"
+
+	"github.com/anath2/language-app/internal/textsplit"
+)
+
+const (
+	DefaultSourceLang = "zh"
+	DefaultTargetLang = "en"
+)
+
+// LanguagePair is the source language of a translation's input text and the
+// target language its segments and full translation are rendered in.
+type LanguagePair struct {
+	Source string
+	Target string
+}
+
+func DefaultLanguagePair() LanguagePair {
+	return LanguagePair{Source: DefaultSourceLang, Target: DefaultTargetLang}
+}
+
+var languageNames = map[string]string{
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+}
+
+// LanguageName returns the English name of a language code, or the code
+// itself when it is unknown.
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// WithDefaults fills in empty fields with the zh→en defaults.
+func (p LanguagePair) WithDefaults() LanguagePair {
+	if p.Source == "" {
+		p.Source = DefaultSourceLang
+	}
+	if p.Target == "" {
+		p.Target = DefaultTargetLang
+	}
+	return p
+}
+
+func (p LanguagePair) Validate() error {
+	if !textsplit.SupportsLanguage(p.Source) {
+		return fmt.Errorf("unsupported source_lang %q", p.Source)
+	}
+	if _, ok := languageNames[p.Target]; !ok {
+		return fmt.Errorf("unsupported target_lang %q", p.Target)
+	}
+	if p.Source == p.Target {
+		return fmt.Errorf("source_lang and target_lang must differ")
+	}
+	return nil
+}
+
+// IsSentenceDelimiter reports whether r ends a sentence in sourceLang.
+// Unknown languages fall back to the Chinese delimiter set.
+func IsSentenceDelimiter(r rune, sourceLang string) bool {
+	return textsplit.IsSentenceDelimiter(r, sourceLang)
+}
@@ -1,6 +1,8 @@
 package translation
 
 import (
+	"context"
+	"database/sql"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -19,6 +21,45 @@ func TestNewDBRequiresMigratedSchema(t *testing.T) {
 	}
 }
 
+func TestNewDBWithConfigAppliesPragmasToEveryConnection(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "translations.db")
+	if err := migrations.RunUp(dbPath, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	if _, err := NewDBWithConfig(dbPath, DBConfig{MaxOpenConns: 1, MaxIdleConns: 2, BusyTimeoutMs: 100}); err == nil {
+		t.Fatal("expected error for more idle than open connections")
+	}
+
+	db, err := NewDBWithConfig(dbPath, DBConfig{MaxOpenConns: 2, MaxIdleConns: 2, BusyTimeoutMs: 1234})
+	if err != nil {
+		t.Fatalf("new db: %v", err)
+	}
+	defer db.Conn.Close()
+	ctx := context.Background()
+	first, err := db.Conn.Conn(ctx)
+	if err != nil {
+		t.Fatalf("first conn: %v", err)
+	}
+	defer first.Close()
+	second, err := db.Conn.Conn(ctx)
+	if err != nil {
+		t.Fatalf("second conn: %v", err)
+	}
+	defer second.Close()
+	for i, conn := range []*sql.Conn{first, second} {
+		var foreignKeys, busyTimeout int
+		if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+			t.Fatalf("conn %d foreign_keys: %v", i, err)
+		}
+		if err := conn.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&busyTimeout); err != nil {
+			t.Fatalf("conn %d busy_timeout: %v", i, err)
+		}
+		if foreignKeys != 1 || busyTimeout != 1234 {
+			t.Fatalf("conn %d: expected foreign_keys=1 busy_timeout=1234, got %d and %d", i, foreignKeys, busyTimeout)
+		}
+	}
+}
+
 func TestRunUpIsIdempotentAndCreatesUsableSchema(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "translations.db")
 	migrationsDir := filepath.Join("..", "..", "migrations")
@@ -0,0 +1,36 @@
+package translation
+
+import "strings"
+
+// NormalizeInputText cleans up pasted text before it is split and
+// segmented. Zero-width characters and byte order marks are dropped,
+// non-breaking spaces become plain spaces, and full-width Latin letters and
+// digits become their ASCII forms. Full-width punctuation (，。！？ and so
+// on) and the ideographic space used for indents are part of Chinese
+// typography and are left alone.
+func NormalizeInputText(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case r == '\u200b', r == '\u200c', r == '\u200d', r == '\u2060', r == '\ufeff':
+			continue
+		case r == '\u00a0', r == '\u202f':
+			b.WriteRune(' ')
+		case r >= '\uff10' && r <= '\uff19', r >= '\uff21' && r <= '\uff3a', r >= '\uff41' && r <= '\uff5a':
+			b.WriteRune(r - 0xfee0)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// rawIfChanged returns text when normalizing would change it, for storing
+// alongside the normalized form, and nil otherwise.
+func rawIfChanged(text string) *string {
+	if NormalizeInputText(text) == text {
+		return nil
+	}
+	return &text
+}
@@ -0,0 +1,49 @@
+package translation
+
+import "testing"
+
+func TestNormalizeInputText(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "full-width letters and digits", in: "ＡＢＣ公司有１２３个人", want: "ABC公司有123个人"},
+		{name: "chinese punctuation kept", in: "你好，世界！（真的？）", want: "你好，世界！（真的？）"},
+		{name: "zero-width characters dropped", in: "\ufeff你\u200b好\u200d", want: "你好"},
+		{name: "non-breaking spaces", in: "你好\u00a0世界", want: "你好 世界"},
+		{name: "ideographic indent kept", in: "　　第一段。", want: "　　第一段。"},
+	} {
+		if got := NormalizeInputText(tc.in); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCreateStoresRawTextWhenNormalized(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	tr, err := store.Create("\u200bＡＩ改变世界。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	got, _ := store.Get(tr.ID)
+	if got.InputText != "AI改变世界。" {
+		t.Fatalf("expected normalized input text, got %q", got.InputText)
+	}
+	if got.RawInputText == nil || *got.RawInputText != "\u200bＡＩ改变世界。" {
+		t.Fatalf("expected raw input text to be kept, got %v", got.RawInputText)
+	}
+
+	plain, err := store.Create("你好。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if got, _ := store.Get(plain.ID); got.RawInputText != nil {
+		t.Fatalf("expected no raw text for already-normal input, got %q", *got.RawInputText)
+	}
+
+	if _, err := store.Create("\u200b\u200b", "text"); err == nil {
+		t.Fatal("expected zero-width-only input to be rejected")
+	}
+}
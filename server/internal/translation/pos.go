@@ -0,0 +1,49 @@
+package translation
+
+import "strings"
+
+// Parts of speech tag translated segments; segments with no clear part of
+// speech (punctuation, untagged legacy rows) leave POS empty.
+const (
+	POSNoun         = "noun"
+	POSVerb         = "verb"
+	POSAdjective    = "adjective"
+	POSAdverb       = "adverb"
+	POSPronoun      = "pronoun"
+	POSNumber       = "number"
+	POSMeasureWord  = "measure_word"
+	POSPreposition  = "preposition"
+	POSConjunction  = "conjunction"
+	POSParticle     = "particle"
+	POSInterjection = "interjection"
+	POSOther        = "other"
+)
+
+// PartsOfSpeech lists every POS* value, in the order offered to the model.
+var PartsOfSpeech = []string{
+	POSNoun, POSVerb, POSAdjective, POSAdverb, POSPronoun, POSNumber, POSMeasureWord,
+	POSPreposition, POSConjunction, POSParticle, POSInterjection, POSOther,
+}
+
+// NormalizePOS maps a part-of-speech label onto one of the POS* values,
+// returning "" for anything it does not recognize.
+func NormalizePOS(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	label = strings.NewReplacer(" ", "_", "-", "_").Replace(label)
+	switch label {
+	case "measure", "classifier", "measureword":
+		return POSMeasureWord
+	case "numeral":
+		return POSNumber
+	case "adj":
+		return POSAdjective
+	case "adv":
+		return POSAdverb
+	}
+	for _, pos := range PartsOfSpeech {
+		if label == pos {
+			return pos
+		}
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package translation
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// progressImport is a parsed and validated ExportProgressJSON bundle.
+type progressImport struct {
+	segments         []map[string]any
+	characters       []map[string]any
+	charSegmentLinks []map[string]any
+	srsState         []map[string]any
+	lookups          []map[string]any
+	reviewLog        []map[string]any
+}
+
+func (p progressImport) counts() map[string]int {
+	counts := map[string]int{
+		"saved_segments":   len(p.segments),
+		"saved_characters": len(p.characters),
+		"srs_state":        len(p.srsState),
+		"vocab_lookups":    len(p.lookups),
+	}
+	if len(p.charSegmentLinks) > 0 {
+		counts["character_segment_links"] = len(p.charSegmentLinks)
+	}
+	if len(p.reviewLog) > 0 {
+		counts["srs_review_log"] = len(p.reviewLog)
+	}
+	return counts
+}
+
+// parseProgressImport decodes a progress bundle and checks it against the
+// constraints the import would otherwise hit halfway through: required
+// fields, duplicate ids and keys, and references from SRS state, lookups,
+// review history and character links to words and characters in the same
+// bundle. Bundles from before the review history was exported import without
+// it.
+func parseProgressImport(input string) (progressImport, error) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(input), &data); err != nil {
+		return progressImport{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	getArr := func(key string, required bool) ([]map[string]any, error) {
+		raw, ok := data[key]
+		if !ok {
+			if !required {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("missing '%s' field", key)
+		}
+		list, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("'%s' must be a list", key)
+		}
+		out := make([]map[string]any, 0, len(list))
+		for i, it := range list {
+			obj, ok := it.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d]: entry must be object", key, i)
+			}
+			out = append(out, obj)
+		}
+		return out, nil
+	}
+
+	var p progressImport
+	var err error
+	if p.segments, err = getArr("saved_segments", true); err != nil {
+		return progressImport{}, err
+	}
+	if p.characters, err = getArr("saved_characters", true); err != nil {
+		return progressImport{}, err
+	}
+	if p.charSegmentLinks, err = getArr("character_segment_links", false); err != nil {
+		return progressImport{}, err
+	}
+	if p.srsState, err = getArr("srs_state", true); err != nil {
+		return progressImport{}, err
+	}
+	if p.lookups, err = getArr("vocab_lookups", true); err != nil {
+		return progressImport{}, err
+	}
+	if p.reviewLog, err = getArr("srs_review_log", false); err != nil {
+		return progressImport{}, err
+	}
+	if err := p.validate(); err != nil {
+		return progressImport{}, err
+	}
+	return p, nil
+}
+
+func (p progressImport) validate() error {
+	segmentIDs, err := uniqueEntries("saved_segments", p.segments, "headword", "pinyin")
+	if err != nil {
+		return err
+	}
+	characterIDs, err := uniqueEntries("saved_characters", p.characters, "character", "pinyin")
+	if err != nil {
+		return err
+	}
+	if _, err := uniqueEntries("character_segment_links", p.charSegmentLinks, "character_id", "segment", "segment_pinyin"); err != nil {
+		return err
+	}
+	for i, item := range p.charSegmentLinks {
+		characterID, _ := optionalString(item, "character_id")
+		if !characterIDs[characterID] {
+			return fmt.Errorf("character_segment_links[%d]: character_id %q does not match any saved_characters entry", i, characterID)
+		}
+	}
+	if _, err := uniqueEntries("srs_state", p.srsState); err != nil {
+		return err
+	}
+	if _, err := uniqueEntries("vocab_lookups", p.lookups, "looked_up_at"); err != nil {
+		return err
+	}
+	if _, err := uniqueEntries("srs_review_log", p.reviewLog, "reviewed_at"); err != nil {
+		return err
+	}
+	for i, item := range p.srsState {
+		if err := checkVocabReference(item, segmentIDs, characterIDs); err != nil {
+			return fmt.Errorf("srs_state[%d]: %w", i, err)
+		}
+	}
+	for i, item := range p.lookups {
+		if err := checkVocabReference(item, segmentIDs, characterIDs); err != nil {
+			return fmt.Errorf("vocab_lookups[%d]: %w", i, err)
+		}
+	}
+	for i, item := range p.reviewLog {
+		if err := checkVocabReference(item, segmentIDs, characterIDs); err != nil {
+			return fmt.Errorf("srs_review_log[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// uniqueEntries checks that every entry has a unique, non-empty string id
+// and returns the set of ids. The first of fields must be non-empty; when
+// more than one field is given, their combined values must also be unique,
+// mirroring the table's unique index.
+func uniqueEntries(key string, list []map[string]any, fields ...string) (map[string]bool, error) {
+	ids := make(map[string]bool, len(list))
+	keys := make(map[string]int, len(list))
+	for i, item := range list {
+		id, err := optionalString(item, "id")
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+		}
+		if id == "" {
+			return nil, fmt.Errorf("%s[%d]: missing 'id'", key, i)
+		}
+		if ids[id] {
+			return nil, fmt.Errorf("%s[%d]: duplicate id %q", key, i, id)
+		}
+		ids[id] = true
+
+		if len(fields) == 0 {
+			continue
+		}
+		values := make([]string, 0, len(fields))
+		for j, field := range fields {
+			value, err := optionalString(item, field)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+			}
+			if j == 0 && value == "" {
+				return nil, fmt.Errorf("%s[%d]: missing '%s'", key, i, field)
+			}
+			values = append(values, value)
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		combined := fmt.Sprintf("%q", values)
+		if first, ok := keys[combined]; ok {
+			return nil, fmt.Errorf("%s[%d]: duplicates %s[%d]", key, i, key, first)
+		}
+		keys[combined] = i
+	}
+	return ids, nil
+}
+
+// checkVocabReference checks that an SRS state or lookup entry points at
+// exactly one saved word or character present in the bundle.
+func checkVocabReference(item map[string]any, segmentIDs map[string]bool, characterIDs map[string]bool) error {
+	segmentID, err := optionalString(item, "segment_id")
+	if err != nil {
+		return err
+	}
+	characterID, err := optionalString(item, "character_id")
+	if err != nil {
+		return err
+	}
+	switch {
+	case segmentID != "" && characterID != "":
+		return fmt.Errorf("must set only one of 'segment_id' and 'character_id'")
+	case segmentID != "":
+		if !segmentIDs[segmentID] {
+			return fmt.Errorf("segment_id %q does not match any saved_segments entry", segmentID)
+		}
+	case characterID != "":
+		if !characterIDs[characterID] {
+			return fmt.Errorf("character_id %q does not match any saved_characters entry", characterID)
+		}
+	default:
+		return fmt.Errorf("missing 'segment_id' or 'character_id'")
+	}
+	return nil
+}
+
+// optionalString reads a string field, treating a missing field or null as
+// empty and rejecting any other type.
+func optionalString(item map[string]any, field string) (string, error) {
+	switch v := item[field].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("'%s' must be a string", field)
+	}
+}
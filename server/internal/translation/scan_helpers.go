@@ -70,6 +70,15 @@ func toInt(v any) int {
 	}
 }
 
+// nullableInt is toInt for optional columns: a missing or null value stays
+// NULL.
+func nullableInt(v any) any {
+	if v == nil {
+		return nil
+	}
+	return toInt(v)
+}
+
 func toFloat(v any) float64 {
 	switch x := v.(type) {
 	case float64:
@@ -0,0 +1,103 @@
+package translation
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/anath2/language-app/internal/pinyin"
+)
+
+const (
+	OpacityDecayLinear      = "linear"
+	OpacityDecayExponential = "exponential"
+)
+
+// Review queue orderings for GetSegmentReviewQueue.
+const (
+	ReviewOrderDue       = "due"
+	ReviewOrderFrequency = "frequency"
+)
+
+const (
+	defaultStrugglingThreshold = 3
+	defaultStrugglingWindow    = 7 * 24 * time.Hour
+	defaultOpacityHorizon      = 30 * 24 * time.Hour
+	defaultStrugglingFloor     = 0.3
+)
+
+// SRSConfig tunes the heuristics SRSStore uses when reporting segment SRS info.
+type SRSConfig struct {
+	// StrugglingThreshold is the number of lookups within StrugglingWindow at
+	// which a segment is flagged as struggling.
+	StrugglingThreshold int
+	StrugglingWindow    time.Duration
+	// OpacityDecay selects how a reviewed word fades since its last review:
+	// linear reaches 0 at OpacityHorizon, exponential uses OpacityHorizon as
+	// its time constant.
+	OpacityDecay   string
+	OpacityHorizon time.Duration
+	// StrugglingFloor is the minimum opacity kept for struggling words.
+	StrugglingFloor float64
+	// PinyinStyle is the form saved segment pinyin is normalized to
+	// (pinyin.StyleToneMarks or pinyin.StyleNumbered).
+	PinyinStyle string
+	// FrequencyRanks maps headword → frequency rank (1 = most common). Saved
+	// segments record their rank; nil leaves ranks unset.
+	FrequencyRanks map[string]int
+	// DayLocation sets where study streak days begin and end; nil means UTC.
+	DayLocation *time.Location
+	// HSKLevels maps headword → HSK level. Saved segments record their
+	// level; nil leaves levels unset.
+	HSKLevels map[string]int
+}
+
+func DefaultSRSConfig() SRSConfig {
+	return SRSConfig{
+		StrugglingThreshold: defaultStrugglingThreshold,
+		StrugglingWindow:    defaultStrugglingWindow,
+		OpacityDecay:        OpacityDecayLinear,
+		OpacityHorizon:      defaultOpacityHorizon,
+		StrugglingFloor:     defaultStrugglingFloor,
+		PinyinStyle:         pinyin.StyleToneMarks,
+	}
+}
+
+func (c SRSConfig) Validate() error {
+	if c.StrugglingThreshold < 1 {
+		return errors.New("struggling threshold must be at least 1")
+	}
+	if c.StrugglingWindow <= 0 {
+		return errors.New("struggling window must be a positive duration")
+	}
+	if c.OpacityDecay != OpacityDecayLinear && c.OpacityDecay != OpacityDecayExponential {
+		return errors.New("opacity decay must be linear or exponential")
+	}
+	if c.OpacityHorizon <= 0 {
+		return errors.New("opacity horizon must be a positive duration")
+	}
+	if c.StrugglingFloor < 0 || c.StrugglingFloor > 1 {
+		return errors.New("struggling floor must be between 0 and 1")
+	}
+	if !pinyin.IsValidStyle(c.PinyinStyle) {
+		return errors.New("pinyin style must be tone_marks or numbered")
+	}
+	return nil
+}
+
+// Opacity returns the highlight opacity for a word last reviewed elapsed ago,
+// clamped to [0,1]. Struggling words never fade below StrugglingFloor.
+func (c SRSConfig) Opacity(elapsed time.Duration, struggling bool) float64 {
+	ratio := elapsed.Hours() / c.OpacityHorizon.Hours()
+	var opacity float64
+	switch c.OpacityDecay {
+	case OpacityDecayExponential:
+		opacity = math.Exp(-ratio)
+	default:
+		opacity = 1 - ratio
+	}
+	if struggling && opacity < c.StrugglingFloor {
+		opacity = c.StrugglingFloor
+	}
+	return math.Min(1, math.Max(0, opacity))
+}
@@ -0,0 +1,56 @@
+package translation
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSRSConfigOpacity(t *testing.T) {
+	day := 24 * time.Hour
+	linear := DefaultSRSConfig()
+	exponential := DefaultSRSConfig()
+	exponential.OpacityDecay = OpacityDecayExponential
+
+	tests := []struct {
+		name       string
+		cfg        SRSConfig
+		elapsed    time.Duration
+		struggling bool
+		want       float64
+	}{
+		{name: "linear just reviewed", cfg: linear, elapsed: 0, want: 1},
+		{name: "linear halfway", cfg: linear, elapsed: 15 * day, want: 0.5},
+		{name: "linear past horizon clamps to zero", cfg: linear, elapsed: 60 * day, want: 0},
+		{name: "linear struggling floor", cfg: linear, elapsed: 60 * day, struggling: true, want: 0.3},
+		{name: "exponential at horizon", cfg: exponential, elapsed: 30 * day, want: math.Exp(-1)},
+		{name: "exponential struggling floor", cfg: exponential, elapsed: 90 * day, struggling: true, want: 0.3},
+		{name: "future review clamps to one", cfg: linear, elapsed: -day, want: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.cfg.Opacity(tc.elapsed, tc.struggling)
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("expected opacity %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSRSConfigValidateRejectsBadDecaySettings(t *testing.T) {
+	cfg := DefaultSRSConfig()
+	cfg.OpacityDecay = "cubic"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown decay mode to be rejected")
+	}
+	cfg = DefaultSRSConfig()
+	cfg.OpacityHorizon = 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected zero horizon to be rejected")
+	}
+	cfg = DefaultSRSConfig()
+	cfg.StrugglingFloor = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected floor above 1 to be rejected")
+	}
+}
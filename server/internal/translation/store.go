@@ -3,22 +3,87 @@ package translation
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"sync"
 )
 
 var ErrNotFound = errors.New("translation not found")
 
+// ErrReviewCardAccepted is returned when modifying a review card that has
+// already been saved to SRS.
+var ErrReviewCardAccepted = errors.New("review card already accepted")
+
+// ErrInvalidCredentials is returned when a username and password do not match
+// an account.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// ErrUsernameTaken is returned when creating a user whose username exists.
+var ErrUsernameTaken = errors.New("username already taken")
+
+// ErrInvalidTag is returned for a tag that is empty or longer than
+// MaxTagLength.
+var ErrInvalidTag = errors.New("tag must be 1-32 characters")
+
+// ErrInvalidFilter is returned by List and Neighbors for an unknown status
+// or an invalid tag in the ListFilter.
+var ErrInvalidFilter = errors.New("invalid list filter")
+
+// ErrInvalidTimezone is returned when saving a profile timezone that is not
+// in the tz database.
+var ErrInvalidTimezone = errors.New("timezone must be an IANA name such as Asia/Shanghai")
+
+// DefaultUserID owns data created before multi-user accounts and is the user
+// signed in by the shared APP_PASSWORD.
+const DefaultUserID = "default"
+
 type Translation struct {
-	ID              string
-	CreatedAt       string
-	Status          string
-	SourceType      string
-	InputText       string
+	ID         string
+	UserID     string
+	CreatedAt  string
+	Status     string
+	SourceType string
+	SourceLang string
+	TargetLang string
+	InputText  string
+	// RawInputText is the submitted text when NormalizeInputText changed
+	// it; InputText holds the normalized form.
+	RawInputText    *string
 	Title           string
 	FullTranslation *string
 	ErrorMessage    *string
 	Sentences       []SentenceResult
+	Metadata        map[string]string
 	Progress        int
 	Total           int
+	IsFavorite      bool
+	Tags            []string
+	// Chunk is set on the parts of input that was split because it was
+	// too long for one translation.
+	Chunk *ChunkInfo
+}
+
+// ChunkInfo places a translation within a chunked input: part Index (from
+// 1) of Count, all sharing GroupID.
+type ChunkInfo struct {
+	GroupID string
+	Index   int
+	Count   int
+}
+
+// ChunkPart summarises one part of a chunked input.
+type ChunkPart struct {
+	TranslationID string
+	Index         int
+	Status        string
+	Title         string
+}
+
+// ListFilter narrows List. Zero values do not filter; Tags must all be
+// present on a translation (AND semantics).
+type ListFilter struct {
+	Status       string
+	FavoriteOnly bool
+	Tags         []string
 }
 
 type CharTranslation struct {
@@ -26,10 +91,34 @@ type CharTranslation struct {
 	Pinyin string
 }
 
+// Segment sources record where a segment's pinyin and gloss came from.
+const (
+	SegmentSourceCedict   = "cedict"
+	SegmentSourceLLM      = "llm"
+	SegmentSourceFallback = "fallback"
+	// SegmentSourceUser marks a segment glossed from the learner's custom
+	// dictionary.
+	SegmentSourceUser = "user"
+)
+
 type SegmentResult struct {
 	Segment string `json:"segment"`
 	Pinyin  string `json:"pinyin"`
 	English string `json:"english"`
+	// Source is one of the SegmentSource* values; empty for segments that
+	// were never translated (punctuation, untranslated legacy rows).
+	Source string `json:"source,omitempty"`
+	// POS is one of the POS* values or empty.
+	POS string `json:"pos,omitempty"`
+	// Classifier is the measure word CEDICT lists for a noun, e.g. 本 for
+	// 书; empty otherwise.
+	Classifier string `json:"classifier,omitempty"`
+	// Note is the learner's own note on the segment, filled in only when a
+	// translation is loaded.
+	Note string `json:"note,omitempty"`
+	// HSKLevel is the segment's HSK level when an HSK wordlist is configured
+	// and lists it; it is filled in when the segment is served, not stored.
+	HSKLevel *int `json:"hsk_level,omitempty"`
 }
 
 type SentenceResult struct {
@@ -39,19 +128,45 @@ type SentenceResult struct {
 }
 
 // SentenceInit carries formatting metadata for a sentence when creating sentence rows.
+// Text is hashed so later edits of the input can tell the sentence is unchanged.
 type SentenceInit struct {
 	Indent    string
 	Separator string
+	Text      string
+}
+
+// SegmentNote is a learner's note on one segment of a translation.
+type SegmentNote struct {
+	TranslationID string
+	SentenceIdx   int
+	SegIdx        int
+	Segment       string
+	Note          string
+	CreatedAt     string
+	UpdatedAt     string
 }
 
 type SegmentProgressEntry struct {
 	Segment       string
 	Pinyin        string
 	English       string
+	Source        string
+	POS           string
+	Classifier    string
 	Index         int
 	SentenceIndex int
 }
 
+// Job priorities order the pending queue: higher values are claimed first.
+// Create and the other constructors that take no priority use
+// JobPriorityNormal; the API queues its requests at JobPriorityHigh unless
+// the caller asks for another.
+const (
+	JobPriorityLow    = -10
+	JobPriorityNormal = 0
+	JobPriorityHigh   = 10
+)
+
 const (
 	ChatRoleUser = "user"
 	ChatRoleAI   = "ai"
@@ -84,16 +199,24 @@ type ChatReviewCard struct {
 	Status      string `json:"status"` // "pending" | "accepted"
 }
 
+// ChatGrammarNote is the structured result of the chat explain_grammar tool.
+type ChatGrammarNote struct {
+	Point       string   `json:"point"`
+	Explanation string   `json:"explanation"`
+	Examples    []string `json:"examples"`
+}
+
 type ChatMessage struct {
-	ID            string          `json:"id"`
-	ChatID        string          `json:"chat_id"`
-	TranslationID string          `json:"translation_id"`
-	MessageIdx    int             `json:"message_idx"`
-	Role          string          `json:"role"`
-	Content       string          `json:"content"`
-	SelectedText  *string         `json:"selected_text,omitempty"`
-	CreatedAt     string          `json:"created_at"`
-	ReviewCard    *ChatReviewCard `json:"review_card,omitempty"`
+	ID            string           `json:"id"`
+	ChatID        string           `json:"chat_id"`
+	TranslationID string           `json:"translation_id"`
+	MessageIdx    int              `json:"message_idx"`
+	Role          string           `json:"role"`
+	Content       string           `json:"content"`
+	SelectedText  *string          `json:"selected_text,omitempty"`
+	CreatedAt     string           `json:"created_at"`
+	ReviewCard    *ChatReviewCard  `json:"review_card,omitempty"`
+	GrammarNote   *ChatGrammarNote `json:"grammar_note,omitempty"`
 }
 
 type SegmentRecord struct {
@@ -104,6 +227,12 @@ type SegmentRecord struct {
 	Status   string
 }
 
+// RecentLookup is a saved word with the time it was last looked up.
+type RecentLookup struct {
+	SegmentRecord
+	LookedUpAt string
+}
+
 type SegmentSRSInfo struct {
 	SegmentID    string
 	Headword     string
@@ -116,12 +245,39 @@ type SegmentSRSInfo struct {
 	NextDueAt    *string
 }
 
+// SegmentReviewCard.Examples are sentences containing the headword from the
+// learner's own translations, other than the stored snippet.
 type SegmentReviewCard struct {
+	SegmentID     string
+	Headword      string
+	Pinyin        string
+	English       string
+	Snippets      []string
+	Examples      []string
+	FrequencyRank *int
+	HSKLevel      *int
+}
+
+// ClozeCard is a review prompt that hides a saved word in a sentence the
+// learner has seen. Sentence holds ClozeBlank wherever the headword appeared
+// and Answer is the headword.
+type ClozeCard struct {
 	SegmentID string
-	Headword  string
 	Pinyin    string
 	English   string
-	Snippets  []string
+	Sentence  string
+	Answer    string
+}
+
+// DifficultyBreakdown classifies a translation's words against the learner's
+// saved vocab. Score runs from 0 (every word known) to 1 (every word
+// unknown); learning words count half.
+type DifficultyBreakdown struct {
+	TotalWords    int
+	UnknownWords  int
+	LearningWords int
+	KnownWords    int
+	Score         float64
 }
 
 type ReviewAnswerResult struct {
@@ -132,6 +288,31 @@ type ReviewAnswerResult struct {
 	RemainingDue int
 }
 
+// SRSStats summarizes review activity over a trailing window of days.
+type SRSStats struct {
+	Days              int
+	TotalReviews      int
+	RetentionRate     float64
+	ReviewsPerDay     []DailyReviewCount
+	StatusCounts      map[string]int
+	IntervalHistogram []IntervalBucket
+}
+
+type DailyReviewCount struct {
+	Date   string
+	Count  int
+	Passed int
+}
+
+// IntervalBucket counts SRS items whose current interval falls in [MinDays, MaxDays).
+// MaxDays is nil for the open-ended top bucket.
+type IntervalBucket struct {
+	Label   string
+	MinDays float64
+	MaxDays *float64
+	Count   int
+}
+
 type CharacterReviewCard struct {
 	CharacterID     string
 	Character       string
@@ -147,14 +328,56 @@ type CharacterExampleSegment struct {
 	SegmentTranslation string
 }
 
+// UserProfile.Timezone is an IANA name; empty falls back to the server's
+// study timezone.
 type UserProfile struct {
 	Name      string
 	Email     string
 	Language  string
+	Timezone  string
 	CreatedAt string
 	UpdatedAt string
 }
 
+type User struct {
+	ID        string
+	Username  string
+	CreatedAt string
+}
+
+// Session is an issued login session. LastSeenAt is refreshed at most once
+// per SessionTouchInterval.
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	CreatedAt  string
+	LastSeenAt string
+}
+
+// IntegrityReport lists problems found by SQLite's integrity and foreign key
+// checks; both slices are empty for a healthy database.
+type IntegrityReport struct {
+	IntegrityErrors      []string
+	ForeignKeyViolations []ForeignKeyViolation
+}
+
+// ForeignKeyViolation is a row whose reference has no parent row. RowID is
+// nil for tables without rowids.
+type ForeignKeyViolation struct {
+	Table  string
+	RowID  *int64
+	Parent string
+}
+
+// WALCheckpoint reports a wal_checkpoint run: Busy is true when a reader or
+// writer prevented it from completing.
+type WALCheckpoint struct {
+	Busy               bool
+	LogFrames          int
+	CheckpointedFrames int
+}
+
 type DB struct {
 	Conn *sql.DB
 }
@@ -168,13 +391,41 @@ type ChatStore struct {
 }
 
 type SRSStore struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg SRSConfig
 }
 
 type ProfileStore struct {
 	db *sql.DB
 }
 
+type UserStore struct {
+	db *sql.DB
+}
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+type MaintenanceStore struct {
+	db *sql.DB
+}
+
+// SegmentCacheStore keeps segment translations for reuse across sentences
+// and translations.
+type SegmentCacheStore struct {
+	db *sql.DB
+}
+
+// BackupStore writes snapshots of the database into dir, keeping the newest
+// retention of them.
+type BackupStore struct {
+	db        *sql.DB
+	dir       string
+	retention int
+	mu        sync.Mutex
+}
+
 func NewTranslationStore(db *DB) *TranslationStore {
 	return &TranslationStore{db: db.Conn}
 }
@@ -184,9 +435,61 @@ func NewChatStore(db *DB) *ChatStore {
 }
 
 func NewSRSStore(db *DB) *SRSStore {
-	return &SRSStore{db: db.Conn}
+	return &SRSStore{db: db.Conn, cfg: DefaultSRSConfig()}
+}
+
+func NewSRSStoreWithConfig(db *DB, cfg SRSConfig) (*SRSStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid srs config: %w", err)
+	}
+	return &SRSStore{db: db.Conn, cfg: cfg}, nil
 }
 
 func NewProfileStore(db *DB) *ProfileStore {
 	return &ProfileStore{db: db.Conn}
 }
+
+func NewUserStore(db *DB) *UserStore {
+	return &UserStore{db: db.Conn}
+}
+
+func NewSessionStore(db *DB) *SessionStore {
+	return &SessionStore{db: db.Conn}
+}
+
+func NewMaintenanceStore(db *DB) *MaintenanceStore {
+	return &MaintenanceStore{db: db.Conn}
+}
+
+func NewSegmentCacheStore(db *DB) *SegmentCacheStore {
+	return &SegmentCacheStore{db: db.Conn}
+}
+
+// NewBackupStore keeps DefaultBackupRetention snapshots when retention is not
+// positive.
+func NewBackupStore(db *DB, dir string, retention int) *BackupStore {
+	if retention <= 0 {
+		retention = DefaultBackupRetention
+	}
+	return &BackupStore{db: db.Conn, dir: dir, retention: retention}
+}
+
+// JobFailure is the error recorded for a translation's job. Detail is the
+// full error; ErrorMessage is the short form shown with the translation.
+// Detail is empty unless the job is failed.
+type JobFailure struct {
+	TranslationID string
+	Status        string
+	ErrorMessage  string
+	Detail        string
+	Attempts      int
+	UpdatedAt     string
+}
+
+// JobStateCounts summarises the translation_jobs table. ExpiredLeases counts
+// leased jobs whose lease_until has passed; the background scanner restarts
+// them, so a number that stays high points at a stuck worker.
+type JobStateCounts struct {
+	ByState       map[string]int
+	ExpiredLeases int
+}
@@ -0,0 +1,99 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBackupRetention is how many snapshots are kept when unset.
+const DefaultBackupRetention = 7
+
+const (
+	backupFilePrefix = "language_app-"
+	backupFileSuffix = ".db"
+	// backupTimeFormat sorts lexically in creation order.
+	backupTimeFormat = "20060102T150405.000000000Z"
+)
+
+// Backup writes a snapshot of the database to a timestamped file, returning
+// its path, and prunes snapshots beyond the retention count. VACUUM INTO reads inside a single
+// transaction, so the snapshot is consistent while the server keeps writing.
+func (s *BackupStore) Backup(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+	path := filepath.Join(s.dir, backupFilePrefix+time.Now().UTC().Format(backupTimeFormat)+backupFileSuffix)
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return "", fmt.Errorf("write backup: %w", err)
+	}
+	// The snapshot is already written, so a failed prune is only logged.
+	if err := s.prune(); err != nil {
+		log.Printf("prune database backups: %v", err)
+	}
+	return path, nil
+}
+
+// ListBackups returns the paths of existing snapshots, oldest first.
+func (s *BackupStore) ListBackups() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			paths = append(paths, filepath.Join(s.dir, name))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (s *BackupStore) prune() error {
+	paths, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+	for len(paths) > s.retention {
+		if err := os.Remove(paths[0]); err != nil {
+			return err
+		}
+		paths = paths[1:]
+	}
+	return nil
+}
+
+// StartPeriodic takes a backup every interval until ctx is cancelled.
+// Failures are logged and retried on the next tick.
+func (s *BackupStore) StartPeriodic(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				path, err := s.Backup(ctx)
+				if err != nil {
+					log.Printf("database backup failed: %v", err)
+					continue
+				}
+				log.Printf("database backup written: path=%s", path)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package translation
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupWritesSnapshotsAndPrunes(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	if _, err := store.Create("你好", "text"); err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	backups := &BackupStore{db: store.db, dir: filepath.Join(t.TempDir(), "backups"), retention: 2}
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path, err := backups.Backup(context.Background())
+		if err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+		paths = append(paths, path)
+	}
+
+	kept, err := backups.ListBackups()
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+	if len(kept) != 2 || kept[0] != paths[1] || kept[1] != paths[2] {
+		t.Fatalf("expected the two newest backups %v, got %v", paths[1:], kept)
+	}
+
+	db, err := NewDB(paths[2])
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	defer db.Conn.Close()
+	var count int
+	if err := db.Conn.QueryRow(`SELECT COUNT(*) FROM translations`).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("expected backup to contain 1 translation, got %d (err=%v)", count, err)
+	}
+}
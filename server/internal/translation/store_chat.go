@@ -81,6 +81,51 @@ func (s *ChatStore) ClearChatMessages(translationID string) error {
 	return fmt.Errorf("clear chat messages: database remained locked")
 }
 
+// UpdateChatMessageContent replaces the content of a user message. AI and
+// tool messages are never edited and report ErrNotFound like a missing id.
+func (s *ChatStore) UpdateChatMessageContent(translationID string, messageID string, content string) error {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return errors.New("content is required")
+	}
+	res, err := s.db.Exec(
+		`UPDATE translation_chat_messages SET content = ?
+		 WHERE id = ? AND translation_id = ? AND role = ?`,
+		content,
+		messageID,
+		translationID,
+		ChatRoleUser,
+	)
+	if err != nil {
+		return fmt.Errorf("update chat message content: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update chat message content rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteChatMessagesAfter drops every message that follows messageIdx in the
+// translation's chat, so an edited turn can be resent on a coherent thread.
+func (s *ChatStore) DeleteChatMessagesAfter(translationID string, messageIdx int) error {
+	for i := 0; i < 8; i++ {
+		err := s.deleteChatMessagesAfterOnce(translationID, messageIdx)
+		if err == nil {
+			return nil
+		}
+		if isDBLocked(err) {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("delete chat messages after: database remained locked")
+}
+
 func (s *ChatStore) ensureChatForTranslationOnce(translationID string) (ChatThread, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -225,7 +270,7 @@ func (s *ChatStore) listChatMessagesOnce(translationID string) ([]ChatMessage, e
 		return nil, err
 	}
 	rows, err := s.db.Query(
-		`SELECT id, message_idx, role, content, selected_text, created_at, review_card_json
+		`SELECT id, message_idx, role, content, selected_text, created_at, review_card_json, grammar_note_json
 		 FROM translation_chat_messages
 		 WHERE translation_id = ?
 		 ORDER BY message_idx ASC`,
@@ -241,7 +286,8 @@ func (s *ChatStore) listChatMessagesOnce(translationID string) ([]ChatMessage, e
 		var msg ChatMessage
 		var selectedText sql.NullString
 		var reviewCardJSON sql.NullString
-		if err := rows.Scan(&msg.ID, &msg.MessageIdx, &msg.Role, &msg.Content, &selectedText, &msg.CreatedAt, &reviewCardJSON); err != nil {
+		var grammarNoteJSON sql.NullString
+		if err := rows.Scan(&msg.ID, &msg.MessageIdx, &msg.Role, &msg.Content, &selectedText, &msg.CreatedAt, &reviewCardJSON, &grammarNoteJSON); err != nil {
 			return nil, fmt.Errorf("scan chat message: %w", err)
 		}
 		msg.ChatID = thread.ID
@@ -256,6 +302,13 @@ func (s *ChatStore) listChatMessagesOnce(translationID string) ([]ChatMessage, e
 			}
 			msg.ReviewCard = &card
 		}
+		if grammarNoteJSON.Valid {
+			var note ChatGrammarNote
+			if err := json.Unmarshal([]byte(grammarNoteJSON.String), &note); err != nil {
+				return nil, fmt.Errorf("decode grammar note json: %w", err)
+			}
+			msg.GrammarNote = &note
+		}
 		out = append(out, msg)
 	}
 	if err := rows.Err(); err != nil {
@@ -264,7 +317,8 @@ func (s *ChatStore) listChatMessagesOnce(translationID string) ([]ChatMessage, e
 	return out, nil
 }
 
-func (s *ChatStore) SetReviewCard(messageID, chineseText, pinyin, english string) error {
+// SetReviewCard stores a pending review card on a message of translationID.
+func (s *ChatStore) SetReviewCard(translationID, messageID, chineseText, pinyin, english string) error {
 	card := ChatReviewCard{
 		ChineseText: chineseText,
 		Pinyin:      pinyin,
@@ -276,9 +330,10 @@ func (s *ChatStore) SetReviewCard(messageID, chineseText, pinyin, english string
 		return fmt.Errorf("marshal review card: %w", err)
 	}
 	res, err := s.db.Exec(
-		`UPDATE translation_chat_messages SET review_card_json = ? WHERE id = ?`,
+		`UPDATE translation_chat_messages SET review_card_json = ? WHERE id = ? AND translation_id = ?`,
 		string(cardJSON),
 		messageID,
+		translationID,
 	)
 	if err != nil {
 		return fmt.Errorf("set review card: %w", err)
@@ -290,11 +345,39 @@ func (s *ChatStore) SetReviewCard(messageID, chineseText, pinyin, english string
 	return nil
 }
 
-func (s *ChatStore) GetMessageReviewCard(messageID string) (*ChatReviewCard, error) {
+// SetGrammarNote stores an explain_grammar result on its tool message.
+func (s *ChatStore) SetGrammarNote(messageID string, note ChatGrammarNote) error {
+	if note.Examples == nil {
+		note.Examples = []string{}
+	}
+	noteJSON, err := json.Marshal(note)
+	if err != nil {
+		return fmt.Errorf("marshal grammar note: %w", err)
+	}
+	res, err := s.db.Exec(
+		`UPDATE translation_chat_messages SET grammar_note_json = ? WHERE id = ?`,
+		string(noteJSON),
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("set grammar note: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetMessageReviewCard returns the review card on a message of
+// translationID, or nil when the message has none. Messages of other
+// translations report ErrNotFound.
+func (s *ChatStore) GetMessageReviewCard(translationID, messageID string) (*ChatReviewCard, error) {
 	var reviewCardJSON sql.NullString
 	err := s.db.QueryRow(
-		`SELECT review_card_json FROM translation_chat_messages WHERE id = ?`,
+		`SELECT review_card_json FROM translation_chat_messages WHERE id = ? AND translation_id = ?`,
 		messageID,
+		translationID,
 	).Scan(&reviewCardJSON)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
@@ -312,8 +395,8 @@ func (s *ChatStore) GetMessageReviewCard(messageID string) (*ChatReviewCard, err
 	return &card, nil
 }
 
-func (s *ChatStore) AcceptMessageReviewCard(messageID string) error {
-	card, err := s.GetMessageReviewCard(messageID)
+func (s *ChatStore) AcceptMessageReviewCard(translationID, messageID string) error {
+	card, err := s.GetMessageReviewCard(translationID, messageID)
 	if err != nil {
 		return err
 	}
@@ -326,21 +409,75 @@ func (s *ChatStore) AcceptMessageReviewCard(messageID string) error {
 		return fmt.Errorf("marshal accepted review card: %w", err)
 	}
 	_, err = s.db.Exec(
-		`UPDATE translation_chat_messages SET review_card_json = ? WHERE id = ?`,
+		`UPDATE translation_chat_messages SET review_card_json = ? WHERE id = ? AND translation_id = ?`,
 		string(cardJSON),
 		messageID,
+		translationID,
 	)
 	return err
 }
 
-func (s *ChatStore) RejectMessageReviewCard(messageID string) error {
+// UpdateMessageReviewCard rewrites the fields of a pending review card. The card
+// stays pending; accepted cards are immutable and return ErrReviewCardAccepted.
+func (s *ChatStore) UpdateMessageReviewCard(translationID, messageID, chineseText, pinyin, english string) error {
+	chineseText = strings.TrimSpace(chineseText)
+	if chineseText == "" {
+		return errors.New("chinese_text is required")
+	}
+	card, err := s.GetMessageReviewCard(translationID, messageID)
+	if err != nil {
+		return err
+	}
+	if card == nil {
+		return ErrNotFound
+	}
+	if card.Status == "accepted" {
+		return ErrReviewCardAccepted
+	}
+	card.ChineseText = chineseText
+	card.Pinyin = strings.TrimSpace(pinyin)
+	card.English = strings.TrimSpace(english)
+	cardJSON, err := json.Marshal(card)
+	if err != nil {
+		return fmt.Errorf("marshal updated review card: %w", err)
+	}
+	// Guard on the stored status so a concurrent accept is not overwritten.
+	res, err := s.db.Exec(
+		`UPDATE translation_chat_messages SET review_card_json = ?
+		 WHERE id = ? AND translation_id = ? AND json_extract(review_card_json, '$.status') = 'pending'`,
+		string(cardJSON),
+		messageID,
+		translationID,
+	)
+	if err != nil {
+		return fmt.Errorf("update review card: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update review card rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrReviewCardAccepted
+	}
+	return nil
+}
+
+func (s *ChatStore) RejectMessageReviewCard(translationID, messageID string) error {
 	// Null the card only. The tool message itself is not rendered when review_card_json is NULL,
 	// so no content update is needed (unlike when cards lived on the AI text message).
-	_, err := s.db.Exec(
-		`UPDATE translation_chat_messages SET review_card_json = NULL WHERE id = ?`,
+	res, err := s.db.Exec(
+		`UPDATE translation_chat_messages SET review_card_json = NULL WHERE id = ? AND translation_id = ?`,
 		messageID,
+		translationID,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("reject review card: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
 func (s *ChatStore) clearChatMessagesOnce(translationID string) error {
@@ -373,6 +510,40 @@ func (s *ChatStore) clearChatMessagesOnce(translationID string) error {
 	return nil
 }
 
+func (s *ChatStore) deleteChatMessagesAfterOnce(translationID string, messageIdx int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin delete chat messages tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	thread, err := loadChatThreadTx(tx, translationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if _, err := tx.Exec(
+		`DELETE FROM translation_chat_messages WHERE translation_id = ? AND message_idx > ?`,
+		translationID,
+		messageIdx,
+	); err != nil {
+		return fmt.Errorf("delete chat messages after %d: %w", messageIdx, err)
+	}
+	if _, err := tx.Exec(
+		`UPDATE translation_chats SET updated_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		thread.ID,
+	); err != nil {
+		return fmt.Errorf("touch chat updated_at on truncate: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete chat messages tx: %w", err)
+	}
+	return nil
+}
+
 func loadChatThreadTx(tx *sql.Tx, translationID string) (ChatThread, error) {
 	var thread ChatThread
 	row := tx.QueryRow(
@@ -85,3 +85,119 @@ func TestClearChatMessages(t *testing.T) {
 		t.Fatalf("expected no messages after clear, got %d", len(msgs))
 	}
 }
+
+func TestDeleteChatMessagesAfterKeepsEarlierTurns(t *testing.T) {
+	ts, cs := newChatStoreWithMigrations(t)
+	tr, err := ts.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	var kept ChatMessage
+	for i, content := range []string{"first", "reply", "second", "reply again"} {
+		role := ChatRoleUser
+		if i%2 == 1 {
+			role = ChatRoleAI
+		}
+		msg, err := cs.AppendChatMessage(tr.ID, role, content, "")
+		if err != nil {
+			t.Fatalf("append message %d: %v", i, err)
+		}
+		if i == 0 {
+			kept = msg
+		}
+	}
+
+	if err := cs.DeleteChatMessagesAfter(tr.ID, kept.MessageIdx); err != nil {
+		t.Fatalf("delete chat messages after: %v", err)
+	}
+	msgs, err := cs.ListChatMessages(tr.ID)
+	if err != nil {
+		t.Fatalf("list after truncate: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != kept.ID {
+		t.Fatalf("expected only the first message to remain, got %#v", msgs)
+	}
+
+	next, err := cs.AppendChatMessage(tr.ID, ChatRoleAI, "new reply", "")
+	if err != nil {
+		t.Fatalf("append after truncate: %v", err)
+	}
+	if next.MessageIdx != kept.MessageIdx+1 {
+		t.Fatalf("expected the next message to follow the kept one, got idx %d", next.MessageIdx)
+	}
+	if err := cs.UpdateChatMessageContent(tr.ID, next.ID, "edited"); err != ErrNotFound {
+		t.Fatalf("expected editing an ai message to report ErrNotFound, got %v", err)
+	}
+}
+
+func TestUpdateMessageReviewCardKeepsPendingAndRejectsAccepted(t *testing.T) {
+	ts, cs := newChatStoreWithMigrations(t)
+	tr, err := ts.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	msg, err := cs.AppendChatMessage(tr.ID, ChatRoleTool, "你好吗？", "")
+	if err != nil {
+		t.Fatalf("append tool message: %v", err)
+	}
+	if err := cs.SetReviewCard(tr.ID, msg.ID, "你好吗？", "ni hao ma", "How are you?"); err != nil {
+		t.Fatalf("set review card: %v", err)
+	}
+
+	if err := cs.UpdateMessageReviewCard(tr.ID, msg.ID, "你好吗？", "nǐ hǎo ma?", "How are you doing?"); err != nil {
+		t.Fatalf("update review card: %v", err)
+	}
+	card, err := cs.GetMessageReviewCard(tr.ID, msg.ID)
+	if err != nil {
+		t.Fatalf("get review card: %v", err)
+	}
+	if card.Pinyin != "nǐ hǎo ma?" || card.English != "How are you doing?" || card.Status != "pending" {
+		t.Fatalf("unexpected card after update: %+v", card)
+	}
+
+	if err := cs.AcceptMessageReviewCard(tr.ID, msg.ID); err != nil {
+		t.Fatalf("accept review card: %v", err)
+	}
+	if err := cs.UpdateMessageReviewCard(tr.ID, msg.ID, "你好", "nǐ hǎo", "hello"); err != ErrReviewCardAccepted {
+		t.Fatalf("expected ErrReviewCardAccepted, got %v", err)
+	}
+}
+
+func TestReviewCardMethodsAreScopedToTheTranslation(t *testing.T) {
+	ts, cs := newChatStoreWithMigrations(t)
+	tr, err := ts.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	other, err := ts.Create("再见", "text")
+	if err != nil {
+		t.Fatalf("create other translation: %v", err)
+	}
+	msg, err := cs.AppendChatMessage(tr.ID, ChatRoleTool, "你好吗？", "")
+	if err != nil {
+		t.Fatalf("append tool message: %v", err)
+	}
+	if err := cs.SetReviewCard(other.ID, msg.ID, "你好吗？", "nǐ hǎo ma", "How are you?"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound setting a card through another translation, got %v", err)
+	}
+	if err := cs.SetReviewCard(tr.ID, msg.ID, "你好吗？", "nǐ hǎo ma", "How are you?"); err != nil {
+		t.Fatalf("set review card: %v", err)
+	}
+
+	if _, err := cs.GetMessageReviewCard(other.ID, msg.ID); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound reading through another translation, got %v", err)
+	}
+	if err := cs.UpdateMessageReviewCard(other.ID, msg.ID, "你好", "nǐ hǎo", "hello"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound updating through another translation, got %v", err)
+	}
+	if err := cs.AcceptMessageReviewCard(other.ID, msg.ID); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound accepting through another translation, got %v", err)
+	}
+	if err := cs.RejectMessageReviewCard(other.ID, msg.ID); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound rejecting through another translation, got %v", err)
+	}
+	card, err := cs.GetMessageReviewCard(tr.ID, msg.ID)
+	if err != nil || card == nil || card.Status != "pending" || card.Pinyin != "nǐ hǎo ma" {
+		t.Fatalf("expected the card untouched, got %+v, %v", card, err)
+	}
+}
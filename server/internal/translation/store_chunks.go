@@ -0,0 +1,129 @@
+package translation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreateChunkedForUser creates one translation per chunk, linked as the
+// parts of one group. Only the first part's job is pending; each later part
+// waits until ReleaseNextChunk hands it to the queue, so a long input is
+// translated one part at a time. Every part's job is queued at priority.
+func (s *TranslationStore) CreateChunkedForUser(userID string, chunks []string, sourceType string, lang LanguagePair, metadata map[string]string, priority int) ([]Translation, error) {
+	if len(chunks) == 0 {
+		return nil, errors.New("input_text is required")
+	}
+	groupID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]Translation, 0, len(chunks))
+	encoded := make([][]byte, 0, len(chunks))
+	for i, chunk := range chunks {
+		tr, metadataJSON, err := newTranslation(userID, chunk, sourceType, lang, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("part %d: %w", i+1, err)
+		}
+		tr.Chunk = &ChunkInfo{GroupID: groupID, Index: i + 1, Count: len(chunks)}
+		parts = append(parts, tr)
+		encoded = append(encoded, metadataJSON)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin create chunked translation tx: %w", err)
+	}
+	defer tx.Rollback()
+	for i, tr := range parts {
+		jobState := "waiting"
+		if i == 0 {
+			jobState = "pending"
+		}
+		if err := insertTranslation(tx, tr, encoded[i], "", jobState, priority); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit create chunked translation tx: %w", err)
+	}
+	return parts, nil
+}
+
+// ListChunks returns the parts of a chunked input in order.
+func (s *TranslationStore) ListChunks(groupID string) ([]ChunkPart, error) {
+	rows, err := s.db.Query(
+		`SELECT id, chunk_index, status, title FROM translations
+		 WHERE chunk_group_id = ?
+		 ORDER BY chunk_index ASC`,
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var parts []ChunkPart
+	for rows.Next() {
+		var part ChunkPart
+		if err := rows.Scan(&part.TranslationID, &part.Index, &part.Status, &part.Title); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		parts = append(parts, part)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate chunks: %w", err)
+	}
+	if len(parts) == 0 {
+		return nil, ErrNotFound
+	}
+	return parts, nil
+}
+
+// ReleaseNextChunk moves the part after translationID from waiting to
+// pending and returns its id. It reports false when translationID is not
+// chunked, is the last part, or the next part was already released.
+func (s *TranslationStore) ReleaseNextChunk(translationID string) (string, bool, error) {
+	var groupID sql.NullString
+	var index sql.NullInt64
+	err := s.db.QueryRow(
+		`SELECT chunk_group_id, chunk_index FROM translations WHERE id = ?`,
+		translationID,
+	).Scan(&groupID, &index)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, ErrNotFound
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("load chunk position: %w", err)
+	}
+	if !groupID.Valid {
+		return "", false, nil
+	}
+
+	var nextID string
+	err = s.db.QueryRow(
+		`SELECT id FROM translations WHERE chunk_group_id = ? AND chunk_index = ?`,
+		groupID.String, index.Int64+1,
+	).Scan(&nextID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("load next chunk: %w", err)
+	}
+
+	res, err := s.db.Exec(
+		`UPDATE translation_jobs SET state = 'pending', updated_at = ?
+		 WHERE translation_id = ? AND state = 'waiting'`,
+		time.Now().UTC().Format(time.RFC3339Nano), nextID,
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("release next chunk: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "", false, fmt.Errorf("release next chunk rows affected: %w", err)
+	}
+	return nextID, affected > 0, nil
+}
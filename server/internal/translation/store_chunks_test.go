@@ -0,0 +1,78 @@
+package translation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateChunkedReleasesPartsInOrder(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	parts, err := store.CreateChunkedForUser(DefaultUserID, []string{"第一句。", "第二句。", "第三句。"}, "text", LanguagePair{}, nil, JobPriorityNormal)
+	if err != nil {
+		t.Fatalf("create chunked translation: %v", err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	groupID := parts[0].Chunk.GroupID
+	for i, part := range parts {
+		if part.Chunk == nil || part.Chunk.GroupID != groupID || part.Chunk.Index != i+1 || part.Chunk.Count != 3 {
+			t.Fatalf("unexpected chunk info for part %d: %+v", i+1, part.Chunk)
+		}
+	}
+
+	got, ok := store.Get(parts[1].ID)
+	if !ok || got.Chunk == nil || *got.Chunk != *parts[1].Chunk {
+		t.Fatalf("expected chunk info to round-trip, got %+v", got.Chunk)
+	}
+
+	// Only the first part can be claimed until it releases the next.
+	ids, err := store.ListRestartableTranslationIDs()
+	if err != nil {
+		t.Fatalf("list restartable: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != parts[0].ID {
+		t.Fatalf("expected only the first part to be pending, got %v", ids)
+	}
+	if claimed, err := store.ClaimTranslationJob(parts[1].ID, time.Minute); err != nil || claimed {
+		t.Fatalf("expected a waiting part not to be claimable, got claimed=%v err=%v", claimed, err)
+	}
+
+	nextID, released, err := store.ReleaseNextChunk(parts[0].ID)
+	if err != nil || !released || nextID != parts[1].ID {
+		t.Fatalf("expected part 2 to be released, got %q released=%v err=%v", nextID, released, err)
+	}
+	if _, released, err := store.ReleaseNextChunk(parts[0].ID); err != nil || released {
+		t.Fatalf("expected a second release to be a no-op, got released=%v err=%v", released, err)
+	}
+	if _, released, err := store.ReleaseNextChunk(parts[2].ID); err != nil || released {
+		t.Fatalf("expected the last part to release nothing, got released=%v err=%v", released, err)
+	}
+
+	listed, err := store.ListChunks(groupID)
+	if err != nil {
+		t.Fatalf("list chunks: %v", err)
+	}
+	if len(listed) != 3 || listed[0].TranslationID != parts[0].ID || listed[2].Index != 3 {
+		t.Fatalf("unexpected chunk list: %+v", listed)
+	}
+	if _, err := store.ListChunks("missing"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for an unknown group, got %v", err)
+	}
+}
+
+func TestReleaseNextChunkIgnoresUnchunkedTranslations(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	item, err := store.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if got, _ := store.Get(item.ID); got.Chunk != nil {
+		t.Fatalf("expected no chunk info, got %+v", got.Chunk)
+	}
+	if _, released, err := store.ReleaseNextChunk(item.ID); err != nil || released {
+		t.Fatalf("expected nothing to release, got released=%v err=%v", released, err)
+	}
+}
@@ -0,0 +1,147 @@
+package translation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CustomDictionaryEntry is a learner's own reading and gloss for a headword.
+// An empty Pinyin keeps the reading the segment would otherwise get.
+type CustomDictionaryEntry struct {
+	Headword  string
+	Pinyin    string
+	English   string
+	CreatedAt string
+	UpdatedAt string
+}
+
+// ListCustomDictionary returns userID's custom entries ordered by headword.
+func (s *TranslationStore) ListCustomDictionary(userID string) ([]CustomDictionaryEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT headword, pinyin, english, created_at, updated_at FROM custom_dictionary
+		 WHERE user_id = ? ORDER BY headword ASC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list custom dictionary: %w", err)
+	}
+	defer rows.Close()
+	entries := make([]CustomDictionaryEntry, 0)
+	for rows.Next() {
+		var entry CustomDictionaryEntry
+		if err := rows.Scan(&entry.Headword, &entry.Pinyin, &entry.English, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan custom dictionary entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate custom dictionary: %w", err)
+	}
+	return entries, nil
+}
+
+// GetCustomDictionaryEntry returns userID's entry for headword, or
+// ErrNotFound when there is none.
+func (s *TranslationStore) GetCustomDictionaryEntry(userID string, headword string) (CustomDictionaryEntry, error) {
+	entry := CustomDictionaryEntry{Headword: headword}
+	err := s.db.QueryRow(
+		`SELECT pinyin, english, created_at, updated_at FROM custom_dictionary
+		 WHERE user_id = ? AND headword = ?`,
+		userID, headword,
+	).Scan(&entry.Pinyin, &entry.English, &entry.CreatedAt, &entry.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CustomDictionaryEntry{}, ErrNotFound
+	}
+	if err != nil {
+		return CustomDictionaryEntry{}, fmt.Errorf("get custom dictionary entry: %w", err)
+	}
+	return entry, nil
+}
+
+// SaveCustomDictionaryEntry creates userID's entry for entry.Headword or
+// replaces its reading and gloss.
+func (s *TranslationStore) SaveCustomDictionaryEntry(userID string, entry CustomDictionaryEntry) (CustomDictionaryEntry, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.db.Exec(
+		`INSERT INTO custom_dictionary (user_id, headword, pinyin, english, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id, headword) DO UPDATE
+		 SET pinyin = excluded.pinyin, english = excluded.english, updated_at = excluded.updated_at`,
+		userID, entry.Headword, entry.Pinyin, entry.English, now, now,
+	); err != nil {
+		return CustomDictionaryEntry{}, fmt.Errorf("save custom dictionary entry: %w", err)
+	}
+	return s.GetCustomDictionaryEntry(userID, entry.Headword)
+}
+
+// DeleteCustomDictionaryEntry removes userID's entry for headword, reporting
+// ErrNotFound when there was none.
+func (s *TranslationStore) DeleteCustomDictionaryEntry(userID string, headword string) error {
+	res, err := s.db.Exec(`DELETE FROM custom_dictionary WHERE user_id = ? AND headword = ?`, userID, headword)
+	if err != nil {
+		return fmt.Errorf("delete custom dictionary entry: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete custom dictionary entry rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ApplyCustomDictionary replaces the reading and gloss of every result whose
+// segment is in userID's custom dictionary and marks it SegmentSourceUser.
+// Custom glosses are English, so other target languages are left alone.
+func (s *TranslationStore) ApplyCustomDictionary(userID string, results []SegmentResult, lang LanguagePair) error {
+	if lang.WithDefaults().Target != DefaultTargetLang {
+		return nil
+	}
+	headwords := make([]any, 0, len(results))
+	for _, result := range results {
+		if result.Segment != "" {
+			headwords = append(headwords, result.Segment)
+		}
+	}
+	if len(headwords) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(headwords)), ",")
+	rows, err := s.db.Query(
+		`SELECT headword, pinyin, english FROM custom_dictionary
+		 WHERE user_id = ? AND headword IN (`+placeholders+`)`,
+		append([]any{userID}, headwords...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("query custom dictionary: %w", err)
+	}
+	defer rows.Close()
+	entries := make(map[string]CustomDictionaryEntry)
+	for rows.Next() {
+		var entry CustomDictionaryEntry
+		if err := rows.Scan(&entry.Headword, &entry.Pinyin, &entry.English); err != nil {
+			return fmt.Errorf("scan custom dictionary entry: %w", err)
+		}
+		entries[entry.Headword] = entry
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate custom dictionary: %w", err)
+	}
+
+	for i := range results {
+		entry, ok := entries[results[i].Segment]
+		if !ok {
+			continue
+		}
+		if entry.Pinyin != "" {
+			results[i].Pinyin = entry.Pinyin
+		}
+		results[i].English = entry.English
+		results[i].Source = SegmentSourceUser
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package translation
+
+import "testing"
+
+func TestCustomDictionaryEntriesArePerUser(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	saved, err := store.SaveCustomDictionaryEntry(DefaultUserID, CustomDictionaryEntry{Headword: "书", Pinyin: "shū", English: "book"})
+	if err != nil {
+		t.Fatalf("save entry: %v", err)
+	}
+	if saved.CreatedAt == "" || saved.English != "book" {
+		t.Fatalf("unexpected saved entry: %+v", saved)
+	}
+	if _, err := store.SaveCustomDictionaryEntry(DefaultUserID, CustomDictionaryEntry{Headword: "书", English: "tome"}); err != nil {
+		t.Fatalf("replace entry: %v", err)
+	}
+	if _, err := store.SaveCustomDictionaryEntry("other", CustomDictionaryEntry{Headword: "我", English: "me"}); err != nil {
+		t.Fatalf("save other user's entry: %v", err)
+	}
+
+	entries, err := store.ListCustomDictionary(DefaultUserID)
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].English != "tome" || entries[0].Pinyin != "" || entries[0].CreatedAt != saved.CreatedAt {
+		t.Fatalf("expected the replaced entry only, got %+v", entries)
+	}
+	if _, err := store.GetCustomDictionaryEntry(DefaultUserID, "我"); err != ErrNotFound {
+		t.Fatalf("expected another user's entry to be hidden, got %v", err)
+	}
+
+	results := []SegmentResult{
+		{Segment: "我", Pinyin: "wǒ", English: "I", Source: SegmentSourceCedict},
+		{Segment: "书", Pinyin: "shū", English: "book", Source: SegmentSourceCedict},
+	}
+	if err := store.ApplyCustomDictionary(DefaultUserID, results, DefaultLanguagePair()); err != nil {
+		t.Fatalf("apply custom dictionary: %v", err)
+	}
+	if results[0].Source != SegmentSourceCedict || results[1].English != "tome" || results[1].Pinyin != "shū" || results[1].Source != SegmentSourceUser {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	french := []SegmentResult{{Segment: "书", English: "livre"}}
+	if err := store.ApplyCustomDictionary(DefaultUserID, french, LanguagePair{Source: DefaultSourceLang, Target: "fr"}); err != nil {
+		t.Fatalf("apply custom dictionary: %v", err)
+	}
+	if french[0].English != "livre" {
+		t.Fatalf("expected non-English targets untouched, got %+v", french[0])
+	}
+
+	if err := store.DeleteCustomDictionaryEntry(DefaultUserID, "书"); err != nil {
+		t.Fatalf("delete entry: %v", err)
+	}
+	if err := store.DeleteCustomDictionaryEntry(DefaultUserID, "书"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
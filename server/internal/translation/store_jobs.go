@@ -1,17 +1,21 @@
 package translation
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ListRestartableTranslationIDs returns the jobs waiting to be claimed,
+// highest priority first and oldest first within a priority.
 func (s *TranslationStore) ListRestartableTranslationIDs() ([]string, error) {
 	nowStr := time.Now().UTC().Format(time.RFC3339Nano)
 	rows, err := s.db.Query(
 		`SELECT translation_id FROM translation_jobs
 		 WHERE state = 'pending'
 		    OR (state = 'leased' AND (lease_until IS NULL OR lease_until < ?))
-		 ORDER BY created_at ASC`,
+		 ORDER BY priority DESC, created_at ASC`,
 		nowStr,
 	)
 	if err != nil {
@@ -93,6 +97,42 @@ func (s *TranslationStore) RenewLease(translationID string, d time.Duration) err
 	return nil
 }
 
+// SetJobPriority sets where a job sits in the pending queue; see the
+// JobPriority* constants.
+func (s *TranslationStore) SetJobPriority(translationID string, priority int) error {
+	res, err := s.db.Exec(
+		`UPDATE translation_jobs SET priority = ?, updated_at = ? WHERE translation_id = ?`,
+		priority, time.Now().UTC().Format(time.RFC3339Nano), translationID,
+	)
+	if err != nil {
+		return fmt.Errorf("set job priority: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("set job priority rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseTranslationJob hands a leased job back to the queue as pending, so
+// it can be claimed again straight away rather than after its lease expires.
+func (s *TranslationStore) ReleaseTranslationJob(translationID string) error {
+	nowStr := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := s.db.Exec(
+		`UPDATE translation_jobs
+		 SET state = 'pending', lease_until = NULL, updated_at = ?
+		 WHERE translation_id = ? AND state = 'leased'`,
+		nowStr, translationID,
+	)
+	if err != nil {
+		return fmt.Errorf("release translation job: %w", err)
+	}
+	return nil
+}
+
 // GetJobAttempts returns the number of times a job has been claimed.
 // Used in tests to verify a job was not double-claimed. Not added to the
 // translationStore interface because Get() only queries the translations table.
@@ -107,3 +147,64 @@ func (s *TranslationStore) GetJobAttempts(translationID string) (int, error) {
 	}
 	return attempts, nil
 }
+
+// GetJobFailure returns the error recorded for a translation, or
+// ErrNotFound when the translation does not exist.
+func (s *TranslationStore) GetJobFailure(translationID string) (JobFailure, error) {
+	failure := JobFailure{TranslationID: translationID}
+	var message, detail, updatedAt sql.NullString
+	var attempts sql.NullInt64
+	err := s.db.QueryRow(
+		`SELECT t.status, t.error_message, j.last_error, j.attempts, j.updated_at
+		 FROM translations t
+		 LEFT JOIN translation_jobs j ON j.translation_id = t.id
+		 WHERE t.id = ?`,
+		translationID,
+	).Scan(&failure.Status, &message, &detail, &attempts, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return JobFailure{}, ErrNotFound
+	}
+	if err != nil {
+		return JobFailure{}, fmt.Errorf("get job failure: %w", err)
+	}
+	failure.ErrorMessage = message.String
+	failure.Detail = detail.String
+	failure.Attempts = int(attempts.Int64)
+	failure.UpdatedAt = updatedAt.String
+	return failure, nil
+}
+
+// CountJobsByState returns the number of jobs in each state along with the
+// leased jobs whose lease has expired.
+func (s *TranslationStore) CountJobsByState() (JobStateCounts, error) {
+	counts := JobStateCounts{ByState: make(map[string]int)}
+	rows, err := s.db.Query(`SELECT state, COUNT(*) FROM translation_jobs GROUP BY state`)
+	if err != nil {
+		return counts, fmt.Errorf("count jobs by state: %w", err)
+	}
+	for rows.Next() {
+		var state string
+		var n int
+		if err := rows.Scan(&state, &n); err != nil {
+			rows.Close()
+			return counts, fmt.Errorf("scan job state count: %w", err)
+		}
+		counts.ByState[state] = n
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return counts, fmt.Errorf("iterate job state counts: %w", err)
+	}
+	rows.Close()
+
+	nowStr := time.Now().UTC().Format(time.RFC3339Nano)
+	err = s.db.QueryRow(
+		`SELECT COUNT(*) FROM translation_jobs
+		 WHERE state = 'leased' AND (lease_until IS NULL OR lease_until < ?)`,
+		nowStr,
+	).Scan(&counts.ExpiredLeases)
+	if err != nil {
+		return counts, fmt.Errorf("count expired leases: %w", err)
+	}
+	return counts, nil
+}
@@ -75,3 +75,69 @@ func getLeaseUntil(t *testing.T, store *TranslationStore, translationID string)
 	}
 	return leaseUntil
 }
+
+func TestCountJobsByStateReportsExpiredLeases(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	ids := make([]string, 0, 3)
+	for _, text := range []string{"你好", "谢谢", "再见"} {
+		item, err := store.Create(text, "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	if claimed, err := store.ClaimTranslationJob(ids[0], 5*time.Minute); err != nil || !claimed {
+		t.Fatalf("claim translation job: err=%v claimed=%v", err, claimed)
+	}
+	// A negative lease leaves the job leased but already past lease_until.
+	if claimed, err := store.ClaimTranslationJob(ids[1], -time.Minute); err != nil || !claimed {
+		t.Fatalf("claim translation job: err=%v claimed=%v", err, claimed)
+	}
+
+	counts, err := store.CountJobsByState()
+	if err != nil {
+		t.Fatalf("count jobs by state: %v", err)
+	}
+	if counts.ByState["pending"] != 1 || counts.ByState["leased"] != 2 {
+		t.Fatalf("unexpected job state counts: %#v", counts.ByState)
+	}
+	if counts.ExpiredLeases != 1 {
+		t.Fatalf("expected 1 expired lease, got %d", counts.ExpiredLeases)
+	}
+
+	statuses, err := store.CountByStatus()
+	if err != nil {
+		t.Fatalf("count translations by status: %v", err)
+	}
+	if statuses["pending"] != 3 {
+		t.Fatalf("unexpected translation status counts: %#v", statuses)
+	}
+}
+
+func TestGetJobFailureReturnsDetail(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	item, err := store.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.Fail(item.ID, "Failed to segment: upstream returned status 500", "Failed to segment: upstream returned status 500: full body"); err != nil {
+		t.Fatalf("fail translation: %v", err)
+	}
+
+	failure, err := store.GetJobFailure(item.ID)
+	if err != nil {
+		t.Fatalf("get job failure: %v", err)
+	}
+	if failure.Status != "failed" || failure.ErrorMessage != "Failed to segment: upstream returned status 500" {
+		t.Fatalf("unexpected failure: %+v", failure)
+	}
+	if failure.Detail != "Failed to segment: upstream returned status 500: full body" {
+		t.Fatalf("expected full detail, got %q", failure.Detail)
+	}
+
+	if _, err := store.GetJobFailure("missing"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
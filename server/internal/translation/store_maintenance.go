@@ -0,0 +1,71 @@
+package translation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CheckIntegrity runs PRAGMA integrity_check and PRAGMA foreign_key_check.
+// Both are read-only and safe to run while the server is serving.
+func (s *MaintenanceStore) CheckIntegrity(ctx context.Context) (IntegrityReport, error) {
+	report := IntegrityReport{
+		IntegrityErrors:      make([]string, 0),
+		ForeignKeyViolations: make([]ForeignKeyViolation, 0),
+	}
+
+	rows, err := s.db.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return IntegrityReport{}, fmt.Errorf("integrity check: %w", err)
+	}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			_ = rows.Close()
+			return IntegrityReport{}, fmt.Errorf("scan integrity check row: %w", err)
+		}
+		// A healthy database reports a single "ok" row.
+		if message != "ok" {
+			report.IntegrityErrors = append(report.IntegrityErrors, message)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return IntegrityReport{}, fmt.Errorf("iterate integrity check rows: %w", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return IntegrityReport{}, fmt.Errorf("foreign key check: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var violation ForeignKeyViolation
+		var rowID sql.NullInt64
+		var fkIndex int
+		if err := rows.Scan(&violation.Table, &rowID, &violation.Parent, &fkIndex); err != nil {
+			return IntegrityReport{}, fmt.Errorf("scan foreign key check row: %w", err)
+		}
+		if rowID.Valid {
+			violation.RowID = &rowID.Int64
+		}
+		report.ForeignKeyViolations = append(report.ForeignKeyViolations, violation)
+	}
+	if err := rows.Err(); err != nil {
+		return IntegrityReport{}, fmt.Errorf("iterate foreign key check rows: %w", err)
+	}
+	return report, nil
+}
+
+// CheckpointWAL copies the write-ahead log into the database file and
+// truncates it, so the WAL does not keep growing between automatic
+// checkpoints.
+func (s *MaintenanceStore) CheckpointWAL(ctx context.Context) (WALCheckpoint, error) {
+	var busy int
+	var checkpoint WALCheckpoint
+	if err := s.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &checkpoint.LogFrames, &checkpoint.CheckpointedFrames); err != nil {
+		return WALCheckpoint{}, fmt.Errorf("wal checkpoint: %w", err)
+	}
+	checkpoint.Busy = busy != 0
+	return checkpoint, nil
+}
@@ -0,0 +1,54 @@
+package translation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckIntegrityReportsForeignKeyViolations(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	maintenance := &MaintenanceStore{db: srs.db}
+	ctx := context.Background()
+
+	report, err := maintenance.CheckIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("check integrity: %v", err)
+	}
+	if len(report.IntegrityErrors) != 0 || len(report.ForeignKeyViolations) != 0 {
+		t.Fatalf("expected a healthy database, got %+v", report)
+	}
+
+	// foreign_keys is a per-connection setting, so pin one to insert an
+	// orphaned row.
+	conn, err := srs.db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("get conn: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		t.Fatalf("disable foreign keys: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `INSERT INTO srs_state (id, segment_id) VALUES ('orphan', 'missing')`); err != nil {
+		t.Fatalf("insert orphaned srs state: %v", err)
+	}
+	_ = conn.Close()
+
+	report, err = maintenance.CheckIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("check integrity: %v", err)
+	}
+	if len(report.ForeignKeyViolations) != 1 {
+		t.Fatalf("expected one foreign key violation, got %+v", report.ForeignKeyViolations)
+	}
+	violation := report.ForeignKeyViolations[0]
+	if violation.Table != "srs_state" || violation.Parent != "saved_segments" || violation.RowID == nil {
+		t.Fatalf("unexpected violation: %+v", violation)
+	}
+
+	checkpoint, err := maintenance.CheckpointWAL(ctx)
+	if err != nil {
+		t.Fatalf("checkpoint wal: %v", err)
+	}
+	if checkpoint.Busy {
+		t.Fatalf("expected checkpoint to complete, got %+v", checkpoint)
+	}
+}
@@ -0,0 +1,64 @@
+package translation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TranslationRef identifies a translation by id and title.
+type TranslationRef struct {
+	ID    string
+	Title string
+}
+
+// Neighbors returns the translations created just before and just after id
+// among those owned by userID that match filter, so a reader can step
+// through the same list they were browsing. id itself need not match the
+// filter. Either result is nil at the end of the list; ErrNotFound means
+// userID has no translation id.
+func (s *TranslationStore) Neighbors(userID string, id string, filter ListFilter) (*TranslationRef, *TranslationRef, error) {
+	filter, err := normalizeListFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	conditions, args := filterConditions(filter)
+	where := `user_id = ?`
+	if len(conditions) > 0 {
+		where += ` AND (id = ? OR (` + strings.Join(conditions, ` AND `) + `))`
+		args = append([]any{userID, id}, args...)
+	} else {
+		args = []any{userID}
+	}
+	args = append(args, id)
+
+	var prevID, prevTitle, nextID, nextTitle sql.NullString
+	err = s.db.QueryRow(
+		`SELECT prev_id, prev_title, next_id, next_title FROM (
+			SELECT id,
+				LAG(id) OVER ordered AS prev_id,
+				LAG(title) OVER ordered AS prev_title,
+				LEAD(id) OVER ordered AS next_id,
+				LEAD(title) OVER ordered AS next_title
+			FROM translations
+			WHERE `+where+`
+			WINDOW ordered AS (ORDER BY created_at, id)
+		) WHERE id = ?`,
+		args...,
+	).Scan(&prevID, &prevTitle, &nextID, &nextTitle)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("load neighbors: %w", err)
+	}
+	return translationRef(prevID, prevTitle), translationRef(nextID, nextTitle), nil
+}
+
+func translationRef(id sql.NullString, title sql.NullString) *TranslationRef {
+	if !id.Valid {
+		return nil
+	}
+	return &TranslationRef{ID: id.String, Title: title.String}
+}
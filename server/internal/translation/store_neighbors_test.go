@@ -0,0 +1,68 @@
+package translation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNeighborsFollowCreationOrderAndFilters(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := make([]string, 4)
+	for i := range ids {
+		tr, err := store.Create("你好。", "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		created := base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339)
+		if _, err := store.db.Exec(`UPDATE translations SET created_at = ?, title = ? WHERE id = ?`, created, "t"+string(rune('0'+i)), tr.ID); err != nil {
+			t.Fatalf("backdate translation: %v", err)
+		}
+		ids[i] = tr.ID
+	}
+
+	prev, next, err := store.Neighbors(DefaultUserID, ids[1], ListFilter{})
+	if err != nil {
+		t.Fatalf("neighbors: %v", err)
+	}
+	if prev == nil || prev.ID != ids[0] || prev.Title != "t0" || next == nil || next.ID != ids[2] {
+		t.Fatalf("expected %s and %s around %s, got prev=%+v next=%+v", ids[0], ids[2], ids[1], prev, next)
+	}
+
+	prev, next, err = store.Neighbors(DefaultUserID, ids[0], ListFilter{})
+	if err != nil || prev != nil || next == nil || next.ID != ids[1] {
+		t.Fatalf("expected no previous for the oldest, got prev=%+v next=%+v err=%v", prev, next, err)
+	}
+	prev, next, err = store.Neighbors(DefaultUserID, ids[3], ListFilter{})
+	if err != nil || next != nil || prev == nil || prev.ID != ids[2] {
+		t.Fatalf("expected no next for the newest, got prev=%+v next=%+v err=%v", prev, next, err)
+	}
+
+	// Only ids[0] and ids[3] are tagged; ids[1] is not, but its neighbors
+	// are still found among the tagged ones.
+	for _, id := range []string{ids[0], ids[3]} {
+		if err := store.AddTag(id, "poetry"); err != nil {
+			t.Fatalf("add tag: %v", err)
+		}
+	}
+	prev, next, err = store.Neighbors(DefaultUserID, ids[0], ListFilter{Tags: []string{"poetry"}})
+	if err != nil || prev != nil || next == nil || next.ID != ids[3] {
+		t.Fatalf("expected the next tagged translation, got prev=%+v next=%+v err=%v", prev, next, err)
+	}
+	prev, next, err = store.Neighbors(DefaultUserID, ids[1], ListFilter{Tags: []string{"poetry"}})
+	if err != nil || prev == nil || prev.ID != ids[0] || next == nil || next.ID != ids[3] {
+		t.Fatalf("expected tagged neighbors of an untagged translation, got prev=%+v next=%+v err=%v", prev, next, err)
+	}
+	if _, _, err := store.Neighbors(DefaultUserID, ids[0], ListFilter{Status: "completed"}); err != nil {
+		t.Fatalf("expected the current translation even when it fails the filter, got %v", err)
+	}
+
+	if _, _, err := store.Neighbors("someone-else", ids[1], ListFilter{}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for another user's translation, got %v", err)
+	}
+	if _, _, err := store.Neighbors(DefaultUserID, ids[1], ListFilter{Status: "bogus"}); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("expected ErrInvalidFilter, got %v", err)
+	}
+}
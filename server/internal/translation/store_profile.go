@@ -2,30 +2,37 @@ package translation
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
-func (s *ProfileStore) UpsertUserProfile(name string, email string, language string) (UserProfile, error) {
+func (s *ProfileStore) UpsertUserProfile(userID string, name string, email string, language string, timezone string) (UserProfile, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return UserProfile{}, ErrInvalidTimezone
+		}
+	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
-	res, err := s.db.Exec(`UPDATE user_profile SET name = ?, email = ?, language = ?, updated_at = ? WHERE id = 1`,
-		name, email, language, now)
+	res, err := s.db.Exec(`UPDATE user_profile SET name = ?, email = ?, language = ?, timezone = ?, updated_at = ? WHERE user_id = ?`,
+		name, email, language, timezone, now, userID)
 	if err != nil {
 		return UserProfile{}, fmt.Errorf("update user profile: %w", err)
 	}
 	affected, _ := res.RowsAffected()
 	if affected == 0 {
-		if _, err := s.db.Exec(`INSERT INTO user_profile (id, name, email, language, created_at, updated_at) VALUES (1, ?, ?, ?, ?, ?)`,
-			name, email, language, now, now); err != nil {
+		if _, err := s.db.Exec(`INSERT INTO user_profile (user_id, name, email, language, timezone, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			userID, name, email, language, timezone, now, now); err != nil {
 			return UserProfile{}, fmt.Errorf("insert user profile: %w", err)
 		}
 	}
-	return UserProfile{Name: name, Email: email, Language: language, CreatedAt: now, UpdatedAt: now}, nil
+	return UserProfile{Name: name, Email: email, Language: language, Timezone: timezone, CreatedAt: now, UpdatedAt: now}, nil
 }
 
-func (s *ProfileStore) GetUserProfile() (UserProfile, bool) {
-	row := s.db.QueryRow(`SELECT name, email, language, created_at, updated_at FROM user_profile WHERE id = 1`)
+func (s *ProfileStore) GetUserProfile(userID string) (UserProfile, bool) {
+	row := s.db.QueryRow(`SELECT name, email, language, timezone, created_at, updated_at FROM user_profile WHERE user_id = ?`, userID)
 	var p UserProfile
-	if err := row.Scan(&p.Name, &p.Email, &p.Language, &p.CreatedAt, &p.UpdatedAt); err != nil {
+	if err := row.Scan(&p.Name, &p.Email, &p.Language, &p.Timezone, &p.CreatedAt, &p.UpdatedAt); err != nil {
 		return UserProfile{}, false
 	}
 	return p, true
@@ -0,0 +1,49 @@
+package translation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetCachedSegment returns the cached translation of segment for lang under
+// contextKey, which is empty for segments cached on their own.
+func (s *SegmentCacheStore) GetCachedSegment(segment string, lang LanguagePair, contextKey string) (SegmentResult, bool, error) {
+	result := SegmentResult{Segment: segment}
+	err := s.db.QueryRow(
+		`SELECT pinyin, english, source, pos, classifier FROM segment_translation_cache
+		 WHERE segment = ? AND source_lang = ? AND target_lang = ? AND context_key = ?`,
+		segment, lang.Source, lang.Target, contextKey,
+	).Scan(&result.Pinyin, &result.English, &result.Source, &result.POS, &result.Classifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SegmentResult{}, false, nil
+	}
+	if err != nil {
+		return SegmentResult{}, false, fmt.Errorf("get cached segment: %w", err)
+	}
+	return result, true, nil
+}
+
+// PutCachedSegment stores result for lang under contextKey, replacing any
+// earlier entry.
+func (s *SegmentCacheStore) PutCachedSegment(result SegmentResult, lang LanguagePair, contextKey string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO segment_translation_cache (segment, source_lang, target_lang, context_key, pinyin, english, source, pos, classifier, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(segment, source_lang, target_lang, context_key) DO UPDATE SET
+		   pinyin = excluded.pinyin,
+		   english = excluded.english,
+		   source = excluded.source,
+		   pos = excluded.pos,
+		   classifier = excluded.classifier,
+		   created_at = excluded.created_at`,
+		result.Segment, lang.Source, lang.Target, contextKey,
+		result.Pinyin, result.English, result.Source, result.POS, result.Classifier,
+		time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("put cached segment: %w", err)
+	}
+	return nil
+}
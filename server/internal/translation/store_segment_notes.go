@@ -0,0 +1,149 @@
+package translation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type segmentNoteKey struct {
+	sentenceKey string
+	segIdx      int
+}
+
+type storedSegmentNote struct {
+	segment string
+	note    string
+}
+
+// sentenceNoteKey keys a sentence's notes by its content hash, so notes
+// follow the sentence when edits move it. Sentences stored before hashes
+// were recorded fall back to their index.
+func sentenceNoteKey(sentenceIdx int, hash string) string {
+	if hash == "" {
+		return fmt.Sprintf("idx:%d", sentenceIdx)
+	}
+	return hash
+}
+
+// resolveSegment looks up the note key and current text of a segment,
+// reporting ErrNotFound when the translation has no such segment.
+func (s *TranslationStore) resolveSegment(translationID string, sentenceIdx int, segIdx int) (string, string, error) {
+	var hash string
+	err := s.db.QueryRow(
+		`SELECT content_hash FROM translation_sentences WHERE translation_id = ? AND sentence_idx = ?`,
+		translationID, sentenceIdx,
+	).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("load sentence hash: %w", err)
+	}
+
+	var segment string
+	err = s.db.QueryRow(
+		`SELECT segment_text FROM translation_segments
+		 WHERE translation_id = ? AND sentence_idx = ?
+		 ORDER BY seg_idx ASC LIMIT 1 OFFSET ?`,
+		translationID, sentenceIdx, segIdx,
+	).Scan(&segment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("load segment: %w", err)
+	}
+	return sentenceNoteKey(sentenceIdx, hash), segment, nil
+}
+
+// SetSegmentNote creates or replaces the note on a segment.
+func (s *TranslationStore) SetSegmentNote(translationID string, sentenceIdx int, segIdx int, note string) (SegmentNote, error) {
+	key, segment, err := s.resolveSegment(translationID, sentenceIdx, segIdx)
+	if err != nil {
+		return SegmentNote{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.db.Exec(
+		`INSERT INTO segment_notes (translation_id, sentence_key, seg_idx, segment_text, note, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (translation_id, sentence_key, seg_idx) DO UPDATE
+		 SET segment_text = excluded.segment_text, note = excluded.note, updated_at = excluded.updated_at`,
+		translationID, key, segIdx, segment, note, now, now,
+	); err != nil {
+		return SegmentNote{}, fmt.Errorf("save segment note: %w", err)
+	}
+	return s.GetSegmentNote(translationID, sentenceIdx, segIdx)
+}
+
+// GetSegmentNote returns the note on a segment, or ErrNotFound when it has
+// none. A note left on a segment that has since been re-split differently
+// no longer applies and is reported as missing.
+func (s *TranslationStore) GetSegmentNote(translationID string, sentenceIdx int, segIdx int) (SegmentNote, error) {
+	key, segment, err := s.resolveSegment(translationID, sentenceIdx, segIdx)
+	if err != nil {
+		return SegmentNote{}, err
+	}
+	note := SegmentNote{TranslationID: translationID, SentenceIdx: sentenceIdx, SegIdx: segIdx, Segment: segment}
+	var noteSegment string
+	err = s.db.QueryRow(
+		`SELECT segment_text, note, created_at, updated_at FROM segment_notes
+		 WHERE translation_id = ? AND sentence_key = ? AND seg_idx = ?`,
+		translationID, key, segIdx,
+	).Scan(&noteSegment, &note.Note, &note.CreatedAt, &note.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && noteSegment != segment) {
+		return SegmentNote{}, ErrNotFound
+	}
+	if err != nil {
+		return SegmentNote{}, fmt.Errorf("get segment note: %w", err)
+	}
+	return note, nil
+}
+
+// DeleteSegmentNote removes the note on a segment, reporting ErrNotFound
+// when there was none.
+func (s *TranslationStore) DeleteSegmentNote(translationID string, sentenceIdx int, segIdx int) error {
+	key, _, err := s.resolveSegment(translationID, sentenceIdx, segIdx)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(
+		`DELETE FROM segment_notes WHERE translation_id = ? AND sentence_key = ? AND seg_idx = ?`,
+		translationID, key, segIdx,
+	)
+	if err != nil {
+		return fmt.Errorf("delete segment note: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete segment note rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// loadSegmentNotes returns every note on a translation; loadSentences
+// matches them to the current segments.
+func (s *TranslationStore) loadSegmentNotes(translationID string) map[segmentNoteKey]storedSegmentNote {
+	notes := make(map[segmentNoteKey]storedSegmentNote)
+	rows, err := s.db.Query(
+		`SELECT sentence_key, seg_idx, segment_text, note FROM segment_notes WHERE translation_id = ?`,
+		translationID,
+	)
+	if err != nil {
+		return notes
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key segmentNoteKey
+		var note storedSegmentNote
+		if err := rows.Scan(&key.sentenceKey, &key.segIdx, &note.segment, &note.note); err != nil {
+			return notes
+		}
+		notes[key] = note
+	}
+	return notes
+}
@@ -0,0 +1,64 @@
+package translation
+
+import (
+	"testing"
+
+	"github.com/anath2/language-app/internal/textsplit"
+)
+
+func TestSegmentNoteFollowsSentenceThroughEdits(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	input := "你好世界。"
+	tr, err := store.Create(input, "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	inits := make([]SentenceInit, 0)
+	for _, s := range textsplit.Split(input, "zh") {
+		inits = append(inits, SentenceInit{Indent: s.Indent, Separator: s.Separator, Text: s.Text})
+	}
+	if err := store.SetProcessing(tr.ID, 2, inits); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	for _, seg := range []SegmentResult{{Segment: "你好", English: "hello"}, {Segment: "世界", English: "world"}} {
+		if _, _, err := store.AddProgressSegment(tr.ID, seg, 0); err != nil {
+			t.Fatalf("add segment: %v", err)
+		}
+	}
+
+	if _, err := store.SetSegmentNote(tr.ID, 0, 1, "shìjiè, not shìjiē"); err != nil {
+		t.Fatalf("set note: %v", err)
+	}
+	if _, err := store.SetSegmentNote(tr.ID, 0, 5, "nope"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a missing segment, got %v", err)
+	}
+	got, _ := store.Get(tr.ID)
+	if got.Sentences[0].Translations[1].Note != "shìjiè, not shìjiē" || got.Sentences[0].Translations[0].Note != "" {
+		t.Fatalf("expected the note inline on 世界 only, got %+v", got.Sentences[0].Translations)
+	}
+
+	// Prepending a sentence moves 你好世界。 to index 1; its note moves too.
+	changed, err := store.UpdateInputTextForReprocessing(tr.ID, "早上好。"+input)
+	if err != nil {
+		t.Fatalf("update input text: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Fatalf("expected both sentence slots to need reprocessing, got %v", changed)
+	}
+	for segIdx, seg := range []SegmentResult{{Segment: "你好", English: "hello"}, {Segment: "世界", English: "world"}} {
+		if err := store.AddReprocessedSegment(tr.ID, seg, 1, segIdx); err != nil {
+			t.Fatalf("add reprocessed segment: %v", err)
+		}
+	}
+	note, err := store.GetSegmentNote(tr.ID, 1, 1)
+	if err != nil || note.Segment != "世界" {
+		t.Fatalf("expected the note on the moved sentence, got %+v (err=%v)", note, err)
+	}
+
+	if err := store.DeleteSegmentNote(tr.ID, 1, 1); err != nil {
+		t.Fatalf("delete note: %v", err)
+	}
+	if _, err := store.GetSegmentNote(tr.ID, 1, 1); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
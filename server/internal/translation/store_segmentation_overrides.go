@@ -0,0 +1,62 @@
+package translation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetSegmentationOverride returns the segmentation userID last corrected
+// sentence to, if any.
+func (s *TranslationStore) GetSegmentationOverride(userID string, sentence string, lang LanguagePair) ([]string, bool, error) {
+	hash := sentenceHash(sentence)
+	if hash == "" {
+		return nil, false, nil
+	}
+	var raw string
+	err := s.db.QueryRow(
+		`SELECT segments_json FROM segmentation_overrides
+		 WHERE user_id = ? AND source_lang = ? AND content_hash = ?`,
+		userID, lang.Source, hash,
+	).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get segmentation override: %w", err)
+	}
+	var segments []string
+	if err := json.Unmarshal([]byte(raw), &segments); err != nil {
+		return nil, false, fmt.Errorf("decode segmentation override: %w", err)
+	}
+	return segments, len(segments) > 0, nil
+}
+
+// saveSegmentationOverride records segments as the corrected segmentation
+// of the sentence with the given content hash. Sentences stored before
+// hashes were recorded have none and are skipped.
+func saveSegmentationOverride(tx *sql.Tx, translationID string, hash string, segments []SegmentResult) error {
+	if hash == "" {
+		return nil
+	}
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		texts = append(texts, seg.Segment)
+	}
+	raw, err := json.Marshal(texts)
+	if err != nil {
+		return fmt.Errorf("encode segmentation override: %w", err)
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO segmentation_overrides (user_id, source_lang, content_hash, segments_json, updated_at)
+		 SELECT user_id, source_lang, ?, ?, ? FROM translations WHERE id = ?
+		 ON CONFLICT (user_id, source_lang, content_hash) DO UPDATE
+		 SET segments_json = excluded.segments_json, updated_at = excluded.updated_at`,
+		hash, string(raw), time.Now().UTC().Format(time.RFC3339Nano), translationID,
+	); err != nil {
+		return fmt.Errorf("save segmentation override: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package translation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SessionTouchInterval bounds how often a session's last_seen_at is written,
+// so authenticated requests do not each cost a database write.
+const SessionTouchInterval = time.Minute
+
+func (s *SessionStore) CreateSession(id string, userID string, userAgent string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.db.Exec(
+		`INSERT INTO sessions (id, user_id, user_agent, created_at, last_seen_at) VALUES (?, ?, ?, ?, ?)`,
+		id, userID, userAgent, now, now,
+	); err != nil {
+		return fmt.Errorf("insert session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession returns the owner of an active session and refreshes its
+// last-seen time. ok is false for unknown or revoked sessions, and for
+// sessions unused for longer than idleTimeout when it is positive.
+func (s *SessionStore) TouchSession(id string, idleTimeout time.Duration) (string, bool, error) {
+	var userID, lastSeenAt string
+	err := s.db.QueryRow(
+		`SELECT user_id, last_seen_at FROM sessions WHERE id = ? AND revoked_at IS NULL`,
+		id,
+	).Scan(&userID, &lastSeenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("load session: %w", err)
+	}
+
+	now := time.Now().UTC()
+	seen, err := time.Parse(time.RFC3339Nano, lastSeenAt)
+	if err == nil && idleTimeout > 0 && now.Sub(seen) > idleTimeout {
+		return "", false, nil
+	}
+	if err != nil || now.Sub(seen) >= SessionTouchInterval {
+		if _, err := s.db.Exec(
+			`UPDATE sessions SET last_seen_at = ? WHERE id = ?`,
+			now.Format(time.RFC3339Nano), id,
+		); err != nil && !isDBLocked(err) {
+			return "", false, fmt.Errorf("touch session: %w", err)
+		}
+	}
+	return userID, true, nil
+}
+
+func (s *SessionStore) RevokeSession(id string) error {
+	if _, err := s.db.Exec(
+		`UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		time.Now().UTC().Format(time.RFC3339Nano), id,
+	); err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every active session of userID and returns how
+// many were revoked.
+func (s *SessionStore) RevokeUserSessions(userID string) (int, error) {
+	res, err := s.db.Exec(
+		`UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`,
+		time.Now().UTC().Format(time.RFC3339Nano), userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("revoke user sessions: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return int(affected), nil
+}
+
+// ListUserSessions returns the active sessions of userID created at or after
+// since, newest first.
+func (s *SessionStore) ListUserSessions(userID string, since time.Time) ([]Session, error) {
+	rows, err := s.db.Query(
+		`SELECT id, user_id, user_agent, created_at, last_seen_at FROM sessions
+		 WHERE user_id = ? AND revoked_at IS NULL AND created_at >= ?
+		 ORDER BY created_at DESC`,
+		userID, since.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("scan session row: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session rows: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,36 @@
+package translation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTouchSessionEnforcesIdleTimeout(t *testing.T) {
+	translations := newTranslationStoreWithMigrations(t)
+	store := &SessionStore{db: translations.db}
+
+	if err := store.CreateSession("s1", DefaultUserID, "test"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if userID, ok, err := store.TouchSession("s1", 15*time.Minute); err != nil || !ok || userID != DefaultUserID {
+		t.Fatalf("expected fresh session to be active, got user=%q ok=%v err=%v", userID, ok, err)
+	}
+
+	idle := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339Nano)
+	if _, err := store.db.Exec(`UPDATE sessions SET last_seen_at = ? WHERE id = ?`, idle, "s1"); err != nil {
+		t.Fatalf("age session: %v", err)
+	}
+	if _, ok, err := store.TouchSession("s1", 15*time.Minute); err != nil || ok {
+		t.Fatalf("expected idle session to be rejected, got ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := store.TouchSession("s1", 0); err != nil || !ok {
+		t.Fatalf("expected session to be active without an idle timeout, got ok=%v err=%v", ok, err)
+	}
+
+	if _, err := store.RevokeUserSessions(DefaultUserID); err != nil {
+		t.Fatalf("revoke sessions: %v", err)
+	}
+	if _, ok, _ := store.TouchSession("s1", 0); ok {
+		t.Fatal("expected revoked session to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package translation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest tag, in characters, AddTag accepts.
+const MaxTagLength = 32
+
+// NormalizeTag trims and lowercases a tag so "News" and "news " are the same
+// label, returning ErrInvalidTag when nothing is left or it is too long.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+// AddTag labels a translation. Adding a tag it already has is a no-op.
+func (s *TranslationStore) AddTag(translationID string, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM translations WHERE id = ?`, translationID).Scan(&exists); err != nil {
+		return fmt.Errorf("check translation: %w", err)
+	}
+	if exists == 0 {
+		return ErrNotFound
+	}
+	if _, err := s.db.Exec(
+		`INSERT OR IGNORE INTO translation_tags (translation_id, tag, created_at) VALUES (?, ?, ?)`,
+		translationID, tag, time.Now().UTC().Format(time.RFC3339Nano),
+	); err != nil {
+		return fmt.Errorf("add tag: %w", err)
+	}
+	return nil
+}
+
+// RemoveTag removes a label, reporting ErrNotFound when the translation did
+// not have it.
+func (s *TranslationStore) RemoveTag(translationID string, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`DELETE FROM translation_tags WHERE translation_id = ? AND tag = ?`, translationID, tag)
+	if err != nil {
+		return fmt.Errorf("remove tag: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("remove tag rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListTags returns a translation's tags in alphabetical order.
+func (s *TranslationStore) ListTags(translationID string) ([]string, error) {
+	tags, err := s.loadTags([]string{translationID})
+	if err != nil {
+		return nil, err
+	}
+	if tags[translationID] == nil {
+		return []string{}, nil
+	}
+	return tags[translationID], nil
+}
+
+// loadTags returns the tags of each translation in ids, sorted. It must not
+// be called while other rows are open.
+func (s *TranslationStore) loadTags(ids []string) (map[string][]string, error) {
+	tags := make(map[string][]string, len(ids))
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(
+		fmt.Sprintf(`SELECT translation_id, tag FROM translation_tags WHERE translation_id IN (%s)`, placeholders),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags[id] = append(tags[id], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tags: %w", err)
+	}
+	for _, list := range tags {
+		sort.Strings(list)
+	}
+	return tags, nil
+}
@@ -0,0 +1,76 @@
+package translation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagsNormalizeAndFilterWithAndSemantics(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	news, err := store.Create("新闻。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	poem, err := store.Create("静夜思。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	for _, tag := range []string{"News", " work ", "news"} {
+		if err := store.AddTag(news.ID, tag); err != nil {
+			t.Fatalf("add tag %q: %v", tag, err)
+		}
+	}
+	if err := store.AddTag(poem.ID, "work"); err != nil {
+		t.Fatalf("add tag: %v", err)
+	}
+
+	tags, err := store.ListTags(news.ID)
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"news", "work"}) {
+		t.Fatalf("expected normalized, deduplicated tags, got %v", tags)
+	}
+	if got, _ := store.Get(news.ID); !reflect.DeepEqual(got.Tags, []string{"news", "work"}) {
+		t.Fatalf("expected tags on Get, got %v", got.Tags)
+	}
+
+	if _, total, _ := store.List(20, 0, ListFilter{Tags: []string{"work"}}); total != 2 {
+		t.Fatalf("expected both translations tagged work, got %d", total)
+	}
+	items, total, err := store.List(20, 0, ListFilter{Tags: []string{"work", "NEWS"}})
+	if err != nil {
+		t.Fatalf("list by tags: %v", err)
+	}
+	if total != 1 || items[0].ID != news.ID || !reflect.DeepEqual(items[0].Tags, []string{"news", "work"}) {
+		t.Fatalf("expected only the translation with every tag, got total=%d items=%+v", total, items)
+	}
+	if _, total, _ := store.List(20, 0, ListFilter{Tags: []string{"work"}, Status: "completed"}); total != 0 {
+		t.Fatalf("expected tag and status filters to compose, got %d", total)
+	}
+
+	if err := store.RemoveTag(news.ID, "NEWS"); err != nil {
+		t.Fatalf("remove tag: %v", err)
+	}
+	if err := store.RemoveTag(news.ID, "news"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound removing a missing tag, got %v", err)
+	}
+	if err := store.AddTag(news.ID, "  "); err != ErrInvalidTag {
+		t.Fatalf("expected ErrInvalidTag, got %v", err)
+	}
+	if err := store.AddTag("missing", "news"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a missing translation, got %v", err)
+	}
+
+	if !store.Delete(news.ID) {
+		t.Fatal("delete translation")
+	}
+	var remaining int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM translation_tags WHERE translation_id = ?`, news.ID).Scan(&remaining); err != nil {
+		t.Fatalf("count tags: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("expected tags to be deleted with the translation, got %d", remaining)
+	}
+}
@@ -3,11 +3,13 @@ package translation
 import (
 	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
-	"unicode/utf8"
+
+	"github.com/anath2/language-app/internal/textsplit"
 )
 
 func computeTitle(inputText string) string {
@@ -19,28 +21,78 @@ func computeTitle(inputText string) string {
 }
 
 func (s *TranslationStore) Create(inputText string, sourceType string) (Translation, error) {
-	if strings.TrimSpace(inputText) == "" {
-		return Translation{}, errors.New("input_text is required")
+	return s.CreateWithLanguagePair(inputText, sourceType, DefaultLanguagePair())
+}
+
+// CreateWithLanguagePair is Create for a non-default language pair. Empty
+// fields in lang fall back to zh→en.
+func (s *TranslationStore) CreateWithLanguagePair(inputText string, sourceType string, lang LanguagePair) (Translation, error) {
+	return s.CreateWithMetadata(inputText, sourceType, lang, nil)
+}
+
+// CreateWithMetadata is CreateWithLanguagePair that also records metadata,
+// such as the source URL of imported text.
+func (s *TranslationStore) CreateWithMetadata(inputText string, sourceType string, lang LanguagePair, metadata map[string]string) (Translation, error) {
+	return s.CreateForUser(DefaultUserID, inputText, sourceType, lang, metadata, JobPriorityNormal)
+}
+
+// CreateForUser is CreateWithMetadata for a translation owned by userID
+// whose job is queued at priority.
+func (s *TranslationStore) CreateForUser(userID string, inputText string, sourceType string, lang LanguagePair, metadata map[string]string, priority int) (Translation, error) {
+	return s.create(userID, "", inputText, sourceType, lang, metadata, priority)
+}
+
+// CreateWithIdempotencyKey is CreateForUser that returns the user's existing
+// translation instead when one was created with the same key within window.
+// created reports whether a new translation was made.
+func (s *TranslationStore) CreateWithIdempotencyKey(userID string, key string, window time.Duration, inputText string, sourceType string, lang LanguagePair, metadata map[string]string, priority int) (Translation, bool, error) {
+	if userID == "" {
+		userID = DefaultUserID
 	}
-	if sourceType == "" {
-		sourceType = "text"
+	if existing, ok, err := s.findByIdempotencyKey(userID, key, window); err != nil || ok {
+		return existing, false, err
+	}
+	// An expired key still holds the unique index; release it for reuse.
+	if _, err := s.db.Exec(
+		`UPDATE translations SET idempotency_key = NULL WHERE user_id = ? AND idempotency_key = ?`,
+		userID, key,
+	); err != nil {
+		return Translation{}, false, fmt.Errorf("release idempotency key: %w", err)
 	}
 
-	id, err := newID()
+	tr, err := s.create(userID, key, inputText, sourceType, lang, metadata, priority)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		// A concurrent request with the same key won the insert.
+		if existing, ok, findErr := s.findByIdempotencyKey(userID, key, window); findErr == nil && ok {
+			return existing, false, nil
+		}
+	}
 	if err != nil {
-		return Translation{}, err
+		return Translation{}, false, err
+	}
+	return tr, true, nil
+}
+
+func (s *TranslationStore) findByIdempotencyKey(userID string, key string, window time.Duration) (Translation, bool, error) {
+	var id string
+	err := s.db.QueryRow(
+		`SELECT id FROM translations WHERE user_id = ? AND idempotency_key = ? AND created_at >= ?`,
+		userID, key, time.Now().UTC().Add(-window).Format(time.RFC3339),
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Translation{}, false, nil
+	}
+	if err != nil {
+		return Translation{}, false, fmt.Errorf("find idempotency key: %w", err)
 	}
+	tr, ok := s.Get(id)
+	return tr, ok, nil
+}
 
-	tr := Translation{
-		ID:         id,
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
-		Status:     "pending",
-		SourceType: sourceType,
-		InputText:  inputText,
-		Title:      computeTitle(inputText),
-		Sentences:  nil,
-		Progress:   0,
-		Total:      0,
+func (s *TranslationStore) create(userID string, idempotencyKey string, inputText string, sourceType string, lang LanguagePair, metadata map[string]string, priority int) (Translation, error) {
+	tr, metadataJSON, err := newTranslation(userID, inputText, sourceType, lang, metadata)
+	if err != nil {
+		return Translation{}, err
 	}
 
 	tx, err := s.db.Begin()
@@ -49,36 +101,115 @@ func (s *TranslationStore) Create(inputText string, sourceType string) (Translat
 	}
 	defer tx.Rollback()
 
+	if err := insertTranslation(tx, tr, metadataJSON, idempotencyKey, "pending", priority); err != nil {
+		return Translation{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Translation{}, fmt.Errorf("commit create translation tx: %w", err)
+	}
+
+	return tr, nil
+}
+
+// newTranslation validates and normalizes the input of a new translation
+// and returns it along with its encoded metadata.
+func newTranslation(userID string, inputText string, sourceType string, lang LanguagePair, metadata map[string]string) (Translation, []byte, error) {
+	if userID == "" {
+		userID = DefaultUserID
+	}
+	rawInputText := rawIfChanged(inputText)
+	inputText = NormalizeInputText(inputText)
+	if strings.TrimSpace(inputText) == "" {
+		return Translation{}, nil, errors.New("input_text is required")
+	}
+	if sourceType == "" {
+		sourceType = "text"
+	}
+	lang = lang.WithDefaults()
+	if err := lang.Validate(); err != nil {
+		return Translation{}, nil, err
+	}
+
+	metadataJSON := []byte("{}")
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return Translation{}, nil, fmt.Errorf("encode metadata: %w", err)
+		}
+		metadataJSON = encoded
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Translation{}, nil, err
+	}
+
+	return Translation{
+		ID:           id,
+		UserID:       userID,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		Status:       "pending",
+		SourceType:   sourceType,
+		SourceLang:   lang.Source,
+		TargetLang:   lang.Target,
+		InputText:    inputText,
+		RawInputText: rawInputText,
+		Title:        computeTitle(inputText),
+		Sentences:    nil,
+		Metadata:     metadata,
+		Progress:     0,
+		Total:        0,
+	}, metadataJSON, nil
+}
+
+// insertTranslation writes tr and its job, which starts in jobState at
+// priority.
+func insertTranslation(tx *sql.Tx, tr Translation, metadataJSON []byte, idempotencyKey string, jobState string, priority int) error {
+	var chunkGroupID sql.NullString
+	var chunkIndex, chunkCount sql.NullInt64
+	if tr.Chunk != nil {
+		chunkGroupID = sql.NullString{String: tr.Chunk.GroupID, Valid: true}
+		chunkIndex = sql.NullInt64{Int64: int64(tr.Chunk.Index), Valid: true}
+		chunkCount = sql.NullInt64{Int64: int64(tr.Chunk.Count), Valid: true}
+	}
 	if _, err := tx.Exec(
 		`INSERT INTO translations (
-		    id, created_at, updated_at, status, translation_type, source_type, input_text,
-		    full_translation, error_message, metadata_json, progress, total, title
+		    id, created_at, updated_at, status, translation_type, source_type, input_text, raw_input_text,
+		    full_translation, error_message, metadata_json, progress, total, title, source_lang, target_lang, user_id,
+		    idempotency_key, chunk_group_id, chunk_index, chunk_count
 		 )
-		 VALUES (?, ?, ?, ?, 'translation', ?, ?, NULL, NULL, '{}', 0, 0, ?)`,
+		 VALUES (?, ?, ?, ?, 'translation', ?, ?, ?, NULL, NULL, ?, 0, 0, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		tr.ID,
 		tr.CreatedAt,
 		tr.CreatedAt,
 		tr.Status,
 		tr.SourceType,
 		tr.InputText,
+		tr.RawInputText,
+		string(metadataJSON),
 		tr.Title,
+		tr.SourceLang,
+		tr.TargetLang,
+		tr.UserID,
+		sql.NullString{String: idempotencyKey, Valid: idempotencyKey != ""},
+		chunkGroupID,
+		chunkIndex,
+		chunkCount,
 	); err != nil {
-		return Translation{}, fmt.Errorf("insert translation: %w", err)
+		return fmt.Errorf("insert translation: %w", err)
 	}
 	if _, err := tx.Exec(
-		`INSERT INTO translation_jobs (translation_id, state, attempts, lease_until, last_error, created_at, updated_at)
-		 VALUES (?, 'pending', 0, NULL, NULL, ?, ?)`,
+		`INSERT INTO translation_jobs (translation_id, state, priority, attempts, lease_until, last_error, created_at, updated_at)
+		 VALUES (?, ?, ?, 0, NULL, NULL, ?, ?)`,
 		tr.ID,
+		jobState,
+		priority,
 		tr.CreatedAt,
 		tr.CreatedAt,
 	); err != nil {
-		return Translation{}, fmt.Errorf("insert translation job: %w", err)
-	}
-	if err := tx.Commit(); err != nil {
-		return Translation{}, fmt.Errorf("commit create translation tx: %w", err)
+		return fmt.Errorf("insert translation job: %w", err)
 	}
-
-	return tr, nil
+	return nil
 }
 
 func (s *TranslationStore) Get(id string) (Translation, bool) {
@@ -99,6 +230,47 @@ func (s *TranslationStore) Get(id string) (Translation, bool) {
 	return Translation{}, false
 }
 
+// GetStatusOnly reads a translation's status and progress counters without
+// loading its sentences, for callers polling a running job.
+func (s *TranslationStore) GetStatusOnly(id string) (status string, progress, total int, ok bool) {
+	for i := 0; i < 8; i++ {
+		err := s.db.QueryRow(`SELECT status, progress, total FROM translations WHERE id = ?`, id).Scan(&status, &progress, &total)
+		if err == nil {
+			return status, progress, total, true
+		}
+		if isDBLocked(err) {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		return "", 0, 0, false
+	}
+	return "", 0, 0, false
+}
+
+// GetOwner returns the id of the user who owns a translation without
+// loading the translation itself. It returns ErrNotFound when there is no
+// such translation and an error when the owner cannot be read, including
+// when the database stays locked through every retry, so callers can fail
+// closed.
+func (s *TranslationStore) GetOwner(id string) (string, error) {
+	var err error
+	for i := 0; i < 8; i++ {
+		var userID string
+		err = s.db.QueryRow(`SELECT user_id FROM translations WHERE id = ?`, id).Scan(&userID)
+		if err == nil {
+			return userID, nil
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		if !isDBLocked(err) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return "", fmt.Errorf("load translation owner: %w", err)
+}
+
 func (s *TranslationStore) Delete(id string) bool {
 	for i := 0; i < 8; i++ {
 		res, err := s.db.Exec(`DELETE FROM translations WHERE id = ?`, id)
@@ -118,9 +290,17 @@ func (s *TranslationStore) Delete(id string) bool {
 	return false
 }
 
-func (s *TranslationStore) List(limit int, offset int, status string) ([]Translation, int, error) {
-	if status != "" && status != "pending" && status != "processing" && status != "completed" && status != "failed" {
-		return nil, 0, errors.New("invalid status filter")
+// List pages through translations, newest first, that match filter.
+func (s *TranslationStore) List(limit int, offset int, filter ListFilter) ([]Translation, int, error) {
+	return s.ListForUser("", limit, offset, filter)
+}
+
+// ListForUser is List restricted to translations owned by userID. An empty
+// userID lists every user's translations.
+func (s *TranslationStore) ListForUser(userID string, limit int, offset int, filter ListFilter) ([]Translation, int, error) {
+	filter, err := normalizeListFilter(filter)
+	if err != nil {
+		return nil, 0, err
 	}
 	if limit <= 0 {
 		limit = 20
@@ -130,7 +310,7 @@ func (s *TranslationStore) List(limit int, offset int, status string) ([]Transla
 	}
 
 	for i := 0; i < 40; i++ {
-		items, total, err := s.listOnce(limit, offset, status)
+		items, total, err := s.listOnce(userID, limit, offset, filter)
 		if err == nil {
 			return items, total, nil
 		}
@@ -163,13 +343,14 @@ func (s *TranslationStore) SetProcessing(id string, total int, sentences []Sente
 	for sentenceIdx, sent := range sentences {
 		if _, err := tx.Exec(
 			`INSERT INTO translation_sentences (id, translation_id, sentence_idx, indent, separator, content_hash)
-			 VALUES (?, ?, ?, ?, ?, '')
+			 VALUES (?, ?, ?, ?, ?, ?)
 			 ON CONFLICT (translation_id, sentence_idx) DO NOTHING`,
 			fmt.Sprintf("%s:%d", id, sentenceIdx),
 			id,
 			sentenceIdx,
 			sent.Indent,
 			sent.Separator,
+			sentenceHash(sent.Text),
 		); err != nil {
 			return fmt.Errorf("ensure sentence row %d: %w", sentenceIdx, err)
 		}
@@ -210,8 +391,8 @@ func (s *TranslationStore) AddProgressSegment(id string, result SegmentResult, s
 		return 0, 0, fmt.Errorf("ensure sentence row: %w", err)
 	}
 	if _, err := tx.Exec(
-		`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, source, pos, classifier, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		fmt.Sprintf("%s:%d:%d", id, sentenceIndex, segIdx),
 		id,
 		sentenceIndex,
@@ -219,6 +400,9 @@ func (s *TranslationStore) AddProgressSegment(id string, result SegmentResult, s
 		result.Segment,
 		result.Pinyin,
 		result.English,
+		result.Source,
+		result.POS,
+		result.Classifier,
 		time.Now().UTC().Format(time.RFC3339Nano),
 	); err != nil {
 		return 0, 0, fmt.Errorf("insert translation segment: %w", err)
@@ -306,7 +490,13 @@ func (s *TranslationStore) Complete(id string) error {
 	return nil
 }
 
-func (s *TranslationStore) Fail(id string, message string) error {
+// Fail marks a translation failed. message is shown with the translation;
+// detail, the full error behind it, is kept on the job for debugging and
+// defaults to message when empty.
+func (s *TranslationStore) Fail(id string, message string, detail string) error {
+	if detail == "" {
+		detail = message
+	}
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("begin fail tx: %w", err)
@@ -329,7 +519,7 @@ func (s *TranslationStore) Fail(id string, message string) error {
 		`UPDATE translation_jobs
 		 SET state = 'failed', lease_until = NULL, last_error = ?, updated_at = ?
 		 WHERE translation_id = ?`,
-		message,
+		detail,
 		time.Now().UTC().Format(time.RFC3339Nano),
 		id,
 	); err != nil {
@@ -349,7 +539,7 @@ func (s *TranslationStore) GetProgressSnapshot(id string) (ProgressSnapshot, boo
 	}
 
 	rows, err := s.db.Query(
-		`SELECT segment_text, pinyin, english, seg_idx, sentence_idx
+		`SELECT segment_text, pinyin, english, source, pos, classifier, seg_idx, sentence_idx
 		 FROM translation_segments
 		 WHERE translation_id = ?
 		 ORDER BY sentence_idx ASC, seg_idx ASC`,
@@ -363,7 +553,7 @@ func (s *TranslationStore) GetProgressSnapshot(id string) (ProgressSnapshot, boo
 	snapshot.Results = make([]SegmentProgressEntry, 0)
 	for rows.Next() {
 		var seg SegmentProgressEntry
-		if err := rows.Scan(&seg.Segment, &seg.Pinyin, &seg.English, &seg.Index, &seg.SentenceIndex); err != nil {
+		if err := rows.Scan(&seg.Segment, &seg.Pinyin, &seg.English, &seg.Source, &seg.POS, &seg.Classifier, &seg.Index, &seg.SentenceIndex); err != nil {
 			return ProgressSnapshot{}, false
 		}
 		snapshot.Results = append(snapshot.Results, seg)
@@ -377,7 +567,8 @@ func (s *TranslationStore) GetProgressSnapshot(id string) (ProgressSnapshot, boo
 
 func (s *TranslationStore) getOnce(id string) (Translation, error) {
 	row := s.db.QueryRow(
-		`SELECT id, created_at, status, source_type, input_text, title, full_translation, error_message, progress, total
+		`SELECT id, user_id, created_at, status, source_type, source_lang, target_lang, input_text, raw_input_text, title, full_translation, error_message, progress, total, is_favorite, metadata_json,
+		        chunk_group_id, chunk_index, chunk_count
 		 FROM translations WHERE id = ?`,
 		id,
 	)
@@ -385,20 +576,39 @@ func (s *TranslationStore) getOnce(id string) (Translation, error) {
 	var tr Translation
 	var fullTranslation sql.NullString
 	var errorMessage sql.NullString
+	var metadataJSON string
+	var chunkGroupID sql.NullString
+	var chunkIndex, chunkCount sql.NullInt64
 	if err := row.Scan(
 		&tr.ID,
+		&tr.UserID,
 		&tr.CreatedAt,
 		&tr.Status,
 		&tr.SourceType,
+		&tr.SourceLang,
+		&tr.TargetLang,
 		&tr.InputText,
+		&tr.RawInputText,
 		&tr.Title,
 		&fullTranslation,
 		&errorMessage,
 		&tr.Progress,
 		&tr.Total,
+		&tr.IsFavorite,
+		&metadataJSON,
+		&chunkGroupID,
+		&chunkIndex,
+		&chunkCount,
 	); err != nil {
 		return Translation{}, err
 	}
+	if chunkGroupID.Valid {
+		tr.Chunk = &ChunkInfo{GroupID: chunkGroupID.String, Index: int(chunkIndex.Int64), Count: int(chunkCount.Int64)}
+	}
+	if metadataJSON != "" && metadataJSON != "{}" {
+		// Older rows may hold non-string values; those are not surfaced.
+		_ = json.Unmarshal([]byte(metadataJSON), &tr.Metadata)
+	}
 	if fullTranslation.Valid {
 		v := fullTranslation.String
 		tr.FullTranslation = &v
@@ -409,18 +619,72 @@ func (s *TranslationStore) getOnce(id string) (Translation, error) {
 	}
 
 	tr.Sentences = s.loadSentences(id)
+	if tags, err := s.loadTags([]string{id}); err == nil {
+		tr.Tags = tags[id]
+	}
 	return tr, nil
 }
 
-func (s *TranslationStore) listOnce(limit int, offset int, status string) ([]Translation, int, error) {
+// normalizeListFilter validates the status and normalizes and deduplicates
+// the tags of a ListFilter.
+func normalizeListFilter(filter ListFilter) (ListFilter, error) {
+	status := filter.Status
+	if status != "" && status != "pending" && status != "processing" && status != "completed" && status != "failed" {
+		return ListFilter{}, fmt.Errorf("%w: invalid status filter", ErrInvalidFilter)
+	}
+	tags := make([]string, 0, len(filter.Tags))
+	seen := make(map[string]bool, len(filter.Tags))
+	for _, tag := range filter.Tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return ListFilter{}, fmt.Errorf("%w: invalid tag filter: %w", ErrInvalidFilter, err)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	filter.Tags = tags
+	return filter, nil
+}
+
+// filterConditions turns a normalized ListFilter into WHERE conditions on
+// translations, to be joined with AND.
+func filterConditions(filter ListFilter) ([]string, []any) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 2+len(filter.Tags))
+	if filter.Status != "" {
+		conditions = append(conditions, `status = ?`)
+		args = append(args, filter.Status)
+	}
+	if filter.FavoriteOnly {
+		conditions = append(conditions, `is_favorite = 1`)
+	}
+	if len(filter.Tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filter.Tags)), ",")
+		conditions = append(conditions, `id IN (SELECT translation_id FROM translation_tags WHERE tag IN (`+placeholders+`)
+			GROUP BY translation_id HAVING COUNT(*) = ?)`)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(filter.Tags))
+	}
+	return conditions, args
+}
+
+func (s *TranslationStore) listOnce(userID string, limit int, offset int, filter ListFilter) ([]Translation, int, error) {
 	countQuery := `SELECT COUNT(*) FROM translations`
-	listQuery := `SELECT id, created_at, status, source_type, input_text, title, full_translation, error_message, progress, total
+	listQuery := `SELECT id, user_id, created_at, status, source_type, source_lang, target_lang, input_text, title, full_translation, error_message, progress, total, is_favorite
 		FROM translations`
-	args := make([]any, 0, 3)
-	if status != "" {
-		countQuery += ` WHERE status = ?`
-		listQuery += ` WHERE status = ?`
-		args = append(args, status)
+	conditions, args := filterConditions(filter)
+	if userID != "" {
+		conditions = append([]string{`user_id = ?`}, conditions...)
+		args = append([]any{userID}, args...)
+	}
+	if len(conditions) > 0 {
+		where := ` WHERE ` + strings.Join(conditions, ` AND `)
+		countQuery += where
+		listQuery += where
 	}
 	listQuery += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
 
@@ -443,15 +707,19 @@ func (s *TranslationStore) listOnce(limit int, offset int, status string) ([]Tra
 		var errorMessage sql.NullString
 		if err := rows.Scan(
 			&tr.ID,
+			&tr.UserID,
 			&tr.CreatedAt,
 			&tr.Status,
 			&tr.SourceType,
+			&tr.SourceLang,
+			&tr.TargetLang,
 			&tr.InputText,
 			&tr.Title,
 			&fullTranslation,
 			&errorMessage,
 			&tr.Progress,
 			&tr.Total,
+			&tr.IsFavorite,
 		); err != nil {
 			return nil, 0, fmt.Errorf("scan translation row: %w", err)
 		}
@@ -468,6 +736,19 @@ func (s *TranslationStore) listOnce(limit int, offset int, status string) ([]Tra
 	if err := rows.Err(); err != nil {
 		return nil, 0, fmt.Errorf("iterate translation rows: %w", err)
 	}
+	rows.Close()
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	tags, err := s.loadTags(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		items[i].Tags = tags[items[i].ID]
+	}
 
 	return items, total, nil
 }
@@ -492,10 +773,10 @@ func (s *TranslationStore) UpdateTranslationSegments(translationID string, sente
 	}
 	for idx, seg := range segments {
 		if _, err := tx.Exec(
-			`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, created_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, source, pos, classifier, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			fmt.Sprintf("%s:%d:%d", translationID, sentenceIdx, idx),
-			translationID, sentenceIdx, idx, seg.Segment, seg.Pinyin, seg.English, time.Now().UTC().Format(time.RFC3339Nano),
+			translationID, sentenceIdx, idx, seg.Segment, seg.Pinyin, seg.English, seg.Source, seg.POS, seg.Classifier, time.Now().UTC().Format(time.RFC3339Nano),
 		); err != nil {
 			return err
 		}
@@ -503,27 +784,153 @@ func (s *TranslationStore) UpdateTranslationSegments(translationID string, sente
 	return tx.Commit()
 }
 
+// UpdateSingleSegment overwrites the pinyin and english of one stored segment,
+// leaving the rest of its sentence untouched.
+func (s *TranslationStore) UpdateSingleSegment(translationID string, sentenceIdx, segIdx int, result SegmentResult) error {
+	res, err := s.db.Exec(
+		`UPDATE translation_segments SET pinyin = ?, english = ?, source = ?, pos = ?, classifier = ?
+		 WHERE translation_id = ? AND sentence_idx = ? AND seg_idx = ?`,
+		result.Pinyin, result.English, result.Source, result.POS, result.Classifier, translationID, sentenceIdx, segIdx,
+	)
+	if err != nil {
+		return fmt.Errorf("update segment: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MergeSegments joins the contiguous segments fromSegIdx..toSegIdx (inclusive)
+// of a sentence into one, re-indexes the segments after it, and returns the
+// sentence's updated segment list. The merged segment carries the joined
+// pinyin and english of its parts until the caller re-translates it. The new
+// segmentation is also kept as an override so translating the same sentence
+// again does not undo the correction.
+func (s *TranslationStore) MergeSegments(translationID string, sentenceIdx int, fromSegIdx, toSegIdx int) ([]SegmentResult, error) {
+	if fromSegIdx < 0 || toSegIdx <= fromSegIdx {
+		return nil, errors.New("to_seg_idx must be greater than from_seg_idx")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin merge segments tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`SELECT segment_text, pinyin, english, source, pos, classifier FROM translation_segments
+		 WHERE translation_id = ? AND sentence_idx = ?
+		 ORDER BY seg_idx ASC`,
+		translationID, sentenceIdx,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load sentence segments: %w", err)
+	}
+	var segments []SegmentResult
+	for rows.Next() {
+		var seg SegmentResult
+		if err := rows.Scan(&seg.Segment, &seg.Pinyin, &seg.English, &seg.Source, &seg.POS, &seg.Classifier); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan sentence segment: %w", err)
+		}
+		segments = append(segments, seg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sentence segments: %w", err)
+	}
+	if len(segments) == 0 {
+		return nil, ErrNotFound
+	}
+	if toSegIdx >= len(segments) {
+		return nil, errors.New("segment index out of range")
+	}
+
+	var text strings.Builder
+	pinyins := make([]string, 0, toSegIdx-fromSegIdx+1)
+	englishes := make([]string, 0, toSegIdx-fromSegIdx+1)
+	for _, seg := range segments[fromSegIdx : toSegIdx+1] {
+		text.WriteString(seg.Segment)
+		if seg.Pinyin != "" {
+			pinyins = append(pinyins, seg.Pinyin)
+		}
+		if seg.English != "" {
+			englishes = append(englishes, seg.English)
+		}
+	}
+	merged := make([]SegmentResult, 0, len(segments)-(toSegIdx-fromSegIdx))
+	merged = append(merged, segments[:fromSegIdx]...)
+	merged = append(merged, SegmentResult{
+		Segment: text.String(),
+		Pinyin:  strings.Join(pinyins, " "),
+		English: strings.Join(englishes, " "),
+		Source:  SegmentSourceFallback,
+	})
+	merged = append(merged, segments[toSegIdx+1:]...)
+
+	if _, err := tx.Exec(`DELETE FROM translation_segments WHERE translation_id = ? AND sentence_idx = ?`, translationID, sentenceIdx); err != nil {
+		return nil, fmt.Errorf("delete sentence segments: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for idx, seg := range merged {
+		if _, err := tx.Exec(
+			`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, source, pos, classifier, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			fmt.Sprintf("%s:%d:%d", translationID, sentenceIdx, idx),
+			translationID, sentenceIdx, idx, seg.Segment, seg.Pinyin, seg.English, seg.Source, seg.POS, seg.Classifier, now,
+		); err != nil {
+			return nil, fmt.Errorf("insert merged segment: %w", err)
+		}
+	}
+	var hash string
+	if err := tx.QueryRow(
+		`SELECT content_hash FROM translation_sentences WHERE translation_id = ? AND sentence_idx = ?`,
+		translationID, sentenceIdx,
+	).Scan(&hash); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("load sentence hash: %w", err)
+	}
+	if err := saveSegmentationOverride(tx, translationID, hash, merged); err != nil {
+		return nil, err
+	}
+	removed := toSegIdx - fromSegIdx
+	if _, err := tx.Exec(
+		`UPDATE translations
+		 SET total = MAX(total - ?, 0), progress = MAX(progress - ?, 0), updated_at = ?
+		 WHERE id = ?`,
+		removed, removed, now, translationID,
+	); err != nil {
+		return nil, fmt.Errorf("update segment counts: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit merge segments tx: %w", err)
+	}
+	return merged, nil
+}
+
 // UpdateInputTextForReprocessing diffs the new text against existing sentence hashes,
 // deletes stale segments, updates the translation's input_text + status, and returns
 // the map of sentenceIdx → sentence for only changed/new sentences.
 // Returns an empty map (no error) when the new text produces no changes.
 func (s *TranslationStore) UpdateInputTextForReprocessing(id string, newText string) (map[int]string, error) {
-	sentences := splitStoreSentences(newText)
+	// Check translation exists and pick up its source language for splitting.
+	var sourceLang string
+	if err := s.db.QueryRow(`SELECT source_lang FROM translations WHERE id = ?`, id).Scan(&sourceLang); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("check translation exists: %w", err)
+	}
+
+	rawText := rawIfChanged(newText)
+	newText = NormalizeInputText(newText)
+	sentences := textsplit.Split(newText, sourceLang)
 
 	// Compute hashes for the new sentences.
 	newHashes := make([]string, len(sentences))
 	for i, si := range sentences {
-		h := sha256.Sum256([]byte(si.Text))
-		newHashes[i] = fmt.Sprintf("%x", h)
-	}
-
-	// Check translation exists.
-	var exists int
-	if err := s.db.QueryRow(`SELECT COUNT(*) FROM translations WHERE id = ?`, id).Scan(&exists); err != nil {
-		return nil, fmt.Errorf("check translation exists: %w", err)
-	}
-	if exists == 0 {
-		return nil, ErrNotFound
+		newHashes[i] = sentenceHash(si.Text)
 	}
 
 	// Load existing sentence hashes (outside transaction — read-only).
@@ -610,8 +1017,9 @@ func (s *TranslationStore) UpdateInputTextForReprocessing(id string, newText str
 	}
 
 	if _, err := tx.Exec(
-		`UPDATE translations SET input_text = ?, status = 'pending', progress = 0, total = 0 WHERE id = ?`,
+		`UPDATE translations SET input_text = ?, raw_input_text = ?, status = 'pending', progress = 0, total = 0 WHERE id = ?`,
 		newText,
+		rawText,
 		id,
 	); err != nil {
 		return nil, fmt.Errorf("update input text: %w", err)
@@ -670,8 +1078,8 @@ func (s *TranslationStore) AddReprocessedSegment(id string, result SegmentResult
 	}
 
 	if _, err := tx.Exec(
-		`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO translation_segments (id, translation_id, sentence_idx, seg_idx, segment_text, pinyin, english, source, pos, classifier, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		fmt.Sprintf("%s:%d:%d", id, sentenceIdx, segIdx),
 		id,
 		sentenceIdx,
@@ -679,6 +1087,9 @@ func (s *TranslationStore) AddReprocessedSegment(id string, result SegmentResult
 		result.Segment,
 		result.Pinyin,
 		result.English,
+		result.Source,
+		result.POS,
+		result.Classifier,
 		time.Now().UTC().Format(time.RFC3339Nano),
 	); err != nil {
 		return fmt.Errorf("insert reprocessed segment: %w", err)
@@ -707,95 +1118,31 @@ func (s *TranslationStore) UpdateTitle(id string, title string) error {
 	return nil
 }
 
-type storeSentenceInfo struct {
-	Text      string
-	Indent    string
-	Separator string
-}
-
-// splitStoreSentences is a copy of the queue package's splitInputSentences logic,
-// kept here so the store package stays decoupled from queue.
-// It now also captures indent and separator for each sentence.
-func splitStoreSentences(text string) []storeSentenceInfo {
-	var out []storeSentenceInfo
-	var sentence strings.Builder
-	var lineIndent strings.Builder
-	atLineStart := true
-
-	addSeparatorChar := func(r rune) {
-		if len(out) > 0 {
-			out[len(out)-1].Separator += string(r)
-		}
-	}
-
-	for len(text) > 0 {
-		r, size := utf8.DecodeRuneInString(text)
-		text = text[size:]
-
-		if atLineStart {
-			if r == ' ' || r == '\t' {
-				lineIndent.WriteRune(r)
-				continue
-			}
-			if r == '\n' || r == '\r' {
-				addSeparatorChar(r)
-				lineIndent.Reset()
-				continue
-			}
-			atLineStart = false
-		}
-
-		if r == '\n' || r == '\r' {
-			s := strings.TrimSpace(sentence.String())
-			if s != "" {
-				out = append(out, storeSentenceInfo{
-					Text:   s,
-					Indent: lineIndent.String(),
-				})
-			}
-			addSeparatorChar(r)
-			sentence.Reset()
-			lineIndent.Reset()
-			atLineStart = true
-			continue
-		}
-
-		sentence.WriteRune(r)
-		if isStoreSentenceDelimiter(r) {
-			s := strings.TrimSpace(sentence.String())
-			if s != "" {
-				out = append(out, storeSentenceInfo{
-					Text:   s,
-					Indent: lineIndent.String(),
-				})
-				sentence.Reset()
-				lineIndent.Reset()
-			}
-		}
+// SetFavorite marks or unmarks a translation as a favorite.
+func (s *TranslationStore) SetFavorite(id string, fav bool) error {
+	res, err := s.db.Exec(`UPDATE translations SET is_favorite = ? WHERE id = ?`, fav, id)
+	if err != nil {
+		return fmt.Errorf("set favorite: %w", err)
 	}
-
-	if s := strings.TrimSpace(sentence.String()); s != "" {
-		out = append(out, storeSentenceInfo{
-			Text:   s,
-			Indent: lineIndent.String(),
-		})
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return ErrNotFound
 	}
-
-	return out
+	return nil
 }
 
-func isStoreSentenceDelimiter(r rune) bool {
-	switch r {
-	case '。', '！', '？', '!', '?', ';', '；':
-		return true
-	default:
-		return false
+// sentenceHash identifies a sentence by its text; empty text (callers that
+// did not pass it) hashes to "" so the sentence always counts as changed.
+func sentenceHash(text string) string {
+	if text == "" {
+		return ""
 	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(text)))
 }
 
 func (s *TranslationStore) loadSentences(translationID string) []SentenceResult {
 	rows, err := s.db.Query(
-		`SELECT sentence_idx, indent, separator
+		`SELECT sentence_idx, indent, separator, content_hash
 		 FROM translation_sentences
 		 WHERE translation_id = ?
 		 ORDER BY sentence_idx ASC`,
@@ -808,11 +1155,13 @@ func (s *TranslationStore) loadSentences(translationID string) []SentenceResult
 
 	sentences := make([]SentenceResult, 0)
 	indices := make([]int, 0)
+	keys := make([]string, 0)
 	for rows.Next() {
 		var idx int
 		var indent string
 		var separator string
-		if err := rows.Scan(&idx, &indent, &separator); err != nil {
+		var hash string
+		if err := rows.Scan(&idx, &indent, &separator, &hash); err != nil {
 			return nil
 		}
 		sentences = append(sentences, SentenceResult{
@@ -821,35 +1170,74 @@ func (s *TranslationStore) loadSentences(translationID string) []SentenceResult
 			Separator:    separator,
 		})
 		indices = append(indices, idx)
+		keys = append(keys, sentenceNoteKey(idx, hash))
 	}
 	if err := rows.Err(); err != nil {
 		return nil
 	}
+	_ = rows.Close()
+	notes := s.loadSegmentNotes(translationID)
 
+	position := make(map[int]int, len(indices))
 	for i, sentenceIdx := range indices {
-		segRows, err := s.db.Query(
-			`SELECT segment_text, pinyin, english
-			 FROM translation_segments
-			 WHERE translation_id = ? AND sentence_idx = ?
-			 ORDER BY seg_idx ASC`,
-			translationID,
-			sentenceIdx,
-		)
-		if err != nil {
+		position[sentenceIdx] = i
+	}
+	// One query for every segment, grouped into sentences here, instead of
+	// a query per sentence.
+	segRows, err := s.db.Query(
+		`SELECT sentence_idx, segment_text, pinyin, english, source, pos, classifier
+		 FROM translation_segments
+		 WHERE translation_id = ?
+		 ORDER BY sentence_idx ASC, seg_idx ASC`,
+		translationID,
+	)
+	if err != nil {
+		return nil
+	}
+	defer segRows.Close()
+	for segRows.Next() {
+		var sentenceIdx int
+		var seg SegmentResult
+		if err := segRows.Scan(&sentenceIdx, &seg.Segment, &seg.Pinyin, &seg.English, &seg.Source, &seg.POS, &seg.Classifier); err != nil {
 			return nil
 		}
-		segments := make([]SegmentResult, 0)
-		for segRows.Next() {
-			var seg SegmentResult
-			if err := segRows.Scan(&seg.Segment, &seg.Pinyin, &seg.English); err != nil {
-				_ = segRows.Close()
-				return nil
-			}
-			segments = append(segments, seg)
+		i, ok := position[sentenceIdx]
+		if !ok {
+			continue
 		}
-		_ = segRows.Close()
-		sentences[i].Translations = segments
+		segments := sentences[i].Translations
+		if note, ok := notes[segmentNoteKey{keys[i], len(segments)}]; ok && note.segment == seg.Segment {
+			seg.Note = note.note
+		}
+		sentences[i].Translations = append(segments, seg)
+	}
+	if err := segRows.Err(); err != nil {
+		return nil
 	}
 
 	return sentences
 }
+
+// CountByStatus returns the number of translations in each status across all
+// users.
+func (s *TranslationStore) CountByStatus() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT status, COUNT(*) FROM translations GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count translations by status: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("scan translation status count: %w", err)
+		}
+		counts[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate translation status counts: %w", err)
+	}
+	return counts, nil
+}
@@ -1,8 +1,12 @@
 package translation
 
 import (
+	"errors"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/anath2/language-app/internal/migrations"
 )
@@ -20,3 +24,431 @@ func newTranslationStoreWithMigrations(t *testing.T) *TranslationStore {
 	}
 	return NewTranslationStore(db)
 }
+
+func TestCreateWithLanguagePairPersistsAndValidates(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	defaulted, err := store.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create default translation: %v", err)
+	}
+	got, ok := store.Get(defaulted.ID)
+	if !ok || got.SourceLang != "zh" || got.TargetLang != "en" {
+		t.Fatalf("expected zh→en defaults, got %+v", got)
+	}
+
+	ja, err := store.CreateWithLanguagePair("こんにちは。", "text", LanguagePair{Source: "ja"})
+	if err != nil {
+		t.Fatalf("create japanese translation: %v", err)
+	}
+	got, ok = store.Get(ja.ID)
+	if !ok || got.SourceLang != "ja" || got.TargetLang != "en" {
+		t.Fatalf("expected ja→en, got %+v", got)
+	}
+
+	if _, err := store.CreateWithLanguagePair("hello", "text", LanguagePair{Source: "xx", Target: "en"}); err == nil {
+		t.Fatal("expected unsupported source_lang to be rejected")
+	}
+}
+
+func TestUpdateSingleSegmentOnlyTouchesTargetSegment(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	tr, err := store.Create("你好世界。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.UpdateTranslationSegments(tr.ID, 0, []SegmentResult{
+		{Segment: "你好", Pinyin: "nǐ hǎo", English: "hello"},
+		{Segment: "世界", Pinyin: "shì jiè", English: "wrong"},
+	}); err != nil {
+		t.Fatalf("seed segments: %v", err)
+	}
+
+	if err := store.UpdateSingleSegment(tr.ID, 0, 1, SegmentResult{Segment: "世界", Pinyin: "shì jiè", English: "world"}); err != nil {
+		t.Fatalf("update single segment: %v", err)
+	}
+	got, ok := store.Get(tr.ID)
+	if !ok || len(got.Sentences) != 1 || len(got.Sentences[0].Translations) != 2 {
+		t.Fatalf("unexpected sentences: %+v", got.Sentences)
+	}
+	segs := got.Sentences[0].Translations
+	if segs[0].English != "hello" || segs[1].English != "world" {
+		t.Fatalf("expected only the second segment to change, got %+v", segs)
+	}
+
+	if err := store.UpdateSingleSegment(tr.ID, 0, 5, SegmentResult{}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for missing segment, got %v", err)
+	}
+}
+
+func TestMergeSegmentsJoinsRangeAndReindexes(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	tr, err := store.Create("我去图书馆。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.UpdateTranslationSegments(tr.ID, 0, []SegmentResult{
+		{Segment: "我", Pinyin: "wǒ", English: "I"},
+		{Segment: "去", Pinyin: "qù", English: "go"},
+		{Segment: "图书", Pinyin: "túshū", English: "books"},
+		{Segment: "馆", Pinyin: "guǎn", English: "hall"},
+		{Segment: "。"},
+	}); err != nil {
+		t.Fatalf("seed segments: %v", err)
+	}
+
+	merged, err := store.MergeSegments(tr.ID, 0, 2, 3)
+	if err != nil {
+		t.Fatalf("merge segments: %v", err)
+	}
+	if len(merged) != 4 || merged[2].Segment != "图书馆" || merged[3].Segment != "。" {
+		t.Fatalf("unexpected merged segments: %+v", merged)
+	}
+	if merged[2].Pinyin != "túshū guǎn" {
+		t.Fatalf("expected joined placeholder pinyin, got %q", merged[2].Pinyin)
+	}
+
+	got, _ := store.Get(tr.ID)
+	if len(got.Sentences) != 1 || len(got.Sentences[0].Translations) != 4 || got.Sentences[0].Translations[3].Segment != "。" {
+		t.Fatalf("expected re-indexed stored segments, got %+v", got.Sentences)
+	}
+
+	if _, err := store.MergeSegments(tr.ID, 0, 2, 9); err == nil {
+		t.Fatal("expected out-of-range merge to fail")
+	}
+	if _, err := store.MergeSegments(tr.ID, 3, 0, 1); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for missing sentence, got %v", err)
+	}
+}
+
+func TestMergeSegmentsSavesSegmentationOverride(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	tr, err := store.Create("我去图书馆。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 5, []SentenceInit{{Text: "我去图书馆。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	if err := store.UpdateTranslationSegments(tr.ID, 0, []SegmentResult{
+		{Segment: "我"}, {Segment: "去"}, {Segment: "图书"}, {Segment: "馆"}, {Segment: "。"},
+	}); err != nil {
+		t.Fatalf("seed segments: %v", err)
+	}
+	lang := DefaultLanguagePair()
+	if _, ok, err := store.GetSegmentationOverride(DefaultUserID, "我去图书馆。", lang); err != nil || ok {
+		t.Fatalf("expected no override before merging, got ok=%v err=%v", ok, err)
+	}
+
+	if _, err := store.MergeSegments(tr.ID, 0, 2, 3); err != nil {
+		t.Fatalf("merge segments: %v", err)
+	}
+	segments, ok, err := store.GetSegmentationOverride(DefaultUserID, "我去图书馆。", lang)
+	if err != nil || !ok {
+		t.Fatalf("expected override after merging, got ok=%v err=%v", ok, err)
+	}
+	if strings.Join(segments, "|") != "我|去|图书馆|。" {
+		t.Fatalf("unexpected override: %v", segments)
+	}
+	if _, ok, _ := store.GetSegmentationOverride("someone-else", "我去图书馆。", lang); ok {
+		t.Fatal("expected override to belong to the translation's owner only")
+	}
+}
+
+func TestCreateWithMetadataRoundTrips(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	tr, err := store.CreateWithMetadata("你好。", "url", DefaultLanguagePair(), map[string]string{"source_url": "https://example.com/a"})
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	got, ok := store.Get(tr.ID)
+	if !ok {
+		t.Fatal("expected translation to exist")
+	}
+	if got.SourceType != "url" || got.Metadata["source_url"] != "https://example.com/a" {
+		t.Fatalf("unexpected translation: type=%q metadata=%v", got.SourceType, got.Metadata)
+	}
+
+	plain, err := store.Create("再见。", "text")
+	if err != nil {
+		t.Fatalf("create plain translation: %v", err)
+	}
+	if got, _ := store.Get(plain.ID); got.Metadata != nil {
+		t.Fatalf("expected no metadata, got %v", got.Metadata)
+	}
+}
+
+func TestUsersAuthenticateAndScopeTranslations(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	userStore := &UserStore{db: store.db}
+
+	user, err := userStore.CreateUser("mei", "s3cret")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := userStore.CreateUser("mei", "other"); err != ErrUsernameTaken {
+		t.Fatalf("expected ErrUsernameTaken, got %v", err)
+	}
+	if got, err := userStore.Authenticate("mei", "s3cret"); err != nil || got.ID != user.ID {
+		t.Fatalf("expected authenticated user %s, got %+v err=%v", user.ID, got, err)
+	}
+	if _, err := userStore.Authenticate("mei", "wrong"); err != ErrInvalidCredentials {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := userStore.Authenticate(DefaultUserID, ""); err != ErrInvalidCredentials {
+		t.Fatalf("default user must not sign in by name, got %v", err)
+	}
+
+	legacy, err := store.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create default translation: %v", err)
+	}
+	if legacy.UserID != DefaultUserID {
+		t.Fatalf("expected default owner, got %q", legacy.UserID)
+	}
+	owned, err := store.CreateForUser(user.ID, "世界", "text", DefaultLanguagePair(), nil, JobPriorityNormal)
+	if err != nil {
+		t.Fatalf("create user translation: %v", err)
+	}
+	if got, ok := store.Get(owned.ID); !ok || got.UserID != user.ID {
+		t.Fatalf("expected owner %s, got %+v", user.ID, got)
+	}
+
+	items, total, err := store.ListForUser(user.ID, 20, 0, ListFilter{})
+	if err != nil {
+		t.Fatalf("list for user: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].ID != owned.ID {
+		t.Fatalf("expected only the user's translation, got total=%d items=%+v", total, items)
+	}
+	if _, total, _ := store.List(20, 0, ListFilter{}); total != 2 {
+		t.Fatalf("expected unscoped list to see both translations, got %d", total)
+	}
+}
+
+func TestCreateWithIdempotencyKeyReplaysWithinWindow(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	first, created, err := store.CreateWithIdempotencyKey(DefaultUserID, "key-1", time.Hour, "你好。", "text", DefaultLanguagePair(), nil, JobPriorityNormal)
+	if err != nil || !created {
+		t.Fatalf("expected first create to insert, got created=%v err=%v", created, err)
+	}
+	again, created, err := store.CreateWithIdempotencyKey(DefaultUserID, "key-1", time.Hour, "你好。", "text", DefaultLanguagePair(), nil, JobPriorityNormal)
+	if err != nil || created || again.ID != first.ID {
+		t.Fatalf("expected replay of %s, got id=%s created=%v err=%v", first.ID, again.ID, created, err)
+	}
+
+	other, created, err := store.CreateWithIdempotencyKey("someone-else", "key-1", time.Hour, "你好。", "text", DefaultLanguagePair(), nil, JobPriorityNormal)
+	if err != nil || !created || other.ID == first.ID {
+		t.Fatalf("expected keys to be scoped per user, got id=%s created=%v err=%v", other.ID, created, err)
+	}
+
+	stale := time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)
+	if _, err := store.db.Exec(`UPDATE translations SET created_at = ? WHERE id = ?`, stale, first.ID); err != nil {
+		t.Fatalf("age translation: %v", err)
+	}
+	fresh, created, err := store.CreateWithIdempotencyKey(DefaultUserID, "key-1", time.Hour, "你好。", "text", DefaultLanguagePair(), nil, JobPriorityNormal)
+	if err != nil || !created || fresh.ID == first.ID {
+		t.Fatalf("expected expired key to create a new translation, got id=%s created=%v err=%v", fresh.ID, created, err)
+	}
+}
+
+func TestCreateInsertsJobAtPriority(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+	jobPriority := func(id string) int {
+		t.Helper()
+		var priority int
+		if err := store.db.QueryRow(`SELECT priority FROM translation_jobs WHERE translation_id = ?`, id).Scan(&priority); err != nil {
+			t.Fatalf("read job priority: %v", err)
+		}
+		return priority
+	}
+
+	high, err := store.CreateForUser(DefaultUserID, "你好。", "text", DefaultLanguagePair(), nil, JobPriorityHigh)
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if got := jobPriority(high.ID); got != JobPriorityHigh {
+		t.Fatalf("expected priority %d, got %d", JobPriorityHigh, got)
+	}
+	keyed, _, err := store.CreateWithIdempotencyKey(DefaultUserID, "key-1", time.Hour, "你好。", "text", DefaultLanguagePair(), nil, JobPriorityLow)
+	if err != nil {
+		t.Fatalf("create keyed translation: %v", err)
+	}
+	if got := jobPriority(keyed.ID); got != JobPriorityLow {
+		t.Fatalf("expected priority %d, got %d", JobPriorityLow, got)
+	}
+	parts, err := store.CreateChunkedForUser(DefaultUserID, []string{"第一句。", "第二句。"}, "text", LanguagePair{}, nil, JobPriorityLow)
+	if err != nil {
+		t.Fatalf("create chunked translation: %v", err)
+	}
+	for _, part := range parts {
+		if got := jobPriority(part.ID); got != JobPriorityLow {
+			t.Fatalf("expected part priority %d, got %d", JobPriorityLow, got)
+		}
+	}
+	if plain, err := store.Create("你好。", "text"); err != nil || jobPriority(plain.ID) != JobPriorityNormal {
+		t.Fatalf("expected Create to queue at normal priority, err=%v", err)
+	}
+}
+
+func TestSetFavoriteFiltersList(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	pinned, err := store.Create("你好", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if _, err := store.Create("世界", "text"); err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetFavorite(pinned.ID, true); err != nil {
+		t.Fatalf("set favorite: %v", err)
+	}
+	if got, _ := store.Get(pinned.ID); !got.IsFavorite {
+		t.Fatal("expected translation to be a favorite")
+	}
+
+	items, total, err := store.List(20, 0, ListFilter{FavoriteOnly: true})
+	if err != nil {
+		t.Fatalf("list favorites: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].ID != pinned.ID || !items[0].IsFavorite {
+		t.Fatalf("expected only the favorite, got total=%d items=%+v", total, items)
+	}
+	if _, total, _ := store.List(20, 0, ListFilter{Status: "completed", FavoriteOnly: true}); total != 0 {
+		t.Fatalf("expected status and favorite filters to compose, got %d", total)
+	}
+	if _, total, _ := store.List(20, 0, ListFilter{Status: "pending", FavoriteOnly: true}); total != 1 {
+		t.Fatalf("expected pending favorite, got %d", total)
+	}
+
+	if err := store.SetFavorite(pinned.ID, false); err != nil {
+		t.Fatalf("unset favorite: %v", err)
+	}
+	if _, total, _ := store.List(20, 0, ListFilter{FavoriteOnly: true}); total != 0 {
+		t.Fatalf("expected no favorites after unsetting, got %d", total)
+	}
+	if err := store.SetFavorite("missing", true); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetStatusOnlyAndGetOwnerReadJustTheRow(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	tr, err := store.Create("你好。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 2, []SentenceInit{{Text: "你好。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	if _, _, err := store.AddProgressSegment(tr.ID, SegmentResult{Segment: "你好", English: "hello"}, 0); err != nil {
+		t.Fatalf("add segment: %v", err)
+	}
+
+	status, progress, total, ok := store.GetStatusOnly(tr.ID)
+	if !ok || status != "processing" || progress != 1 || total != 2 {
+		t.Fatalf("expected processing 1/2, got %q %d/%d ok=%v", status, progress, total, ok)
+	}
+	if owner, err := store.GetOwner(tr.ID); err != nil || owner != DefaultUserID {
+		t.Fatalf("expected default owner, got %q err=%v", owner, err)
+	}
+
+	if _, _, _, ok := store.GetStatusOnly("missing"); ok {
+		t.Fatal("expected no status for a missing translation")
+	}
+	if _, err := store.GetOwner("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing translation, got %v", err)
+	}
+}
+
+func TestGetGroupsSegmentsBySentence(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	tr, err := store.Create("你好。再见。谢谢。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 4, []SentenceInit{{Text: "你好。"}, {Text: "再见。"}, {Text: "谢谢。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	for _, add := range []struct {
+		sentence int
+		segment  string
+	}{{0, "你"}, {0, "好"}, {2, "谢谢"}, {2, "。"}} {
+		if _, _, err := store.AddProgressSegment(tr.ID, SegmentResult{Segment: add.segment}, add.sentence); err != nil {
+			t.Fatalf("add segment: %v", err)
+		}
+	}
+
+	got, ok := store.Get(tr.ID)
+	if !ok {
+		t.Fatal("expected translation")
+	}
+	if len(got.Sentences) != 3 {
+		t.Fatalf("expected 3 sentences, got %d", len(got.Sentences))
+	}
+	want := [][]string{{"你", "好"}, {}, {"谢谢", "。"}}
+	for i, sentence := range got.Sentences {
+		segments := make([]string, 0, len(sentence.Translations))
+		for _, seg := range sentence.Translations {
+			segments = append(segments, seg.Segment)
+		}
+		if !reflect.DeepEqual(segments, want[i]) {
+			t.Fatalf("sentence %d: expected %v, got %v", i, want[i], segments)
+		}
+	}
+}
+
+func TestSegmentPOSAndClassifierAreStoredAndCached(t *testing.T) {
+	store := newTranslationStoreWithMigrations(t)
+
+	tr, err := store.Create("我学习。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 2, []SentenceInit{{Text: "我学习。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	for _, seg := range []SegmentResult{{Segment: "我", POS: POSPronoun}, {Segment: "学习", POS: POSVerb}} {
+		if _, _, err := store.AddProgressSegment(tr.ID, seg, 0); err != nil {
+			t.Fatalf("add segment: %v", err)
+		}
+	}
+	if err := store.UpdateSingleSegment(tr.ID, 0, 1, SegmentResult{English: "study", POS: POSNoun, Classifier: "门"}); err != nil {
+		t.Fatalf("update segment: %v", err)
+	}
+	got, ok := store.Get(tr.ID)
+	if !ok || len(got.Sentences) != 1 {
+		t.Fatalf("expected one sentence, got %+v", got.Sentences)
+	}
+	if segs := got.Sentences[0].Translations; segs[0].POS != POSPronoun || segs[1].POS != POSNoun || segs[1].Classifier != "门" {
+		t.Fatalf("expected stored parts of speech and classifier, got %+v", segs)
+	}
+
+	cache := &SegmentCacheStore{db: store.db}
+	lang := DefaultLanguagePair()
+	if err := cache.PutCachedSegment(SegmentResult{Segment: "书", English: "book", POS: POSNoun, Classifier: "本"}, lang, ""); err != nil {
+		t.Fatalf("put cached segment: %v", err)
+	}
+	if cached, ok, err := cache.GetCachedSegment("书", lang, ""); err != nil || !ok || cached.POS != POSNoun || cached.Classifier != "本" {
+		t.Fatalf("expected the cached part of speech, got %+v (ok=%v err=%v)", cached, ok, err)
+	}
+}
+
+func TestNormalizePOS(t *testing.T) {
+	for label, want := range map[string]string{
+		"Verb":          POSVerb,
+		" measure word": POSMeasureWord,
+		"classifier":    POSMeasureWord,
+		"adj":           POSAdjective,
+		"gerund":        "",
+		"":              "",
+	} {
+		if got := NormalizePOS(label); got != want {
+			t.Fatalf("NormalizePOS(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
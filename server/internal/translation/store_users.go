@@ -0,0 +1,138 @@
+package translation
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Password hashes are stored as "pbkdf2-sha256$<iterations>$<salt>$<key>"
+// with base64 (raw, URL-safe) salt and key.
+const (
+	passwordHashScheme     = "pbkdf2-sha256"
+	passwordHashIterations = 600_000
+	passwordSaltBytes      = 16
+	passwordKeyBytes       = 32
+)
+
+// CreateUser adds an account with a hashed password.
+func (s *UserStore) CreateUser(username string, password string) (User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return User{}, errors.New("username is required")
+	}
+	if password == "" {
+		return User{}, errors.New("password is required")
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return User{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.db.Exec(
+		`INSERT INTO users (id, username, password_hash, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		id, username, hash, now, now,
+	); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return User{}, ErrUsernameTaken
+		}
+		return User{}, fmt.Errorf("insert user: %w", err)
+	}
+	return User{ID: id, Username: username, CreatedAt: now}, nil
+}
+
+// Authenticate returns the user whose username and password match. Accounts
+// without a password hash, such as the default user, cannot sign in by name.
+func (s *UserStore) Authenticate(username string, password string) (User, error) {
+	var user User
+	var hash string
+	err := s.db.QueryRow(
+		`SELECT id, username, password_hash, created_at FROM users WHERE username = ?`,
+		strings.TrimSpace(username),
+	).Scan(&user.ID, &user.Username, &hash, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("load user: %w", err)
+	}
+	if hash == "" || !verifyPassword(password, hash) {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// ListUsers returns all accounts ordered by username.
+func (s *UserStore) ListUsers() ([]User, error) {
+	rows, err := s.db.Query(`SELECT id, username, created_at FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan user row: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user rows: %w", err)
+	}
+	return users, nil
+}
+
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate password salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, passwordKeyBytes)
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+	return strings.Join([]string{
+		passwordHashScheme,
+		strconv.Itoa(passwordHashIterations),
+		base64.RawURLEncoding.EncodeToString(salt),
+		base64.RawURLEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+func verifyPassword(password string, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != passwordHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
@@ -0,0 +1,151 @@
+package translation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxVocabImportErrors caps how many row errors ImportVocabCSV reports; the
+// rest are still counted as skipped.
+const maxVocabImportErrors = 100
+
+// VocabImportError is a row ImportVocabCSV could not import.
+type VocabImportError struct {
+	Line    int
+	Message string
+}
+
+// VocabImportResult summarises an ImportVocabCSV run.
+type VocabImportResult struct {
+	Imported int
+	Skipped  int
+	Errors   []VocabImportError
+}
+
+// ImportVocabCSV saves the words in a CSV alongside the user's vocabulary,
+// each with a fresh SRS schedule. A header row naming a headword column is
+// optional: with one, the pinyin, english, status and type columns are read
+// by name; without one, columns are headword, pinyin, english and status in
+// that order, so a bare wordlist with one word per line works. Words already
+// saved under the same headword, repeats within the file and rows of type
+// "character" (characters are saved from their words) are skipped. Rows
+// with an empty headword or unknown status are reported by line and skipped.
+func (s *SRSStore) ImportVocabCSV(userID string, input string) (VocabImportResult, error) {
+	var result VocabImportResult
+	reportError := func(line int, message string) {
+		result.Skipped++
+		if len(result.Errors) < maxVocabImportErrors {
+			result.Errors = append(result.Errors, VocabImportError{Line: line, Message: message})
+		}
+	}
+
+	existing, err := s.savedHeadwords(userID)
+	if err != nil {
+		return result, err
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(input, "\ufeff")))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	columns := map[string]int{"headword": 0, "pinyin": 1, "english": 2, "status": 3}
+	first := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			reportError(parseErr.Line, parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("read vocab csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			if header, ok := vocabCSVColumns(record); ok {
+				columns = header
+				continue
+			}
+		}
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		headword := field("headword")
+		if headword == "" {
+			if strings.TrimSpace(strings.Join(record, "")) == "" {
+				continue
+			}
+			reportError(line, "headword is required")
+			continue
+		}
+		if strings.EqualFold(field("type"), "character") {
+			result.Skipped++
+			continue
+		}
+		status := strings.ToLower(field("status"))
+		if status != "" && !isValidStatus(status) {
+			reportError(line, fmt.Sprintf("invalid status %q: must be unknown, learning or known", status))
+			continue
+		}
+		if existing[headword] {
+			result.Skipped++
+			continue
+		}
+
+		pinyin, english := field("pinyin"), field("english")
+		id, err := s.SaveSegment(userID, headword, pinyin, english, nil, nil, status)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := s.ExtractAndLinkCharacters(userID, id, headword, pinyin, english, nil); err != nil {
+			return result, fmt.Errorf("line %d: link characters: %w", line, err)
+		}
+		existing[headword] = true
+		result.Imported++
+	}
+	return result, nil
+}
+
+// vocabCSVColumns maps column names to indexes when record is a header row,
+// that is, when one of its fields is "headword".
+func vocabCSVColumns(record []string) (map[string]int, bool) {
+	columns := make(map[string]int, len(record))
+	for i, name := range record {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, ok := columns["headword"]
+	return columns, ok
+}
+
+// savedHeadwords returns the headwords of every word the user saved.
+func (s *SRSStore) savedHeadwords(userID string) (map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT headword FROM saved_segments WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query saved headwords: %w", err)
+	}
+	defer rows.Close()
+	headwords := make(map[string]bool)
+	for rows.Next() {
+		var headword string
+		if err := rows.Scan(&headword); err != nil {
+			return nil, fmt.Errorf("scan saved headword: %w", err)
+		}
+		headwords[headword] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate saved headwords: %w", err)
+	}
+	return headwords, nil
+}
@@ -0,0 +1,71 @@
+package translation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportVocabCSVWithHeader(t *testing.T) {
+	store := newSRSStoreWithMigrations(t)
+	if _, err := store.SaveSegment(DefaultUserID, "你好", "nǐ hǎo", "hello", nil, nil, "known"); err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+
+	input := "\ufeffEnglish,Headword,Pinyin,Status,Type\n" +
+		"\"to study, to learn\",学习,xué xí,learning,word\n" +
+		"hello again,你好,nǐ hǎo,,word\n" +
+		"learn,学,xué,,character\n" +
+		",,,,\n" +
+		"no headword,,,learning,word\n" +
+		"teacher,老师,lǎo shī,mastered,word\n" +
+		"to study,学习,xué xí,,word\n" +
+		"book,书,shū,KNOWN,word\n"
+
+	result, err := store.ImportVocabCSV(DefaultUserID, input)
+	if err != nil {
+		t.Fatalf("import vocab csv: %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 5 {
+		t.Fatalf("expected 2 imported and 5 skipped, got %+v", result)
+	}
+	wantErrors := []VocabImportError{
+		{Line: 6, Message: "headword is required"},
+		{Line: 7, Message: `invalid status "mastered": must be unknown, learning or known`},
+	}
+	if !reflect.DeepEqual(result.Errors, wantErrors) {
+		t.Fatalf("unexpected errors: %+v", result.Errors)
+	}
+
+	info, err := store.GetSegmentSRSInfo(DefaultUserID, []string{"学习", "书"})
+	if err != nil {
+		t.Fatalf("get srs info: %v", err)
+	}
+	if len(info) != 2 {
+		t.Fatalf("expected both imported words to be scheduled, got %+v", info)
+	}
+	for _, item := range info {
+		if item.Headword == "书" && item.Status != "known" {
+			t.Fatalf("expected 书 to be known, got %+v", item)
+		}
+	}
+}
+
+func TestImportVocabCSVReadsBareWordlist(t *testing.T) {
+	store := newSRSStoreWithMigrations(t)
+
+	result, err := store.ImportVocabCSV(DefaultUserID, "爱\n八,bā\n\n爸爸,bà ba,dad\n")
+	if err != nil {
+		t.Fatalf("import vocab csv: %v", err)
+	}
+	if result.Imported != 3 || result.Skipped != 0 || len(result.Errors) != 0 {
+		t.Fatalf("expected 3 imported, got %+v", result)
+	}
+
+	result, err = store.ImportVocabCSV(DefaultUserID, "爱\n\"unterminated\n")
+	if err != nil {
+		t.Fatalf("import vocab csv: %v", err)
+	}
+	if result.Imported != 0 || result.Skipped != 2 || len(result.Errors) != 1 || result.Errors[0].Line != 2 {
+		t.Fatalf("expected the duplicate skipped and a parse error on line 2, got %+v", result)
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+
+	pinyinpkg "github.com/anath2/language-app/internal/pinyin"
 )
 
 const (
@@ -14,7 +18,7 @@ const (
 	reviewEntityCharacter = "character"
 )
 
-func (s *SRSStore) SaveSegment(headword string, pinyin string, english string, translationID *string, snippet *string, status string) (string, error) {
+func (s *SRSStore) SaveSegment(userID string, headword string, pinyin string, english string, translationID *string, snippet *string, status string) (string, error) {
 	if strings.TrimSpace(headword) == "" {
 		return "", errors.New("headword is required")
 	}
@@ -24,19 +28,22 @@ func (s *SRSStore) SaveSegment(headword string, pinyin string, english string, t
 	if !isValidStatus(status) {
 		return "", errors.New("invalid status")
 	}
+	pinyin = normalizePinyin(pinyin, s.cfg.PinyinStyle)
 	now := time.Now().UTC().Format(time.RFC3339Nano)
+	frequencyRank := s.frequencyRank(headword)
+	hskLevel := s.hskLevel(headword)
 	id, _ := newID()
 	if _, err := s.db.Exec(
-		`INSERT OR IGNORE INTO saved_segments (id, headword, pinyin, english, status, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		id, strings.TrimSpace(headword), strings.TrimSpace(pinyin), strings.TrimSpace(english), status, now, now,
+		`INSERT OR IGNORE INTO saved_segments (id, user_id, headword, pinyin, english, status, frequency_rank, hsk_level, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, userID, strings.TrimSpace(headword), strings.TrimSpace(pinyin), strings.TrimSpace(english), status, frequencyRank, hskLevel, now, now,
 	); err != nil {
 		return "", fmt.Errorf("insert segment: %w", err)
 	}
 	var segmentID string
 	if err := s.db.QueryRow(
-		`SELECT id FROM saved_segments WHERE headword = ? AND pinyin = ?`,
-		strings.TrimSpace(headword), strings.TrimSpace(pinyin),
+		`SELECT id FROM saved_segments WHERE user_id = ? AND headword = ? AND pinyin = ?`,
+		userID, strings.TrimSpace(headword), strings.TrimSpace(pinyin),
 	).Scan(&segmentID); err != nil {
 		return "", fmt.Errorf("resolve segment id: %w", err)
 	}
@@ -57,24 +64,164 @@ func (s *SRSStore) SaveSegment(headword string, pinyin string, english string, t
 		     last_seen_translation_id = COALESCE(?, last_seen_translation_id),
 		     last_seen_snippet = CASE WHEN ? = '' THEN last_seen_snippet ELSE ? END,
 		     last_seen_at = ?,
-		     seen_count = seen_count + 1
+		     seen_count = seen_count + 1,
+		     frequency_rank = COALESCE(?, frequency_rank),
+		     hsk_level = COALESCE(?, hsk_level)
 		 WHERE id = ?`,
-		now, strings.TrimSpace(english), strings.TrimSpace(english), status, translationIDVal, snippetVal, snippetVal, now, segmentID,
+		now, strings.TrimSpace(english), strings.TrimSpace(english), status, translationIDVal, snippetVal, snippetVal, now, frequencyRank, hskLevel, segmentID,
 	); err != nil {
 		return "", fmt.Errorf("update segment context: %w", err)
 	}
-	if err := s.ensureSegmentSRSState(segmentID, now); err != nil {
+	if err := s.ensureSegmentSRSState(userID, segmentID, now); err != nil {
 		return "", err
 	}
 	return segmentID, nil
 }
 
-func (s *SRSStore) UpdateSegmentStatus(segmentID string, status string) error {
+// frequencyRank returns the configured frequency rank of headword, or nil
+// when the frequency list does not have it.
+func (s *SRSStore) frequencyRank(headword string) any {
+	if rank, ok := s.cfg.FrequencyRanks[strings.TrimSpace(headword)]; ok {
+		return rank
+	}
+	return nil
+}
+
+// hskLevel returns the configured HSK level of headword, or nil when the
+// wordlist does not have it.
+func (s *SRSStore) hskLevel(headword string) any {
+	if level, ok := s.cfg.HSKLevels[strings.TrimSpace(headword)]; ok {
+		return level
+	}
+	return nil
+}
+
+// FillHSKLevels sets the HSK level on saved segments that do not have one
+// yet, so words saved before the wordlist was configured can be filtered by
+// level too. It returns how many segments were updated.
+func (s *SRSStore) FillHSKLevels() (int, error) {
+	return s.fillSegmentColumn("hsk_level", s.cfg.HSKLevels)
+}
+
+// FillFrequencyRanks sets the frequency rank on saved segments that do not
+// have one yet, such as words saved before the frequency list was
+// configured. It returns how many segments were updated.
+func (s *SRSStore) FillFrequencyRanks() (int, error) {
+	return s.fillSegmentColumn("frequency_rank", s.cfg.FrequencyRanks)
+}
+
+// fillSegmentColumn sets column from values, keyed by headword, on saved
+// segments where it is still NULL.
+func (s *SRSStore) fillSegmentColumn(column string, values map[string]int) (int, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	rows, err := s.db.Query(`SELECT id, headword FROM saved_segments WHERE ` + column + ` IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("query segments without %s: %w", column, err)
+	}
+	updates := make(map[string]int)
+	for rows.Next() {
+		var id, headword string
+		if err := rows.Scan(&id, &headword); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("scan segment: %w", err)
+		}
+		if value, ok := values[headword]; ok {
+			updates[id] = value
+		}
+	}
+	_ = rows.Close()
+	for id, value := range updates {
+		if _, err := s.db.Exec(`UPDATE saved_segments SET `+column+` = ? WHERE id = ?`, value, id); err != nil {
+			return 0, fmt.Errorf("update %s: %w", column, err)
+		}
+	}
+	return len(updates), nil
+}
+
+// NormalizeSavedPinyin rewrites the pinyin of saved segments stored before
+// SaveSegment normalized it, so saving the same word again matches the
+// existing row. A segment whose normalized reading is already saved is folded
+// into that row: its lookups and review history move over and it is deleted.
+// It returns how many segments were rewritten or folded.
+func (s *SRSStore) NormalizeSavedPinyin() (int, error) {
+	rows, err := s.db.Query(`SELECT id, user_id, headword, pinyin FROM saved_segments`)
+	if err != nil {
+		return 0, fmt.Errorf("query saved segment pinyin: %w", err)
+	}
+	type pending struct {
+		id, userID, headword, pinyin string
+	}
+	var changes []pending
+	for rows.Next() {
+		var item pending
+		if err := rows.Scan(&item.id, &item.userID, &item.headword, &item.pinyin); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("scan segment: %w", err)
+		}
+		if normalized := normalizePinyin(item.pinyin, s.cfg.PinyinStyle); normalized != item.pinyin {
+			item.pinyin = normalized
+			changes = append(changes, item)
+		}
+	}
+	_ = rows.Close()
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin pinyin backfill: %w", err)
+	}
+	defer tx.Rollback()
+	for _, item := range changes {
+		var existingID string
+		err := tx.QueryRow(
+			`SELECT id FROM saved_segments WHERE user_id = ? AND headword = ? AND pinyin = ? AND id != ?`,
+			item.userID, item.headword, item.pinyin, item.id,
+		).Scan(&existingID)
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := tx.Exec(`UPDATE saved_segments SET pinyin = ? WHERE id = ?`, item.pinyin, item.id); err != nil {
+				return 0, fmt.Errorf("normalize segment pinyin: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("find normalized segment: %w", err)
+		}
+		for _, query := range []string{
+			`UPDATE vocab_lookups SET segment_id = ? WHERE segment_id = ?`,
+			`UPDATE srs_review_log SET segment_id = ?, undoable = 0 WHERE segment_id = ?`,
+		} {
+			if _, err := tx.Exec(query, existingID, item.id); err != nil {
+				return 0, fmt.Errorf("move segment history: %w", err)
+			}
+		}
+		if _, err := tx.Exec(
+			`UPDATE saved_segments
+			 SET seen_count = seen_count + (SELECT seen_count FROM saved_segments WHERE id = ?)
+			 WHERE id = ?`,
+			item.id, existingID,
+		); err != nil {
+			return 0, fmt.Errorf("merge segment seen count: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM saved_segments WHERE id = ?`, item.id); err != nil {
+			return 0, fmt.Errorf("delete duplicate segment: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit pinyin backfill: %w", err)
+	}
+	return len(changes), nil
+}
+
+func (s *SRSStore) UpdateSegmentStatus(userID string, segmentID string, status string) error {
 	if !isValidStatus(status) {
 		return errors.New("invalid status")
 	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
-	res, err := s.db.Exec(`UPDATE saved_segments SET status = ?, updated_at = ? WHERE id = ?`, status, now, segmentID)
+	res, err := s.db.Exec(`UPDATE saved_segments SET status = ?, updated_at = ? WHERE id = ? AND user_id = ?`, status, now, segmentID, userID)
 	if err != nil {
 		return fmt.Errorf("update segment status: %w", err)
 	}
@@ -85,12 +232,12 @@ func (s *SRSStore) UpdateSegmentStatus(segmentID string, status string) error {
 	return nil
 }
 
-func (s *SRSStore) UpdateCharacterStatus(characterID string, status string) error {
+func (s *SRSStore) UpdateCharacterStatus(userID string, characterID string, status string) error {
 	if !isValidStatus(status) {
 		return errors.New("invalid status")
 	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
-	res, err := s.db.Exec(`UPDATE saved_characters SET status = ?, updated_at = ? WHERE id = ?`, status, now, characterID)
+	res, err := s.db.Exec(`UPDATE saved_characters SET status = ?, updated_at = ? WHERE id = ? AND user_id = ?`, status, now, characterID, userID)
 	if err != nil {
 		return fmt.Errorf("update character status: %w", err)
 	}
@@ -101,18 +248,18 @@ func (s *SRSStore) UpdateCharacterStatus(characterID string, status string) erro
 	return nil
 }
 
-func (s *SRSStore) RecordLookup(segmentID string) (SegmentSRSInfo, bool) {
-	row := s.db.QueryRow(`SELECT id, headword, pinyin, english, status FROM saved_segments WHERE id = ?`, segmentID)
+func (s *SRSStore) RecordLookup(userID string, segmentID string) (SegmentSRSInfo, bool) {
+	row := s.db.QueryRow(`SELECT id, headword, pinyin, english, status FROM saved_segments WHERE id = ? AND user_id = ?`, segmentID, userID)
 	var rec SegmentSRSInfo
 	if err := row.Scan(&rec.SegmentID, &rec.Headword, &rec.Pinyin, &rec.English, &rec.Status); err != nil {
 		return SegmentSRSInfo{}, false
 	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	lookupID, _ := newID()
-	_, _ = s.db.Exec(`INSERT INTO vocab_lookups (id, segment_id, looked_up_at) VALUES (?, ?, ?)`, lookupID, segmentID, now)
-	_ = s.ensureSegmentSRSState(segmentID, now)
+	_, _ = s.db.Exec(`INSERT INTO vocab_lookups (id, user_id, segment_id, looked_up_at) VALUES (?, ?, ?, ?)`, lookupID, userID, segmentID, now)
+	_ = s.ensureSegmentSRSState(userID, segmentID, now)
 	_, _ = s.db.Exec(`UPDATE srs_state SET last_reviewed_at = ? WHERE segment_id = ?`, now, segmentID)
-	infoList, _ := s.GetSegmentSRSInfo([]string{rec.Headword})
+	infoList, _ := s.GetSegmentSRSInfo(userID, []string{rec.Headword})
 	if len(infoList) > 0 {
 		return infoList[0], true
 	}
@@ -120,7 +267,7 @@ func (s *SRSStore) RecordLookup(segmentID string) (SegmentSRSInfo, bool) {
 	return rec, true
 }
 
-func (s *SRSStore) GetSegmentSRSInfo(headwords []string) ([]SegmentSRSInfo, error) {
+func (s *SRSStore) GetSegmentSRSInfo(userID string, headwords []string) ([]SegmentSRSInfo, error) {
 	filtered := make([]string, 0, len(headwords))
 	for _, h := range headwords {
 		h = strings.TrimSpace(h)
@@ -133,29 +280,31 @@ func (s *SRSStore) GetSegmentSRSInfo(headwords []string) ([]SegmentSRSInfo, erro
 	}
 	placeholders := strings.Repeat("?,", len(filtered))
 	placeholders = strings.TrimSuffix(placeholders, ",")
-	args := make([]any, len(filtered))
-	for i, h := range filtered {
-		args[i] = h
+	args := make([]any, 0, len(filtered)+1)
+	args = append(args, userID)
+	for _, h := range filtered {
+		args = append(args, h)
 	}
 	rows, err := s.db.Query(
 		fmt.Sprintf(`SELECT ss.id, ss.headword, ss.pinyin, ss.english, ss.status, st.last_reviewed_at, st.interval_days, st.due_at
 			FROM saved_segments ss
 			LEFT JOIN srs_state st ON ss.id = st.segment_id
-			WHERE ss.headword IN (%s)`, placeholders),
+			WHERE ss.user_id = ? AND ss.headword IN (%s)`, placeholders),
 		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("query segment srs info: %w", err)
 	}
-	defer rows.Close()
 	now := time.Now().UTC()
 	out := make([]SegmentSRSInfo, 0)
+	lastReviewedAt := make([]sql.NullString, 0)
 	for rows.Next() {
 		var info SegmentSRSInfo
 		var lastReviewed sql.NullString
 		var intervalDays sql.NullFloat64
 		var dueAt sql.NullString
 		if err := rows.Scan(&info.SegmentID, &info.Headword, &info.Pinyin, &info.English, &info.Status, &lastReviewed, &intervalDays, &dueAt); err != nil {
+			_ = rows.Close()
 			return nil, fmt.Errorf("scan segment srs info: %w", err)
 		}
 		if intervalDays.Valid {
@@ -164,83 +313,275 @@ func (s *SRSStore) GetSegmentSRSInfo(headwords []string) ([]SegmentSRSInfo, erro
 		if dueAt.Valid {
 			info.NextDueAt = &dueAt.String
 		}
+		out = append(out, info)
+		lastReviewedAt = append(lastReviewedAt, lastReviewed)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate segment srs info: %w", err)
+	}
+	// Lookup counts are queried once the rows are closed, so the pool's
+	// single connection is free.
+	for i := range out {
+		info := &out[i]
 		recentCount := 0
 		_ = s.db.QueryRow(
 			`SELECT COUNT(*) FROM vocab_lookups WHERE segment_id = ? AND looked_up_at >= ?`,
 			info.SegmentID,
-			now.Add(-7*24*time.Hour).Format(time.RFC3339Nano),
+			now.Add(-s.cfg.StrugglingWindow).Format(time.RFC3339Nano),
 		).Scan(&recentCount)
-		info.IsStruggling = recentCount >= 3
-		if !lastReviewed.Valid {
+		info.IsStruggling = recentCount >= s.cfg.StrugglingThreshold
+		if lastReviewed := lastReviewedAt[i]; !lastReviewed.Valid {
 			info.Opacity = 0
 		} else {
 			lastDt, parseErr := time.Parse(time.RFC3339Nano, lastReviewed.String)
 			if parseErr != nil {
 				info.Opacity = 1
 			} else {
-				days := now.Sub(lastDt).Hours() / 24
-				base := 1 - days/30
-				if base < 0 {
-					base = 0
-				}
-				if info.IsStruggling && base < 0.3 {
-					base = 0.3
-				}
-				info.Opacity = base
+				info.Opacity = s.cfg.Opacity(now.Sub(lastDt), info.IsStruggling)
 			}
 		}
-		out = append(out, info)
 	}
 	return out, nil
 }
 
-func (s *SRSStore) GetSegmentReviewQueue(limit int) ([]SegmentReviewCard, error) {
+// dueCondition matches srs_state rows that are due given the now and dayEnd
+// arguments from dueCutoffs. Cards scheduled a day or more out are due for the
+// whole local day they land on; shorter learning steps wait for their exact
+// time.
+const dueCondition = `(st.due_at IS NULL OR st.due_at <= ? OR (st.interval_days >= 1 AND st.due_at < ?))`
+
+// dayLocation resolves where study days begin and end: the profile timezone,
+// then SRSConfig.DayLocation, then UTC.
+func (s *SRSStore) dayLocation(userID string) *time.Location {
+	var name string
+	if err := s.db.QueryRow(`SELECT timezone FROM user_profile WHERE user_id = ?`, userID).Scan(&name); err == nil && name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	if s.cfg.DayLocation != nil {
+		return s.cfg.DayLocation
+	}
+	return time.UTC
+}
+
+// dueCutoffs returns the current time and the start of the next local day as
+// stored timestamps.
+func (s *SRSStore) dueCutoffs(userID string) (now string, dayEnd string) {
+	current := time.Now().In(s.dayLocation(userID))
+	next := time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, current.Location())
+	return current.UTC().Format(time.RFC3339Nano), next.UTC().Format(time.RFC3339Nano)
+}
+
+// GetSegmentReviewQueue returns due learning segments. order is
+// ReviewOrderDue (the default) or ReviewOrderFrequency, which surfaces the
+// most common words first and puts unranked words last. A non-zero hskLevel
+// keeps only words at that HSK level.
+func (s *SRSStore) GetSegmentReviewQueue(userID string, limit int, order string, hskLevel int) ([]SegmentReviewCard, error) {
 	if limit <= 0 {
 		limit = 10
 	}
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if hskLevel < 0 || hskLevel > MaxHSKLevel {
+		return nil, fmt.Errorf("hsk must be between 1 and %d", MaxHSKLevel)
+	}
+	// Cards never scheduled (NULL due_at, e.g. from imports) come first, and
+	// creation order breaks ties so the queue is stable.
+	orderBy := `st.due_at IS NULL DESC, st.due_at ASC, ss.created_at ASC, ss.id ASC`
+	switch order {
+	case "", ReviewOrderDue:
+	case ReviewOrderFrequency:
+		orderBy = `ss.frequency_rank IS NULL, ss.frequency_rank ASC, ` + orderBy
+	default:
+		return nil, errors.New("order must be due or frequency")
+	}
+	now, dayEnd := s.dueCutoffs(userID)
 	rows, err := s.db.Query(
-		`SELECT ss.id, ss.headword, ss.pinyin, ss.english
+		`SELECT ss.id, ss.headword, ss.pinyin, ss.english, ss.frequency_rank, ss.hsk_level, ss.last_seen_snippet
 		 FROM saved_segments ss
 		 JOIN srs_state st ON ss.id = st.segment_id
-		 WHERE ss.status = 'learning' AND (st.due_at IS NULL OR st.due_at <= ?)
-		 ORDER BY st.due_at ASC
+		 WHERE ss.user_id = ? AND ss.status = 'learning' AND `+dueCondition+`
+		   AND (? = 0 OR ss.hsk_level = ?)
+		 ORDER BY `+orderBy+`
 		 LIMIT ?`,
+		userID,
 		now,
+		dayEnd,
+		hskLevel,
+		hskLevel,
 		limit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("query review queue: %w", err)
 	}
-	defer rows.Close()
 	out := make([]SegmentReviewCard, 0)
 	for rows.Next() {
 		var card SegmentReviewCard
-		if err := rows.Scan(&card.SegmentID, &card.Headword, &card.Pinyin, &card.English); err != nil {
+		var frequencyRank, level sql.NullInt64
+		var snippet sql.NullString
+		if err := rows.Scan(&card.SegmentID, &card.Headword, &card.Pinyin, &card.English, &frequencyRank, &level, &snippet); err != nil {
+			_ = rows.Close()
 			return nil, fmt.Errorf("scan review card: %w", err)
 		}
-		var snippet sql.NullString
-		if err := s.db.QueryRow(`SELECT last_seen_snippet FROM saved_segments WHERE id = ?`, card.SegmentID).Scan(&snippet); err == nil && snippet.Valid && snippet.String != "" {
+		if frequencyRank.Valid {
+			rank := int(frequencyRank.Int64)
+			card.FrequencyRank = &rank
+		}
+		if level.Valid {
+			hsk := int(level.Int64)
+			card.HSKLevel = &hsk
+		}
+		if snippet.Valid && snippet.String != "" {
 			card.Snippets = []string{snippet.String}
 		}
 		out = append(out, card)
 	}
+	_ = rows.Close()
+	for i := range out {
+		examples, err := s.GetExampleSentencesForHeadword(userID, out[i].Headword, reviewCardExampleLimit+len(out[i].Snippets))
+		if err != nil {
+			return nil, err
+		}
+		out[i].Examples = make([]string, 0, reviewCardExampleLimit)
+		for _, example := range examples {
+			if len(out[i].Examples) == reviewCardExampleLimit {
+				break
+			}
+			if len(out[i].Snippets) > 0 && out[i].Snippets[0] == example {
+				continue
+			}
+			out[i].Examples = append(out[i].Examples, example)
+		}
+	}
 	return out, nil
 }
 
-func (s *SRSStore) GetSegmentDueCount() int {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+// ClozeBlank replaces the headword in a cloze sentence.
+const ClozeBlank = "＿＿"
+
+// GetClozeCard blanks a saved word out of one of its example sentences,
+// preferring the learner's translations and falling back to the stored
+// snippet. It returns false when the word is unknown or no sentence contains
+// it.
+func (s *SRSStore) GetClozeCard(userID string, segmentID string) (ClozeCard, bool) {
+	var card ClozeCard
+	var snippet sql.NullString
+	err := s.db.QueryRow(
+		`SELECT id, headword, pinyin, english, last_seen_snippet FROM saved_segments WHERE id = ? AND user_id = ?`,
+		segmentID, userID,
+	).Scan(&card.SegmentID, &card.Answer, &card.Pinyin, &card.English, &snippet)
+	if err != nil || strings.TrimSpace(card.Answer) == "" {
+		return ClozeCard{}, false
+	}
+	candidates, _ := s.GetExampleSentencesForHeadword(userID, card.Answer, 1)
+	if snippet.Valid {
+		candidates = append(candidates, snippet.String)
+	}
+	for _, sentence := range candidates {
+		if strings.Contains(sentence, card.Answer) {
+			card.Sentence = strings.ReplaceAll(sentence, card.Answer, ClozeBlank)
+			return card, true
+		}
+	}
+	return ClozeCard{}, false
+}
+
+// reviewCardExampleLimit caps the example sentences attached to each review
+// card.
+const reviewCardExampleLimit = 2
+
+// GetExampleSentencesForHeadword returns sentences from the learner's own
+// translations that contain headword, newest translation first. Each sentence
+// is rebuilt from its segments; repeated sentences are returned once.
+func (s *SRSStore) GetExampleSentencesForHeadword(userID string, headword string, limit int) ([]string, error) {
+	headword = strings.TrimSpace(headword)
+	if headword == "" {
+		return []string{}, nil
+	}
+	if limit <= 0 {
+		limit = 3
+	}
+	// Fetch extra sentences so duplicates across translations can be dropped
+	// without coming up short.
+	rows, err := s.db.Query(
+		`SELECT ts.translation_id, ts.sentence_idx, ts.segment_text
+		 FROM (
+		   SELECT m.translation_id, m.sentence_idx, MAX(t.created_at) AS created_at
+		   FROM translation_segments m
+		   JOIN translations t ON t.id = m.translation_id
+		   WHERE t.user_id = ? AND instr(m.segment_text, ?) > 0
+		   GROUP BY m.translation_id, m.sentence_idx
+		   ORDER BY created_at DESC, m.translation_id, m.sentence_idx
+		   LIMIT ?
+		 ) hit
+		 JOIN translation_segments ts ON ts.translation_id = hit.translation_id AND ts.sentence_idx = hit.sentence_idx
+		 ORDER BY hit.created_at DESC, hit.translation_id, hit.sentence_idx, ts.seg_idx`,
+		userID,
+		headword,
+		limit*2,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query example sentences: %w", err)
+	}
+	defer rows.Close()
+	sentences := make([]string, 0)
+	var current strings.Builder
+	var lastKey string
+	flush := func() {
+		if current.Len() > 0 {
+			sentences = append(sentences, current.String())
+			current.Reset()
+		}
+	}
+	for rows.Next() {
+		var translationID, segment string
+		var sentenceIdx int
+		if err := rows.Scan(&translationID, &sentenceIdx, &segment); err != nil {
+			return nil, fmt.Errorf("scan example sentence: %w", err)
+		}
+		key := translationID + ":" + strconv.Itoa(sentenceIdx)
+		if key != lastKey {
+			flush()
+			lastKey = key
+		}
+		current.WriteString(segment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read example sentences: %w", err)
+	}
+	flush()
+
+	out := make([]string, 0, limit)
+	seen := make(map[string]bool, len(sentences))
+	for _, sentence := range sentences {
+		sentence = strings.TrimSpace(sentence)
+		if sentence == "" || seen[sentence] {
+			continue
+		}
+		seen[sentence] = true
+		out = append(out, sentence)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *SRSStore) GetSegmentDueCount(userID string) int {
+	now, dayEnd := s.dueCutoffs(userID)
 	var cnt int
 	_ = s.db.QueryRow(
 		`SELECT COUNT(*) FROM saved_segments ss
 		 JOIN srs_state st ON ss.id = st.segment_id
-		 WHERE ss.status = 'learning' AND (st.due_at IS NULL OR st.due_at <= ?)`,
+		 WHERE ss.user_id = ? AND ss.status = 'learning' AND `+dueCondition,
+		userID,
 		now,
+		dayEnd,
 	).Scan(&cnt)
 	return cnt
 }
 
-func (s *SRSStore) RecordReviewAnswer(entityID string, entityType string, grade int) (ReviewAnswerResult, bool, error) {
+func (s *SRSStore) RecordReviewAnswer(userID string, entityID string, entityType string, grade int) (ReviewAnswerResult, bool, error) {
 	if grade < 0 || grade > 2 {
 		return ReviewAnswerResult{}, false, errors.New("grade must be 0, 1, or 2")
 	}
@@ -252,34 +593,37 @@ func (s *SRSStore) RecordReviewAnswer(entityID string, entityType string, grade
 		return ReviewAnswerResult{}, false, errors.New("invalid entity type")
 	}
 
-	entityExistsQuery := `SELECT 1 FROM saved_segments WHERE id = ?`
+	entityExistsQuery := `SELECT 1 FROM saved_segments WHERE id = ? AND user_id = ?`
 	if entityType == reviewEntityCharacter {
-		entityExistsQuery = `SELECT 1 FROM saved_characters WHERE id = ?`
+		entityExistsQuery = `SELECT 1 FROM saved_characters WHERE id = ? AND user_id = ?`
 	}
 	var exists int
-	err := s.db.QueryRow(entityExistsQuery, entityID).Scan(&exists)
+	err := s.db.QueryRow(entityExistsQuery, entityID, userID).Scan(&exists)
 	if err != nil {
 		return ReviewAnswerResult{}, false, nil
 	}
 
 	now := time.Now().UTC()
 	nowStr := now.Format(time.RFC3339Nano)
-	var dueAt sql.NullString
+	var dueAt, lastReviewed sql.NullString
 	var interval, ease float64
 	var reps, lapses int
-	stateQuery := `SELECT due_at, interval_days, ease, reps, lapses FROM srs_state WHERE segment_id = ?`
+	stateQuery := `SELECT due_at, interval_days, ease, reps, lapses, last_reviewed_at FROM srs_state WHERE segment_id = ?`
 	if entityType == reviewEntityCharacter {
-		stateQuery = `SELECT due_at, interval_days, ease, reps, lapses FROM srs_state WHERE character_id = ?`
+		stateQuery = `SELECT due_at, interval_days, ease, reps, lapses, last_reviewed_at FROM srs_state WHERE character_id = ?`
 	}
 	err = s.db.QueryRow(stateQuery, entityID).
-		Scan(&dueAt, &interval, &ease, &reps, &lapses)
+		Scan(&dueAt, &interval, &ease, &reps, &lapses, &lastReviewed)
 	if err != nil {
 		if entityType == reviewEntityCharacter {
-			_ = s.ensureCharacterSRSState(entityID, nowStr)
+			_ = s.ensureCharacterSRSState(userID, entityID, nowStr)
 		} else {
-			_ = s.ensureSegmentSRSState(entityID, nowStr)
+			_ = s.ensureSegmentSRSState(userID, entityID, nowStr)
 		}
+		// The card has never been reviewed, so undo must restore a NULL
+		// last_reviewed_at rather than the time of this first answer.
 		dueAt = sql.NullString{String: nowStr, Valid: true}
+		lastReviewed = sql.NullString{}
 		interval = 0
 		ease = 2.5
 		reps = 0
@@ -318,13 +662,28 @@ func (s *SRSStore) RecordReviewAnswer(entityID string, entityType string, grade
 	if entityType == reviewEntityCharacter {
 		updateQuery = `UPDATE srs_state SET due_at = ?, interval_days = ?, ease = ?, reps = ?, lapses = ?, last_reviewed_at = ? WHERE character_id = ?`
 	}
-	_, _ = s.db.Exec(updateQuery, nextDue, newInterval, newEase, newReps, newLapses, nowStr, entityID)
+	// The new state and the undo record that reverts it land together, so
+	// undo never restores a snapshot of a state that was not written.
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ReviewAnswerResult{}, false, fmt.Errorf("begin record review: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(updateQuery, nextDue, newInterval, newEase, newReps, newLapses, nowStr, entityID); err != nil {
+		return ReviewAnswerResult{}, false, fmt.Errorf("update srs state: %w", err)
+	}
+	if err := logReviewAnswer(tx, userID, entityID, entityType, grade, nowStr, dueAt, interval, ease, reps, lapses, lastReviewed); err != nil {
+		return ReviewAnswerResult{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return ReviewAnswerResult{}, false, fmt.Errorf("commit record review: %w", err)
+	}
 	nextDuePtr := nextDue
-	remainingDue := s.GetSegmentDueCount()
+	remainingDue := s.GetSegmentDueCount(userID)
 	var segmentID *string
 	var characterID *string
 	if entityType == reviewEntityCharacter {
-		remainingDue = s.GetCharacterDueCount()
+		remainingDue = s.GetCharacterDueCount(userID)
 		characterID = &entityID
 	} else {
 		segmentID = &entityID
@@ -338,21 +697,296 @@ func (s *SRSStore) RecordReviewAnswer(entityID string, entityType string, grade
 	}, true, nil
 }
 
-func (s *SRSStore) CountSegmentsByStatus(status string) int {
+// logReviewAnswer records a review together with the srs_state snapshot taken
+// before it was applied. Earlier entries for the same entity stop being undoable,
+// so only the latest answer can be reverted by UndoLastReview.
+func logReviewAnswer(tx *sql.Tx, userID string, entityID string, entityType string, grade int, reviewedAt string, prevDueAt sql.NullString, prevInterval float64, prevEase float64, prevReps int, prevLapses int, prevLastReviewed sql.NullString) error {
+	entityColumn := reviewLogEntityColumn(entityType)
+	logID, _ := newID()
+	if _, err := tx.Exec(
+		fmt.Sprintf(`UPDATE srs_review_log SET undoable = 0 WHERE %s = ?`, entityColumn),
+		entityID,
+	); err != nil {
+		return fmt.Errorf("reset review log undo: %w", err)
+	}
+	if _, err := tx.Exec(
+		fmt.Sprintf(`INSERT INTO srs_review_log (id, user_id, %s, grade, reviewed_at, prev_due_at, prev_interval_days, prev_ease, prev_reps, prev_lapses, prev_last_reviewed_at, undoable)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)`, entityColumn),
+		logID, userID, entityID, grade, reviewedAt, prevDueAt, prevInterval, prevEase, prevReps, prevLapses, prevLastReviewed,
+	); err != nil {
+		return fmt.Errorf("insert review log: %w", err)
+	}
+	return nil
+}
+
+// UndoLastReview restores the srs_state captured before the most recent review
+// answer for the entity and removes that log entry. It returns false when there
+// is no undoable answer.
+func (s *SRSStore) UndoLastReview(userID string, entityID string, entityType string) (bool, error) {
+	entityType = strings.TrimSpace(entityType)
+	if entityType == "" {
+		entityType = reviewEntitySegment
+	}
+	if entityType != reviewEntitySegment && entityType != reviewEntityCharacter {
+		return false, errors.New("invalid entity type")
+	}
+	entityColumn := reviewLogEntityColumn(entityType)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("begin undo review: %w", err)
+	}
+	defer tx.Rollback()
+
+	var logID string
+	var prevDueAt, prevLastReviewed sql.NullString
+	var prevInterval, prevEase float64
+	var prevReps, prevLapses int
+	err = tx.QueryRow(
+		fmt.Sprintf(`SELECT id, prev_due_at, prev_interval_days, prev_ease, prev_reps, prev_lapses, prev_last_reviewed_at
+		 FROM srs_review_log
+		 WHERE %s = ? AND user_id = ? AND undoable = 1
+		 ORDER BY reviewed_at DESC
+		 LIMIT 1`, entityColumn),
+		entityID,
+		userID,
+	).Scan(&logID, &prevDueAt, &prevInterval, &prevEase, &prevReps, &prevLapses, &prevLastReviewed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("load review log: %w", err)
+	}
+	if _, err := tx.Exec(
+		fmt.Sprintf(`UPDATE srs_state SET due_at = ?, interval_days = ?, ease = ?, reps = ?, lapses = ?, last_reviewed_at = ? WHERE %s = ?`, entityColumn),
+		prevDueAt, prevInterval, prevEase, prevReps, prevLapses, prevLastReviewed, entityID,
+	); err != nil {
+		return false, fmt.Errorf("restore srs state: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM srs_review_log WHERE id = ?`, logID); err != nil {
+		return false, fmt.Errorf("delete review log: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit undo review: %w", err)
+	}
+	return true, nil
+}
+
+// GetSRSStats reports review activity from srs_review_log over the last days
+// (local calendar days, including today). Retention is the share of answers graded
+// 1 or 2. Status counts and the interval histogram describe the current deck.
+func (s *SRSStore) GetSRSStats(userID string, days int) (SRSStats, error) {
+	if days <= 0 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+	loc := s.dayLocation(userID)
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, -(days - 1))
+	stats := SRSStats{
+		Days:          days,
+		ReviewsPerDay: make([]DailyReviewCount, 0, days),
+		StatusCounts:  map[string]int{"unknown": 0, "learning": 0, "known": 0},
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+quarterHourBucket("reviewed_at")+` AS bucket, COUNT(*), SUM(CASE WHEN grade >= 1 THEN 1 ELSE 0 END)
+		 FROM srs_review_log
+		 WHERE user_id = ? AND reviewed_at >= ?
+		 GROUP BY bucket`,
+		userID,
+		start.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return SRSStats{}, fmt.Errorf("query review log stats: %w", err)
+	}
+	byDay := make(map[string]DailyReviewCount)
+	totalPassed := 0
+	for rows.Next() {
+		var bucket string
+		var count, passed int
+		if err := rows.Scan(&bucket, &count, &passed); err != nil {
+			_ = rows.Close()
+			return SRSStats{}, fmt.Errorf("scan review log stats: %w", err)
+		}
+		date, ok := bucketDay(bucket, loc)
+		if !ok {
+			continue
+		}
+		day := byDay[date]
+		day.Date = date
+		day.Count += count
+		day.Passed += passed
+		byDay[date] = day
+		stats.TotalReviews += count
+		totalPassed += passed
+	}
+	_ = rows.Close()
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		day, ok := byDay[date]
+		if !ok {
+			day = DailyReviewCount{Date: date}
+		}
+		stats.ReviewsPerDay = append(stats.ReviewsPerDay, day)
+	}
+	if stats.TotalReviews > 0 {
+		stats.RetentionRate = float64(totalPassed) / float64(stats.TotalReviews)
+	}
+
+	statusRows, err := s.db.Query(`SELECT status, COUNT(*) FROM saved_segments WHERE user_id = ? GROUP BY status`, userID)
+	if err != nil {
+		return SRSStats{}, fmt.Errorf("query status counts: %w", err)
+	}
+	for statusRows.Next() {
+		var status string
+		var cnt int
+		if err := statusRows.Scan(&status, &cnt); err != nil {
+			_ = statusRows.Close()
+			return SRSStats{}, fmt.Errorf("scan status counts: %w", err)
+		}
+		stats.StatusCounts[status] = cnt
+	}
+	_ = statusRows.Close()
+
+	stats.IntervalHistogram = newIntervalBuckets()
+	intervalRows, err := s.db.Query(`SELECT interval_days FROM srs_state WHERE user_id = ?`, userID)
+	if err != nil {
+		return SRSStats{}, fmt.Errorf("query intervals: %w", err)
+	}
+	defer intervalRows.Close()
+	for intervalRows.Next() {
+		var interval float64
+		if err := intervalRows.Scan(&interval); err != nil {
+			return SRSStats{}, fmt.Errorf("scan interval: %w", err)
+		}
+		for i := range stats.IntervalHistogram {
+			b := &stats.IntervalHistogram[i]
+			if interval >= b.MinDays && (b.MaxDays == nil || interval < *b.MaxDays) {
+				b.Count++
+				break
+			}
+		}
+	}
+	return stats, nil
+}
+
+// GetStudyStreak counts consecutive local calendar days with at least one
+// review or lookup. The current streak still counts through today when the
+// last study day was yesterday.
+func (s *SRSStore) GetStudyStreak(userID string) (current, longest int, err error) {
+	loc := s.dayLocation(userID)
+	rows, err := s.db.Query(
+		`SELECT `+quarterHourBucket("ts")+` AS bucket
+		 FROM (
+		   SELECT looked_up_at AS ts FROM vocab_lookups WHERE user_id = ?
+		   UNION ALL
+		   SELECT reviewed_at AS ts FROM srs_review_log WHERE user_id = ?
+		 )
+		 GROUP BY bucket
+		 ORDER BY bucket`,
+		userID,
+		userID,
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("query study activity: %w", err)
+	}
+	days := make([]time.Time, 0)
+	for rows.Next() {
+		var bucket string
+		if err := rows.Scan(&bucket); err != nil {
+			_ = rows.Close()
+			return 0, 0, fmt.Errorf("scan study activity: %w", err)
+		}
+		date, ok := bucketDay(bucket, loc)
+		if !ok {
+			continue
+		}
+		day, _ := time.Parse("2006-01-02", date)
+		if len(days) == 0 || !days[len(days)-1].Equal(day) {
+			days = append(days, day)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("read study activity: %w", err)
+	}
+
+	run := 0
+	for i, day := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	if len(days) > 0 {
+		today, _ := time.Parse("2006-01-02", time.Now().In(loc).Format("2006-01-02"))
+		last := days[len(days)-1]
+		if last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+			current = run
+		}
+	}
+	return current, longest, nil
+}
+
+// quarterHourBucket groups a stored UTC timestamp column into 15-minute
+// buckets ("2006-01-02T15:04"). Every real timezone offset is a multiple of 15
+// minutes, so bucketDay maps each bucket to exactly one local day while the
+// aggregate stays small.
+func quarterHourBucket(column string) string {
+	return fmt.Sprintf(`substr(%[1]s, 1, 14) || printf('%%02d', CAST(substr(%[1]s, 15, 2) AS INTEGER) / 15 * 15)`, column)
+}
+
+// bucketDay returns the local calendar date of a quarterHourBucket value.
+func bucketDay(bucket string, loc *time.Location) (string, bool) {
+	at, err := time.ParseInLocation("2006-01-02T15:04", bucket, time.UTC)
+	if err != nil {
+		return "", false
+	}
+	return at.In(loc).Format("2006-01-02"), true
+}
+
+// newIntervalBuckets returns the histogram layout used by GetSRSStats. Items at
+// 21 days or more are conventionally considered "mature".
+func newIntervalBuckets() []IntervalBucket {
+	bound := func(v float64) *float64 { return &v }
+	return []IntervalBucket{
+		{Label: "new", MinDays: 0, MaxDays: bound(0.5)},
+		{Label: "<1d", MinDays: 0.5, MaxDays: bound(1)},
+		{Label: "1-6d", MinDays: 1, MaxDays: bound(7)},
+		{Label: "7-20d", MinDays: 7, MaxDays: bound(21)},
+		{Label: "21d+", MinDays: 21, MaxDays: nil},
+	}
+}
+
+func reviewLogEntityColumn(entityType string) string {
+	if entityType == reviewEntityCharacter {
+		return "character_id"
+	}
+	return "segment_id"
+}
+
+func (s *SRSStore) CountSegmentsByStatus(userID string, status string) int {
 	var cnt int
-	_ = s.db.QueryRow(`SELECT COUNT(*) FROM saved_segments WHERE status = ?`, status).Scan(&cnt)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM saved_segments WHERE user_id = ? AND status = ?`, userID, status).Scan(&cnt)
 	return cnt
 }
 
-func (s *SRSStore) CountTotalSegments() int {
+func (s *SRSStore) CountTotalSegments(userID string) int {
 	var cnt int
-	_ = s.db.QueryRow(`SELECT COUNT(*) FROM saved_segments`).Scan(&cnt)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM saved_segments WHERE user_id = ?`, userID).Scan(&cnt)
 	return cnt
 }
 
-func (s *SRSStore) ExportProgressJSON() (string, error) {
+func (s *SRSStore) ExportProgressJSON(userID string) (string, error) {
 	bundle := map[string]any{
-		"schema_version": 2,
+		"schema_version": 3,
 		"exported_at":    time.Now().UTC().Format(time.RFC3339Nano),
 	}
 	type tableDump struct {
@@ -360,14 +994,15 @@ func (s *SRSStore) ExportProgressJSON() (string, error) {
 		key   string
 	}
 	dumps := []tableDump{
-		{query: "SELECT id, headword, pinyin, english, status, created_at, updated_at, last_seen_translation_id, last_seen_snippet, last_seen_at, seen_count FROM saved_segments ORDER BY created_at", key: "saved_segments"},
-		{query: "SELECT id, character, pinyin, english, status, created_at, updated_at FROM saved_characters ORDER BY created_at", key: "saved_characters"},
-		{query: "SELECT id, character_id, segment, segment_pinyin, segment_translation, created_at FROM character_segment_links ORDER BY created_at", key: "character_segment_links"},
-		{query: "SELECT id, segment_id, character_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at FROM srs_state", key: "srs_state"},
-		{query: "SELECT id, segment_id, character_id, looked_up_at FROM vocab_lookups ORDER BY looked_up_at", key: "vocab_lookups"},
+		{query: "SELECT id, headword, pinyin, english, status, created_at, updated_at, last_seen_translation_id, last_seen_snippet, last_seen_at, seen_count, frequency_rank, hsk_level FROM saved_segments WHERE user_id = ? ORDER BY created_at", key: "saved_segments"},
+		{query: "SELECT id, character, pinyin, english, status, created_at, updated_at FROM saved_characters WHERE user_id = ? ORDER BY created_at", key: "saved_characters"},
+		{query: "SELECT id, character_id, segment, segment_pinyin, segment_translation, created_at FROM character_segment_links WHERE character_id IN (SELECT id FROM saved_characters WHERE user_id = ?) ORDER BY created_at", key: "character_segment_links"},
+		{query: "SELECT id, segment_id, character_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at FROM srs_state WHERE user_id = ?", key: "srs_state"},
+		{query: "SELECT id, segment_id, character_id, looked_up_at FROM vocab_lookups WHERE user_id = ? ORDER BY looked_up_at", key: "vocab_lookups"},
+		{query: "SELECT id, segment_id, character_id, grade, reviewed_at, prev_due_at, prev_interval_days, prev_ease, prev_reps, prev_lapses, prev_last_reviewed_at FROM srs_review_log WHERE user_id = ? ORDER BY reviewed_at", key: "srs_review_log"},
 	}
 	for _, d := range dumps {
-		rows, err := s.db.Query(d.query)
+		rows, err := s.db.Query(d.query, userID)
 		if err != nil {
 			return "", err
 		}
@@ -385,62 +1020,197 @@ func (s *SRSStore) ExportProgressJSON() (string, error) {
 	return string(b), nil
 }
 
-func (s *SRSStore) ImportProgressJSON(input string) (map[string]int, error) {
-	var data map[string]any
-	if err := json.Unmarshal([]byte(input), &data); err != nil {
-		return nil, fmt.Errorf("invalid JSON: %w", err)
+// ImportProgressJSON replaces the user's saved vocabulary and SRS data,
+// including the review history, with the contents of an ExportProgressJSON
+// bundle. The bundle is validated in full before anything is deleted, so a
+// malformed import leaves existing data untouched. Ids already used by
+// another user's rows are replaced with fresh ones. Frequency ranks and HSK
+// levels come from the configured lists, falling back to the bundle's
+// values; imported reviews cannot be undone.
+func (s *SRSStore) ImportProgressJSON(userID string, input string) (map[string]int, error) {
+	p, err := parseProgressImport(input)
+	if err != nil {
+		return nil, err
 	}
-	getArr := func(key string) ([]map[string]any, error) {
-		raw, ok := data[key]
-		if !ok {
-			return nil, fmt.Errorf("missing '%s' field", key)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		"DELETE FROM character_segment_links WHERE character_id IN (SELECT id FROM saved_characters WHERE user_id = ?)",
+		"DELETE FROM vocab_lookups WHERE user_id = ?",
+		"DELETE FROM srs_review_log WHERE user_id = ?",
+		"DELETE FROM srs_state WHERE user_id = ?",
+		"DELETE FROM saved_characters WHERE user_id = ?",
+		"DELETE FROM saved_segments WHERE user_id = ?",
+	} {
+		if _, err := tx.Exec(stmt, userID); err != nil {
+			return nil, err
 		}
-		list, ok := raw.([]any)
-		if !ok {
-			return nil, fmt.Errorf("'%s' must be a list", key)
+	}
+	segmentIDs := make(map[string]string, len(p.segments))
+	for _, item := range p.segments {
+		id, err := unusedID(tx, "saved_segments", toString(item["id"]))
+		if err != nil {
+			return nil, err
 		}
-		out := make([]map[string]any, 0, len(list))
-		for _, it := range list {
-			obj, ok := it.(map[string]any)
-			if !ok {
-				return nil, fmt.Errorf("%s entry must be object", key)
-			}
-			out = append(out, obj)
+		frequencyRank, hskLevel := s.importedRanks(item)
+		_, err = tx.Exec(`INSERT INTO saved_segments (id, user_id, headword, pinyin, english, status, created_at, updated_at, last_seen_translation_id, last_seen_snippet, last_seen_at, seen_count, frequency_rank, hsk_level) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id,
+			userID,
+			toString(item["headword"]),
+			toString(item["pinyin"]),
+			toString(item["english"]),
+			toString(item["status"]),
+			toString(item["created_at"]),
+			toString(item["updated_at"]),
+			nullableString(item["last_seen_translation_id"]),
+			toString(item["last_seen_snippet"]),
+			nullableString(item["last_seen_at"]),
+			toInt(item["seen_count"]),
+			frequencyRank,
+			hskLevel,
+		)
+		if err != nil {
+			return nil, err
 		}
-		return out, nil
+		segmentIDs[toString(item["id"])] = id
 	}
-	getArrOptional := func(key string) []map[string]any {
-		raw, ok := data[key]
-		if !ok {
-			return nil
+	characterIDs := make(map[string]string, len(p.characters))
+	for _, item := range p.characters {
+		id, err := unusedID(tx, "saved_characters", toString(item["id"]))
+		if err != nil {
+			return nil, err
 		}
-		list, ok := raw.([]any)
-		if !ok {
-			return nil
+		_, err = tx.Exec(`INSERT INTO saved_characters (id, user_id, character, pinyin, english, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id,
+			userID,
+			toString(item["character"]),
+			toString(item["pinyin"]),
+			toString(item["english"]),
+			toString(item["status"]),
+			toString(item["created_at"]),
+			toString(item["updated_at"]),
+		)
+		if err != nil {
+			return nil, err
 		}
-		out := make([]map[string]any, 0, len(list))
-		for _, it := range list {
-			obj, ok := it.(map[string]any)
-			if ok {
-				out = append(out, obj)
-			}
+		characterIDs[toString(item["id"])] = id
+	}
+	for _, item := range p.srsState {
+		id, err := unusedID(tx, "srs_state", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO srs_state (id, user_id, segment_id, character_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id,
+			userID,
+			nullableString(segmentIDs[toString(item["segment_id"])]),
+			nullableString(characterIDs[toString(item["character_id"])]),
+			nullableString(item["due_at"]),
+			toFloat(item["interval_days"]),
+			toFloat(item["ease"]),
+			toInt(item["reps"]),
+			toInt(item["lapses"]),
+			nullableString(item["last_reviewed_at"]),
+		)
+		if err != nil {
+			return nil, err
 		}
-		return out
 	}
-	segments, err := getArr("saved_segments")
-	if err != nil {
-		return nil, err
+	for _, item := range p.lookups {
+		id, err := unusedID(tx, "vocab_lookups", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO vocab_lookups (id, user_id, segment_id, character_id, looked_up_at) VALUES (?, ?, ?, ?, ?)`,
+			id,
+			userID,
+			nullableString(segmentIDs[toString(item["segment_id"])]),
+			nullableString(characterIDs[toString(item["character_id"])]),
+			toString(item["looked_up_at"]),
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
-	characters, err := getArr("saved_characters")
-	if err != nil {
+	for _, item := range p.reviewLog {
+		id, err := unusedID(tx, "srs_review_log", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO srs_review_log (id, user_id, segment_id, character_id, grade, reviewed_at, prev_due_at, prev_interval_days, prev_ease, prev_reps, prev_lapses, prev_last_reviewed_at, undoable) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`,
+			id,
+			userID,
+			nullableString(segmentIDs[toString(item["segment_id"])]),
+			nullableString(characterIDs[toString(item["character_id"])]),
+			toInt(item["grade"]),
+			toString(item["reviewed_at"]),
+			nullableString(item["prev_due_at"]),
+			toFloat(item["prev_interval_days"]),
+			toFloat(item["prev_ease"]),
+			toInt(item["prev_reps"]),
+			toInt(item["prev_lapses"]),
+			nullableString(item["prev_last_reviewed_at"]),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range p.charSegmentLinks {
+		id, err := unusedID(tx, "character_segment_links", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO character_segment_links (id, character_id, segment, segment_pinyin, segment_translation, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			id,
+			characterIDs[toString(item["character_id"])],
+			toString(item["segment"]),
+			toString(item["segment_pinyin"]),
+			toString(item["segment_translation"]),
+			toString(item["created_at"]),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
-	charSegmentLinks := getArrOptional("character_segment_links")
-	srsState, err := getArr("srs_state")
+	return p.counts(), nil
+}
+
+// ValidateProgressJSON checks a bundle the same way ImportProgressJSON does
+// and returns the counts it would import, without writing anything.
+func (s *SRSStore) ValidateProgressJSON(_ string, input string) (map[string]int, error) {
+	p, err := parseProgressImport(input)
 	if err != nil {
 		return nil, err
 	}
-	lookups, err := getArr("vocab_lookups")
+	return p.counts(), nil
+}
+
+// MergeProgressJSON combines an ExportProgressJSON bundle with the user's
+// existing data instead of replacing it. Conflicts are resolved as follows:
+//
+//   - words and characters match on their (headword|character, pinyin) key;
+//     the row with the later updated_at supplies english and status, the
+//     later last_seen_at supplies the last-seen fields, seen_count keeps the
+//     larger value and created_at the earlier one;
+//   - a word or character's SRS state is replaced only when the imported one
+//     is more advanced: more reps, or equal reps and a later last review;
+//   - lookups and review history are appended, skipping ones the user
+//     already has for the same word or character and time, and character
+//     links skip ones already present, so merging the same file twice
+//     changes nothing.
+//
+// Imported ids are remapped to the local ids of matched rows. Counts report
+// inserted and updated rows per table; a matched word or character counts as
+// updated even when the existing row wins every field.
+func (s *SRSStore) MergeProgressJSON(userID string, input string) (map[string]int, error) {
+	p, err := parseProgressImport(input)
 	if err != nil {
 		return nil, err
 	}
@@ -450,20 +1220,29 @@ func (s *SRSStore) ImportProgressJSON(input string) (map[string]int, error) {
 		return nil, err
 	}
 	defer tx.Rollback()
-	for _, stmt := range []string{
-		"DELETE FROM character_segment_links",
-		"DELETE FROM vocab_lookups",
-		"DELETE FROM srs_state",
-		"DELETE FROM saved_characters",
-		"DELETE FROM saved_segments",
-	} {
-		if _, err := tx.Exec(stmt); err != nil {
+	counts := map[string]int{}
+	segmentIDs := make(map[string]string, len(p.segments))
+	for _, item := range p.segments {
+		localID, inserted, err := mergeRowID(tx, "saved_segments", "headword", userID, toString(item["id"]), toString(item["headword"]), toString(item["pinyin"]))
+		if err != nil {
 			return nil, err
 		}
-	}
-	for _, item := range segments {
-		_, err := tx.Exec(`INSERT INTO saved_segments (id, headword, pinyin, english, status, created_at, updated_at, last_seen_translation_id, last_seen_snippet, last_seen_at, seen_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			toString(item["id"]),
+		frequencyRank, hskLevel := s.importedRanks(item)
+		_, err = tx.Exec(`INSERT INTO saved_segments (id, user_id, headword, pinyin, english, status, created_at, updated_at, last_seen_translation_id, last_seen_snippet, last_seen_at, seen_count, frequency_rank, hsk_level)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(user_id, headword, pinyin) DO UPDATE SET
+				english = CASE WHEN excluded.updated_at > saved_segments.updated_at THEN excluded.english ELSE saved_segments.english END,
+				status = CASE WHEN excluded.updated_at > saved_segments.updated_at THEN excluded.status ELSE saved_segments.status END,
+				last_seen_translation_id = CASE WHEN excluded.last_seen_at > COALESCE(saved_segments.last_seen_at, '') THEN excluded.last_seen_translation_id ELSE saved_segments.last_seen_translation_id END,
+				last_seen_snippet = CASE WHEN excluded.last_seen_at > COALESCE(saved_segments.last_seen_at, '') THEN excluded.last_seen_snippet ELSE saved_segments.last_seen_snippet END,
+				last_seen_at = CASE WHEN excluded.last_seen_at > COALESCE(saved_segments.last_seen_at, '') THEN excluded.last_seen_at ELSE saved_segments.last_seen_at END,
+				seen_count = MAX(saved_segments.seen_count, excluded.seen_count),
+				created_at = MIN(saved_segments.created_at, excluded.created_at),
+				updated_at = MAX(saved_segments.updated_at, excluded.updated_at),
+				frequency_rank = COALESCE(saved_segments.frequency_rank, excluded.frequency_rank),
+				hsk_level = COALESCE(saved_segments.hsk_level, excluded.hsk_level)`,
+			localID,
+			userID,
 			toString(item["headword"]),
 			toString(item["pinyin"]),
 			toString(item["english"]),
@@ -474,14 +1253,30 @@ func (s *SRSStore) ImportProgressJSON(input string) (map[string]int, error) {
 			toString(item["last_seen_snippet"]),
 			nullableString(item["last_seen_at"]),
 			toInt(item["seen_count"]),
+			frequencyRank,
+			hskLevel,
 		)
 		if err != nil {
 			return nil, err
 		}
+		segmentIDs[toString(item["id"])] = localID
+		countMerge(counts, "saved_segments", inserted)
 	}
-	for _, item := range characters {
-		_, err := tx.Exec(`INSERT INTO saved_characters (id, character, pinyin, english, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			toString(item["id"]),
+	characterIDs := make(map[string]string, len(p.characters))
+	for _, item := range p.characters {
+		localID, inserted, err := mergeRowID(tx, "saved_characters", "character", userID, toString(item["id"]), toString(item["character"]), toString(item["pinyin"]))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO saved_characters (id, user_id, character, pinyin, english, status, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(user_id, character, pinyin) DO UPDATE SET
+				english = CASE WHEN excluded.updated_at > saved_characters.updated_at THEN excluded.english ELSE saved_characters.english END,
+				status = CASE WHEN excluded.updated_at > saved_characters.updated_at THEN excluded.status ELSE saved_characters.status END,
+				created_at = MIN(saved_characters.created_at, excluded.created_at),
+				updated_at = MAX(saved_characters.updated_at, excluded.updated_at)`,
+			localID,
+			userID,
 			toString(item["character"]),
 			toString(item["pinyin"]),
 			toString(item["english"]),
@@ -492,38 +1287,124 @@ func (s *SRSStore) ImportProgressJSON(input string) (map[string]int, error) {
 		if err != nil {
 			return nil, err
 		}
+		characterIDs[toString(item["id"])] = localID
+		countMerge(counts, "saved_characters", inserted)
 	}
-	for _, item := range srsState {
-		_, err := tx.Exec(`INSERT INTO srs_state (id, segment_id, character_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			toString(item["id"]),
-			nullableString(item["segment_id"]),
-			nullableString(item["character_id"]),
-			nullableString(item["due_at"]),
-			toFloat(item["interval_days"]),
-			toFloat(item["ease"]),
-			toInt(item["reps"]),
-			toInt(item["lapses"]),
-			nullableString(item["last_reviewed_at"]),
-		)
-		if err != nil {
+	for _, item := range p.srsState {
+		column, entityID := "segment_id", segmentIDs[toString(item["segment_id"])]
+		if entityID == "" {
+			column, entityID = "character_id", characterIDs[toString(item["character_id"])]
+		}
+		var existingID string
+		var existingReps int
+		var existingReviewed sql.NullString
+		err := tx.QueryRow(`SELECT id, reps, last_reviewed_at FROM srs_state WHERE `+column+` = ?`, entityID).Scan(&existingID, &existingReps, &existingReviewed)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			id, err := unusedID(tx, "srs_state", toString(item["id"]))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tx.Exec(`INSERT INTO srs_state (id, user_id, `+column+`, due_at, interval_days, ease, reps, lapses, last_reviewed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				id,
+				userID,
+				entityID,
+				nullableString(item["due_at"]),
+				toFloat(item["interval_days"]),
+				toFloat(item["ease"]),
+				toInt(item["reps"]),
+				toInt(item["lapses"]),
+				nullableString(item["last_reviewed_at"]),
+			); err != nil {
+				return nil, err
+			}
+			countMerge(counts, "srs_state", true)
+		case err != nil:
 			return nil, err
+		default:
+			reps := toInt(item["reps"])
+			if reps < existingReps || (reps == existingReps && toString(item["last_reviewed_at"]) <= existingReviewed.String) {
+				continue
+			}
+			if _, err := tx.Exec(`UPDATE srs_state SET due_at = ?, interval_days = ?, ease = ?, reps = ?, lapses = ?, last_reviewed_at = ? WHERE id = ?`,
+				nullableString(item["due_at"]),
+				toFloat(item["interval_days"]),
+				toFloat(item["ease"]),
+				reps,
+				toInt(item["lapses"]),
+				nullableString(item["last_reviewed_at"]),
+				existingID,
+			); err != nil {
+				return nil, err
+			}
+			countMerge(counts, "srs_state", false)
 		}
 	}
-	for _, item := range lookups {
-		_, err := tx.Exec(`INSERT INTO vocab_lookups (id, segment_id, character_id, looked_up_at) VALUES (?, ?, ?, ?)`,
-			toString(item["id"]),
-			nullableString(item["segment_id"]),
-			nullableString(item["character_id"]),
+	for _, item := range p.lookups {
+		segmentID := nullableString(segmentIDs[toString(item["segment_id"])])
+		characterID := nullableString(characterIDs[toString(item["character_id"])])
+		present, err := hasHistoryRow(tx, "vocab_lookups", "looked_up_at", userID, segmentID, characterID, toString(item["looked_up_at"]))
+		if err != nil {
+			return nil, err
+		}
+		if present {
+			continue
+		}
+		id, err := unusedID(tx, "vocab_lookups", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO vocab_lookups (id, user_id, segment_id, character_id, looked_up_at) VALUES (?, ?, ?, ?, ?)`,
+			id,
+			userID,
+			segmentID,
+			characterID,
 			toString(item["looked_up_at"]),
-		)
+		); err != nil {
+			return nil, err
+		}
+		counts["vocab_lookups_inserted"]++
+	}
+	for _, item := range p.reviewLog {
+		segmentID := nullableString(segmentIDs[toString(item["segment_id"])])
+		characterID := nullableString(characterIDs[toString(item["character_id"])])
+		present, err := hasHistoryRow(tx, "srs_review_log", "reviewed_at", userID, segmentID, characterID, toString(item["reviewed_at"]))
 		if err != nil {
 			return nil, err
 		}
+		if present {
+			continue
+		}
+		id, err := unusedID(tx, "srs_review_log", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO srs_review_log (id, user_id, segment_id, character_id, grade, reviewed_at, prev_due_at, prev_interval_days, prev_ease, prev_reps, prev_lapses, prev_last_reviewed_at, undoable) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`,
+			id,
+			userID,
+			segmentID,
+			characterID,
+			toInt(item["grade"]),
+			toString(item["reviewed_at"]),
+			nullableString(item["prev_due_at"]),
+			toFloat(item["prev_interval_days"]),
+			toFloat(item["prev_ease"]),
+			toInt(item["prev_reps"]),
+			toInt(item["prev_lapses"]),
+			nullableString(item["prev_last_reviewed_at"]),
+		); err != nil {
+			return nil, err
+		}
+		counts["srs_review_log_inserted"]++
 	}
-	for _, item := range charSegmentLinks {
-		_, err := tx.Exec(`INSERT INTO character_segment_links (id, character_id, segment, segment_pinyin, segment_translation, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
-			toString(item["id"]),
-			toString(item["character_id"]),
+	for _, item := range p.charSegmentLinks {
+		id, err := unusedID(tx, "character_segment_links", toString(item["id"]))
+		if err != nil {
+			return nil, err
+		}
+		res, err := tx.Exec(`INSERT INTO character_segment_links (id, character_id, segment, segment_pinyin, segment_translation, created_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(character_id, segment, segment_pinyin) DO NOTHING`,
+			id,
+			characterIDs[toString(item["character_id"])],
 			toString(item["segment"]),
 			toString(item["segment_pinyin"]),
 			toString(item["segment_translation"]),
@@ -532,23 +1413,78 @@ func (s *SRSStore) ImportProgressJSON(input string) (map[string]int, error) {
 		if err != nil {
 			return nil, err
 		}
+		affected, _ := res.RowsAffected()
+		counts["character_segment_links_inserted"] += int(affected)
 	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
-	counts := map[string]int{
-		"saved_segments":   len(segments),
-		"saved_characters": len(characters),
-		"srs_state":        len(srsState),
-		"vocab_lookups":    len(lookups),
+	return counts, nil
+}
+
+// importedRanks returns the frequency rank and HSK level for an imported
+// word: the configured lists' values, else the ones in the bundle.
+func (s *SRSStore) importedRanks(item map[string]any) (frequencyRank any, hskLevel any) {
+	frequencyRank = s.frequencyRank(toString(item["headword"]))
+	if frequencyRank == nil {
+		frequencyRank = nullableInt(item["frequency_rank"])
+	}
+	hskLevel = s.hskLevel(toString(item["headword"]))
+	if hskLevel == nil {
+		hskLevel = nullableInt(item["hsk_level"])
+	}
+	return frequencyRank, hskLevel
+}
+
+// mergeRowID resolves the local id for an imported word or character: the
+// id of the user's existing row with the same key, or the imported id when
+// it is new (a fresh id if that one is taken by another row).
+func mergeRowID(tx *sql.Tx, table string, keyColumn string, userID string, importedID string, key string, pinyin string) (string, bool, error) {
+	var existingID string
+	err := tx.QueryRow(`SELECT id FROM `+table+` WHERE user_id = ? AND `+keyColumn+` = ? AND pinyin = ?`, userID, key, pinyin).Scan(&existingID)
+	if err == nil {
+		return existingID, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", false, err
+	}
+	id, err := unusedID(tx, table, importedID)
+	return id, true, err
+}
+
+// hasHistoryRow reports whether the user already has a lookup or review of
+// the same word or character at the same time.
+func hasHistoryRow(tx *sql.Tx, table string, timeColumn string, userID string, segmentID any, characterID any, at string) (bool, error) {
+	var count int
+	err := tx.QueryRow(
+		`SELECT COUNT(*) FROM `+table+` WHERE user_id = ? AND segment_id IS ? AND character_id IS ? AND `+timeColumn+` = ?`,
+		userID, segmentID, characterID, at,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// unusedID returns id, or a fresh id if table already has a row with it.
+func unusedID(tx *sql.Tx, table string, id string) (string, error) {
+	var exists int
+	err := tx.QueryRow(`SELECT 1 FROM `+table+` WHERE id = ?`, id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return id, nil
 	}
-	if len(charSegmentLinks) > 0 {
-		counts["character_segment_links"] = len(charSegmentLinks)
+	if err != nil {
+		return "", err
+	}
+	return newID()
+}
+
+func countMerge(counts map[string]int, table string, inserted bool) {
+	if inserted {
+		counts[table+"_inserted"]++
+	} else {
+		counts[table+"_updated"]++
 	}
-	return counts, nil
 }
 
-func (s *SRSStore) ExtractAndLinkCharacters(segmentID string, segment string, segmentPinyin string, segmentEnglish string, charData []CharTranslation) error {
+func (s *SRSStore) ExtractAndLinkCharacters(userID string, segmentID string, segment string, segmentPinyin string, segmentEnglish string, charData []CharTranslation) error {
 	runes := []rune(segment)
 	cjkRunes := make([]rune, 0, len(runes))
 	for _, r := range runes {
@@ -583,19 +1519,19 @@ func (s *SRSStore) ExtractAndLinkCharacters(segmentID string, segment string, se
 
 		charID, _ := newID()
 		_, _ = s.db.Exec(
-			`INSERT OR IGNORE INTO saved_characters (id, character, pinyin, english, status, created_at, updated_at)
-			 VALUES (?, ?, ?, ?, 'learning', ?, ?)`,
-			charID, char, pinyin, charEnglish, now, now,
+			`INSERT OR IGNORE INTO saved_characters (id, user_id, character, pinyin, english, status, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, 'learning', ?, ?)`,
+			charID, userID, char, pinyin, charEnglish, now, now,
 		)
 
 		var resolvedCharID string
 		if err := s.db.QueryRow(
-			`SELECT id FROM saved_characters WHERE character = ? AND pinyin = ?`,
-			char, pinyin,
+			`SELECT id FROM saved_characters WHERE user_id = ? AND character = ? AND pinyin = ?`,
+			userID, char, pinyin,
 		).Scan(&resolvedCharID); err != nil {
 			continue
 		}
-		if err := s.ensureCharacterSRSState(resolvedCharID, now); err != nil {
+		if err := s.ensureCharacterSRSState(userID, resolvedCharID, now); err != nil {
 			return err
 		}
 
@@ -610,31 +1546,43 @@ func (s *SRSStore) ExtractAndLinkCharacters(segmentID string, segment string, se
 	return nil
 }
 
-func (s *SRSStore) GetCharacterReviewQueue(limit int) ([]CharacterReviewCard, error) {
+func (s *SRSStore) GetCharacterReviewQueue(userID string, limit int) ([]CharacterReviewCard, error) {
 	if limit <= 0 {
 		limit = 10
 	}
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	now, dayEnd := s.dueCutoffs(userID)
 	rows, err := s.db.Query(
 		`SELECT sc.id, sc.character, sc.pinyin, sc.english
 		 FROM saved_characters sc
 		 JOIN srs_state st ON sc.id = st.character_id
-		 WHERE sc.status = 'learning' AND (st.due_at IS NULL OR st.due_at <= ?)
-		 ORDER BY st.due_at ASC
+		 WHERE sc.user_id = ? AND sc.status = 'learning' AND `+dueCondition+`
+		 ORDER BY st.due_at IS NULL DESC, st.due_at ASC, sc.created_at ASC, sc.id ASC
 		 LIMIT ?`,
+		userID,
 		now,
+		dayEnd,
 		limit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("query character review queue: %w", err)
 	}
-	defer rows.Close()
 	out := make([]CharacterReviewCard, 0)
 	for rows.Next() {
 		var card CharacterReviewCard
 		if err := rows.Scan(&card.CharacterID, &card.Character, &card.Pinyin, &card.English); err != nil {
+			_ = rows.Close()
 			return nil, fmt.Errorf("scan character review card: %w", err)
 		}
+		out = append(out, card)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate character review cards: %w", err)
+	}
+	// Examples are loaded after the cards so the pool's single connection is
+	// free for each query.
+	for i := range out {
+		card := &out[i]
 		exRows, err := s.db.Query(
 			`SELECT csl.segment, csl.segment_pinyin, csl.segment_translation
 			 FROM character_segment_links csl
@@ -651,47 +1599,197 @@ func (s *SRSStore) GetCharacterReviewQueue(limit int) ([]CharacterReviewCard, er
 			}
 			_ = exRows.Close()
 		}
-		out = append(out, card)
 	}
 	return out, nil
 }
 
-func (s *SRSStore) GetCharacterDueCount() int {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+// GetWordsForCharacter returns the segments linked to a saved character, most
+// recently linked first. Segments that were never saved themselves are still
+// returned with an empty ID and status.
+func (s *SRSStore) GetWordsForCharacter(userID string, characterID string) ([]SegmentRecord, error) {
+	var exists int
+	if err := s.db.QueryRow(`SELECT 1 FROM saved_characters WHERE id = ? AND user_id = ?`, characterID, userID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("load character: %w", err)
+	}
+	rows, err := s.db.Query(
+		`SELECT COALESCE(ss.id, ''), csl.segment, csl.segment_pinyin,
+		        COALESCE(NULLIF(ss.english, ''), csl.segment_translation), COALESCE(ss.status, '')
+		 FROM character_segment_links csl
+		 LEFT JOIN saved_segments ss ON ss.user_id = ? AND ss.headword = csl.segment AND ss.pinyin = csl.segment_pinyin
+		 WHERE csl.character_id = ?
+		 ORDER BY csl.created_at DESC`,
+		userID,
+		characterID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query character words: %w", err)
+	}
+	defer rows.Close()
+	out := make([]SegmentRecord, 0)
+	for rows.Next() {
+		var rec SegmentRecord
+		if err := rows.Scan(&rec.ID, &rec.Headword, &rec.Pinyin, &rec.English, &rec.Status); err != nil {
+			return nil, fmt.Errorf("scan character word: %w", err)
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+// ListRecentLookups returns saved words by their latest lookup, newest first.
+// Each word appears once no matter how often it was looked up.
+func (s *SRSStore) ListRecentLookups(userID string, limit int) ([]RecentLookup, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := s.db.Query(
+		`SELECT ss.id, ss.headword, ss.pinyin, ss.english, ss.status, vl.last_looked_up_at
+		 FROM (
+		   SELECT segment_id, MAX(looked_up_at) AS last_looked_up_at
+		   FROM vocab_lookups
+		   WHERE user_id = ? AND segment_id IS NOT NULL
+		   GROUP BY segment_id
+		 ) vl
+		 JOIN saved_segments ss ON ss.id = vl.segment_id
+		 ORDER BY vl.last_looked_up_at DESC, ss.id
+		 LIMIT ?`,
+		userID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query recent lookups: %w", err)
+	}
+	defer rows.Close()
+	out := make([]RecentLookup, 0)
+	for rows.Next() {
+		var rec RecentLookup
+		if err := rows.Scan(&rec.ID, &rec.Headword, &rec.Pinyin, &rec.English, &rec.Status, &rec.LookedUpAt); err != nil {
+			return nil, fmt.Errorf("scan recent lookup: %w", err)
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *SRSStore) GetCharacterDueCount(userID string) int {
+	now, dayEnd := s.dueCutoffs(userID)
 	var cnt int
 	_ = s.db.QueryRow(
 		`SELECT COUNT(*) FROM saved_characters sc
 		 JOIN srs_state st ON sc.id = st.character_id
-		 WHERE sc.status = 'learning' AND (st.due_at IS NULL OR st.due_at <= ?)`,
+		 WHERE sc.user_id = ? AND sc.status = 'learning' AND `+dueCondition,
+		userID,
 		now,
+		dayEnd,
 	).Scan(&cnt)
 	return cnt
 }
 
-func (s *SRSStore) ensureSegmentSRSState(segmentID string, now string) error {
+func (s *SRSStore) ensureSegmentSRSState(userID string, segmentID string, now string) error {
 	id := "seg-" + segmentID
 	if _, err := s.db.Exec(
-		`INSERT OR IGNORE INTO srs_state (id, segment_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at)
-		 VALUES (?, ?, ?, 0, 2.5, 0, 0, ?)`,
-		id, segmentID, now, now,
+		`INSERT OR IGNORE INTO srs_state (id, user_id, segment_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at)
+		 VALUES (?, ?, ?, ?, 0, 2.5, 0, 0, ?)`,
+		id, userID, segmentID, now, now,
 	); err != nil {
 		return fmt.Errorf("init segment srs state: %w", err)
 	}
 	return nil
 }
 
-func (s *SRSStore) ensureCharacterSRSState(characterID string, now string) error {
+func (s *SRSStore) ensureCharacterSRSState(userID string, characterID string, now string) error {
 	id := "char-" + characterID
 	if _, err := s.db.Exec(
-		`INSERT OR IGNORE INTO srs_state (id, character_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at)
-		 VALUES (?, ?, ?, 0, 2.5, 0, 0, ?)`,
-		id, characterID, now, now,
+		`INSERT OR IGNORE INTO srs_state (id, user_id, character_id, due_at, interval_days, ease, reps, lapses, last_reviewed_at)
+		 VALUES (?, ?, ?, ?, 0, 2.5, 0, 0, ?)`,
+		id, userID, characterID, now, now,
 	); err != nil {
 		return fmt.Errorf("init character srs state: %w", err)
 	}
 	return nil
 }
 
+// GetKnownHeadwords returns the status of every segment the user saved,
+// keyed by headword.
+func (s *SRSStore) GetKnownHeadwords(userID string) (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT headword, status FROM saved_segments WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query known headwords: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var headword, status string
+		if err := rows.Scan(&headword, &status); err != nil {
+			return nil, fmt.Errorf("scan known headword: %w", err)
+		}
+		out[headword] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate known headwords: %w", err)
+	}
+	return out, nil
+}
+
+// ComputeDifficulty classifies each word segment of a translation against the
+// user's vocab. Punctuation and other segments without letters are not
+// counted. Returns ErrNotFound when the translation does not exist.
+func (s *SRSStore) ComputeDifficulty(userID string, translationID string) (DifficultyBreakdown, error) {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM translations WHERE id = ?`, translationID).Scan(&exists); err != nil {
+		return DifficultyBreakdown{}, fmt.Errorf("check translation: %w", err)
+	}
+	if exists == 0 {
+		return DifficultyBreakdown{}, ErrNotFound
+	}
+	known, err := s.GetKnownHeadwords(userID)
+	if err != nil {
+		return DifficultyBreakdown{}, err
+	}
+	rows, err := s.db.Query(`SELECT segment_text FROM translation_segments WHERE translation_id = ?`, translationID)
+	if err != nil {
+		return DifficultyBreakdown{}, fmt.Errorf("query translation segments: %w", err)
+	}
+	defer rows.Close()
+	var out DifficultyBreakdown
+	for rows.Next() {
+		var segment string
+		if err := rows.Scan(&segment); err != nil {
+			return DifficultyBreakdown{}, fmt.Errorf("scan translation segment: %w", err)
+		}
+		segment = strings.TrimSpace(segment)
+		if !strings.ContainsFunc(segment, unicode.IsLetter) {
+			continue
+		}
+		out.TotalWords++
+		switch known[segment] {
+		case "known":
+			out.KnownWords++
+		case "learning":
+			out.LearningWords++
+		default:
+			out.UnknownWords++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return DifficultyBreakdown{}, fmt.Errorf("iterate translation segments: %w", err)
+	}
+	if out.TotalWords > 0 {
+		out.Score = (float64(out.UnknownWords) + 0.5*float64(out.LearningWords)) / float64(out.TotalWords)
+	}
+	return out, nil
+}
+
+func normalizePinyin(raw string, style string) string {
+	if style == "" {
+		style = pinyinpkg.StyleToneMarks
+	}
+	return pinyinpkg.Normalize(raw, style)
+}
+
 func isValidStatus(status string) bool {
 	return status == "unknown" || status == "learning" || status == "known"
 }
@@ -2,10 +2,14 @@ package translation
 
 import (
 	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/anath2/language-app/internal/migrations"
 	_ "modernc.org/sqlite"
@@ -118,11 +122,11 @@ func TestVocabSplitMigrationDedupKeepsLatestEnglish(t *testing.T) {
 func TestCharacterReviewQueueIncludesExampleSegments(t *testing.T) {
 	srs := newSRSStoreWithMigrations(t)
 
-	segmentID, err := srs.SaveSegment("银行", "yin hang", "bank", nil, nil, "learning")
+	segmentID, err := srs.SaveSegment(DefaultUserID, "银行", "yin hang", "bank", nil, nil, "learning")
 	if err != nil {
 		t.Fatalf("save segment: %v", err)
 	}
-	err = srs.ExtractAndLinkCharacters(segmentID, "银行", "yin hang", "bank", []CharTranslation{
+	err = srs.ExtractAndLinkCharacters(DefaultUserID, segmentID, "银行", "yin hang", "bank", []CharTranslation{
 		{Char: "银", Pinyin: "yin"},
 		{Char: "行", Pinyin: "hang"},
 	})
@@ -130,7 +134,7 @@ func TestCharacterReviewQueueIncludesExampleSegments(t *testing.T) {
 		t.Fatalf("extract and link characters: %v", err)
 	}
 
-	cards, err := srs.GetCharacterReviewQueue(10)
+	cards, err := srs.GetCharacterReviewQueue(DefaultUserID, 10)
 	if err != nil {
 		t.Fatalf("get character review queue: %v", err)
 	}
@@ -152,14 +156,14 @@ func TestCharacterReviewQueueIncludesExampleSegments(t *testing.T) {
 
 func TestExportImportProgressJSONSplitTablesRoundtrip(t *testing.T) {
 	origin := newSRSStoreWithMigrations(t)
-	segmentID, err := origin.SaveSegment("人工智能", "ren gong zhi neng", "artificial intelligence", nil, nil, "learning")
+	segmentID, err := origin.SaveSegment(DefaultUserID, "人工智能", "ren gong zhi neng", "artificial intelligence", nil, nil, "learning")
 	if err != nil {
 		t.Fatalf("save segment: %v", err)
 	}
-	if err := origin.ExtractAndLinkCharacters(segmentID, "人工智能", "ren gong zhi neng", "artificial intelligence", nil); err != nil {
+	if err := origin.ExtractAndLinkCharacters(DefaultUserID, segmentID, "人工智能", "ren gong zhi neng", "artificial intelligence", nil); err != nil {
 		t.Fatalf("extract and link characters: %v", err)
 	}
-	exported, err := origin.ExportProgressJSON()
+	exported, err := origin.ExportProgressJSON(DefaultUserID)
 	if err != nil {
 		t.Fatalf("export progress json: %v", err)
 	}
@@ -176,7 +180,7 @@ func TestExportImportProgressJSONSplitTablesRoundtrip(t *testing.T) {
 	}
 	target := NewSRSStore(db)
 
-	counts, err := target.ImportProgressJSON(exported)
+	counts, err := target.ImportProgressJSON(DefaultUserID, exported)
 	if err != nil {
 		t.Fatalf("import progress json: %v", err)
 	}
@@ -187,14 +191,14 @@ func TestExportImportProgressJSONSplitTablesRoundtrip(t *testing.T) {
 		t.Fatal("expected imported saved_characters count to be > 0")
 	}
 
-	segmentCards, err := target.GetSegmentReviewQueue(10)
+	segmentCards, err := target.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 0)
 	if err != nil {
 		t.Fatalf("get segment review queue: %v", err)
 	}
 	if len(segmentCards) == 0 {
 		t.Fatal("expected segment review queue to be populated after import")
 	}
-	charCards, err := target.GetCharacterReviewQueue(10)
+	charCards, err := target.GetCharacterReviewQueue(DefaultUserID, 10)
 	if err != nil {
 		t.Fatalf("get character review queue: %v", err)
 	}
@@ -202,3 +206,1070 @@ func TestExportImportProgressJSONSplitTablesRoundtrip(t *testing.T) {
 		t.Fatal("expected character review queue to be populated after import")
 	}
 }
+
+func TestImportProgressJSONKeepsRanksAndReviewHistory(t *testing.T) {
+	origin := newSRSStoreWithMigrations(t)
+	origin.cfg.FrequencyRanks = map[string]int{"学习": 250}
+	origin.cfg.HSKLevels = map[string]int{"学习": 1}
+	segmentID, err := origin.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	for _, grade := range []int{2, 1} {
+		if _, ok, err := origin.RecordReviewAnswer(DefaultUserID, segmentID, reviewEntitySegment, grade); err != nil || !ok {
+			t.Fatalf("review answer: ok=%v err=%v", ok, err)
+		}
+	}
+	exported, err := origin.ExportProgressJSON(DefaultUserID)
+	if err != nil {
+		t.Fatalf("export progress json: %v", err)
+	}
+
+	// Replacing a deck with its own export keeps the history it deletes.
+	if _, err := origin.ImportProgressJSON(DefaultUserID, exported); err != nil {
+		t.Fatalf("re-import progress json: %v", err)
+	}
+	// The target has no frequency list or wordlist, so ranks come from the file.
+	target := newSRSStoreWithMigrations(t)
+	counts, err := target.ImportProgressJSON(DefaultUserID, exported)
+	if err != nil {
+		t.Fatalf("import progress json: %v", err)
+	}
+	if counts["srs_review_log"] != 2 {
+		t.Fatalf("expected 2 review log entries imported, got %v", counts)
+	}
+
+	for name, store := range map[string]*SRSStore{"origin": origin, "target": target} {
+		var rank, level sql.NullInt64
+		if err := store.db.QueryRow(`SELECT frequency_rank, hsk_level FROM saved_segments WHERE id = ?`, segmentID).Scan(&rank, &level); err != nil {
+			t.Fatalf("%s: read ranks: %v", name, err)
+		}
+		if rank.Int64 != 250 || level.Int64 != 1 {
+			t.Fatalf("%s: expected rank 250 and level 1, got %v and %v", name, rank, level)
+		}
+		stats, err := store.GetSRSStats(DefaultUserID, 7)
+		if err != nil {
+			t.Fatalf("%s: get srs stats: %v", name, err)
+		}
+		if stats.TotalReviews != 2 {
+			t.Fatalf("%s: expected 2 reviews after import, got %d", name, stats.TotalReviews)
+		}
+		current, _, err := store.GetStudyStreak(DefaultUserID)
+		if err != nil {
+			t.Fatalf("%s: get study streak: %v", name, err)
+		}
+		if current != 1 {
+			t.Fatalf("%s: expected today's streak to survive the import, got %d", name, current)
+		}
+		if undone, err := store.UndoLastReview(DefaultUserID, segmentID, reviewEntitySegment); err != nil || undone {
+			t.Fatalf("%s: expected imported reviews not to be undoable, undone=%v err=%v", name, undone, err)
+		}
+	}
+}
+
+func TestImportProgressJSONRejectsInvalidBundleWithoutWiping(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	if _, err := srs.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning"); err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+
+	valid := `{
+		"saved_segments": [{"id": "s1", "headword": "你好", "pinyin": "ni hao", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-01T00:00:00Z"}],
+		"saved_characters": [{"id": "c1", "character": "你", "pinyin": "ni", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-01T00:00:00Z"}],
+		"character_segment_links": [{"id": "l1", "character_id": "c1", "segment": "你好", "created_at": "2026-01-01T00:00:00Z"}],
+		"srs_state": [{"id": "r1", "segment_id": "s1", "character_id": null}, {"id": "r2", "segment_id": null, "character_id": "c1"}],
+		"vocab_lookups": [{"id": "v1", "segment_id": "s1", "looked_up_at": "2026-01-01T00:00:00Z"}]
+	}`
+	counts, err := srs.ValidateProgressJSON(DefaultUserID, valid)
+	if err != nil {
+		t.Fatalf("validate progress json: %v", err)
+	}
+	if counts["saved_segments"] != 1 || counts["srs_state"] != 2 || counts["character_segment_links"] != 1 {
+		t.Fatalf("unexpected dry-run counts: %v", counts)
+	}
+	if got := srs.CountTotalSegments(DefaultUserID); got != 1 {
+		t.Fatalf("expected dry run to leave 1 segment, got %d", got)
+	}
+
+	cases := []struct {
+		name   string
+		bundle string
+		want   string
+	}{
+		{
+			name:   "dangling srs segment",
+			bundle: strings.Replace(valid, `{"id": "r1", "segment_id": "s1"`, `{"id": "r1", "segment_id": "missing"`, 1),
+			want:   `srs_state[0]: segment_id "missing" does not match any saved_segments entry`,
+		},
+		{
+			name:   "dangling link character",
+			bundle: strings.Replace(valid, `"character_id": "c1", "segment"`, `"character_id": "c9", "segment"`, 1),
+			want:   `character_segment_links[0]: character_id "c9" does not match any saved_characters entry`,
+		},
+		{
+			name:   "missing headword",
+			bundle: strings.Replace(valid, `"headword": "你好", `, ``, 1),
+			want:   `saved_segments[0]: missing 'headword'`,
+		},
+		{
+			name:   "both references",
+			bundle: strings.Replace(valid, `"segment_id": null, "character_id": "c1"`, `"segment_id": "s1", "character_id": "c1"`, 1),
+			want:   `srs_state[1]: must set only one of 'segment_id' and 'character_id'`,
+		},
+		{
+			name:   "duplicate id",
+			bundle: strings.Replace(valid, `"id": "r2"`, `"id": "r1"`, 1),
+			want:   `srs_state[1]: duplicate id "r1"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := srs.ImportProgressJSON(DefaultUserID, tc.bundle)
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("expected error %q, got %v", tc.want, err)
+			}
+			if got := srs.CountTotalSegments(DefaultUserID); got != 1 {
+				t.Fatalf("expected rejected import to leave 1 segment, got %d", got)
+			}
+		})
+	}
+
+	if _, err := srs.ImportProgressJSON(DefaultUserID, valid); err != nil {
+		t.Fatalf("import valid progress json: %v", err)
+	}
+	var headword string
+	if err := srs.db.QueryRow(`SELECT headword FROM saved_segments`).Scan(&headword); err != nil || headword != "你好" {
+		t.Fatalf("expected imported segment 你好, got %q (err=%v)", headword, err)
+	}
+}
+
+func TestMergeProgressJSONCombinesDecks(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	var pinyin string
+	if err := srs.db.QueryRow(`SELECT pinyin FROM saved_segments WHERE id = ?`, segmentID).Scan(&pinyin); err != nil {
+		t.Fatalf("read pinyin: %v", err)
+	}
+
+	bundle := `{
+		"saved_segments": [
+			{"id": "s1", "headword": "学习", "pinyin": "` + pinyin + `", "english": "to learn", "status": "known", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2099-01-01T00:00:00Z", "seen_count": 7},
+			{"id": "s2", "headword": "你好", "pinyin": "ni hao", "english": "hello", "status": "learning", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-01T00:00:00Z"}
+		],
+		"saved_characters": [],
+		"srs_state": [
+			{"id": "r1", "segment_id": "s1", "due_at": "2099-02-01T00:00:00Z", "interval_days": 30, "ease": 2.6, "reps": 5, "lapses": 0, "last_reviewed_at": "2099-01-01T00:00:00Z"},
+			{"id": "r2", "segment_id": "s2", "due_at": "2026-01-02T00:00:00Z", "interval_days": 1, "ease": 2.5, "reps": 0, "lapses": 0}
+		],
+		"vocab_lookups": [{"id": "v1", "segment_id": "s1", "looked_up_at": "2026-01-01T00:00:00Z"}]
+	}`
+	counts, err := srs.MergeProgressJSON(DefaultUserID, bundle)
+	if err != nil {
+		t.Fatalf("merge progress json: %v", err)
+	}
+	want := map[string]int{
+		"saved_segments_inserted": 1,
+		"saved_segments_updated":  1,
+		"srs_state_inserted":      1,
+		"srs_state_updated":       1,
+		"vocab_lookups_inserted":  1,
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Fatalf("expected %s=%d, got counts %v", key, n, counts)
+		}
+	}
+
+	var english, status string
+	var seenCount, reps int
+	if err := srs.db.QueryRow(`SELECT english, status, seen_count FROM saved_segments WHERE id = ?`, segmentID).Scan(&english, &status, &seenCount); err != nil {
+		t.Fatalf("read merged segment: %v", err)
+	}
+	if english != "to learn" || status != "known" || seenCount != 7 {
+		t.Fatalf("expected newer imported fields to win, got english=%q status=%q seen_count=%d", english, status, seenCount)
+	}
+	if err := srs.db.QueryRow(`SELECT reps FROM srs_state WHERE segment_id = ?`, segmentID).Scan(&reps); err != nil || reps != 5 {
+		t.Fatalf("expected more advanced srs state to win, got reps=%d err=%v", reps, err)
+	}
+	var lookupSegmentID string
+	if err := srs.db.QueryRow(`SELECT segment_id FROM vocab_lookups WHERE id = 'v1'`).Scan(&lookupSegmentID); err != nil || lookupSegmentID != segmentID {
+		t.Fatalf("expected lookup remapped to local segment %q, got %q (err=%v)", segmentID, lookupSegmentID, err)
+	}
+	if got := srs.CountTotalSegments(DefaultUserID); got != 2 {
+		t.Fatalf("expected 2 segments after merge, got %d", got)
+	}
+
+	// Merging the same file again only matches existing rows.
+	counts, err = srs.MergeProgressJSON(DefaultUserID, bundle)
+	if err != nil {
+		t.Fatalf("merge progress json again: %v", err)
+	}
+	if counts["saved_segments_inserted"] != 0 || counts["srs_state_inserted"] != 0 || counts["srs_state_updated"] != 0 || counts["vocab_lookups_inserted"] != 0 {
+		t.Fatalf("expected repeated merge to change nothing, got %v", counts)
+	}
+}
+
+func TestMergeProgressJSONSetsRanksOnInsert(t *testing.T) {
+	origin := newSRSStoreWithMigrations(t)
+	origin.cfg.HSKLevels = map[string]int{"经济": 4}
+	for _, headword := range []string{"学习", "经济"} {
+		if _, err := origin.SaveSegment(DefaultUserID, headword, "", "gloss", nil, nil, "learning"); err != nil {
+			t.Fatalf("save segment %s: %v", headword, err)
+		}
+	}
+	bundle, err := origin.ExportProgressJSON(DefaultUserID)
+	if err != nil {
+		t.Fatalf("export progress json: %v", err)
+	}
+
+	target := newSRSStoreWithMigrations(t)
+	target.cfg.FrequencyRanks = map[string]int{"学习": 250}
+	target.cfg.HSKLevels = map[string]int{"学习": 1}
+	if _, err := target.MergeProgressJSON(DefaultUserID, bundle); err != nil {
+		t.Fatalf("merge progress json: %v", err)
+	}
+	want := map[string][2]int64{"学习": {250, 1}, "经济": {0, 4}}
+	for headword, ranks := range want {
+		var rank, level sql.NullInt64
+		if err := target.db.QueryRow(`SELECT frequency_rank, hsk_level FROM saved_segments WHERE headword = ?`, headword).Scan(&rank, &level); err != nil {
+			t.Fatalf("read ranks of %s: %v", headword, err)
+		}
+		if rank.Int64 != ranks[0] || level.Int64 != ranks[1] {
+			t.Fatalf("expected %s ranked %v, got rank=%v level=%v", headword, ranks, rank, level)
+		}
+	}
+}
+
+func TestProgressImportsKeepEachUsersVocabSeparate(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xué xí", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	if _, ok, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, reviewEntitySegment, 2); err != nil || !ok {
+		t.Fatalf("review answer: ok=%v err=%v", ok, err)
+	}
+	bundle, err := srs.ExportProgressJSON(DefaultUserID)
+	if err != nil {
+		t.Fatalf("export progress json: %v", err)
+	}
+
+	// The same bundle is imported by a second user, so every id collides
+	// with the first user's rows.
+	if _, err := srs.ImportProgressJSON("mei", bundle); err != nil {
+		t.Fatalf("import progress json for second user: %v", err)
+	}
+	if counts, err := srs.MergeProgressJSON("mei", bundle); err != nil {
+		t.Fatalf("merge progress json for second user: %v", err)
+	} else if counts["saved_segments_inserted"] != 0 || counts["srs_review_log_inserted"] != 0 {
+		t.Fatalf("expected merge into the second user's copy to insert nothing, got %v", counts)
+	}
+	if _, ok, err := srs.RecordReviewAnswer("mei", segmentID, reviewEntitySegment, 0); err != nil || ok {
+		t.Fatalf("expected the first user's word to be unknown to the second: ok=%v err=%v", ok, err)
+	}
+	if err := srs.UpdateSegmentStatus("mei", segmentID, "known"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating another user's word, got %v", err)
+	}
+	for _, userID := range []string{DefaultUserID, "mei"} {
+		if total := srs.CountTotalSegments(userID); total != 1 {
+			t.Fatalf("expected %s to have 1 word, got %d", userID, total)
+		}
+		stats, err := srs.GetSRSStats(userID, 7)
+		if err != nil {
+			t.Fatalf("stats for %s: %v", userID, err)
+		}
+		if stats.TotalReviews != 1 {
+			t.Fatalf("expected %s to have 1 review, got %d", userID, stats.TotalReviews)
+		}
+	}
+
+	if _, err := srs.ImportProgressJSON("mei", `{"saved_segments": [], "saved_characters": [], "srs_state": [], "vocab_lookups": []}`); err != nil {
+		t.Fatalf("clear second user's progress: %v", err)
+	}
+	if total := srs.CountTotalSegments(DefaultUserID); total != 1 {
+		t.Fatalf("expected the second user's replace import to keep the first user's word, got %d", total)
+	}
+}
+
+func TestUndoLastReviewRestoresPreviousState(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+
+	if _, ok, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, reviewEntitySegment, 2); err != nil || !ok {
+		t.Fatalf("first review answer: ok=%v err=%v", ok, err)
+	}
+	var intervalAfterFirst float64
+	var repsAfterFirst int
+	if err := srs.db.QueryRow(`SELECT interval_days, reps FROM srs_state WHERE segment_id = ?`, segmentID).Scan(&intervalAfterFirst, &repsAfterFirst); err != nil {
+		t.Fatalf("read state after first review: %v", err)
+	}
+
+	if _, ok, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, "segment", 0); err != nil || !ok {
+		t.Fatalf("second review answer: ok=%v err=%v", ok, err)
+	}
+
+	undone, err := srs.UndoLastReview(DefaultUserID, segmentID, "segment")
+	if err != nil {
+		t.Fatalf("undo last review: %v", err)
+	}
+	if !undone {
+		t.Fatal("expected most recent review to be undone")
+	}
+	var interval float64
+	var reps, lapses int
+	if err := srs.db.QueryRow(`SELECT interval_days, reps, lapses FROM srs_state WHERE segment_id = ?`, segmentID).Scan(&interval, &reps, &lapses); err != nil {
+		t.Fatalf("read state after undo: %v", err)
+	}
+	if interval != intervalAfterFirst || reps != repsAfterFirst || lapses != 0 {
+		t.Fatalf("expected state restored to interval=%v reps=%d lapses=0, got interval=%v reps=%d lapses=%d", intervalAfterFirst, repsAfterFirst, interval, reps, lapses)
+	}
+
+	undone, err = srs.UndoLastReview(DefaultUserID, segmentID, "segment")
+	if err != nil {
+		t.Fatalf("second undo: %v", err)
+	}
+	if undone {
+		t.Fatal("expected only the most recent answer to be undoable")
+	}
+}
+
+func TestRecordReviewAnswerKeepsStateWhenLogFails(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	readState := func() (int, float64, string) {
+		t.Helper()
+		var reps int
+		var interval float64
+		var dueAt string
+		if err := srs.db.QueryRow(`SELECT reps, interval_days, due_at FROM srs_state WHERE segment_id = ?`, segmentID).Scan(&reps, &interval, &dueAt); err != nil {
+			t.Fatalf("read state: %v", err)
+		}
+		return reps, interval, dueAt
+	}
+	reps, interval, dueAt := readState()
+	if _, err := srs.db.Exec(`CREATE TRIGGER fail_review_log BEFORE INSERT ON srs_review_log
+		BEGIN SELECT RAISE(ABORT, 'review log unavailable'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	if _, _, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, reviewEntitySegment, 2); err == nil {
+		t.Fatal("expected the review to fail when its undo record cannot be written")
+	}
+	if gotReps, gotInterval, gotDueAt := readState(); gotReps != reps || gotInterval != interval || gotDueAt != dueAt {
+		t.Fatalf("expected srs_state left untouched, got reps=%d interval=%v due_at=%s", gotReps, gotInterval, gotDueAt)
+	}
+}
+
+func TestUndoFirstReviewKeepsCardUnreviewed(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "图书馆", "tu shu guan", "library", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	// Imported cards may arrive without any SRS state.
+	if _, err := srs.db.Exec(`DELETE FROM srs_state WHERE segment_id = ?`, segmentID); err != nil {
+		t.Fatalf("delete srs state: %v", err)
+	}
+
+	if _, ok, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, reviewEntitySegment, 2); err != nil || !ok {
+		t.Fatalf("review answer: ok=%v err=%v", ok, err)
+	}
+	undone, err := srs.UndoLastReview(DefaultUserID, segmentID, reviewEntitySegment)
+	if err != nil || !undone {
+		t.Fatalf("undo first review: undone=%v err=%v", undone, err)
+	}
+
+	var lastReviewed sql.NullString
+	var reps int
+	if err := srs.db.QueryRow(`SELECT last_reviewed_at, reps FROM srs_state WHERE segment_id = ?`, segmentID).Scan(&lastReviewed, &reps); err != nil {
+		t.Fatalf("read state after undo: %v", err)
+	}
+	if lastReviewed.Valid || reps != 0 {
+		t.Fatalf("expected never-reviewed state after undo, got last_reviewed_at=%v reps=%d", lastReviewed, reps)
+	}
+}
+
+func TestGetSRSStatsCountsReviewsAndRetention(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "图书馆", "tu shu guan", "library", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	for _, grade := range []int{2, 0, 1} {
+		if _, ok, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, "segment", grade); err != nil || !ok {
+			t.Fatalf("review answer grade %d: ok=%v err=%v", grade, ok, err)
+		}
+	}
+
+	stats, err := srs.GetSRSStats(DefaultUserID, 7)
+	if err != nil {
+		t.Fatalf("get srs stats: %v", err)
+	}
+	if stats.TotalReviews != 3 {
+		t.Fatalf("expected 3 reviews, got %d", stats.TotalReviews)
+	}
+	if len(stats.ReviewsPerDay) != 7 {
+		t.Fatalf("expected 7 daily buckets, got %d", len(stats.ReviewsPerDay))
+	}
+	if today := stats.ReviewsPerDay[6]; today.Count != 3 || today.Passed != 2 {
+		t.Fatalf("expected today's bucket to have 3 reviews and 2 passes, got %+v", today)
+	}
+	if stats.RetentionRate < 0.66 || stats.RetentionRate > 0.67 {
+		t.Fatalf("expected retention of 2/3, got %v", stats.RetentionRate)
+	}
+	if stats.StatusCounts["learning"] != 1 {
+		t.Fatalf("expected 1 learning segment, got %d", stats.StatusCounts["learning"])
+	}
+	histogramTotal := 0
+	for _, b := range stats.IntervalHistogram {
+		histogramTotal += b.Count
+	}
+	if histogramTotal == 0 {
+		t.Fatal("expected interval histogram to count srs items")
+	}
+}
+
+func TestStrugglingThresholdIsConfigurable(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	cfg := DefaultSRSConfig()
+	cfg.StrugglingThreshold = 1
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+	srs.cfg = cfg
+
+	segmentID, err := srs.SaveSegment(DefaultUserID, "困难", "kun nan", "difficult", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	info, ok := srs.RecordLookup(DefaultUserID, segmentID)
+	if !ok {
+		t.Fatal("expected lookup to resolve saved segment")
+	}
+	if !info.IsStruggling {
+		t.Fatal("expected a single lookup to flag struggling with threshold 1")
+	}
+
+	if err := (SRSConfig{StrugglingThreshold: 0, StrugglingWindow: time.Hour}).Validate(); err == nil {
+		t.Fatal("expected threshold below 1 to be rejected")
+	}
+	if err := (SRSConfig{StrugglingThreshold: 1}).Validate(); err == nil {
+		t.Fatal("expected zero window to be rejected")
+	}
+}
+
+func TestGetWordsForCharacterReturnsLinkedSegments(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	for _, seg := range []struct{ headword, pinyin, english string }{
+		{"学习", "xue xi", "to study"},
+		{"学生", "xue sheng", "student"},
+	} {
+		segmentID, err := srs.SaveSegment(DefaultUserID, seg.headword, seg.pinyin, seg.english, nil, nil, "learning")
+		if err != nil {
+			t.Fatalf("save segment %s: %v", seg.headword, err)
+		}
+		if err := srs.ExtractAndLinkCharacters(DefaultUserID, segmentID, seg.headword, seg.pinyin, seg.english, []CharTranslation{{Char: "学", Pinyin: "xue"}}); err != nil {
+			t.Fatalf("extract and link %s: %v", seg.headword, err)
+		}
+	}
+	var characterID string
+	if err := srs.db.QueryRow(`SELECT id FROM saved_characters WHERE character = '学' AND pinyin = 'xue'`).Scan(&characterID); err != nil {
+		t.Fatalf("resolve character id: %v", err)
+	}
+
+	words, err := srs.GetWordsForCharacter(DefaultUserID, characterID)
+	if err != nil {
+		t.Fatalf("get words for character: %v", err)
+	}
+	if len(words) != 2 {
+		t.Fatalf("expected 2 linked words, got %d", len(words))
+	}
+	for _, w := range words {
+		if w.ID == "" || w.Status != "learning" {
+			t.Fatalf("expected saved segment details on linked word, got %+v", w)
+		}
+	}
+
+	if _, err := srs.GetWordsForCharacter(DefaultUserID, "missing"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for unknown character, got %v", err)
+	}
+}
+
+func TestSaveSegmentNormalizesPinyinStyle(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "你好", "ni3  hao3", "hello", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	var stored string
+	if err := srs.db.QueryRow(`SELECT pinyin FROM saved_segments WHERE id = ?`, segmentID).Scan(&stored); err != nil {
+		t.Fatalf("read pinyin: %v", err)
+	}
+	if stored != "nǐ hǎo" {
+		t.Fatalf("expected tone-marked pinyin, got %q", stored)
+	}
+
+	srs.cfg.PinyinStyle = "numbered"
+	segmentID, err = srs.SaveSegment(DefaultUserID, "谢谢", "xiè xie", "thanks", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	if err := srs.db.QueryRow(`SELECT pinyin FROM saved_segments WHERE id = ?`, segmentID).Scan(&stored); err != nil {
+		t.Fatalf("read pinyin: %v", err)
+	}
+	if stored != "xie4 xie" {
+		t.Fatalf("expected numbered pinyin, got %q", stored)
+	}
+}
+
+func TestNormalizeSavedPinyinRewritesAndFoldsOldRows(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, row := range [][]string{{"old-nihao", "你好", "ni3 hao3"}, {"old-xiexie", "谢谢", "xie4 xie5"}} {
+		if _, err := srs.db.Exec(
+			`INSERT INTO saved_segments (id, headword, pinyin, english, status, created_at, updated_at, seen_count) VALUES (?, ?, ?, '', 'learning', ?, ?, 2)`,
+			row[0], row[1], row[2], now, now,
+		); err != nil {
+			t.Fatalf("insert legacy segment: %v", err)
+		}
+	}
+	if _, err := srs.db.Exec(`INSERT INTO vocab_lookups (id, segment_id, looked_up_at) VALUES ('lookup-1', 'old-xiexie', ?)`, now); err != nil {
+		t.Fatalf("insert lookup: %v", err)
+	}
+	// A re-save after the style change already created a normalized twin.
+	twinID, err := srs.SaveSegment(DefaultUserID, "谢谢", "xiè xie", "thanks", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+
+	changed, err := srs.NormalizeSavedPinyin()
+	if err != nil {
+		t.Fatalf("normalize saved pinyin: %v", err)
+	}
+	if changed != 2 {
+		t.Fatalf("expected 2 segments changed, got %d", changed)
+	}
+	if got := srs.CountTotalSegments(DefaultUserID); got != 2 {
+		t.Fatalf("expected duplicates folded into 2 segments, got %d", got)
+	}
+	var lookupSegment string
+	var seenCount int
+	if err := srs.db.QueryRow(`SELECT segment_id FROM vocab_lookups WHERE id = 'lookup-1'`).Scan(&lookupSegment); err != nil {
+		t.Fatalf("read lookup: %v", err)
+	}
+	if err := srs.db.QueryRow(`SELECT seen_count FROM saved_segments WHERE id = ?`, twinID).Scan(&seenCount); err != nil {
+		t.Fatalf("read seen count: %v", err)
+	}
+	if lookupSegment != twinID || seenCount != 3 {
+		t.Fatalf("expected lookup and seen count moved to %s, got lookup=%s seen=%d", twinID, lookupSegment, seenCount)
+	}
+
+	resavedID, err := srs.SaveSegment(DefaultUserID, "你好", "ni3 hao3", "hello", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("re-save segment: %v", err)
+	}
+	if resavedID != "old-nihao" {
+		t.Fatalf("expected re-save to match the normalized row, got %s", resavedID)
+	}
+	if changed, err := srs.NormalizeSavedPinyin(); err != nil || changed != 0 {
+		t.Fatalf("expected second run to change nothing, got %d err=%v", changed, err)
+	}
+}
+
+func TestReviewQueueOrdersByFrequencyRank(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	srs.cfg.FrequencyRanks = map[string]int{"的": 1, "学习": 250}
+	for _, headword := range []string{"罕见", "学习", "的"} {
+		if _, err := srs.SaveSegment(DefaultUserID, headword, "", "gloss", nil, nil, "learning"); err != nil {
+			t.Fatalf("save segment %s: %v", headword, err)
+		}
+	}
+
+	cards, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderFrequency, 0)
+	if err != nil {
+		t.Fatalf("get review queue: %v", err)
+	}
+	if len(cards) != 3 {
+		t.Fatalf("expected 3 due cards, got %d", len(cards))
+	}
+	got := []string{cards[0].Headword, cards[1].Headword, cards[2].Headword}
+	if got[0] != "的" || got[1] != "学习" || got[2] != "罕见" {
+		t.Fatalf("expected most frequent first and unranked last, got %v", got)
+	}
+	if cards[0].FrequencyRank == nil || *cards[0].FrequencyRank != 1 {
+		t.Fatalf("expected rank 1 on first card, got %v", cards[0].FrequencyRank)
+	}
+	if cards[2].FrequencyRank != nil {
+		t.Fatalf("expected no rank for unlisted word, got %d", *cards[2].FrequencyRank)
+	}
+
+	if _, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, "alphabetical", 0); err == nil {
+		t.Fatal("expected unknown order to be rejected")
+	}
+}
+
+func TestFrequencyRanksCoverBackfillAndImports(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	if _, err := srs.SaveSegment(DefaultUserID, "学习", "", "to study", nil, nil, "learning"); err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	rankOf := func(store *SRSStore, headword string) sql.NullInt64 {
+		t.Helper()
+		var rank sql.NullInt64
+		if err := store.db.QueryRow(`SELECT frequency_rank FROM saved_segments WHERE headword = ?`, headword).Scan(&rank); err != nil {
+			t.Fatalf("read frequency rank of %s: %v", headword, err)
+		}
+		return rank
+	}
+
+	// 学习 was saved before the frequency list was configured.
+	srs.cfg.FrequencyRanks = map[string]int{"的": 1, "学习": 250}
+	filled, err := srs.FillFrequencyRanks()
+	if err != nil {
+		t.Fatalf("fill frequency ranks: %v", err)
+	}
+	if filled != 1 || rankOf(srs, "学习").Int64 != 250 {
+		t.Fatalf("expected 学习 ranked by the backfill, filled=%d rank=%v", filled, rankOf(srs, "学习"))
+	}
+
+	if _, err := srs.db.Exec(`UPDATE saved_segments SET frequency_rank = NULL`); err != nil {
+		t.Fatalf("clear frequency ranks: %v", err)
+	}
+	bundle, err := srs.ExportProgressJSON(DefaultUserID)
+	if err != nil {
+		t.Fatalf("export progress: %v", err)
+	}
+	if _, err := srs.ImportProgressJSON(DefaultUserID, bundle); err != nil {
+		t.Fatalf("import progress: %v", err)
+	}
+	if rank := rankOf(srs, "学习"); rank.Int64 != 250 {
+		t.Fatalf("expected import to rank 学习, got %v", rank)
+	}
+
+	merged := newSRSStoreWithMigrations(t)
+	merged.cfg.FrequencyRanks = srs.cfg.FrequencyRanks
+	if _, err := merged.MergeProgressJSON(DefaultUserID, bundle); err != nil {
+		t.Fatalf("merge progress: %v", err)
+	}
+	if rank := rankOf(merged, "学习"); rank.Int64 != 250 {
+		t.Fatalf("expected merge to rank 学习, got %v", rank)
+	}
+
+	if _, err := srs.ImportVocabCSV(DefaultUserID, "的\n"); err != nil {
+		t.Fatalf("import vocab csv: %v", err)
+	}
+	if rank := rankOf(srs, "的"); rank.Int64 != 1 {
+		t.Fatalf("expected csv import to rank 的, got %v", rank)
+	}
+}
+
+func TestReviewQueuesPutUnscheduledCardsFirst(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	dueAt := map[string]any{
+		"晚": "2026-01-02T00:00:00Z",
+		"新": nil,
+		"早": "2026-01-01T00:00:00Z",
+		"同": "2026-01-01T00:00:00Z",
+	}
+	for _, headword := range []string{"晚", "新", "早", "同"} {
+		segmentID, err := srs.SaveSegment(DefaultUserID, headword, "", "gloss", nil, nil, "learning")
+		if err != nil {
+			t.Fatalf("save segment %s: %v", headword, err)
+		}
+		if _, err := srs.db.Exec(`UPDATE srs_state SET due_at = ? WHERE segment_id = ?`, dueAt[headword], segmentID); err != nil {
+			t.Fatalf("set due_at for %s: %v", headword, err)
+		}
+	}
+
+	cards, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 0)
+	if err != nil {
+		t.Fatalf("get review queue: %v", err)
+	}
+	got := make([]string, 0, len(cards))
+	for _, card := range cards {
+		got = append(got, card.Headword)
+	}
+	// Equal due dates fall back to creation order.
+	if strings.Join(got, ",") != "新,早,同,晚" {
+		t.Fatalf("expected unscheduled card first then by due date, got %v", got)
+	}
+
+	if _, err := srs.db.Exec(`UPDATE saved_segments SET status = 'known'`); err != nil {
+		t.Fatalf("retire segments: %v", err)
+	}
+	segmentID, err := srs.SaveSegment(DefaultUserID, "你好", "nǐ hǎo", "hello", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	if err := srs.ExtractAndLinkCharacters(DefaultUserID, segmentID, "你好", "nǐ hǎo", "hello", nil); err != nil {
+		t.Fatalf("extract characters: %v", err)
+	}
+	if _, err := srs.db.Exec(`UPDATE srs_state SET due_at = '2026-01-01T00:00:00Z' WHERE character_id IS NOT NULL`); err != nil {
+		t.Fatalf("schedule characters: %v", err)
+	}
+	if _, err := srs.db.Exec(`UPDATE srs_state SET due_at = NULL WHERE character_id = (SELECT id FROM saved_characters WHERE character = '好')`); err != nil {
+		t.Fatalf("unschedule character: %v", err)
+	}
+	charCards, err := srs.GetCharacterReviewQueue(DefaultUserID, 10)
+	if err != nil {
+		t.Fatalf("get character review queue: %v", err)
+	}
+	if len(charCards) != 2 || charCards[0].Character != "好" || charCards[1].Character != "你" {
+		t.Fatalf("expected unscheduled character first, got %+v", charCards)
+	}
+}
+
+func TestLoadFrequencyRanks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "freq.csv")
+	data := "headword,rank\n的,1\n学习,250\n学习,300\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write frequency list: %v", err)
+	}
+	ranks, err := LoadFrequencyRanks(path)
+	if err != nil {
+		t.Fatalf("load frequency list: %v", err)
+	}
+	if len(ranks) != 2 || ranks["的"] != 1 || ranks["学习"] != 250 {
+		t.Fatalf("unexpected ranks: %v", ranks)
+	}
+	if _, err := LoadFrequencyRanks(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Fatal("expected missing file to error")
+	}
+}
+
+func TestLoadHSKLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hsk.csv")
+	data := "headword,level\n# HSK 3.0\n你好,1\n学习,2\n学习,4\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write hsk wordlist: %v", err)
+	}
+	levels, err := LoadHSKLevels(path)
+	if err != nil {
+		t.Fatalf("load hsk wordlist: %v", err)
+	}
+	if len(levels) != 2 || levels["你好"] != 1 || levels["学习"] != 2 {
+		t.Fatalf("unexpected levels: %v", levels)
+	}
+	if err := os.WriteFile(path, []byte("你好,10\n"), 0o644); err != nil {
+		t.Fatalf("write hsk wordlist: %v", err)
+	}
+	if _, err := LoadHSKLevels(path); err == nil {
+		t.Fatal("expected out-of-range level to error")
+	}
+}
+
+func TestReviewQueueFiltersByHSKLevel(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	if _, err := srs.SaveSegment(DefaultUserID, "学习", "", "to study", nil, nil, "learning"); err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	srs.cfg.HSKLevels = map[string]int{"你好": 1, "学习": 1, "经济": 4}
+	for _, headword := range []string{"你好", "经济", "罕见"} {
+		if _, err := srs.SaveSegment(DefaultUserID, headword, "", "gloss", nil, nil, "learning"); err != nil {
+			t.Fatalf("save segment %s: %v", headword, err)
+		}
+	}
+	// 学习 was saved before the wordlist was configured.
+	filled, err := srs.FillHSKLevels()
+	if err != nil {
+		t.Fatalf("fill hsk levels: %v", err)
+	}
+	if filled != 1 {
+		t.Fatalf("expected 1 segment filled in, got %d", filled)
+	}
+
+	cards, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 1)
+	if err != nil {
+		t.Fatalf("get review queue: %v", err)
+	}
+	got := make([]string, 0, len(cards))
+	for _, card := range cards {
+		if card.HSKLevel == nil || *card.HSKLevel != 1 {
+			t.Fatalf("expected level 1 on %s, got %v", card.Headword, card.HSKLevel)
+		}
+		got = append(got, card.Headword)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "你好,学习" {
+		t.Fatalf("expected only HSK 1 words, got %v", got)
+	}
+
+	all, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 0)
+	if err != nil {
+		t.Fatalf("get review queue: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("expected every due word without a filter, got %d", len(all))
+	}
+	if _, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 10); err == nil {
+		t.Fatal("expected out-of-range level to be rejected")
+	}
+}
+
+func TestComputeDifficultyClassifiesSegmentsAgainstVocab(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	translations := &TranslationStore{db: srs.db}
+	tr, err := translations.Create("我学习中文。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := translations.UpdateTranslationSegments(tr.ID, 0, []SegmentResult{
+		{Segment: "我", Pinyin: "wǒ", English: "I"},
+		{Segment: "学习", Pinyin: "xué xí", English: "study"},
+		{Segment: "中文", Pinyin: "zhōng wén", English: "Chinese"},
+		{Segment: "。", Pinyin: "", English: ""},
+	}); err != nil {
+		t.Fatalf("seed segments: %v", err)
+	}
+	if _, err := srs.SaveSegment(DefaultUserID, "我", "wǒ", "I", nil, nil, "known"); err != nil {
+		t.Fatalf("save known segment: %v", err)
+	}
+	if _, err := srs.SaveSegment(DefaultUserID, "学习", "xué xí", "study", nil, nil, "learning"); err != nil {
+		t.Fatalf("save learning segment: %v", err)
+	}
+
+	got, err := srs.ComputeDifficulty(DefaultUserID, tr.ID)
+	if err != nil {
+		t.Fatalf("compute difficulty: %v", err)
+	}
+	want := DifficultyBreakdown{TotalWords: 3, UnknownWords: 1, LearningWords: 1, KnownWords: 1, Score: 0.5}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	if _, err := srs.ComputeDifficulty(DefaultUserID, "missing"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for unknown translation, got %v", err)
+	}
+}
+
+func TestListRecentLookupsKeepsLatestLookupPerWord(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	ids := map[string]string{}
+	for _, headword := range []string{"学习", "老师", "朋友"} {
+		id, err := srs.SaveSegment(DefaultUserID, headword, "", headword, nil, nil, "learning")
+		if err != nil {
+			t.Fatalf("save segment %s: %v", headword, err)
+		}
+		ids[headword] = id
+	}
+	for i, lookup := range []struct{ headword, at string }{
+		{"学习", "2026-01-01T00:00:00Z"},
+		{"老师", "2026-01-02T00:00:00Z"},
+		{"学习", "2026-01-03T00:00:00Z"},
+	} {
+		if _, err := srs.db.Exec(`INSERT INTO vocab_lookups (id, segment_id, looked_up_at) VALUES (?, ?, ?)`, "lookup-"+strconv.Itoa(i), ids[lookup.headword], lookup.at); err != nil {
+			t.Fatalf("insert lookup: %v", err)
+		}
+	}
+
+	words, err := srs.ListRecentLookups(DefaultUserID, 10)
+	if err != nil {
+		t.Fatalf("list recent lookups: %v", err)
+	}
+	if len(words) != 2 {
+		t.Fatalf("expected one entry per looked-up word, got %+v", words)
+	}
+	if words[0].Headword != "学习" || words[0].LookedUpAt != "2026-01-03T00:00:00Z" {
+		t.Fatalf("expected the latest lookup of 学习 first, got %+v", words[0])
+	}
+	if words[1].Headword != "老师" {
+		t.Fatalf("expected 老师 second, got %+v", words[1])
+	}
+
+	limited, err := srs.ListRecentLookups(DefaultUserID, 1)
+	if err != nil {
+		t.Fatalf("list recent lookups with limit: %v", err)
+	}
+	if len(limited) != 1 || limited[0].ID != ids["学习"] {
+		t.Fatalf("expected the limit to keep the newest word, got %+v", limited)
+	}
+}
+
+func TestGetStudyStreakCountsConsecutiveDays(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	now := time.Now().UTC()
+	for i, daysAgo := range []int{10, 9, 8, 1} {
+		at := now.AddDate(0, 0, -daysAgo).Format(time.RFC3339Nano)
+		if _, err := srs.db.Exec(`INSERT INTO vocab_lookups (id, segment_id, looked_up_at) VALUES (?, ?, ?)`, "lookup-"+strconv.Itoa(i), segmentID, at); err != nil {
+			t.Fatalf("insert lookup: %v", err)
+		}
+	}
+
+	current, longest, err := srs.GetStudyStreak(DefaultUserID)
+	if err != nil {
+		t.Fatalf("get study streak: %v", err)
+	}
+	if current != 1 || longest != 3 {
+		t.Fatalf("expected yesterday to keep a streak of 1 and a longest of 3, got current=%d longest=%d", current, longest)
+	}
+
+	if _, _, err := srs.RecordReviewAnswer(DefaultUserID, segmentID, reviewEntitySegment, 2); err != nil {
+		t.Fatalf("record review: %v", err)
+	}
+	if current, _, err = srs.GetStudyStreak(DefaultUserID); err != nil || current != 2 {
+		t.Fatalf("expected a review today to extend the streak to 2, got %d (err=%v)", current, err)
+	}
+}
+
+func TestGetStudyStreakUsesDayLocation(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	srs := newSRSStoreWithMigrations(t)
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xue xi", "to study", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	// 18:40 UTC is already the next day at UTC+5:30.
+	for i, at := range []string{"2026-01-01T12:00:00Z", "2026-01-02T18:40:00Z"} {
+		if _, err := srs.db.Exec(`INSERT INTO vocab_lookups (id, segment_id, looked_up_at) VALUES (?, ?, ?)`, "lookup-"+strconv.Itoa(i), segmentID, at); err != nil {
+			t.Fatalf("insert lookup: %v", err)
+		}
+	}
+
+	if _, longest, err := srs.GetStudyStreak(DefaultUserID); err != nil || longest != 2 {
+		t.Fatalf("expected consecutive UTC days, got longest=%d (err=%v)", longest, err)
+	}
+	srs.cfg.DayLocation = kolkata
+	if _, longest, err := srs.GetStudyStreak(DefaultUserID); err != nil || longest != 1 {
+		t.Fatalf("expected a gap day in Asia/Kolkata, got longest=%d (err=%v)", longest, err)
+	}
+}
+
+func TestProfileTimezoneSetsDueTodayCutoff(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	profiles := &ProfileStore{db: srs.db}
+	if _, err := profiles.UpsertUserProfile(DefaultUserID, "", "", "en", "Mars/Olympus"); !errors.Is(err, ErrInvalidTimezone) {
+		t.Fatalf("expected ErrInvalidTimezone, got %v", err)
+	}
+	if _, err := profiles.UpsertUserProfile(DefaultUserID, "", "", "en", "Asia/Shanghai"); err != nil {
+		t.Fatalf("save profile timezone: %v", err)
+	}
+	if profile, ok := profiles.GetUserProfile(DefaultUserID); !ok || profile.Timezone != "Asia/Shanghai" {
+		t.Fatalf("expected the timezone to be stored, got %+v", profile)
+	}
+	if loc := srs.dayLocation(DefaultUserID); loc.String() != "Asia/Shanghai" {
+		t.Fatalf("expected the profile timezone to set study days, got %v", loc)
+	}
+
+	// Both cards fall due just before local midnight; only the one on a
+	// day-scale interval counts as due today.
+	_, dayEnd := srs.dueCutoffs(DefaultUserID)
+	end, err := time.Parse(time.RFC3339Nano, dayEnd)
+	if err != nil {
+		t.Fatalf("parse day end: %v", err)
+	}
+	dueAt := end.Add(-time.Second).Format(time.RFC3339Nano)
+	for headword, interval := range map[string]float64{"明天": 1, "等等": 0.5} {
+		segmentID, err := srs.SaveSegment(DefaultUserID, headword, "", headword, nil, nil, "learning")
+		if err != nil {
+			t.Fatalf("save segment %s: %v", headword, err)
+		}
+		if _, err := srs.db.Exec(`UPDATE srs_state SET due_at = ?, interval_days = ? WHERE segment_id = ?`, dueAt, interval, segmentID); err != nil {
+			t.Fatalf("schedule %s: %v", headword, err)
+		}
+	}
+	cards, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 0)
+	if err != nil {
+		t.Fatalf("review queue: %v", err)
+	}
+	if len(cards) != 1 || cards[0].Headword != "明天" {
+		t.Fatalf("expected only the day-scale card to be due today, got %+v", cards)
+	}
+	if got := srs.GetSegmentDueCount(DefaultUserID); got != 1 {
+		t.Fatalf("expected a due count of 1, got %d", got)
+	}
+}
+
+func TestExampleSentencesComeFromOwnTranslations(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	translations := &TranslationStore{db: srs.db}
+	seed := func(input string, sentences ...[]SegmentResult) {
+		t.Helper()
+		tr, err := translations.Create(input, "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		for idx, segments := range sentences {
+			if err := translations.UpdateTranslationSegments(tr.ID, idx, segments); err != nil {
+				t.Fatalf("seed segments: %v", err)
+			}
+		}
+	}
+	studyChinese := []SegmentResult{{Segment: "我"}, {Segment: "学习"}, {Segment: "中文"}, {Segment: "。"}}
+	seed("我学习中文。", studyChinese)
+	seed("他们学习。我学习中文。", []SegmentResult{{Segment: "他们"}, {Segment: "学习"}, {Segment: "。"}}, studyChinese)
+	seed("你好。", []SegmentResult{{Segment: "你好"}, {Segment: "。"}})
+
+	examples, err := srs.GetExampleSentencesForHeadword(DefaultUserID, "学习", 5)
+	if err != nil {
+		t.Fatalf("get example sentences: %v", err)
+	}
+	sort.Strings(examples)
+	if want := []string{"他们学习。", "我学习中文。"}; strings.Join(examples, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected each containing sentence once, got %q", examples)
+	}
+
+	snippet := "他们学习。"
+	if _, err := srs.SaveSegment(DefaultUserID, "学习", "xué xí", "study", nil, &snippet, "learning"); err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	cards, err := srs.GetSegmentReviewQueue(DefaultUserID, 10, ReviewOrderDue, 0)
+	if err != nil {
+		t.Fatalf("review queue: %v", err)
+	}
+	if len(cards) != 1 || len(cards[0].Examples) != 1 || cards[0].Examples[0] != "我学习中文。" {
+		t.Fatalf("expected the card to add the sentence that is not its snippet, got %+v", cards)
+	}
+}
+
+func TestGetClozeCardBlanksHeadwordInSentence(t *testing.T) {
+	srs := newSRSStoreWithMigrations(t)
+	translations := &TranslationStore{db: srs.db}
+
+	snippet := "我们一起学习吧"
+	segmentID, err := srs.SaveSegment(DefaultUserID, "学习", "xué xí", "study", nil, &snippet, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	card, ok := srs.GetClozeCard(DefaultUserID, segmentID)
+	if !ok || card.Sentence != "我们一起"+ClozeBlank+"吧" || card.Answer != "学习" {
+		t.Fatalf("expected the stored snippet as a fallback, got %+v (ok=%v)", card, ok)
+	}
+
+	tr, err := translations.Create("我学习中文。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := translations.UpdateTranslationSegments(tr.ID, 0, []SegmentResult{{Segment: "我"}, {Segment: "学习"}, {Segment: "中文"}, {Segment: "。"}}); err != nil {
+		t.Fatalf("seed segments: %v", err)
+	}
+	if card, ok = srs.GetClozeCard(DefaultUserID, segmentID); !ok || card.Sentence != "我"+ClozeBlank+"中文。" {
+		t.Fatalf("expected a sentence from the learner's translations first, got %+v (ok=%v)", card, ok)
+	}
+
+	bare, err := srs.SaveSegment(DefaultUserID, "图书馆", "tú shū guǎn", "library", nil, nil, "learning")
+	if err != nil {
+		t.Fatalf("save segment: %v", err)
+	}
+	if _, ok := srs.GetClozeCard(DefaultUserID, bare); ok {
+		t.Fatal("expected no cloze card for a word without a sentence")
+	}
+	if _, ok := srs.GetClozeCard(DefaultUserID, "missing"); ok {
+		t.Fatal("expected no cloze card for an unknown word")
+	}
+}
@@ -0,0 +1,103 @@
+// Package webhook delivers JSON callbacks to client-supplied URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anath2/language-app/internal/netguard"
+)
+
+// maxURLLength bounds callback URLs accepted by ValidateURL.
+const maxURLLength = 2048
+
+// ValidateURL reports whether rawURL is an absolute http(s) URL usable as a
+// callback target. Where it points is checked when Client connects.
+func ValidateURL(rawURL string) error {
+	if len(rawURL) > maxURLLength {
+		return fmt.Errorf("callback_url must be at most %d characters", maxURLLength)
+	}
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("callback_url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// Client POSTs JSON payloads, retrying failed deliveries with exponential
+// backoff.
+type Client struct {
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+}
+
+// NewClient returns a client that gives each attempt timeout, and tries up to
+// attempts times, waiting backoff, then twice as long, between tries. It
+// only connects to public addresses, even across redirects, unless
+// allowPrivate is set.
+func NewClient(timeout time.Duration, attempts int, backoff time.Duration, allowPrivate bool) *Client {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &Client{
+		client:   &http.Client{Timeout: timeout, Transport: netguard.Transport(allowPrivate)},
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+// Post delivers payload to rawURL. A 2xx response is success; anything else
+// is retried until the attempts run out or ctx is done, except a refused
+// non-public address, which fails at once.
+func (c *Client) Post(ctx context.Context, rawURL string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+
+	wait := c.backoff
+	var lastErr error
+	for attempt := 1; attempt <= c.attempts; attempt++ {
+		if lastErr = c.post(ctx, rawURL, body); lastErr == nil {
+			return nil
+		}
+		if errors.Is(lastErr, netguard.ErrNonPublicAddress) {
+			return fmt.Errorf("deliver callback: %w", lastErr)
+		}
+		if attempt == c.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return fmt.Errorf("deliver callback after %d attempts: %w", c.attempts, lastErr)
+}
+
+func (c *Client) post(ctx context.Context, rawURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("status %d", res.StatusCode)
+	}
+	return nil
+}
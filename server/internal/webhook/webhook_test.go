@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anath2/language-app/internal/netguard"
+)
+
+func TestValidateURL(t *testing.T) {
+	for _, raw := range []string{"https://example.com/hook", "http://localhost:9000/cb?x=1"} {
+		if err := ValidateURL(raw); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"", "example.com/hook", "ftp://example.com", "https://"} {
+		if err := ValidateURL(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestPostRetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, 3, time.Millisecond, true)
+	if err := client.Post(context.Background(), server.URL, map[string]string{"status": "completed"}); err != nil {
+		t.Fatalf("expected delivery on third attempt, got %v", err)
+	}
+	if calls.Load() != 3 || got["status"] != "completed" {
+		t.Fatalf("unexpected delivery: calls=%d payload=%v", calls.Load(), got)
+	}
+
+	calls.Store(-10)
+	if err := client.Post(context.Background(), server.URL, map[string]string{}); err == nil {
+		t.Fatal("expected error once attempts are exhausted")
+	}
+}
+
+func TestPostRefusesNonPublicAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, 3, time.Millisecond, false)
+	for _, rawURL := range []string{server.URL, "http://169.254.169.254/latest/meta-data/"} {
+		if err := client.Post(context.Background(), rawURL, map[string]string{}); !errors.Is(err, netguard.ErrNonPublicAddress) {
+			t.Fatalf("expected %s to be refused, got %v", rawURL, err)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("expected no request to reach the loopback server, got %d", calls.Load())
+	}
+}
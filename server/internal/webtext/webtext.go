@@ -0,0 +1,137 @@
+// Package webtext fetches web pages and extracts their readable text.
+package webtext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/anath2/language-app/internal/netguard"
+)
+
+// ErrTooLarge is returned when a page body exceeds the fetcher's limit.
+var ErrTooLarge = errors.New("page exceeds maximum size")
+
+// minSourceShare is the fraction of a paragraph's letters that must be in the
+// source script for MainText to keep it.
+const minSourceShare = 0.3
+
+// Fetcher downloads pages with a timeout and a body size cap.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+// NewFetcher returns a fetcher that only connects to public addresses, even
+// across redirects, unless allowPrivate is set.
+func NewFetcher(timeout time.Duration, maxBytes int64, allowPrivate bool) *Fetcher {
+	return &Fetcher{
+		client:   &http.Client{Timeout: timeout, Transport: netguard.Transport(allowPrivate)},
+		maxBytes: maxBytes,
+	}
+}
+
+// Fetch returns the body of an http(s) page. Non-2xx responses and bodies
+// larger than the limit are errors.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("url must be an absolute http or https URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html, text/plain;q=0.9")
+	res, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch page: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("fetch page: status %d", res.StatusCode)
+	}
+	if res.ContentLength > f.maxBytes {
+		return "", ErrTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, f.maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("read page: %w", err)
+	}
+	if int64(len(body)) > f.maxBytes {
+		return "", ErrTooLarge
+	}
+	return string(body), nil
+}
+
+var (
+	hiddenElementPattern = regexp.MustCompile(`(?is)<(script|style|noscript|template|svg|head)\b.*?</(script|style|noscript|template|svg|head)\s*>`)
+	commentPattern       = regexp.MustCompile(`(?s)<!--.*?-->`)
+	blockTagPattern      = regexp.MustCompile(`(?i)</?(p|div|br|li|h[1-6]|tr|section|article|blockquote|pre|header|footer|nav|ul|ol|table)\b[^>]*>`)
+	tagPattern           = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacePattern         = regexp.MustCompile(`[ \t\f\r\x{00a0}\x{3000}]+`)
+)
+
+// ExtractText strips markup from an HTML document and returns its visible
+// text with one paragraph per line.
+func ExtractText(document string) string {
+	text := commentPattern.ReplaceAllString(document, "")
+	text = hiddenElementPattern.ReplaceAllString(text, "")
+	text = blockTagPattern.ReplaceAllString(text, "\n")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// MainText keeps the paragraphs of text written mostly in sourceLang's script,
+// dropping navigation, bylines and other boilerplate in other scripts.
+func MainText(text string, sourceLang string) string {
+	paragraphs := strings.Split(text, "\n")
+	out := make([]string, 0, len(paragraphs))
+	for _, p := range paragraphs {
+		source, letters := 0, 0
+		for _, r := range p {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			letters++
+			if isSourceScript(r, sourceLang) {
+				source++
+			}
+		}
+		if letters > 0 && float64(source)/float64(letters) >= minSourceShare {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func isSourceScript(r rune, sourceLang string) bool {
+	if unicode.Is(unicode.Han, r) {
+		return true
+	}
+	switch sourceLang {
+	case "ja":
+		return unicode.In(r, unicode.Hiragana, unicode.Katakana)
+	case "ko":
+		return unicode.Is(unicode.Hangul, r)
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package webtext
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anath2/language-app/internal/netguard"
+)
+
+const samplePage = `<html><head><title>新闻</title><style>p { color: red; }</style></head>
+<body><nav>Home | About</nav>
+<!-- ad slot -->
+<article><h1>今天天气很好</h1><p>我们去公园&amp;散步。</p><script>track("x")</script>
+<p>Share this article</p></article></body></html>`
+
+func TestExtractTextAndMainText(t *testing.T) {
+	text := ExtractText(samplePage)
+	if strings.Contains(text, "color") || strings.Contains(text, "track") || strings.Contains(text, "ad slot") {
+		t.Fatalf("expected hidden content removed, got %q", text)
+	}
+	got := MainText(text, "zh")
+	want := "今天天气很好\n我们去公园&散步。"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFetchEnforcesLimitsAndStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(samplePage))
+		case "/big":
+			_, _ = w.Write([]byte(strings.Repeat("字", 1000)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	fetcher := NewFetcher(5*time.Second, 1024, true)
+
+	body, err := fetcher.Fetch(context.Background(), server.URL+"/ok")
+	if err != nil || !strings.Contains(body, "今天天气很好") {
+		t.Fatalf("expected page body, got %q, %v", body, err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/big"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/missing"); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	if _, err := fetcher.Fetch(context.Background(), "ftp://example.com/file"); err == nil {
+		t.Fatal("expected error for non-http URL")
+	}
+}
+
+func TestFetchRefusesNonPublicAddresses(t *testing.T) {
+	fetcher := NewFetcher(5*time.Second, 1024, false)
+	for _, rawURL := range []string{"http://127.0.0.1/", "http://169.254.169.254/latest/meta-data/", "http://[::1]:8080/"} {
+		if _, err := fetcher.Fetch(context.Background(), rawURL); !errors.Is(err, netguard.ErrNonPublicAddress) {
+			t.Fatalf("expected %s to be refused, got %v", rawURL, err)
+		}
+	}
+}
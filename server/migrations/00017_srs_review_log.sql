@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE srs_review_log (
+  id TEXT PRIMARY KEY,
+  segment_id TEXT,
+  character_id TEXT,
+  grade INTEGER NOT NULL,
+  reviewed_at TEXT NOT NULL,
+  prev_due_at TEXT,
+  prev_interval_days REAL NOT NULL DEFAULT 0,
+  prev_ease REAL NOT NULL DEFAULT 2.5,
+  prev_reps INTEGER NOT NULL DEFAULT 0,
+  prev_lapses INTEGER NOT NULL DEFAULT 0,
+  prev_last_reviewed_at TEXT,
+  undoable INTEGER NOT NULL DEFAULT 1,
+  FOREIGN KEY(segment_id) REFERENCES saved_segments(id) ON DELETE CASCADE,
+  FOREIGN KEY(character_id) REFERENCES saved_characters(id) ON DELETE CASCADE,
+  CHECK (
+    (segment_id IS NOT NULL AND character_id IS NULL) OR
+    (segment_id IS NULL AND character_id IS NOT NULL)
+  )
+);
+CREATE INDEX idx_srs_review_log_segment_id ON srs_review_log(segment_id);
+CREATE INDEX idx_srs_review_log_character_id ON srs_review_log(character_id);
+CREATE INDEX idx_srs_review_log_reviewed_at ON srs_review_log(reviewed_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_srs_review_log_reviewed_at;
+DROP INDEX IF EXISTS idx_srs_review_log_character_id;
+DROP INDEX IF EXISTS idx_srs_review_log_segment_id;
+DROP TABLE IF EXISTS srs_review_log;
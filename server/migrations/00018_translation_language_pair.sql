@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE translations ADD COLUMN source_lang TEXT NOT NULL DEFAULT 'zh';
+ALTER TABLE translations ADD COLUMN target_lang TEXT NOT NULL DEFAULT 'en';
+
+-- +goose Down
+ALTER TABLE translations DROP COLUMN target_lang;
+ALTER TABLE translations DROP COLUMN source_lang;
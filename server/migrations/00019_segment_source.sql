@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE translation_segments ADD COLUMN source TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE translation_segments DROP COLUMN source;
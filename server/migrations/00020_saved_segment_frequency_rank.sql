@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE saved_segments ADD COLUMN frequency_rank INTEGER;
+CREATE INDEX idx_saved_segments_frequency_rank ON saved_segments(frequency_rank);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_saved_segments_frequency_rank;
+ALTER TABLE saved_segments DROP COLUMN frequency_rank;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Accounts for households sharing one instance. The 'default' user has no
+-- password hash: it signs in with APP_PASSWORD and owns all pre-existing data.
+CREATE TABLE IF NOT EXISTS users (
+  id TEXT PRIMARY KEY,
+  username TEXT NOT NULL UNIQUE,
+  password_hash TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);
+
+INSERT OR IGNORE INTO users (id, username, password_hash, created_at, updated_at)
+VALUES ('default', 'default', '', datetime('now'), datetime('now'));
+
+ALTER TABLE translations ADD COLUMN user_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_translations_user_created ON translations(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_translations_user_created;
+ALTER TABLE translations DROP COLUMN user_id;
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Issued login sessions. The signed cookie carries the id; a session is only
+-- honored while its row exists and revoked_at is NULL.
+CREATE TABLE IF NOT EXISTS sessions (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  user_agent TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  last_seen_at TEXT NOT NULL,
+  revoked_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_created ON sessions(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sessions_user_created;
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
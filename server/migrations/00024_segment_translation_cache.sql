@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- context_key is empty for segments cached on their own and a hash of the
+-- sentence for segments whose gloss depends on it.
+CREATE TABLE IF NOT EXISTS segment_translation_cache (
+  segment TEXT NOT NULL,
+  source_lang TEXT NOT NULL,
+  target_lang TEXT NOT NULL,
+  context_key TEXT NOT NULL DEFAULT '',
+  pinyin TEXT NOT NULL,
+  english TEXT NOT NULL,
+  source TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (segment, source_lang, target_lang, context_key)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS segment_translation_cache;
+-- +goose StatementEnd
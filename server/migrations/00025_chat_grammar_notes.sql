@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE translation_chat_messages ADD COLUMN grammar_note_json TEXT NULL;
+
+-- +goose Down
+ALTER TABLE translation_chat_messages DROP COLUMN grammar_note_json;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Higher priority jobs are claimed first; equal priorities keep FCFS order.
+ALTER TABLE translation_jobs ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_translation_jobs_queue
+  ON translation_jobs(state, priority DESC, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_translation_jobs_queue;
+ALTER TABLE translation_jobs DROP COLUMN priority;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Notes are keyed by the sentence's content hash so they follow the
+-- sentence through edits of the input text; segment_text guards against
+-- showing a note on a segment that was re-split underneath it.
+CREATE TABLE IF NOT EXISTS segment_notes (
+  translation_id TEXT NOT NULL,
+  sentence_key TEXT NOT NULL,
+  seg_idx INTEGER NOT NULL,
+  segment_text TEXT NOT NULL,
+  note TEXT NOT NULL,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  PRIMARY KEY (translation_id, sentence_key, seg_idx),
+  FOREIGN KEY (translation_id) REFERENCES translations(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS segment_notes;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Favorites let learners pin articles they come back to.
+ALTER TABLE translations ADD COLUMN is_favorite INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_translations_favorite
+  ON translations(is_favorite, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_translations_favorite;
+ALTER TABLE translations DROP COLUMN is_favorite;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS translation_tags (
+  translation_id TEXT NOT NULL,
+  tag TEXT NOT NULL,
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (translation_id, tag),
+  FOREIGN KEY (translation_id) REFERENCES translations(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_translation_tags_tag ON translation_tags(tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_translation_tags_tag;
+DROP TABLE IF EXISTS translation_tags;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_vocab_lookups_segment_looked_up_at ON vocab_lookups(segment_id, looked_up_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_vocab_lookups_segment_looked_up_at;
+-- +goose StatementEnd
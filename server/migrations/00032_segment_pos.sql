@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE translation_segments ADD COLUMN pos TEXT NOT NULL DEFAULT '';
+ALTER TABLE segment_translation_cache ADD COLUMN pos TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE segment_translation_cache DROP COLUMN pos;
+ALTER TABLE translation_segments DROP COLUMN pos;
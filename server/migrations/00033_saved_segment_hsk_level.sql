@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE saved_segments ADD COLUMN hsk_level INTEGER;
+CREATE INDEX idx_saved_segments_hsk_level ON saved_segments(hsk_level);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_saved_segments_hsk_level;
+ALTER TABLE saved_segments DROP COLUMN hsk_level;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE translation_segments ADD COLUMN classifier TEXT NOT NULL DEFAULT '';
+ALTER TABLE segment_translation_cache ADD COLUMN classifier TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE segment_translation_cache DROP COLUMN classifier;
+ALTER TABLE translation_segments DROP COLUMN classifier;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A learner's corrected segmentation of a sentence, keyed by the sentence's
+-- content hash so later translations of the same sentence reuse it instead
+-- of re-segmenting.
+CREATE TABLE IF NOT EXISTS segmentation_overrides (
+  user_id TEXT NOT NULL,
+  source_lang TEXT NOT NULL,
+  content_hash TEXT NOT NULL,
+  segments_json TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, source_lang, content_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS segmentation_overrides;
+-- +goose StatementEnd
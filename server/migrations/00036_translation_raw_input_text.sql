@@ -0,0 +1,8 @@
+-- +goose Up
+-- input_text holds the normalized text the pipeline works on; raw_input_text
+-- keeps what was submitted when normalization changed it, and is NULL
+-- otherwise.
+ALTER TABLE translations ADD COLUMN raw_input_text TEXT;
+
+-- +goose Down
+ALTER TABLE translations DROP COLUMN raw_input_text;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Input too long for one translation can be split into parts that share a
+-- chunk_group_id; chunk_index counts from 1 to chunk_count. Parts after the
+-- first start with their job in the 'waiting' state and are released one at
+-- a time as the previous part completes.
+ALTER TABLE translations ADD COLUMN chunk_group_id TEXT;
+ALTER TABLE translations ADD COLUMN chunk_index INTEGER;
+ALTER TABLE translations ADD COLUMN chunk_count INTEGER;
+CREATE INDEX IF NOT EXISTS idx_translations_chunk_group ON translations(chunk_group_id, chunk_index);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_translations_chunk_group;
+ALTER TABLE translations DROP COLUMN chunk_count;
+ALTER TABLE translations DROP COLUMN chunk_index;
+ALTER TABLE translations DROP COLUMN chunk_group_id;
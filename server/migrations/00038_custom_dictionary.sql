@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A learner's own reading and gloss for a headword. They take the place of
+-- CC-CEDICT's and the model's in that learner's later translations.
+CREATE TABLE IF NOT EXISTS custom_dictionary (
+  user_id TEXT NOT NULL,
+  headword TEXT NOT NULL,
+  pinyin TEXT NOT NULL DEFAULT '',
+  english TEXT NOT NULL,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, headword)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS custom_dictionary;
+-- +goose StatementEnd
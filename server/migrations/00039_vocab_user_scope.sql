@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Saved vocabulary, SRS state, study history and the profile belong to one
+-- user, like translations. Existing rows go to the 'default' user.
+ALTER TABLE saved_segments ADD COLUMN user_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS ux_saved_segments_key;
+CREATE UNIQUE INDEX ux_saved_segments_key ON saved_segments(user_id, headword, pinyin);
+
+ALTER TABLE saved_characters ADD COLUMN user_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS ux_saved_characters_key;
+CREATE UNIQUE INDEX ux_saved_characters_key ON saved_characters(user_id, character, pinyin);
+
+ALTER TABLE srs_state ADD COLUMN user_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_srs_state_user_id ON srs_state(user_id);
+
+ALTER TABLE srs_review_log ADD COLUMN user_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_srs_review_log_user_reviewed_at ON srs_review_log(user_id, reviewed_at);
+
+ALTER TABLE vocab_lookups ADD COLUMN user_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_vocab_lookups_user_looked_up_at ON vocab_lookups(user_id, looked_up_at);
+
+CREATE TABLE user_profile_new (
+  user_id TEXT PRIMARY KEY,
+  name TEXT NOT NULL DEFAULT '',
+  email TEXT NOT NULL DEFAULT '',
+  language TEXT NOT NULL DEFAULT 'zh-CN',
+  timezone TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);
+INSERT INTO user_profile_new (user_id, name, email, language, timezone, created_at, updated_at)
+SELECT 'default', name, email, language, timezone, created_at, updated_at FROM user_profile WHERE id = 1;
+DROP TABLE user_profile;
+ALTER TABLE user_profile_new RENAME TO user_profile;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE user_profile_old (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  name TEXT NOT NULL DEFAULT '',
+  email TEXT NOT NULL DEFAULT '',
+  language TEXT NOT NULL DEFAULT 'zh-CN',
+  timezone TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);
+INSERT INTO user_profile_old (id, name, email, language, timezone, created_at, updated_at)
+SELECT 1, name, email, language, timezone, created_at, updated_at FROM user_profile WHERE user_id = 'default';
+DROP TABLE user_profile;
+ALTER TABLE user_profile_old RENAME TO user_profile;
+
+DROP INDEX IF EXISTS idx_vocab_lookups_user_looked_up_at;
+ALTER TABLE vocab_lookups DROP COLUMN user_id;
+
+DROP INDEX IF EXISTS idx_srs_review_log_user_reviewed_at;
+ALTER TABLE srs_review_log DROP COLUMN user_id;
+
+DROP INDEX IF EXISTS idx_srs_state_user_id;
+ALTER TABLE srs_state DROP COLUMN user_id;
+
+DROP INDEX IF EXISTS ux_saved_characters_key;
+DELETE FROM saved_characters WHERE user_id != 'default';
+ALTER TABLE saved_characters DROP COLUMN user_id;
+CREATE UNIQUE INDEX ux_saved_characters_key ON saved_characters(character, pinyin);
+
+DROP INDEX IF EXISTS ux_saved_segments_key;
+DELETE FROM saved_segments WHERE user_id != 'default';
+ALTER TABLE saved_segments DROP COLUMN user_id;
+CREATE UNIQUE INDEX ux_saved_segments_key ON saved_segments(headword, pinyin);
+-- +goose StatementEnd
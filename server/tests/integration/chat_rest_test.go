@@ -3,7 +3,9 @@ package integration_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/anath2/language-app/internal/config"
@@ -15,11 +17,11 @@ import (
 
 type mockTranslationProvider struct{}
 
-func (m mockTranslationProvider) Segment(_ context.Context, text string) ([]string, error) {
+func (m mockTranslationProvider) Segment(_ context.Context, text string, _ translation.LanguagePair) ([]string, error) {
 	return []string{text}, nil
 }
 
-func (m mockTranslationProvider) TranslateSentenceSegments(_ context.Context, segments []string, _ string, _ string) ([]translation.SegmentResult, error) {
+func (m mockTranslationProvider) TranslateSentenceSegments(_ context.Context, segments []string, _ string, _ string, _ translation.LanguagePair) ([]translation.SegmentResult, error) {
 	out := make([]translation.SegmentResult, 0, len(segments))
 	for _, seg := range segments {
 		out = append(out, translation.SegmentResult{
@@ -31,14 +33,55 @@ func (m mockTranslationProvider) TranslateSentenceSegments(_ context.Context, se
 	return out, nil
 }
 
-func (m mockTranslationProvider) TranslateFull(_ context.Context, text string) (string, error) {
+func (m mockTranslationProvider) TranslateFull(_ context.Context, text string, _ translation.LanguagePair) (string, error) {
 	return "mock full: " + text, nil
 }
 
+func (m mockTranslationProvider) TranslateFullStream(ctx context.Context, text string, lang translation.LanguagePair, onChunk func(string) error) (string, error) {
+	full, _ := m.TranslateFull(ctx, text, lang)
+	if err := onChunk(full); err != nil {
+		return "", err
+	}
+	return full, nil
+}
+
 type mockChatProvider struct{}
 
-func (m mockChatProvider) ChatWithTranslationContext(_ context.Context, req intelligence.ChatWithTranslationRequest, onChunk func(string) error, _ func(string)) (intelligence.ChatResult, error) {
+func (m mockChatProvider) ChatWithTranslationContext(_ context.Context, req intelligence.ChatWithTranslationRequest, onChunk func(string) error, onToolCallStart func(string)) (intelligence.ChatResult, error) {
+	if strings.Contains(req.UserMessage, "review card") {
+		if onToolCallStart != nil {
+			onToolCallStart("create_review_card")
+		}
+		return intelligence.ChatResult{ToolCalls: []intelligence.ToolCallResult{{
+			Name: "create_review_card",
+			Arguments: map[string]any{
+				"chinese_text": "我们在学习中文。",
+				"pinyin":       "wǒmen zài xuéxí zhōngwén.",
+				"english":      "We are studying Chinese.",
+			},
+		}}}, nil
+	}
+	if strings.Contains(req.UserMessage, "grammar") {
+		if onToolCallStart != nil {
+			onToolCallStart("explain_grammar")
+		}
+		return intelligence.ChatResult{ToolCalls: []intelligence.ToolCallResult{{
+			Name: "explain_grammar",
+			Arguments: map[string]any{
+				"point":       "了 marking a completed action",
+				"explanation": "了 after a verb shows the action is done.",
+				"examples":    []any{"我吃了饭。 (wǒ chī le fàn.) I ate."},
+			},
+		}}}, nil
+	}
 	reply := "mock answer: " + req.UserMessage
+	if req.Model != "" || req.Temperature != nil {
+		temperature := "default"
+		if req.Temperature != nil {
+			temperature = fmt.Sprint(*req.Temperature)
+		}
+		reply += fmt.Sprintf(" [model=%s temperature=%s]", req.Model, temperature)
+	}
 	if onChunk != nil {
 		_ = onChunk("mock ")
 		_ = onChunk("answer: ")
@@ -60,7 +103,7 @@ func overrideDepsWithMockProvider(t *testing.T, cfg config.Config) *translation.
 	transProv := mockTranslationProvider{}
 	chatProv := mockChatProvider{}
 	manager := queue.NewManager(translationStore, transProv)
-	handlers.ConfigureDependencies(translationStore, chatStore, srsStore, profileStore, manager, transProv, chatProv)
+	handlers.ConfigureDependencies(translationStore, chatStore, srsStore, profileStore, manager, transProv, chatProv, nil)
 	return translationStore
 }
 
@@ -141,3 +184,330 @@ func TestTranslationChatSSELifecycleAndClear(t *testing.T) {
 		t.Fatalf("expected no messages after clear, got %d", len(listPayload.Messages))
 	}
 }
+
+func TestRegenerateReviewCardReplacesPendingCard(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("我喜欢学习", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	createRes := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", map[string]any{
+		"message": "Make a review card for 学习",
+	}, sessionCookie)
+	if createRes.Code != http.StatusOK {
+		t.Fatalf("expected chat new 200, got %d", createRes.Code)
+	}
+	messageID := toolMessageID(t, router, tr.ID, sessionCookie)
+
+	regenRes := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/messages/"+messageID+"/regenerate", nil, sessionCookie)
+	if regenRes.Code != http.StatusOK {
+		t.Fatalf("expected regenerate 200, got %d body=%s", regenRes.Code, regenRes.Body.String())
+	}
+	var gotComplete bool
+	for _, line := range extractSSEDataLines(regenRes.Body.String()) {
+		var evt map[string]any
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			t.Fatalf("decode sse payload: %v line=%s", err, line)
+		}
+		if evt["type"] == "complete" {
+			gotComplete = true
+		}
+	}
+	if !gotComplete {
+		t.Fatalf("expected complete event, body=%s", regenRes.Body.String())
+	}
+
+	acceptRes := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/messages/"+messageID+"/accept", nil, sessionCookie)
+	if acceptRes.Code != http.StatusOK {
+		t.Fatalf("expected accept 200, got %d", acceptRes.Code)
+	}
+	regenAgain := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/messages/"+messageID+"/regenerate", nil, sessionCookie)
+	if regenAgain.Code != http.StatusConflict {
+		t.Fatalf("expected regenerate of accepted card 409, got %d", regenAgain.Code)
+	}
+}
+
+func toolMessageID(t *testing.T, router http.Handler, translationID string, sessionCookie string) string {
+	t.Helper()
+	listRes := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+translationID+"/chat/list", nil, sessionCookie)
+	if listRes.Code != http.StatusOK {
+		t.Fatalf("expected chat list 200, got %d", listRes.Code)
+	}
+	var listPayload struct {
+		Messages []translation.ChatMessage `json:"messages"`
+	}
+	decodeBodyJSON(t, listRes, &listPayload)
+	for _, msg := range listPayload.Messages {
+		if msg.Role == translation.ChatRoleTool && msg.ReviewCard != nil {
+			return msg.ID
+		}
+	}
+	t.Fatal("expected a tool message with a review card")
+	return ""
+}
+
+func TestChatMessageModelAndTemperatureOverrides(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("人工智能改变世界", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+
+	res := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", map[string]any{
+		"message":     "Explain this",
+		"model":       " cheap-model ",
+		"temperature": 0,
+	}, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected chat new 200, got %d body=%s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), "[model=cheap-model temperature=0]") {
+		t.Fatalf("expected overrides to reach the provider, body=%s", res.Body.String())
+	}
+
+	for _, body := range []map[string]any{
+		{"message": "Explain this", "temperature": 2.5},
+		{"message": "Explain this", "temperature": -0.1},
+		{"message": "Explain this", "model": "two words"},
+	} {
+		res := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", body, sessionCookie)
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %v, got %d body=%s", body, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestChatStreamReplayReturnsFinalCard(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("我喜欢学习", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	createRes := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", map[string]any{
+		"message": "Make a review card for 学习",
+	}, sessionCookie)
+	if createRes.Code != http.StatusOK {
+		t.Fatalf("expected chat new 200, got %d", createRes.Code)
+	}
+	// A client that dropped after `start` only knows the user message id.
+	var userMessageID string
+	for _, line := range extractSSEDataLines(createRes.Body.String()) {
+		var evt map[string]any
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			t.Fatalf("decode sse payload: %v line=%s", err, line)
+		}
+		if evt["type"] == "start" {
+			userMessageID, _ = evt["user_message_id"].(string)
+		}
+	}
+	if userMessageID == "" {
+		t.Fatalf("expected user_message_id in start event, body=%s", createRes.Body.String())
+	}
+
+	replayRes := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/messages/"+userMessageID+"/stream-replay", nil, sessionCookie)
+	if replayRes.Code != http.StatusOK {
+		t.Fatalf("expected replay 200, got %d body=%s", replayRes.Code, replayRes.Body.String())
+	}
+	var replay struct {
+		Type        string `json:"type"`
+		MessageID   string `json:"message_id"`
+		ToolResults []struct {
+			MessageID  string                     `json:"message_id"`
+			ReviewCard translation.ChatReviewCard `json:"review_card"`
+		} `json:"tool_results"`
+	}
+	decodeBodyJSON(t, replayRes, &replay)
+	if replay.Type != "complete" || replay.MessageID == "" || len(replay.ToolResults) != 1 {
+		t.Fatalf("unexpected replay: %+v", replay)
+	}
+	if replay.ToolResults[0].MessageID != toolMessageID(t, router, tr.ID, sessionCookie) || replay.ToolResults[0].ReviewCard.ChineseText == "" {
+		t.Fatalf("expected the stored review card, got %+v", replay.ToolResults[0])
+	}
+
+	// The reply's own id replays the same turn.
+	again := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/messages/"+replay.MessageID+"/stream-replay", nil, sessionCookie)
+	if again.Code != http.StatusOK {
+		t.Fatalf("expected replay by reply id 200, got %d", again.Code)
+	}
+	missing := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/messages/missing/stream-replay", nil, sessionCookie)
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("expected replay of unknown message 404, got %d", missing.Code)
+	}
+}
+
+func TestChatEditAndResendReplacesLaterMessages(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("我喜欢学习", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	for _, message := range []string{"What does 喜欢 mean?", "And 学习?"} {
+		res := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", map[string]any{
+			"message": message,
+		}, sessionCookie)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected chat new 200, got %d", res.Code)
+		}
+	}
+
+	listRes := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/list", nil, sessionCookie)
+	var list struct {
+		Messages []translation.ChatMessage `json:"messages"`
+	}
+	decodeBodyJSON(t, listRes, &list)
+	if len(list.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(list.Messages))
+	}
+	first, firstReply := list.Messages[0], list.Messages[1]
+
+	aiEdit := doJSONRequest(t, router, http.MethodPatch, "/api/translations/"+tr.ID+"/chat/messages/"+firstReply.ID, map[string]any{
+		"message": "rewritten",
+	}, sessionCookie)
+	if aiEdit.Code != http.StatusBadRequest {
+		t.Fatalf("expected editing an AI message to be rejected, got %d", aiEdit.Code)
+	}
+	missing := doJSONRequest(t, router, http.MethodPatch, "/api/translations/"+tr.ID+"/chat/messages/missing", map[string]any{
+		"message": "rewritten",
+	}, sessionCookie)
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("expected editing an unknown message 404, got %d", missing.Code)
+	}
+
+	editRes := doJSONRequest(t, router, http.MethodPatch, "/api/translations/"+tr.ID+"/chat/messages/"+first.ID, map[string]any{
+		"message": "What does 喜欢 mean here?",
+	}, sessionCookie)
+	if editRes.Code != http.StatusOK {
+		t.Fatalf("expected edit 200, got %d body=%s", editRes.Code, editRes.Body.String())
+	}
+	var edited translation.ChatMessage
+	decodeBodyJSON(t, editRes, &edited)
+	if edited.ID != first.ID || edited.Content != "What does 喜欢 mean here?" {
+		t.Fatalf("unexpected edited message: %+v", edited)
+	}
+
+	resendRes := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/messages/"+first.ID+"/resend", nil, sessionCookie)
+	if resendRes.Code != http.StatusOK {
+		t.Fatalf("expected resend 200, got %d body=%s", resendRes.Code, resendRes.Body.String())
+	}
+	if !strings.Contains(resendRes.Body.String(), `"type":"complete"`) {
+		t.Fatalf("expected a complete event, body=%s", resendRes.Body.String())
+	}
+
+	listRes = doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/list", nil, sessionCookie)
+	decodeBodyJSON(t, listRes, &list)
+	if len(list.Messages) != 2 {
+		t.Fatalf("expected the thread truncated to the resent turn, got %d messages", len(list.Messages))
+	}
+	if list.Messages[0].ID != first.ID || list.Messages[1].Content != "mock answer: What does 喜欢 mean here?" {
+		t.Fatalf("unexpected thread after resend: %+v", list.Messages)
+	}
+}
+
+func TestChatGrammarToolStoresGrammarNote(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("我吃了饭", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	res := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", map[string]any{
+		"message": "Explain the grammar of 了 here",
+	}, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected chat new 200, got %d", res.Code)
+	}
+	var complete struct {
+		ToolResults []struct {
+			MessageID   string                      `json:"message_id"`
+			GrammarNote translation.ChatGrammarNote `json:"grammar_note"`
+		} `json:"tool_results"`
+	}
+	for _, line := range extractSSEDataLines(res.Body.String()) {
+		if strings.Contains(line, `"type":"complete"`) {
+			if err := json.Unmarshal([]byte(line), &complete); err != nil {
+				t.Fatalf("decode complete event: %v", err)
+			}
+		}
+	}
+	if len(complete.ToolResults) != 1 || complete.ToolResults[0].GrammarNote.Point != "了 marking a completed action" {
+		t.Fatalf("expected one grammar note in complete event, body=%s", res.Body.String())
+	}
+
+	listRes := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/list", nil, sessionCookie)
+	var list struct {
+		Messages []translation.ChatMessage `json:"messages"`
+	}
+	decodeBodyJSON(t, listRes, &list)
+	if len(list.Messages) != 3 {
+		t.Fatalf("expected user, ai and tool messages, got %d", len(list.Messages))
+	}
+	tool := list.Messages[2]
+	if tool.ID != complete.ToolResults[0].MessageID || tool.ReviewCard != nil || tool.GrammarNote == nil {
+		t.Fatalf("expected the tool message to carry only the grammar note, got %+v", tool)
+	}
+	if len(tool.GrammarNote.Examples) != 1 || tool.GrammarNote.Explanation == "" {
+		t.Fatalf("unexpected stored grammar note: %+v", tool.GrammarNote)
+	}
+}
+
+func TestChatExportMarkdownOmitsRejectedCards(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("我喜欢学习", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	for _, message := range []string{"What does 喜欢 mean?", "Make a review card for 学习"} {
+		res := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/new", map[string]any{
+			"message": message,
+		}, sessionCookie)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected chat new 200, got %d", res.Code)
+		}
+	}
+
+	res := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/export", nil, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected export 200, got %d body=%s", res.Code, res.Body.String())
+	}
+	if got := res.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment;") {
+		t.Fatalf("expected a download, got Content-Disposition %q", got)
+	}
+	body := res.Body.String()
+	for _, want := range []string{"# Chat: 我喜欢学习", "**You:**", "mock answer: What does 喜欢 mean?", "> 我们在学习中文。"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in export:\n%s", want, body)
+		}
+	}
+
+	reject := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+tr.ID+"/chat/messages/"+toolMessageID(t, router, tr.ID, sessionCookie)+"/reject", nil, sessionCookie)
+	if reject.Code != http.StatusOK {
+		t.Fatalf("expected reject 200, got %d", reject.Code)
+	}
+	res = doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID+"/chat/export", nil, sessionCookie)
+	if strings.Contains(res.Body.String(), "Review card") {
+		t.Fatalf("expected rejected card omitted, got:\n%s", res.Body.String())
+	}
+}
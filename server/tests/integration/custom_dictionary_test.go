@@ -0,0 +1,83 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCustomDictionaryRoundTripAndGlossesSegments(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	entryPath := "/api/dictionary/custom/" + url.PathEscape("学习")
+	if res := doJSONRequest(t, router, http.MethodGet, entryPath, nil, sessionCookie); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before an entry exists, got %d", res.Code)
+	}
+	if res := doJSONRequest(t, router, http.MethodPost, "/api/dictionary/custom", map[string]string{"headword": "学习"}, sessionCookie); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a gloss, got %d", res.Code)
+	}
+
+	saved := doJSONRequest(t, router, http.MethodPost, "/api/dictionary/custom", map[string]string{
+		"headword": " 学习 ",
+		"pinyin":   "xue2 xi2",
+		"english":  "to study (think: 'learn + practise')",
+	}, sessionCookie)
+	if saved.Code != http.StatusOK {
+		t.Fatalf("expected 200 saving an entry, got %d: %s", saved.Code, saved.Body.String())
+	}
+	var entry struct {
+		Headword string `json:"headword"`
+		Pinyin   string `json:"pinyin"`
+		English  string `json:"english"`
+	}
+	decodeBodyJSON(t, saved, &entry)
+	if entry.Headword != "学习" || entry.Pinyin != "xué xí" {
+		t.Fatalf("unexpected saved entry: %+v", entry)
+	}
+
+	res := doJSONRequest(t, router, http.MethodPost, "/api/translations/sentence-segments/translate", map[string]any{
+		"segments": []string{"我", "学习"},
+	}, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 translating segments, got %d: %s", res.Code, res.Body.String())
+	}
+	var translated struct {
+		Translations []struct {
+			Segment string `json:"segment"`
+			Pinyin  string `json:"pinyin"`
+			English string `json:"english"`
+			Source  string `json:"source"`
+		} `json:"translations"`
+	}
+	decodeBodyJSON(t, res, &translated)
+	if len(translated.Translations) != 2 {
+		t.Fatalf("expected 2 segments, got %+v", translated.Translations)
+	}
+	if got := translated.Translations[1]; got.English != entry.English || got.Pinyin != "xué xí" || got.Source != "user" {
+		t.Fatalf("expected the custom gloss, got %+v", got)
+	}
+	if got := translated.Translations[0]; got.Source == "user" {
+		t.Fatalf("expected other segments untouched, got %+v", got)
+	}
+
+	list := doJSONRequest(t, router, http.MethodGet, "/api/dictionary/custom", nil, sessionCookie)
+	var listed struct {
+		Entries []struct {
+			Headword string `json:"headword"`
+		} `json:"entries"`
+	}
+	decodeBodyJSON(t, list, &listed)
+	if len(listed.Entries) != 1 || listed.Entries[0].Headword != "学习" {
+		t.Fatalf("unexpected entries: %+v", listed.Entries)
+	}
+
+	if res := doJSONRequest(t, router, http.MethodDelete, entryPath, nil, sessionCookie); res.Code != http.StatusOK {
+		t.Fatalf("expected 200 deleting the entry, got %d", res.Code)
+	}
+	if res := doJSONRequest(t, router, http.MethodDelete, entryPath, nil, sessionCookie); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting it again, got %d", res.Code)
+	}
+}
@@ -21,7 +21,7 @@ func TestAuthAndSessionFlow(t *testing.T) {
 	if rec.Code != http.StatusUnauthorized {
 		t.Fatalf("expected unauthenticated status 401, got %d", rec.Code)
 	}
-	if strings.TrimSpace(rec.Body.String()) != `{"detail":"Not authenticated"}` {
+	if strings.TrimSpace(rec.Body.String()) != `{"code":"unauthorized","detail":"Not authenticated"}` {
 		t.Fatalf("unexpected unauthenticated body: %q", rec.Body.String())
 	}
 
@@ -0,0 +1,63 @@
+package integration_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFavoriteTranslationsFilterList(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	pinned, err := store.Create("你好。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if _, err := store.Create("再见。", "text"); err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+
+	favoritePath := "/api/translations/" + pinned.ID + "/favorite"
+	res := doJSONRequest(t, router, http.MethodPost, favoritePath, nil, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 favoriting, got %d: %s", res.Code, res.Body.String())
+	}
+	var fav struct {
+		IsFavorite bool `json:"is_favorite"`
+	}
+	decodeBodyJSON(t, res, &fav)
+	if !fav.IsFavorite {
+		t.Fatal("expected an empty body to favorite the translation")
+	}
+
+	type listBody struct {
+		Translations []struct {
+			ID         string `json:"id"`
+			IsFavorite bool   `json:"is_favorite"`
+		} `json:"translations"`
+		Total int `json:"total"`
+	}
+	var list listBody
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations?favorite=true", nil, sessionCookie), &list)
+	if list.Total != 1 || len(list.Translations) != 1 || list.Translations[0].ID != pinned.ID || !list.Translations[0].IsFavorite {
+		t.Fatalf("expected only the favorite, got %+v", list)
+	}
+
+	var composed listBody
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations?favorite=true&status=completed", nil, sessionCookie), &composed)
+	if composed.Total != 0 {
+		t.Fatalf("expected status filter to apply to favorites, got %+v", composed)
+	}
+
+	res = doJSONRequest(t, router, http.MethodPost, favoritePath, map[string]bool{"favorite": false}, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 unfavoriting, got %d", res.Code)
+	}
+	var all listBody
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations?favorite=true", nil, sessionCookie), &all)
+	if all.Total != 0 {
+		t.Fatalf("expected no favorites after unfavoriting, got %+v", all)
+	}
+}
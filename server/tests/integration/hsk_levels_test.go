@@ -0,0 +1,55 @@
+package integration_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/anath2/language-app/internal/http/handlers"
+	"github.com/anath2/language-app/internal/translation"
+)
+
+func TestTranslationDetailAnnotatesHSKLevels(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+	handlers.ConfigureHSKLevels(map[string]int{"书": 1})
+	t.Cleanup(func() { handlers.ConfigureHSKLevels(nil) })
+
+	tr, err := store.Create("三本书。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 2, []translation.SentenceInit{{Text: "三本书。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	for _, seg := range []translation.SegmentResult{{Segment: "三本", English: "three (volumes)"}, {Segment: "书", English: "book"}} {
+		if _, _, err := store.AddProgressSegment(tr.ID, seg, 0); err != nil {
+			t.Fatalf("add segment: %v", err)
+		}
+	}
+
+	detail := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID, nil, sessionCookie)
+	if detail.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", detail.Code, detail.Body.String())
+	}
+	var body struct {
+		Sentences []struct {
+			Translations []struct {
+				Segment  string `json:"segment"`
+				HSKLevel *int   `json:"hsk_level"`
+			} `json:"translations"`
+		} `json:"sentences"`
+	}
+	decodeBodyJSON(t, detail, &body)
+	if len(body.Sentences) != 1 || len(body.Sentences[0].Translations) != 2 {
+		t.Fatalf("unexpected sentences: %+v", body.Sentences)
+	}
+	segs := body.Sentences[0].Translations
+	if segs[0].HSKLevel != nil {
+		t.Fatalf("expected no level for an unlisted word, got %d", *segs[0].HSKLevel)
+	}
+	if segs[1].HSKLevel == nil || *segs[1].HSKLevel != 1 {
+		t.Fatalf("expected HSK 1 for 书, got %v", segs[1].HSKLevel)
+	}
+}
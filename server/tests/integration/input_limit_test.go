@@ -0,0 +1,104 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLongInputIsRejectedOrChunked(t *testing.T) {
+	cfg := newLocalConfig(t)
+	cfg.MaxInputChars = 8
+	router := newRouterWithConfig(cfg)
+	overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	text := "第一句话。第二句话。第三句话。"
+
+	rejected := doJSONRequest(t, router, http.MethodPost, "/api/translations", map[string]any{"input_text": text}, sessionCookie)
+	if rejected.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rejected.Code, rejected.Body.String())
+	}
+	if !strings.Contains(rejected.Body.String(), `\"chunk\": true`) {
+		t.Fatalf("expected the error to point at chunk, got %s", rejected.Body.String())
+	}
+
+	res := doJSONRequest(t, router, http.MethodPost, "/api/translations", map[string]any{"input_text": text, "chunk": true}, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var created struct {
+		TranslationID string   `json:"translation_id"`
+		ChunkGroupID  string   `json:"chunk_group_id"`
+		Parts         []string `json:"parts"`
+	}
+	decodeBodyJSON(t, res, &created)
+	if created.ChunkGroupID == "" || len(created.Parts) != 3 || created.Parts[0] != created.TranslationID {
+		t.Fatalf("expected three linked parts, got %+v", created)
+	}
+
+	detail := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+created.Parts[1], nil, sessionCookie)
+	var item struct {
+		InputText string `json:"input_text"`
+		Chunk     *struct {
+			GroupID string `json:"group_id"`
+			Index   int    `json:"index"`
+			Count   int    `json:"count"`
+		} `json:"chunk"`
+	}
+	decodeBodyJSON(t, detail, &item)
+	if item.InputText != "第二句话。" || item.Chunk == nil || item.Chunk.Index != 2 || item.Chunk.Count != 3 || item.Chunk.GroupID != created.ChunkGroupID {
+		t.Fatalf("expected part 2 of 3, got %+v", item)
+	}
+
+	// The queue works through the parts one after another.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		chunks := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+created.TranslationID+"/chunks", nil, sessionCookie)
+		if chunks.Code != http.StatusOK {
+			t.Fatalf("expected 200 listing chunks, got %d: %s", chunks.Code, chunks.Body.String())
+		}
+		var body struct {
+			GroupID string `json:"group_id"`
+			Parts   []struct {
+				TranslationID string `json:"translation_id"`
+				Index         int    `json:"index"`
+				Status        string `json:"status"`
+			} `json:"parts"`
+		}
+		decodeBodyJSON(t, chunks, &body)
+		if len(body.Parts) != 3 || body.GroupID != created.ChunkGroupID {
+			t.Fatalf("unexpected chunk list: %+v", body)
+		}
+		done := 0
+		for _, part := range body.Parts {
+			if part.Status == "completed" {
+				done++
+			}
+		}
+		if done == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out with parts %+v", body.Parts)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/translations", strings.NewReader(`{"input_text": "`+text+`", "chunk": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", sessionCookie)
+	req.Header.Set("Idempotency-Key", "k1")
+	keyed := httptest.NewRecorder()
+	router.ServeHTTP(keyed, req)
+	if keyed.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for chunk with an idempotency key, got %d", keyed.Code)
+	}
+
+	patched := doJSONRequest(t, router, http.MethodPatch, "/api/translations/"+created.TranslationID, map[string]any{"input_text": text}, sessionCookie)
+	if patched.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 editing in overlong text, got %d", patched.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package integration_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+func TestTranslationNeighborsRespectListFilters(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	db, err := translation.NewDB(cfg.TranslationDBPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Conn.Close()
+
+	// created_at has second precision, so spread the translations out.
+	base := time.Now().UTC().Add(-time.Hour)
+	ids := make([]string, 3)
+	for i := range ids {
+		tr, err := store.Create("你好。", "text")
+		if err != nil {
+			t.Fatalf("create translation: %v", err)
+		}
+		created := base.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)
+		if _, err := db.Conn.Exec(`UPDATE translations SET created_at = ? WHERE id = ?`, created, tr.ID); err != nil {
+			t.Fatalf("backdate translation: %v", err)
+		}
+		ids[i] = tr.ID
+	}
+	if err := store.SetFavorite(ids[0], true); err != nil {
+		t.Fatalf("set favorite: %v", err)
+	}
+	if err := store.SetFavorite(ids[2], true); err != nil {
+		t.Fatalf("set favorite: %v", err)
+	}
+
+	type ref struct {
+		ID string `json:"id"`
+	}
+	type neighbors struct {
+		Previous *ref `json:"previous"`
+		Next     *ref `json:"next"`
+	}
+
+	var all neighbors
+	res := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+ids[1]+"/neighbors", nil, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	decodeBodyJSON(t, res, &all)
+	if all.Previous == nil || all.Previous.ID != ids[0] || all.Next == nil || all.Next.ID != ids[2] {
+		t.Fatalf("expected adjacent translations, got %+v", all)
+	}
+
+	var favorites neighbors
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations/"+ids[0]+"/neighbors?favorite=true", nil, sessionCookie), &favorites)
+	if favorites.Previous != nil || favorites.Next == nil || favorites.Next.ID != ids[2] {
+		t.Fatalf("expected to skip non-favorites and null at the start, got %+v", favorites)
+	}
+
+	if res := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+ids[0]+"/neighbors?status=bogus", nil, sessionCookie); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid status, got %d", res.Code)
+	}
+}
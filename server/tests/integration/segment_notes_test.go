@@ -0,0 +1,74 @@
+package integration_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+func TestSegmentNotesRoundTripAndShowInline(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("三本书。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 2, []translation.SentenceInit{{Text: "三本书。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	for _, seg := range []translation.SegmentResult{{Segment: "三本", English: "three (volumes)"}, {Segment: "书", English: "book"}} {
+		if _, _, err := store.AddProgressSegment(tr.ID, seg, 0); err != nil {
+			t.Fatalf("add segment: %v", err)
+		}
+	}
+	notePath := "/api/translations/" + tr.ID + "/segments/0/0/note"
+
+	if res := doJSONRequest(t, router, http.MethodGet, notePath, nil, sessionCookie); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before a note exists, got %d", res.Code)
+	}
+	if res := doJSONRequest(t, router, http.MethodPost, notePath, map[string]string{"note": "  "}, sessionCookie); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an empty note, got %d", res.Code)
+	}
+	missing := "/api/translations/" + tr.ID + "/segments/0/9/note"
+	if res := doJSONRequest(t, router, http.MethodPost, missing, map[string]string{"note": "x"}, sessionCookie); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing segment, got %d", res.Code)
+	}
+
+	saved := doJSONRequest(t, router, http.MethodPost, notePath, map[string]string{"note": "本 is the measure word for books"}, sessionCookie)
+	if saved.Code != http.StatusOK {
+		t.Fatalf("expected 200 saving a note, got %d: %s", saved.Code, saved.Body.String())
+	}
+	var note struct {
+		Segment string `json:"segment"`
+		Note    string `json:"note"`
+	}
+	decodeBodyJSON(t, saved, &note)
+	if note.Segment != "三本" || note.Note != "本 is the measure word for books" {
+		t.Fatalf("unexpected saved note: %+v", note)
+	}
+
+	detail := doJSONRequest(t, router, http.MethodGet, "/api/translations/"+tr.ID, nil, sessionCookie)
+	var body struct {
+		Sentences []struct {
+			Translations []struct {
+				Segment string `json:"segment"`
+				Note    string `json:"note"`
+			} `json:"translations"`
+		} `json:"sentences"`
+	}
+	decodeBodyJSON(t, detail, &body)
+	if len(body.Sentences) != 1 || body.Sentences[0].Translations[0].Note == "" || body.Sentences[0].Translations[1].Note != "" {
+		t.Fatalf("expected the note inline on the first segment only, got %+v", body.Sentences)
+	}
+
+	if res := doJSONRequest(t, router, http.MethodDelete, notePath, nil, sessionCookie); res.Code != http.StatusOK {
+		t.Fatalf("expected 200 deleting the note, got %d", res.Code)
+	}
+	if res := doJSONRequest(t, router, http.MethodDelete, notePath, nil, sessionCookie); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting it again, got %d", res.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package integration_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/anath2/language-app/internal/translation"
+)
+
+func TestPreviewSegmentsSplitsSentencesWithoutPersisting(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	// A sentence corrected in an earlier translation previews with its
+	// corrected segmentation.
+	tr, err := store.Create("我来了。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	if err := store.SetProcessing(tr.ID, 3, []translation.SentenceInit{{Text: "我来了。"}}); err != nil {
+		t.Fatalf("set processing: %v", err)
+	}
+	if err := store.UpdateTranslationSegments(tr.ID, 0, []translation.SegmentResult{{Segment: "我"}, {Segment: "来了"}, {Segment: "。"}}); err != nil {
+		t.Fatalf("seed segments: %v", err)
+	}
+	if _, err := store.MergeSegments(tr.ID, 0, 1, 2); err != nil {
+		t.Fatalf("merge segments: %v", err)
+	}
+	_, before, err := store.List(50, 0, translation.ListFilter{})
+	if err != nil {
+		t.Fatalf("list translations: %v", err)
+	}
+
+	res := doJSONRequest(t, router, http.MethodPost, "/api/segments/preview", map[string]any{"text": "你好。\n我来了。"}, sessionCookie)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var body struct {
+		Sentences []struct {
+			Text      string   `json:"text"`
+			Separator string   `json:"separator"`
+			Segments  []string `json:"segments"`
+		} `json:"sentences"`
+	}
+	decodeBodyJSON(t, res, &body)
+	if len(body.Sentences) != 2 {
+		t.Fatalf("expected two sentences, got %+v", body.Sentences)
+	}
+	if body.Sentences[0].Text != "你好。" || body.Sentences[0].Separator != "\n" || len(body.Sentences[0].Segments) != 1 {
+		t.Fatalf("unexpected first sentence: %+v", body.Sentences[0])
+	}
+	if got := body.Sentences[1].Segments; len(got) != 2 || got[0] != "我" || got[1] != "来了。" {
+		t.Fatalf("expected the corrected segmentation, got %v", got)
+	}
+
+	_, after, err := store.List(50, 0, translation.ListFilter{})
+	if err != nil {
+		t.Fatalf("list translations: %v", err)
+	}
+	if after != before {
+		t.Fatalf("expected preview not to create a translation, had %d now %d", before, after)
+	}
+
+	empty := doJSONRequest(t, router, http.MethodPost, "/api/segments/preview", map[string]any{"text": "  "}, sessionCookie)
+	if empty.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty text, got %d", empty.Code)
+	}
+}
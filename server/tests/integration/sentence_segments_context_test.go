@@ -20,11 +20,11 @@ type captureSentenceContextProvider struct {
 	lastFullText string
 }
 
-func (p *captureSentenceContextProvider) Segment(_ context.Context, text string) ([]string, error) {
+func (p *captureSentenceContextProvider) Segment(_ context.Context, text string, _ translation.LanguagePair) ([]string, error) {
 	return []string{text}, nil
 }
 
-func (p *captureSentenceContextProvider) TranslateSentenceSegments(_ context.Context, segments []string, sentence string, fullText string) ([]translation.SegmentResult, error) {
+func (p *captureSentenceContextProvider) TranslateSentenceSegments(_ context.Context, segments []string, sentence string, fullText string, _ translation.LanguagePair) ([]translation.SegmentResult, error) {
 	p.lastSegments = append([]string(nil), segments...)
 	p.lastSentence = sentence
 	p.lastFullText = fullText
@@ -40,10 +40,18 @@ func (p *captureSentenceContextProvider) TranslateSentenceSegments(_ context.Con
 	return out, nil
 }
 
-func (p *captureSentenceContextProvider) TranslateFull(_ context.Context, text string) (string, error) {
+func (p *captureSentenceContextProvider) TranslateFull(_ context.Context, text string, _ translation.LanguagePair) (string, error) {
 	return "mock full: " + text, nil
 }
 
+func (p *captureSentenceContextProvider) TranslateFullStream(ctx context.Context, text string, lang translation.LanguagePair, onChunk func(string) error) (string, error) {
+	full, _ := p.TranslateFull(ctx, text, lang)
+	if err := onChunk(full); err != nil {
+		return "", err
+	}
+	return full, nil
+}
+
 func overrideDepsWithTranslationProvider(t *testing.T, cfg config.Config, transProv intelligence.TranslationProvider) {
 	t.Helper()
 
@@ -56,7 +64,7 @@ func overrideDepsWithTranslationProvider(t *testing.T, cfg config.Config, transP
 	srsStore := translation.NewSRSStore(db)
 	profileStore := translation.NewProfileStore(db)
 	manager := queue.NewManager(translationStore, transProv)
-	handlers.ConfigureDependencies(translationStore, chatStore, srsStore, profileStore, manager, transProv, mockChatProvider{})
+	handlers.ConfigureDependencies(translationStore, chatStore, srsStore, profileStore, manager, transProv, mockChatProvider{}, nil)
 }
 
 func TestTranslateSentenceSegmentsUsesDistinctSentenceAndFullText(t *testing.T) {
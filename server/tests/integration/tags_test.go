@@ -0,0 +1,71 @@
+package integration_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTranslationTagsFilterList(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	news, err := store.Create("新闻。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	poem, err := store.Create("静夜思。", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+
+	tagsPath := "/api/translations/" + news.ID + "/tags"
+	for _, tag := range []string{"News", "work"} {
+		if res := doJSONRequest(t, router, http.MethodPost, tagsPath, map[string]string{"tag": tag}, sessionCookie); res.Code != http.StatusOK {
+			t.Fatalf("expected 200 adding %q, got %d: %s", tag, res.Code, res.Body.String())
+		}
+	}
+	res := doJSONRequest(t, router, http.MethodPost, "/api/translations/"+poem.ID+"/tags", map[string]string{"tag": "work"}, sessionCookie)
+	var added struct {
+		Tags []string `json:"tags"`
+	}
+	decodeBodyJSON(t, res, &added)
+	if !reflect.DeepEqual(added.Tags, []string{"work"}) {
+		t.Fatalf("expected tags in the response, got %v", added.Tags)
+	}
+	if res := doJSONRequest(t, router, http.MethodPost, tagsPath, map[string]string{"tag": ""}, sessionCookie); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an empty tag, got %d", res.Code)
+	}
+
+	type listBody struct {
+		Translations []struct {
+			ID   string   `json:"id"`
+			Tags []string `json:"tags"`
+		} `json:"translations"`
+		Total int `json:"total"`
+	}
+	var both listBody
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations?tag=work&tag=news", nil, sessionCookie), &both)
+	if both.Total != 1 || both.Translations[0].ID != news.ID || !reflect.DeepEqual(both.Translations[0].Tags, []string{"news", "work"}) {
+		t.Fatalf("expected only the translation with both tags, got %+v", both)
+	}
+	var comma listBody
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations?tag=work,news", nil, sessionCookie), &comma)
+	if comma.Total != 1 {
+		t.Fatalf("expected comma-separated tags to AND, got %+v", comma)
+	}
+	var work listBody
+	decodeBodyJSON(t, doJSONRequest(t, router, http.MethodGet, "/api/translations?tag=work", nil, sessionCookie), &work)
+	if work.Total != 2 {
+		t.Fatalf("expected both translations tagged work, got %+v", work)
+	}
+
+	if res := doJSONRequest(t, router, http.MethodDelete, tagsPath, map[string]string{"tag": "news"}, sessionCookie); res.Code != http.StatusOK {
+		t.Fatalf("expected 200 removing a tag, got %d", res.Code)
+	}
+	if res := doJSONRequest(t, router, http.MethodDelete, tagsPath, map[string]string{"tag": "news"}, sessionCookie); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 removing a missing tag, got %d", res.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetTranslationETagForFinishedTranslations(t *testing.T) {
+	cfg := newLocalConfig(t)
+	router := newRouterWithConfig(cfg)
+	store := overrideDepsWithMockProvider(t, cfg)
+	sessionCookie := loginSessionCookie(t, router, cfg.AppPassword)
+
+	tr, err := store.Create("你好世界", "text")
+	if err != nil {
+		t.Fatalf("create translation: %v", err)
+	}
+	getWithETag := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/translations/"+tr.ID, nil)
+		req.Header.Set("Cookie", sessionCookie)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	pending := getWithETag("")
+	if pending.Code != http.StatusOK || pending.Header().Get("ETag") != "" || pending.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("expected pending translations uncached, got %d etag=%q cache=%q", pending.Code, pending.Header().Get("ETag"), pending.Header().Get("Cache-Control"))
+	}
+
+	if err := store.SetFullTranslation(tr.ID, "Hello world"); err != nil {
+		t.Fatalf("set full translation: %v", err)
+	}
+	if err := store.Complete(tr.ID); err != nil {
+		t.Fatalf("complete translation: %v", err)
+	}
+	first := getWithETag("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("expected a tagged 200, got %d etag=%q", first.Code, etag)
+	}
+	notModified := getWithETag(etag)
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 || notModified.Header().Get("ETag") != etag {
+		t.Fatalf("expected an empty 304 with the same ETag, got %d body=%q", notModified.Code, notModified.Body.String())
+	}
+
+	if err := store.UpdateTitle(tr.ID, "Greeting"); err != nil {
+		t.Fatalf("update title: %v", err)
+	}
+	changed := getWithETag(etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Fatalf("expected a new ETag after an edit, got %d etag=%q", changed.Code, changed.Header().Get("ETag"))
+	}
+}
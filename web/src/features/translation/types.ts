@@ -26,20 +26,57 @@ export interface TranslationDetailResponse {
   source_type: string;
   title: string;
   input_text: string;
+  raw_input_text: string | null;
   full_translation: string | null;
   error_message: string | null;
   sentences: SentenceMeta[] | null;
+  chunk: ChunkInfo | null;
+}
+
+export interface ChunkInfo {
+  group_id: string;
+  index: number;
+  count: number;
 }
 
 export interface CreateTranslationResponse {
   translation_id: string;
   status: TranslationStatus;
+  chunk_group_id?: string;
+  parts?: string[];
+}
+
+export interface ChunkPart {
+  translation_id: string;
+  index: number;
+  status: TranslationStatus;
+  title: string;
+}
+
+export interface ListChunksResponse {
+  group_id: string;
+  count: number;
+  parts: ChunkPart[];
+}
+
+export interface SegmentPreviewSentence {
+  text: string;
+  indent: string;
+  separator: string;
+  segments: string[];
+}
+
+export interface SegmentPreviewResponse {
+  sentences: SegmentPreviewSentence[];
 }
 
 export interface TranslationResult {
   segment: string;
   pinyin: string;
   english: string;
+  pos?: PartOfSpeech;
+  classifier?: string;
+  hsk_level?: number;
 }
 
 export interface SentenceResult {
@@ -63,6 +100,9 @@ export interface SegmentResult {
   segment: string;
   pinyin: string;
   english: string;
+  pos?: PartOfSpeech;
+  classifier?: string;
+  hsk_level?: number | null;
   index: number;
   sentence_index: number;
   pending: boolean;
@@ -73,10 +113,28 @@ export interface DisplaySentence extends SentenceMeta {
   segments: SegmentResult[];
 }
 
+export type PartOfSpeech =
+  | 'noun'
+  | 'verb'
+  | 'adjective'
+  | 'adverb'
+  | 'pronoun'
+  | 'number'
+  | 'measure_word'
+  | 'preposition'
+  | 'conjunction'
+  | 'particle'
+  | 'interjection'
+  | 'other';
+
 export interface StreamSegmentResult {
   segment: string;
   pinyin: string;
   english: string;
+  source?: 'cedict' | 'llm' | 'fallback' | 'user';
+  pos?: PartOfSpeech;
+  classifier?: string;
+  hsk_level?: number | null;
   index: number;
   sentence_index: number;
 }
@@ -96,6 +154,20 @@ export type StreamProgressEvent = {
   result: StreamSegmentResult;
 };
 
+// Sent in place of progress events when the client fell behind.
+export type StreamCatchUpEvent = {
+  type: 'catch_up';
+  current: number;
+  total: number;
+  results: StreamSegmentResult[];
+};
+
+export type StreamFullDeltaEvent = {
+  type: 'full_delta';
+  delta: string;
+  fullTranslation: string;
+};
+
 export type StreamCompleteEvent = {
   type: 'complete';
   sentences?: SentenceResult[];
@@ -110,6 +182,8 @@ export type StreamErrorEvent = {
 export type StreamEvent =
   | StreamStartEvent
   | StreamProgressEvent
+  | StreamCatchUpEvent
+  | StreamFullDeltaEvent
   | StreamCompleteEvent
   | StreamErrorEvent;
 
@@ -119,6 +193,8 @@ export interface ReviewCard {
   pinyin: string;
   english: string;
   snippets: string[];
+  examples?: string[];
+  hsk_level?: number | null;
 }
 
 export interface ReviewQueueResponse {
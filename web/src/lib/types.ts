@@ -2,6 +2,7 @@ export interface UserProfile {
   name: string;
   email: string;
   language: string;
+  timezone: string;
   created_at: string;
   updated_at: string;
 }
@@ -19,3 +20,21 @@ export interface ImportProgressResponse {
   success: boolean;
   counts: Record<string, number>;
 }
+
+export interface VocabImportResponse {
+  imported: number;
+  skipped: number;
+  errors: { line: number; message: string }[];
+}
+
+export interface CustomDictionaryEntry {
+  headword: string;
+  pinyin: string;
+  english: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CustomDictionaryListResponse {
+  entries: CustomDictionaryEntry[];
+}